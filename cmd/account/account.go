@@ -0,0 +1,68 @@
+package accountCmd
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/account"
+	"github.com/spf13/cobra"
+)
+
+// AccountCmd groups commands that manage the signed-in account
+func AccountCmd(globals *cmdutil.Globals) *cobra.Command {
+	accountCmd := &cobra.Command{
+		Use:   "account",
+		Short: "Manage your account, password, and sessions",
+	}
+
+	accountCmd.AddCommand(InfoCmd(globals))
+	accountCmd.AddCommand(ChangePasswordCmd(globals))
+	accountCmd.AddCommand(SessionsCmd(globals))
+
+	return accountCmd
+}
+
+// InfoCmd shows the account the profile is signed in as
+func InfoCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	infoCmd := &cobra.Command{
+		Use:   "info",
+		Short: "Show the account the profile is signed in as",
+		Long: `Show the username, email, and user ID of the signed-in account, along with
+the profile's tenant and when its access token expires.
+
+Example:
+  cozyctl account info
+  cozyctl account info -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return account.Info(account.InfoOptions{Profile: globals.ProfileRef(), Transport: globals.Transport(), Output: globals.OutputFormat()})
+		},
+	}
+
+	return infoCmd
+}
+
+// ChangePasswordCmd changes the account password
+func ChangePasswordCmd(globals *cmdutil.Globals) *cobra.Command {
+	changePasswordCmd := &cobra.Command{
+		Use:   "change-password",
+		Short: "Change your account password",
+		Long: `Change the password of the signed-in account. You are prompted for the
+current password and the new one (twice); input is hidden on a terminal.
+
+Changing the password signs out every other session of the account. The
+profile used for the change stays signed in.
+
+Example:
+  cozyctl account change-password`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return account.ChangePassword(globals.ProfileRef(), globals.Transport())
+		},
+	}
+
+	return changePasswordCmd
+}
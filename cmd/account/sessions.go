@@ -0,0 +1,80 @@
+package accountCmd
+
+import (
+	"fmt"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/account"
+	"github.com/spf13/cobra"
+)
+
+// SessionsCmd groups commands that manage signed-in sessions
+func SessionsCmd(globals *cmdutil.Globals) *cobra.Command {
+	sessionsCmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "List and revoke signed-in sessions",
+	}
+
+	sessionsCmd.AddCommand(SessionsListCmd(globals))
+	sessionsCmd.AddCommand(SessionsRevokeCmd(globals))
+
+	return sessionsCmd
+}
+
+// SessionsListCmd lists the account's signed-in sessions
+func SessionsListCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List signed-in sessions",
+		Long: `List the sessions signed in to your account. The session used by this
+profile is marked with an asterisk (*).
+
+Example:
+  cozyctl account sessions list`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return account.ListSessions(account.ListSessionsOptions{Profile: globals.ProfileRef(), Transport: globals.Transport(), Output: globals.OutputFormat()})
+		},
+	}
+
+	return listCmd
+}
+
+// SessionsRevokeCmd signs out sessions
+func SessionsRevokeCmd(globals *cmdutil.Globals) *cobra.Command {
+	var allOthers bool
+	var yes bool
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke [session-id...]",
+		Short: "Sign out sessions",
+		Long: `Sign out one or more sessions by ID, or every session except this
+profile's with --all-others.
+
+Example:
+  cozyctl account sessions revoke session-0042
+  cozyctl account sessions revoke --all-others --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			if allOthers == (len(args) > 0) {
+				return fmt.Errorf("pass session IDs or --all-others")
+			}
+			return account.RevokeSessions(account.RevokeOptions{
+				Profile:    globals.ProfileRef(),
+				Transport:  globals.Transport(),
+				SessionIDs: args,
+				AllOthers:  allOthers,
+				Yes:        yes,
+			})
+		},
+	}
+
+	revokeCmd.Flags().BoolVar(&allOthers, "all-others", false, "Revoke every session except this profile's")
+	revokeCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation for --all-others")
+
+	return revokeCmd
+}
@@ -0,0 +1,111 @@
+package activity
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// ActivityCmd shows the command history of a profile
+func ActivityCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		limit   int
+		command string
+		asJSON  bool
+	)
+
+	activityCmd := &cobra.Command{
+		Use:   "activity",
+		Short: "Show the builds, deploys, and updates run from this machine",
+		Long: `Show the history of mutating commands (build, deploy, update, ...) run
+against the current profile from this machine, newest first, with their
+outcome and the IDs they produced.
+
+The history is kept in ~/.cozy/<name>/<profile>/history.jsonl.
+
+Example:
+  cozyctl activity
+  cozyctl activity --command deploy --limit 5
+  cozyctl --profile prod activity --json`,
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := history.Read(globals.ProfileRef())
+			if err != nil {
+				return err
+			}
+
+			if command != "" {
+				entries = slices.DeleteFunc(entries, func(e history.Entry) bool {
+					return e.Command != command
+				})
+			}
+			slices.Reverse(entries)
+			if limit > 0 && len(entries) > limit {
+				entries = entries[:limit]
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				for _, e := range entries {
+					if err := enc.Encode(e); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No activity recorded for this profile.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tCOMMAND\tSTATUS\tDURATION\tIDS")
+			for _, e := range entries {
+				status := e.Status
+				if e.Error != "" {
+					status = fmt.Sprintf("%s: %s", e.Status, e.Error)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					e.Time.Local().Format("2006-01-02 15:04:05"),
+					e.Command,
+					status,
+					ui.FormatDuration(time.Duration(e.DurationMS)*time.Millisecond),
+					formatIDs(e.IDs),
+				)
+			}
+			return w.Flush()
+		},
+	}
+
+	activityCmd.Flags().IntVar(&limit, "limit", 20, "maximum number of entries to show (0 for all)")
+	activityCmd.Flags().StringVar(&command, "command", "", "only show entries for this command (e.g. deploy)")
+	activityCmd.Flags().BoolVar(&asJSON, "json", false, "print entries as JSON lines")
+
+	return activityCmd
+}
+
+// formatIDs renders result IDs as sorted key=value pairs.
+func formatIDs(ids map[string]string) string {
+	keys := make([]string, 0, len(ids))
+	for k := range ids {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + ids[k]
+	}
+	return strings.Join(pairs, " ")
+}
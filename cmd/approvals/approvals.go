@@ -0,0 +1,150 @@
+package approvals
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/approvals"
+	"github.com/spf13/cobra"
+)
+
+// ApprovalsCmd groups commands that review deploys waiting for approval
+func ApprovalsCmd(globals *cmdutil.Globals) *cobra.Command {
+	approvalsCmd := &cobra.Command{
+		Use:   "approvals",
+		Short: "Review deploys waiting for approval",
+		Long: `Deployments can be set to require approval, typically in production. A
+deploy to one is held by cozy-hub as a pending change, and the build goes
+live only once an authorized user approves it. 'cozyctl deploy' prints the
+approval ID; add --wait-for-approval to wait for the decision.
+
+Approving or rejecting needs an account or API key with the manage scope.`,
+	}
+
+	approvalsCmd.AddCommand(ListCmd(globals))
+	approvalsCmd.AddCommand(GetCmd(globals))
+	approvalsCmd.AddCommand(ApproveCmd(globals))
+	approvalsCmd.AddCommand(RejectCmd(globals))
+
+	return approvalsCmd
+}
+
+// ListCmd lists deploys submitted for approval
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+	var status string
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List deploys submitted for approval",
+		Long: `List deploys submitted for approval, newest first, with the deployment and
+build of each and who submitted it. --status shows only pending, approved,
+or rejected ones.
+
+Example:
+  cozyctl approvals list --status pending
+  cozyctl approvals list -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return approvals.List(approvals.ListOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Status:    status,
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	listCmd.Flags().StringVar(&status, "status", "", "Only list approvals with this status: pending, approved, or rejected")
+
+	return listCmd
+}
+
+// GetCmd shows one deploy submitted for approval
+func GetCmd(globals *cmdutil.Globals) *cobra.Command {
+	getCmd := &cobra.Command{
+		Use:   "get <approval-id>",
+		Short: "Show a deploy submitted for approval",
+		Long: `Show a deploy submitted for approval: its deployment and build, its status,
+where to review it, and who decided it and why.
+
+Example:
+  cozyctl approvals get approval-0042`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return approvals.Get(approvals.GetOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				ID:        args[0],
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	return getCmd
+}
+
+// ApproveCmd approves a pending deploy
+func ApproveCmd(globals *cmdutil.Globals) *cobra.Command {
+	var comment string
+
+	approveCmd := &cobra.Command{
+		Use:   "approve <approval-id>",
+		Short: "Approve a pending deploy, activating its build",
+		Long: `Approve a pending deploy. Cozy-hub then activates its build on the
+deployment, as the deploy would have without approval. A 'cozyctl deploy
+--wait-for-approval' waiting for it continues with its smoke test and
+rollout watch.
+
+Example:
+  cozyctl approvals approve approval-0042
+  cozyctl approvals approve approval-0042 --comment "reviewed in CHG-311"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return approvals.Approve(approvals.DecideOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				ID:        args[0],
+				Comment:   comment,
+			})
+		},
+	}
+
+	approveCmd.Flags().StringVar(&comment, "comment", "", "Note recorded with the approval")
+
+	return approveCmd
+}
+
+// RejectCmd rejects a pending deploy
+func RejectCmd(globals *cmdutil.Globals) *cobra.Command {
+	var comment string
+
+	rejectCmd := &cobra.Command{
+		Use:   "reject <approval-id> --comment <why>",
+		Short: "Reject a pending deploy",
+		Long: `Reject a pending deploy. The deployment keeps its current build, and a
+'cozyctl deploy --wait-for-approval' waiting for it fails with the comment.
+
+Example:
+  cozyctl approvals reject approval-0042 --comment "wait for the load test"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return approvals.Reject(approvals.DecideOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				ID:        args[0],
+				Comment:   comment,
+			})
+		},
+	}
+
+	rejectCmd.Flags().StringVar(&comment, "comment", "", "Why the deploy is rejected (required)")
+	rejectCmd.MarkFlagRequired("comment")
+
+	return rejectCmd
+}
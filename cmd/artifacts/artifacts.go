@@ -0,0 +1,78 @@
+package artifacts
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/artifacts"
+	"github.com/spf13/cobra"
+)
+
+// ArtifactsCmd groups commands that work with invocation output artifacts
+func ArtifactsCmd(globals *cmdutil.Globals) *cobra.Command {
+	artifactsCmd := &cobra.Command{
+		Use:   "artifacts",
+		Short: "List and download the files invocations produce",
+		Long: `Invocations that generate images, videos, or other files store them in the
+file store and return references to them. These commands list and download
+an invocation's artifacts by invocation ID, so results of async jobs can be
+pulled without going to the underlying storage.`,
+	}
+
+	artifactsCmd.AddCommand(ListCmd(globals))
+	artifactsCmd.AddCommand(DownloadCmd(globals))
+
+	return artifactsCmd
+}
+
+// ListCmd lists the artifacts of an invocation
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts artifacts.ListOptions
+
+	listCmd := &cobra.Command{
+		Use:   "list <invocation-id>",
+		Short: "List the artifacts an invocation produced",
+		Long: `List the artifacts an invocation produced, with their type and size.
+
+Example:
+  cozyctl artifacts list inv-0042
+  cozyctl artifacts list inv-0042 -o wide`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.InvocationID = args[0]
+			opts.Output = globals.OutputFormat()
+			return artifacts.List(opts)
+		},
+	}
+
+	return listCmd
+}
+
+// DownloadCmd downloads the artifacts of an invocation
+func DownloadCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts artifacts.DownloadOptions
+
+	downloadCmd := &cobra.Command{
+		Use:   "download <invocation-id> [name...]",
+		Short: "Download the artifacts an invocation produced",
+		Long: `Download an invocation's artifacts, or only those named, into a directory
+named after the invocation (or --out).
+
+Example:
+  cozyctl artifacts download inv-0042
+  cozyctl artifacts download inv-0042 image-0.png --out ./results/`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.InvocationID = args[0]
+			opts.Names = args[1:]
+			return artifacts.Download(opts)
+		},
+	}
+
+	downloadCmd.Flags().StringVar(&opts.OutDir, "out", "", "Directory to download into (default <invocation-id>)")
+
+	return downloadCmd
+}
@@ -0,0 +1,20 @@
+package authCmd
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// AuthCmd groups commands that manage stored credentials
+func AuthCmd(globals *cmdutil.Globals) *cobra.Command {
+	authCmd := &cobra.Command{
+		Use:         "auth",
+		Short:       "Manage stored credentials and check permissions",
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+	}
+
+	authCmd.AddCommand(RefreshCmd(globals))
+	authCmd.AddCommand(CanICmd(globals))
+
+	return authCmd
+}
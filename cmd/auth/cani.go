@@ -0,0 +1,66 @@
+package authCmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/account"
+	"github.com/spf13/cobra"
+)
+
+// CanICmd checks whether the current token has a permission
+func CanICmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		opts account.CanIOptions
+	)
+
+	canICmd := &cobra.Command{
+		Use:   "can-i <action>",
+		Short: "Check whether you are allowed to do something",
+		Long: `Ask cozy-hub whether the current token may perform an action, on one
+deployment (--deployment) or all of them, and why. Use it to debug 403
+errors: the answer names the role or API key scope that decides it.
+
+The action is a scope operation (read, deploy, invoke, manage) or a
+command such as build, update, rollback, delete, or transfer. With --list,
+every operation is checked.
+
+Prints "yes" or "no" and exits with status 1 when the answer is no, so it
+can guard scripts.
+
+Example:
+  cozyctl auth can-i deploy --deployment my-model
+  cozyctl auth can-i delete --deployment my-model
+  cozyctl auth can-i --list --deployment my-model`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case opts.All && len(args) > 0:
+				return fmt.Errorf("--list cannot be combined with an action")
+			case !opts.All && len(args) == 0:
+				return fmt.Errorf("an action is required (or --list)")
+			case len(args) > 0:
+				opts.Action = args[0]
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.Output = globals.OutputFormat()
+
+			err := account.CanI(opts)
+			// The answer was printed; only pass on the status
+			var denied *account.DeniedError
+			if errors.As(err, &denied) {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+
+	canICmd.Flags().StringVar(&opts.Deployment, "deployment", "", "Deployment to check (default: all deployments)")
+	canICmd.Flags().BoolVar(&opts.All, "list", false, "Check every operation")
+
+	return canICmd
+}
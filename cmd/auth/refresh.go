@@ -0,0 +1,31 @@
+package authCmd
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/login"
+	"github.com/spf13/cobra"
+)
+
+// RefreshCmd exchanges the stored refresh token for a new access token
+func RefreshCmd(globals *cmdutil.Globals) *cobra.Command {
+	refreshCmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Get a new access token using the stored refresh token",
+		Long: `Exchange the profile's refresh token for a new access token, save it to the
+profile, and print when it expires.
+
+Useful in long-lived shells, or to warm credentials before a batch of
+scripted calls. Only email/password logins store a refresh token; API keys
+do not expire.
+
+Example:
+  cozyctl auth refresh
+  cozyctl auth refresh --name work --profile prod`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return login.RunRefresh(globals.ProfileRef(), globals.Transport())
+		},
+	}
+
+	return refreshCmd
+}
@@ -0,0 +1,58 @@
+package bench
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/bench"
+	"github.com/cozy-creator/cozyctl/internal/invoke"
+	"github.com/spf13/cobra"
+)
+
+// BenchCmd load-tests a deployment function
+func BenchCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		opts bench.Options
+		data string
+		file string
+	)
+
+	benchCmd := &cobra.Command{
+		Use:   "bench <deployment-id> <function>",
+		Short: "Load-test a deployment function",
+		Long: `Send a number of invocations of a function with a fixed payload, a set
+number at a time, and report the latency distribution of successful
+invocations, throughput, error rate by status code, and how many
+invocations had to wait for a worker to start (cold starts).
+
+Run it at the concurrency you expect in production to size a deployment's
+minimum and maximum workers.
+
+Example:
+  cozyctl bench my-model generate -d '{"prompt": "a cat"}'
+  cozyctl bench my-model generate -f payload.json --requests 500 --concurrency 25
+  cozyctl bench my-model generate -n 50 -c 5 -o json | jq .latency.p95_ms`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			var err error
+			if opts.Payload, err = invoke.ReadPayload(data, file, cmd.InOrStdin()); err != nil {
+				return err
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.DeploymentID = args[0]
+			opts.Function = args[1]
+			opts.Output = globals.OutputFormat()
+			return bench.Run(opts)
+		},
+	}
+
+	benchCmd.Flags().StringVarP(&data, "data", "d", "", "JSON payload")
+	benchCmd.Flags().StringVarP(&file, "file", "f", "", "Read the JSON payload from a file (- for stdin)")
+	benchCmd.Flags().IntVarP(&opts.Requests, "requests", "n", bench.DefaultRequests, "Number of invocations to send")
+	benchCmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "c", bench.DefaultConcurrency, "Number of invocations in flight at once")
+
+	return benchCmd
+}
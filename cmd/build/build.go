@@ -3,16 +3,22 @@ package build
 import (
 	"fmt"
 
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/api"
 	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/ui"
 	"github.com/spf13/cobra"
 )
 
-var (
-	BuildProjectDirectory string
-	BuildProjectLocally   bool
-)
+func BuildCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		projectDirectory string
+		local            bool
+		progress         string
+		priority         string
+		machine          string
+	)
 
-func BuildCmd() *cobra.Command {
 	buildCmd := &cobra.Command{
 		Use:   "build",
 		Short: "Build a project",
@@ -21,22 +27,58 @@ func BuildCmd() *cobra.Command {
 By default, uploads the project to cozy-hub for server-side building.
 Use --local to build locally with Docker instead.
 
+Server builds wait in the builder's queue when it is busy; the queue position
+and estimated start are shown while waiting. Queued builds start in priority
+order, so --priority high lets an urgent hotfix jump ahead of bulk CI builds
+(and --priority low keeps those out of the way).
+
+--build-machine picks the builder machine type: small (the default), large
+for projects whose pip or torch compilation steps need more CPU and memory,
+or gpu. It overrides build-machine in the project's [tool.cozy] config.
+
 Examples:
   cozyctl build --dir ./my-project
-  cozyctl build --local --dir ./my-project`,
+  cozyctl build --local --dir ./my-project
+  cozyctl build --dir ./my-project --priority high
+  cozyctl build --dir ./my-project --build-machine large
+  cozyctl build --dir ./my-project --progress json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if BuildProjectDirectory == "" {
+			if projectDirectory == "" {
 				return fmt.Errorf("please specify a project path with --dir/-d")
 			}
-			if BuildProjectLocally {
-				return build.BuildProjectLocally(BuildProjectDirectory)
+			progressMode, err := ui.ParseMode(progress)
+			if err != nil {
+				return err
+			}
+			buildPriority, err := api.ParseBuildPriority(priority)
+			if err != nil {
+				return err
+			}
+			buildMachine, err := api.ParseBuildMachine(machine)
+			if err != nil {
+				return err
+			}
+			if local {
+				if buildPriority != "" || buildMachine != "" {
+					return fmt.Errorf("--priority and --build-machine apply to server builds, not --local")
+				}
+				return build.BuildProjectLocally(cmd.Context(), projectDirectory, progressMode)
+			}
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
 			}
-			return build.BuildProjectOnServer(BuildProjectDirectory)
+			return build.BuildProjectOnServer(cmd.Context(), projectDirectory, globals.ProfileRef(), globals.Transport(), progressMode, api.BuildOptions{
+				Priority: buildPriority,
+				Machine:  buildMachine,
+			})
 		},
 	}
 
-	buildCmd.Flags().BoolVarP(&BuildProjectLocally, "local", "l", false, "Pass this if you want to build your project locally.")
-	buildCmd.Flags().StringVarP(&BuildProjectDirectory, "dir", "d", "", "Pass in the project that you want to build.")
+	buildCmd.Flags().BoolVarP(&local, "local", "l", false, "Pass this if you want to build your project locally.")
+	buildCmd.Flags().StringVarP(&projectDirectory, "dir", "d", "", "Pass in the project that you want to build.")
+	buildCmd.Flags().StringVar(&progress, "progress", "auto", "Progress output: auto, plain, or json")
+	buildCmd.Flags().StringVar(&priority, "priority", "", "Queue priority of the server build: high, normal, or low (default normal)")
+	buildCmd.Flags().StringVar(&machine, "build-machine", "", "Builder machine type of the server build: small, large, or gpu (default: build-machine in pyproject.toml, else small)")
 
 	return buildCmd
 }
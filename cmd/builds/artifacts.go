@@ -0,0 +1,39 @@
+package builds
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/builds"
+	"github.com/spf13/cobra"
+)
+
+// ArtifactsCmd downloads the artifacts of a build
+func ArtifactsCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts builds.ArtifactsOptions
+
+	artifactsCmd := &cobra.Command{
+		Use:   "artifacts <build-id>",
+		Short: "Download the artifacts attached to a build",
+		Long: `Download the files the builder attached to a finished build: the generated
+Dockerfile, the dependency lock snapshot, the SBOM, and the logs archive.
+Useful for post-mortems without access to the underlying storage.
+
+Example:
+  cozyctl builds artifacts build-123
+  cozyctl builds artifacts build-123 --out ./postmortem/
+  cozyctl builds artifacts build-123 --name sbom.spdx.json --name Dockerfile
+  cozyctl builds artifacts build-123 --list`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.BuildID = args[0]
+			return builds.Artifacts(opts)
+		},
+	}
+
+	artifactsCmd.Flags().StringVar(&opts.OutDir, "out", "", "Directory to download into (default <build-id>-artifacts)")
+	artifactsCmd.Flags().StringArrayVar(&opts.Names, "name", nil, "Only download this artifact (repeatable)")
+	artifactsCmd.Flags().BoolVar(&opts.List, "list", false, "List the artifacts instead of downloading them")
+
+	return artifactsCmd
+}
@@ -0,0 +1,24 @@
+package builds
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// BuildsCmd groups commands that inspect server-side builds
+func BuildsCmd(globals *cmdutil.Globals) *cobra.Command {
+	buildsCmd := &cobra.Command{
+		Use:   "builds",
+		Short: "Inspect server-side builds",
+	}
+
+	buildsCmd.AddCommand(ListCmd(globals))
+	buildsCmd.AddCommand(WatchCmd(globals))
+	buildsCmd.AddCommand(LogsCmd(globals))
+	buildsCmd.AddCommand(CancelCmd(globals))
+	buildsCmd.AddCommand(ArtifactsCmd(globals))
+	buildsCmd.AddCommand(ProvenanceCmd(globals))
+	buildsCmd.AddCommand(TimingsCmd(globals))
+
+	return buildsCmd
+}
@@ -0,0 +1,60 @@
+package builds
+
+import (
+	"fmt"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/builds"
+	"github.com/spf13/cobra"
+)
+
+// CancelCmd cancels builds
+func CancelCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts builds.CancelOptions
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel [build-id...]",
+		Short: "Cancel running builds",
+		Long: `Cancel one or more queued or running builds.
+
+With --all-pending, every pending, queued, or running build is canceled
+(optionally only those of --deployment), e.g. when a bad commit triggered a
+storm of CI builds. The builds are listed and must be confirmed unless --yes.
+
+--dry-run shows which builds would be canceled without canceling any.
+
+Example:
+  cozyctl builds cancel build-123
+  cozyctl builds cancel --all-pending --deployment my-model
+  cozyctl builds cancel --all-pending --dry-run
+  cozyctl builds cancel --all-pending --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.DryRun {
+				if err := globals.CheckWritable(cmd); err != nil {
+					return err
+				}
+			}
+			if opts.AllPending && len(args) > 0 {
+				return fmt.Errorf("build IDs cannot be combined with --all-pending")
+			}
+			if !opts.AllPending && len(args) == 0 {
+				return fmt.Errorf("a build ID or --all-pending is required")
+			}
+			if opts.Deployment != "" && !opts.AllPending {
+				return fmt.Errorf("--deployment requires --all-pending")
+			}
+
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.BuildIDs = args
+			return builds.Cancel(opts)
+		},
+	}
+
+	cancelCmd.Flags().BoolVar(&opts.AllPending, "all-pending", false, "Cancel every pending or running build")
+	cancelCmd.Flags().StringVar(&opts.Deployment, "deployment", "", "Only cancel builds of this deployment (with --all-pending)")
+	cancelCmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Don't ask for confirmation")
+	cancelCmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show the builds that would be canceled without canceling them")
+
+	return cancelCmd
+}
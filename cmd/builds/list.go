@@ -0,0 +1,61 @@
+package builds
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/builds"
+	"github.com/spf13/cobra"
+)
+
+// ListCmd lists recent builds
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		opts  builds.ListOptions
+		watch bool
+		every time.Duration
+	)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recent builds with status",
+		Long: `List the tenant's most recent server-side builds, newest first.
+
+With --watch the list is refreshed every --interval, highlighting builds that
+are new or whose status changed, until interrupted.
+
+Example:
+  cozyctl builds list
+  cozyctl builds list --deployment my-model --limit 5
+  cozyctl builds list --watch`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if watch && every <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.Output = globals.OutputFormat()
+			if watch {
+				opts.Watch = every
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return builds.List(ctx, opts)
+		},
+	}
+
+	listCmd.Flags().StringVar(&opts.DeploymentID, "deployment", "", "Only list builds of this deployment")
+	listCmd.Flags().IntVar(&opts.Limit, "limit", 20, "Maximum number of builds to list")
+	listCmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep refreshing the list, highlighting status changes")
+	listCmd.Flags().DurationVar(&every, "interval", 2*time.Second, "Refresh interval for --watch")
+
+	return listCmd
+}
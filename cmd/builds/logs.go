@@ -0,0 +1,36 @@
+package builds
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/builds"
+	"github.com/spf13/cobra"
+)
+
+// LogsCmd prints a build's logs
+func LogsCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts builds.LogsOptions
+
+	logsCmd := &cobra.Command{
+		Use:   "logs <build-id>",
+		Short: "View build logs",
+		Long: `Print a server build's logs.
+
+With --follow, new lines are printed as they arrive until the build finishes.
+Interrupting stops following; the build keeps running.
+
+Example:
+  cozyctl builds logs build-123
+  cozyctl builds logs -f build-123`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.BuildID = args[0]
+			return builds.Logs(cmd.Context(), opts)
+		},
+	}
+
+	logsCmd.Flags().BoolVarP(&opts.Follow, "follow", "f", false, "Keep printing new lines until the build finishes")
+
+	return logsCmd
+}
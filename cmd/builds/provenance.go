@@ -0,0 +1,44 @@
+package builds
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/builds"
+	"github.com/spf13/cobra"
+)
+
+// ProvenanceCmd retrieves and verifies the provenance of a build
+func ProvenanceCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts builds.ProvenanceOptions
+
+	provenanceCmd := &cobra.Command{
+		Use:   "provenance <build-id>",
+		Short: "Show and verify the SLSA provenance of a build",
+		Long: `Show the in-toto SLSA provenance statement recorded when a build finished:
+the image it produced, the builder that ran it, its parameters, and the
+digests of its source and base image.
+
+The statement is checked against the build record (build ID, image tag and
+digest, builder). With --dir, the source digest is also recomputed from a
+local checkout, so you can confirm which code a running image was built from.
+Statements are not signed; verification checks consistency, not authenticity.
+Exits non-zero if any check fails. -o json or -o yaml prints the raw statement.
+
+Example:
+  cozyctl builds provenance build-123
+  cozyctl builds provenance build-123 --dir ./my-project
+  cozyctl builds provenance build-123 -o json > provenance.json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.BuildID = args[0]
+			opts.Output = globals.OutputFormat()
+			return builds.Provenance(opts)
+		},
+	}
+
+	provenanceCmd.Flags().StringVar(&opts.Dir, "dir", "", "Project checkout to verify the source digest against")
+
+	return provenanceCmd
+}
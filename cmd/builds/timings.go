@@ -0,0 +1,52 @@
+package builds
+
+import (
+	"fmt"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/builds"
+	"github.com/spf13/cobra"
+)
+
+// TimingsCmd shows where the time of builds goes
+func TimingsCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts builds.TimingsOptions
+
+	timingsCmd := &cobra.Command{
+		Use:   "timings [build-id]",
+		Short: "Show how long each phase of a build took",
+		Long: `Break a build down into phases: package & upload, queue wait, image build,
+push, and deploy. Without a build ID, the last builds (--last, default 20) are
+summarized per phase with the mean, median, and maximum time and each phase's
+share of the total, and the phase where most time goes gets a suggestion,
+such as a larger --build-machine or keeping the layer cache warm.
+
+Queue wait, image build, and push come from the builder. Packaging and
+deploying are measured by cozyctl, so they are only known for builds
+submitted or deployed from this machine with this profile.
+
+Example:
+  cozyctl builds timings build-123
+  cozyctl builds timings --last 20
+  cozyctl builds timings --deployment my-model -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 && (cmd.Flags().Changed("last") || cmd.Flags().Changed("deployment")) {
+				return fmt.Errorf("--last and --deployment apply to the report over several builds, not to one build")
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			if len(args) == 1 {
+				opts.BuildID = args[0]
+			}
+			opts.Output = globals.OutputFormat()
+			return builds.Timings(opts)
+		},
+	}
+
+	timingsCmd.Flags().IntVar(&opts.Last, "last", builds.DefaultTimingsLast, "Number of recent builds to summarize")
+	timingsCmd.Flags().StringVar(&opts.DeploymentID, "deployment", "", "Only summarize builds of this deployment")
+
+	return timingsCmd
+}
@@ -0,0 +1,48 @@
+package builds
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/builds"
+	"github.com/spf13/cobra"
+)
+
+// WatchCmd shows a live board of a deployment's builds
+func WatchCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts builds.WatchOptions
+
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch a deployment's recent builds and the newest build's logs",
+		Long: `Show a continuously updating view of a deployment's most recent builds,
+with how long each has been queued or running (or how long it took), and the
+last log lines of the newest build, until interrupted.
+
+When output is not a terminal, each refresh is appended after a timestamp line.
+
+Example:
+  cozyctl builds watch --deployment my-model
+  cozyctl builds watch --deployment my-model --limit 3 --tail 20 --interval 5s`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return builds.Watch(ctx, opts)
+		},
+	}
+
+	watchCmd.Flags().StringVar(&opts.DeploymentID, "deployment", "", "Deployment whose builds to watch (required)")
+	watchCmd.Flags().IntVar(&opts.Limit, "limit", builds.DefaultWatchLimit, "Number of recent builds to show")
+	watchCmd.Flags().IntVar(&opts.Tail, "tail", builds.DefaultTailLines, "Log lines of the newest build to show (0 hides them)")
+	watchCmd.Flags().DurationVar(&opts.Interval, "interval", 2*time.Second, "Refresh interval")
+	watchCmd.MarkFlagRequired("deployment")
+
+	return watchCmd
+}
@@ -0,0 +1,36 @@
+package capabilities
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/capabilities"
+	"github.com/spf13/cobra"
+)
+
+// CapabilitiesCmd shows which optional features the servers support
+func CapabilitiesCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	capabilitiesCmd := &cobra.Command{
+		Use:   "capabilities",
+		Short: "Show which optional features your servers support",
+		Long: `Show the version of the profile's cozy-hub and orchestrator and the optional
+features each supports, such as traffic splitting or async jobs. Commands
+that need a feature a server lacks fail with an error naming it instead of
+an HTTP 404; older self-hosted servers that predate capability discovery
+support none of them.
+
+Example:
+  cozyctl capabilities
+  cozyctl capabilities -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return capabilities.Show(capabilities.Options{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	return capabilitiesCmd
+}
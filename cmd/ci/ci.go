@@ -0,0 +1,55 @@
+package ci
+
+import (
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/ci"
+	"github.com/spf13/cobra"
+)
+
+// CICmd groups commands that set up continuous deployment
+func CICmd() *cobra.Command {
+	ciCmd := &cobra.Command{
+		Use:         "ci",
+		Short:       "Set up CI pipelines that build and deploy with cozyctl",
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+	}
+
+	ciCmd.AddCommand(InitCmd())
+
+	return ciCmd
+}
+
+// InitCmd writes a CI pipeline file
+func InitCmd() *cobra.Command {
+	opts := ci.InitOptions{RepoRoot: "."}
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a CI pipeline that builds and deploys the project",
+		Long: `Write a ready-made pipeline for GitHub Actions (.github/workflows/cozy-deploy.yml)
+or GitLab CI (.gitlab-ci.yml) in the current directory, which should be the
+repository root. On every push to --branch the pipeline logs in with the
+COZY_API_KEY secret, builds the project in --dir on cozy-hub, and deploys the
+build to the deployment-id of the project's pyproject.toml. cozyctl and its
+Go build cache are cached between runs.
+
+Example:
+  cozyctl ci init --provider github
+  cozyctl ci init --provider gitlab --dir services/generate --branch release`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ci.Init(opts)
+		},
+	}
+
+	initCmd.Flags().StringVar(&opts.Provider, "provider", "", "CI provider: "+strings.Join(ci.Providers, " or "))
+	initCmd.Flags().StringVarP(&opts.Dir, "dir", "d", ".", "Project directory, relative to the repository root")
+	initCmd.Flags().StringVar(&opts.Branch, "branch", "main", "Branch whose pushes deploy")
+	initCmd.Flags().StringVar(&opts.CozyctlVersion, "cozyctl-version", "latest", "cozyctl version the pipeline installs")
+	initCmd.Flags().BoolVar(&opts.Force, "force", false, "Overwrite an existing pipeline file")
+	initCmd.MarkFlagRequired("provider")
+
+	return initCmd
+}
@@ -0,0 +1,268 @@
+// Package cmdutil holds state shared between cozyctl commands.
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/httpcache"
+	"github.com/cozy-creator/cozyctl/internal/httprecord"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+	"github.com/cozy-creator/cozyctl/internal/ratelimit"
+	"github.com/cozy-creator/cozyctl/internal/readonly"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// SkipTokenCheck is a command annotation exempting the command and its
+// subcommands from the token expiry check: commands that work offline, or
+// that exist to replace the token.
+const SkipTokenCheck = "cozyctl/skip-token-check"
+
+// GlobalOutput is a command annotation marking a command that prints its
+// result in the format of the root --output flag; any other command rejects
+// --output.
+const GlobalOutput = "cozyctl/global-output"
+
+// ExplicitProfile is a command annotation marking a command that picks the
+// profile it works on with its own --name/--profile flags (login, logout,
+// use): it skips the directory profile mapping and the token expiry check.
+const ExplicitProfile = "cozyctl/explicit-profile"
+
+// Globals holds the root command's persistent flags for one invocation.
+// A fresh Globals is created each time the command tree is built, so commands
+// can be constructed and executed more than once (tests, embedding) without
+// sharing state.
+type Globals struct {
+	Name    string // --name
+	Profile string // --profile
+	Record  string // --record: session file to capture HTTP interactions to
+	Replay  string // --replay: session file to answer HTTP requests from
+	NoCache bool   // --no-cache: don't answer GETs from the response cache
+
+	MaxRequests int // --max-requests: API requests in flight at once
+
+	// -o/--output for commands annotated with GlobalOutput
+	Output string
+
+	StrictAuth bool // --strict-auth: fail instead of warning about an expiring token
+
+	ReadOnly bool // --read-only: refuse API requests that would change anything
+
+	// --unlock-reason: why this command may change locked deployments
+	UnlockReason string
+
+	// Why requests are limited to reads when the flag wasn't given: the
+	// selected profile is read-only (see ApplyProfileReadOnly)
+	readOnlyReason string
+
+	// The API clients' transport, built by StartHTTPSession
+	transport http.RoundTripper
+}
+
+// ProfileRef returns the profile selected by --name/--profile.
+func (g *Globals) ProfileRef() config.ProfileRef {
+	return config.ProfileRef{Name: g.Name, Profile: g.Profile}
+}
+
+// ApplyDirectoryProfile selects the profile mapped to the working directory
+// (see 'cozyctl use --map') when neither --name nor --profile was given, and
+// notes the choice on w so it is never silent.
+func (g *Globals) ApplyDirectoryProfile(w io.Writer) error {
+	if g.Name != "" || g.Profile != "" {
+		return nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	mapping, ok, err := config.ProfileForDir(wd)
+	if err != nil || !ok {
+		return err
+	}
+
+	g.Name, g.Profile = mapping.Name, mapping.Profile
+	fmt.Fprintf(w, "Using profile '%s/%s' (mapped to %s)\n", mapping.Name, mapping.Profile, mapping.Dir)
+	return nil
+}
+
+// ApplyProfileReadOnly puts the command in read-only mode if the selected
+// profile is marked read-only (see 'cozyctl config set read-only').
+func (g *Globals) ApplyProfileReadOnly() {
+	g.readOnlyReason = ""
+	if config.ProfileReadOnly(g.ProfileRef()) {
+		ref, _ := config.ResolveProfileRef(g.ProfileRef())
+		g.readOnlyReason = fmt.Sprintf("profile '%s/%s' is read-only (cozyctl config set read-only false to allow changes)", ref.Name, ref.Profile)
+	}
+}
+
+// readOnly returns why the command may only read, or "" if it may change things.
+func (g *Globals) readOnly() string {
+	if g.ReadOnly {
+		return "read-only mode (--read-only)"
+	}
+	return g.readOnlyReason
+}
+
+// CheckWritable refuses cmd in read-only mode, before it packages, builds, or
+// sends anything; the transport refuses whatever a command sends anyway, but
+// only once the work leading up to the request is done.
+func (g *Globals) CheckWritable(cmd *cobra.Command) error {
+	if reason := g.readOnly(); reason != "" {
+		return fmt.Errorf("'%s' refused: %s", cmd.CommandPath(), reason)
+	}
+	return nil
+}
+
+// StartHTTPSession builds the transport this invocation's API clients send
+// their requests through (see Transport): the --record or --replay transport,
+// or else the response cache unless --no-cache is set, behind the rate
+// limiter (see --max-requests), all aborted when ctx is cancelled. Sessions
+// are recorded and replayed uncached, so they hold full responses rather
+// than 304s that only make sense next to this machine's cache. In read-only
+// mode requests that would change anything are refused in front of all of
+// it; otherwise changes carry the --unlock-reason. Requests to other hosts,
+// such as PyPI or GitHub, don't go through it.
+func (g *Globals) StartHTTPSession(ctx context.Context, stderr io.Writer) error {
+	transport := interrupt.Transport(ctx, http.DefaultTransport)
+	switch {
+	case g.Record != "" && g.Replay != "":
+		return fmt.Errorf("--record and --replay cannot be used together")
+	case g.Replay != "":
+		replayer, err := httprecord.LoadReplayer(g.Replay)
+		if err != nil {
+			return err
+		}
+		g.transport = g.guard(replayer)
+		return nil
+	case g.Record != "":
+		transport = httprecord.NewRecorder(g.Record, transport)
+	}
+
+	transport = ratelimit.New(transport, g.MaxRequests, ratelimit.NotifyWriter(stderr))
+	if g.Record == "" && !g.NoCache {
+		// Without a home directory to cache in, requests go uncached
+		if cache, err := httpcache.New(transport); err == nil {
+			transport = cache
+		}
+	}
+	g.transport = g.guard(transport)
+	return nil
+}
+
+// Transport returns the transport StartHTTPSession built, for the API
+// clients of the command. Before the session starts it is nil, which the
+// clients take as http.DefaultTransport.
+func (g *Globals) Transport() http.RoundTripper {
+	return g.transport
+}
+
+// guard puts the read-only check in front of transport in read-only mode,
+// and the unlock reason, if given, on requests that change something.
+func (g *Globals) guard(transport http.RoundTripper) http.RoundTripper {
+	if reason := g.readOnly(); reason != "" {
+		return &readonly.Transport{Base: transport, Reason: reason}
+	}
+	if g.UnlockReason != "" {
+		return &api.UnlockTransport{Base: transport, Reason: g.UnlockReason}
+	}
+	return transport
+}
+
+// OutputFormat returns the format selected with the root --output flag.
+func (g *Globals) OutputFormat() ui.Output {
+	format, _ := ui.ParseOutput(g.Output)
+	return format
+}
+
+// CheckOutput validates the root --output flag for cmd: it must name a
+// format, and cmd must be annotated with GlobalOutput to print in it.
+func (g *Globals) CheckOutput(cmd *cobra.Command) error {
+	if g.Output == "" {
+		return nil
+	}
+	if _, err := ui.ParseOutput(g.Output); err != nil {
+		return err
+	}
+	if _, ok := cmd.Annotations[GlobalOutput]; !ok {
+		return fmt.Errorf("'%s' doesn't support --output", cmd.CommandPath())
+	}
+	return nil
+}
+
+// PicksProfile reports whether cmd is annotated with ExplicitProfile.
+func PicksProfile(cmd *cobra.Command) bool {
+	_, ok := cmd.Annotations[ExplicitProfile]
+	return ok
+}
+
+// SkipsTokenCheck reports whether cmd or one of its parents is annotated
+// with SkipTokenCheck.
+func SkipsTokenCheck(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[SkipTokenCheck]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTokenExpiry warns on w when the selected profile's access token has
+// expired or expires within config.TokenExpiryWarning. With --strict-auth the
+// warning is returned as an error instead, so CI fails early rather than
+// partway through a deploy. Profiles that are not logged in, and tokens with
+// no known expiry, are not checked.
+func (g *Globals) CheckTokenExpiry(w io.Writer, now time.Time) error {
+	ref, err := config.ResolveProfileRef(g.ProfileRef())
+	if err != nil {
+		return nil
+	}
+	profileCfg, err := config.LoadProfileConfig(ref)
+	if err != nil || profileCfg.Config == nil || profileCfg.Config.Token == "" {
+		return nil
+	}
+	expires, ok := profileCfg.Config.TokenExpiry()
+	if !ok {
+		return nil
+	}
+
+	remaining := expires.Sub(now)
+	if remaining > config.TokenExpiryWarning {
+		return nil
+	}
+
+	var problem string
+	if remaining <= 0 {
+		problem = fmt.Sprintf("the access token for profile '%s/%s' expired at %s",
+			ref.Name, ref.Profile, expires.Local().Format("2006-01-02 15:04"))
+	} else {
+		problem = fmt.Sprintf("the access token for profile '%s/%s' expires in %s (%s)",
+			ref.Name, ref.Profile, formatRemaining(remaining), expires.Local().Format("2006-01-02 15:04"))
+	}
+	fix := "run 'cozyctl login' to get a new one"
+	if profileCfg.Config.RefreshToken != "" {
+		fix = "run 'cozyctl auth refresh' to renew it"
+	}
+
+	if g.StrictAuth {
+		return fmt.Errorf("%s; %s (--strict-auth)", problem, fix)
+	}
+	fmt.Fprintf(w, "Warning: %s; %s\n", problem, fix)
+	return nil
+}
+
+// formatRemaining shows a duration under a day as hours and minutes.
+func formatRemaining(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
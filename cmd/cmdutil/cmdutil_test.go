@@ -0,0 +1,112 @@
+package cmdutil
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/readonly"
+	"github.com/spf13/cobra"
+)
+
+func TestCheckTokenExpiry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	save := func(expires time.Time, refresh string) {
+		t.Helper()
+		err := config.SaveProfileConfig("ci", "prod", &config.ProfileConfig{Config: &config.ConfigData{
+			Token:          "tok",
+			RefreshToken:   refresh,
+			TokenExpiresAt: expires.Format(time.RFC3339),
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	g := &Globals{Name: "ci", Profile: "prod"}
+
+	save(now.Add(48*time.Hour), "")
+	var out bytes.Buffer
+	if err := g.CheckTokenExpiry(&out, now); err != nil || out.Len() != 0 {
+		t.Errorf("far-off expiry: err = %v, output %q", err, out.String())
+	}
+
+	save(now.Add(3*time.Hour+10*time.Minute), "rt")
+	if err := g.CheckTokenExpiry(&out, now); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "expires in 3h10m") || !strings.Contains(out.String(), "cozyctl auth refresh") {
+		t.Errorf("unexpected warning: %q", out.String())
+	}
+
+	save(now.Add(-time.Minute), "")
+	g.StrictAuth = true
+	err := g.CheckTokenExpiry(&out, now)
+	if err == nil || !strings.Contains(err.Error(), "expired at") || !strings.Contains(err.Error(), "cozyctl login") {
+		t.Errorf("err = %v, want an expired token error", err)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := config.SaveProfileConfig("ci", "prod", &config.ProfileConfig{Config: &config.ConfigData{Token: "tok"}}); err != nil {
+		t.Fatal(err)
+	}
+	g := &Globals{Name: "ci", Profile: "prod"}
+
+	g.ApplyProfileReadOnly()
+	if _, ok := g.guard(http.DefaultTransport).(*readonly.Transport); ok {
+		t.Fatal("writable profile got a read-only transport")
+	}
+
+	if err := config.SetProfileReadOnly(g.ProfileRef(), true); err != nil {
+		t.Fatal(err)
+	}
+	g.ApplyProfileReadOnly()
+	guarded, ok := g.guard(http.DefaultTransport).(*readonly.Transport)
+	if !ok || !strings.Contains(guarded.Reason, "profile 'ci/prod' is read-only") {
+		t.Fatalf("read-only profile: transport %#v", guarded)
+	}
+	if err := g.CheckWritable(&cobra.Command{Use: "deploy"}); err == nil || !strings.Contains(err.Error(), "'deploy' refused") {
+		t.Errorf("CheckWritable: err = %v, want deploy refused", err)
+	}
+
+	// The flag makes any profile read-only
+	if err := config.SetProfileReadOnly(g.ProfileRef(), false); err != nil {
+		t.Fatal(err)
+	}
+	g.ReadOnly = true
+	g.ApplyProfileReadOnly()
+	if guarded, ok := g.guard(http.DefaultTransport).(*readonly.Transport); !ok || !strings.Contains(guarded.Reason, "--read-only") {
+		t.Errorf("--read-only: transport %#v", guarded)
+	}
+}
+
+func TestStartHTTPSessionLeavesDefaultTransport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	before := http.DefaultTransport
+	g := &Globals{ReadOnly: true}
+	if err := g.StartHTTPSession(context.Background(), &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	if http.DefaultTransport != before {
+		t.Error("StartHTTPSession replaced http.DefaultTransport")
+	}
+	if _, ok := g.Transport().(*readonly.Transport); !ok {
+		t.Errorf("read-only session: transport %#v", g.Transport())
+	}
+
+	// Each invocation builds its own chain
+	other := &Globals{}
+	if err := other.StartHTTPSession(context.Background(), &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := other.Transport().(*readonly.Transport); ok {
+		t.Error("a second session shares the first one's read-only transport")
+	}
+}
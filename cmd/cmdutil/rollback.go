@@ -0,0 +1,43 @@
+package cmdutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/rollout"
+	"github.com/spf13/cobra"
+)
+
+// RollbackFlags are the --auto-rollback flags shared by deploy and update.
+type RollbackFlags struct {
+	cmd       *cobra.Command
+	enabled   bool
+	window    time.Duration
+	errorRate float64 // Percent
+}
+
+// Register adds the flags to cmd.
+func (f *RollbackFlags) Register(cmd *cobra.Command) {
+	f.cmd = cmd
+	cmd.Flags().BoolVar(&f.enabled, "auto-rollback", false, "Watch the rollout and roll back if it fails")
+	cmd.Flags().DurationVar(&f.window, "rollback-window", rollout.DefaultWindow, "How long to watch the rollout (with --auto-rollback)")
+	cmd.Flags().Float64Var(&f.errorRate, "rollback-error-rate", rollout.DefaultMaxErrorRate*100, "Percentage of failed requests that triggers a rollback (with --auto-rollback)")
+}
+
+// Policy returns the rollout policy the flags describe, or nil without
+// --auto-rollback.
+func (f *RollbackFlags) Policy() (*rollout.Policy, error) {
+	if !f.enabled {
+		if f.cmd.Flags().Changed("rollback-window") || f.cmd.Flags().Changed("rollback-error-rate") {
+			return nil, fmt.Errorf("--rollback-window and --rollback-error-rate require --auto-rollback")
+		}
+		return nil, nil
+	}
+	if f.window <= 0 {
+		return nil, fmt.Errorf("--rollback-window must be positive")
+	}
+	if f.errorRate < 0 || f.errorRate > 100 {
+		return nil, fmt.Errorf("--rollback-error-rate must be a percentage between 0 and 100")
+	}
+	return &rollout.Policy{Window: f.window, MaxErrorRate: f.errorRate / 100}, nil
+}
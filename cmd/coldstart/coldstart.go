@@ -0,0 +1,61 @@
+package coldstart
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/cozy-creator/cozyctl/internal/invoke"
+	"github.com/spf13/cobra"
+)
+
+// ColdStartCmd measures a deployment's cold start
+func ColdStartCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		opts deployments.ColdStartOptions
+		data string
+		file string
+	)
+
+	coldStartCmd := &cobra.Command{
+		Use:   "coldstart <deployment-id>",
+		Short: "Measure a deployment's cold start and suggest remedies",
+		Long: `Stop all of a deployment's workers, invoke one of its functions, and
+measure the time to the first successful response, broken into phases from
+the orchestrator's events: scheduling a worker on a machine, pulling the
+image, loading models, and the first inference.
+
+Remedies are suggested for the phases that dominate, such as baking model
+weights into the image or keeping a minimum of warm workers.
+
+Invocations arriving while the deployment is scaled to zero wait for the new
+worker, so run this against a staging deployment or at a quiet time.
+
+Example:
+  cozyctl coldstart my-model
+  cozyctl coldstart my-model --function generate -d '{"prompt": "a cat"}' --yes
+  cozyctl coldstart my-model --yes -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			var err error
+			if opts.Payload, err = invoke.ReadPayload(data, file, cmd.InOrStdin()); err != nil {
+				return err
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.DeploymentID = args[0]
+			opts.Output = globals.OutputFormat()
+			return deployments.ColdStart(opts)
+		},
+	}
+
+	coldStartCmd.Flags().StringVar(&opts.Function, "function", "", "Function to invoke (default: the deployment's first)")
+	coldStartCmd.Flags().StringVarP(&data, "data", "d", "", "JSON payload")
+	coldStartCmd.Flags().StringVarP(&file, "file", "f", "", "Read the JSON payload from a file")
+	coldStartCmd.Flags().DurationVar(&opts.Timeout, "timeout", deployments.DefaultColdStartTimeout, "How long to wait for the first response")
+	coldStartCmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Scale to zero without prompting")
+
+	return coldStartCmd
+}
@@ -0,0 +1,65 @@
+package completionCmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/completion"
+	"github.com/spf13/cobra"
+)
+
+// AddInstallCmd adds 'install' to cobra's generated completion command, which
+// it creates first if needed.
+func AddInstallCmd(root *cobra.Command) {
+	root.InitDefaultCompletionCmd()
+	for _, c := range root.Commands() {
+		if c.Name() == "completion" {
+			c.Annotations = map[string]string{cmdutil.SkipTokenCheck: ""}
+			c.AddCommand(InstallCmd(root))
+		}
+	}
+}
+
+// InstallCmd writes the completion script where the user's shell loads it
+func InstallCmd(root *cobra.Command) *cobra.Command {
+	var opts completion.InstallOptions
+
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the completion script for your shell",
+		Long: `Detect your shell from $SHELL (or use --shell), write the completion script
+where that shell picks it up, and print every file changed:
+
+  bash  Homebrew's etc/bash_completion.d, else
+        ~/.local/share/bash-completion/completions (needs bash-completion 2)
+  zsh   Homebrew's share/zsh/site-functions, else ~/.zsh/completions,
+        adding that directory to fpath in ~/.zshrc
+  fish  ~/.config/fish/completions
+
+Re-run after upgrading cozyctl to pick up new commands and flags.
+
+Example:
+  cozyctl completion install
+  cozyctl completion install --shell zsh`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Generate = func(shell string, w io.Writer) error {
+				switch shell {
+				case "bash":
+					return root.GenBashCompletionV2(w, true)
+				case "zsh":
+					return root.GenZshCompletion(w)
+				case "fish":
+					return root.GenFishCompletion(w, true)
+				}
+				return fmt.Errorf("unsupported shell %q", shell)
+			}
+			return completion.Install(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	installCmd.Flags().StringVar(&opts.Shell, "shell", "", "Shell to install for: bash, zsh, or fish (default: detected from $SHELL)")
+
+	return installCmd
+}
@@ -0,0 +1,22 @@
+package configCmd
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// ConfigCmd groups commands that inspect and manage the config files
+func ConfigCmd(globals *cmdutil.Globals) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:         "config",
+		Short:       "Inspect and manage cozyctl config files",
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+	}
+
+	configCmd.AddCommand(ViewCmd(globals))
+	configCmd.AddCommand(MigrateCmd(globals))
+	configCmd.AddCommand(SetDefaultURLsCmd(globals))
+	configCmd.AddCommand(SetCmd(globals))
+
+	return configCmd
+}
@@ -0,0 +1,99 @@
+package configCmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// MigrateCmd upgrades profile configs to the current layout
+func MigrateCmd(globals *cmdutil.Globals) *cobra.Command {
+	var dryRun bool
+
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade profile configs to the current layout",
+		Long: `Upgrade profile configs written by older versions of cozyctl.
+
+Configs are migrated automatically when they are loaded; this command upgrades
+every profile at once (or only the one selected with --name/--profile) and
+--dry-run previews the changes without writing anything. The original of each
+migrated file is kept as config.yaml.v<N>.bak.
+
+Example:
+  cozyctl config migrate --dry-run
+  cozyctl config migrate --name briheet --profile dev`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := selectProfiles(globals)
+			if err != nil {
+				return err
+			}
+
+			if len(profiles) == 0 {
+				fmt.Println("No profiles found. Run 'cozyctl login' to create one.")
+				return nil
+			}
+
+			for _, p := range profiles {
+				result, err := config.MigrateProfileConfig(p.Name, p.Profile, dryRun)
+				if err != nil {
+					return err
+				}
+
+				label := fmt.Sprintf("%s/%s", p.Name, p.Profile)
+				if len(result.Changes) == 0 {
+					fmt.Printf("%s: up to date (version %d)\n", label, result.To)
+					continue
+				}
+
+				verb := "migrated"
+				if dryRun {
+					verb = "would migrate"
+				}
+				fmt.Printf("%s: %s from version %d to %d\n", label, verb, result.From, result.To)
+				for _, change := range result.Changes {
+					fmt.Printf("  - %s\n", change)
+				}
+				if dryRun {
+					fmt.Printf("  new %s:\n", result.Path)
+					for _, line := range strings.Split(strings.TrimRight(string(result.After), "\n"), "\n") {
+						fmt.Printf("    %s\n", line)
+					}
+				}
+			}
+
+			return nil
+		},
+	}
+
+	migrateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "show what would change without writing")
+
+	return migrateCmd
+}
+
+// selectProfiles returns the profile chosen with --name/--profile, or every profile.
+func selectProfiles(globals *cmdutil.Globals) ([]struct{ Name, Profile string }, error) {
+	if globals.Name != "" || globals.Profile != "" {
+		ref, err := config.ResolveProfileRef(globals.ProfileRef())
+		if err != nil {
+			return nil, err
+		}
+		return []struct{ Name, Profile string }{{ref.Name, ref.Profile}}, nil
+	}
+
+	profiles, err := config.ListAllProfiles()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Name != profiles[j].Name {
+			return profiles[i].Name < profiles[j].Name
+		}
+		return profiles[i].Profile < profiles[j].Profile
+	})
+	return profiles, nil
+}
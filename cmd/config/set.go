@@ -0,0 +1,73 @@
+package configCmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// SetCmd changes a setting that applies to cozyctl as a whole
+func SetCmd(globals *cmdutil.Globals) *cobra.Command {
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a cozyctl or profile setting",
+		Long: `Change a setting. credential-store applies to every profile and is saved in
+~/.cozy/settings.yaml; read-only applies to the active profile (or the one
+selected with --name/--profile) and is saved in its config.yaml.
+
+Settings:
+  credential-store  Where tokens and passwords are kept: "file" (config.yaml,
+                    the default) or "keychain" (macOS Keychain, Windows
+                    Credential Manager, or the Secret Service on Linux).
+                    Existing profiles are moved to the new store.
+  read-only         "true" refuses every API request that would change
+                    anything (deploy, update, delete, scale, cancel, ...)
+                    for commands using the profile, like --read-only.
+
+Example:
+  cozyctl config set credential-store keychain
+  cozyctl config set credential-store file
+  cozyctl config set read-only true --name work --profile prod`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+			switch key {
+			case "credential-store":
+				moved, err := config.SetCredentialStore(value)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Credentials are now kept in: %s\n", value)
+				if moved > 0 {
+					fmt.Printf("Moved the credentials of %d profile(s).\n", moved)
+				}
+				return nil
+			case "read-only":
+				readOnly, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("read-only must be true or false, not '%s'", value)
+				}
+				ref, err := config.ResolveProfileRef(globals.ProfileRef())
+				if err != nil {
+					return err
+				}
+				if err := config.SetProfileReadOnly(ref, readOnly); err != nil {
+					return err
+				}
+				if readOnly {
+					fmt.Printf("Profile '%s/%s' is now read-only: commands using it can't change anything.\n", ref.Name, ref.Profile)
+				} else {
+					fmt.Printf("Profile '%s/%s' can make changes again.\n", ref.Name, ref.Profile)
+				}
+				return nil
+			default:
+				return fmt.Errorf("unknown setting '%s' (must be credential-store or read-only)", key)
+			}
+		},
+	}
+
+	return setCmd
+}
@@ -0,0 +1,78 @@
+package configCmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// SetDefaultURLsCmd points a profile at an environment preset or at given server URLs
+func SetDefaultURLsCmd(globals *cmdutil.Globals) *cobra.Command {
+	var env, hubURL, builderURL, orchestratorURL string
+
+	setCmd := &cobra.Command{
+		Use:   "set-default-urls",
+		Short: "Point a profile at an environment preset or other servers",
+		Long: `Set the hub, builder, and orchestrator URLs saved in the active profile (or
+the one selected with --name/--profile).
+
+--env sets all three from a preset; --hub-url, --builder-url, and
+--orchestrator-url set single URLs, overriding the preset's when combined
+with --env. URLs that aren't given are left as they are.
+
+Presets:
+` + presetList() + `
+Example:
+  cozyctl config set-default-urls --env staging
+  cozyctl config set-default-urls --orchestrator-url http://localhost:9000
+  cozyctl config set-default-urls --name briheet --profile prod --env production`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if env == "" && hubURL == "" && builderURL == "" && orchestratorURL == "" {
+				return fmt.Errorf("nothing to set: give --env or one of --hub-url, --builder-url, --orchestrator-url")
+			}
+			urls, err := config.SelectURLs(env, hubURL, builderURL, orchestratorURL)
+			if err != nil {
+				return err
+			}
+
+			ref, err := config.ResolveProfileRef(globals.ProfileRef())
+			if err != nil {
+				return err
+			}
+			saved, err := config.SetProfileURLs(ref, urls)
+			if err != nil {
+				return err
+			}
+
+			label := "custom URLs"
+			if name := config.EnvironmentOf(saved); name != "" {
+				label = "environment " + name
+			}
+			fmt.Printf("Profile '%s/%s' now uses %s:\n", ref.Name, ref.Profile, label)
+			fmt.Printf("  hub_url:          %s\n", saved.HubURL)
+			fmt.Printf("  builder_url:      %s\n", saved.BuilderURL)
+			fmt.Printf("  orchestrator_url: %s\n", saved.OrchestratorURL)
+			return nil
+		},
+	}
+
+	setCmd.Flags().StringVar(&env, "env", "", "environment preset: "+strings.Join(config.EnvironmentNames(), ", "))
+	setCmd.Flags().StringVar(&hubURL, "hub-url", "", "Cozy Hub API URL")
+	setCmd.Flags().StringVar(&builderURL, "builder-url", "", "Builder API URL (now part of cozy-hub)")
+	setCmd.Flags().StringVar(&orchestratorURL, "orchestrator-url", "", "Orchestrator API URL")
+
+	return setCmd
+}
+
+// presetList describes the environment presets in help text.
+func presetList() string {
+	var b strings.Builder
+	for _, env := range config.Environments() {
+		fmt.Fprintf(&b, "  %-11s hub/builder %s, orchestrator %s\n", env.Name, env.HubURL, env.OrchestratorURL)
+	}
+	return b.String()
+}
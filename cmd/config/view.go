@@ -0,0 +1,64 @@
+package configCmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// ViewCmd prints the resolved configuration of the active profile
+func ViewCmd(globals *cmdutil.Globals) *cobra.Command {
+	var showSecrets bool
+
+	viewCmd := &cobra.Command{
+		Use:   "view",
+		Short: "Show the configuration commands will actually use",
+		Long: `Show the resolved configuration of the active profile (or the one selected
+with --name/--profile), after COZY_* environment overrides and defaults are
+applied, along with where each value came from.
+
+Tokens and passwords are redacted unless --show-secrets is given.
+
+Example:
+  cozyctl config view
+  COZY_ORCHESTRATOR_URL=http://localhost:9000 cozyctl config view
+  cozyctl config view --name briheet --profile dev -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := globals.OutputFormat()
+
+			resolved, err := config.ResolveConfig(globals.ProfileRef())
+			if err != nil {
+				return err
+			}
+			if !showSecrets {
+				resolved.RedactSecrets()
+			}
+
+			if format.Structured() {
+				return ui.WriteStructured(os.Stdout, format, resolved)
+			}
+
+			fmt.Printf("Profile: %s/%s\n", resolved.Name, resolved.Profile)
+			fmt.Printf("File:    %s\n\n", resolved.Path)
+
+			table := &ui.Table{Columns: []string{"KEY", "VALUE", "SOURCE"}}
+			for _, s := range resolved.Settings {
+				value := s.Value
+				if value == "" {
+					value = "-"
+				}
+				table.Rows = append(table.Rows, ui.Row{Key: s.Key, Cells: []string{s.Key, value, s.Source}})
+			}
+			return table.Write(os.Stdout)
+		},
+	}
+
+	viewCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show tokens and passwords instead of redacting them")
+
+	return viewCmd
+}
@@ -1,33 +1,395 @@
 package deploy
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
 	"github.com/cozy-creator/cozyctl/internal/deploy"
+	"github.com/cozy-creator/cozyctl/internal/smoke"
+	"github.com/cozy-creator/cozyctl/internal/ui"
 	"github.com/spf13/cobra"
 )
 
-func DeployCmd() *cobra.Command {
+type deployOptions struct {
+	fromBuild  string
+	deployment string
+	localBuild bool
+	all        bool
+	queue      bool
+	dir        string
+	registry   string
+	functions  string
+	minWorkers int
+	maxWorkers int
+	priority   string
+	parallel   int
+
+	checkEntrypoint bool
+	checkDuration   time.Duration
+
+	policies []string
+
+	smokeTest     string
+	smokeExpect   string
+	smokeTimeout  time.Duration
+	smokeRollback bool
+
+	rollback cmdutil.RollbackFlags
+
+	waitForApproval bool
+
+	confirmFunctionChanges bool
+
+	dryRun      bool
+	summaryFile string
+	progress    string
+}
+
+func DeployCmd(globals *cmdutil.Globals) *cobra.Command {
+	opts := &deployOptions{}
+
 	deployCmd := &cobra.Command{
-		Use:   "deploy <build-id>",
+		Use:   "deploy [build-id]",
 		Short: "Deploy a build via cozy-hub",
 		Long: `Deploy a previously built image using its build ID.
 
 Cozy-hub will promote the build and register the deployment with the orchestrator.
+No packaging or rebuilding takes place.
 
 This command will:
 1. Read tenant-id from your config
-2. Send build-id to cozy-hub
-3. Cozy-hub promotes the build, registers with orchestrator
+2. Verify the build finished successfully
+3. Send build-id (and optional target deployment) to cozy-hub
+4. Cozy-hub promotes the build, registers with orchestrator
+
+The build ID can be given with --from-build or as a positional argument.
+Use --deployment to deploy the build to a specific named deployment.
+
+With --local-build, the project is instead built with your local Docker daemon,
+pushed to the registry configured in your profile (or --registry), and the
+deployment is created or updated with the pushed image. The server-side builder
+is bypassed entirely. Add --check-entrypoint to run the built image locally for
+a few seconds before pushing, catching import errors and missing dependencies
+and verifying the detected functions are registered.
+
+With --smoke-test function:payload.json, once the deployment reports ready the
+function is invoked with the payload and must return a 2xx response (and match
+--smoke-expect, a JSONPath expression such as '$.status == "ok"', if given).
+The invocation may take up to --smoke-timeout (default 5m), since it often
+includes the model's cold start. A failed smoke test fails the deploy;
+--smoke-rollback also restores the previous build.
+
+With --auto-rollback, the rollout is watched for --rollback-window (default
+5m). If the deployment reports failure, its error rate exceeds
+--rollback-error-rate percent (default 5), or no worker becomes ready within
+the window, the previous build (or image, with --local-build) is restored and
+a report of why is printed, including any worker crashes.
+
+If the deployment requires approval (typically production), the deploy is
+submitted as a pending change instead: its approval ID and review URL are
+printed, and the build goes live once an authorized user runs 'cozyctl
+approvals approve <id>'. With --wait-for-approval, the command waits for
+the decision and then continues with any smoke test and rollout watch; a
+rejection fails the deploy.
+
+With --all, --dir is a workspace root whose pyproject.toml lists member
+projects under [tool.cozy.workspace] members. Each member is built on the
+server and deployed to its own deployment-id, after the members named in its
+[tool.cozy] depends-on list. If a member fails, the members that depend on it
+are skipped and the rest still deploy; a summary of every member is printed
+at the end. --priority sets the builder queue priority of those builds.
+With --parallel N, up to N members are built at once, their output lines
+prefixed with the member's name; a member still deploys only after the
+members it depends on, and the summary table shows each member's duration.
+
+With --queue, the project at --dir is packaged, built on the server, and
+deployed to its deployment-id. If cozy-hub can't be reached, the package and
+the deploy request are queued locally instead and the command succeeds; run
+'cozyctl flush' once you're back online to submit them. Useful on flaky
+connections, e.g. while travelling or demoing.
+
+The deployment request is checked against the project's [tool.cozy.policy]
+Rego policies (and, with --local-build, any --policy paths) before it is
+submitted; a denial aborts the deploy (see 'cozyctl policy --help').
+
+A pre-deploy command under [tool.cozy.hooks] in pyproject.toml runs before
+--local-build and --all deploys (and 'cozyctl update'), with the deploy's context in COZY_*
+environment variables; a non-zero exit aborts the deploy.
+
+With --local-build, the resolved functions are compared with those
+registered on an existing deployment before anything is built, and a
+warning lists any the deploy adds or removes; removing a function takes its
+endpoint offline. With --confirm-function-changes, the deploy asks for
+confirmation when the functions change and stops unless it is given.
+
+With --local-build --dry-run, nothing is built, pushed, or deployed: the
+generated Dockerfile, the exact CreateDeployment/UpdateDeployment payload, and
+the manifest of files in the project archive are written to .cozy/out/ in the
+project directory for review.
+
+Use --progress json to emit newline-delimited JSON progress events (stage,
+percent, message, ids) instead of human-readable output.
+
+With --summary-file, a JSON summary of the result is written when the deploy
+finishes, whether it succeeded or not: status, build ID, image tag,
+deployment ID, the invoke URL of each function, the duration of each phase,
+and any warnings, for later pipeline steps and release dashboards. With
+-o json or -o yaml, the same summary is printed to stdout and progress goes
+to stderr.
 
 Example:
-  cozyctl deploy abc-123-def-456`,
-		Args: cobra.ExactArgs(1),
-		RunE: runDeploy,
+  cozyctl deploy --from-build abc-123-def-456
+  cozyctl deploy --from-build abc-123-def-456 --deployment my-model
+  cozyctl deploy abc-123-def-456
+  cozyctl deploy --local-build --dir ./my-project
+  cozyctl deploy --local-build --dir ./my-project --registry docker.io/myuser/
+  cozyctl deploy --local-build --dir ./my-project --check-entrypoint
+  cozyctl deploy --local-build --dir ./my-project --dry-run
+  cozyctl deploy --local-build --dir ./my-project --confirm-function-changes
+  cozyctl deploy --queue --dir ./my-project
+  cozyctl deploy --all --dir ./my-workspace
+  cozyctl deploy --all --dir ./my-workspace --parallel 4
+  cozyctl deploy --from-build abc-123 --smoke-test generate:sample.json --smoke-expect '$.images[0].url'
+  cozyctl deploy --from-build abc-123 --auto-rollback --rollback-window 10m --rollback-error-rate 2
+  cozyctl deploy --from-build abc-123 --deployment prod-model --wait-for-approval
+  cozyctl deploy --from-build abc-123 --summary-file deploy-summary.json
+  cozyctl deploy --from-build abc-123 -o json | jq -r '.endpoints[]'`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.dryRun {
+				if err := globals.CheckWritable(cmd); err != nil {
+					return err
+				}
+			}
+			return runDeploy(cmd.Context(), globals, opts, args)
+		},
 	}
 
+	deployCmd.Flags().StringVar(&opts.fromBuild, "from-build", "", "ID of an existing successful build to deploy")
+	deployCmd.Flags().StringVar(&opts.deployment, "deployment", "", "Target deployment ID (with --from-build)")
+	deployCmd.Flags().BoolVar(&opts.localBuild, "local-build", false, "Build the image locally with Docker, push it, and deploy it")
+	deployCmd.Flags().BoolVar(&opts.all, "all", false, "Build and deploy every project of the workspace at --dir, in depends-on order")
+	deployCmd.Flags().BoolVar(&opts.queue, "queue", false, "Build the project at --dir on the server and deploy it, queueing the deploy for 'cozyctl flush' if cozy-hub is unreachable")
+	deployCmd.Flags().StringVarP(&opts.dir, "dir", "d", ".", "Project directory (with --local-build or --queue) or workspace root (with --all)")
+	deployCmd.Flags().StringVar(&opts.registry, "registry", "", "Registry prefix to push to (overrides registry_prefix in profile)")
+	deployCmd.Flags().StringVar(&opts.functions, "functions", "", "Comma-separated function specs (e.g., 'generate:true,health:false')")
+	deployCmd.Flags().IntVar(&opts.minWorkers, "min-workers", -1, "Minimum number of workers (-1 = server default)")
+	deployCmd.Flags().IntVar(&opts.maxWorkers, "max-workers", -1, "Maximum number of workers (-1 = server default)")
+	deployCmd.Flags().StringVar(&opts.priority, "priority", "", "Queue priority of the server builds: high, normal, or low (with --all or --queue)")
+	deployCmd.Flags().IntVar(&opts.parallel, "parallel", 1, "Workspace members built and deployed at once (with --all)")
+
+	deployCmd.Flags().StringSliceVar(&opts.policies, "policy", nil, "Rego policy file or directory to check the deployment request against (with --local-build; repeatable)")
+	deployCmd.Flags().BoolVar(&opts.checkEntrypoint, "check-entrypoint", false, "Run the image locally before pushing to verify the worker starts (with --local-build)")
+	deployCmd.Flags().DurationVar(&opts.checkDuration, "check-duration", build.DefaultEntrypointCheckDuration, "How long to run the entrypoint during --check-entrypoint")
+	deployCmd.Flags().StringVar(&opts.smokeTest, "smoke-test", "", "Invoke function:payload.json after deploy and require a 2xx response")
+	deployCmd.Flags().StringVar(&opts.smokeExpect, "smoke-expect", "", "JSONPath the smoke test response must match (e.g. '$.status == \"ok\"')")
+	deployCmd.Flags().DurationVar(&opts.smokeTimeout, "smoke-timeout", smoke.DefaultInvokeTimeout, "How long the smoke test invocation may take, including a cold start")
+	deployCmd.Flags().BoolVar(&opts.smokeRollback, "smoke-rollback", false, "Roll back to the previous build if the smoke test fails")
+	opts.rollback.Register(deployCmd)
+	deployCmd.Flags().BoolVar(&opts.waitForApproval, "wait-for-approval", false, "If the deployment requires approval, wait until the deploy is approved or rejected")
+	deployCmd.Flags().BoolVar(&opts.confirmFunctionChanges, "confirm-function-changes", false, "Ask for confirmation before adding or removing the deployment's functions (with --local-build)")
+	deployCmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Write the Dockerfile, request payload, and archive manifest to .cozy/out/ instead of deploying (with --local-build)")
+	deployCmd.Flags().StringVar(&opts.summaryFile, "summary-file", "", "Write a JSON summary of the result (IDs, endpoints, phase durations, warnings) to this file")
+	deployCmd.Flags().StringVar(&opts.progress, "progress", "auto", "Progress output: auto, plain, or json")
+
 	return deployCmd
 }
 
-func runDeploy(cmd *cobra.Command, args []string) error {
-	buildID := args[0]
-	return deploy.Run(buildID)
+func runDeploy(ctx context.Context, globals *cmdutil.Globals, opts *deployOptions, args []string) error {
+	progressMode, err := ui.ParseMode(opts.progress)
+	if err != nil {
+		return err
+	}
+
+	var smokeTest *smoke.Test
+	if opts.smokeTest != "" {
+		smokeTest, err = smoke.ParseSpec(opts.smokeTest, opts.smokeExpect)
+		if err != nil {
+			return err
+		}
+		smokeTest.Timeout = opts.smokeTimeout
+	} else if opts.smokeExpect != "" || opts.smokeRollback {
+		return fmt.Errorf("--smoke-expect and --smoke-rollback require --smoke-test")
+	}
+
+	autoRollback, err := opts.rollback.Policy()
+	if err != nil {
+		return err
+	}
+
+	priority, err := api.ParseBuildPriority(opts.priority)
+	if err != nil {
+		return err
+	}
+	if priority != "" && !opts.all && !opts.queue {
+		return fmt.Errorf("--priority requires --all or --queue (other deploys don't build on the server)")
+	}
+	if opts.parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+	if opts.parallel > 1 && !opts.all {
+		return fmt.Errorf("--parallel requires --all")
+	}
+
+	output := globals.OutputFormat()
+
+	if opts.all {
+		switch {
+		case len(args) > 0 || opts.fromBuild != "":
+			return fmt.Errorf("a build ID cannot be combined with --all")
+		case opts.localBuild:
+			return fmt.Errorf("--local-build cannot be combined with --all")
+		case opts.queue:
+			return fmt.Errorf("--queue cannot be combined with --all")
+		case opts.deployment != "":
+			return fmt.Errorf("--deployment cannot be combined with --all (each project deploys to its own deployment-id)")
+		case smokeTest != nil:
+			return fmt.Errorf("--smoke-test cannot be combined with --all")
+		case opts.dryRun:
+			return fmt.Errorf("--dry-run requires --local-build")
+		case len(opts.policies) > 0:
+			return fmt.Errorf("--policy requires --local-build")
+		case opts.summaryFile != "":
+			return fmt.Errorf("--summary-file cannot be combined with --all")
+		case opts.waitForApproval:
+			return fmt.Errorf("--wait-for-approval cannot be combined with --all")
+		case opts.confirmFunctionChanges:
+			return fmt.Errorf("--confirm-function-changes requires --local-build")
+		case output.Structured():
+			return fmt.Errorf("--output cannot be combined with --all")
+		}
+		return deploy.RunAll(ctx, deploy.AllOptions{
+			Profile:      globals.ProfileRef(),
+			Transport:    globals.Transport(),
+			Root:         opts.dir,
+			AutoRollback: autoRollback,
+			Build:        api.BuildOptions{Priority: priority},
+			Parallel:     opts.parallel,
+			Progress:     progressMode,
+		})
+	}
+
+	if opts.queue {
+		switch {
+		case len(args) > 0 || opts.fromBuild != "":
+			return fmt.Errorf("a build ID cannot be combined with --queue")
+		case opts.localBuild:
+			return fmt.Errorf("--local-build cannot be combined with --queue")
+		case opts.deployment != "":
+			return fmt.Errorf("--deployment cannot be combined with --queue (set deployment-id in pyproject.toml)")
+		case smokeTest != nil:
+			return fmt.Errorf("--smoke-test cannot be combined with --queue")
+		case opts.checkEntrypoint:
+			return fmt.Errorf("--check-entrypoint requires --local-build")
+		case opts.dryRun:
+			return fmt.Errorf("--dry-run requires --local-build")
+		case len(opts.policies) > 0:
+			return fmt.Errorf("--policy requires --local-build")
+		case opts.summaryFile != "":
+			return fmt.Errorf("--summary-file cannot be combined with --queue")
+		case opts.waitForApproval:
+			return fmt.Errorf("--wait-for-approval cannot be combined with --queue")
+		case opts.confirmFunctionChanges:
+			return fmt.Errorf("--confirm-function-changes requires --local-build")
+		case output.Structured():
+			return fmt.Errorf("--output cannot be combined with --queue")
+		}
+		return deploy.RunQueued(ctx, deploy.QueuedOptions{
+			Profile:      globals.ProfileRef(),
+			Transport:    globals.Transport(),
+			ProjectPath:  opts.dir,
+			Build:        api.BuildOptions{Priority: priority},
+			AutoRollback: autoRollback,
+			Progress:     progressMode,
+		})
+	}
+
+	if opts.localBuild {
+		if len(args) > 0 || opts.fromBuild != "" {
+			return fmt.Errorf("a build ID cannot be combined with --local-build")
+		}
+		if opts.deployment != "" {
+			return fmt.Errorf("--deployment cannot be combined with --local-build (set deployment-id in pyproject.toml)")
+		}
+		if opts.waitForApproval {
+			return fmt.Errorf("--wait-for-approval cannot be combined with --local-build (local builds deploy to the orchestrator directly)")
+		}
+		if opts.dryRun && opts.summaryFile != "" {
+			return fmt.Errorf("--summary-file cannot be combined with --dry-run")
+		}
+		if opts.dryRun && output.Structured() {
+			return fmt.Errorf("--output cannot be combined with --dry-run")
+		}
+		return deploy.RunLocalBuild(ctx, deploy.LocalBuildOptions{
+			Profile:     globals.ProfileRef(),
+			Transport:   globals.Transport(),
+			ProjectPath: opts.dir,
+			Registry:    opts.registry,
+			Functions:   opts.functions,
+			MinWorkers:  opts.minWorkers,
+			MaxWorkers:  opts.maxWorkers,
+
+			CheckEntrypoint: opts.checkEntrypoint,
+			CheckDuration:   opts.checkDuration,
+
+			Policies: opts.policies,
+
+			ConfirmFunctionChanges: opts.confirmFunctionChanges,
+
+			SmokeTest:     smokeTest,
+			SmokeRollback: opts.smokeRollback,
+			AutoRollback:  autoRollback,
+
+			DryRun:      opts.dryRun,
+			SummaryFile: opts.summaryFile,
+			Output:      output,
+			Progress:    progressMode,
+		})
+	}
+
+	if opts.checkEntrypoint {
+		return fmt.Errorf("--check-entrypoint requires --local-build")
+	}
+	if len(opts.policies) > 0 {
+		return fmt.Errorf("--policy requires --local-build")
+	}
+	if opts.dryRun {
+		return fmt.Errorf("--dry-run requires --local-build")
+	}
+	if opts.confirmFunctionChanges {
+		return fmt.Errorf("--confirm-function-changes requires --local-build")
+	}
+
+	buildID := opts.fromBuild
+	if len(args) > 0 {
+		if buildID != "" && buildID != args[0] {
+			return fmt.Errorf("conflicting build IDs: --from-build %s and argument %s", buildID, args[0])
+		}
+		buildID = args[0]
+	}
+	if buildID == "" {
+		return fmt.Errorf("a build ID is required (use --from-build <id> or --local-build)")
+	}
+
+	return deploy.Run(ctx, deploy.Options{
+		Profile:      globals.ProfileRef(),
+		Transport:    globals.Transport(),
+		BuildID:      buildID,
+		DeploymentID: opts.deployment,
+
+		SmokeTest:     smokeTest,
+		SmokeRollback: opts.smokeRollback,
+		AutoRollback:  autoRollback,
+
+		WaitForApproval: opts.waitForApproval,
+
+		SummaryFile: opts.summaryFile,
+		Output:      output,
+		Progress:    progressMode,
+	})
 }
@@ -0,0 +1,56 @@
+package deployments
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// CompareCmd diffs the specs of two deployments
+func CompareCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		opts     deployments.CompareOptions
+		profileB string
+	)
+
+	compareCmd := &cobra.Command{
+		Use:   "compare <deployment-a> <deployment-b>",
+		Short: "Show where two deployments' specs differ",
+		Long: `Compare two deployments field by field: image, worker counts, each
+function and whether it needs a GPU or is disabled, supported models, and
+each secret variable. Only differing fields are shown unless --all is given.
+
+Secrets are compared by variable name and whether they map to the same
+secret; what they map to is never shown.
+
+Use --profile-b when the second deployment lives under another profile,
+e.g. staging and production on separate accounts.
+
+Example:
+  cozyctl deployments compare my-model-staging my-model
+  cozyctl deployments compare my-model my-model --profile work/staging --profile-b work/prod
+  cozyctl deployments compare my-model-staging my-model --all -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if profileB != "" {
+				ref, err := config.ParseProfileRef(profileB)
+				if err != nil {
+					return err
+				}
+				opts.ProfileB = &ref
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.A, opts.B = args[0], args[1]
+			opts.Output = globals.OutputFormat()
+			return deployments.Compare(opts)
+		},
+	}
+
+	compareCmd.Flags().StringVar(&profileB, "profile-b", "", "Look up the second deployment with this name/profile")
+	compareCmd.Flags().BoolVar(&opts.All, "all", false, "Show fields that are the same too")
+
+	return compareCmd
+}
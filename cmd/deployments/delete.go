@@ -0,0 +1,46 @@
+package deployments
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// DeleteCmd deletes a deployment
+func DeleteCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts deployments.DeleteOptions
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete <deployment-id>",
+		Short: "Delete a deployment",
+		Long: `Delete a deployment from the orchestrator, stopping its workers. Its builds
+stay in cozy-hub, so it can be deployed again from one of them.
+
+Before deleting, what goes with the deployment is listed: its snapshots,
+traffic split, and rebuild schedule, and the notification rules and API keys
+scoped to it, which are kept but no longer do anything. You are asked to
+confirm unless --yes is given; --dry-run shows the list and stops.
+
+Example:
+  cozyctl deployments delete my-model --dry-run
+  cozyctl deployments delete my-model
+  cozyctl deployments delete my-model --yes`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.DryRun {
+				if err := globals.CheckWritable(cmd); err != nil {
+					return err
+				}
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.DeploymentID = args[0]
+			return deployments.Delete(opts)
+		},
+	}
+
+	deleteCmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Delete without prompting")
+	deleteCmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show what would be deleted without deleting anything")
+
+	return deleteCmd
+}
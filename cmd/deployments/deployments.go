@@ -0,0 +1,31 @@
+package deployments
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// DeploymentsCmd groups commands that inspect and manage deployments
+func DeploymentsCmd(globals *cmdutil.Globals) *cobra.Command {
+	deploymentsCmd := &cobra.Command{
+		Use:     "deployments",
+		Aliases: []string{"deployment"},
+		Short:   "Inspect and manage deployments",
+	}
+
+	deploymentsCmd.AddCommand(ListCmd(globals))
+	deploymentsCmd.AddCommand(GetCmd(globals))
+	deploymentsCmd.AddCommand(DescribeCmd(globals))
+	deploymentsCmd.AddCommand(HistoryCmd(globals))
+	deploymentsCmd.AddCommand(DeleteCmd(globals))
+	deploymentsCmd.AddCommand(CompareCmd(globals))
+	deploymentsCmd.AddCommand(TransferCmd(globals))
+	deploymentsCmd.AddCommand(ExportCmd(globals))
+	deploymentsCmd.AddCommand(SnapshotCmd(globals))
+	deploymentsCmd.AddCommand(SnapshotsCmd(globals))
+	deploymentsCmd.AddCommand(RestoreCmd(globals))
+	deploymentsCmd.AddCommand(LockCmd(globals))
+	deploymentsCmd.AddCommand(UnlockCmd(globals))
+
+	return deploymentsCmd
+}
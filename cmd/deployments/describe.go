@@ -0,0 +1,41 @@
+package deployments
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// DescribeCmd shows the full spec of a deployment
+func DescribeCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	describeCmd := &cobra.Command{
+		Use:   "describe <deployment-id>",
+		Short: "Show the full spec of a deployment",
+		Long: `Show a deployment's status, image, worker counts, functions, supported
+models, and secret mappings. Each function shows its invocations, errors,
+average latency, and last invocation, and functions never invoked are listed
+as possibly safe to remove.
+
+The default view summarizes long lists; --output wide shows everything with
+full timestamps, and --output json or yaml dumps the complete spec for tooling.
+
+Example:
+  cozyctl deployments describe my-model
+  cozyctl deployments describe my-model --output wide
+  cozyctl deployments describe my-model -o json | jq '.function_requirements'`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			return deployments.Describe(deployments.DescribeOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Output:       globals.OutputFormat(),
+			})
+		},
+	}
+
+	return describeCmd
+}
@@ -0,0 +1,47 @@
+package deployments
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// ExportCmd renders deployments as infrastructure-as-code
+func ExportCmd(globals *cmdutil.Globals) *cobra.Command {
+	opts := deployments.ExportOptions{}
+
+	exportCmd := &cobra.Command{
+		Use:   "export [deployment-id...]",
+		Short: "Export deployments as Terraform configuration",
+		Long: `Render deployments, including their functions, supported models, and secret
+mappings, as HCL for the cozy Terraform provider. Each resource comes with an
+import block, so 'terraform plan' adopts the existing deployment instead of
+creating a new one. Secret mappings name the secrets; their values are never
+exported.
+
+Example:
+  cozyctl deployments export my-model --format terraform > my-model.tf
+  cozyctl deployments export --all --format terraform > deployments.tf`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.All && len(args) > 0 {
+				return fmt.Errorf("deployment IDs cannot be combined with --all")
+			}
+			if !opts.All && len(args) == 0 {
+				return fmt.Errorf("a deployment ID or --all is required")
+			}
+
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.DeploymentIDs = args
+			return deployments.Export(opts)
+		},
+	}
+
+	exportCmd.Flags().StringVar(&opts.Format, "format", deployments.FormatTerraform, "Export format: "+strings.Join(deployments.ExportFormats, ", "))
+	exportCmd.Flags().BoolVar(&opts.All, "all", false, "Export every deployment")
+
+	return exportCmd
+}
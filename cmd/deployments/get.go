@@ -0,0 +1,37 @@
+package deployments
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// GetCmd shows a single deployment
+func GetCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	getCmd := &cobra.Command{
+		Use:   "get <deployment-id>",
+		Short: "Show a deployment's status and worker counts",
+		Long: `Show one deployment as a row of 'cozyctl deployments list': status, ready
+and min-max workers, function count, and when it was last updated. Use
+'cozyctl deployments describe' for the full spec.
+
+Example:
+  cozyctl deployments get my-model
+  cozyctl deployments get my-model -o wide    # Also the image URL
+  cozyctl deployments get my-model -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			return deployments.Get(deployments.GetOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Output:       globals.OutputFormat(),
+			})
+		},
+	}
+
+	return getCmd
+}
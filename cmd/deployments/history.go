@@ -0,0 +1,52 @@
+package deployments
+
+import (
+	"fmt"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// HistoryCmd lists the builds that have been active on a deployment
+func HistoryCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		limit int
+	)
+
+	historyCmd := &cobra.Command{
+		Use:   "history <deployment-id>",
+		Short: "List every build that has been active on a deployment",
+		Long: `List a deployment's revisions, newest first: each build and image that has
+been active on it, whether it was deployed, approved, or rolled back to, who
+deployed it and when, and whether it is still active, was superseded by a
+later deploy, or was rolled back.
+
+Every revision is fetched, page by page; --limit stops after the newest N.
+-o json or yaml prints them for audit pipelines.
+
+Example:
+  cozyctl deployments history my-model
+  cozyctl deployments history my-model --limit 5 -o wide
+  cozyctl deployments history my-model -o json > my-model-revisions.json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if limit < 0 {
+				return fmt.Errorf("--limit must be positive")
+			}
+
+			return deployments.History(deployments.HistoryOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Limit:        limit,
+				Output:       globals.OutputFormat(),
+			})
+		},
+	}
+
+	historyCmd.Flags().IntVar(&limit, "limit", 0, "Only list the newest N revisions (0 lists all)")
+
+	return historyCmd
+}
@@ -0,0 +1,101 @@
+package deployments
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// sortFields maps --sort values to the server's sort fields.
+var sortFields = map[string]string{
+	"name":    "name",
+	"created": "created_at",
+	"updated": "updated_at",
+}
+
+// ListCmd lists deployments
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		query api.ListDeploymentsOptions
+		sort  string
+		desc  bool
+		watch bool
+		every time.Duration
+	)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List deployments",
+		Long: `List the tenant's deployments, one page at a time.
+
+Filtering, sorting, and paging happen on the server. Use --page to jump to a
+page, or --cursor with the value printed under the table to fetch the next one.
+
+With --watch the page is refreshed every --interval, highlighting deployments
+that are new or whose status changed, until interrupted.
+
+Example:
+  cozyctl deployments list
+  cozyctl deployments list --name-filter sdxl --sort updated --desc
+  cozyctl deployments list --limit 20 --page 3
+  cozyctl deployments list --cursor offset-50 -o json
+  cozyctl deployments list --watch --interval 5s`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if sort != "" {
+				field, ok := sortFields[sort]
+				if !ok {
+					return fmt.Errorf("invalid --sort %q (must be name, created, or updated)", sort)
+				}
+				query.Sort = field
+			}
+			if desc {
+				if query.Sort == "" {
+					return fmt.Errorf("--desc requires --sort")
+				}
+				query.Sort = "-" + query.Sort
+			}
+			if query.Limit < 0 || query.Page < 0 {
+				return fmt.Errorf("--limit and --page must be positive")
+			}
+
+			if watch && every <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			listOpts := deployments.ListOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Query:     query,
+				Output:    globals.OutputFormat(),
+			}
+			if watch {
+				listOpts.Watch = every
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return deployments.List(ctx, listOpts)
+		},
+	}
+
+	listCmd.Flags().IntVar(&query.Limit, "limit", 50, "Maximum number of deployments per page")
+	listCmd.Flags().IntVar(&query.Page, "page", 0, "Page number to fetch (1-based)")
+	listCmd.Flags().StringVar(&query.Cursor, "cursor", "", "Cursor from a previous page")
+	listCmd.Flags().StringVar(&query.NameFilter, "name-filter", "", "Only list deployments whose ID or name contains this")
+	listCmd.Flags().StringVar(&sort, "sort", "", "Sort by name, created, or updated")
+	listCmd.Flags().BoolVar(&desc, "desc", false, "Sort in descending order")
+	listCmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep refreshing the list, highlighting status changes")
+	listCmd.Flags().DurationVar(&every, "interval", 2*time.Second, "Refresh interval for --watch")
+	listCmd.MarkFlagsMutuallyExclusive("page", "cursor")
+
+	return listCmd
+}
@@ -0,0 +1,78 @@
+package deployments
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// LockCmd protects a deployment from changes
+func LockCmd(globals *cmdutil.Globals) *cobra.Command {
+	var reason string
+
+	lockCmd := &cobra.Command{
+		Use:   "lock <deployment-id> --reason <reason>",
+		Short: "Protect a deployment from updates, scaling, and deletes",
+		Long: `Lock a deployment on the orchestrator, e.g. for a production freeze. While
+it is locked, the orchestrator refuses to update, scale, or delete it, for
+everyone, unless the request gives a reason with --unlock-reason; those
+changes are recorded in the deployment's events with the reason.
+
+'cozyctl deployments describe' shows the lock, and 'cozyctl deployments
+unlock' removes it.
+
+Example:
+  cozyctl deployments lock my-model --reason "prod freeze"
+  cozyctl update --unlock-reason "hotfix for INC-142"
+  cozyctl deployments unlock my-model --reason "freeze over"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return deployments.Lock(deployments.LockOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Reason:       reason,
+			})
+		},
+	}
+
+	lockCmd.Flags().StringVar(&reason, "reason", "", "Why the deployment is locked (required)")
+	lockCmd.MarkFlagRequired("reason")
+
+	return lockCmd
+}
+
+// UnlockCmd removes a deployment's protection
+func UnlockCmd(globals *cmdutil.Globals) *cobra.Command {
+	var reason string
+
+	unlockCmd := &cobra.Command{
+		Use:   "unlock <deployment-id>",
+		Short: "Allow changes to a locked deployment again",
+		Long: `Remove the lock set with 'cozyctl deployments lock', so the deployment can
+be updated, scaled, and deleted without --unlock-reason. The reason, if
+given, is recorded in the deployment's events.
+
+Example:
+  cozyctl deployments unlock my-model --reason "freeze over"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return deployments.Unlock(deployments.LockOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Reason:       reason,
+			})
+		},
+	}
+
+	unlockCmd.Flags().StringVar(&reason, "reason", "", "Why the deployment is unlocked")
+
+	return unlockCmd
+}
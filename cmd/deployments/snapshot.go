@@ -0,0 +1,105 @@
+package deployments
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// SnapshotCmd saves a copy of a deployment's spec
+func SnapshotCmd(globals *cmdutil.Globals) *cobra.Command {
+	var description string
+
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot <deployment-id>",
+		Short: "Save a copy of a deployment's spec",
+		Long: `Save a copy of a deployment's spec on the orchestrator: its name, functions,
+supported models, secret mappings, and worker counts. The image is not part of
+a snapshot; to go back to an earlier build, use 'cozyctl deploy --from-build'.
+
+Take a snapshot before bulk updates or scripted changes, so a misconfiguration
+can be undone with 'cozyctl deployments restore'.
+
+Example:
+  cozyctl deployments snapshot my-model
+  cozyctl deployments snapshot my-model --description "before scaling test"`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return deployments.Snapshot(deployments.SnapshotOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Description:  description,
+				Output:       globals.OutputFormat(),
+			})
+		},
+	}
+
+	snapshotCmd.Flags().StringVar(&description, "description", "", "Note to keep with the snapshot")
+
+	return snapshotCmd
+}
+
+// SnapshotsCmd lists a deployment's snapshots
+func SnapshotsCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	snapshotsCmd := &cobra.Command{
+		Use:   "snapshots <deployment-id>",
+		Short: "List a deployment's snapshots",
+		Long: `List the snapshots saved for a deployment, newest first.
+
+Example:
+  cozyctl deployments snapshots my-model
+  cozyctl deployments snapshots my-model -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			return deployments.Snapshots(deployments.SnapshotsOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Output:       globals.OutputFormat(),
+			})
+		},
+	}
+
+	return snapshotsCmd
+}
+
+// RestoreCmd restores a deployment's spec from a snapshot
+func RestoreCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts deployments.RestoreOptions
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore <deployment-id> --from <snapshot-id>",
+		Short: "Restore a deployment's spec from a snapshot",
+		Long: `Replace a deployment's spec with a snapshot taken by 'cozyctl deployments
+snapshot'. The changes are shown and confirmed before anything is applied; the
+image the deployment runs is left as it is.
+
+Example:
+  cozyctl deployments restore my-model --from snapshot-12
+  cozyctl deployments restore my-model --from snapshot-12 --yes`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.DeploymentID = args[0]
+			return deployments.Restore(opts)
+		},
+	}
+
+	restoreCmd.Flags().StringVar(&opts.SnapshotID, "from", "", "Snapshot ID to restore (required)")
+	restoreCmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Restore without prompting")
+	restoreCmd.MarkFlagRequired("from")
+
+	return restoreCmd
+}
@@ -0,0 +1,48 @@
+package deployments
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// TransferCmd moves a deployment to another tenant
+func TransferCmd(globals *cmdutil.Globals) *cobra.Command {
+	var toTenant string
+	var yes bool
+
+	transferCmd := &cobra.Command{
+		Use:   "transfer <deployment-id> --to-tenant <tenant>",
+		Short: "Move a deployment to another tenant",
+		Long: `Move a deployment to another tenant, together with its build history and
+endpoint configuration. Use this when teams are reorganized and a deployment
+changes owners; its ID and endpoints stay the same.
+
+The orchestrator first opens a pending transfer and reports what will move.
+Nothing changes until the transfer is confirmed; declining the prompt
+withdraws it, and unconfirmed transfers expire on their own.
+
+Example:
+  cozyctl deployments transfer my-model --to-tenant research-team
+  cozyctl deployments transfer my-model --to-tenant research-team --yes`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return deployments.Transfer(deployments.TransferOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				ToTenantID:   toTenant,
+				Yes:          yes,
+			})
+		},
+	}
+
+	transferCmd.Flags().StringVar(&toTenant, "to-tenant", "", "Tenant ID to move the deployment to (required)")
+	transferCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Confirm the transfer without prompting")
+	transferCmd.MarkFlagRequired("to-tenant")
+
+	return transferCmd
+}
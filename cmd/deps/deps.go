@@ -0,0 +1,63 @@
+package deps
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deps"
+	"github.com/cozy-creator/cozyctl/internal/pypi"
+	"github.com/spf13/cobra"
+)
+
+// DepsCmd groups commands that inspect a project's dependencies
+func DepsCmd(globals *cmdutil.Globals) *cobra.Command {
+	depsCmd := &cobra.Command{
+		Use:         "deps",
+		Short:       "Inspect a project's base image and Python dependencies",
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+	}
+
+	depsCmd.AddCommand(OutdatedCmd(globals))
+
+	return depsCmd
+}
+
+// OutdatedCmd lists dependencies with newer releases or advisories
+func OutdatedCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts deps.OutdatedOptions
+
+	outdatedCmd := &cobra.Command{
+		Use:   "outdated [dir]",
+		Short: "List base image and dependency updates, most urgent first",
+		Long: `Check whether the project's base image and direct Python dependencies have
+newer releases, and whether the versions it pins have security advisories.
+
+The base image is the one 'cozyctl build' would use; newer tags of the same
+shape are looked up on Docker Hub (python:3.13-slim for python:3.11-slim).
+Dependency versions come from the project's lock file, or its pins in
+pyproject.toml, and are checked against PyPI. Updates are listed by priority:
+security, major, minor, patch, then unpinned dependencies.
+
+Useful as a scheduled job for long-lived deployments.
+
+Example:
+  cozyctl deps outdated
+  cozyctl deps outdated ./my-project --all -o wide
+  cozyctl deps outdated --fail-on-advisories -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.ProjectDir = "."
+			if len(args) == 1 {
+				opts.ProjectDir = args[0]
+			}
+			opts.Output = globals.OutputFormat()
+			return deps.Outdated(cmd.Context(), opts)
+		},
+	}
+
+	outdatedCmd.Flags().BoolVar(&opts.All, "all", false, "Also list dependencies that are up to date")
+	outdatedCmd.Flags().BoolVar(&opts.FailOnAdvisories, "fail-on-advisories", false, "Exit non-zero if a pinned version has security advisories")
+	outdatedCmd.Flags().StringVar(&opts.IndexURL, "index-url", pypi.DefaultIndexURL, "PyPI JSON API to check dependencies on")
+	outdatedCmd.Flags().StringVar(&opts.DockerHubURL, "docker-hub-url", deps.DefaultDockerHubURL, "Docker Hub API to check the base image on")
+
+	return outdatedCmd
+}
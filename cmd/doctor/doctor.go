@@ -0,0 +1,39 @@
+package doctor
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+// DoctorCmd checks that this machine supports cozyctl
+func DoctorCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		opts doctor.Options
+	)
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that this machine supports cozyctl",
+		Long: `Check the environment cozyctl runs in: whether the platform is supported,
+the config directory (~/.cozy, or %USERPROFILE%\.cozy on Windows) is
+writable, the terminal supports live views and hidden password input, and
+the tools some commands call (docker, git) are installed.
+
+Missing tools and unsupported environments are warnings; the command fails
+only when cozyctl cannot work at all, e.g. without a writable config
+directory. Run 'cozyctl env doctor' to check a project's Python setup.
+
+Example:
+  cozyctl doctor
+  cozyctl doctor -o json`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{cmdutil.GlobalOutput: "", cmdutil.SkipTokenCheck: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Output = globals.OutputFormat()
+			return doctor.Run(opts)
+		},
+	}
+
+	return doctorCmd
+}
@@ -0,0 +1,61 @@
+package env
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+// EnvCmd groups commands that inspect a project's local environment
+func EnvCmd(globals *cmdutil.Globals) *cobra.Command {
+	envCmd := &cobra.Command{
+		Use:         "env",
+		Short:       "Inspect a project's local Python environment",
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+	}
+
+	envCmd.AddCommand(DoctorCmd(globals))
+
+	return envCmd
+}
+
+// DoctorCmd checks a project's Python setup against what its deploys run
+func DoctorCmd(globals *cmdutil.Globals) *cobra.Command {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor [dir]",
+		Short: "Check a project's Python version, dependencies, and functions",
+		Long: `Check the project in dir (default: the current directory) for problems that
+otherwise surface during a build or deploy:
+
+  - the base image's Python doesn't satisfy requires-python
+  - the local Python (.python-version, or python3 on the PATH) differs from
+    the one deploys run
+  - uv isn't installed
+  - gen-worker, the worker runtime, isn't a dependency
+  - the lock file (uv.lock, requirements.lock, or requirements.txt) is
+    missing or out of date with pyproject.toml
+  - functions declared in [tool.cozy.functions] aren't defined with
+    @worker_function() in the source
+
+Each problem is printed with the command that fixes it. Mismatches that only
+affect local runs are warnings; the command fails on problems that break a
+deploy.
+
+Run 'cozyctl doctor' to check the machine itself.
+
+Example:
+  cozyctl env doctor
+  cozyctl env doctor ./my-project -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := doctor.ProjectOptions{Dir: ".", Output: globals.OutputFormat()}
+			if len(args) == 1 {
+				opts.Dir = args[0]
+			}
+			return doctor.RunProject(opts)
+		},
+	}
+
+	return doctorCmd
+}
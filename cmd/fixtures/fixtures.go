@@ -0,0 +1,184 @@
+package fixtures
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/fixtures"
+	"github.com/spf13/cobra"
+)
+
+// FixturesCmd groups commands that manage sample payloads for regression testing
+func FixturesCmd(globals *cmdutil.Globals) *cobra.Command {
+	fixturesCmd := &cobra.Command{
+		Use:   "fixtures",
+		Short: "Replay sample payloads against deployments and compare outputs",
+		Long: `Fixtures are named sample payloads for a project's worker functions, stored
+in .cozy/fixtures/<function>/<name>.json so they can be committed with the
+project. Running them records each output under a label (by default the
+deployment's image tag), and outputs of two runs can be diffed to catch
+regressions between builds.`,
+	}
+
+	fixturesCmd.AddCommand(AddCmd())
+	fixturesCmd.AddCommand(ListCmd(globals))
+	fixturesCmd.AddCommand(RunCmd(globals))
+	fixturesCmd.AddCommand(DiffCmd(globals))
+
+	return fixturesCmd
+}
+
+// AddCmd stores a sample payload as a named fixture
+func AddCmd() *cobra.Command {
+	var opts fixtures.AddOptions
+	var payloadFile, data string
+
+	addCmd := &cobra.Command{
+		Use:   "add <function> <name>",
+		Short: "Save a sample payload for a function",
+		Long: `Save a JSON payload as a named fixture for a worker function. The payload is
+read from --payload (a file, or - for stdin) or given inline with --data; with
+neither, the fixture is an empty object.
+
+Example:
+  cozyctl fixtures add generate cat --data '{"prompt": "a cat in a hat"}'
+  cozyctl fixtures add generate portrait --payload ./samples/portrait.json
+  cat request.json | cozyctl fixtures add upscale large --payload -`,
+		Args:        cobra.ExactArgs(2),
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case payloadFile != "" && data != "":
+				return fmt.Errorf("--payload and --data are mutually exclusive")
+			case payloadFile == "-":
+				payload, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return fmt.Errorf("failed to read payload: %w", err)
+				}
+				opts.Payload = payload
+			case payloadFile != "":
+				payload, err := os.ReadFile(payloadFile)
+				if err != nil {
+					return fmt.Errorf("failed to read payload: %w", err)
+				}
+				opts.Payload = payload
+			default:
+				opts.Payload = []byte(data)
+			}
+			opts.Function, opts.Name = args[0], args[1]
+			return fixtures.Add(opts)
+		},
+	}
+
+	addCmd.Flags().StringVarP(&opts.ProjectDir, "dir", "d", ".", "Project directory")
+	addCmd.Flags().StringVar(&payloadFile, "payload", "", "File to read the JSON payload from (- for stdin)")
+	addCmd.Flags().StringVar(&data, "data", "", "JSON payload")
+	addCmd.Flags().BoolVar(&opts.Force, "force", false, "Replace an existing fixture")
+
+	return addCmd
+}
+
+// ListCmd lists a project's fixtures
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts fixtures.ListOptions
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List a project's fixtures",
+		Long: `List the project's fixtures and the runs recorded for them.
+
+Example:
+  cozyctl fixtures list
+  cozyctl fixtures list --dir ./my-project -o json`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{cmdutil.GlobalOutput: "", cmdutil.SkipTokenCheck: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Output = globals.OutputFormat()
+			return fixtures.List(opts)
+		},
+	}
+
+	listCmd.Flags().StringVarP(&opts.ProjectDir, "dir", "d", ".", "Project directory")
+
+	return listCmd
+}
+
+// RunCmd invokes fixtures against a deployment and records the outputs
+func RunCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts fixtures.RunOptions
+
+	runCmd := &cobra.Command{
+		Use:   "run <deployment-id> [function[/name]...]",
+		Short: "Invoke fixtures and compare outputs with an earlier run",
+		Long: `Invoke each fixture (or only those named) against a deployment and record
+the outputs in .cozy/fixtures/.runs/<label>/. The label defaults to the image
+tag the deployment is running, so each build's outputs are kept apart; runs
+against a local dev stack (--local) are labeled "local".
+
+With --against, outputs are compared with a recorded run and the command
+fails if any changed. Fields that differ on every run, such as seeds or
+timings, can be left out of the comparison with --ignore.
+
+--local invokes the orchestrator of a local dev stack instead of the
+profile's (` + "`cozyctl mock-server`" + ` works too); no login is needed.
+
+Example:
+  cozyctl fixtures run my-app --label v1
+  cozyctl fixtures run my-app --against v1
+  cozyctl fixtures run my-app generate/cat --against v1 --ignore $.seed
+  cozyctl fixtures run my-app --local`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.DeploymentID = args[0]
+			opts.Fixtures = args[1:]
+			opts.Output = globals.OutputFormat()
+			return fixtures.Run(opts)
+		},
+	}
+
+	runCmd.Flags().StringVarP(&opts.ProjectDir, "dir", "d", ".", "Project directory")
+	runCmd.Flags().StringVar(&opts.LocalURL, "local", "", "Invoke a local dev stack's orchestrator at this URL")
+	runCmd.Flags().Lookup("local").NoOptDefVal = "http://localhost:8090"
+	runCmd.Flags().StringVar(&opts.Label, "label", "", "Record the run under this label (default: the deployment's image tag)")
+	runCmd.Flags().StringVar(&opts.Against, "against", "", "Compare outputs with this recorded run")
+	runCmd.Flags().StringArrayVar(&opts.Ignore, "ignore", nil, "JSON path to leave out of comparisons (repeatable)")
+
+	return runCmd
+}
+
+// DiffCmd compares the outputs of two recorded runs
+func DiffCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts fixtures.DiffOptions
+
+	diffCmd := &cobra.Command{
+		Use:   "diff <from-label> <to-label> [function[/name]...]",
+		Short: "Compare the outputs of two recorded runs",
+		Long: `Compare the outputs recorded by two fixture runs, e.g. of two builds, without
+invoking anything. Fails if any output changed.
+
+Example:
+  cozyctl fixtures diff cozy-build-my-app-1a2b3c4d cozy-build-my-app-5e6f7a8b
+  cozyctl fixtures diff v1 v2 generate --ignore $.timings`,
+		Args:        cobra.MinimumNArgs(2),
+		Annotations: map[string]string{cmdutil.GlobalOutput: "", cmdutil.SkipTokenCheck: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.From, opts.To = args[0], args[1]
+			opts.Fixtures = args[2:]
+			opts.Output = globals.OutputFormat()
+			return fixtures.Diff(opts)
+		},
+	}
+
+	diffCmd.Flags().StringVarP(&opts.ProjectDir, "dir", "d", ".", "Project directory")
+	diffCmd.Flags().StringArrayVar(&opts.Ignore, "ignore", nil, "JSON path to leave out of comparisons (repeatable)")
+
+	return diffCmd
+}
@@ -0,0 +1,65 @@
+package flush
+
+import (
+	"fmt"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deploy"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// FlushCmd submits deploys queued while cozy-hub was unreachable
+func FlushCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		opts     deploy.FlushOptions
+		progress string
+	)
+
+	flushCmd := &cobra.Command{
+		Use:   "flush",
+		Short: "Submit deploys queued while cozy-hub was unreachable",
+		Long: `Build and deploy the deploys that 'cozyctl deploy --queue' queued because
+cozy-hub couldn't be reached, oldest first. Each queued deploy is the project
+archive packaged at the time and the request to build and deploy it; the
+archives are kept under ~/.cozy/<name>/<profile>/outbox.
+
+A queued deploy leaves the outbox once its archive is uploaded, even if the
+build or deploy then fails, since retrying would build it again. If cozy-hub
+is still unreachable, flushing stops and the remaining deploys stay queued.
+
+Use --list to show the queued deploys without submitting them (-o json or
+-o yaml prints the list for scripts).
+
+Example:
+  cozyctl flush
+  cozyctl flush --list`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.List {
+				if err := globals.CheckWritable(cmd); err != nil {
+					return err
+				}
+			}
+			format := globals.OutputFormat()
+			if format != "" && !opts.List {
+				return fmt.Errorf("--output requires --list")
+			}
+			mode, err := ui.ParseMode(progress)
+			if err != nil {
+				return err
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.Output = format
+			opts.Progress = mode
+			return deploy.Flush(cmd.Context(), opts)
+		},
+	}
+
+	flushCmd.Flags().BoolVar(&opts.List, "list", false, "List the queued deploys instead of submitting them")
+	flushCmd.Flags().StringVar(&progress, "progress", "auto", "Progress output: auto, plain, or json")
+
+	return flushCmd
+}
@@ -0,0 +1,76 @@
+package functions
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/functions"
+	"github.com/spf13/cobra"
+)
+
+// FunctionsCmd groups commands that manage individual functions of a deployment
+func FunctionsCmd(globals *cmdutil.Globals) *cobra.Command {
+	functionsCmd := &cobra.Command{
+		Use:     "functions",
+		Aliases: []string{"function"},
+		Short:   "Take individual functions of a deployment out of rotation",
+		Long: `Disable a single misbehaving worker function without redeploying or
+affecting its sibling functions, and enable it again once it's fixed.
+Disabled functions refuse invocations; they stay disabled across redeploys
+until enabled. 'cozyctl deployments describe' shows which are disabled.`,
+	}
+
+	functionsCmd.AddCommand(DisableCmd(globals))
+	functionsCmd.AddCommand(EnableCmd(globals))
+
+	return functionsCmd
+}
+
+// DisableCmd takes a function out of rotation
+func DisableCmd(globals *cmdutil.Globals) *cobra.Command {
+	return &cobra.Command{
+		Use:   "disable <deployment-id> <function>",
+		Short: "Take a function out of rotation",
+		Long: `Take a function out of rotation. Its invocations are refused while the
+deployment's other functions keep serving; nothing is redeployed.
+
+Example:
+  cozyctl functions disable my-model upscale`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return functions.SetEnabled(functions.Options{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Function:     args[1],
+				Enabled:      false,
+			})
+		},
+	}
+}
+
+// EnableCmd puts a disabled function back into rotation
+func EnableCmd(globals *cmdutil.Globals) *cobra.Command {
+	return &cobra.Command{
+		Use:   "enable <deployment-id> <function>",
+		Short: "Put a disabled function back into rotation",
+		Long: `Put a function disabled with 'cozyctl functions disable' back into rotation.
+
+Example:
+  cozyctl functions enable my-model upscale`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return functions.SetEnabled(functions.Options{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Function:     args[1],
+				Enabled:      true,
+			})
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package images
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/images"
+	"github.com/spf13/cobra"
+)
+
+// ImagesCmd groups commands that work with cozy-built container images
+func ImagesCmd(globals *cmdutil.Globals) *cobra.Command {
+	imagesCmd := &cobra.Command{
+		Use:         "images",
+		Short:       "Inspect container images built by cozyctl",
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+	}
+
+	imagesCmd.AddCommand(InspectCmd(globals))
+
+	return imagesCmd
+}
+
+// InspectCmd shows the build metadata recorded in an image
+func InspectCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts images.InspectOptions
+
+	inspectCmd := &cobra.Command{
+		Use:   "inspect <image>",
+		Short: "Show the build metadata recorded in an image",
+		Long: `Show the build metadata cozyctl records in the labels of the images it
+generates Dockerfiles for: the build ID, deployment, base image, git remote
+and commit, source digest, the worker functions the image serves, and the
+cozyctl version that generated it. The image is read from
+the local Docker daemon; pass --pull to pull it first when it isn't there.
+
+Example:
+  cozyctl images inspect cozy-build-my-model-1a2b3c4d
+  cozyctl images inspect registry.example.com/team/cozy-build-my-model-1a2b3c4d --pull -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Image = args[0]
+			opts.Output = globals.OutputFormat()
+			return images.Inspect(opts)
+		},
+	}
+
+	inspectCmd.Flags().BoolVar(&opts.Pull, "pull", false, "Pull the image if it isn't in the local Docker daemon")
+
+	return inspectCmd
+}
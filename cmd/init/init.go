@@ -0,0 +1,68 @@
+package initCmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+// InitCmd writes a starter project
+func InitCmd() *cobra.Command {
+	var (
+		template string
+		set      []string
+	)
+
+	initCmd := &cobra.Command{
+		Use:   "init [dir]",
+		Short: "Start a new project from a built-in starter",
+		Long: `Write a starter project into dir (default: the current directory, which
+may already hold other files such as a git checkout): a pyproject.toml with
+a filled-in [tool.cozy] section, an example @worker_function() worker in
+src/<project>/worker.py, and a .cozyignore listing files to leave out of
+builds. Nothing is written if any of those files already exist.
+
+The project is named after the directory, and deploys to a deployment of
+the same name; set project_name or deployment_id with --set to change that.
+
+Starters (--template):
+  basic     A minimal CPU worker with one function (the default)
+  cpu-api   A CPU-only JSON API with several functions
+  sdxl      Text-to-image generation with SDXL-Turbo (--set model=...)
+  llm       Chat completions with an open LLM (--set model=...)
+
+The starters are built in, so init works offline. For the curated gallery
+on cozy-hub, see 'cozyctl templates'.
+
+Example:
+  cozyctl init
+  cozyctl init my-image-worker --template sdxl
+  cozyctl init chat --template llm --set model=meta-llama/Llama-3.1-8B-Instruct`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := templates.InitOptions{
+				Dir:      ".",
+				Template: template,
+				Set:      map[string]string{},
+			}
+			if len(args) > 0 {
+				opts.Dir = args[0]
+			}
+			for _, kv := range set {
+				name, value, ok := strings.Cut(kv, "=")
+				if !ok || name == "" {
+					return fmt.Errorf("invalid --set %q: expected NAME=VALUE", kv)
+				}
+				opts.Set[name] = value
+			}
+			return templates.Init(opts)
+		},
+	}
+
+	initCmd.Flags().StringVarP(&template, "template", "t", templates.DefaultStarter, "Starter: basic, cpu-api, sdxl, or llm")
+	initCmd.Flags().StringArrayVar(&set, "set", nil, "Set a template variable, as NAME=VALUE (repeatable)")
+
+	return initCmd
+}
@@ -0,0 +1,120 @@
+package keysCmd
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/account"
+	"github.com/spf13/cobra"
+)
+
+// KeysCmd groups commands that manage scoped API keys
+func KeysCmd(globals *cmdutil.Globals) *cobra.Command {
+	keysCmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Create and revoke scoped API keys for CI",
+	}
+
+	keysCmd.AddCommand(CreateCmd(globals))
+	keysCmd.AddCommand(ListCmd(globals))
+	keysCmd.AddCommand(RevokeCmd(globals))
+
+	return keysCmd
+}
+
+// CreateCmd creates a scoped API key
+func CreateCmd(globals *cmdutil.Globals) *cobra.Command {
+	var scopes []string
+	var expires string
+
+	createCmd := &cobra.Command{
+		Use:   "create [key-name]",
+		Short: "Create an API key limited to specific deployments and operations",
+		Long: `Create an API key for automation such as CI. A key can only do what its
+scopes allow, which limits the damage if it leaks.
+
+A scope is operation[:deployment]; without a deployment it covers them all.
+Operations:
+  read    view deployments, builds, and logs
+  deploy  upload builds and roll them out (includes read)
+  invoke  call deployed functions (includes read)
+  manage  everything, including deleting and transferring deployments
+
+Requests outside a key's scopes fail with a 403 error. The key is printed
+once; store it in your CI secrets right away.
+
+Example:
+  cozyctl keys create --scope deploy:my-model --expires 30d
+  cozyctl keys create github-actions --scope deploy:my-model --scope invoke:my-model
+  cozyctl keys create dashboards --scope read --expires never`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			lifetime, err := account.ParseExpiry(expires)
+			if err != nil {
+				return err
+			}
+			opts := account.CreateKeyOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Scopes:    scopes,
+				Expires:   lifetime,
+				Output:    globals.OutputFormat(),
+			}
+			if len(args) > 0 {
+				opts.Name = args[0]
+			}
+			return account.CreateKey(opts)
+		},
+	}
+
+	createCmd.Flags().StringArrayVar(&scopes, "scope", nil, "Scope to grant, as operation[:deployment] (repeatable)")
+	createCmd.Flags().StringVar(&expires, "expires", "90d", "Key lifetime, e.g. 30d or 12h, or never")
+	createCmd.MarkFlagRequired("scope")
+
+	return createCmd
+}
+
+// ListCmd lists the account's API keys
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List API keys",
+		Long: `List your API keys with their scopes and expiry. Secrets are never shown
+again after creation; the prefix identifies a key.
+
+Example:
+  cozyctl keys list`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return account.ListKeys(account.ListKeysOptions{Profile: globals.ProfileRef(), Transport: globals.Transport(), Output: globals.OutputFormat()})
+		},
+	}
+
+	return listCmd
+}
+
+// RevokeCmd revokes API keys
+func RevokeCmd(globals *cmdutil.Globals) *cobra.Command {
+	revokeCmd := &cobra.Command{
+		Use:   "revoke <key-id>...",
+		Short: "Revoke API keys",
+		Long: `Revoke one or more API keys by ID. Requests made with a revoked key fail
+immediately.
+
+Example:
+  cozyctl keys revoke key-0007`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return account.RevokeKeys(globals.ProfileRef(), globals.Transport(), args)
+		},
+	}
+
+	return revokeCmd
+}
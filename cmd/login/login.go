@@ -2,33 +2,53 @@ package loginCmd
 
 import (
 	"os"
+	"strings"
 
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/config"
 	"github.com/cozy-creator/cozyctl/internal/login"
 	"github.com/spf13/cobra"
 )
 
-var (
-	loginAPIKey     string
-	loginHubURL     string
-	loginBuilderURL string
-	loginTenantID   string
-	loginName       string
-	loginProfile    string
-	loginConfigFile string
-	loginEmail      string
-	loginPassword   string
-)
+func LoginCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		loginAPIKey     string
+		loginEnv        string
+		loginHubURL     string
+		loginBuilderURL string
+		loginOrchURL    string
+		loginTenantID   string
+		loginName       string
+		loginProfile    string
+		loginConfigFile string
+		loginEmail      string
+		loginPassword   string
+		loginSSO        string
+		loginNoBrowser  bool
+		loginReset      bool
+	)
 
-func LoginCmd() *cobra.Command {
 	loginCmd := &cobra.Command{
 		Use:   "login",
 		Short: "Authenticate with Cozy",
-		Long: `Authenticate with the Cozy platform using email/password or API key.
+		Long: `Authenticate with the Cozy platform using email/password, API key, or your
+organization's single sign-on.
 
 You can provide credentials via:
   1. Interactive prompt (default - prompts for email and password)
   2. Flags: --email and --password
   3. API key: --api-key or COZY_API_KEY environment variable
+  4. SSO: --sso <org-slug> signs in through your organization's identity
+     provider in the browser (OIDC with PKCE)
+
+--env picks the servers to log in to from a preset: production, staging, or
+local-dev. --hub-url, --builder-url, and --orchestrator-url override single
+URLs of the preset. Without them, a new profile uses local-dev and an
+existing one keeps its servers.
+
+Logging into an existing profile only replaces its credentials and tenant;
+other settings, such as the orchestrator URL and registry, are kept. Use
+--reset to replace the whole profile instead.
 
 Examples:
   # Interactive login (prompts for email and password)
@@ -43,17 +63,50 @@ Examples:
   # Login with custom profile
   cozyctl login --name briheet --profile dev
 
+  # Login to production
+  cozyctl login --env production --profile prod
+
   # Login with API key
   cozyctl login --api-key sk_live_xxx
 
+  # Login with your organization's SSO
+  cozyctl login --sso acme
+
+  # SSO on a machine without a browser (open the printed URL elsewhere)
+  cozyctl login --sso acme --no-browser
+
+  # Replace an existing profile instead of only its credentials
+  cozyctl login --profile dev --reset
+
   # Import existing config file
   cozyctl login --name briheet --profile prod --config-file ./prod-config.yaml`,
+		Annotations: map[string]string{cmdutil.ExplicitProfile: ""},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Handle config file import
 			if loginConfigFile != "" {
 				return login.ImportConfig(loginConfigFile, loginName, loginProfile)
 			}
 
+			urls, err := config.SelectURLs(loginEnv, loginHubURL, loginBuilderURL, loginOrchURL)
+			if err != nil {
+				return err
+			}
+
+			if loginSSO != "" {
+				return login.RunSSOLogin(login.SSOOptions{
+					Org:             loginSSO,
+					HubURL:          urls.HubURL,
+					BuilderURL:      urls.BuilderURL,
+					OrchestratorURL: urls.OrchestratorURL,
+					TenantID:        loginTenantID,
+					Name:            loginName,
+					Profile:         loginProfile,
+					Reset:           loginReset,
+					NoBrowser:       loginNoBrowser,
+					Transport:       globals.Transport(),
+				})
+			}
+
 			// Check for API key from flag or environment
 			apiKey := loginAPIKey
 			if apiKey == "" {
@@ -64,11 +117,14 @@ Examples:
 			if apiKey != "" {
 				return login.RunLogin(
 					apiKey,
-					loginHubURL,
-					loginBuilderURL,
+					urls.HubURL,
+					urls.BuilderURL,
+					urls.OrchestratorURL,
 					loginTenantID,
 					loginName,
 					loginProfile,
+					loginReset,
+					globals.Transport(),
 				)
 			}
 
@@ -76,11 +132,14 @@ Examples:
 			return login.RunPasswordLogin(
 				loginEmail,
 				loginPassword,
-				loginHubURL,
-				loginBuilderURL,
+				urls.HubURL,
+				urls.BuilderURL,
+				urls.OrchestratorURL,
 				loginTenantID,
 				loginName,
 				loginProfile,
+				loginReset,
+				globals.Transport(),
 			)
 		},
 	}
@@ -91,9 +150,17 @@ Examples:
 	loginCmd.Flags().StringVarP(&loginPassword, "password", "p", "", "password for login")
 	loginCmd.Flags().StringVar(&loginAPIKey, "api-key", "", "API key (or set COZY_API_KEY)")
 	loginCmd.Flags().StringVar(&loginConfigFile, "config-file", "", "import existing config file")
-	loginCmd.Flags().StringVar(&loginHubURL, "hub-url", "http://localhost:3001", "Cozy Hub API URL")
-	loginCmd.Flags().StringVar(&loginBuilderURL, "builder-url", "http://localhost:3001", "Builder API URL (now part of cozy-hub)")
+	loginCmd.Flags().StringVar(&loginEnv, "env", "", "environment preset: "+strings.Join(config.EnvironmentNames(), ", ")+" (default: the profile's servers, or "+config.DefaultEnvironment+")")
+	loginCmd.Flags().StringVar(&loginHubURL, "hub-url", "", "Cozy Hub API URL (default: from --env)")
+	loginCmd.Flags().StringVar(&loginBuilderURL, "builder-url", "", "Builder API URL, now part of cozy-hub (default: from --env)")
+	loginCmd.Flags().StringVar(&loginOrchURL, "orchestrator-url", "", "Orchestrator API URL (default: from --env)")
+	loginCmd.Flags().BoolVar(&loginReset, "reset", false, "replace an existing profile's settings instead of only its credentials")
 	loginCmd.Flags().StringVar(&loginTenantID, "tenant-id", "", "tenant ID (usually auto-detected)")
+	loginCmd.Flags().StringVar(&loginSSO, "sso", "", "sign in with the SSO provider of this organization slug")
+	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false, "with --sso, print the sign-in URL instead of opening a browser")
+	loginCmd.MarkFlagsMutuallyExclusive("sso", "api-key")
+	loginCmd.MarkFlagsMutuallyExclusive("sso", "password")
+	loginCmd.MarkFlagsMutuallyExclusive("sso", "config-file")
 
 	return loginCmd
 }
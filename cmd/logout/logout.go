@@ -1,16 +1,17 @@
 package logoutCmd
 
 import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
 	"github.com/cozy-creator/cozyctl/internal/logout"
 	"github.com/spf13/cobra"
 )
 
-var (
-	name    string
-	profile []string
-)
-
 func LogoutCmd() *cobra.Command {
+	var (
+		name    string
+		profile []string
+	)
+
 	logoutCmd := &cobra.Command{
 		Use:   "logout",
 		Short: "Logout of the system",
@@ -23,6 +24,7 @@ Examples:
   # Logout with name and a profile/profiles. It can be one, can be many profiles.
   cozyctl logout --name <put-your-name-here> --profile <put-your-profile-here> <put-your-profile-here>
 `,
+		Annotations: map[string]string{cmdutil.ExplicitProfile: ""},
 		RunE: func(cmd *cobra.Command, args []string) error {
 
 			if name == "" {
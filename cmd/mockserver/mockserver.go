@@ -0,0 +1,89 @@
+package mockserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/spf13/cobra"
+)
+
+func MockServerCmd() *cobra.Command {
+	var (
+		addr          string
+		buildDuration time.Duration
+		buildSlots    int
+		tenantID      string
+	)
+
+	mockServerCmd := &cobra.Command{
+		Use:   "mock-server",
+		Short: "Run a local fake of cozy-hub, the builder, and the orchestrator",
+		Long: `Run an in-process fake of the cozy-hub, builder, and orchestrator APIs.
+
+All state is kept in memory and discarded on exit. Any API key or password is
+accepted. Builds succeed after --build-duration, deployed functions echo their
+input, and deployments report ready immediately. With --build-slots, only
+that many builds run at once and the rest queue by priority, as on the real
+builder.
+
+Useful for demos, developing cozyctl itself, and end-to-end tests without
+access to a real Cozy environment.
+
+Example:
+  cozyctl mock-server
+  cozyctl mock-server --addr 127.0.0.1:9000 --build-duration 10s
+  cozyctl mock-server --build-slots 1`,
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			srv := mockserver.New()
+			srv.BuildDuration = buildDuration
+			srv.BuildSlots = buildSlots
+			srv.TenantID = tenantID
+
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", addr, err)
+			}
+			url := "http://" + listener.Addr().String()
+
+			fmt.Printf("Mock cozy-hub, builder, and orchestrator listening on %s\n\n", url)
+			fmt.Println("Point a profile at it with:")
+			fmt.Printf("  cozyctl login --name mock --profile local --api-key mock --hub-url %s --builder-url %s\n", url, url)
+			fmt.Printf("  export COZY_ORCHESTRATOR_URL=%s\n\n", url)
+			fmt.Println("Press Ctrl+C to stop.")
+
+			httpServer := &http.Server{Handler: srv.Handler()}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				httpServer.Shutdown(shutdownCtx)
+			}()
+
+			if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	mockServerCmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8099", "Address to listen on")
+	mockServerCmd.Flags().DurationVar(&buildDuration, "build-duration", 5*time.Second, "How long builds run before succeeding")
+	mockServerCmd.Flags().IntVar(&buildSlots, "build-slots", 0, "How many builds run at once (0 for no limit)")
+	mockServerCmd.Flags().StringVar(&tenantID, "tenant-id", mockserver.DefaultTenantID, "Tenant ID reported for every credential")
+
+	return mockServerCmd
+}
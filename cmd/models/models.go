@@ -0,0 +1,118 @@
+package models
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/models"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// ModelsCmd groups commands that work with the models deployments load
+func ModelsCmd(globals *cmdutil.Globals) *cobra.Command {
+	modelsCmd := &cobra.Command{
+		Use:   "models",
+		Short: "Work with the models deployments load",
+	}
+
+	modelsCmd.AddCommand(ResolveCmd(globals))
+	modelsCmd.AddCommand(PushCmd(globals))
+
+	return modelsCmd
+}
+
+// ResolveCmd validates model references against the Hugging Face Hub
+func ResolveCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts models.ResolveOptions
+
+	resolveCmd := &cobra.Command{
+		Use:   "resolve [ref...]",
+		Short: "Check that referenced models exist and are accessible",
+		Long: `Check the project's model references against the Hugging Face Hub: that each
+model (and revision) exists, whether it is gated, and how large it is. Gated
+models without an access token are flagged, since workers would fail to load
+them at cold start.
+
+References come from [tool.cozy.models] in pyproject.toml and from ModelRef
+annotations in the project's Python files; annotations naming a key that
+[tool.cozy.models] doesn't define are errors. Pass references as arguments
+to check them instead.
+
+  [tool.cozy.models]
+  sdxl-turbo = "hf:stabilityai/sdxl-turbo"
+  flux = "hf:black-forest-labs/FLUX.1-dev@main"
+
+The access token is read from HF_TOKEN, HUGGING_FACE_HUB_TOKEN, or the token
+saved by 'huggingface-cli login'. With --register, resolved models are
+registered with cozy-hub so deploys can fetch them without resolving them.
+
+Example:
+  cozyctl models resolve
+  cozyctl models resolve --dir ./my-project --register
+  cozyctl models resolve hf:stabilityai/sdxl-turbo -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.Refs = args
+			opts.Output = globals.OutputFormat()
+			return models.Resolve(cmd.Context(), opts)
+		},
+	}
+
+	resolveCmd.Flags().StringVarP(&opts.ProjectDir, "dir", "d", ".", "Project directory")
+	resolveCmd.Flags().BoolVar(&opts.Register, "register", false, "Register resolved models with cozy-hub")
+	resolveCmd.Flags().StringVar(&opts.HFEndpoint, "hf-endpoint", "", "Hugging Face Hub URL (default $HF_ENDPOINT or "+models.DefaultHFEndpoint+")")
+
+	return resolveCmd
+}
+
+// PushCmd uploads local model weights to the cozy-hub file store
+func PushCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts models.PushOptions
+	var chunkSizeMiB int64
+	var progress string
+
+	pushCmd := &cobra.Command{
+		Use:   "push <path>",
+		Short: "Upload local model weights and register them with cozy-hub",
+		Long: `Upload a weights file, or every file in a directory, to the cozy-hub file
+store and register it as the model cozy:<model>. Files larger than
+--chunk-size are sent as multipart uploads, and a failed part is retried on
+its own. Hidden files are skipped; symlinks are followed, so a Hugging Face
+cache snapshot can be pushed as is.
+
+Reference the pushed model from pyproject.toml to load it with ModelRef:
+
+  [tool.cozy.models]
+  my-lora = "cozy:my-lora"
+
+Pushing to an existing name replaces the model's files.
+
+Example:
+  cozyctl models push ./weights --model my-lora
+  cozyctl models push ./model.safetensors --model my-checkpoint --chunk-size 256`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			mode, err := ui.ParseMode(progress)
+			if err != nil {
+				return err
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.Path = args[0]
+			opts.ChunkSize = chunkSizeMiB << 20
+			opts.ProgressMode = mode
+			return models.Push(opts)
+		},
+	}
+
+	pushCmd.Flags().StringVar(&opts.Name, "model", "", "Model name; the model is registered as cozy:<model>")
+	pushCmd.Flags().Int64Var(&chunkSizeMiB, "chunk-size", models.DefaultChunkSize>>20, "Multipart upload part size, in MiB")
+	pushCmd.Flags().StringVar(&progress, "progress", "auto", "Progress output: auto, plain, or json")
+	pushCmd.MarkFlagRequired("model")
+
+	return pushCmd
+}
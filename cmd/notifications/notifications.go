@@ -0,0 +1,130 @@
+package notifications
+
+import (
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/notifications"
+	"github.com/spf13/cobra"
+)
+
+// NotificationsCmd groups commands that configure build and deploy notifications
+func NotificationsCmd(globals *cmdutil.Globals) *cobra.Command {
+	notificationsCmd := &cobra.Command{
+		Use:   "notifications",
+		Short: "Get notified when builds and deploys finish",
+		Long: `Notification rules make cozy-hub email you or call a webhook when the
+tenant's builds and deploys fail or succeed, instead of polling
+'cozyctl builds list' to notice.
+
+Events: ` + strings.Join(api.NotificationEvents, ", ") + `.
+Webhooks receive a JSON POST per event with the event, deployment ID, build
+ID, and status.`,
+	}
+
+	notificationsCmd.AddCommand(SetCmd(globals))
+	notificationsCmd.AddCommand(ListCmd(globals))
+	notificationsCmd.AddCommand(ClearCmd(globals))
+
+	return notificationsCmd
+}
+
+// SetCmd creates or updates a notification rule
+func SetCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		opts notifications.SetOptions
+		on   string
+	)
+
+	setCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Notify a channel about build and deploy events",
+		Long: `Notify an email address or webhook about build and deploy events, for one
+deployment or all of them. Setting a channel, target, and deployment that
+already have a rule replaces that rule's events.
+
+Example:
+  cozyctl notifications set --on build-failed,deploy-failed --channel email
+  cozyctl notifications set --on all --channel email --target oncall@example.com --deployment my-model
+  cozyctl notifications set --on deploy-failed --channel webhook --target https://hooks.example.com/cozy`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			var err error
+			if opts.Events, err = notifications.ParseEvents(on); err != nil {
+				return err
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			opts.Output = globals.OutputFormat()
+			return notifications.Set(opts)
+		},
+	}
+
+	setCmd.Flags().StringVar(&on, "on", "", "Comma-separated events: "+strings.Join(api.NotificationEvents, ", ")+", or all")
+	setCmd.Flags().StringVar(&opts.Channel, "channel", api.ChannelEmail, "Channel: email or webhook")
+	setCmd.Flags().StringVar(&opts.Target, "target", "", "Email address (default: your account's) or webhook URL")
+	setCmd.Flags().StringVar(&opts.Deployment, "deployment", "", "Only notify about this deployment (default: all deployments)")
+	setCmd.MarkFlagRequired("on")
+
+	return setCmd
+}
+
+// ListCmd lists notification rules
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List notification rules",
+		Long: `List the tenant's notification rules.
+
+Example:
+  cozyctl notifications list
+  cozyctl notifications list -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return notifications.List(notifications.ListOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	return listCmd
+}
+
+// ClearCmd deletes notification rules
+func ClearCmd(globals *cmdutil.Globals) *cobra.Command {
+	var yes bool
+
+	clearCmd := &cobra.Command{
+		Use:   "clear [rule-id]...",
+		Short: "Delete notification rules",
+		Long: `Delete notification rules by ID, or all of them when none are given.
+
+Example:
+  cozyctl notifications clear notify-0003
+  cozyctl notifications clear --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return notifications.Clear(notifications.ClearOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				IDs:       args,
+				Yes:       yes,
+			})
+		},
+	}
+
+	clearCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation")
+
+	return clearCmd
+}
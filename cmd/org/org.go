@@ -0,0 +1,163 @@
+package org
+
+import (
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/org"
+	"github.com/spf13/cobra"
+)
+
+// OrgCmd groups commands that manage the tenant's organization
+func OrgCmd(globals *cmdutil.Globals) *cobra.Command {
+	orgCmd := &cobra.Command{
+		Use:   "org",
+		Short: "Manage your organization",
+	}
+
+	orgCmd.AddCommand(MembersCmd(globals))
+
+	return orgCmd
+}
+
+// MembersCmd groups commands that manage organization members
+func MembersCmd(globals *cmdutil.Globals) *cobra.Command {
+	membersCmd := &cobra.Command{
+		Use:   "members",
+		Short: "Manage who can deploy to your tenant",
+		Long: `Members of an organization work on the tenant's deployments with the
+permissions of their role:
+  viewer    view deployments, builds, and logs
+  deployer  also build, deploy, and invoke
+  admin     everything, including deleting deployments and managing members
+
+Inviting, removing, and changing roles requires the admin role.`,
+	}
+
+	membersCmd.AddCommand(ListCmd(globals))
+	membersCmd.AddCommand(InviteCmd(globals))
+	membersCmd.AddCommand(RemoveCmd(globals))
+	membersCmd.AddCommand(SetRoleCmd(globals))
+
+	return membersCmd
+}
+
+// ListCmd lists organization members
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List members and pending invitations",
+		Long: `List the organization's members and pending invitations with their roles.
+
+Example:
+  cozyctl org members list
+  cozyctl org members list -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return org.List(org.ListOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	return listCmd
+}
+
+// InviteCmd invites people to the organization
+func InviteCmd(globals *cmdutil.Globals) *cobra.Command {
+	var role string
+
+	inviteCmd := &cobra.Command{
+		Use:   "invite <email>...",
+		Short: "Invite people to the organization",
+		Long: `Email invitations to join the organization. Invitees get the role once
+they accept; until then they are listed with status "invited".
+
+Example:
+  cozyctl org members invite alice@example.com --role deployer
+  cozyctl org members invite bob@example.com carol@example.com`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return org.Invite(org.InviteOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Emails:    args,
+				Role:      role,
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	inviteCmd.Flags().StringVar(&role, "role", api.RoleViewer, "Role: "+strings.Join(api.OrgRoles, ", "))
+
+	return inviteCmd
+}
+
+// RemoveCmd removes organization members
+func RemoveCmd(globals *cmdutil.Globals) *cobra.Command {
+	var yes bool
+
+	removeCmd := &cobra.Command{
+		Use:   "remove <member>...",
+		Short: "Remove members or withdraw invitations",
+		Long: `Remove members, by ID or email, from the organization, or withdraw their
+invitations. Their sessions and API keys stop working for the tenant. The
+last admin cannot be removed.
+
+Example:
+  cozyctl org members remove alice@example.com
+  cozyctl org members remove member-0042 --yes`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return org.Remove(org.RemoveOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Members:   args,
+				Yes:       yes,
+			})
+		},
+	}
+
+	removeCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation")
+
+	return removeCmd
+}
+
+// SetRoleCmd changes a member's role
+func SetRoleCmd(globals *cmdutil.Globals) *cobra.Command {
+	setRoleCmd := &cobra.Command{
+		Use:   "set-role <member> <role>",
+		Short: "Change a member's role",
+		Long: `Change the role of a member or invitation, given by ID or email. Roles:
+viewer, deployer, admin. The last admin cannot be demoted.
+
+Example:
+  cozyctl org members set-role alice@example.com admin`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return org.SetRole(org.SetRoleOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Member:    args[0],
+				Role:      args[1],
+			})
+		},
+	}
+
+	return setRoleCmd
+}
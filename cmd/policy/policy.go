@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+// PolicyCmd groups commands for authoring deployment policies
+func PolicyCmd() *cobra.Command {
+	policyCmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Author and test deployment policies",
+		Long: `Deployment requests can be checked against Rego policies before they are
+submitted. Policies are Rego v1 modules in package cozy.deploy that add
+messages to the deny set (the deploy is rejected) or the warn set (the
+message is only shown). They see the request as input:
+
+  {"action": "create", "deployment_id": "...", "request": {...},
+   "project": {"dir": "...", "base_image": "...", "labels": {...}, ...}}
+
+and their parameters as data.cozy.params. Enable them in pyproject.toml:
+
+  [tool.cozy.policy]
+  paths = ["policies/"]
+  params = { max_workers = 10 }
+
+Bundled policies enforce max_workers, allowed_base_images (image prefixes),
+and required_labels ([tool.cozy.labels]) once those params are set.`,
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+	}
+
+	policyCmd.AddCommand(TestCmd())
+
+	return policyCmd
+}
+
+// TestCmd runs policy unit tests or evaluates policies against an input
+func TestCmd() *cobra.Command {
+	opts := policy.TestOptions{}
+
+	testCmd := &cobra.Command{
+		Use:   "test [path...]",
+		Short: "Run policy tests or evaluate policies against an input",
+		Long: `Run the Rego unit tests (rules named test_*) of the policies configured in the
+project at --dir and of any extra policy files or directories given as
+arguments. The bundled policies are loaded too, with the project's params.
+
+With --input, the policies are instead evaluated against a JSON input
+document, and the resulting warnings and denials are printed.
+
+Example:
+  cozyctl policy test
+  cozyctl policy test ./policies --dir ./my-project
+  cozyctl policy test --input request.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Paths = args
+			return policy.Test(opts)
+		},
+	}
+
+	testCmd.Flags().StringVarP(&opts.ProjectDir, "dir", "d", ".", "Project directory whose [tool.cozy.policy] is used")
+	testCmd.Flags().StringVar(&opts.InputFile, "input", "", "Evaluate the policies against this JSON input document")
+
+	return testCmd
+}
@@ -3,6 +3,7 @@ package profileCmd
 import (
 	"fmt"
 
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
 	"github.com/cozy-creator/cozyctl/internal/config"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +23,7 @@ If you delete the currently active profile, it will automatically switch to defa
 
 Example:
   cozyctl delete --name briheet --profile staging`,
+		Annotations: map[string]string{cmdutil.ExplicitProfile: ""},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Both name and profile are required
 			if deleteName == "" || deleteProfile == "" {
@@ -6,32 +6,46 @@ import (
 	"sort"
 	"text/tabwriter"
 
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
 	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+// profileEntry is a profile in structured output.
+type profileEntry struct {
+	Name    string `json:"name"`
+	Profile string `json:"profile"`
+	Current bool   `json:"current"`
+}
+
+// directoryEntry is a directory mapping in structured output.
+type directoryEntry struct {
+	Dir     string `json:"dir"`
+	Name    string `json:"name"`
+	Profile string `json:"profile"`
+}
+
 // ProfilesCmd lists all profiles
-func ProfileCmd() *cobra.Command {
+func ProfileCmd(globals *cmdutil.Globals) *cobra.Command {
 	profileCmd := &cobra.Command{
 		Use:   "profiles",
 		Short: "List all profiles",
 		Long: `List all configured name/profile combinations.
 
-The currently active profile is marked with an asterisk (*).
+The currently active profile is marked with an asterisk (*). Directories
+mapped to a profile with 'cozyctl use --map' are listed below the profiles.
 
 Example:
-  cozyctl profiles`,
+  cozyctl profiles
+  cozyctl profiles -o json`,
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: "", cmdutil.GlobalOutput: ""},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			profiles, err := config.ListAllProfiles()
 			if err != nil {
 				return err
 			}
 
-			if len(profiles) == 0 {
-				fmt.Println("No profiles found. Run 'cozyctl login' to create one.")
-				return nil
-			}
-
 			// Sort profiles by name, then by profile
 			sort.Slice(profiles, func(i, j int) bool {
 				if profiles[i].Name != profiles[j].Name {
@@ -46,6 +60,31 @@ Example:
 				return err
 			}
 
+			mappings, err := config.ListDirectoryMappings()
+			if err != nil {
+				return err
+			}
+
+			if format := globals.OutputFormat(); format.Structured() {
+				doc := struct {
+					Profiles    []profileEntry   `json:"profiles"`
+					Directories []directoryEntry `json:"directories"`
+				}{Profiles: []profileEntry{}, Directories: []directoryEntry{}}
+				for _, p := range profiles {
+					current := p.Name == defaultCfg.CurrentName && p.Profile == defaultCfg.CurrentProfile
+					doc.Profiles = append(doc.Profiles, profileEntry{Name: p.Name, Profile: p.Profile, Current: current})
+				}
+				for _, m := range mappings {
+					doc.Directories = append(doc.Directories, directoryEntry{Dir: m.Dir, Name: m.Name, Profile: m.Profile})
+				}
+				return ui.WriteStructured(os.Stdout, format, doc)
+			}
+
+			if len(profiles) == 0 {
+				fmt.Println("No profiles found. Run 'cozyctl login' to create one.")
+				return nil
+			}
+
 			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 			fmt.Fprintln(w, "NAME\tPROFILE\tCURRENT")
 			for _, p := range profiles {
@@ -57,13 +96,24 @@ Example:
 			}
 			w.Flush()
 
+			if len(mappings) > 0 {
+				fmt.Println("\nDirectory mappings:")
+				w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				for _, m := range mappings {
+					fmt.Fprintf(w, "  %s\t%s/%s\n", m.Dir, m.Name, m.Profile)
+				}
+				w.Flush()
+			}
+
 			return nil
 		},
 	}
 
 	profileCmd.AddCommand(SwitchCmd())
 	profileCmd.AddCommand(CurrentCmd())
+	profileCmd.AddCommand(ShowCmd(globals))
 	profileCmd.AddCommand(DeleteCmd())
+	profileCmd.AddCommand(PruneCmd())
 
 	return profileCmd
 }
@@ -0,0 +1,64 @@
+package profileCmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+// PruneCmd deletes profiles that haven't been used for a while
+func PruneCmd() *cobra.Command {
+	var (
+		unusedFor string
+		yes       bool
+	)
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete profiles that haven't been used for a while",
+		Long: `Offer to delete each profile no command has used within --unused-for, so
+~/.cozy doesn't collect credentials nobody uses anymore.
+
+A profile counts as used whenever a command loads it. Profiles last used
+before cozyctl tracked this count from when their config was last saved.
+The default/default profile and the current profile are never deleted.
+
+Example:
+  cozyctl profiles prune --unused-for 90d
+  cozyctl profiles prune --unused-for 30d --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			age, err := parseAge(unusedFor)
+			if err != nil {
+				return err
+			}
+			return profiles.Prune(profiles.PruneOptions{UnusedFor: age, Yes: yes})
+		},
+	}
+
+	pruneCmd.Flags().StringVar(&unusedFor, "unused-for", "90d", "delete profiles unused for this long (e.g. 90d, 720h)")
+	pruneCmd.Flags().BoolVarP(&yes, "yes", "y", false, "delete without prompting")
+
+	return pruneCmd
+}
+
+// parseAge parses a duration such as "90d" or "720h". Days are accepted on
+// top of the units time.ParseDuration understands.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid --unused-for %q (use e.g. 90d or 720h)", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --unused-for %q (use e.g. 90d or 720h)", s)
+	}
+	return d, nil
+}
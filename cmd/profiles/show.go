@@ -0,0 +1,37 @@
+package profileCmd
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/profiles"
+	"github.com/spf13/cobra"
+)
+
+// ShowCmd shows one profile's settings
+func ShowCmd(globals *cmdutil.Globals) *cobra.Command {
+	showCmd := &cobra.Command{
+		Use:   "show <name> <profile>",
+		Short: "Show a profile's settings without switching to it",
+		Long: `Show a profile's resolved settings: the server URLs and the environment
+preset they match, tenant, token state and expiry, whether a refresh token is
+saved, where credentials are stored, when a command last used it, and the
+last command recorded for it. COZY_* environment overrides are applied and
+listed.
+
+The current profile is left as it is. Tokens are never printed.
+
+Example:
+  cozyctl profiles show briheet prod
+  cozyctl profiles show work staging -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return profiles.Show(profiles.ShowOptions{
+				Name:    args[0],
+				Profile: args[1],
+				Output:  globals.OutputFormat(),
+			})
+		},
+	}
+
+	return showCmd
+}
@@ -2,7 +2,9 @@ package profileCmd
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
 	"github.com/cozy-creator/cozyctl/internal/config"
 	"github.com/spf13/cobra"
 )
@@ -11,6 +13,8 @@ func SwitchCmd() *cobra.Command {
 
 	var useName string
 	var useProfile string
+	var mapDirs []string
+	var unmapDirs []string
 
 	switchCmd := &cobra.Command{
 		Use:   "use",
@@ -27,11 +31,26 @@ Examples:
   cozyctl use --profile staging
 
   # Switch only the name (keep current profile)
-  cozyctl use --name damon`,
+  cozyctl use --name damon
+
+  # Always use work/prod for commands run in this directory (or below it)
+  cozyctl use --map $(pwd)=work/prod
+
+  # Remove the mapping
+  cozyctl use --unmap $(pwd)
+
+A directory mapping takes effect whenever a command runs inside the mapped
+directory without --name or --profile, and is announced on stderr. The
+closest mapped parent wins; 'cozyctl profiles' lists all mappings.`,
+		Annotations: map[string]string{cmdutil.ExplicitProfile: ""},
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(mapDirs) > 0 || len(unmapDirs) > 0 {
+				return updateDirectoryMappings(mapDirs, unmapDirs)
+			}
+
 			// Config
 			defaultCfg, err := config.GetDefaultConfig()
 			if err != nil {
@@ -66,6 +85,43 @@ Examples:
 
 	switchCmd.Flags().StringVar(&useName, "name", "", "name to switch to")
 	switchCmd.Flags().StringVar(&useProfile, "profile", "", "profile to switch to")
+	switchCmd.Flags().StringArrayVar(&mapDirs, "map", nil, "map a directory to a profile, as DIR=NAME/PROFILE (repeatable)")
+	switchCmd.Flags().StringArrayVar(&unmapDirs, "unmap", nil, "remove the profile mapping of a directory (repeatable)")
+	switchCmd.MarkFlagsMutuallyExclusive("map", "name")
+	switchCmd.MarkFlagsMutuallyExclusive("map", "profile")
 
 	return switchCmd
 }
+
+// updateDirectoryMappings adds the DIR=NAME/PROFILE mappings and removes the unmapped directories.
+func updateDirectoryMappings(mapDirs, unmapDirs []string) error {
+	for _, dir := range unmapDirs {
+		removed, err := config.RemoveDirectoryMapping(dir)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("no profile is mapped to %s", dir)
+		}
+		fmt.Printf("Removed profile mapping for %s\n", dir)
+	}
+
+	for _, m := range mapDirs {
+		dir, target, ok := strings.Cut(m, "=")
+		if !ok || dir == "" {
+			return fmt.Errorf("invalid --map %q (expected DIR=NAME/PROFILE)", m)
+		}
+		ref, err := config.ParseProfileRef(target)
+		if err != nil {
+			return err
+		}
+
+		dir, err = config.SaveDirectoryMapping(dir, ref)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Mapped %s to profile '%s/%s'\n", dir, ref.Name, ref.Profile)
+	}
+
+	return nil
+}
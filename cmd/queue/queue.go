@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// QueueCmd shows a deployment's pending and in-flight invocations
+func QueueCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		stuckAfter time.Duration
+	)
+
+	queueCmd := &cobra.Command{
+		Use:   "queue <deployment-id>",
+		Short: "Show a deployment's pending and in-flight invocations",
+		Long: `Show how many invocations of each of a deployment's functions are waiting for
+a worker and how many are running, with the age of the oldest of each, as
+reported by the orchestrator.
+
+A backlog with every worker busy means the deployment needs more workers; a
+backlog with idle workers, or an invocation running far longer than usual
+(--stuck-after), points at a stuck worker.
+
+Example:
+  cozyctl queue my-model
+  cozyctl queue my-model --stuck-after 30m
+  cozyctl queue my-model -o json | jq '.functions[] | select(.pending > 0)'`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return deployments.Queue(deployments.QueueOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				StuckAfter:   stuckAfter,
+				Output:       globals.OutputFormat(),
+			})
+		},
+	}
+
+	queueCmd.Flags().DurationVar(&stuckAfter, "stuck-after", deployments.DefaultStuckAfter, "Warn about invocations running longer than this")
+
+	return queueCmd
+}
@@ -0,0 +1,122 @@
+package rebuild
+
+import (
+	"fmt"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/rebuild"
+	"github.com/spf13/cobra"
+)
+
+// RebuildCmd groups commands that keep deployments patched with scheduled rebuilds
+func RebuildCmd(globals *cmdutil.Globals) *cobra.Command {
+	rebuildCmd := &cobra.Command{
+		Use:   "rebuild",
+		Short: "Rebuild deployments when their base image gets security updates",
+		Long: `Rebuild policies keep long-lived deployments patched. On a schedule,
+cozy-hub checks whether a deployment's base image has security updates and,
+if it has, rebuilds the deployment from its last source tarball and deploys
+the new build.`,
+	}
+
+	rebuildCmd.AddCommand(ScheduleCmd(globals))
+	rebuildCmd.AddCommand(ListCmd(globals))
+	rebuildCmd.AddCommand(RemoveCmd(globals))
+
+	return rebuildCmd
+}
+
+// ScheduleCmd registers a rebuild policy for a deployment
+func ScheduleCmd(globals *cmdutil.Globals) *cobra.Command {
+	var daily, weekly bool
+
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule <deployment-id>",
+		Short: "Rebuild a deployment when its base image gets security updates",
+		Long: `Check a deployment's base image for security updates every day (--daily)
+or week (--weekly, the default), and rebuild and redeploy it from the source
+of its active build when there are any. Scheduling a deployment that already
+has a policy changes its schedule.
+
+Example:
+  cozyctl rebuild schedule my-model --weekly
+  cozyctl rebuild schedule my-model --daily`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			if daily && weekly {
+				return fmt.Errorf("--daily and --weekly are mutually exclusive")
+			}
+			schedule := api.RebuildWeekly
+			if daily {
+				schedule = api.RebuildDaily
+			}
+			return rebuild.Schedule(rebuild.ScheduleOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Schedule:     schedule,
+			})
+		},
+	}
+
+	scheduleCmd.Flags().BoolVar(&weekly, "weekly", false, "Check for base image updates every week (default)")
+	scheduleCmd.Flags().BoolVar(&daily, "daily", false, "Check for base image updates every day")
+
+	return scheduleCmd
+}
+
+// ListCmd lists rebuild policies
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List scheduled rebuilds",
+		Long: `List the deployments with a rebuild policy, the build each is rebuilt from,
+and when its base image is checked next.
+
+Example:
+  cozyctl rebuild list
+  cozyctl rebuild list -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rebuild.List(rebuild.ListOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	return listCmd
+}
+
+// RemoveCmd removes a deployment's rebuild policy
+func RemoveCmd(globals *cmdutil.Globals) *cobra.Command {
+	removeCmd := &cobra.Command{
+		Use:   "remove <deployment-id>",
+		Short: "Stop scheduled rebuilds of a deployment",
+		Long: `Remove a deployment's rebuild policy. Builds already started by it are not
+canceled.
+
+Example:
+  cozyctl rebuild remove my-model`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return rebuild.Remove(rebuild.RemoveOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+			})
+		},
+	}
+
+	return removeCmd
+}
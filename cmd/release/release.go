@@ -0,0 +1,65 @@
+package release
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/release"
+	"github.com/spf13/cobra"
+)
+
+// ReleaseCmd generates the packaging metadata for a release. It is release
+// tooling for cozyctl's maintainers, so it is hidden from help.
+func ReleaseCmd() *cobra.Command {
+	var opts release.Options
+
+	releaseCmd := &cobra.Command{
+		Use:    "release",
+		Short:  "Generate Homebrew, Scoop, and deb/rpm packaging for a release",
+		Hidden: true,
+		Long: `Generate the distribution metadata for a release from the archives and
+binaries in --dist:
+
+  homebrew/cozyctl.rb    Homebrew formula for macOS and Linux
+  scoop/cozyctl.json     Scoop manifest for Windows
+  nfpm/nfpm-<arch>.yaml  nfpm configs for the deb and rpm packages
+  completions/           bash, zsh, and fish completions the packages ship
+
+--dist must hold cozyctl_<version>_<os>_<arch>.tar.gz (.zip on Windows) for
+every platform, and the Linux binaries as linux_<arch>/cozyctl. Run it with
+the cozyctl being released, so the completions match its commands.
+
+Example:
+  cozyctl release --version v1.4.0 --dist dist --out dist/packaging`,
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Version == "" {
+				opts.Version = build.CozyctlVersion()
+			}
+			root := cmd.Root()
+			opts.Generate = func(shell string, w io.Writer) error {
+				switch shell {
+				case "bash":
+					return root.GenBashCompletionV2(w, true)
+				case "zsh":
+					return root.GenZshCompletion(w)
+				case "fish":
+					return root.GenFishCompletion(w, true)
+				}
+				return fmt.Errorf("unsupported shell %q", shell)
+			}
+			return release.Generate(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	releaseCmd.Flags().StringVar(&opts.Version, "version", "", "Release tag (default: the running cozyctl's version)")
+	releaseCmd.Flags().StringVar(&opts.DistDir, "dist", "dist", "Directory with the release archives and binaries")
+	releaseCmd.Flags().StringVar(&opts.OutDir, "out", "dist/packaging", "Directory to write the packaging metadata to")
+	releaseCmd.Flags().StringVar(&opts.BaseURL, "base-url", release.DefaultBaseURL, "Download URL prefix of the archives; {version} is replaced with the tag")
+	releaseCmd.Flags().StringVar(&opts.Maintainer, "maintainer", release.DefaultMaintainer, "Maintainer of the deb and rpm packages")
+
+	return releaseCmd
+}
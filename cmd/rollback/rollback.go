@@ -0,0 +1,47 @@
+package rollback
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/rollback"
+	"github.com/spf13/cobra"
+)
+
+// RollbackCmd re-activates an earlier build of a deployment
+func RollbackCmd(globals *cmdutil.Globals) *cobra.Command {
+	var toBuild string
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback <deployment-id>",
+		Short: "Re-activate the previous build of a deployment",
+		Long: `Roll a deployment back to the build that was active before the current one,
+or with --to-build to any earlier successful build of the deployment (see
+'cozyctl builds list --deployment <id>'). The build and image tag before and
+after are shown, and cozy-hub then re-activates the build and registers its
+image with the orchestrator. Nothing is rebuilt.
+
+Rolling back is itself a deploy: rolling back again returns to the build
+that was rolled back from. A locked deployment needs --unlock-reason, and
+one that requires approval holds the rollback until it is approved (see
+'cozyctl approvals').
+
+Example:
+  cozyctl rollback my-model
+  cozyctl rollback my-model --to-build abc-123-def-456`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return rollback.Run(rollback.Options{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				ToBuild:      toBuild,
+			})
+		},
+	}
+
+	rollbackCmd.Flags().StringVar(&toBuild, "to-build", "", "Build to re-activate instead of the previous one")
+
+	return rollbackCmd
+}
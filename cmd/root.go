@@ -1,75 +1,187 @@
 package cmd
 
 import (
-	"fmt"
-	"slices"
+	"context"
+	"errors"
+	"os"
+	"time"
 
+	accountCmd "github.com/cozy-creator/cozyctl/cmd/account"
+	"github.com/cozy-creator/cozyctl/cmd/activity"
+	"github.com/cozy-creator/cozyctl/cmd/approvals"
+	"github.com/cozy-creator/cozyctl/cmd/artifacts"
+	authCmd "github.com/cozy-creator/cozyctl/cmd/auth"
+	"github.com/cozy-creator/cozyctl/cmd/bench"
 	"github.com/cozy-creator/cozyctl/cmd/build"
+	"github.com/cozy-creator/cozyctl/cmd/builds"
+	"github.com/cozy-creator/cozyctl/cmd/capabilities"
+	"github.com/cozy-creator/cozyctl/cmd/ci"
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/cmd/coldstart"
+	completionCmd "github.com/cozy-creator/cozyctl/cmd/completion"
+	configCmd "github.com/cozy-creator/cozyctl/cmd/config"
 	"github.com/cozy-creator/cozyctl/cmd/deploy"
+	"github.com/cozy-creator/cozyctl/cmd/deployments"
+	"github.com/cozy-creator/cozyctl/cmd/deps"
+	"github.com/cozy-creator/cozyctl/cmd/doctor"
+	"github.com/cozy-creator/cozyctl/cmd/env"
+	"github.com/cozy-creator/cozyctl/cmd/fixtures"
+	"github.com/cozy-creator/cozyctl/cmd/flush"
+	"github.com/cozy-creator/cozyctl/cmd/functions"
+	"github.com/cozy-creator/cozyctl/cmd/images"
+	initCmd "github.com/cozy-creator/cozyctl/cmd/init"
+	"github.com/cozy-creator/cozyctl/cmd/invoke"
+	keysCmd "github.com/cozy-creator/cozyctl/cmd/keys"
 	"github.com/cozy-creator/cozyctl/cmd/login"
 	logoutCmd "github.com/cozy-creator/cozyctl/cmd/logout"
+	"github.com/cozy-creator/cozyctl/cmd/mockserver"
+	"github.com/cozy-creator/cozyctl/cmd/models"
+	"github.com/cozy-creator/cozyctl/cmd/notifications"
+	"github.com/cozy-creator/cozyctl/cmd/org"
+	"github.com/cozy-creator/cozyctl/cmd/policy"
 	profileCmd "github.com/cozy-creator/cozyctl/cmd/profiles"
+	"github.com/cozy-creator/cozyctl/cmd/queue"
+	"github.com/cozy-creator/cozyctl/cmd/rebuild"
+	"github.com/cozy-creator/cozyctl/cmd/release"
+	"github.com/cozy-creator/cozyctl/cmd/rollback"
+	"github.com/cozy-creator/cozyctl/cmd/scan"
+	"github.com/cozy-creator/cozyctl/cmd/selfupdate"
+	signupCmd "github.com/cozy-creator/cozyctl/cmd/signup"
+	"github.com/cozy-creator/cozyctl/cmd/stacks"
+	"github.com/cozy-creator/cozyctl/cmd/status"
+	"github.com/cozy-creator/cozyctl/cmd/storage"
+	"github.com/cozy-creator/cozyctl/cmd/templates"
+	"github.com/cozy-creator/cozyctl/cmd/test"
+	"github.com/cozy-creator/cozyctl/cmd/traffic"
 	"github.com/cozy-creator/cozyctl/cmd/update"
-	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/cmd/upgradeconfig"
+	"github.com/cozy-creator/cozyctl/cmd/workers"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+	"github.com/cozy-creator/cozyctl/internal/ratelimit"
+	"github.com/cozy-creator/cozyctl/internal/redact"
 	"github.com/spf13/cobra"
 )
 
-var (
-	nameFlag    string
-	profileFlag string
-	profileCfg  *config.ProfileConfig
-)
-
+// Execute runs cozyctl. Ctrl+C and SIGTERM cancel the command's context and
+// every API request in flight; the command then reports what it left
+// running and exits with interrupt.ExitCode.
 func Execute() error {
+	ctx, stop := interrupt.NotifyContext(context.Background())
+	defer stop()
+
+	rootCmd := NewRootCmd()
+	rootCmd.SetErr(redact.Writer(os.Stderr))
+	silenceUsageOnInterrupt(rootCmd)
+	return interrupt.Check(ctx, rootCmd.ExecuteContext(ctx), "")
+}
+
+// silenceUsageOnInterrupt keeps cobra from printing a command's usage when
+// it fails only because it was interrupted.
+func silenceUsageOnInterrupt(cmd *cobra.Command) {
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			err := run(cmd, args)
+			if cmd.Context().Err() != nil || errors.As(err, new(*interrupt.Error)) {
+				cmd.SilenceUsage = true
+			}
+			return err
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		silenceUsageOnInterrupt(sub)
+	}
+}
+
+// NewRootCmd builds the full command tree. Each call returns an independent
+// tree with its own flag state.
+func NewRootCmd() *cobra.Command {
+	globals := &cmdutil.Globals{}
+
 	var rootCmd = &cobra.Command{
 		Use:   "cozyctl",
 		Short: "cozyctl - deploy and manage ML functions",
 		Long: `cozyctl is a command-line tool for deploying and managing
 machine learning functions on the Cozy platform.`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			// Skip config loading for these commands (they handle their own config)
-			skipCommands := []string{"login", "profiles", "use", "current", "delete", "build", "deploy", "update"}
-			isTrue := slices.Contains(skipCommands, cmd.Name())
-			if isTrue {
-				return nil
-			}
-
-			// Get default config (pointer to current name+profile)
-			defaultCfg, err := config.GetDefaultConfig()
-			if err != nil {
-				return fmt.Errorf("failed to load default config: %w", err)
-			}
-
-			// Determine which name and profile to use
-			name := nameFlag
-			if name == "" {
-				name = defaultCfg.CurrentName
-			}
-
-			profile := profileFlag
-			if profile == "" {
-				profile = defaultCfg.CurrentProfile
-			}
-
-			// Load the profile config
-			profileCfg, err = config.GetProfileConfig(name, profile)
-			if err != nil {
+			if err := globals.CheckOutput(cmd); err != nil {
 				return err
 			}
-
-			return nil
+			if !cmdutil.PicksProfile(cmd) {
+				if err := globals.ApplyDirectoryProfile(cmd.ErrOrStderr()); err != nil {
+					return err
+				}
+				if !cmdutil.SkipsTokenCheck(cmd) {
+					if err := globals.CheckTokenExpiry(cmd.ErrOrStderr(), time.Now()); err != nil {
+						return err
+					}
+				}
+			}
+			globals.ApplyProfileReadOnly()
+			return globals.StartHTTPSession(cmd.Context(), cmd.ErrOrStderr())
 		},
 	}
 
-	rootCmd.PersistentFlags().StringVar(&nameFlag, "name", "", "name to use for this command")
-	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "profile to use for this command")
+	rootCmd.PersistentFlags().StringVar(&globals.Name, "name", "", "name to use for this command")
+	rootCmd.PersistentFlags().StringVar(&globals.Profile, "profile", "", "profile to use for this command")
+	rootCmd.PersistentFlags().StringVarP(&globals.Output, "output", "o", "", "output format: table, json, or yaml (list and get commands; deploy and update print their summary)")
+	rootCmd.PersistentFlags().StringVar(&globals.Record, "record", "", "record API interactions (credentials redacted) to a session file")
+	rootCmd.PersistentFlags().StringVar(&globals.Replay, "replay", "", "replay API interactions from a recorded session file instead of the network")
+	rootCmd.PersistentFlags().BoolVar(&globals.NoCache, "no-cache", false, "don't reuse cached deployment and build responses the server reports unchanged")
+	rootCmd.PersistentFlags().IntVar(&globals.MaxRequests, "max-requests", ratelimit.DefaultBudget, "API requests in flight at once (0 for no limit; function invocations don't count)")
+	rootCmd.PersistentFlags().BoolVar(&globals.ReadOnly, "read-only", false, "refuse API requests that would change anything (deploy, update, delete, scale, cancel, ...)")
+	rootCmd.PersistentFlags().StringVar(&globals.UnlockReason, "unlock-reason", "", "change locked deployments anyway, recording this reason in their events")
+	rootCmd.PersistentFlags().BoolVar(&globals.StrictAuth, "strict-auth", false, "fail instead of warning when the access token is expired or expires within 24h (for CI)")
 
-	rootCmd.AddCommand(loginCmd.LoginCmd())
+	rootCmd.AddCommand(signupCmd.SignupCmd(globals))
+	rootCmd.AddCommand(loginCmd.LoginCmd(globals))
 	rootCmd.AddCommand(logoutCmd.LogoutCmd())
-	rootCmd.AddCommand(deploy.DeployCmd())
-	rootCmd.AddCommand(update.UpdateCmd())
-	rootCmd.AddCommand(build.BuildCmd())
-	rootCmd.AddCommand(profileCmd.ProfileCmd())
+	rootCmd.AddCommand(authCmd.AuthCmd(globals))
+	rootCmd.AddCommand(accountCmd.AccountCmd(globals))
+	rootCmd.AddCommand(keysCmd.KeysCmd(globals))
+	rootCmd.AddCommand(org.OrgCmd(globals))
+	rootCmd.AddCommand(deploy.DeployCmd(globals))
+	rootCmd.AddCommand(update.UpdateCmd(globals))
+	rootCmd.AddCommand(rollback.RollbackCmd(globals))
+	rootCmd.AddCommand(flush.FlushCmd(globals))
+	rootCmd.AddCommand(deployments.DeploymentsCmd(globals))
+	rootCmd.AddCommand(status.StatusCmd(globals))
+	rootCmd.AddCommand(invoke.InvokeCmd(globals))
+	rootCmd.AddCommand(bench.BenchCmd(globals))
+	rootCmd.AddCommand(coldstart.ColdStartCmd(globals))
+	rootCmd.AddCommand(queue.QueueCmd(globals))
+	rootCmd.AddCommand(functions.FunctionsCmd(globals))
+	rootCmd.AddCommand(traffic.TrafficCmd(globals))
+	rootCmd.AddCommand(approvals.ApprovalsCmd(globals))
+	rootCmd.AddCommand(stacks.StacksCmd(globals))
+	rootCmd.AddCommand(ci.CICmd())
+	rootCmd.AddCommand(policy.PolicyCmd())
+	rootCmd.AddCommand(scan.ScanCmd(globals))
+	rootCmd.AddCommand(deps.DepsCmd(globals))
+	rootCmd.AddCommand(upgradeconfig.UpgradeConfigCmd())
+	rootCmd.AddCommand(doctor.DoctorCmd(globals))
+	rootCmd.AddCommand(env.EnvCmd(globals))
+	rootCmd.AddCommand(models.ModelsCmd(globals))
+	rootCmd.AddCommand(fixtures.FixturesCmd(globals))
+	rootCmd.AddCommand(artifacts.ArtifactsCmd(globals))
+	rootCmd.AddCommand(workers.WorkersCmd(globals))
+	rootCmd.AddCommand(build.BuildCmd(globals))
+	rootCmd.AddCommand(builds.BuildsCmd(globals))
+	rootCmd.AddCommand(images.ImagesCmd(globals))
+	rootCmd.AddCommand(rebuild.RebuildCmd(globals))
+	rootCmd.AddCommand(storage.StorageCmd(globals))
+	rootCmd.AddCommand(notifications.NotificationsCmd(globals))
+	rootCmd.AddCommand(initCmd.InitCmd())
+	rootCmd.AddCommand(templates.TemplatesCmd(globals))
+	rootCmd.AddCommand(profileCmd.ProfileCmd(globals))
+	rootCmd.AddCommand(profileCmd.SwitchCmd())
+	rootCmd.AddCommand(configCmd.ConfigCmd(globals))
+	rootCmd.AddCommand(activity.ActivityCmd(globals))
+	rootCmd.AddCommand(capabilities.CapabilitiesCmd(globals))
+	rootCmd.AddCommand(test.TestCmd())
+	rootCmd.AddCommand(mockserver.MockServerCmd())
+	rootCmd.AddCommand(release.ReleaseCmd())
+	rootCmd.AddCommand(selfupdate.SelfUpdateCmd())
+	completionCmd.AddInstallCmd(rootCmd)
 
-	return rootCmd.Execute()
+	return rootCmd
 }
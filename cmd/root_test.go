@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+)
+
+func TestNewRootCmdHasIndependentState(t *testing.T) {
+	first := NewRootCmd()
+	first.SetArgs([]string{"deploy", "--from-build", "abc", "--smoke-expect", "$.ok", "--profile", "staging"})
+	first.SetOut(io.Discard)
+	first.SetErr(io.Discard)
+	// Fails flag validation before touching config or the network
+	if err := first.Execute(); err == nil {
+		t.Fatal("expected --smoke-expect without --smoke-test to fail")
+	}
+
+	second := NewRootCmd()
+	deployCmd, _, err := second.Find([]string{"deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := deployCmd.Flags().Lookup("from-build").Value.String(); got != "" {
+		t.Errorf("second tree sees --from-build %q from the first", got)
+	}
+	if got := second.PersistentFlags().Lookup("profile").Value.String(); got != "" {
+		t.Errorf("second tree sees --profile %q from the first", got)
+	}
+}
+
+func TestGlobalOutput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	run := func(args ...string) (string, error) {
+		root := NewRootCmd()
+		var out bytes.Buffer
+		root.SetArgs(args)
+		root.SetOut(&out)
+		root.SetErr(&out)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	if _, err := run("profiles", "-o", "xml"); err == nil || !strings.Contains(err.Error(), "invalid output format") {
+		t.Errorf("profiles -o xml: got %v", err)
+	}
+	if _, err := run("-o", "json", "logout"); err == nil || !strings.Contains(err.Error(), "doesn't support --output") {
+		t.Errorf("logout -o json: got %v", err)
+	}
+	if _, err := run("deploy", "--all", "-o", "json"); err == nil || !strings.Contains(err.Error(), "--output cannot be combined with --all") {
+		t.Errorf("deploy --all -o json: got %v", err)
+	}
+	// Read-only commands take the root --output flag instead of their own
+	if _, err := run("doctor", "-o", "table"); err != nil && strings.Contains(err.Error(), "output") {
+		t.Errorf("doctor -o table: got %v", err)
+	}
+	for _, path := range [][]string{{"doctor"}, {"env", "doctor"}} {
+		c, _, err := NewRootCmd().Find(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.LocalNonPersistentFlags().Lookup("output") != nil {
+			t.Errorf("%s declares its own --output", c.CommandPath())
+		}
+	}
+}
+
+func TestModelsPushKeepsProfileSelection(t *testing.T) {
+	pushCmd, _, err := NewRootCmd().Find([]string{"models", "push"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A local --name would shadow the root profile selector
+	if pushCmd.LocalNonPersistentFlags().Lookup("name") != nil {
+		t.Error("models push declares its own --name")
+	}
+	if pushCmd.Flags().Lookup("model") == nil {
+		t.Error("models push has no --model flag")
+	}
+}
+
+func TestReadOnlyDeployNeverBuilds(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Reads reach a server that knows no deployments, so the deploy would go
+	// on to build
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	profile := &config.ConfigData{
+		HubURL:          server.URL,
+		OrchestratorURL: server.URL,
+		TenantID:        "acme",
+		Token:           "tok",
+		RegistryPrefix:  "registry.example.com/acme/",
+	}
+	if err := config.SaveProfileConfig("ci", "prod", &config.ProfileConfig{Config: profile}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetProfileReadOnly(config.ProfileRef{Name: "ci", Profile: "prod"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// A docker that records being run
+	bin := t.TempDir()
+	ran := filepath.Join(bin, "ran")
+	script := "#!/bin/sh\necho \"$@\" >> " + ran + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	project := t.TempDir()
+	pyproject := "[project]\nname = \"demo\"\ndependencies = [\"gen-worker\"]\n\n[tool.cozy]\ndeployment-id = \"demo\"\n"
+	if err := os.WriteFile(filepath.Join(project, "pyproject.toml"), []byte(pyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"--read-only", "--name", "ci", "--profile", "prod", "deploy", "--local-build", "--dir", project},
+		{"--name", "ci", "--profile", "prod", "deploy", "--local-build", "--dir", project},
+	} {
+		root := NewRootCmd()
+		root.SetArgs(args)
+		root.SetOut(io.Discard)
+		root.SetErr(io.Discard)
+		err := root.Execute()
+		if err == nil || !strings.Contains(err.Error(), "'cozyctl deploy' refused") {
+			t.Errorf("%v: err = %v, want it refused", args, err)
+		}
+	}
+	if calls, err := os.ReadFile(ran); err == nil {
+		t.Errorf("read-only deploy ran docker:\n%s", calls)
+	}
+}
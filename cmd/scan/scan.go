@@ -0,0 +1,68 @@
+package scan
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/pypi"
+	"github.com/cozy-creator/cozyctl/internal/scan"
+	"github.com/spf13/cobra"
+)
+
+// ScanCmd groups commands that check a project before it is deployed
+func ScanCmd(globals *cmdutil.Globals) *cobra.Command {
+	scanCmd := &cobra.Command{
+		Use:         "scan",
+		Short:       "Check a project's dependencies before deploying it",
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+	}
+
+	scanCmd.AddCommand(LicensesCmd(globals))
+
+	return scanCmd
+}
+
+// LicensesCmd reports the licenses of a project's dependencies
+func LicensesCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts scan.LicensesOptions
+
+	licensesCmd := &cobra.Command{
+		Use:   "licenses [dir]",
+		Short: "Report dependency licenses and fail on denied ones",
+		Long: `Resolve the project's Python dependencies, look up each one's license on
+PyPI, and fail if any uses a denied license.
+
+Dependencies come from uv.lock, requirements.lock, or requirements.txt if the
+project has one; otherwise pyproject.toml is resolved with 'uv pip compile'
+when uv is installed, or only its direct dependencies are scanned.
+
+Licenses are denied by [tool.cozy.licenses] in pyproject.toml (default: AGPL
+and SSPL) and by --deny. Entries match SPDX identifiers and their versions,
+so "AGPL" denies AGPL-3.0-only and AGPL-3.0-or-later. A dual-licensed package
+is only denied if every alternative is.
+
+  [tool.cozy.licenses]
+  deny = ["AGPL", "SSPL", "GPL-3.0"]
+  ignore = ["internal-package"]   # Exempt after review
+  fail-on-unknown = true
+
+Example:
+  cozyctl scan licenses
+  cozyctl scan licenses ./my-project --deny GPL --fail-on-unknown
+  cozyctl scan licenses -o json > licenses.json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.ProjectDir = "."
+			if len(args) == 1 {
+				opts.ProjectDir = args[0]
+			}
+			opts.Output = globals.OutputFormat()
+			return scan.Licenses(cmd.Context(), opts)
+		},
+	}
+
+	licensesCmd.Flags().StringSliceVar(&opts.Deny, "deny", nil, "Also deny these licenses (repeatable)")
+	licensesCmd.Flags().BoolVar(&opts.FailOnUnknown, "fail-on-unknown", false, "Fail when a dependency's license can't be determined")
+	licensesCmd.Flags().StringVar(&opts.IndexURL, "index-url", pypi.DefaultIndexURL, "PyPI JSON API to look licenses up on")
+
+	return licensesCmd
+}
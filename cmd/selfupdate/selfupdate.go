@@ -0,0 +1,39 @@
+package selfupdate
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/selfupdate"
+	"github.com/spf13/cobra"
+)
+
+// SelfUpdateCmd replaces cozyctl with a verified release
+func SelfUpdateCmd() *cobra.Command {
+	var opts selfupdate.Options
+
+	selfUpdateCmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update cozyctl to the latest release",
+		Long: `Download the latest cozyctl release (or --version) for this platform from
+GitHub and replace the running binary with it.
+
+Every release archive is signed, and the signature is checked against the
+release keys built into this cozyctl before anything is installed. An
+archive without a signature, or with one no trusted key made, is refused.
+
+If cozyctl was installed with Homebrew, Scoop, or a deb/rpm package, update
+it with that package manager instead, so it keeps track of the version.
+
+Example:
+  cozyctl self-update
+  cozyctl self-update --version v1.4.0`,
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return selfupdate.Run(opts)
+		},
+	}
+
+	selfUpdateCmd.Flags().StringVar(&opts.Version, "version", "", "Release tag to install (default: the latest release)")
+
+	return selfUpdateCmd
+}
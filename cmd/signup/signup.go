@@ -0,0 +1,59 @@
+package signupCmd
+
+import (
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/login"
+	"github.com/spf13/cobra"
+)
+
+func SignupCmd(globals *cmdutil.Globals) *cobra.Command {
+	var opts login.SignupOptions
+	var env string
+
+	signupCmd := &cobra.Command{
+		Use:   "signup",
+		Short: "Create a Cozy account",
+		Long: `Create a Cozy account, verify its email, and log in.
+
+You are prompted for anything not given as a flag: email, username, and
+password. Cozy then emails a verification code; enter it when asked (press
+Enter on an empty line to have a new one sent). Once verified you are logged
+in and the profile is saved and made current, just like 'cozyctl login'.
+
+Examples:
+  # Interactive signup into the default profile
+  cozyctl signup
+
+  # Sign up into a named profile
+  cozyctl signup --email me@example.com --username me --name me --profile dev
+
+  # Sign up on production
+  cozyctl signup --env production`,
+		Annotations: map[string]string{cmdutil.ExplicitProfile: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			urls, err := config.EnvironmentURLs(env, opts.HubURL, opts.BuilderURL, opts.OrchestratorURL)
+			if err != nil {
+				return err
+			}
+			opts.HubURL, opts.BuilderURL, opts.OrchestratorURL = urls.HubURL, urls.BuilderURL, urls.OrchestratorURL
+			opts.Transport = globals.Transport()
+			return login.RunSignup(opts)
+		},
+	}
+
+	signupCmd.Flags().StringVar(&opts.Name, "name", "", "name/account identifier (default: 'default')")
+	signupCmd.Flags().StringVar(&opts.Profile, "profile", "", "profile/environment (default: 'default')")
+	signupCmd.Flags().StringVarP(&opts.Email, "email", "e", "", "email address for the new account")
+	signupCmd.Flags().StringVarP(&opts.Username, "username", "u", "", "username for the new account")
+	signupCmd.Flags().StringVarP(&opts.Password, "password", "p", "", "password for the new account")
+	signupCmd.Flags().StringVar(&env, "env", config.DefaultEnvironment, "environment preset: "+strings.Join(config.EnvironmentNames(), ", "))
+	signupCmd.Flags().StringVar(&opts.HubURL, "hub-url", "", "Cozy Hub API URL (default: the --env preset's)")
+	signupCmd.Flags().StringVar(&opts.BuilderURL, "builder-url", "", "Builder API URL, now part of cozy-hub (default: the --env preset's)")
+	signupCmd.Flags().StringVar(&opts.OrchestratorURL, "orchestrator-url", "", "Orchestrator API URL (default: the --env preset's)")
+
+	return signupCmd
+}
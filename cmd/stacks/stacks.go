@@ -0,0 +1,130 @@
+package stacks
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/stacks"
+	"github.com/spf13/cobra"
+)
+
+// StacksCmd groups commands that manage stacks of deployments
+func StacksCmd(globals *cmdutil.Globals) *cobra.Command {
+	stacksCmd := &cobra.Command{
+		Use:   "stacks",
+		Short: "Manage groups of deployments as one unit",
+		Long: `A stack is a named group of deployments, such as the stages of a
+preprocess → generate → postprocess pipeline, defined in one manifest
+(cozy-stack.toml by default):
+
+  name = "image-pipeline"
+
+  [[deployments]]
+  id = "preprocess"
+  build = "build-0001"
+
+  [[deployments]]
+  id = "generate"
+  build = "build-0002"
+  min-workers = 1
+  max-workers = 4
+  depends-on = ["preprocess"]`,
+	}
+
+	stacksCmd.AddCommand(ApplyCmd(globals))
+	stacksCmd.AddCommand(StatusCmd(globals))
+	stacksCmd.AddCommand(DestroyCmd(globals))
+
+	return stacksCmd
+}
+
+// ApplyCmd deploys a stack
+func ApplyCmd(globals *cmdutil.Globals) *cobra.Command {
+	var file string
+
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Deploy every deployment of a stack",
+		Long: `Deploy each deployment of a stack with the build and worker limits of its
+manifest entry, after the deployments it depends on. Deployments that
+already match are left alone. Applying stops at the first deployment that
+fails, so later stages never run against a broken earlier one.
+
+Example:
+  cozyctl stacks apply
+  cozyctl stacks apply -f pipelines/images.toml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return stacks.Apply(stacks.ApplyOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Manifest:  file,
+			})
+		},
+	}
+
+	applyCmd.Flags().StringVarP(&file, "file", "f", stacks.DefaultManifest, "Stack manifest")
+
+	return applyCmd
+}
+
+// StatusCmd shows the state of a stack
+func StatusCmd(globals *cmdutil.Globals) *cobra.Command {
+	var file string
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of every deployment of a stack",
+		Long: `Show the status, workers, and active build of each deployment of a stack,
+and whether it still matches the manifest.
+
+Example:
+  cozyctl stacks status
+  cozyctl stacks status -f pipelines/images.toml -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return stacks.Status(stacks.StatusOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Manifest:  file,
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	statusCmd.Flags().StringVarP(&file, "file", "f", stacks.DefaultManifest, "Stack manifest")
+
+	return statusCmd
+}
+
+// DestroyCmd deletes a stack
+func DestroyCmd(globals *cmdutil.Globals) *cobra.Command {
+	opts := stacks.DestroyOptions{}
+
+	destroyCmd := &cobra.Command{
+		Use:   "destroy",
+		Short: "Delete every deployment of a stack",
+		Long: `Delete each deployment of a stack, dependents first. The deployments are
+listed and must be confirmed unless --yes.
+
+Example:
+  cozyctl stacks destroy
+  cozyctl stacks destroy -f pipelines/images.toml --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			return stacks.Destroy(opts)
+		},
+	}
+
+	destroyCmd.Flags().StringVarP(&opts.Manifest, "file", "f", stacks.DefaultManifest, "Stack manifest")
+	destroyCmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Don't ask for confirmation")
+
+	return destroyCmd
+}
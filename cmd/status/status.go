@@ -0,0 +1,43 @@
+package status
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/deployments"
+	"github.com/spf13/cobra"
+)
+
+// StatusCmd shows a deployment's state and its recent events
+func StatusCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		events int
+	)
+
+	statusCmd := &cobra.Command{
+		Use:   "status <deployment-id>",
+		Short: "Show a deployment's state and recent events",
+		Long: `Show a deployment's status, image, worker counts, and per-function invocation
+counters, followed by its recent events from the orchestrator, newest first: scale ups and downs, image
+switches, and workers that crashed (with their exit code and reason) or ran
+out of memory. Use it to find out why a rollout failed.
+
+Example:
+  cozyctl status my-model
+  cozyctl status my-model --events 50
+  cozyctl status my-model -o json | jq '.events[] | select(.type == "worker_crashed")'`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return deployments.Status(deployments.StatusOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Events:       events,
+				Output:       globals.OutputFormat(),
+			})
+		},
+	}
+
+	statusCmd.Flags().IntVar(&events, "events", 10, "Number of recent events to show")
+
+	return statusCmd
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// StorageCmd groups commands that manage cozy-hub's file store
+func StorageCmd(globals *cmdutil.Globals) *cobra.Command {
+	storageCmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Manage files in the cozy-hub file store",
+	}
+
+	storageCmd.AddCommand(UsageCmd(globals))
+	storageCmd.AddCommand(PruneCmd(globals))
+
+	return storageCmd
+}
+
+// UsageCmd reports file store consumption
+func UsageCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	usageCmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Show what is using space in the file store",
+		Long: `Summarize the file store's consumption per deployment by category: build
+source tarballs, build logs, model weights, and invocation artifacts. Files
+not tied to a deployment, such as models, are listed as (shared).
+
+Example:
+  cozyctl storage usage
+  cozyctl storage usage -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return storage.Usage(storage.UsageOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	return usageCmd
+}
+
+// PruneCmd deletes old build tarballs
+func PruneCmd(globals *cmdutil.Globals) *cobra.Command {
+	opts := storage.PruneOptions{KeepDays: storage.DefaultKeepDays}
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old build tarballs from the file store",
+		Long: `Every server build uploads the project as builds/<name>/<timestamp>.tar.gz,
+and the tarballs are otherwise kept forever. prune deletes those uploaded
+more than --keep-days ago. The newest tarball of each deployment, tarballs of
+builds that haven't finished, and those 'cozyctl rebuild' policies rebuild
+from are always kept.
+
+Example:
+  cozyctl storage prune --dry-run
+  cozyctl storage prune --keep-days 7`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.DryRun {
+				if err := globals.CheckWritable(cmd); err != nil {
+					return err
+				}
+			}
+			opts.Profile = globals.ProfileRef()
+			opts.Transport = globals.Transport()
+			return storage.Prune(opts)
+		},
+	}
+
+	pruneCmd.Flags().IntVar(&opts.KeepDays, "keep-days", storage.DefaultKeepDays, "Keep tarballs uploaded within this many days")
+	pruneCmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "List the tarballs that would be deleted without deleting them")
+
+	return pruneCmd
+}
@@ -0,0 +1,119 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+// TemplatesCmd groups commands for starting projects from templates
+func TemplatesCmd(globals *cmdutil.Globals) *cobra.Command {
+	templatesCmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Start a project from a curated template",
+		Long: `Templates are starter projects, such as an SDXL image worker, Whisper
+transcription, or LLM chat, ready to build and deploy.
+
+Templates come from cozy-hub's gallery, or with --repo from a git
+repository in which each top-level directory with a template.toml is a
+template. A template.toml has a name, description, tags, and the template's
+variables:
+
+  name = "my-worker"
+  description = "What the template is for"
+
+  [[variables]]
+  name = "model"
+  description = "Hugging Face model"
+  default = "stabilityai/sdxl-turbo"`,
+	}
+
+	templatesCmd.AddCommand(ListCmd(globals))
+	templatesCmd.AddCommand(NewCmd(globals))
+
+	return templatesCmd
+}
+
+// ListCmd lists templates
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		repo string
+	)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List templates",
+		Long: `List the templates in cozy-hub's gallery, or in a git repository of templates.
+
+Example:
+  cozyctl templates list
+  cozyctl templates list --repo https://github.com/my-org/cozy-templates#main -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return templates.List(templates.ListOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Repo:      repo,
+				Output:    globals.OutputFormat(),
+			})
+		},
+	}
+
+	listCmd.Flags().StringVar(&repo, "repo", "", "Git repository of templates, as URL[#ref]")
+
+	return listCmd
+}
+
+// NewCmd creates a project from a template
+func NewCmd(globals *cmdutil.Globals) *cobra.Command {
+	var (
+		repo string
+		set  []string
+	)
+
+	newCmd := &cobra.Command{
+		Use:   "new <template> [dir]",
+		Short: "Create a project from a template",
+		Long: `Create a project from a template in a new directory (default: the
+template's name), substituting {{ variable }} in its files and file names.
+
+Every template can use project_name, which defaults to the directory's name,
+and deployment_id, which defaults to the project name. Other variables are
+declared by the template; set them with --set, or their defaults are used.
+
+Example:
+  cozyctl templates new sdxl-worker my-image-worker
+  cozyctl templates new whisper-transcription --set model=openai/whisper-small
+  cozyctl templates new my-worker ./worker --repo https://github.com/my-org/cozy-templates`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := templates.NewOptions{
+				Profile:   globals.ProfileRef(),
+				Transport: globals.Transport(),
+				Repo:      repo,
+				Template:  args[0],
+				Set:       map[string]string{},
+			}
+			if len(args) > 1 {
+				opts.Dir = args[1]
+			}
+			for _, kv := range set {
+				name, value, ok := strings.Cut(kv, "=")
+				if !ok || name == "" {
+					return fmt.Errorf("invalid --set %q: expected NAME=VALUE", kv)
+				}
+				opts.Set[name] = value
+			}
+			return templates.New(opts)
+		},
+	}
+
+	newCmd.Flags().StringVar(&repo, "repo", "", "Git repository of templates, as URL[#ref]")
+	newCmd.Flags().StringArrayVar(&set, "set", nil, "Set a template variable, as NAME=VALUE (repeatable)")
+
+	return newCmd
+}
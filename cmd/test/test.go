@@ -0,0 +1,71 @@
+package test
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/testrun"
+	"github.com/spf13/cobra"
+)
+
+type testOptions struct {
+	rebuild bool
+	gpus    string
+}
+
+func TestCmd() *cobra.Command {
+	opts := &testOptions{}
+
+	testCmd := &cobra.Command{
+		Use:   "test [path] [-- pytest-args...]",
+		Short: "Run project tests inside the build image",
+		Long: `Run the project's pytest suite inside its Docker build image.
+
+The image is built locally (or the last local build is reused) and the project
+files are mounted over their copies in the container, so dependency and CUDA
+issues that only appear in the container environment are caught before
+deploying. The image's .cozy directory, with the model manifest baked in at
+build time, is left as deployed.
+
+Arguments after -- are passed to pytest.
+
+Example:
+  cozyctl test
+  cozyctl test ./my-project --rebuild
+  cozyctl test ./my-project --gpus all
+  cozyctl test ./my-project -- -k generate -x`,
+		Annotations: map[string]string{cmdutil.SkipTokenCheck: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTest(cmd, opts, args)
+		},
+	}
+
+	testCmd.Flags().BoolVar(&opts.rebuild, "rebuild", false, "Build a fresh image instead of reusing the last local build")
+	testCmd.Flags().StringVar(&opts.gpus, "gpus", "", "GPUs to expose to the container (e.g. 'all')")
+
+	return testCmd
+}
+
+func runTest(cmd *cobra.Command, opts *testOptions, args []string) error {
+	projectPath := "."
+	pytestArgs := args
+
+	// Anything before -- is the project path
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		pytestArgs = args[dash:]
+		args = args[:dash]
+	} else {
+		pytestArgs = nil
+	}
+	if len(args) > 1 {
+		return cmd.Usage()
+	}
+	if len(args) == 1 {
+		projectPath = args[0]
+	}
+
+	return testrun.Run(testrun.Options{
+		ProjectPath: projectPath,
+		Rebuild:     opts.rebuild,
+		GPUs:        opts.gpus,
+		PytestArgs:  pytestArgs,
+	})
+}
@@ -0,0 +1,82 @@
+package traffic
+
+import (
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/traffic"
+	"github.com/spf13/cobra"
+)
+
+// TrafficCmd groups commands that split a deployment's traffic between builds
+func TrafficCmd(globals *cmdutil.Globals) *cobra.Command {
+	trafficCmd := &cobra.Command{
+		Use:   "traffic",
+		Short: "Split a deployment's traffic between builds",
+	}
+
+	trafficCmd.AddCommand(ShowCmd(globals))
+	trafficCmd.AddCommand(SetCmd(globals))
+
+	return trafficCmd
+}
+
+// ShowCmd shows how a deployment's traffic is split
+func ShowCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	showCmd := &cobra.Command{
+		Use:   "show <deployment-id>",
+		Short: "Show how a deployment's traffic is split between builds",
+		Long: `Show the builds serving a deployment and the share of requests each one
+receives. A deployment without a split sends all traffic to its active build.
+
+Example:
+  cozyctl traffic show my-model
+  cozyctl traffic show my-model -o json`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return traffic.Show(traffic.ShowOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Output:       globals.OutputFormat(),
+			})
+		},
+	}
+
+	return showCmd
+}
+
+// SetCmd changes how a deployment's traffic is split
+func SetCmd(globals *cmdutil.Globals) *cobra.Command {
+	setCmd := &cobra.Command{
+		Use:   "set <deployment-id> <build-id>=<percent>...",
+		Short: "Split a deployment's traffic between builds",
+		Long: `Send a share of a deployment's requests to each of the given successful
+builds. The percentages must add up to 100. Use it to shift load from one
+build to another step by step, and set the old build to 0 (or leave it out)
+once the new one has taken over.
+
+Example:
+  cozyctl traffic set my-model build-a=90 build-b=10
+  cozyctl traffic set my-model build-a=50 build-b=50
+  cozyctl traffic set my-model build-b=100`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			routes, err := traffic.ParseRoutes(args[1:])
+			if err != nil {
+				return err
+			}
+			return traffic.Set(traffic.SetOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Routes:       routes,
+			})
+		},
+	}
+
+	return setCmd
+}
@@ -1,19 +1,33 @@
 package update
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/ui"
 	"github.com/cozy-creator/cozyctl/internal/update"
 	"github.com/spf13/cobra"
 )
 
-var (
-	flagDryRun     bool
-	flagFunctions  string
-	flagMinWorkers int
-	flagMaxWorkers int
-	flagImageOnly  bool
-)
+type updateOptions struct {
+	dryRun       bool
+	functions    string
+	minWorkers   int
+	maxWorkers   int
+	imageOnly    bool
+	forceRebuild bool
+	summaryFile  string
+	progress     string
+
+	rollback cmdutil.RollbackFlags
+
+	confirmFunctionChanges bool
+}
+
+func UpdateCmd(globals *cmdutil.Globals) *cobra.Command {
+	opts := &updateOptions{}
 
-func UpdateCmd() *cobra.Command {
 	updateCmd := &cobra.Command{
 		Use:   "update [path]",
 		Short: "Rebuild and update an existing deployment",
@@ -28,37 +42,118 @@ This command will:
 3. Build the Docker image locally
 4. Update the existing deployment with the new image
 
+With --dry-run, nothing is built or updated: the generated Dockerfile, the
+exact UpdateDeployment payload, and the manifest of files in the project
+archive are written to .cozy/out/ in the project directory for review.
+
+With --auto-rollback, the rollout is watched for --rollback-window (default
+5m). If the deployment reports failure, its error rate exceeds
+--rollback-error-rate percent (default 5), or no worker becomes ready within
+the window, the previous image is restored and a report of why is printed.
+
+The source digest of each image deployed from the project is recorded in
+.cozy/deployed-sources.json. When the deployment still runs the image last
+deployed from here and the project's files haven't changed, the Docker build
+is skipped and only the functions and worker counts are updated (with
+--image-only there is nothing to do). Pass --force-rebuild to build anyway,
+e.g. to pick up a new base image.
+
+Before anything is built, the resolved functions are compared with those
+registered on the deployment, and a warning lists any the update adds or
+removes; removing a function takes its endpoint offline. With
+--confirm-function-changes, the update asks for confirmation when the
+functions change and stops unless it is given.
+
+A pre-deploy command under [tool.cozy.hooks] in pyproject.toml runs after
+the image is built and before the deployment is updated, with the update's
+context in COZY_* environment variables; a non-zero exit aborts the update.
+The UpdateDeployment request is then checked against the project's
+[tool.cozy.policy] Rego policies, and a denial aborts the update too.
+
+With --summary-file, a JSON summary of the result (status, image tag,
+deployment ID, function invoke URLs, phase durations, and warnings) is
+written when the update finishes, whether it succeeded or not. With -o json
+or -o yaml, the same summary is printed to stdout and progress goes to
+stderr.
+
 Example:
   cozyctl update .
   cozyctl update ./my-project
   cozyctl update ./my-project --dry-run
   cozyctl update ./my-project --image-only
-  cozyctl update ./my-project --functions "generate:true,health:false"`,
-		Args: cobra.MaximumNArgs(1),
-		RunE: runUpdate,
+  cozyctl update ./my-project --min-workers 2 --force-rebuild
+  cozyctl update ./my-project --functions "generate:true,health:false"
+  cozyctl update ./my-project --confirm-function-changes
+  cozyctl update ./my-project --progress json
+  cozyctl update ./my-project --auto-rollback --rollback-window 10m
+  cozyctl update ./my-project --summary-file update-summary.json
+  cozyctl update ./my-project -o json | jq -r .image_tag`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.dryRun {
+				if err := globals.CheckWritable(cmd); err != nil {
+					return err
+				}
+			}
+			return runUpdate(cmd.Context(), globals, opts, args)
+		},
 	}
 
-	updateCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "Show what would be done without executing")
-	updateCmd.Flags().StringVar(&flagFunctions, "functions", "", "Comma-separated function specs (e.g., 'generate:true,health:false')")
-	updateCmd.Flags().IntVar(&flagMinWorkers, "min-workers", -1, "Minimum number of workers (-1 = keep existing)")
-	updateCmd.Flags().IntVar(&flagMaxWorkers, "max-workers", -1, "Maximum number of workers (-1 = keep existing)")
-	updateCmd.Flags().BoolVar(&flagImageOnly, "image-only", false, "Only update the image, keep other settings")
+	updateCmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Write the Dockerfile, request payload, and archive manifest to .cozy/out/ instead of updating")
+	updateCmd.Flags().StringVar(&opts.functions, "functions", "", "Comma-separated function specs (e.g., 'generate:true,health:false')")
+	updateCmd.Flags().IntVar(&opts.minWorkers, "min-workers", -1, "Minimum number of workers (-1 = keep existing)")
+	updateCmd.Flags().IntVar(&opts.maxWorkers, "max-workers", -1, "Maximum number of workers (-1 = keep existing)")
+	updateCmd.Flags().BoolVar(&opts.imageOnly, "image-only", false, "Only update the image, keep other settings")
+	updateCmd.Flags().BoolVar(&opts.forceRebuild, "force-rebuild", false, "Rebuild the image even if the source is unchanged since it was last deployed")
+	updateCmd.Flags().StringVar(&opts.summaryFile, "summary-file", "", "Write a JSON summary of the result (IDs, endpoints, phase durations, warnings) to this file")
+	updateCmd.Flags().StringVar(&opts.progress, "progress", "auto", "Progress output: auto, plain, or json")
+	opts.rollback.Register(updateCmd)
+	updateCmd.Flags().BoolVar(&opts.confirmFunctionChanges, "confirm-function-changes", false, "Ask for confirmation before adding or removing the deployment's functions")
 
 	return updateCmd
 }
 
-func runUpdate(cmd *cobra.Command, args []string) error {
+func runUpdate(ctx context.Context, globals *cmdutil.Globals, opts *updateOptions, args []string) error {
 	projectPath := "."
 	if len(args) > 0 {
 		projectPath = args[0]
 	}
 
-	return update.Run(update.Options{
-		ProjectPath: projectPath,
-		DryRun:      flagDryRun,
-		Functions:   flagFunctions,
-		MinWorkers:  flagMinWorkers,
-		MaxWorkers:  flagMaxWorkers,
-		ImageOnly:   flagImageOnly,
+	progressMode, err := ui.ParseMode(opts.progress)
+	if err != nil {
+		return err
+	}
+
+	autoRollback, err := opts.rollback.Policy()
+	if err != nil {
+		return err
+	}
+
+	if opts.dryRun && opts.summaryFile != "" {
+		return fmt.Errorf("--summary-file cannot be combined with --dry-run")
+	}
+	output := globals.OutputFormat()
+	if opts.dryRun && output.Structured() {
+		return fmt.Errorf("--output cannot be combined with --dry-run")
+	}
+
+	return update.Run(ctx, update.Options{
+		Profile:      globals.ProfileRef(),
+		Transport:    globals.Transport(),
+		ProjectPath:  projectPath,
+		DryRun:       opts.dryRun,
+		Functions:    opts.functions,
+		MinWorkers:   opts.minWorkers,
+		MaxWorkers:   opts.maxWorkers,
+		ImageOnly:    opts.imageOnly,
+		ForceRebuild: opts.forceRebuild,
+		SummaryFile:  opts.summaryFile,
+		Output:       output,
+		Progress:     progressMode,
+
+		AutoRollback: autoRollback,
+
+		ConfirmFunctionChanges: opts.confirmFunctionChanges,
 	})
 }
@@ -0,0 +1,42 @@
+package upgradeconfig
+
+import (
+	"github.com/cozy-creator/cozyctl/internal/upgradeconfig"
+	"github.com/spf13/cobra"
+)
+
+// UpgradeConfigCmd brings a project's [tool.cozy] section up to current practice
+func UpgradeConfigCmd() *cobra.Command {
+	var opts upgradeconfig.Options
+
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade-config",
+		Short: "Bring a project's [tool.cozy] config up to current practice",
+		Long: `Inspect a project and extend the [tool.cozy] section of its pyproject.toml:
+
+- list the @worker_function() functions found in the source in
+  [tool.cozy.functions], so deploys don't depend on auto-detection
+- pin the Python version, and the CUDA and PyTorch versions when functions
+  need a GPU, so the base image doesn't change under the project
+- add [tool.cozy.workers] worker bounds, used by deploys that don't pass
+  --min-workers/--max-workers
+
+Existing keys, comments and formatting are kept. The changes are shown as a
+diff and written only once confirmed.
+
+Example:
+  cozyctl upgrade-config
+  cozyctl upgrade-config --dir ./my-project --dry-run
+  cozyctl upgrade-config --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return upgradeconfig.Run(opts)
+		},
+	}
+
+	upgradeCmd.Flags().StringVarP(&opts.Dir, "dir", "d", ".", "Project directory")
+	upgradeCmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show the changes without writing them")
+	upgradeCmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Don't ask for confirmation")
+
+	return upgradeCmd
+}
@@ -0,0 +1,174 @@
+package workers
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/cmd/cmdutil"
+	"github.com/cozy-creator/cozyctl/internal/workers"
+	"github.com/spf13/cobra"
+)
+
+// WorkersCmd groups commands that inspect and debug a deployment's workers
+func WorkersCmd(globals *cmdutil.Globals) *cobra.Command {
+	workersCmd := &cobra.Command{
+		Use:     "workers",
+		Aliases: []string{"worker"},
+		Short:   "Inspect and debug the containers running a deployment",
+	}
+
+	workersCmd.AddCommand(ListCmd(globals))
+	workersCmd.AddCommand(ExecCmd(globals))
+	workersCmd.AddCommand(ShellCmd(globals))
+	workersCmd.AddCommand(TopCmd(globals))
+
+	return workersCmd
+}
+
+// ListCmd lists the workers running a deployment
+func ListCmd(globals *cmdutil.Globals) *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:   "list <deployment-id>",
+		Short: "List the workers running a deployment",
+		Long: `List the worker containers running a deployment, with their status, GPU,
+and node. Use a worker ID with 'cozyctl workers exec' or 'workers shell'.
+
+Example:
+  cozyctl workers list my-model`,
+		Annotations: map[string]string{cmdutil.GlobalOutput: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return workers.List(workers.ListOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Output:       globals.OutputFormat(),
+			})
+		},
+	}
+
+	return listCmd
+}
+
+// ExecCmd runs a command inside a worker container
+func ExecCmd(globals *cmdutil.Globals) *cobra.Command {
+	var tty bool
+
+	execCmd := &cobra.Command{
+		Use:   "exec <worker-id> -- <command> [args...]",
+		Short: "Run a command inside a running worker",
+		Long: `Run a command inside a running worker container, attached to your terminal
+through the orchestrator. Use it to debug problems that only show up on the
+platform, such as CUDA or driver mismatches.
+
+A terminal is allocated when stdin is one (disable with --tty=false).
+cozyctl exits with the command's exit status. Exec sessions require a
+token with the manage scope.
+
+Example:
+  cozyctl workers exec my-model-worker-0 -- nvidia-smi
+  cozyctl workers exec my-model-worker-0 -- bash
+  cozyctl workers exec my-model-worker-0 --tty=false -- python -c 'import torch; print(torch.cuda.is_available())'`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() != 1 || len(args) < 2 {
+				return errors.New("usage: cozyctl workers exec <worker-id> -- <command> [args...]")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return runExec(cmd, globals, args[0], args[1:], tty)
+		},
+	}
+
+	execCmd.Flags().BoolVarP(&tty, "tty", "t", true, "Allocate a terminal when stdin is one")
+
+	return execCmd
+}
+
+// ShellCmd opens an interactive shell inside a worker container
+func ShellCmd(globals *cmdutil.Globals) *cobra.Command {
+	var shell string
+
+	shellCmd := &cobra.Command{
+		Use:   "shell <worker-id>",
+		Short: "Open an interactive shell inside a running worker",
+		Long: `Open an interactive shell inside a running worker container. Shorthand for
+'cozyctl workers exec <worker-id> -- bash'.
+
+Example:
+  cozyctl workers shell my-model-worker-0
+  cozyctl workers shell my-model-worker-0 --shell sh`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := globals.CheckWritable(cmd); err != nil {
+				return err
+			}
+			return runExec(cmd, globals, args[0], []string{shell}, true)
+		},
+	}
+
+	shellCmd.Flags().StringVar(&shell, "shell", "bash", "Shell to run")
+
+	return shellCmd
+}
+
+// TopCmd shows live GPU usage for a deployment's workers
+func TopCmd(globals *cmdutil.Globals) *cobra.Command {
+	var interval time.Duration
+	var once bool
+
+	topCmd := &cobra.Command{
+		Use:   "top <deployment-id>",
+		Short: "Show live GPU usage of a deployment's workers",
+		Long: `Show GPU memory, utilization, temperature, and the function being served
+for every worker of a deployment, refreshed in place like nvidia-smi. Press
+Ctrl+C to stop. When the output is not a terminal, each refresh is printed
+after a timestamp line instead.
+
+Example:
+  cozyctl workers top my-model
+  cozyctl workers top my-model --interval 5s
+  cozyctl workers top my-model --once`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return workers.Top(ctx, workers.TopOptions{
+				Profile:      globals.ProfileRef(),
+				Transport:    globals.Transport(),
+				DeploymentID: args[0],
+				Interval:     interval,
+				Once:         once,
+			})
+		},
+	}
+
+	topCmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "How often to refresh")
+	topCmd.Flags().BoolVar(&once, "once", false, "Print one snapshot and exit")
+
+	return topCmd
+}
+
+func runExec(cmd *cobra.Command, globals *cmdutil.Globals, workerID string, command []string, tty bool) error {
+	err := workers.Exec(cmd.Context(), workers.ExecOptions{
+		Profile:   globals.ProfileRef(),
+		Transport: globals.Transport(),
+		WorkerID:  workerID,
+		Command:   command,
+		TTY:       tty,
+	})
+	// The remote command already reported its own failure; only pass on the status
+	var exitErr *workers.ExitError
+	if errors.As(err, &exitErr) {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+// Package account manages the signed-in AuthKit account: its details,
+// password, sessions, and API keys.
+package account
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/login"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// InfoOptions contains the options for showing the account.
+type InfoOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Output    ui.Output
+}
+
+// Details is the account as shown by Info.
+type Details struct {
+	User           *api.AuthUser `json:"user"`
+	Profile        string        `json:"profile"`
+	TenantID       string        `json:"tenant_id"`
+	TokenExpiresAt string        `json:"token_expires_at,omitempty"`
+}
+
+// Info prints the account the profile is signed in as.
+func Info(opts InfoOptions) error {
+	profileCfg, err := config.LoadLoggedInProfile(opts.Profile)
+	if err != nil {
+		return err
+	}
+	cfg := profileCfg.Config.WithDefaultURLs()
+	client := api.NewAuthClient(cfg.HubURL, cfg.Token, opts.Transport)
+
+	user, err := client.GetUser()
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	return writeInfo(os.Stdout, &Details{
+		User:           user,
+		Profile:        profileCfg.CurrentName + "/" + profileCfg.CurrentProfile,
+		TenantID:       cfg.TenantID,
+		TokenExpiresAt: cfg.TokenExpiresAt,
+	}, opts.Output)
+}
+
+func writeInfo(w io.Writer, d *Details, output ui.Output) error {
+	if output.Structured() {
+		return ui.WriteStructured(w, output, d)
+	}
+
+	email := "-"
+	if d.User.Email != nil && *d.User.Email != "" {
+		email = *d.User.Email
+		if d.User.EmailVerified {
+			email += " (verified)"
+		} else {
+			email += " (unverified)"
+		}
+	}
+	expires := "does not expire"
+	if d.TokenExpiresAt != "" {
+		expires = d.TokenExpiresAt
+	}
+
+	fmt.Fprintf(w, "Username:       %s\n", d.User.Username)
+	fmt.Fprintf(w, "Email:          %s\n", email)
+	fmt.Fprintf(w, "User ID:        %s\n", d.User.ID)
+	if d.User.CreatedAt != "" {
+		fmt.Fprintf(w, "Created:        %s\n", d.User.CreatedAt)
+	}
+	fmt.Fprintf(w, "Tenant:         %s\n", d.TenantID)
+	fmt.Fprintf(w, "Profile:        %s\n", d.Profile)
+	fmt.Fprintf(w, "Token expires:  %s\n", expires)
+	return nil
+}
+
+// ChangePassword prompts for the current and new password and changes it.
+// The profile's own session stays signed in; the server signs out the others.
+func ChangePassword(profile config.ProfileRef, transport http.RoundTripper) error {
+	client, err := api.NewProfileAuthClient(profile, transport)
+	if err != nil {
+		return err
+	}
+
+	return changePassword(bufio.NewReader(os.Stdin), os.Stdout, client)
+}
+
+func changePassword(in *bufio.Reader, out io.Writer, client *api.AuthClient) error {
+	current, err := ui.PromptSecret(in, out, "Current password: ")
+	if err != nil {
+		return err
+	}
+	newPassword, err := ui.PromptSecret(in, out, "New password: ")
+	if err != nil {
+		return err
+	}
+	if err := login.ValidatePassword(newPassword); err != nil {
+		return fmt.Errorf("invalid password: %w", err)
+	}
+	confirm, err := ui.PromptSecret(in, out, "Confirm new password: ")
+	if err != nil {
+		return err
+	}
+	if confirm != newPassword {
+		return fmt.Errorf("passwords do not match")
+	}
+	if newPassword == current {
+		return fmt.Errorf("new password must differ from the current one")
+	}
+
+	result, err := client.ChangePassword(current, newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	fmt.Fprintln(out, "Password changed.")
+	if result.RevokedSessions > 0 {
+		fmt.Fprintf(out, "Signed out %d other session(s).\n", result.RevokedSessions)
+	}
+	return nil
+}
+
+// formatTime shows an RFC 3339 timestamp in local time, or the raw value if it does not parse.
+func formatTime(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		if ts == "" {
+			return "-"
+		}
+		return ts
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,86 @@
+package account
+
+import (
+	"bufio"
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/login"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// signIn logs in twice against a fresh mock server and returns a client for
+// the first session.
+func signIn(t *testing.T) (*api.AuthClient, string) {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+
+	first, err := login.PasswordLogin(ts.URL, "tester", "password123", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := login.PasswordLogin(ts.URL, "tester", "password123", nil); err != nil {
+		t.Fatal(err)
+	}
+	return api.NewAuthClient(ts.URL, first.AccessToken, nil), ts.URL
+}
+
+func TestChangePasswordSignsOutOtherSessions(t *testing.T) {
+	client, hubURL := signIn(t)
+
+	var out bytes.Buffer
+	in := bufio.NewReader(strings.NewReader("password123\nnew-password-1\nnew-password-1\n"))
+	if err := changePassword(in, &out, client); err != nil {
+		t.Fatalf("changePassword: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "Signed out 1 other session(s).") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	if _, err := login.PasswordLogin(hubURL, "tester", "password123", nil); err == nil {
+		t.Error("old password still works")
+	}
+	if _, err := login.PasswordLogin(hubURL, "tester", "new-password-1", nil); err != nil {
+		t.Errorf("new password rejected: %v", err)
+	}
+
+	// A mismatched confirmation never reaches the server
+	in = bufio.NewReader(strings.NewReader("new-password-1\nnew-password-2\ntypo-password\n"))
+	if err := changePassword(in, &out, client); err == nil || !strings.Contains(err.Error(), "do not match") {
+		t.Errorf("err = %v, want a mismatch error", err)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	client, _ := signIn(t)
+
+	var out bytes.Buffer
+	if err := listSessions(&out, client, ui.OutputDefault); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 3 {
+		t.Fatalf("expected a header and two sessions:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := revokeSessions(strings.NewReader("y\n"), &out, client, RevokeOptions{AllOthers: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := client.ListSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("expected only the current session to remain, got %+v", sessions)
+	}
+
+	if err := revokeSessions(strings.NewReader(""), &out, client, RevokeOptions{SessionIDs: []string{"session-missing"}}); err == nil {
+		t.Error("expected revoking an unknown session to fail")
+	}
+}
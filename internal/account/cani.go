@@ -0,0 +1,156 @@
+package account
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// actionOperations maps the commands people hit 403s with to the scope
+// operation they need.
+var actionOperations = map[string]string{
+	"view":     api.ScopeRead,
+	"get":      api.ScopeRead,
+	"list":     api.ScopeRead,
+	"logs":     api.ScopeRead,
+	"build":    api.ScopeDeploy,
+	"update":   api.ScopeDeploy,
+	"rollback": api.ScopeDeploy,
+	"call":     api.ScopeInvoke,
+	"delete":   api.ScopeManage,
+	"transfer": api.ScopeManage,
+}
+
+// CanIOptions contains the options for checking a permission.
+type CanIOptions struct {
+	Profile    config.ProfileRef
+	Transport  http.RoundTripper
+	Action     string // A scope operation, or a command such as "delete"; empty with All
+	Deployment string // Empty for every deployment
+	All        bool   // Check every operation
+	Output     ui.Output
+}
+
+// DeniedError reports that a checked permission is not granted. It makes
+// cozyctl exit with status 1, like 'kubectl auth can-i'.
+type DeniedError struct {
+	Operation string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("not allowed to %s", e.Operation)
+}
+
+// ExitCode is the status cozyctl exits with.
+func (e *DeniedError) ExitCode() int {
+	return 1
+}
+
+// ParseAction returns the scope operation an action needs.
+func ParseAction(action string) (string, error) {
+	action = strings.ToLower(strings.TrimSpace(action))
+	for _, op := range api.ScopeOperations {
+		if action == op {
+			return op, nil
+		}
+	}
+	if op, ok := actionOperations[action]; ok {
+		return op, nil
+	}
+	return "", fmt.Errorf("unknown action %q: use an operation (%s) or a command such as build, delete, or transfer", action, strings.Join(api.ScopeOperations, ", "))
+}
+
+// CanI asks cozy-hub whether the profile's token may perform an action and
+// prints the answer with the reason.
+func CanI(opts CanIOptions) error {
+	client, err := api.NewProfileAuthClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return canI(os.Stdout, client, opts)
+}
+
+func canI(w io.Writer, client *api.AuthClient, opts CanIOptions) error {
+	if opts.All {
+		return canIAll(w, client, opts)
+	}
+
+	op, err := ParseAction(opts.Action)
+	if err != nil {
+		return err
+	}
+	check, err := client.CanI(op, opts.Deployment)
+	if err != nil {
+		return fmt.Errorf("failed to check permission: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		if err := ui.WriteStructured(w, opts.Output, check); err != nil {
+			return err
+		}
+	} else {
+		answer := "no"
+		if check.Allowed {
+			answer = "yes"
+		}
+		fmt.Fprintf(w, "%s - %s\n", answer, check.Reason)
+		if !check.Allowed {
+			if hint := permissionHint(check); hint != "" {
+				fmt.Fprintln(w, hint)
+			}
+		}
+	}
+
+	if !check.Allowed {
+		return &DeniedError{Operation: op}
+	}
+	return nil
+}
+
+// canIAll checks every operation and prints a table of the answers.
+func canIAll(w io.Writer, client *api.AuthClient, opts CanIOptions) error {
+	var checks []api.PermissionCheck
+	for _, op := range api.ScopeOperations {
+		check, err := client.CanI(op, opts.Deployment)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", op, err)
+		}
+		checks = append(checks, *check)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, checks)
+	}
+
+	table := &ui.Table{Columns: []string{"OPERATION", "ALLOWED", "REASON"}}
+	for _, c := range checks {
+		allowed := "no"
+		if c.Allowed {
+			allowed = "yes"
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: c.Operation, Cells: []string{c.Operation, allowed, c.Reason}})
+	}
+	return table.Write(w)
+}
+
+// permissionHint suggests how to get a permission that was denied.
+func permissionHint(check *api.PermissionCheck) string {
+	scope := check.Operation
+	if check.Deployment != "" {
+		scope += ":" + check.Deployment
+	}
+	switch {
+	case check.Scopes != nil:
+		return fmt.Sprintf("The API key's scopes are %s; create a key with 'cozyctl keys create --scope %s'.", strings.Join(check.Scopes, ", "), scope)
+	case check.Role != "":
+		return fmt.Sprintf("Your role is %s; ask an admin for a role that allows %s ('cozyctl org members set-role').", check.Role, check.Operation)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,74 @@
+package account
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestCanIWithScopedKey(t *testing.T) {
+	client, hubURL := signIn(t)
+
+	var out bytes.Buffer
+	if err := canI(&out, client, CanIOptions{Action: "delete", Deployment: "my-model"}); err != nil {
+		t.Fatalf("the owner should be allowed to delete: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "yes - role admin") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	key, err := client.CreateAPIKey(&api.CreateAPIKeyRequest{Scopes: []string{"deploy:my-model"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyClient := api.NewAuthClient(hubURL, key.Key, nil)
+
+	out.Reset()
+	if err := canI(&out, keyClient, CanIOptions{Action: "deploy", Deployment: "my-model"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err = canI(&out, keyClient, CanIOptions{Action: "deploy", Deployment: "other-model"})
+	var denied *DeniedError
+	if !errors.As(err, &denied) || denied.ExitCode() != 1 {
+		t.Fatalf("got %v, want DeniedError", err)
+	}
+	if !strings.HasPrefix(out.String(), "no - ") || !strings.Contains(out.String(), "cozyctl keys create --scope deploy:other-model") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestCanIList(t *testing.T) {
+	client, hubURL := signIn(t)
+	key, err := client.CreateAPIKey(&api.CreateAPIKeyRequest{Scopes: []string{"invoke"}, ExpiresAt: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := canI(&out, api.NewAuthClient(hubURL, key.Key, nil), CanIOptions{All: true, Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"operation": "read",` + "\n    \"allowed\": true", `"operation": "manage",` + "\n    \"allowed\": false"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	for action, want := range map[string]string{"deploy": api.ScopeDeploy, "Delete": api.ScopeManage, "build": api.ScopeDeploy, "logs": api.ScopeRead} {
+		if got, err := ParseAction(action); err != nil || got != want {
+			t.Errorf("ParseAction(%q) = %q, %v; want %q", action, got, err, want)
+		}
+	}
+	if _, err := ParseAction("fly"); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+}
@@ -0,0 +1,163 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// CreateKeyOptions contains the options for creating an API key.
+type CreateKeyOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Name      string
+	Scopes    []string      // "operation[:deployment]"
+	Expires   time.Duration // Zero for a key that does not expire
+	Output    ui.Output
+}
+
+// CreateKey creates a scoped API key and prints its secret.
+func CreateKey(opts CreateKeyOptions) error {
+	client, err := api.NewProfileAuthClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return createKey(os.Stdout, client, opts, time.Now())
+}
+
+func createKey(w io.Writer, client *api.AuthClient, opts CreateKeyOptions, now time.Time) error {
+	if len(opts.Scopes) == 0 {
+		return fmt.Errorf("at least one --scope is required (e.g. deploy:my-model)")
+	}
+	req := &api.CreateAPIKeyRequest{Name: opts.Name}
+	for _, raw := range opts.Scopes {
+		scope, err := api.ParseScope(raw)
+		if err != nil {
+			return err
+		}
+		req.Scopes = append(req.Scopes, scope.String())
+	}
+	if opts.Expires > 0 {
+		req.ExpiresAt = now.Add(opts.Expires).UTC().Format(time.RFC3339)
+	}
+
+	key, err := client.CreateAPIKey(req)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, key)
+	}
+
+	expires := "never"
+	if key.ExpiresAt != "" {
+		expires = formatTime(key.ExpiresAt)
+	}
+	fmt.Fprintf(w, "Created API key %s\n", key.ID)
+	fmt.Fprintf(w, "  Scopes:  %s\n", strings.Join(key.Scopes, ", "))
+	fmt.Fprintf(w, "  Expires: %s\n\n", expires)
+	fmt.Fprintln(w, key.Key)
+	fmt.Fprintln(w, "\nStore the key now; it is not shown again. Use it in CI with")
+	fmt.Fprintln(w, "'cozyctl login --api-key' or the COZY_API_KEY environment variable.")
+	return nil
+}
+
+// ListKeysOptions contains the options for listing API keys.
+type ListKeysOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Output    ui.Output
+}
+
+// ListKeys prints the account's API keys.
+func ListKeys(opts ListKeysOptions) error {
+	client, err := api.NewProfileAuthClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return listKeys(os.Stdout, client, opts.Output)
+}
+
+func listKeys(w io.Writer, client *api.AuthClient, output ui.Output) error {
+	keys, err := client.ListAPIKeys()
+	if err != nil {
+		return fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	if output.Structured() {
+		return ui.WriteStructured(w, output, keys)
+	}
+
+	if len(keys) == 0 {
+		fmt.Fprintln(w, "No API keys.")
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"ID", "NAME", "PREFIX", "SCOPES", "EXPIRES", "LAST USED"}}
+	for _, k := range keys {
+		expires := "never"
+		if k.ExpiresAt != "" {
+			expires = formatTime(k.ExpiresAt)
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: k.ID, Cells: []string{
+			k.ID, orDash(k.Name), k.Prefix + "…", strings.Join(k.Scopes, ","), expires, formatTime(k.LastUsedAt),
+		}})
+	}
+	return table.Write(w)
+}
+
+// RevokeKeys deletes API keys by ID.
+func RevokeKeys(profile config.ProfileRef, transport http.RoundTripper, ids []string) error {
+	client, err := api.NewProfileAuthClient(profile, transport)
+	if err != nil {
+		return err
+	}
+	return revokeKeys(os.Stdout, client, ids)
+}
+
+func revokeKeys(w io.Writer, client *api.AuthClient, ids []string) error {
+	var errs []error
+	for _, id := range ids {
+		if err := client.RevokeAPIKey(id); err != nil {
+			fmt.Fprintf(w, "Failed to revoke %s: %v\n", id, err)
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		fmt.Fprintf(w, "Revoked %s\n", id)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to revoke %d of %d key(s): %w", len(errs), len(ids), errors.Join(errs...))
+	}
+	return nil
+}
+
+// ParseExpiry parses a key lifetime such as "30d", "12h", or "never" (zero).
+// Days are accepted on top of the units time.ParseDuration understands.
+func ParseExpiry(s string) (time.Duration, error) {
+	if s == "never" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid expiry %q (use e.g. 30d, 12h, or never)", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid expiry %q (use e.g. 30d, 12h, or never)", s)
+	}
+	return d, nil
+}
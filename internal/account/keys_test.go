@@ -0,0 +1,80 @@
+package account
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestScopedKeyIsLimitedToItsDeployment(t *testing.T) {
+	client, hubURL := signIn(t)
+
+	var out bytes.Buffer
+	opts := CreateKeyOptions{Name: "ci", Scopes: []string{"deploy:my-model"}, Expires: 30 * 24 * time.Hour, Output: ui.OutputJSON}
+	if err := createKey(&out, client, opts, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := client.ListAPIKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].ExpiresAt == "" || keys[0].Scopes[0] != "deploy:my-model" {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+	secret := strings.Split(strings.Split(out.String(), `"key": "`)[1], `"`)[0]
+
+	orchestrator := api.NewClient(hubURL, secret, nil)
+	if _, err := orchestrator.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-model", Name: "my-model", ImageURL: "img:1"}); err != nil {
+		t.Fatalf("deploying the scoped deployment: %v", err)
+	}
+	if _, err := orchestrator.GetDeployment("my-model"); err != nil {
+		t.Errorf("deploy scope should allow reads: %v", err)
+	}
+
+	_, err = orchestrator.GetDeployment("other-model")
+	if !errors.Is(err, api.ErrInsufficientScope) {
+		t.Errorf("err = %v, want ErrInsufficientScope", err)
+	}
+	if err := orchestrator.DeleteDeployment("my-model"); !errors.Is(err, api.ErrInsufficientScope) {
+		t.Errorf("err = %v, want ErrInsufficientScope for delete", err)
+	}
+
+	if err := revokeKeys(&out, client, []string{keys[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orchestrator.GetDeployment("my-model"); err == nil || !strings.Contains(err.Error(), "revoked") {
+		t.Errorf("err = %v, want a revoked key error", err)
+	}
+}
+
+func TestCreateKeyRejectsUnknownOperation(t *testing.T) {
+	client, _ := signIn(t)
+	err := createKey(&bytes.Buffer{}, client, CreateKeyOptions{Scopes: []string{"delete:my-model"}}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "operation must be one of") {
+		t.Errorf("err = %v, want an invalid scope error", err)
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d":   30 * 24 * time.Hour,
+		"12h":   12 * time.Hour,
+		"never": 0,
+	}
+	for in, want := range tests {
+		got, err := ParseExpiry(in)
+		if err != nil || got != want {
+			t.Errorf("ParseExpiry(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0d", "-1h", "month"} {
+		if _, err := ParseExpiry(in); err == nil {
+			t.Errorf("ParseExpiry(%q) should fail", in)
+		}
+	}
+}
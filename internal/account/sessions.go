@@ -0,0 +1,135 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ListSessionsOptions contains the options for listing sessions.
+type ListSessionsOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Output    ui.Output
+}
+
+// ListSessions prints the account's signed-in sessions.
+func ListSessions(opts ListSessionsOptions) error {
+	client, err := api.NewProfileAuthClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return listSessions(os.Stdout, client, opts.Output)
+}
+
+func listSessions(w io.Writer, client *api.AuthClient, output ui.Output) error {
+	sessions, err := client.ListSessions()
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	if output.Structured() {
+		return ui.WriteStructured(w, output, sessions)
+	}
+
+	if len(sessions) == 0 {
+		fmt.Fprintln(w, "No active sessions.")
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"ID", "CURRENT", "CREATED", "LAST USED", "IP", "USER AGENT"}}
+	for _, s := range sessions {
+		current := ""
+		if s.Current {
+			current = "*"
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: s.ID, Cells: []string{
+			s.ID, current, formatTime(s.CreatedAt), formatTime(s.LastUsedAt), orDash(s.IPAddress), orDash(s.UserAgent),
+		}})
+	}
+	return table.Write(w)
+}
+
+// RevokeOptions contains the options for signing out sessions.
+type RevokeOptions struct {
+	Profile    config.ProfileRef
+	Transport  http.RoundTripper
+	SessionIDs []string
+	AllOthers  bool // Revoke every session except the profile's own
+	Yes        bool // Skip the confirmation for AllOthers
+}
+
+// RevokeSessions signs out the given sessions, or all but the current one.
+func RevokeSessions(opts RevokeOptions) error {
+	client, err := api.NewProfileAuthClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return revokeSessions(os.Stdin, os.Stdout, client, opts)
+}
+
+func revokeSessions(in io.Reader, out io.Writer, client *api.AuthClient, opts RevokeOptions) error {
+	ids := opts.SessionIDs
+	var current string
+
+	sessions, err := client.ListSessions()
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for _, s := range sessions {
+		if s.Current {
+			current = s.ID
+		}
+		if opts.AllOthers && !s.Current {
+			ids = append(ids, s.ID)
+		}
+	}
+
+	if opts.AllOthers {
+		if len(ids) == 0 {
+			fmt.Fprintln(out, "No other sessions.")
+			return nil
+		}
+		if !opts.Yes {
+			ok, err := ui.Confirm(in, out, fmt.Sprintf("Sign out %d other session(s)?", len(ids)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(out, "Aborted.")
+				return nil
+			}
+		}
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := client.RevokeSession(id); err != nil {
+			fmt.Fprintf(out, "Failed to revoke %s: %v\n", id, err)
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		fmt.Fprintf(out, "Revoked %s\n", id)
+		if id == current {
+			fmt.Fprintln(out, "That was this profile's session; run 'cozyctl login' to sign in again.")
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to revoke %d of %d session(s): %w", len(errs), len(ids), errors.Join(errs...))
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Approval states.
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// Approval is a deploy to a deployment that requires approval, held by
+// cozy-hub until an authorized user approves or rejects it. Approving it
+// activates the build.
+type Approval struct {
+	ID           string `json:"id"`
+	DeploymentID string `json:"deployment_id"`
+	BuildID      string `json:"build_id"`
+	Status       string `json:"status"`        // One of the Approval* states
+	URL          string `json:"url,omitempty"` // Where to review it in the dashboard
+	RequestedBy  string `json:"requested_by,omitempty"`
+	DecidedBy    string `json:"decided_by,omitempty"`
+	Comment      string `json:"comment,omitempty"`
+	CreatedAt    string `json:"created_at"`
+	DecidedAt    string `json:"decided_at,omitempty"`
+}
+
+// DecideApprovalRequest is the request body for approving or rejecting a deploy.
+type DecideApprovalRequest struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// ListApprovalsResponse is the response from listing approvals.
+type ListApprovalsResponse struct {
+	Approvals []Approval `json:"approvals"`
+}
+
+// PendingApprovalError is returned by DeployBuild when the deployment
+// requires approval: nothing was deployed yet, and Approval is the pending
+// change.
+type PendingApprovalError struct {
+	Approval *Approval
+}
+
+func (e *PendingApprovalError) Error() string {
+	return fmt.Sprintf("deploy of build %s to %s is waiting for approval %s",
+		e.Approval.BuildID, e.Approval.DeploymentID, e.Approval.ID)
+}
+
+// ListApprovals lists deploys submitted for approval, newest first. An
+// empty status lists them all.
+func (c *BuilderClient) ListApprovals(status string) ([]Approval, error) {
+	path := "/api/v1/approvals"
+	if status != "" {
+		path += "?status=" + url.QueryEscape(status)
+	}
+	var listResp ListApprovalsResponse
+	if err := c.doApproval("GET", path, "", nil, &listResp); err != nil {
+		return nil, err
+	}
+	return listResp.Approvals, nil
+}
+
+// GetApproval fetches a deploy submitted for approval.
+func (c *BuilderClient) GetApproval(id string) (*Approval, error) {
+	var approval Approval
+	if err := c.doApproval("GET", "/api/v1/approvals/"+id, id, nil, &approval); err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// ApproveDeploy approves a pending deploy, which activates its build.
+func (c *BuilderClient) ApproveDeploy(id, comment string) (*Approval, error) {
+	return c.decideApproval(id, "approve", comment)
+}
+
+// RejectDeploy rejects a pending deploy; the deployment is left unchanged.
+func (c *BuilderClient) RejectDeploy(id, comment string) (*Approval, error) {
+	return c.decideApproval(id, "reject", comment)
+}
+
+func (c *BuilderClient) decideApproval(id, action, comment string) (*Approval, error) {
+	var approval Approval
+	err := c.doApproval("POST", "/api/v1/approvals/"+id+"/"+action, id, &DecideApprovalRequest{Comment: comment}, &approval)
+	if err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// doApproval sends a request to the approvals API and decodes the response
+// into out. id names the approval in not-found errors.
+func (c *BuilderClient) doApproval(method, path, id string, req, out any) error {
+	if err := c.require(FeatureApprovals); err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound && id != "" {
+		return fmt.Errorf("approval '%s' not found", id)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AuthClient is an HTTP client for the AuthKit account endpoints served by cozy-hub.
+type AuthClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewAuthClient creates a new AuthKit API client whose requests go through
+// transport, or http.DefaultTransport if it is nil.
+func NewAuthClient(baseURL, token string, transport http.RoundTripper) *AuthClient {
+	return &AuthClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+// AuthUser is the account an access token belongs to.
+type AuthUser struct {
+	ID            string  `json:"id"`
+	Username      string  `json:"username"`
+	Email         *string `json:"email"`
+	EmailVerified bool    `json:"email_verified,omitempty"`
+	CreatedAt     string  `json:"created_at,omitempty"`
+}
+
+// AuthSession is a signed-in session of the account.
+type AuthSession struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	IPAddress  string `json:"ip_address,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	Current    bool   `json:"current,omitempty"` // The session making the request
+}
+
+// ListSessionsResponse is the response from GET /api/v1/auth/sessions.
+type ListSessionsResponse struct {
+	Sessions []AuthSession `json:"sessions"`
+}
+
+// ChangePasswordRequest is the request body for POST /api/v1/auth/password/change.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangePasswordResponse reports the sessions signed out by a password change.
+type ChangePasswordResponse struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+// RegisterRequest is the request body for POST /api/v1/auth/register.
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RegisterResponse is returned when an account is created.
+type RegisterResponse struct {
+	UserID               string `json:"user_id"`
+	VerificationRequired bool   `json:"verification_required"` // A code was emailed and must be verified before login
+}
+
+// VerifyEmailRequest is the request body for POST /api/v1/auth/email/verify.
+type VerifyEmailRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+// APIKey is a long-lived token for automation, limited to its scopes.
+type APIKey struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	Prefix     string   `json:"prefix"` // Leading characters of the key, to recognize it
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at,omitempty"` // Empty if the key does not expire
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+}
+
+// CreateAPIKeyRequest is the request body for POST /api/v1/auth/keys.
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name,omitempty"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyResponse is a new key. The secret is only ever returned here.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// ListAPIKeysResponse is the response from GET /api/v1/auth/keys.
+type ListAPIKeysResponse struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// PermissionCheckRequest is the request body for POST /api/v1/auth/can-i.
+type PermissionCheckRequest struct {
+	Operation  string `json:"operation"`
+	Deployment string `json:"deployment,omitempty"` // Empty for every deployment
+}
+
+// PermissionCheck is whether the token making the request may perform an
+// operation, and why.
+type PermissionCheck struct {
+	Operation  string   `json:"operation"`
+	Deployment string   `json:"deployment,omitempty"`
+	Allowed    bool     `json:"allowed"`
+	Reason     string   `json:"reason"`
+	Role       string   `json:"role,omitempty"`   // The member's role, for a signed-in session
+	Scopes     []string `json:"scopes,omitempty"` // The key's scopes, for an API key
+}
+
+// Register creates an account. It does not need a token.
+func (c *AuthClient) Register(req *RegisterRequest) (*RegisterResponse, error) {
+	var result RegisterResponse
+	if err := c.do("POST", "/api/v1/auth/register", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// VerifyEmail confirms an account's email with the code sent to it.
+func (c *AuthClient) VerifyEmail(email, code string) error {
+	return c.do("POST", "/api/v1/auth/email/verify", &VerifyEmailRequest{Email: email, Code: code}, nil)
+}
+
+// ResendVerification emails a new verification code.
+func (c *AuthClient) ResendVerification(email string) error {
+	return c.do("POST", "/api/v1/auth/email/resend", map[string]string{"email": email}, nil)
+}
+
+// GetUser returns the account the client's token belongs to.
+func (c *AuthClient) GetUser() (*AuthUser, error) {
+	var user AuthUser
+	if err := c.do("GET", "/api/v1/auth/user/me", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ChangePassword changes the account password. The server signs out the
+// account's other sessions.
+func (c *AuthClient) ChangePassword(current, newPassword string) (*ChangePasswordResponse, error) {
+	var result ChangePasswordResponse
+	req := &ChangePasswordRequest{CurrentPassword: current, NewPassword: newPassword}
+	if err := c.do("POST", "/api/v1/auth/password/change", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListSessions lists the account's signed-in sessions.
+func (c *AuthClient) ListSessions() ([]AuthSession, error) {
+	var list ListSessionsResponse
+	if err := c.do("GET", "/api/v1/auth/sessions", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Sessions, nil
+}
+
+// RevokeSession signs out a session.
+func (c *AuthClient) RevokeSession(id string) error {
+	return c.do("DELETE", "/api/v1/auth/sessions/"+id, nil, nil)
+}
+
+// CreateAPIKey creates a scoped API key.
+func (c *AuthClient) CreateAPIKey(req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	var result CreateAPIKeyResponse
+	if err := c.do("POST", "/api/v1/auth/keys", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListAPIKeys lists the account's API keys.
+func (c *AuthClient) ListAPIKeys() ([]APIKey, error) {
+	var list ListAPIKeysResponse
+	if err := c.do("GET", "/api/v1/auth/keys", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Keys, nil
+}
+
+// RevokeAPIKey deletes an API key; requests made with it fail from then on.
+func (c *AuthClient) RevokeAPIKey(id string) error {
+	return c.do("DELETE", "/api/v1/auth/keys/"+id, nil, nil)
+}
+
+// CanI asks cozy-hub whether the client's token may perform a scope
+// operation, on one deployment or, when deployment is empty, on all of them.
+func (c *AuthClient) CanI(operation, deployment string) (*PermissionCheck, error) {
+	var result PermissionCheck
+	req := &PermissionCheckRequest{Operation: operation, Deployment: deployment}
+	if err := c.do("POST", "/api/v1/auth/can-i", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// do sends an authenticated request with an optional JSON body and decodes
+// the JSON response into out when it is non-nil.
+func (c *AuthClient) do(method, path string, req, out any) error {
+	var reqBody io.Reader
+	if req != nil {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if req != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
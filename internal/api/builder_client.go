@@ -6,8 +6,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/redact"
 )
 
 // BuilderClient is an HTTP client for the cozy-hub builder API.
@@ -15,32 +20,98 @@ type BuilderClient struct {
 	baseURL    string
 	token      string
 	httpClient *http.Client
+	caps       capabilityCache
 }
 
-// NewBuilderClient creates a new cozy-hub builder API client.
-func NewBuilderClient(baseURL, token string) *BuilderClient {
+// NewBuilderClient creates a new cozy-hub builder API client whose requests go through
+// transport, or http.DefaultTransport if it is nil.
+func NewBuilderClient(baseURL, token string, transport http.RoundTripper) *BuilderClient {
 	return &BuilderClient{
 		baseURL: strings.TrimRight(baseURL, "/"),
 		token:   token,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
 	}
 }
 
 // Build represents a build in cozy-hub.
 type Build struct {
-	ID           string  `json:"id"`
-	TenantID     string  `json:"tenant_id"`
-	DeploymentID string  `json:"deployment_id,omitempty"`
-	Status       string  `json:"status"`
-	TarballPath  string  `json:"tarball_path,omitempty"`
-	ImageTag     string  `json:"image_tag,omitempty"`
-	ErrorMessage string  `json:"error_message,omitempty"`
-	StartedAt    *string `json:"started_at,omitempty"`
-	FinishedAt   *string `json:"finished_at,omitempty"`
-	CreatedAt    string  `json:"created_at"`
-	UpdatedAt    string  `json:"updated_at"`
+	ID           string `json:"id"`
+	TenantID     string `json:"tenant_id"`
+	DeploymentID string `json:"deployment_id,omitempty"`
+	Status       string `json:"status"`
+	TarballPath  string `json:"tarball_path,omitempty"`
+	ImageTag     string `json:"image_tag,omitempty"`
+	ImageDigest  string `json:"image_digest,omitempty"` // sha256:<hex> of the pushed image
+	BaseDigest   string `json:"base_image_digest,omitempty"`
+	BuilderID    string `json:"builder_id,omitempty"` // Identity of the builder that ran the build
+	ErrorMessage string `json:"error_message,omitempty"`
+	Priority     string `json:"priority,omitempty"`
+	Machine      string `json:"machine,omitempty"` // Builder machine type the build runs on
+	// QueuePosition is 1 for the next queued build to start; 0 once started.
+	QueuePosition    int     `json:"queue_position,omitempty"`
+	EstimatedStartAt *string `json:"estimated_start_at,omitempty"`
+	StartedAt        *string `json:"started_at,omitempty"`
+	FinishedAt       *string `json:"finished_at,omitempty"`
+	CreatedAt        string  `json:"created_at"`
+	UpdatedAt        string  `json:"updated_at"`
+}
+
+// Build priorities. Queued builds start highest priority first, then oldest
+// first, so urgent builds can jump ahead of bulk CI builds.
+const (
+	BuildPriorityHigh   = "high"
+	BuildPriorityNormal = "normal"
+	BuildPriorityLow    = "low"
+)
+
+// BuildPriorities lists the valid build priorities, highest first.
+var BuildPriorities = []string{BuildPriorityHigh, BuildPriorityNormal, BuildPriorityLow}
+
+// ParseBuildPriority validates a build priority. Empty leaves the choice to
+// the server (normal).
+func ParseBuildPriority(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s != "" && !slices.Contains(BuildPriorities, s) {
+		return "", fmt.Errorf("invalid build priority %q: must be one of %s", s, strings.Join(BuildPriorities, ", "))
+	}
+	return s, nil
+}
+
+// Builder machine types. Builds run on small machines unless a project's
+// dependencies need more CPU and memory to compile, or a GPU.
+const (
+	BuildMachineSmall = "small"
+	BuildMachineLarge = "large"
+	BuildMachineGPU   = "gpu"
+)
+
+// BuildMachines lists the valid builder machine types.
+var BuildMachines = []string{BuildMachineSmall, BuildMachineLarge, BuildMachineGPU}
+
+// ParseBuildMachine validates a builder machine type. Empty leaves the choice
+// to the server (small).
+func ParseBuildMachine(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s != "" && !slices.Contains(BuildMachines, s) {
+		return "", fmt.Errorf("invalid build machine %q: must be one of %s", s, strings.Join(BuildMachines, ", "))
+	}
+	return s, nil
+}
+
+// BuildOptions are the optional settings of a new build.
+type BuildOptions struct {
+	Priority string // One of BuildPriorities; empty for the server default
+	Machine  string // One of BuildMachines; empty for the server default
+}
+
+// CreateBuildRequest is the request body for POST /api/v1/builds.
+type CreateBuildRequest struct {
+	TarballPath string `json:"tarball_path"`
+	Priority    string `json:"priority,omitempty"`
+	Machine     string `json:"machine,omitempty"`
 }
 
 // BuildLog represents a single log entry from a build.
@@ -59,6 +130,127 @@ type BuildLogsResponse struct {
 	Count int        `json:"count"`
 }
 
+// ListBuildsResponse is the response from GET /api/v1/builds.
+type ListBuildsResponse struct {
+	Builds []Build `json:"builds"`
+	Count  int     `json:"count"`
+}
+
+// BuildArtifact is a file the builder attached to a build, such as the
+// generated Dockerfile, a dependency lock snapshot, an SBOM, or a logs archive.
+type BuildArtifact struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind,omitempty"` // dockerfile, lockfile, sbom, logs, ...
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// ListBuildArtifactsResponse is the response from GET /api/v1/builds/:id/artifacts.
+type ListBuildArtifactsResponse struct {
+	Artifacts []BuildArtifact `json:"artifacts"`
+}
+
+// ProvenanceStatement is an in-toto v1 statement carrying SLSA v1 build
+// provenance, stored alongside a build at /api/v1/builds/:id/provenance.
+type ProvenanceStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     SLSAProvenance       `json:"predicate"`
+}
+
+// ResourceDescriptor identifies an artifact by name and digest.
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"` // Algorithm to hex digest
+}
+
+// SLSAProvenance is the SLSA v1 provenance predicate.
+type SLSAProvenance struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition describes what was built and from which inputs.
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]any       `json:"externalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// RunDetails describes who ran a build and when.
+type RunDetails struct {
+	Builder  ProvenanceBuilder  `json:"builder"`
+	Metadata ProvenanceMetadata `json:"metadata"`
+}
+
+// ProvenanceBuilder identifies the builder that produced an artifact.
+type ProvenanceBuilder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// ProvenanceMetadata records the invocation of a build.
+type ProvenanceMetadata struct {
+	InvocationID string `json:"invocationId"`
+	StartedOn    string `json:"startedOn,omitempty"`
+	FinishedOn   string `json:"finishedOn,omitempty"`
+}
+
+// RegisterModelRequest is the request body for POST /api/v1/models.
+type RegisterModelRequest struct {
+	ID        string      `json:"id"`     // Model reference, e.g. hf:org/repo or cozy:name
+	Source    string      `json:"source"` // huggingface or cozy
+	Repo      string      `json:"repo,omitempty"`
+	Revision  string      `json:"revision,omitempty"`
+	SHA       string      `json:"sha,omitempty"` // Commit the revision resolved to
+	SizeBytes int64       `json:"size_bytes,omitempty"`
+	Gated     bool        `json:"gated,omitempty"`
+	Path      string      `json:"path,omitempty"` // File store prefix of uploaded weights
+	Files     []ModelFile `json:"files,omitempty"`
+}
+
+// ModelFile is one file of a model uploaded to the file store.
+type ModelFile struct {
+	Path   string `json:"path"` // Relative to the model's file store prefix
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Model is a model registered with cozy-hub, so workers can fetch it
+// without resolving it at cold start.
+type Model struct {
+	ID        string      `json:"id"`
+	Source    string      `json:"source"`
+	Repo      string      `json:"repo,omitempty"`
+	Revision  string      `json:"revision,omitempty"`
+	SHA       string      `json:"sha,omitempty"`
+	SizeBytes int64       `json:"size_bytes,omitempty"`
+	Gated     bool        `json:"gated,omitempty"`
+	Path      string      `json:"path,omitempty"`
+	Files     []ModelFile `json:"files,omitempty"`
+	CreatedAt string      `json:"created_at"`
+	UpdatedAt string      `json:"updated_at"`
+}
+
+// MultipartUpload is an upload to the file store sent in parts.
+type MultipartUpload struct {
+	UploadID string `json:"upload_id"`
+	Path     string `json:"path"`
+}
+
+// UploadedPart identifies a part of a multipart upload when completing it.
+type UploadedPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// CompleteMultipartUploadRequest is the request body for completing a multipart upload.
+type CompleteMultipartUploadRequest struct {
+	Parts []UploadedPart `json:"parts"`
+}
+
 // Deployment represents a deployment in cozy-hub.
 type HubDeployment struct {
 	ID              string  `json:"id"`
@@ -71,6 +263,174 @@ type HubDeployment struct {
 	DeploymentType  string  `json:"deployment_type,omitempty"`
 	CreatedAt       string  `json:"created_at"`
 	UpdatedAt       string  `json:"updated_at"`
+
+	RequiresApproval bool `json:"requires_approval,omitempty"` // Deploys wait for an approval
+}
+
+// DeployResponse describes the deployment as the result of a deploy.
+func (d *HubDeployment) DeployResponse() *BuilderDeployResponse {
+	resp := &BuilderDeployResponse{
+		ID:        d.ID,
+		TenantID:  d.TenantID,
+		ImageTag:  d.ImageURL,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+	if d.ActiveBuildID != nil {
+		resp.ActiveBuildID = *d.ActiveBuildID
+	}
+	if d.PreviousBuildID != nil {
+		resp.PreviousBuildID = *d.PreviousBuildID
+	}
+	return resp
+}
+
+// TrafficRoute sends a share of a deployment's requests to one build.
+type TrafficRoute struct {
+	BuildID  string `json:"build_id"`
+	Percent  int    `json:"percent"`
+	ImageTag string `json:"image_tag,omitempty"` // Set by the server
+}
+
+// TrafficSplit is how a deployment's requests are divided between builds.
+// Percentages add up to 100.
+type TrafficSplit struct {
+	DeploymentID string         `json:"deployment_id"`
+	Routes       []TrafficRoute `json:"routes"`
+	UpdatedAt    string         `json:"updated_at,omitempty"`
+}
+
+// SetTrafficRequest is the request body for PUT /api/v1/deployments/:id/traffic.
+type SetTrafficRequest struct {
+	Routes []TrafficRoute `json:"routes"`
+}
+
+// Rebuild schedules: how often a rebuild policy checks the base image for
+// security updates.
+const (
+	RebuildDaily  = "daily"
+	RebuildWeekly = "weekly"
+)
+
+// RebuildPolicy rebuilds and redeploys a deployment from its last source
+// tarball whenever a scheduled check finds its base image has security updates.
+type RebuildPolicy struct {
+	DeploymentID  string  `json:"deployment_id"`
+	Schedule      string  `json:"schedule"`
+	SourceBuildID string  `json:"source_build_id"` // Build whose source tarball is rebuilt
+	LastCheckedAt *string `json:"last_checked_at,omitempty"`
+	LastRebuildID string  `json:"last_rebuild_id,omitempty"`
+	NextCheckAt   string  `json:"next_check_at,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// SetRebuildPolicyRequest is the request body for PUT /api/v1/deployments/:id/rebuild-policy.
+type SetRebuildPolicyRequest struct {
+	Schedule string `json:"schedule"`
+}
+
+// ListRebuildPoliciesResponse is the response from GET /api/v1/rebuild-policies.
+type ListRebuildPoliciesResponse struct {
+	Policies []RebuildPolicy `json:"policies"`
+}
+
+// Events a notification rule can be triggered by.
+const (
+	NotifyBuildFailed     = "build-failed"
+	NotifyBuildSucceeded  = "build-succeeded"
+	NotifyDeployFailed    = "deploy-failed"
+	NotifyDeploySucceeded = "deploy-succeeded"
+)
+
+// NotificationEvents lists the valid notification events.
+var NotificationEvents = []string{NotifyBuildFailed, NotifyBuildSucceeded, NotifyDeployFailed, NotifyDeploySucceeded}
+
+// Notification channels.
+const (
+	ChannelEmail   = "email"   // Target is an address; defaults to the account's
+	ChannelWebhook = "webhook" // Target is a URL that gets a JSON POST per event
+)
+
+// NotificationRule sends the tenant's build and deploy events to a channel.
+type NotificationRule struct {
+	ID         string   `json:"id"`
+	Events     []string `json:"events"`
+	Channel    string   `json:"channel"`
+	Target     string   `json:"target,omitempty"`
+	Deployment string   `json:"deployment,omitempty"` // Empty for every deployment
+	CreatedAt  string   `json:"created_at"`
+}
+
+// SetNotificationRuleRequest is the request body for POST /api/v1/notifications.
+// A rule with the same channel, target, and deployment is replaced.
+type SetNotificationRuleRequest struct {
+	Events     []string `json:"events"`
+	Channel    string   `json:"channel"`
+	Target     string   `json:"target,omitempty"`
+	Deployment string   `json:"deployment,omitempty"`
+}
+
+// ListNotificationRulesResponse is the response from GET /api/v1/notifications.
+type ListNotificationRulesResponse struct {
+	Rules []NotificationRule `json:"rules"`
+}
+
+// ProjectTemplate is a curated starter project in cozy-hub's template gallery.
+type ProjectTemplate struct {
+	Name        string             `json:"name" toml:"name"`
+	Description string             `json:"description" toml:"description"`
+	Tags        []string           `json:"tags,omitempty" toml:"tags"`
+	Variables   []TemplateVariable `json:"variables,omitempty" toml:"variables"`
+}
+
+// TemplateVariable is a value substituted for {{ name }} in a template's
+// files and paths when it is instantiated.
+type TemplateVariable struct {
+	Name        string `json:"name" toml:"name"`
+	Description string `json:"description,omitempty" toml:"description"`
+	Default     string `json:"default,omitempty" toml:"default"` // Required when empty
+}
+
+// ListTemplatesResponse is the response from GET /api/v1/templates.
+type ListTemplatesResponse struct {
+	Templates []ProjectTemplate `json:"templates"`
+}
+
+// StoredFile is a file in cozy-hub's file store.
+type StoredFile struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ListFilesResponse is the response from GET /api/v1/files.
+type ListFilesResponse struct {
+	Files []StoredFile `json:"files"`
+}
+
+// File store usage categories.
+const (
+	StorageTarballs  = "tarballs"   // Build source tarballs
+	StorageBuildLogs = "build_logs" // Logs of finished builds
+	StorageModels    = "models"     // Uploaded model weights
+	StorageArtifacts = "artifacts"  // Invocation outputs
+)
+
+// StorageCategories lists the file store usage categories.
+var StorageCategories = []string{StorageTarballs, StorageBuildLogs, StorageModels, StorageArtifacts}
+
+// StorageUsage is the tenant's file store consumption.
+type StorageUsage struct {
+	Entries    []StorageUsageEntry `json:"entries"`
+	TotalBytes int64               `json:"total_bytes"`
+}
+
+// StorageUsageEntry is the space one category of files takes for one deployment.
+type StorageUsageEntry struct {
+	DeploymentID string `json:"deployment_id,omitempty"` // Empty for files not tied to a deployment, such as models
+	Category     string `json:"category"`
+	Files        int    `json:"files"`
+	Bytes        int64  `json:"bytes"`
 }
 
 // BuildUploadResponse is returned after creating a build.
@@ -81,14 +441,33 @@ type BuildUploadResponse struct {
 
 // BuildStatusResponse is the response from GET /api/v1/builds/:id.
 type BuildStatusResponse struct {
-	ID          string  `json:"id"`
-	Status      string  `json:"status"`
-	ImageTag    string  `json:"image_tag,omitempty"`
-	LogsPath    string  `json:"logs_path,omitempty"`
-	Error       string  `json:"error,omitempty"`
-	CreatedAt   string  `json:"created_at"`
-	StartedAt   *string `json:"started_at,omitempty"`
-	CompletedAt *string `json:"completed_at,omitempty"`
+	ID               string  `json:"id"`
+	Status           string  `json:"status"`
+	ImageTag         string  `json:"image_tag,omitempty"`
+	ImageDigest      string  `json:"image_digest,omitempty"`
+	BaseDigest       string  `json:"base_image_digest,omitempty"`
+	BuilderID        string  `json:"builder_id,omitempty"`
+	LogsPath         string  `json:"logs_path,omitempty"`
+	Error            string  `json:"error,omitempty"`
+	Priority         string  `json:"priority,omitempty"`
+	Machine          string  `json:"machine,omitempty"`
+	QueuePosition    int     `json:"queue_position,omitempty"`     // While queued; 1 is next
+	EstimatedStartAt *string `json:"estimated_start_at,omitempty"` // While queued, if the builder can tell
+	CreatedAt        string  `json:"created_at"`
+	StartedAt        *string `json:"started_at,omitempty"`
+	CompletedAt      *string `json:"completed_at,omitempty"`
+}
+
+// DeployBuildRequest is the request body for POST /api/v1/builds/:id/deploy.
+// DeploymentID is optional; when empty cozy-hub deploys to the build's own deployment.
+type DeployBuildRequest struct {
+	TenantID     string `json:"tenant_id,omitempty"`
+	DeploymentID string `json:"deployment_id,omitempty"`
+}
+
+// RollbackRequest is the request body for rolling back a deployment.
+type RollbackRequest struct {
+	BuildID string `json:"build_id,omitempty"` // The previous build if empty
 }
 
 // BuilderDeployResponse is the response from the deploy endpoint.
@@ -103,73 +482,108 @@ type BuilderDeployResponse struct {
 }
 
 // UploadTarball uploads a tarball to cozy-hub's file store.
+// The tarball is streamed, so it may be produced while the upload is in flight.
 // Returns the S3 path (tarball_path) to use when creating a build.
-func (c *BuilderClient) UploadTarball(tarball *bytes.Buffer, buildName string) (string, error) {
+func (c *BuilderClient) UploadTarball(tarball io.Reader, buildName string) (string, error) {
 	// Generate a unique path for the tarball
 	tarballPath := fmt.Sprintf("builds/%s/%d.tar.gz", buildName, time.Now().UnixNano())
 
-	url := fmt.Sprintf("%s/api/v1/file/%s", c.baseURL, tarballPath)
-	httpReq, err := http.NewRequest("PUT", url, tarball)
+	if err := c.UploadFile(tarballPath, tarball, "application/gzip"); err != nil {
+		return "", err
+	}
+	return tarballPath, nil
+}
+
+// ListFiles lists the files in cozy-hub's file store whose path starts with prefix.
+func (c *BuilderClient) ListFiles(prefix string) ([]StoredFile, error) {
+	reqURL := c.baseURL + "/api/v1/files"
+	if prefix != "" {
+		reqURL += "?" + neturl.Values{"prefix": {prefix}}.Encode()
+	}
+	httpReq, err := http.NewRequest("GET", reqURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/gzip")
 	if c.token != "" {
 		httpReq.Header.Set("Authorization", "Bearer "+c.token)
 	}
 
-	// Use a longer timeout for uploads
-	uploadClient := &http.Client{Timeout: 5 * time.Minute}
-	resp, err := uploadClient.Do(httpReq)
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("upload request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
-			return "", fmt.Errorf("upload failed (%d): %s", resp.StatusCode, errResp.Error)
-		}
-		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return "", fmt.Errorf("upload failed (%d): %s", resp.StatusCode, errResp.Message)
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
 		}
-		return "", fmt.Errorf("upload failed (%d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
 	}
 
-	return tarballPath, nil
+	var listResp ListFilesResponse
+	if err := json.Unmarshal(respBody, &listResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return listResp.Files, nil
 }
 
-// UploadBuild uploads a tarball and creates a build in cozy-hub.
-func (c *BuilderClient) UploadBuild(tarball *bytes.Buffer, buildName string) (*BuildUploadResponse, error) {
-	// Step 1: Upload tarball to file store
-	tarballPath, err := c.UploadTarball(tarball, buildName)
+// GetStorageUsage summarizes the tenant's file store consumption by
+// deployment and category.
+func (c *BuilderClient) GetStorageUsage() (*StorageUsage, error) {
+	httpReq, err := http.NewRequest("GET", c.baseURL+"/api/v1/storage/usage", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload tarball: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Step 2: Create build with tarball path
-	return c.CreateBuild(tarballPath)
-}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
 
-// CreateBuild creates a new build in cozy-hub with an already-uploaded tarball.
-func (c *BuilderClient) CreateBuild(tarballPath string) (*BuildUploadResponse, error) {
-	reqBody := map[string]string{
-		"tarball_path": tarballPath,
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	body, err := json.Marshal(reqBody)
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var usage StorageUsage
+	if err := json.Unmarshal(respBody, &usage); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &usage, nil
+}
+
+// SetNotificationRule creates a notification rule, or replaces the events of
+// the rule with the same channel, target, and deployment.
+func (c *BuilderClient) SetNotificationRule(req *SetNotificationRuleRequest) (*NotificationRule, error) {
+	data, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/api/v1/builds", c.baseURL)
-	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/v1/notifications", bytes.NewReader(data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -190,34 +604,25 @@ func (c *BuilderClient) CreateBuild(tarballPath string) (*BuildUploadResponse, e
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
-			return nil, fmt.Errorf("create build failed (%d): %s", resp.StatusCode, errResp.Error)
-		}
-		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return nil, fmt.Errorf("create build failed (%d): %s", resp.StatusCode, errResp.Message)
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
 		}
-		return nil, fmt.Errorf("create build failed (%d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
 	}
 
-	// Parse cozy-hub Build response
-	var build Build
-	if err := json.Unmarshal(respBody, &build); err != nil {
+	var rule NotificationRule
+	if err := json.Unmarshal(respBody, &rule); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	// Map to legacy response format
-	return &BuildUploadResponse{
-		BuildID: build.ID,
-		Status:  build.Status,
-	}, nil
+	return &rule, nil
 }
 
-// GetBuildStatus fetches the current status of a build.
-func (c *BuilderClient) GetBuildStatus(buildID string) (*BuildStatusResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/builds/%s", c.baseURL, buildID)
-	httpReq, err := http.NewRequest("GET", url, nil)
+// ListNotificationRules lists the tenant's notification rules.
+func (c *BuilderClient) ListNotificationRules() ([]NotificationRule, error) {
+	httpReq, err := http.NewRequest("GET", c.baseURL+"/api/v1/notifications", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -240,35 +645,25 @@ func (c *BuilderClient) GetBuildStatus(buildID string) (*BuildStatusResponse, er
 	if resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Error)
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
 	}
 
-	// Parse cozy-hub Build response
-	var build Build
-	if err := json.Unmarshal(respBody, &build); err != nil {
+	var listResp ListNotificationRulesResponse
+	if err := json.Unmarshal(respBody, &listResp); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	// Map to legacy response format
-	return &BuildStatusResponse{
-		ID:          build.ID,
-		Status:      build.Status,
-		ImageTag:    build.ImageTag,
-		Error:       build.ErrorMessage,
-		CreatedAt:   build.CreatedAt,
-		StartedAt:   build.StartedAt,
-		CompletedAt: build.FinishedAt,
-	}, nil
+	return listResp.Rules, nil
 }
 
-// GetBuildLogs fetches the logs for a build.
-func (c *BuilderClient) GetBuildLogs(buildID string, afterID int64, limit int) (*BuildLogsResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/builds/%s/logs?after_id=%d&limit=%d", c.baseURL, buildID, afterID, limit)
-	httpReq, err := http.NewRequest("GET", url, nil)
+// DeleteNotificationRule deletes a notification rule.
+func (c *BuilderClient) DeleteNotificationRule(id string) error {
+	url := fmt.Sprintf("%s/api/v1/notifications/%s", c.baseURL, neturl.PathEscape(id))
+	httpReq, err := http.NewRequest("DELETE", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if c.token != "" {
@@ -277,40 +672,37 @@ func (c *BuilderClient) GetBuildLogs(buildID string, afterID int64, limit int) (
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("notification rule '%s' not found", id)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Error)
+			return apiError("API error", resp.StatusCode, errResp.Error)
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
-	}
-
-	var logsResp BuildLogsResponse
-	if err := json.Unmarshal(respBody, &logsResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return apiError("API error", resp.StatusCode, string(respBody))
 	}
 
-	return &logsResp, nil
+	return nil
 }
 
-// DeployBuild calls POST /api/v1/builds/:id/deploy on cozy-hub.
-func (c *BuilderClient) DeployBuild(buildID, tenantID string) (*BuilderDeployResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/builds/%s/deploy", c.baseURL, buildID)
-	httpReq, err := http.NewRequest("POST", url, nil)
+// ListTemplates returns the starter templates in cozy-hub's gallery.
+func (c *BuilderClient) ListTemplates() ([]ProjectTemplate, error) {
+	httpReq, err := http.NewRequest("GET", c.baseURL+"/api/v1/templates", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
 	if c.token != "" {
 		httpReq.Header.Set("Authorization", "Bearer "+c.token)
 	}
@@ -326,57 +718,26 @@ func (c *BuilderClient) DeployBuild(buildID, tenantID string) (*BuilderDeployRes
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
-		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
-		}
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Error)
-		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
-	}
-
-	// Try to parse as HubDeployment first
-	var deployment HubDeployment
-	if err := json.Unmarshal(respBody, &deployment); err == nil && deployment.ID != "" {
-		activeBuildID := ""
-		previousBuildID := ""
-		if deployment.ActiveBuildID != nil {
-			activeBuildID = *deployment.ActiveBuildID
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
 		}
-		if deployment.PreviousBuildID != nil {
-			previousBuildID = *deployment.PreviousBuildID
-		}
-		return &BuilderDeployResponse{
-			ID:              deployment.ID,
-			TenantID:        deployment.TenantID,
-			ActiveBuildID:   activeBuildID,
-			PreviousBuildID: previousBuildID,
-			ImageTag:        deployment.ImageURL,
-			CreatedAt:       deployment.CreatedAt,
-			UpdatedAt:       deployment.UpdatedAt,
-		}, nil
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
 	}
 
-	// Fallback: try to parse as simple status response
-	var simpleResp struct {
-		Status  string `json:"status"`
-		BuildID string `json:"build_id"`
-	}
-	if err := json.Unmarshal(respBody, &simpleResp); err == nil && simpleResp.Status == "deployed" {
-		return &BuilderDeployResponse{
-			ID:            simpleResp.BuildID,
-			ActiveBuildID: simpleResp.BuildID,
-		}, nil
+	var listResp ListTemplatesResponse
+	if err := json.Unmarshal(respBody, &listResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return nil, fmt.Errorf("unexpected response format: %s", string(respBody))
+	return listResp.Templates, nil
 }
 
-// GetHubDeployment fetches a deployment by ID from cozy-hub.
-func (c *BuilderClient) GetHubDeployment(deploymentID string) (*HubDeployment, error) {
-	url := fmt.Sprintf("%s/api/v1/deployments/%s", c.baseURL, deploymentID)
+// DownloadTemplate streams a template's files as a gzipped tarball. The
+// caller must close the returned reader.
+func (c *BuilderClient) DownloadTemplate(name string) (io.ReadCloser, error) {
+	url := fmt.Sprintf("%s/api/v1/templates/%s/archive", c.baseURL, neturl.PathEscape(name))
 	httpReq, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -388,31 +749,1029 @@ func (c *BuilderClient) GetHubDeployment(deploymentID string) (*HubDeployment, e
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("download request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
+		resp.Body.Close()
+		return nil, fmt.Errorf("template '%s' not found (see 'cozyctl templates list')", name)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	return resp.Body, nil
+}
+
+// DeleteFile deletes a file from cozy-hub's file store.
+func (c *BuilderClient) DeleteFile(path string) error {
+	url := fmt.Sprintf("%s/api/v1/file/%s", c.baseURL, path)
+	httpReq, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("file '%s' not found", path)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Error)
+			return apiError("API error", resp.StatusCode, errResp.Error)
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return apiError("API error", resp.StatusCode, string(respBody))
 	}
 
-	var deployment HubDeployment
-	if err := json.Unmarshal(respBody, &deployment); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	return nil
+}
+
+// UploadFile uploads a file to cozy-hub's file store in a single request.
+// Large files should use a multipart upload instead (see CreateMultipartUpload).
+func (c *BuilderClient) UploadFile(path string, body io.Reader, contentType string) error {
+	url := fmt.Sprintf("%s/api/v1/file/%s", c.baseURL, path)
+	httpReq, err := http.NewRequest("PUT", url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", contentType)
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// Use a longer timeout for uploads
+	uploadClient := &http.Client{Timeout: 5 * time.Minute, Transport: c.httpClient.Transport}
+	resp, err := uploadClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return uploadError(resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
+// CreateMultipartUpload starts an upload to the file store that is sent in
+// parts with UploadPart, so large files never need a single long request and
+// a failed part can be retried on its own.
+func (c *BuilderClient) CreateMultipartUpload(path string) (*MultipartUpload, error) {
+	respBody, err := c.doFile("POST", path, "uploads", nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var upload MultipartUpload
+	if err := json.Unmarshal(respBody, &upload); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &upload, nil
+}
+
+// UploadPart uploads one part (numbered from 1) of a multipart upload.
+func (c *BuilderClient) UploadPart(path, uploadID string, partNumber int, data []byte) (*UploadedPart, error) {
+	query := neturl.Values{"upload_id": {uploadID}, "part_number": {strconv.Itoa(partNumber)}}.Encode()
+	respBody, err := c.doFile("PUT", path, query, bytes.NewReader(data), "application/octet-stream")
+	if err != nil {
+		return nil, err
+	}
+
+	var part UploadedPart
+	if err := json.Unmarshal(respBody, &part); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &part, nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into the file.
+func (c *BuilderClient) CompleteMultipartUpload(path, uploadID string, parts []UploadedPart) error {
+	data, err := json.Marshal(&CompleteMultipartUploadRequest{Parts: parts})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	query := neturl.Values{"upload_id": {uploadID}}.Encode()
+	_, err = c.doFile("POST", path, query, bytes.NewReader(data), "application/json")
+	return err
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts.
+func (c *BuilderClient) AbortMultipartUpload(path, uploadID string) error {
+	query := neturl.Values{"upload_id": {uploadID}}.Encode()
+	_, err := c.doFile("DELETE", path, query, nil, "")
+	return err
+}
+
+// doFile sends a multipart upload request for a file store path and returns the response body.
+func (c *BuilderClient) doFile(method, path, query string, body io.Reader, contentType string) ([]byte, error) {
+	url := fmt.Sprintf("%s/api/v1/file/%s?%s", c.baseURL, path, query)
+	httpReq, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// Parts can be large; use the upload timeout
+	uploadClient := &http.Client{Timeout: 5 * time.Minute, Transport: c.httpClient.Transport}
+	resp, err := uploadClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, uploadError(resp.StatusCode, respBody)
+	}
+	return respBody, nil
+}
+
+// uploadError turns a failed file store response into an error.
+func uploadError(status int, respBody []byte) error {
+	var errResp ErrorResponse
+	if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+		return apiError("upload failed", status, errResp.Error)
+	}
+	if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+		return apiError("upload failed", status, errResp.Message)
+	}
+	return apiError("upload failed", status, string(respBody))
+}
+
+// UploadBuild uploads a tarball and creates a build in cozy-hub.
+func (c *BuilderClient) UploadBuild(tarball io.Reader, buildName string, opts BuildOptions) (*BuildUploadResponse, error) {
+	// Step 1: Upload tarball to file store
+	tarballPath, err := c.UploadTarball(tarball, buildName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload tarball: %w", err)
+	}
+
+	// Step 2: Create build with tarball path
+	return c.CreateBuild(tarballPath, opts)
+}
+
+// CreateBuild creates a new build in cozy-hub with an already-uploaded tarball.
+func (c *BuilderClient) CreateBuild(tarballPath string, opts BuildOptions) (*BuildUploadResponse, error) {
+	reqBody := CreateBuildRequest{
+		TarballPath: tarballPath,
+		Priority:    opts.Priority,
+		Machine:     opts.Machine,
+	}
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/builds", c.baseURL)
+	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("create build failed", resp.StatusCode, errResp.Error)
+		}
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("create build failed", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("create build failed", resp.StatusCode, string(respBody))
+	}
+
+	// Parse cozy-hub Build response
+	var build Build
+	if err := json.Unmarshal(respBody, &build); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// Map to legacy response format
+	return &BuildUploadResponse{
+		BuildID: build.ID,
+		Status:  build.Status,
+	}, nil
+}
+
+// GetBuildStatus fetches the current status of a build.
+func (c *BuilderClient) GetBuildStatus(buildID string) (*BuildStatusResponse, error) {
+	url := fmt.Sprintf("%s/api/v1/builds/%s", c.baseURL, buildID)
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	// Parse cozy-hub Build response
+	var build Build
+	if err := json.Unmarshal(respBody, &build); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// Map to legacy response format
+	return &BuildStatusResponse{
+		ID:               build.ID,
+		Status:           build.Status,
+		ImageTag:         build.ImageTag,
+		ImageDigest:      build.ImageDigest,
+		BaseDigest:       build.BaseDigest,
+		BuilderID:        build.BuilderID,
+		Error:            build.ErrorMessage,
+		Priority:         build.Priority,
+		Machine:          build.Machine,
+		QueuePosition:    build.QueuePosition,
+		EstimatedStartAt: build.EstimatedStartAt,
+		CreatedAt:        build.CreatedAt,
+		StartedAt:        build.StartedAt,
+		CompletedAt:      build.FinishedAt,
+	}, nil
+}
+
+// ListBuilds lists the tenant's most recent builds, newest first. An empty
+// deploymentID lists builds of every deployment; limit <= 0 uses the server default.
+func (c *BuilderClient) ListBuilds(deploymentID string, limit int) ([]Build, error) {
+	query := neturl.Values{}
+	if deploymentID != "" {
+		query.Set("deployment_id", deploymentID)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	reqURL := c.baseURL + "/api/v1/builds"
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	httpReq, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var listResp ListBuildsResponse
+	if err := json.Unmarshal(respBody, &listResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return listResp.Builds, nil
+}
+
+// CancelBuild cancels a queued or running build and returns its final state.
+func (c *BuilderClient) CancelBuild(buildID string) (*Build, error) {
+	url := fmt.Sprintf("%s/api/v1/builds/%s/cancel", c.baseURL, buildID)
+	httpReq, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var build Build
+	if err := json.Unmarshal(respBody, &build); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &build, nil
+}
+
+// ListBuildArtifacts lists the artifacts attached to a build.
+func (c *BuilderClient) ListBuildArtifacts(buildID string) ([]BuildArtifact, error) {
+	url := fmt.Sprintf("%s/api/v1/builds/%s/artifacts", c.baseURL, buildID)
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var listResp ListBuildArtifactsResponse
+	if err := json.Unmarshal(respBody, &listResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return listResp.Artifacts, nil
+}
+
+// DownloadBuildArtifact streams the content of a build artifact.
+// The caller must close the returned reader.
+func (c *BuilderClient) DownloadBuildArtifact(buildID, name string) (io.ReadCloser, error) {
+	url := fmt.Sprintf("%s/api/v1/builds/%s/artifacts/%s", c.baseURL, buildID, neturl.PathEscape(name))
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// Artifacts such as logs archives can be large; don't cut the download short
+	downloadClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := downloadClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	return resp.Body, nil
+}
+
+// PutBuildProvenance stores the provenance statement of a build.
+func (c *BuilderClient) PutBuildProvenance(buildID string, statement *ProvenanceStatement) error {
+	data, err := json.Marshal(statement)
+	if err != nil {
+		return fmt.Errorf("failed to marshal provenance: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/builds/%s/provenance", c.baseURL, buildID)
+	httpReq, err := http.NewRequest("PUT", url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// GetBuildProvenance fetches the provenance statement of a build.
+// Returns nil if the build has no provenance.
+func (c *BuilderClient) GetBuildProvenance(buildID string) (*ProvenanceStatement, error) {
+	url := fmt.Sprintf("%s/api/v1/builds/%s/provenance", c.baseURL, buildID)
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var statement ProvenanceStatement
+	if err := json.Unmarshal(respBody, &statement); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &statement, nil
+}
+
+// GetBuildLogs fetches the logs for a build.
+func (c *BuilderClient) GetBuildLogs(buildID string, afterID int64, limit int) (*BuildLogsResponse, error) {
+	url := fmt.Sprintf("%s/api/v1/builds/%s/logs?after_id=%d&limit=%d", c.baseURL, buildID, afterID, limit)
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var logsResp BuildLogsResponse
+	if err := json.Unmarshal(respBody, &logsResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &logsResp, nil
+}
+
+// DeployBuild calls POST /api/v1/builds/:id/deploy on cozy-hub.
+func (c *BuilderClient) DeployBuild(buildID string, req *DeployBuildRequest) (*BuilderDeployResponse, error) {
+	if req == nil {
+		req = &DeployBuildRequest{}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/builds/%s/deploy", c.baseURL, buildID)
+	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// The deployment requires approval: the deploy waits for a decision
+	if resp.StatusCode == http.StatusAccepted {
+		var approval Approval
+		if err := json.Unmarshal(respBody, &approval); err != nil || approval.ID == "" {
+			return nil, fmt.Errorf("unexpected response format: %s", redact.String(string(respBody)))
+		}
+		return nil, &PendingApprovalError{Approval: &approval}
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	// Try to parse as HubDeployment first
+	var deployment HubDeployment
+	if err := json.Unmarshal(respBody, &deployment); err == nil && deployment.ID != "" {
+		return deployment.DeployResponse(), nil
+	}
+
+	// Fallback: try to parse as simple status response
+	var simpleResp struct {
+		Status  string `json:"status"`
+		BuildID string `json:"build_id"`
+	}
+	if err := json.Unmarshal(respBody, &simpleResp); err == nil && simpleResp.Status == "deployed" {
+		return &BuilderDeployResponse{
+			ID:            simpleResp.BuildID,
+			ActiveBuildID: simpleResp.BuildID,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected response format: %s", redact.String(string(respBody)))
+}
+
+// GetHubDeployment fetches a deployment by ID from cozy-hub.
+func (c *BuilderClient) GetHubDeployment(deploymentID string) (*HubDeployment, error) {
+	url := fmt.Sprintf("%s/api/v1/deployments/%s", c.baseURL, deploymentID)
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var deployment HubDeployment
+	if err := json.Unmarshal(respBody, &deployment); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &deployment, nil
+}
+
+// RollbackDeployment re-activates an earlier build of a deployment: buildID,
+// or the previous build if empty. Like DeployBuild, it returns a
+// *PendingApprovalError if the deployment requires approval.
+func (c *BuilderClient) RollbackDeployment(deploymentID, buildID string) (*HubDeployment, error) {
+	body, err := json.Marshal(&RollbackRequest{BuildID: buildID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/deployments/%s/rollback", c.baseURL, deploymentID)
+	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode == http.StatusAccepted {
+		var approval Approval
+		if err := json.Unmarshal(respBody, &approval); err != nil || approval.ID == "" {
+			return nil, fmt.Errorf("unexpected response format: %s", redact.String(string(respBody)))
+		}
+		return nil, &PendingApprovalError{Approval: &approval}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var deployment HubDeployment
+	if err := json.Unmarshal(respBody, &deployment); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	return &deployment, nil
 }
+
+// RegisterModel registers a resolved model with cozy-hub. Registering a
+// model again updates it.
+func (c *BuilderClient) RegisterModel(req *RegisterModelRequest) (*Model, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/models", c.baseURL)
+	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var model Model
+	if err := json.Unmarshal(respBody, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &model, nil
+}
+
+// GetTraffic returns how a deployment's requests are split between builds.
+// A deployment without a split sends all traffic to its active build.
+func (c *BuilderClient) GetTraffic(deploymentID string) (*TrafficSplit, error) {
+	return c.doTraffic("GET", deploymentID, nil)
+}
+
+// SetTraffic replaces a deployment's traffic split.
+func (c *BuilderClient) SetTraffic(deploymentID string, routes []TrafficRoute) (*TrafficSplit, error) {
+	return c.doTraffic("PUT", deploymentID, &SetTrafficRequest{Routes: routes})
+}
+
+func (c *BuilderClient) doTraffic(method, deploymentID string, req any) (*TrafficSplit, error) {
+	if err := c.require(FeatureTrafficSplit); err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/deployments/%s/traffic", c.baseURL, deploymentID)
+	httpReq, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var split TrafficSplit
+	if err := json.Unmarshal(respBody, &split); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &split, nil
+}
+
+// SetRebuildPolicy schedules rebuilds of a deployment when its base image
+// gets security updates, replacing any existing policy of the deployment.
+func (c *BuilderClient) SetRebuildPolicy(deploymentID, schedule string) (*RebuildPolicy, error) {
+	if err := c.require(FeatureRebuildPolicies); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(&SetRebuildPolicyRequest{Schedule: schedule})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/deployments/%s/rebuild-policy", c.baseURL, deploymentID)
+	httpReq, err := http.NewRequest("PUT", url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var policy RebuildPolicy
+	if err := json.Unmarshal(respBody, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// ListRebuildPolicies lists the tenant's rebuild policies.
+func (c *BuilderClient) ListRebuildPolicies() ([]RebuildPolicy, error) {
+	if err := c.require(FeatureRebuildPolicies); err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest("GET", c.baseURL+"/api/v1/rebuild-policies", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var listResp ListRebuildPoliciesResponse
+	if err := json.Unmarshal(respBody, &listResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return listResp.Policies, nil
+}
+
+// DeleteRebuildPolicy stops scheduled rebuilds of a deployment.
+func (c *BuilderClient) DeleteRebuildPolicy(deploymentID string) error {
+	if err := c.require(FeatureRebuildPolicies); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/deployments/%s/rebuild-policy", c.baseURL, deploymentID)
+	httpReq, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("deployment '%s' has no rebuild policy", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeployBuild_SendsTargetDeployment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Method = %q, want POST", r.Method)
+		}
+		if r.URL.Path != "/api/v1/builds/build-123/deploy" {
+			t.Errorf("Path = %q, want /api/v1/builds/build-123/deploy", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Authorization = %q, want 'Bearer test-token'", r.Header.Get("Authorization"))
+		}
+
+		var req DeployBuildRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		if req.DeploymentID != "my-model" {
+			t.Errorf("DeploymentID = %q, want 'my-model'", req.DeploymentID)
+		}
+		if req.TenantID != "tenant-123" {
+			t.Errorf("TenantID = %q, want 'tenant-123'", req.TenantID)
+		}
+
+		activeBuildID := "build-123"
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(HubDeployment{
+			ID:            "my-model",
+			TenantID:      "tenant-123",
+			ActiveBuildID: &activeBuildID,
+			ImageURL:      "registry.example.com/my-model:abc",
+		})
+	}))
+	defer server.Close()
+
+	client := NewBuilderClient(server.URL, "test-token", nil)
+	resp, err := client.DeployBuild("build-123", &DeployBuildRequest{
+		TenantID:     "tenant-123",
+		DeploymentID: "my-model",
+	})
+
+	if err != nil {
+		t.Fatalf("DeployBuild failed: %v", err)
+	}
+	if resp.ID != "my-model" {
+		t.Errorf("ID = %q, want 'my-model'", resp.ID)
+	}
+	if resp.ActiveBuildID != "build-123" {
+		t.Errorf("ActiveBuildID = %q, want 'build-123'", resp.ActiveBuildID)
+	}
+	if resp.ImageTag != "registry.example.com/my-model:abc" {
+		t.Errorf("ImageTag = %q, want 'registry.example.com/my-model:abc'", resp.ImageTag)
+	}
+}
+
+func TestDeployBuild_SimpleStatusResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"deployed","build_id":"build-123"}`))
+	}))
+	defer server.Close()
+
+	client := NewBuilderClient(server.URL, "test-token", nil)
+	resp, err := client.DeployBuild("build-123", nil)
+
+	if err != nil {
+		t.Fatalf("DeployBuild failed: %v", err)
+	}
+	if resp.ActiveBuildID != "build-123" {
+		t.Errorf("ActiveBuildID = %q, want 'build-123'", resp.ActiveBuildID)
+	}
+}
+
+func TestGetBuildStatus_MapsHubBuild(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/builds/build-123" {
+			t.Errorf("Path = %q, want /api/v1/builds/build-123", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Build{
+			ID:           "build-123",
+			Status:       "failed",
+			ErrorMessage: "pip install failed",
+		})
+	}))
+	defer server.Close()
+
+	client := NewBuilderClient(server.URL, "test-token", nil)
+	status, err := client.GetBuildStatus("build-123")
+
+	if err != nil {
+		t.Fatalf("GetBuildStatus failed: %v", err)
+	}
+	if status.Status != "failed" {
+		t.Errorf("Status = %q, want 'failed'", status.Status)
+	}
+	if status.Error != "pip install failed" {
+		t.Errorf("Error = %q, want 'pip install failed'", status.Error)
+	}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// Optional server features. Servers list the ones they support at their
+// capabilities endpoint; requests that need one fail with an
+// *UnsupportedError on servers that don't.
+const (
+	FeatureTrafficSplit    = "traffic_split"    // cozy-hub: split requests between builds
+	FeatureRebuildPolicies = "rebuild_policies" // cozy-hub: scheduled security rebuilds
+	FeatureApprovals       = "approvals"        // cozy-hub: deploys that wait for approval
+	FeatureRevisions       = "revisions"        // cozy-hub: history of each deployment's active builds
+	FeatureAsyncJobs       = "async_jobs"       // Orchestrator: invocation records and their result artifacts
+	FeatureSnapshots       = "snapshots"        // Orchestrator: deployment snapshots
+	FeatureTransfers       = "transfers"        // Orchestrator: deployment transfers between tenants
+	FeatureWorkerExec      = "worker_exec"      // Orchestrator: commands in running workers
+	FeatureDeploymentLocks = "deployment_locks" // Orchestrator: protecting deployments from changes
+	FeatureFunctionStats   = "function_stats"   // Orchestrator: per-function invocation counters
+)
+
+// Features lists the optional server features.
+var Features = []string{
+	FeatureTrafficSplit, FeatureRebuildPolicies, FeatureApprovals, FeatureRevisions,
+	FeatureAsyncJobs, FeatureSnapshots, FeatureTransfers, FeatureWorkerExec,
+	FeatureDeploymentLocks, FeatureFunctionStats,
+}
+
+// featureNames describe features in errors.
+var featureNames = map[string]string{
+	FeatureTrafficSplit:    "traffic splitting",
+	FeatureRebuildPolicies: "scheduled rebuilds",
+	FeatureApprovals:       "deploy approvals",
+	FeatureRevisions:       "deployment history",
+	FeatureAsyncJobs:       "async jobs",
+	FeatureSnapshots:       "deployment snapshots",
+	FeatureTransfers:       "deployment transfers",
+	FeatureWorkerExec:      "exec into workers",
+	FeatureDeploymentLocks: "deployment locks",
+	FeatureFunctionStats:   "function statistics",
+}
+
+// Server names in errors.
+const (
+	ServerOrchestrator = "orchestrator"
+	ServerHub          = "cozy-hub"
+)
+
+// Capabilities is what a server reports it supports, from GET
+// /v1/capabilities (orchestrator) or /api/v1/capabilities (cozy-hub).
+type Capabilities struct {
+	Version  string   `json:"version,omitempty"`
+	Features []string `json:"features"`
+	// Legacy is set for servers without the endpoint. They predate
+	// capability discovery and none of the optional features.
+	Legacy bool `json:"legacy,omitempty"`
+}
+
+// Supports reports whether the server supports feature.
+func (c *Capabilities) Supports(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// UnsupportedError reports a request for a feature the server doesn't
+// support, typically an older self-hosted server.
+type UnsupportedError struct {
+	Server  string // ServerOrchestrator or ServerHub
+	Feature string
+	Version string // The server's version, if it reports one
+}
+
+func (e *UnsupportedError) Error() string {
+	name := featureNames[e.Feature]
+	if name == "" {
+		name = e.Feature
+	}
+	if e.Version == "" {
+		return fmt.Sprintf("your %s doesn't support %s yet (it predates capability discovery); upgrade it to use this", e.Server, name)
+	}
+	return fmt.Sprintf("your %s (version %s) doesn't support %s yet; upgrade it to use this", e.Server, e.Version, name)
+}
+
+// capabilityCache holds a server's capabilities once fetched, so each
+// client asks at most once per run. Failed fetches are not cached.
+type capabilityCache struct {
+	mu   sync.Mutex
+	caps *Capabilities
+}
+
+func (cc *capabilityCache) get(fetch func() (*Capabilities, error)) (*Capabilities, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.caps != nil {
+		return cc.caps, nil
+	}
+	caps, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	cc.caps = caps
+	return caps, nil
+}
+
+// require returns an *UnsupportedError if the server doesn't support
+// feature. When the capabilities can't be fetched, the feature's request
+// is let through to report the problem itself.
+func (cc *capabilityCache) require(server, feature string, fetch func() (*Capabilities, error)) error {
+	caps, err := cc.get(fetch)
+	if err != nil || caps.Supports(feature) {
+		return nil
+	}
+	return &UnsupportedError{Server: server, Feature: feature, Version: caps.Version}
+}
+
+func fetchCapabilities(httpClient *http.Client, url, token string) (*Capabilities, error) {
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &Capabilities{Features: []string{}, Legacy: true}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var caps Capabilities
+	if err := json.Unmarshal(respBody, &caps); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if caps.Features == nil {
+		caps.Features = []string{}
+	}
+	return &caps, nil
+}
+
+// Capabilities returns what the orchestrator supports, fetched on first use.
+func (c *Client) Capabilities() (*Capabilities, error) {
+	return c.caps.get(c.fetchCapabilities)
+}
+
+func (c *Client) fetchCapabilities() (*Capabilities, error) {
+	return fetchCapabilities(c.httpClient, c.baseURL+"/v1/capabilities", c.token)
+}
+
+func (c *Client) require(feature string) error {
+	return c.caps.require(ServerOrchestrator, feature, c.fetchCapabilities)
+}
+
+// Capabilities returns what cozy-hub supports, fetched on first use.
+func (c *BuilderClient) Capabilities() (*Capabilities, error) {
+	return c.caps.get(c.fetchCapabilities)
+}
+
+func (c *BuilderClient) fetchCapabilities() (*Capabilities, error) {
+	return fetchCapabilities(c.httpClient, c.baseURL+"/api/v1/capabilities", c.token)
+}
+
+func (c *BuilderClient) require(feature string) error {
+	return c.caps.require(ServerHub, feature, c.fetchCapabilities)
+}
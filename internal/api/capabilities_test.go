@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequire_LegacyServer(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/capabilities" {
+			calls++
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", nil)
+	for range 2 {
+		_, err := client.GetInvocation("inv-1")
+		var unsupported *UnsupportedError
+		if !errors.As(err, &unsupported) {
+			t.Fatalf("GetInvocation: got %v, want *UnsupportedError", err)
+		}
+		if !strings.Contains(err.Error(), "your orchestrator doesn't support async jobs yet") {
+			t.Errorf("Error = %q", err.Error())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("capabilities fetched %d times, want once", calls)
+	}
+
+	caps, err := client.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.Legacy || len(caps.Features) != 0 {
+		t.Errorf("Capabilities = %+v, want legacy without features", caps)
+	}
+}
+
+func TestRequire_SupportedFeature(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/capabilities":
+			json.NewEncoder(w).Encode(Capabilities{Version: "1.4.0", Features: []string{FeatureTrafficSplit}})
+		case "/api/v1/deployments/my-model/traffic":
+			json.NewEncoder(w).Encode(TrafficSplit{DeploymentID: "my-model"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBuilderClient(server.URL, "test-token", nil)
+	if _, err := client.GetTraffic("my-model"); err != nil {
+		t.Fatalf("GetTraffic: %v", err)
+	}
+
+	_, err := client.ListRebuildPolicies()
+	if err == nil || err.Error() != "your cozy-hub (version 1.4.0) doesn't support scheduled rebuilds yet; upgrade it to use this" {
+		t.Errorf("ListRebuildPolicies: got %v", err)
+	}
+}
+
+func TestRequire_CapabilitiesUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/capabilities" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(Invocation{ID: "inv-1"})
+	}))
+	defer server.Close()
+
+	// The feature's own request goes ahead when capabilities can't be fetched
+	client := NewClient(server.URL, "test-token", nil)
+	if _, err := client.GetInvocation("inv-1"); err != nil {
+		t.Fatalf("GetInvocation: %v", err)
+	}
+}
@@ -2,12 +2,18 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/websocket"
 )
 
 // Client is an HTTP client for the orchestrator API.
@@ -15,15 +21,18 @@ type Client struct {
 	baseURL    string
 	token      string
 	httpClient *http.Client
+	caps       capabilityCache
 }
 
-// NewClient creates a new orchestrator API client.
-func NewClient(baseURL, token string) *Client {
+// NewClient creates a new orchestrator API client whose requests go through
+// transport, or http.DefaultTransport if it is nil.
+func NewClient(baseURL, token string, transport http.RoundTripper) *Client {
 	return &Client{
 		baseURL: strings.TrimRight(baseURL, "/"),
 		token:   token,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
 	}
 }
@@ -62,9 +71,9 @@ func (c *Client) DeployWithBuildID(req *DeployWithBuildIDRequest) (*DeploymentRe
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
 	}
 
 	var deployment DeploymentResponse
@@ -108,9 +117,9 @@ func (c *Client) CreateDeployment(req *CreateDeploymentRequest) (*DeploymentResp
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
 	}
 
 	var deployment DeploymentResponse
@@ -154,9 +163,9 @@ func (c *Client) UpdateDeployment(id string, req *UpdateDeploymentRequest) (*Dep
 	if resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
 	}
 
 	var deployment DeploymentResponse
@@ -194,9 +203,9 @@ func (c *Client) GetDeployment(id string) (*DeploymentResponse, error) {
 	if resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
 	}
 
 	var deployment DeploymentResponse
@@ -207,9 +216,42 @@ func (c *Client) GetDeployment(id string) (*DeploymentResponse, error) {
 	return &deployment, nil
 }
 
-// ListDeployments lists all deployments for the tenant.
+// ListDeployments lists the deployments for the tenant in a single request,
+// returning the server's default page.
 func (c *Client) ListDeployments() ([]DeploymentResponse, error) {
-	httpReq, err := http.NewRequest("GET", c.baseURL+"/v1/deployments", nil)
+	listResp, err := c.ListDeploymentsPage(ListDeploymentsOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return listResp.Items, nil
+}
+
+// ListDeploymentsPage lists one page of deployments, passing filtering,
+// sorting, and paging options to the server as query parameters.
+func (c *Client) ListDeploymentsPage(opts ListDeploymentsOptions) (*ListDeploymentsResponse, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	if opts.NameFilter != "" {
+		query.Set("name", opts.NameFilter)
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+
+	reqURL := c.baseURL + "/v1/deployments"
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	httpReq, err := http.NewRequest("GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -230,9 +272,9 @@ func (c *Client) ListDeployments() ([]DeploymentResponse, error) {
 	if resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
 		}
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
 	}
 
 	var listResp ListDeploymentsResponse
@@ -240,7 +282,7 @@ func (c *Client) ListDeployments() ([]DeploymentResponse, error) {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return listResp.Items, nil
+	return &listResp, nil
 }
 
 // DeleteDeployment deletes a deployment by ID.
@@ -266,10 +308,657 @@ func (c *Client) DeleteDeployment(id string) error {
 		respBody, _ := io.ReadAll(resp.Body)
 		var errResp ErrorResponse
 		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
-			return fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
+			return apiError("API error", resp.StatusCode, errResp.Message)
 		}
-		return fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return apiError("API error", resp.StatusCode, string(respBody))
 	}
 
 	return nil
 }
+
+// RequestDeploymentTransfer starts moving a deployment to another tenant.
+// Nothing moves until the returned transfer is confirmed.
+func (c *Client) RequestDeploymentTransfer(id string, req *TransferDeploymentRequest) (*DeploymentTransfer, error) {
+	return c.doTransfer("POST", fmt.Sprintf("/v1/deployments/%s/transfers", id), id, req)
+}
+
+// ConfirmDeploymentTransfer completes a pending transfer using the token
+// returned by RequestDeploymentTransfer.
+func (c *Client) ConfirmDeploymentTransfer(id, transferID, token string) (*DeploymentTransfer, error) {
+	path := fmt.Sprintf("/v1/deployments/%s/transfers/%s/confirm", id, transferID)
+	return c.doTransfer("POST", path, id, &ConfirmTransferRequest{ConfirmationToken: token})
+}
+
+// CancelDeploymentTransfer withdraws a pending transfer.
+func (c *Client) CancelDeploymentTransfer(id, transferID string) (*DeploymentTransfer, error) {
+	return c.doTransfer("DELETE", fmt.Sprintf("/v1/deployments/%s/transfers/%s", id, transferID), id, nil)
+}
+
+// doTransfer sends a deployment transfer request and decodes the transfer.
+func (c *Client) doTransfer(method, path, deploymentID string, req any) (*DeploymentTransfer, error) {
+	if err := c.require(FeatureTransfers); err != nil {
+		return nil, err
+	}
+
+	var reqBody io.Reader
+	if req != nil {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if req != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			if resp.StatusCode == http.StatusNotFound && errResp.Message == "deployment not found" {
+				return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+			}
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var transfer DeploymentTransfer
+	if err := json.Unmarshal(respBody, &transfer); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+// CreateDeploymentSnapshot saves a copy of a deployment's current spec.
+func (c *Client) CreateDeploymentSnapshot(id string, req *CreateDeploymentSnapshotRequest) (*DeploymentSnapshot, error) {
+	var snapshot DeploymentSnapshot
+	if err := c.doSnapshot("POST", fmt.Sprintf("/v1/deployments/%s/snapshots", id), id, "", req, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// ListDeploymentSnapshots returns a deployment's snapshots, newest first.
+func (c *Client) ListDeploymentSnapshots(id string) ([]DeploymentSnapshot, error) {
+	var list ListDeploymentSnapshotsResponse
+	if err := c.doSnapshot("GET", fmt.Sprintf("/v1/deployments/%s/snapshots", id), id, "", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// RestoreDeploymentSnapshot replaces a deployment's spec with a snapshot of
+// it and returns the restored deployment. The image is left unchanged.
+func (c *Client) RestoreDeploymentSnapshot(id, snapshotID string) (*DeploymentResponse, error) {
+	var deployment DeploymentResponse
+	path := fmt.Sprintf("/v1/deployments/%s/snapshots/%s/restore", id, snapshotID)
+	if err := c.doSnapshot("POST", path, id, snapshotID, nil, &deployment); err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// doSnapshot sends a deployment snapshot request and decodes the response into out.
+func (c *Client) doSnapshot(method, path, deploymentID, snapshotID string, req, out any) error {
+	if err := c.require(FeatureSnapshots); err != nil {
+		return err
+	}
+
+	var reqBody io.Reader
+	if req != nil {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if req != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			switch {
+			case resp.StatusCode == http.StatusNotFound && errResp.Message == "deployment not found":
+				return fmt.Errorf("deployment '%s' not found", deploymentID)
+			case resp.StatusCode == http.StatusNotFound && errResp.Message == "snapshot not found":
+				return fmt.Errorf("snapshot '%s' not found for deployment '%s' (see 'cozyctl deployments snapshots %s')", snapshotID, deploymentID, deploymentID)
+			}
+			return apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// SetFunctionEnabled puts one of a deployment's functions back into
+// rotation, or takes it out, without touching the rest of the deployment.
+func (c *Client) SetFunctionEnabled(deploymentID, function string, enabled bool) (*DeploymentResponse, error) {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+
+	url := fmt.Sprintf("%s/v1/deployments/%s/functions/%s/%s", c.baseURL, deploymentID, function, action)
+	httpReq, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			switch {
+			case resp.StatusCode == http.StatusNotFound && errResp.Message == "deployment not found":
+				return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+			case resp.StatusCode == http.StatusNotFound && errResp.Message == "function not found":
+				return nil, fmt.Errorf("function '%s' not found on deployment '%s'", function, deploymentID)
+			}
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var deployment DeploymentResponse
+	if err := json.Unmarshal(respBody, &deployment); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &deployment, nil
+}
+
+// ScaleToZero stops all of a deployment's workers, regardless of its
+// minimum, until the next invocation starts one again.
+func (c *Client) ScaleToZero(deploymentID string) (*DeploymentResponse, error) {
+	httpReq, err := http.NewRequest("POST", c.baseURL+"/v1/deployments/"+deploymentID+"/scale-to-zero", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var deployment DeploymentResponse
+	if err := json.Unmarshal(respBody, &deployment); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &deployment, nil
+}
+
+// Invoke calls a function on a deployment with a JSON payload.
+// Non-2xx responses are returned as errors alongside the response.
+func (c *Client) Invoke(deploymentID, function string, payload []byte) (*InvokeResponse, error) {
+	return c.InvokeWithOptions(deploymentID, function, payload, InvokeOptions{})
+}
+
+// InvokeURL is the endpoint that invokes a deployment's function on the
+// orchestrator at baseURL.
+func InvokeURL(baseURL, deploymentID, function string) string {
+	return fmt.Sprintf("%s/v1/deployments/%s/functions/%s/invoke", baseURL, deploymentID, function)
+}
+
+// InvokeWithOptions calls a function on a deployment like Invoke, with an
+// idempotency key and request timeout.
+func (c *Client) InvokeWithOptions(deploymentID, function string, payload []byte, opts InvokeOptions) (*InvokeResponse, error) {
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+
+	httpReq, err := http.NewRequest("POST", InvokeURL(c.baseURL, deploymentID, function), bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	if opts.IdempotencyKey != "" {
+		httpReq.Header.Set(IdempotencyKeyHeader, opts.IdempotencyKey)
+	}
+
+	httpClient := c.httpClient
+	if opts.Timeout > 0 {
+		httpClient = &http.Client{Timeout: opts.Timeout, Transport: c.httpClient.Transport}
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	result := &InvokeResponse{
+		StatusCode:   resp.StatusCode,
+		Body:         respBody,
+		Duration:     time.Since(start),
+		InvocationID: resp.Header.Get(InvocationIDHeader),
+		ColdStart:    resp.Header.Get(ColdStartHeader) == "true",
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return result, fmt.Errorf("function '%s' not found on deployment '%s'", function, deploymentID)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return result, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return result, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	return result, nil
+}
+
+// GetInvocation returns an invocation and the artifacts it produced.
+func (c *Client) GetInvocation(id string) (*Invocation, error) {
+	if err := c.require(FeatureAsyncJobs); err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest("GET", c.baseURL+"/v1/invocations/"+id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("invocation '%s' not found", id)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var invocation Invocation
+	if err := json.Unmarshal(respBody, &invocation); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &invocation, nil
+}
+
+// DownloadInvocationArtifact streams the content of an invocation artifact.
+// The orchestrator may redirect to the file store; the caller must close
+// the returned reader.
+func (c *Client) DownloadInvocationArtifact(id, name string) (io.ReadCloser, error) {
+	if err := c.require(FeatureAsyncJobs); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/invocations/%s/artifacts/%s", c.baseURL, id, url.PathEscape(name))
+	httpReq, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	// Videos can be large; don't cut the download short
+	downloadClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := downloadClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	return resp.Body, nil
+}
+
+// ListWorkers lists the workers running a deployment.
+func (c *Client) ListWorkers(deploymentID string) ([]Worker, error) {
+	httpReq, err := http.NewRequest("GET", c.baseURL+"/v1/deployments/"+deploymentID+"/workers", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var result ListWorkersResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.Items, nil
+}
+
+// GetDeploymentHealth returns a deployment's readiness and recent error rate.
+func (c *Client) GetDeploymentHealth(deploymentID string) (*DeploymentHealth, error) {
+	httpReq, err := http.NewRequest("GET", c.baseURL+"/v1/deployments/"+deploymentID+"/health", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var health DeploymentHealth
+	if err := json.Unmarshal(respBody, &health); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &health, nil
+}
+
+// GetDeploymentQueue returns the pending and in-flight invocations of a
+// deployment's functions.
+func (c *Client) GetDeploymentQueue(deploymentID string) (*DeploymentQueue, error) {
+	httpReq, err := http.NewRequest("GET", c.baseURL+"/v1/deployments/"+deploymentID+"/queue", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var queue DeploymentQueue
+	if err := json.Unmarshal(respBody, &queue); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &queue, nil
+}
+
+// ListDeploymentEvents returns a deployment's most recent events, newest
+// first. A limit of 0 uses the server's default.
+func (c *Client) ListDeploymentEvents(deploymentID string, limit int) ([]DeploymentEvent, error) {
+	endpoint := c.baseURL + "/v1/deployments/" + deploymentID + "/events"
+	if limit > 0 {
+		endpoint += "?limit=" + strconv.Itoa(limit)
+	}
+	httpReq, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var result ListDeploymentEventsResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.Items, nil
+}
+
+// StreamWorkerMetrics follows a deployment's metrics stream, calling fn with
+// each snapshot (one JSON object per line, sent every interval) until ctx is
+// cancelled, the server ends the stream, or fn returns an error.
+func (c *Client) StreamWorkerMetrics(ctx context.Context, deploymentID string, interval time.Duration, fn func(*MetricsSnapshot) error) error {
+	endpoint := fmt.Sprintf("%s/v1/deployments/%s/metrics/stream", c.baseURL, deploymentID)
+	if interval > 0 {
+		endpoint += "?interval=" + url.QueryEscape(interval.String())
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Accept", "application/x-ndjson")
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	// No client timeout: the stream stays open until ctx is cancelled
+	resp, err := (&http.Client{Transport: c.httpClient.Transport}).Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var snapshot MetricsSnapshot
+		if err := decoder.Decode(&snapshot); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read metrics stream: %w", err)
+		}
+		if err := fn(&snapshot); err != nil {
+			return err
+		}
+	}
+}
+
+// ExecWorker starts command in a worker container and returns the websocket
+// carrying the session, proxied by the orchestrator. Messages use the Exec*
+// channels. With tty the command gets a pseudo-terminal.
+func (c *Client) ExecWorker(ctx context.Context, workerID string, command []string, tty bool) (*websocket.Conn, error) {
+	if err := c.require(FeatureWorkerExec); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{"command": command}
+	if tty {
+		query.Set("tty", "true")
+	}
+	endpoint := fmt.Sprintf("%s/v1/workers/%s/exec?%s", c.baseURL, workerID, query.Encode())
+
+	conn, err := websocket.Dial(ctx, endpoint, http.Header{"Authorization": {"Bearer " + c.token}})
+	var handshakeErr *websocket.HandshakeError
+	if errors.As(err, &handshakeErr) {
+		if handshakeErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("worker '%s' not found", workerID)
+		}
+		var errResp ErrorResponse
+		if json.Unmarshal(handshakeErr.Body, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", handshakeErr.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", handshakeErr.StatusCode, string(handshakeErr.Body))
+	}
+	return conn, err
+}
@@ -4,12 +4,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
 
 func TestNewClient(t *testing.T) {
-	client := NewClient("http://localhost:8090", "test-token")
+	client := NewClient("http://localhost:8090", "test-token", nil)
 
 	if client.baseURL != "http://localhost:8090" {
 		t.Errorf("baseURL = %q, want %q", client.baseURL, "http://localhost:8090")
@@ -20,7 +21,7 @@ func TestNewClient(t *testing.T) {
 }
 
 func TestNewClient_TrimsTrailingSlash(t *testing.T) {
-	client := NewClient("http://localhost:8090/", "test-token")
+	client := NewClient("http://localhost:8090/", "test-token", nil)
 
 	if client.baseURL != "http://localhost:8090" {
 		t.Errorf("baseURL = %q, want %q (trailing slash should be trimmed)", client.baseURL, "http://localhost:8090")
@@ -65,7 +66,7 @@ func TestCreateDeployment_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	resp, err := client.CreateDeployment(&CreateDeploymentRequest{
 		ID:       "test-deployment",
 		Name:     "test-deployment",
@@ -93,7 +94,7 @@ func TestCreateDeployment_Conflict(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	_, err := client.CreateDeployment(&CreateDeploymentRequest{
 		ID: "existing-deployment",
 	})
@@ -137,7 +138,7 @@ func TestCreateDeployment_WithFunctions(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	resp, err := client.CreateDeployment(&CreateDeploymentRequest{
 		ID:       "ml-deployment",
 		ImageURL: "registry.example.com/ml:v1",
@@ -180,7 +181,7 @@ func TestUpdateDeployment_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	resp, err := client.UpdateDeployment("test-deployment", &UpdateDeploymentRequest{
 		ImageURL: "registry.example.com/test:v2",
 	})
@@ -199,7 +200,7 @@ func TestUpdateDeployment_NotFound(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	_, err := client.UpdateDeployment("nonexistent", &UpdateDeploymentRequest{
 		ImageURL: "registry.example.com/test:v2",
 	})
@@ -230,7 +231,7 @@ func TestGetDeployment_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	resp, err := client.GetDeployment("test-deployment")
 
 	if err != nil {
@@ -250,7 +251,7 @@ func TestGetDeployment_NotFound(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	resp, err := client.GetDeployment("nonexistent")
 
 	if err != nil {
@@ -280,7 +281,7 @@ func TestListDeployments_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	deployments, err := client.ListDeployments()
 
 	if err != nil {
@@ -300,7 +301,7 @@ func TestListDeployments_Empty(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	deployments, err := client.ListDeployments()
 
 	if err != nil {
@@ -311,6 +312,41 @@ func TestListDeployments_Empty(t *testing.T) {
 	}
 }
 
+func TestListDeploymentsPage_QueryParams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("limit") != "20" || q.Get("cursor") != "abc" || q.Get("name") != "sdxl" || q.Get("sort") != "-updated_at" {
+			t.Errorf("Query = %q", r.URL.RawQuery)
+		}
+		if q.Has("page") {
+			t.Errorf("page should be omitted when zero")
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(ListDeploymentsResponse{
+			Items:      []DeploymentResponse{{ID: "sdxl-1"}},
+			Total:      41,
+			NextCursor: "def",
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", nil)
+	page, err := client.ListDeploymentsPage(ListDeploymentsOptions{
+		Limit:      20,
+		Cursor:     "abc",
+		NameFilter: "sdxl",
+		Sort:       "-updated_at",
+	})
+
+	if err != nil {
+		t.Fatalf("ListDeploymentsPage failed: %v", err)
+	}
+	if len(page.Items) != 1 || page.Total != 41 || page.NextCursor != "def" {
+		t.Errorf("Page = %+v", page)
+	}
+}
+
 func TestDeleteDeployment_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "DELETE" {
@@ -324,7 +360,7 @@ func TestDeleteDeployment_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	err := client.DeleteDeployment("test-deployment")
 
 	if err != nil {
@@ -338,7 +374,7 @@ func TestDeleteDeployment_NotFound(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	err := client.DeleteDeployment("nonexistent")
 
 	if err == nil {
@@ -359,7 +395,7 @@ func TestAPIError_WithMessage(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, "test-token")
+	client := NewClient(server.URL, "test-token", nil)
 	_, err := client.CreateDeployment(&CreateDeploymentRequest{
 		ID:       "test",
 		ImageURL: "invalid",
@@ -372,3 +408,23 @@ func TestAPIError_WithMessage(t *testing.T) {
 		t.Errorf("Error = %q, want API error message", err.Error())
 	}
 }
+
+func TestGetDeployment_RedactsEchoedCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error:   "unauthorized",
+			Message: "invalid credentials: Authorization: " + r.Header.Get("Authorization"),
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "leaky-token-0123456789", nil)
+	_, err := client.GetDeployment("my-model")
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if strings.Contains(err.Error(), "leaky-token") {
+		t.Errorf("Error leaks the token: %q", err.Error())
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/websocket"
+)
+
+// OrchestratorAPI is the orchestrator API used by commands.
+// *Client implements it against a live orchestrator; tests and the mock
+// server can substitute their own implementation.
+type OrchestratorAPI interface {
+	Capabilities() (*Capabilities, error)
+	DeployWithBuildID(req *DeployWithBuildIDRequest) (*DeploymentResponse, error)
+	CreateDeployment(req *CreateDeploymentRequest) (*DeploymentResponse, error)
+	UpdateDeployment(id string, req *UpdateDeploymentRequest) (*DeploymentResponse, error)
+	GetDeployment(id string) (*DeploymentResponse, error)
+	ListDeployments() ([]DeploymentResponse, error)
+	ListDeploymentsPage(opts ListDeploymentsOptions) (*ListDeploymentsResponse, error)
+	DeleteDeployment(id string) error
+	RequestDeploymentTransfer(id string, req *TransferDeploymentRequest) (*DeploymentTransfer, error)
+	ConfirmDeploymentTransfer(id, transferID, token string) (*DeploymentTransfer, error)
+	CancelDeploymentTransfer(id, transferID string) (*DeploymentTransfer, error)
+	CreateDeploymentSnapshot(id string, req *CreateDeploymentSnapshotRequest) (*DeploymentSnapshot, error)
+	ListDeploymentSnapshots(id string) ([]DeploymentSnapshot, error)
+	RestoreDeploymentSnapshot(id, snapshotID string) (*DeploymentResponse, error)
+	SetFunctionEnabled(deploymentID, function string, enabled bool) (*DeploymentResponse, error)
+	ScaleToZero(deploymentID string) (*DeploymentResponse, error)
+	LockDeployment(id, reason string) (*DeploymentResponse, error)
+	UnlockDeployment(id, reason string) (*DeploymentResponse, error)
+	Invoke(deploymentID, function string, payload []byte) (*InvokeResponse, error)
+	InvokeWithOptions(deploymentID, function string, payload []byte, opts InvokeOptions) (*InvokeResponse, error)
+	GetInvocation(id string) (*Invocation, error)
+	DownloadInvocationArtifact(id, name string) (io.ReadCloser, error)
+	ListWorkers(deploymentID string) ([]Worker, error)
+	ListDeploymentEvents(deploymentID string, limit int) ([]DeploymentEvent, error)
+	GetDeploymentHealth(deploymentID string) (*DeploymentHealth, error)
+	GetDeploymentQueue(deploymentID string) (*DeploymentQueue, error)
+	GetDeploymentStats(deploymentID string) (*DeploymentStats, error)
+	StreamWorkerMetrics(ctx context.Context, deploymentID string, interval time.Duration, fn func(*MetricsSnapshot) error) error
+	ExecWorker(ctx context.Context, workerID string, command []string, tty bool) (*websocket.Conn, error)
+}
+
+// BuilderAPI is the cozy-hub builder API used by commands.
+// *BuilderClient implements it against a live cozy-hub.
+type BuilderAPI interface {
+	Capabilities() (*Capabilities, error)
+	UploadTarball(tarball io.Reader, buildName string) (string, error)
+	UploadFile(path string, body io.Reader, contentType string) error
+	ListFiles(prefix string) ([]StoredFile, error)
+	DeleteFile(path string) error
+	GetStorageUsage() (*StorageUsage, error)
+	SetNotificationRule(req *SetNotificationRuleRequest) (*NotificationRule, error)
+	ListNotificationRules() ([]NotificationRule, error)
+	DeleteNotificationRule(id string) error
+	ListTemplates() ([]ProjectTemplate, error)
+	DownloadTemplate(name string) (io.ReadCloser, error)
+	CreateMultipartUpload(path string) (*MultipartUpload, error)
+	UploadPart(path, uploadID string, partNumber int, data []byte) (*UploadedPart, error)
+	CompleteMultipartUpload(path, uploadID string, parts []UploadedPart) error
+	AbortMultipartUpload(path, uploadID string) error
+	UploadBuild(tarball io.Reader, buildName string, opts BuildOptions) (*BuildUploadResponse, error)
+	CreateBuild(tarballPath string, opts BuildOptions) (*BuildUploadResponse, error)
+	GetBuildStatus(buildID string) (*BuildStatusResponse, error)
+	ListBuilds(deploymentID string, limit int) ([]Build, error)
+	CancelBuild(buildID string) (*Build, error)
+	ListBuildArtifacts(buildID string) ([]BuildArtifact, error)
+	DownloadBuildArtifact(buildID, name string) (io.ReadCloser, error)
+	PutBuildProvenance(buildID string, statement *ProvenanceStatement) error
+	GetBuildProvenance(buildID string) (*ProvenanceStatement, error)
+	GetBuildLogs(buildID string, afterID int64, limit int) (*BuildLogsResponse, error)
+	DeployBuild(buildID string, req *DeployBuildRequest) (*BuilderDeployResponse, error)
+	GetHubDeployment(deploymentID string) (*HubDeployment, error)
+	RollbackDeployment(deploymentID, buildID string) (*HubDeployment, error)
+	ListRevisions(deploymentID string, opts ListRevisionsOptions) (*ListRevisionsResponse, error)
+	ListApprovals(status string) ([]Approval, error)
+	GetApproval(id string) (*Approval, error)
+	ApproveDeploy(id, comment string) (*Approval, error)
+	RejectDeploy(id, comment string) (*Approval, error)
+	RegisterModel(req *RegisterModelRequest) (*Model, error)
+	GetTraffic(deploymentID string) (*TrafficSplit, error)
+	SetTraffic(deploymentID string, routes []TrafficRoute) (*TrafficSplit, error)
+	SetRebuildPolicy(deploymentID, schedule string) (*RebuildPolicy, error)
+	ListRebuildPolicies() ([]RebuildPolicy, error)
+	DeleteRebuildPolicy(deploymentID string) error
+}
+
+var (
+	_ OrchestratorAPI = (*Client)(nil)
+	_ BuilderAPI      = (*BuilderClient)(nil)
+)
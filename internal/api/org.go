@@ -0,0 +1,94 @@
+package api
+
+import "net/url"
+
+// Organization roles, from least to most privileged. A role grants what
+// the scope operation of the same rank does, on every deployment of the
+// tenant.
+const (
+	RoleViewer   = "viewer"   // View deployments, builds, and logs
+	RoleDeployer = "deployer" // Also build, deploy, and invoke
+	RoleAdmin    = "admin"    // Everything, including managing members
+)
+
+// OrgRoles lists the valid organization roles.
+var OrgRoles = []string{RoleViewer, RoleDeployer, RoleAdmin}
+
+// Organization member statuses.
+const (
+	MemberActive  = "active"
+	MemberInvited = "invited" // Invitation sent and not yet accepted
+)
+
+// OrgMember is a member of the tenant's organization, or someone invited to it.
+type OrgMember struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Username  string `json:"username,omitempty"` // Empty until an invitation is accepted
+	Role      string `json:"role"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"` // When the member joined or was invited
+}
+
+// ListOrgMembersResponse is the response from GET /api/v1/org/members.
+type ListOrgMembersResponse struct {
+	Members []OrgMember `json:"members"`
+}
+
+// InviteOrgMemberRequest is the request body for POST /api/v1/org/members.
+type InviteOrgMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// SetOrgMemberRoleRequest is the request body for PUT /api/v1/org/members/{id}/role.
+type SetOrgMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// ListOrgMembers lists the organization's members and pending invitations.
+func (c *AuthClient) ListOrgMembers() ([]OrgMember, error) {
+	var list ListOrgMembersResponse
+	if err := c.do("GET", "/api/v1/org/members", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Members, nil
+}
+
+// InviteOrgMember emails an invitation to join the organization with a role.
+func (c *AuthClient) InviteOrgMember(email, role string) (*OrgMember, error) {
+	var member OrgMember
+	if err := c.do("POST", "/api/v1/org/members", &InviteOrgMemberRequest{Email: email, Role: role}, &member); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// SetOrgMemberRole changes a member's role.
+func (c *AuthClient) SetOrgMemberRole(id, role string) (*OrgMember, error) {
+	var member OrgMember
+	if err := c.do("PUT", "/api/v1/org/members/"+url.PathEscape(id)+"/role", &SetOrgMemberRoleRequest{Role: role}, &member); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveOrgMember removes a member from the organization, or withdraws an
+// invitation. Their sessions and API keys stop working for the tenant.
+func (c *AuthClient) RemoveOrgMember(id string) error {
+	return c.do("DELETE", "/api/v1/org/members/"+url.PathEscape(id), nil, nil)
+}
+
+// RoleAllows reports whether an organization role permits a scope operation.
+func RoleAllows(role, op string) bool {
+	switch role {
+	case RoleAdmin:
+		return true
+	case RoleDeployer:
+		return op != ScopeManage
+	case RoleViewer:
+		return op == ScopeRead
+	default:
+		return false
+	}
+}
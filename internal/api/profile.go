@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+)
+
+// NewProfileClient creates an orchestrator API client for a logged-in
+// profile, whose requests go through transport.
+func NewProfileClient(ref config.ProfileRef, transport http.RoundTripper) (*Client, error) {
+	cfg, err := loadProfile(ref)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(cfg.OrchestratorURL, cfg.Token, transport), nil
+}
+
+// NewProfileBuilderClient creates a cozy-hub builder API client for a
+// logged-in profile, whose requests go through transport.
+func NewProfileBuilderClient(ref config.ProfileRef, transport http.RoundTripper) (*BuilderClient, error) {
+	cfg, err := loadProfile(ref)
+	if err != nil {
+		return nil, err
+	}
+	return NewBuilderClient(cfg.BuilderURL, cfg.Token, transport), nil
+}
+
+// NewProfileAuthClient creates an AuthKit API client for a logged-in
+// profile, whose requests go through transport.
+func NewProfileAuthClient(ref config.ProfileRef, transport http.RoundTripper) (*AuthClient, error) {
+	cfg, err := loadProfile(ref)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuthClient(cfg.HubURL, cfg.Token, transport), nil
+}
+
+// loadProfile loads a logged-in profile's config, with default server URLs
+// filled in.
+func loadProfile(ref config.ProfileRef) (*config.ConfigData, error) {
+	profileCfg, err := config.LoadLoggedInProfile(ref)
+	if err != nil {
+		return nil, err
+	}
+	return profileCfg.Config.WithDefaultURLs(), nil
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Revision states.
+const (
+	RevisionActive     = "active"
+	RevisionSuperseded = "superseded"  // Replaced by a later deploy
+	RevisionRolledBack = "rolled_back" // Replaced by rolling back to an earlier build
+)
+
+// Revision causes: how a build became active.
+const (
+	RevisionCauseDeploy   = "deploy"
+	RevisionCauseRollback = "rollback"
+)
+
+// Revision records one build that was active on a deployment: every deploy,
+// approved deploy, and rollback adds one.
+type Revision struct {
+	Number       int    `json:"revision"` // 1 for the deployment's first build
+	DeploymentID string `json:"deployment_id"`
+	BuildID      string `json:"build_id"`
+	ImageTag     string `json:"image_tag"`
+	Status       string `json:"status"` // One of the Revision* states
+	Cause        string `json:"cause"`  // One of the RevisionCause* values
+	DeployedBy   string `json:"deployed_by,omitempty"`
+	ApprovalID   string `json:"approval_id,omitempty"` // Set if the deploy was approved
+	DeployedAt   string `json:"deployed_at"`
+	ReplacedAt   string `json:"replaced_at,omitempty"` // Unset while active
+}
+
+// ListRevisionsOptions pages a deployment's revisions. Zero values leave the
+// choice to the server.
+type ListRevisionsOptions struct {
+	Limit  int    // Maximum revisions per page
+	Cursor string // NextCursor from a previous page
+}
+
+// ListRevisionsResponse is one page of a deployment's revisions, newest first.
+type ListRevisionsResponse struct {
+	Revisions  []Revision `json:"revisions"`
+	NextCursor string     `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// ListRevisions lists one page of the builds that have been active on a
+// deployment, newest first.
+func (c *BuilderClient) ListRevisions(deploymentID string, opts ListRevisionsOptions) (*ListRevisionsResponse, error) {
+	if err := c.require(FeatureRevisions); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	reqURL := fmt.Sprintf("%s/api/v1/deployments/%s/revisions", c.baseURL, deploymentID)
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	httpReq, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Error)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var listResp ListRevisionsResponse
+	if err := json.Unmarshal(respBody, &listResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if listResp.Revisions == nil {
+		listResp.Revisions = []Revision{}
+	}
+
+	return &listResp, nil
+}
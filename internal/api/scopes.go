@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/redact"
+)
+
+// Operations an API key scope can grant.
+const (
+	ScopeRead   = "read"   // View deployments, builds, and logs
+	ScopeDeploy = "deploy" // Upload builds and roll them out (implies read)
+	ScopeInvoke = "invoke" // Call deployed functions (implies read)
+	ScopeManage = "manage" // Everything, including deleting and transferring deployments
+)
+
+// ScopeOperations lists the valid scope operations.
+var ScopeOperations = []string{ScopeRead, ScopeDeploy, ScopeInvoke, ScopeManage}
+
+// ErrInsufficientScope is wrapped by API errors for requests refused because
+// the token's scopes do not cover them.
+var ErrInsufficientScope = errors.New("the API key's scopes do not allow this " +
+	"(create a key with the needed scope, e.g. 'cozyctl keys create --scope deploy:<deployment>', " +
+	"or log in with an unscoped token)")
+
+// Scope limits an API key to one operation, on one deployment or on all of them.
+type Scope struct {
+	Operation  string
+	Deployment string // "*" for every deployment
+}
+
+// ParseScope parses "operation[:deployment]". Without a deployment the
+// scope covers every deployment.
+func ParseScope(s string) (Scope, error) {
+	op, deployment, found := strings.Cut(strings.TrimSpace(s), ":")
+	if !slices.Contains(ScopeOperations, op) {
+		return Scope{}, fmt.Errorf("invalid scope %q: operation must be one of %s", s, strings.Join(ScopeOperations, ", "))
+	}
+	if !found || deployment == "" {
+		deployment = "*"
+	}
+	return Scope{Operation: op, Deployment: deployment}, nil
+}
+
+// String returns the scope in "operation:deployment" form.
+func (s Scope) String() string {
+	return s.Operation + ":" + s.Deployment
+}
+
+// Allows reports whether the scope permits op on deployment. An empty
+// deployment is a request not tied to one, such as uploading a build.
+func (s Scope) Allows(op, deployment string) bool {
+	if s.Deployment != "*" && deployment != "" && s.Deployment != deployment {
+		return false
+	}
+	switch s.Operation {
+	case ScopeManage:
+		return true
+	case ScopeDeploy, ScopeInvoke:
+		return op == s.Operation || op == ScopeRead
+	default:
+		return op == s.Operation
+	}
+}
+
+// apiError formats an unsuccessful response as "<what> (<status>): <msg>",
+// wrapping ErrInsufficientScope when a scoped key was refused and
+// ErrDeploymentLocked when a locked deployment was. Credentials the server
+// echoed back in msg are redacted.
+func apiError(what string, status int, msg string) error {
+	msg = redact.String(msg)
+	if status == http.StatusForbidden && strings.Contains(strings.ToLower(msg), "scope") {
+		return fmt.Errorf("%s (%d): %s: %w", what, status, msg, ErrInsufficientScope)
+	}
+	if status == http.StatusLocked {
+		return fmt.Errorf("%s (%d): %s: %w", what, status, msg, ErrDeploymentLocked)
+	}
+	return fmt.Errorf("%s (%d): %s", what, status, msg)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// FunctionStats counts the invocations of one of a deployment's functions.
+type FunctionStats struct {
+	Function      string     `json:"function"`
+	Invocations   int64      `json:"invocations"`
+	Errors        int64      `json:"errors"`         // Invocations that failed
+	AvgLatencyMs  float64    `json:"avg_latency_ms"` // Mean time to a result, over all invocations
+	LastInvokedAt *time.Time `json:"last_invoked_at,omitempty"`
+}
+
+// DeploymentStats are the invocation counters of a deployment's functions,
+// covering the invocations since Since (how long the orchestrator keeps them).
+// Functions that were never invoked are listed with zero counts.
+type DeploymentStats struct {
+	DeploymentID string          `json:"deployment_id"`
+	Since        time.Time       `json:"since"`
+	Functions    []FunctionStats `json:"functions"`
+}
+
+// GetDeploymentStats returns the per-function invocation counters of a
+// deployment.
+func (c *Client) GetDeploymentStats(deploymentID string) (*DeploymentStats, error) {
+	if err := c.require(FeatureFunctionStats); err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest("GET", c.baseURL+"/v1/deployments/"+deploymentID+"/stats", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment '%s' not found", deploymentID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return nil, apiError("API error", resp.StatusCode, errResp.Message)
+		}
+		return nil, apiError("API error", resp.StatusCode, string(respBody))
+	}
+
+	var stats DeploymentStats
+	if err := json.Unmarshal(respBody, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &stats, nil
+}
@@ -6,29 +6,34 @@ import "time"
 type FunctionRequirement struct {
 	Name        string `json:"name"`
 	RequiresGPU bool   `json:"requires_gpu"`
+	// Disabled takes the function out of rotation: invocations are refused
+	// while its sibling functions keep serving. The orchestrator keeps the
+	// flag when a redeploy replaces the function list, so only
+	// SetFunctionEnabled turns it back on.
+	Disabled bool `json:"disabled,omitempty"`
 }
 
 // CreateDeploymentRequest is the request body for creating a deployment.
 type CreateDeploymentRequest struct {
-	ID                   string              `json:"id"`
-	Name                 string              `json:"name,omitempty"`
-	ImageURL             string              `json:"image_url"`
+	ID                   string                `json:"id"`
+	Name                 string                `json:"name,omitempty"`
+	ImageURL             string                `json:"image_url"`
 	FunctionRequirements []FunctionRequirement `json:"function_requirements,omitempty"`
-	SupportedModelIDs    []string            `json:"supported_model_ids,omitempty"`
-	RunpodSecretMapping  map[string]string   `json:"runpod_secret_mapping,omitempty"`
-	MinWorkers           *int                `json:"min_workers,omitempty"`
-	MaxWorkers           *int                `json:"max_workers,omitempty"`
+	SupportedModelIDs    []string              `json:"supported_model_ids,omitempty"`
+	RunpodSecretMapping  map[string]string     `json:"runpod_secret_mapping,omitempty"`
+	MinWorkers           *int                  `json:"min_workers,omitempty"`
+	MaxWorkers           *int                  `json:"max_workers,omitempty"`
 }
 
 // UpdateDeploymentRequest is the request body for updating a deployment.
 type UpdateDeploymentRequest struct {
-	Name                 string              `json:"name,omitempty"`
-	ImageURL             string              `json:"image_url,omitempty"`
+	Name                 string                `json:"name,omitempty"`
+	ImageURL             string                `json:"image_url,omitempty"`
 	FunctionRequirements []FunctionRequirement `json:"function_requirements,omitempty"`
-	SupportedModelIDs    []string            `json:"supported_model_ids,omitempty"`
-	RunpodSecretMapping  map[string]string   `json:"runpod_secret_mapping,omitempty"`
-	MinWorkers           *int                `json:"min_workers,omitempty"`
-	MaxWorkers           *int                `json:"max_workers,omitempty"`
+	SupportedModelIDs    []string              `json:"supported_model_ids,omitempty"`
+	RunpodSecretMapping  map[string]string     `json:"runpod_secret_mapping,omitempty"`
+	MinWorkers           *int                  `json:"min_workers,omitempty"`
+	MaxWorkers           *int                  `json:"max_workers,omitempty"`
 }
 
 // DeployWithBuildIDRequest is the request body for deploying with a build ID.
@@ -40,22 +45,263 @@ type DeployWithBuildIDRequest struct {
 
 // DeploymentResponse is the response from deployment operations.
 type DeploymentResponse struct {
-	ID                   string              `json:"id"`
-	TenantID             string              `json:"tenant_id"`
-	Name                 string              `json:"name"`
-	ImageURL             string              `json:"image_url"`
+	ID                   string                `json:"id"`
+	TenantID             string                `json:"tenant_id"`
+	Name                 string                `json:"name"`
+	ImageURL             string                `json:"image_url"`
 	FunctionRequirements []FunctionRequirement `json:"function_requirements,omitempty"`
-	SupportedModelIDs    []string            `json:"supported_model_ids,omitempty"`
-	RunpodSecretMapping  map[string]string   `json:"runpod_secret_mapping,omitempty"`
-	MinWorkers           int                 `json:"min_workers"`
-	MaxWorkers           int                 `json:"max_workers"`
-	CreatedAt            time.Time           `json:"created_at"`
-	UpdatedAt            time.Time           `json:"updated_at"`
+	SupportedModelIDs    []string              `json:"supported_model_ids,omitempty"`
+	RunpodSecretMapping  map[string]string     `json:"runpod_secret_mapping,omitempty"`
+	MinWorkers           int                   `json:"min_workers"`
+	MaxWorkers           int                   `json:"max_workers"`
+	Status               string                `json:"status,omitempty"`
+	ReadyWorkers         int                   `json:"ready_workers,omitempty"`
+	Lock                 *DeploymentLock       `json:"lock,omitempty"` // Set while the deployment is locked
+	CreatedAt            time.Time             `json:"created_at"`
+	UpdatedAt            time.Time             `json:"updated_at"`
 }
 
 // ListDeploymentsResponse is the response for listing deployments.
 type ListDeploymentsResponse struct {
-	Items []DeploymentResponse `json:"items"`
+	Items      []DeploymentResponse `json:"items"`
+	Total      int                  `json:"total,omitempty"`       // Matching deployments across all pages
+	NextCursor string               `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// ListDeploymentsOptions filters and pages a deployment listing.
+// Zero values leave the choice to the server.
+type ListDeploymentsOptions struct {
+	Limit      int    // Maximum items per page
+	Page       int    // 1-based page number (alternative to Cursor)
+	Cursor     string // NextCursor from a previous page
+	NameFilter string // Only deployments whose ID or name contains this
+	Sort       string // Field to sort by: name, created_at, or updated_at; prefix with - for descending
+}
+
+// TransferDeploymentRequest starts moving a deployment to another tenant.
+type TransferDeploymentRequest struct {
+	ToTenantID string `json:"to_tenant_id"`
+}
+
+// DeploymentTransfer is a request to move a deployment, with its build
+// history and endpoint configuration, to another tenant. The orchestrator
+// holds it as "pending" until it is confirmed with ConfirmationToken.
+type DeploymentTransfer struct {
+	ID                string     `json:"id"`
+	DeploymentID      string     `json:"deployment_id"`
+	FromTenantID      string     `json:"from_tenant_id"`
+	ToTenantID        string     `json:"to_tenant_id"`
+	Status            string     `json:"status"` // pending, completed, canceled, expired
+	ConfirmationToken string     `json:"confirmation_token,omitempty"`
+	Builds            int        `json:"builds"`    // Build records that move with the deployment
+	Endpoints         int        `json:"endpoints"` // Function endpoints that move with the deployment
+	ExpiresAt         time.Time  `json:"expires_at"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+}
+
+// ConfirmTransferRequest confirms a pending deployment transfer.
+type ConfirmTransferRequest struct {
+	ConfirmationToken string `json:"confirmation_token"`
+}
+
+// DeploymentSnapshot is a copy of a deployment's spec saved by the
+// orchestrator. It covers everything but the image, so restoring one undoes
+// configuration changes without rolling back a build.
+type DeploymentSnapshot struct {
+	ID                   string                `json:"id"`
+	DeploymentID         string                `json:"deployment_id"`
+	Description          string                `json:"description,omitempty"`
+	Name                 string                `json:"name"`
+	FunctionRequirements []FunctionRequirement `json:"function_requirements,omitempty"`
+	SupportedModelIDs    []string              `json:"supported_model_ids,omitempty"`
+	RunpodSecretMapping  map[string]string     `json:"runpod_secret_mapping,omitempty"`
+	MinWorkers           int                   `json:"min_workers"`
+	MaxWorkers           int                   `json:"max_workers"`
+	CreatedAt            time.Time             `json:"created_at"`
+}
+
+// CreateDeploymentSnapshotRequest is the request body for snapshotting a deployment.
+type CreateDeploymentSnapshotRequest struct {
+	Description string `json:"description,omitempty"`
+}
+
+// ListDeploymentSnapshotsResponse is the response for listing a deployment's snapshots.
+type ListDeploymentSnapshotsResponse struct {
+	Items []DeploymentSnapshot `json:"items"` // Newest first
+}
+
+// Worker is a container running a deployment's image.
+type Worker struct {
+	ID           string    `json:"id"`
+	DeploymentID string    `json:"deployment_id"`
+	Status       string    `json:"status"` // starting, ready, busy, stopping
+	GPU          string    `json:"gpu,omitempty"`
+	Node         string    `json:"node,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+}
+
+// ListWorkersResponse is the response for listing a deployment's workers.
+type ListWorkersResponse struct {
+	Items []Worker `json:"items"`
+}
+
+// DeploymentHealth is a deployment's readiness and the outcome of its recent
+// invocations, as judged by the orchestrator over a trailing window.
+type DeploymentHealth struct {
+	DeploymentID   string `json:"deployment_id"`
+	Status         string `json:"status"`
+	ReadyWorkers   int    `json:"ready_workers"`
+	DesiredWorkers int    `json:"desired_workers"`
+	Requests       int    `json:"requests"`       // Invocations in the window
+	Errors         int    `json:"errors"`         // Invocations that failed with a server error
+	WindowSeconds  int    `json:"window_seconds"` // Length of the trailing window
+}
+
+// DeploymentQueue is the backlog of a deployment's invocations, per function.
+type DeploymentQueue struct {
+	DeploymentID string          `json:"deployment_id"`
+	ReadyWorkers int             `json:"ready_workers"`
+	BusyWorkers  int             `json:"busy_workers"`
+	MaxWorkers   int             `json:"max_workers"`
+	Functions    []FunctionQueue `json:"functions"`
+}
+
+// FunctionQueue is the backlog of one function's invocations.
+type FunctionQueue struct {
+	Function         string     `json:"function"`
+	Pending          int        `json:"pending"`                       // Queued, not yet picked up by a worker
+	InFlight         int        `json:"in_flight"`                     // Running on a worker
+	OldestPendingAt  *time.Time `json:"oldest_pending_at,omitempty"`   // When the oldest pending invocation was queued
+	OldestInFlightAt *time.Time `json:"oldest_in_flight_at,omitempty"` // When the longest-running invocation started
+}
+
+// ErrorRate is the fraction of invocations in the window that failed.
+func (h *DeploymentHealth) ErrorRate() float64 {
+	if h.Requests == 0 {
+		return 0
+	}
+	return float64(h.Errors) / float64(h.Requests)
+}
+
+// Deployment event types reported by the orchestrator.
+const (
+	EventScaledUp      = "scaled_up"
+	EventScaledDown    = "scaled_down"
+	EventImageUpdated  = "image_updated"
+	EventWorkerCrashed = "worker_crashed"
+	EventWorkerOOM     = "worker_oom"
+
+	// Startup of a worker from zero, in order: placed on a machine, image
+	// pulled, models loaded and ready to serve
+	EventWorkerScheduled = "worker_scheduled"
+	EventImagePulled     = "image_pulled"
+	EventModelsLoaded    = "models_loaded"
+)
+
+// DeploymentEvent is something that happened to a deployment or one of its
+// workers, such as a scale change or a crash.
+type DeploymentEvent struct {
+	Type      string    `json:"type"`
+	Message   string    `json:"message"`
+	WorkerID  string    `json:"worker_id,omitempty"`
+	ExitCode  *int      `json:"exit_code,omitempty"` // Set for crashed workers
+	Reason    string    `json:"reason,omitempty"`    // Why a worker exited, e.g. "Error" or "OOMKilled"
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ListDeploymentEventsResponse is the response for listing a deployment's events.
+type ListDeploymentEventsResponse struct {
+	Items []DeploymentEvent `json:"items"`
+}
+
+// WorkerMetrics is one worker's GPU usage in a metrics snapshot.
+type WorkerMetrics struct {
+	WorkerID           string  `json:"worker_id"`
+	Status             string  `json:"status"`
+	GPU                string  `json:"gpu,omitempty"`
+	MemoryUsedMB       int     `json:"memory_used_mb"`
+	MemoryTotalMB      int     `json:"memory_total_mb"`
+	UtilizationPercent float64 `json:"utilization_percent"`
+	TemperatureC       float64 `json:"temperature_c"`
+	CurrentFunction    string  `json:"current_function,omitempty"` // Empty when idle
+}
+
+// MetricsSnapshot is one message of a deployment's metrics stream.
+type MetricsSnapshot struct {
+	DeploymentID string          `json:"deployment_id"`
+	Timestamp    time.Time       `json:"timestamp"`
+	Workers      []WorkerMetrics `json:"workers"`
+}
+
+// Channels of an exec session. Every websocket message is a binary frame
+// whose first byte names the channel; the rest is the payload.
+const (
+	ExecStdin  byte = 0 // Client to worker; an empty payload closes stdin
+	ExecStdout byte = 1 // Worker to client
+	ExecStderr byte = 2 // Worker to client
+	ExecStatus byte = 3 // Worker to client, last message: ExecResult as JSON
+	ExecResize byte = 4 // Client to worker: TerminalSize as JSON
+)
+
+// ExecResult reports how an exec'd command ended.
+type ExecResult struct {
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"` // Set if the command could not be started
+}
+
+// TerminalSize is the client's terminal size, for TTY sessions.
+type TerminalSize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// InvokeResponse is the raw result of invoking a deployment function.
+type InvokeResponse struct {
+	StatusCode   int
+	Body         []byte
+	Duration     time.Duration
+	InvocationID string // From the X-Invocation-ID header, when the orchestrator sends one
+	ColdStart    bool   // The orchestrator started a worker to serve the invocation
+}
+
+// InvocationIDHeader carries the ID of an invocation in its response.
+const InvocationIDHeader = "X-Invocation-ID"
+
+// ColdStartHeader is set to "true" on the response of an invocation that
+// had to wait for a worker to start.
+const ColdStartHeader = "X-Cold-Start"
+
+// IdempotencyKeyHeader carries a client-chosen key identifying an
+// invocation. The orchestrator runs a function once per key and answers
+// repeats with the original result, so retried requests are safe.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// InvokeOptions contains the per-request options of an invocation.
+type InvokeOptions struct {
+	IdempotencyKey string
+	Timeout        time.Duration // Overrides the client's request timeout when set
+}
+
+// Invocation is one call of a deployment function, including async jobs
+// whose results are written to the file store.
+type Invocation struct {
+	ID           string               `json:"id"`
+	DeploymentID string               `json:"deployment_id"`
+	Function     string               `json:"function"`
+	Status       string               `json:"status"` // queued, running, succeeded, failed
+	Error        string               `json:"error,omitempty"`
+	Artifacts    []InvocationArtifact `json:"artifacts"`
+	CreatedAt    time.Time            `json:"created_at"`
+	CompletedAt  *time.Time           `json:"completed_at,omitempty"`
+}
+
+// InvocationArtifact is an output file an invocation stored in the file
+// store, such as a generated image or video.
+type InvocationArtifact struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"` // File store path
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type,omitempty"`
 }
 
 // ErrorResponse represents an API error response.
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// IsUnreachable reports whether err means a request never got a response:
+// the server's name didn't resolve, the connection was refused or dropped,
+// or the request timed out. Errors the server answered with, and requests
+// cancelled by the caller, are not.
+func IsUnreachable(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled)
+}
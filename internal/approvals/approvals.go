@@ -0,0 +1,185 @@
+// Package approvals reviews deploys held by cozy-hub until an authorized
+// user approves or rejects them.
+package approvals
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ListOptions contains the options for listing approvals.
+type ListOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Status    string // One of the api.Approval* states; all if empty
+	Output    ui.Output
+}
+
+// GetOptions contains the options for showing an approval.
+type GetOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	ID        string
+	Output    ui.Output
+}
+
+// DecideOptions contains the options for approving or rejecting a deploy.
+type DecideOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	ID        string
+	Comment   string
+}
+
+// List prints the deploys submitted for approval.
+func List(opts ListOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return list(os.Stdout, client, opts)
+}
+
+func list(w io.Writer, client api.BuilderAPI, opts ListOptions) error {
+	switch opts.Status {
+	case "", api.ApprovalPending, api.ApprovalApproved, api.ApprovalRejected:
+	default:
+		return fmt.Errorf("unknown status '%s' (must be %s, %s, or %s)", opts.Status, api.ApprovalPending, api.ApprovalApproved, api.ApprovalRejected)
+	}
+
+	approvals, err := client.ListApprovals(opts.Status)
+	if err != nil {
+		return fmt.Errorf("failed to list approvals: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		if approvals == nil {
+			approvals = []api.Approval{}
+		}
+		return ui.WriteStructured(w, opts.Output, approvals)
+	}
+	if len(approvals) == 0 {
+		if opts.Status != "" {
+			fmt.Fprintf(w, "No %s approvals.\n", opts.Status)
+		} else {
+			fmt.Fprintln(w, "No approvals.")
+		}
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"ID", "DEPLOYMENT", "BUILD", "STATUS", "REQUESTED BY", "CREATED"}}
+	for _, a := range approvals {
+		table.Rows = append(table.Rows, ui.Row{Key: a.ID, Cells: []string{
+			a.ID, a.DeploymentID, a.BuildID, a.Status, orDash(a.RequestedBy), formatTime(a.CreatedAt),
+		}})
+	}
+	return table.Write(w)
+}
+
+// Get prints one deploy submitted for approval.
+func Get(opts GetOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return get(os.Stdout, client, opts)
+}
+
+func get(w io.Writer, client api.BuilderAPI, opts GetOptions) error {
+	approval, err := client.GetApproval(opts.ID)
+	if err != nil {
+		return err
+	}
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, approval)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Approval:\t%s\n", approval.ID)
+	fmt.Fprintf(tw, "Deployment:\t%s\n", approval.DeploymentID)
+	fmt.Fprintf(tw, "Build:\t%s\n", approval.BuildID)
+	fmt.Fprintf(tw, "Status:\t%s\n", approval.Status)
+	if approval.URL != "" {
+		fmt.Fprintf(tw, "Review:\t%s\n", approval.URL)
+	}
+	fmt.Fprintf(tw, "Requested:\t%s by %s\n", formatTime(approval.CreatedAt), orDash(approval.RequestedBy))
+	if approval.Status != api.ApprovalPending {
+		fmt.Fprintf(tw, "Decided:\t%s by %s\n", formatTime(approval.DecidedAt), orDash(approval.DecidedBy))
+	}
+	if approval.Comment != "" {
+		fmt.Fprintf(tw, "Comment:\t%s\n", approval.Comment)
+	}
+	return tw.Flush()
+}
+
+// Approve approves a pending deploy, which activates its build.
+func Approve(opts DecideOptions) (err error) {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	recorder := history.Start(opts.Profile, "approvals approve")
+	defer func() { recorder.Finish(map[string]string{"approval_id": opts.ID}, err) }()
+
+	return approve(os.Stdout, client, opts)
+}
+
+func approve(w io.Writer, client api.BuilderAPI, opts DecideOptions) error {
+	approval, err := client.ApproveDeploy(opts.ID, opts.Comment)
+	if err != nil {
+		return fmt.Errorf("failed to approve %s: %w", opts.ID, err)
+	}
+	fmt.Fprintf(w, "Approved %s: build %s is now active on %s.\n", approval.ID, approval.BuildID, approval.DeploymentID)
+	return nil
+}
+
+// Reject rejects a pending deploy, leaving the deployment unchanged.
+func Reject(opts DecideOptions) (err error) {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	recorder := history.Start(opts.Profile, "approvals reject")
+	defer func() { recorder.Finish(map[string]string{"approval_id": opts.ID}, err) }()
+
+	return reject(os.Stdout, client, opts)
+}
+
+func reject(w io.Writer, client api.BuilderAPI, opts DecideOptions) error {
+	if opts.Comment == "" {
+		return fmt.Errorf("a comment is required to reject a deploy (use --comment)")
+	}
+	approval, err := client.RejectDeploy(opts.ID, opts.Comment)
+	if err != nil {
+		return fmt.Errorf("failed to reject %s: %w", opts.ID, err)
+	}
+	fmt.Fprintf(w, "Rejected %s: build %s was not deployed to %s.\n", approval.ID, approval.BuildID, approval.DeploymentID)
+	return nil
+}
+
+// formatTime shows an RFC 3339 timestamp in local time; other values are shown as-is.
+func formatTime(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return orDash(ts)
+	}
+	return t.Local().Format("2006-01-02 15:04 MST")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
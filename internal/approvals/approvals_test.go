@@ -0,0 +1,88 @@
+package approvals
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestApproveAndReject(t *testing.T) {
+	server := mockserver.New()
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+	server.RequireApproval("prod-model")
+
+	submit := func() *api.Approval {
+		t.Helper()
+		upload, err := client.UploadBuild(strings.NewReader("tarball"), "prod-model", api.BuildOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.DeployBuild(upload.BuildID, &api.DeployBuildRequest{})
+		var pending *api.PendingApprovalError
+		if !errors.As(err, &pending) {
+			t.Fatalf("got %v, want a pending approval", err)
+		}
+		return pending.Approval
+	}
+	first, second := submit(), submit()
+
+	var out bytes.Buffer
+	if err := list(&out, client, ListOptions{Status: api.ApprovalPending}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), first.ID) || !strings.Contains(out.String(), second.ID) {
+		t.Errorf("pending approvals missing:\n%s", out.String())
+	}
+
+	if err := reject(&out, client, DecideOptions{ID: first.ID}); err == nil || !strings.Contains(err.Error(), "comment is required") {
+		t.Errorf("got %v, want comment required", err)
+	}
+	if err := reject(&out, client, DecideOptions{ID: first.ID, Comment: "not during the freeze"}); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := approve(&out, client, DecideOptions{ID: second.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), second.BuildID+" is now active on prod-model") {
+		t.Errorf("unexpected approve output:\n%s", out.String())
+	}
+
+	hub, err := client.GetHubDeployment("prod-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hub.ActiveBuildID == nil || *hub.ActiveBuildID != second.BuildID {
+		t.Errorf("active build %v, want %s", hub.ActiveBuildID, second.BuildID)
+	}
+
+	// Decided approvals can't be decided again
+	if err := approve(&out, client, DecideOptions{ID: first.ID}); err == nil || !strings.Contains(err.Error(), "already rejected") {
+		t.Errorf("got %v, want already rejected", err)
+	}
+
+	out.Reset()
+	if err := get(&out, client, GetOptions{ID: first.ID}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Status:      rejected", "Comment:     not during the freeze"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("get output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := list(&out, client, ListOptions{Status: api.ApprovalPending}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "No pending approvals.") {
+		t.Errorf("unexpected list output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,193 @@
+// Package artifacts lists and downloads the output files invocations store
+// in the file store, such as generated images and videos.
+package artifacts
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ListOptions contains the options for listing an invocation's artifacts.
+type ListOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	InvocationID string
+	Output       ui.Output
+}
+
+// List prints the artifacts an invocation produced.
+func List(opts ListOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return list(os.Stdout, client, opts)
+}
+
+func list(w io.Writer, client api.OrchestratorAPI, opts ListOptions) error {
+	invocation, err := client.GetInvocation(opts.InvocationID)
+	if err != nil {
+		return fmt.Errorf("failed to get invocation: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, invocation)
+	}
+
+	fmt.Fprintf(w, "Invocation %s: %s on %s, %s\n", invocation.ID, invocation.Function, invocation.DeploymentID, invocation.Status)
+	if invocation.Error != "" {
+		fmt.Fprintf(w, "Error: %s\n", invocation.Error)
+	}
+
+	if len(invocation.Artifacts) == 0 {
+		fmt.Fprintln(w, noArtifacts(invocation))
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	columns := []string{"NAME", "TYPE", "SIZE"}
+	if opts.Output == ui.OutputWide {
+		columns = append(columns, "PATH")
+	}
+	table := &ui.Table{Columns: columns}
+	for _, a := range invocation.Artifacts {
+		cells := []string{a.Name, orDash(a.ContentType), ui.FormatBytes(a.Size)}
+		if opts.Output == ui.OutputWide {
+			cells = append(cells, a.Path)
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: a.Name, Cells: cells})
+	}
+	return table.Write(w)
+}
+
+// DownloadOptions contains the options for downloading an invocation's artifacts.
+type DownloadOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	InvocationID string
+	Names        []string // Only these artifacts (default: all)
+	OutDir       string   // Defaults to <invocation-id>
+}
+
+// Download saves the artifacts an invocation produced.
+func Download(opts DownloadOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return download(os.Stdout, client, opts)
+}
+
+func download(w io.Writer, client api.OrchestratorAPI, opts DownloadOptions) error {
+	invocation, err := client.GetInvocation(opts.InvocationID)
+	if err != nil {
+		return fmt.Errorf("failed to get invocation: %w", err)
+	}
+	if len(invocation.Artifacts) == 0 {
+		return fmt.Errorf("invocation %s: %s", invocation.ID, noArtifacts(invocation))
+	}
+
+	selected := invocation.Artifacts
+	if len(opts.Names) > 0 {
+		selected = nil
+		for _, name := range opts.Names {
+			i := slices.IndexFunc(invocation.Artifacts, func(a api.InvocationArtifact) bool { return a.Name == name })
+			if i < 0 {
+				return fmt.Errorf("invocation %s has no artifact %q", invocation.ID, name)
+			}
+			selected = append(selected, invocation.Artifacts[i])
+		}
+	}
+
+	outDir := opts.OutDir
+	if outDir == "" {
+		outDir = invocation.ID
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", outDir, err)
+	}
+
+	var total int64
+	for _, a := range selected {
+		path, n, err := downloadArtifact(client, invocation.ID, a, outDir)
+		if err != nil {
+			return err
+		}
+		total += n
+		fmt.Fprintf(w, "Downloaded %s (%s)\n", path, ui.FormatBytes(n))
+	}
+	if len(selected) > 1 {
+		fmt.Fprintf(w, "%d artifacts, %s\n", len(selected), ui.FormatBytes(total))
+	}
+	return nil
+}
+
+// downloadArtifact saves one artifact into dir, returning its path and size.
+func downloadArtifact(client api.OrchestratorAPI, invocationID string, a api.InvocationArtifact, dir string) (string, int64, error) {
+	// Artifact names come from the server; never let them escape dir
+	if a.Name == "" || a.Name != filepath.Base(a.Name) || a.Name == "." || a.Name == ".." {
+		return "", 0, fmt.Errorf("refusing to write artifact with unsafe name %q", a.Name)
+	}
+	path := filepath.Join(dir, a.Name)
+	n, err := Save(client, invocationID, a, path)
+	return path, n, err
+}
+
+// Save downloads one artifact to path, returning its size. The file only
+// appears once it is complete; its directory must exist.
+func Save(client api.OrchestratorAPI, invocationID string, a api.InvocationArtifact, path string) (int64, error) {
+	body, err := client.DownloadInvocationArtifact(invocationID, a.Name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", a.Name, err)
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", a.Name, err)
+	}
+	if a.Size > 0 && n != a.Size {
+		return 0, fmt.Errorf("failed to download %s: got %d bytes, expected %d", a.Name, n, a.Size)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// noArtifacts explains why an invocation has no artifacts.
+func noArtifacts(invocation *api.Invocation) string {
+	switch invocation.Status {
+	case "queued", "running":
+		return "no artifacts yet (the invocation is still " + invocation.Status + ")"
+	case "failed":
+		return "no artifacts (the invocation failed)"
+	default:
+		return "no artifacts"
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package artifacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// fakeInvocation serves a fixed invocation.
+type fakeInvocation struct {
+	api.OrchestratorAPI
+	invocation api.Invocation
+}
+
+func (f *fakeInvocation) GetInvocation(string) (*api.Invocation, error) {
+	return &f.invocation, nil
+}
+
+func (f *fakeInvocation) DownloadInvocationArtifact(string, string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("data")), nil
+}
+
+func invoke(t *testing.T, payload string) (*api.Client, string) {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+
+	client := api.NewClient(ts.URL, "token", nil)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-app", Name: "my-app", ImageURL: "img:1"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Invoke("my-app", "generate", []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.InvocationID == "" {
+		t.Fatal("expected an invocation ID")
+	}
+	return client, resp.InvocationID
+}
+
+func TestListArtifacts(t *testing.T) {
+	client, id := invoke(t, `{"prompt":"a cat","num_images":2}`)
+
+	var out bytes.Buffer
+	if err := list(&out, client, ListOptions{InvocationID: id}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"generate on my-app, succeeded", "output.json", "image-0.png", "image-1.png", "image/png"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := list(&out, client, ListOptions{InvocationID: id, Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var invocation api.Invocation
+	if err := json.Unmarshal(out.Bytes(), &invocation); err != nil {
+		t.Fatal(err)
+	}
+	if len(invocation.Artifacts) != 3 || invocation.Artifacts[1].Path != "outputs/"+id+"/image-0.png" {
+		t.Errorf("unexpected artifacts %+v", invocation.Artifacts)
+	}
+
+	if err := list(&out, client, ListOptions{InvocationID: "inv-missing"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestDownloadArtifacts(t *testing.T) {
+	client, id := invoke(t, `{"num_images":1}`)
+
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := download(io.Discard, client, DownloadOptions{InvocationID: id, OutDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "image-0.png"))
+	if err != nil || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Errorf("image not downloaded: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expected only the 2 artifacts in %s, got %d entries", dir, len(entries))
+	}
+
+	err = download(io.Discard, client, DownloadOptions{InvocationID: id, OutDir: dir, Names: []string{"video.mp4"}})
+	if err == nil || !strings.Contains(err.Error(), `no artifact "video.mp4"`) {
+		t.Errorf("expected unknown artifact error, got %v", err)
+	}
+}
+
+func TestDownloadRejectsUnsafeNames(t *testing.T) {
+	client := &fakeInvocation{invocation: api.Invocation{ID: "inv-1", Status: "succeeded", Artifacts: []api.InvocationArtifact{{Name: "../escape.png"}}}}
+
+	dir := t.TempDir()
+	err := download(io.Discard, client, DownloadOptions{InvocationID: "inv-1", OutDir: dir})
+	if err == nil || !strings.Contains(err.Error(), "unsafe name") {
+		t.Errorf("expected unsafe name error, got %v", err)
+	}
+
+	client.invocation = api.Invocation{ID: "inv-2", Status: "running"}
+	err = download(io.Discard, client, DownloadOptions{InvocationID: "inv-2", OutDir: dir})
+	if err == nil || !strings.Contains(err.Error(), "still running") {
+		t.Errorf("expected a still running error, got %v", err)
+	}
+}
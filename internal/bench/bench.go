@@ -0,0 +1,230 @@
+// Package bench load-tests a deployment function by sending concurrent
+// invocations with a fixed payload and summarizing how they went.
+package bench
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Defaults for the number of invocations and how many run at once.
+const (
+	DefaultRequests    = 100
+	DefaultConcurrency = 10
+)
+
+// Options contains the options for a benchmark.
+type Options struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Function     string
+	Payload      []byte
+	Requests     int // Total invocations
+	Concurrency  int // Invocations in flight at once
+	Output       ui.Output
+}
+
+// Report summarizes a benchmark.
+type Report struct {
+	DeploymentID string         `json:"deployment_id"`
+	Function     string         `json:"function"`
+	Requests     int            `json:"requests"`
+	Concurrency  int            `json:"concurrency"`
+	Succeeded    int            `json:"succeeded"`
+	Failed       int            `json:"failed"`
+	ErrorRate    float64        `json:"error_rate"`
+	ColdStarts   int            `json:"cold_starts"`
+	ElapsedMS    int64          `json:"elapsed_ms"`
+	Throughput   float64        `json:"throughput"` // Successful invocations per second
+	Latency      Latency        `json:"latency"`    // Of successful invocations
+	Errors       map[string]int `json:"errors,omitempty"`
+}
+
+// Latency is a latency distribution in milliseconds.
+type Latency struct {
+	Min  int64 `json:"min_ms"`
+	Mean int64 `json:"mean_ms"`
+	P50  int64 `json:"p50_ms"`
+	P90  int64 `json:"p90_ms"`
+	P95  int64 `json:"p95_ms"`
+	P99  int64 `json:"p99_ms"`
+	Max  int64 `json:"max_ms"`
+}
+
+// sample is the outcome of one invocation.
+type sample struct {
+	duration  time.Duration
+	status    int
+	coldStart bool
+	err       error
+}
+
+// Run benchmarks a function and prints the report.
+func Run(opts Options) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return run(os.Stdout, client, opts)
+}
+
+func run(w io.Writer, client api.OrchestratorAPI, opts Options) error {
+	if opts.Requests < 1 {
+		return fmt.Errorf("--requests must be at least 1")
+	}
+	if opts.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	concurrency := min(opts.Concurrency, opts.Requests)
+
+	if !opts.Output.Structured() {
+		fmt.Fprintf(w, "Sending %d invocations of %s on %s, %d at a time...\n", opts.Requests, opts.Function, opts.DeploymentID, concurrency)
+	}
+
+	samples := make([]sample, opts.Requests)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				began := time.Now()
+				resp, err := client.Invoke(opts.DeploymentID, opts.Function, opts.Payload)
+				s := sample{duration: time.Since(began), err: err}
+				if resp != nil {
+					s.status = resp.StatusCode
+					s.coldStart = resp.ColdStart
+				}
+				samples[i] = s
+			}
+		}()
+	}
+	for i := range opts.Requests {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	report := summarize(samples, time.Since(start))
+	report.DeploymentID = opts.DeploymentID
+	report.Function = opts.Function
+	report.Concurrency = concurrency
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, report)
+	}
+	writeReport(w, report)
+	return nil
+}
+
+// summarize builds the report of a benchmark that took elapsed.
+func summarize(samples []sample, elapsed time.Duration) Report {
+	report := Report{Requests: len(samples), ElapsedMS: elapsed.Milliseconds(), Errors: map[string]int{}}
+
+	var latencies []time.Duration
+	for _, s := range samples {
+		if s.coldStart {
+			report.ColdStarts++
+		}
+		if s.err != nil {
+			report.Failed++
+			reason := "no response"
+			if s.status != 0 {
+				reason = strconv.Itoa(s.status)
+			}
+			report.Errors[reason]++
+			continue
+		}
+		report.Succeeded++
+		latencies = append(latencies, s.duration)
+	}
+
+	report.ErrorRate = float64(report.Failed) / float64(len(samples))
+	if elapsed > 0 {
+		report.Throughput = float64(report.Succeeded) / elapsed.Seconds()
+	}
+
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		var total time.Duration
+		for _, d := range latencies {
+			total += d
+		}
+		report.Latency = Latency{
+			Min:  latencies[0].Milliseconds(),
+			Mean: (total / time.Duration(len(latencies))).Milliseconds(),
+			P50:  percentile(latencies, 50).Milliseconds(),
+			P90:  percentile(latencies, 90).Milliseconds(),
+			P95:  percentile(latencies, 95).Milliseconds(),
+			P99:  percentile(latencies, 99).Milliseconds(),
+			Max:  latencies[len(latencies)-1].Milliseconds(),
+		}
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+func writeReport(w io.Writer, r Report) {
+	ms := func(v int64) string { return (time.Duration(v) * time.Millisecond).String() }
+
+	fmt.Fprintf(w, "\nRequests:    %d in %s (%d succeeded, %d failed, %.1f%% errors)\n",
+		r.Requests, ms(r.ElapsedMS), r.Succeeded, r.Failed, r.ErrorRate*100)
+	fmt.Fprintf(w, "Throughput:  %.2f invocations/s\n", r.Throughput)
+	fmt.Fprintf(w, "Cold starts: %d\n", r.ColdStarts)
+
+	if r.Succeeded > 0 {
+		fmt.Fprintln(w, "\nLatency:")
+		table := &ui.Table{Columns: []string{"MIN", "MEAN", "P50", "P90", "P95", "P99", "MAX"}}
+		l := r.Latency
+		table.Rows = append(table.Rows, ui.Row{Cells: []string{
+			ms(l.Min), ms(l.Mean), ms(l.P50), ms(l.P90), ms(l.P95), ms(l.P99), ms(l.Max),
+		}})
+		table.Write(w)
+	}
+
+	if len(r.Errors) > 0 {
+		fmt.Fprintln(w, "\nErrors:")
+		reasons := make([]string, 0, len(r.Errors))
+		for reason := range r.Errors {
+			reasons = append(reasons, reason)
+		}
+		slices.Sort(reasons)
+		for _, reason := range reasons {
+			fmt.Fprintf(w, "  %-12s %d\n", reason, r.Errors[reason])
+		}
+	}
+
+	if r.ColdStarts > 0 {
+		fmt.Fprintf(w, "\n%d %s waited for a worker to start; raise the minimum with 'cozyctl update --min-workers' to keep workers warm.\n",
+			r.ColdStarts, plural(r.ColdStarts, "invocation", "invocations"))
+	}
+	if r.Errors["429"] > 0 || r.Errors["503"] > 0 {
+		fmt.Fprintln(w, "Requests were refused for lack of capacity; raise the maximum with 'cozyctl update --max-workers' or lower the concurrency.")
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,61 @@
+package bench
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+var errFake = errors.New("failed")
+
+func TestSummarize(t *testing.T) {
+	var samples []sample
+	for i := 1; i <= 8; i++ {
+		samples = append(samples, sample{duration: time.Duration(i*10) * time.Millisecond, status: 200})
+	}
+	samples[0].coldStart = true
+	samples = append(samples,
+		sample{status: 503, err: errFake},
+		sample{err: errFake},
+	)
+
+	r := summarize(samples, 2*time.Second)
+	if r.Succeeded != 8 || r.Failed != 2 || r.ColdStarts != 1 {
+		t.Errorf("unexpected counts %+v", r)
+	}
+	if r.ErrorRate != 0.2 || r.Throughput != 4 {
+		t.Errorf("unexpected rates %+v", r)
+	}
+	want := Latency{Min: 10, Mean: 45, P50: 40, P90: 80, P95: 80, P99: 80, Max: 80}
+	if r.Latency != want {
+		t.Errorf("latency = %+v, want %+v", r.Latency, want)
+	}
+	if r.Errors["503"] != 1 || r.Errors["no response"] != 1 {
+		t.Errorf("unexpected errors %v", r.Errors)
+	}
+}
+
+func TestBenchAgainstMockServer(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewClient(ts.URL, "token", nil)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-app", Name: "my-app", ImageURL: "img:1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run(&out, client, Options{DeploymentID: "my-app", Function: "generate", Requests: 20, Concurrency: 4}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"20 in", "20 succeeded, 0 failed", "Cold starts: 1", "P99", "cozyctl update --min-workers"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
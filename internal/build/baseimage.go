@@ -76,6 +76,18 @@ func ImageDescription(cfg *ToolsCozyConfig) string {
 	}
 }
 
+// ImagePython returns the Python version of the plain Python base image a
+// project builds on, or "" for the PyTorch images, which bring their own.
+func ImagePython(cfg *ToolsCozyConfig) string {
+	if cfg.Pytorch != "" || cfg.Cuda != "" {
+		return ""
+	}
+	if py := normalizePython(cfg.Python); py != "" {
+		return py
+	}
+	return DefaultPython
+}
+
 func normalizePython(v string) string {
 	v = strings.TrimSpace(v)
 	v = strings.TrimPrefix(v, "python")
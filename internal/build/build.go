@@ -2,14 +2,22 @@ package build
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cozy-creator/cozyctl/internal/api"
 	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+	"github.com/cozy-creator/cozyctl/internal/ui"
 	"github.com/google/uuid"
 )
 
@@ -17,7 +25,7 @@ const (
 	PyProjectTomlPath = "pyproject.toml"
 )
 
-func BuildProjectLocally(directoryPath string) error {
+func BuildProjectLocally(ctx context.Context, directoryPath string, progressMode ui.Mode) error {
 
 	// First sanitize the directoryPath and find the directory.
 	directoryPath, err := filepath.Abs(directoryPath)
@@ -45,57 +53,117 @@ func BuildProjectLocally(directoryPath string) error {
 		return err
 	}
 
+	progress := ui.NewWithMode(os.Stdout, progressMode)
+	defer progress.Close()
+
+	stage := progress.Start("Building")
+	result, err := BuildLocalImage(ctx, progress, directoryPath, toolsCozyConfig, nil)
+	if err != nil {
+		return stage.Fail(err)
+	}
+	stage.Done()
+	progress.SetID("image_tag", result.ImageTag)
+
+	progress.Printf("Build completed successfully in %v\n", result.Duration)
+	progress.Printf("Image tag: %s\n", result.ImageTag)
+	progress.Println(progress.Summary())
+
+	return nil
+}
+
+// BuildLocalImage generates the Dockerfile for a project and builds its image
+// with the local Docker daemon. Progress and build logs are written to out.
+// The image is labeled with the functions it serves; nil resolves them from
+// the project. Cancelling ctx stops the docker build and removes the
+// generated Dockerfile.
+func BuildLocalImage(ctx context.Context, out io.Writer, directoryPath string, toolsCozyConfig *ToolsCozyConfig, functions []DetectedFunction) (result *BuildResult, err error) {
 	// Resolve the appropriate base image
 	baseImage, err := ResolveBaseImage(toolsCozyConfig)
 	if err != nil {
-		return fmt.Errorf("failed to resolve base image: %w", err)
+		return nil, fmt.Errorf("failed to resolve base image: %w", err)
+	}
+	fmt.Fprintf(out, "Using base image: %s\n", baseImage)
+
+	if functions == nil {
+		functions, _, _ = ResolveFunctions(directoryPath, toolsCozyConfig, "")
 	}
-	fmt.Printf("Using base image: %s\n", baseImage)
+
+	// Generate unique build ID and image tag
+	buildID := uuid.New().String()
+	imageTag := GenerateImageTag(buildID, toolsCozyConfig.DeploymentID)
 
 	// Generate Dockerfile from template
-	dockerfile, err := GenerateDockerfile(baseImage, toolsCozyConfig)
+	dockerfile, err := GenerateDockerfile(baseImage, toolsCozyConfig, NewImageMetadata(directoryPath, buildID, functions))
 	if err != nil {
-		return fmt.Errorf("failed to generate Dockerfile: %w", err)
+		return nil, fmt.Errorf("failed to generate Dockerfile: %w", err)
 	}
 
 	// Write Dockerfile to the project directory
 	dockerfilePath := filepath.Join(directoryPath, "Dockerfile")
 	if err := os.WriteFile(dockerfilePath, []byte(dockerfile), 0644); err != nil {
-		return fmt.Errorf("failed to write Dockerfile: %w", err)
+		return nil, fmt.Errorf("failed to write Dockerfile: %w", err)
 	}
-	fmt.Printf("Generated Dockerfile at: %s\n", dockerfilePath)
+	fmt.Fprintf(out, "Generated Dockerfile at: %s\n", dockerfilePath)
+	defer RemoveIfInterrupted(ctx, dockerfilePath)
 
-	// Generate unique build ID and image tag
-	buildID := uuid.New().String()
-	imageTag := GenerateImageTag(buildID, toolsCozyConfig.DeploymentID)
-	fmt.Printf("Building image: %s\n", imageTag)
+	fmt.Fprintf(out, "Building image: %s\n", imageTag)
 
 	// Build the Docker image
 	builder := NewDockerBuilder()
-	ctx := context.Background()
 	buildTimeout := 30 * time.Minute
 
-	fmt.Println("Starting Docker build...")
-	result := builder.Build(ctx, directoryPath, imageTag, buildTimeout)
+	fmt.Fprintln(out, "Starting Docker build...")
+	result = builder.Build(ctx, directoryPath, imageTag, buildTimeout)
 
 	// Print build logs
 	if result.Logs != "" {
-		fmt.Println("\n--- Build Logs ---")
-		fmt.Println(result.Logs)
-		fmt.Println("--- End Build Logs ---")
+		fmt.Fprintln(out, "\n--- Build Logs ---")
+		fmt.Fprintln(out, result.Logs)
+		fmt.Fprintln(out, "--- End Build Logs ---")
 	}
 
 	if result.Error != nil {
-		return fmt.Errorf("docker build failed: %w", result.Error)
+		return nil, interrupt.Check(ctx, fmt.Errorf("docker build failed: %w", result.Error), "the docker build was stopped; nothing was tagged")
 	}
 
-	fmt.Printf("Build completed successfully in %v\n", result.Duration)
-	fmt.Printf("Image tag: %s\n", result.ImageTag)
+	if err := RecordLastLocalBuild(directoryPath, result.ImageTag); err != nil {
+		fmt.Fprintf(out, "Warning: failed to record local build: %v\n", err)
+	}
 
-	return nil
+	return result, nil
+}
+
+// RemoveIfInterrupted removes a file generated for a command, such as a
+// Dockerfile, when the command was interrupted before finishing with it.
+func RemoveIfInterrupted(ctx context.Context, path string) {
+	if ctx.Err() != nil {
+		os.Remove(path)
+	}
 }
 
-func BuildProjectOnServer(projectDir string) error {
+// lastLocalBuildPath is where the most recent local image tag is recorded, relative to the project.
+var lastLocalBuildPath = filepath.Join(".cozy", "last-local-build")
+
+// RecordLastLocalBuild records the image tag of the most recent local build of a project.
+func RecordLastLocalBuild(directoryPath, imageTag string) error {
+	path := filepath.Join(directoryPath, lastLocalBuildPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(imageTag+"\n"), 0644)
+}
+
+// LastLocalBuild returns the image tag of the most recent local build of a project,
+// or "" if the project has not been built locally.
+func LastLocalBuild(directoryPath string) string {
+	data, err := os.ReadFile(filepath.Join(directoryPath, lastLocalBuildPath))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func BuildProjectOnServer(ctx context.Context, projectDir string, profile config.ProfileRef, transport http.RoundTripper, progressMode ui.Mode, opts api.BuildOptions) error {
 	// Validate directory
 	projectDir, err := filepath.Abs(projectDir)
 	if err != nil {
@@ -117,14 +185,9 @@ func BuildProjectOnServer(projectDir string) error {
 	}
 
 	// Load config for builder URL and token
-	defaultCfg, err := config.GetDefaultConfig()
+	profileCfg, err := config.LoadProfileConfig(profile)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
-	profileCfg, err := config.GetProfileConfig(defaultCfg.CurrentName, defaultCfg.CurrentProfile)
-	if err != nil {
-		return fmt.Errorf("failed to load profile config: %w", err)
+		return err
 	}
 
 	if profileCfg.Config == nil {
@@ -140,77 +203,296 @@ func BuildProjectOnServer(projectDir string) error {
 		builderURL = config.DefaultConfigData().BuilderURL
 	}
 
-	// Create tarball
-	fmt.Println("Creating tarball...")
-	tarball, err := CreateTarball(projectDir)
-	if err != nil {
-		return fmt.Errorf("failed to create tarball: %w", err)
-	}
-	fmt.Printf("Tarball size: %d bytes\n", tarball.Len())
-
 	// Use directory name as build name
 	buildName := filepath.Base(projectDir)
 
 	// Upload to cozy-hub builder
-	client := api.NewBuilderClient(builderURL, profileCfg.Config.Token)
+	client := api.NewBuilderClient(builderURL, profileCfg.Config.Token, transport)
+
+	progress := ui.NewWithMode(os.Stdout, progressMode)
+	defer progress.Close()
 
-	fmt.Printf("Uploading to cozy-hub at %s...\n", builderURL)
-	buildResp, err := client.UploadBuild(tarball, buildName)
+	progress.Printf("Uploading to cozy-hub at %s...\n", builderURL)
+	recorder := history.Start(profile, "build")
+	_, err = SubmitBuild(ctx, progress, client, projectDir, buildName, opts)
+	recorder.FinishProgress(progress, err)
+	return err
+}
+
+// SubmitBuild uploads a project to the builder, waits for the build to
+// finish, and returns its ID. While the build is queued, its position in the
+// builder's queue and estimated start are reported as they change.
+//
+// Cancelling ctx aborts the upload, or stops waiting for a submitted build,
+// which keeps running on the builder; the returned *interrupt.Error says how
+// to follow it.
+func SubmitBuild(ctx context.Context, progress *ui.Progress, client api.BuilderAPI, projectDir, buildName string, opts api.BuildOptions) (string, error) {
+	startedOn := time.Now()
+	opts, err := projectBuildOptions(projectDir, opts)
 	if err != nil {
-		return fmt.Errorf("failed to upload build: %w", err)
+		return "", err
 	}
 
-	fmt.Printf("Build submitted: ID=%s, Status=%s\n", buildResp.BuildID, buildResp.Status)
+	sourceDigest, err := SourceDigest(projectDir)
+	if err != nil {
+		progress.Warnf("%v; provenance will not include a source digest", err)
+	}
+
+	// Package and upload concurrently: the tarball is compressed while it streams
+	stage := progress.Start("Packaging & uploading")
+	tarball := &countingReader{h: sha256.New(), r: StreamTarballWithProgress(projectDir, func(written, total int64) {
+		if total > 0 {
+			stage.SetPercent(int(written * 100 / total))
+		}
+	})}
+	defer tarball.Close()
+	return submit(ctx, progress, client, stage, tarball, projectDir, buildName, opts, sourceDigest, startedOn)
+}
+
+// PackageArchive writes the project's source archive to path, to be
+// uploaded later with SubmitArchive, and returns the project's build
+// settings and source digest as they are now.
+func PackageArchive(projectDir, path string, opts api.BuildOptions) (api.BuildOptions, string, error) {
+	opts, err := projectBuildOptions(projectDir, opts)
+	if err != nil {
+		return opts, "", err
+	}
+	sourceDigest, err := SourceDigest(projectDir)
+	if err != nil {
+		return opts, "", err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return opts, "", fmt.Errorf("failed to create archive: %w", err)
+	}
+	if err := WriteTarball(projectDir, f); err != nil {
+		f.Close()
+		return opts, "", fmt.Errorf("failed to package %s: %w", projectDir, err)
+	}
+	if err := f.Close(); err != nil {
+		return opts, "", fmt.Errorf("failed to write archive: %w", err)
+	}
+	return opts, sourceDigest, nil
+}
+
+// SubmitArchive uploads an archive written by PackageArchive and waits for
+// its build like SubmitBuild. opts and sourceDigest are those PackageArchive
+// returned; projectDir is only read for provenance, and may be gone.
+func SubmitArchive(ctx context.Context, progress *ui.Progress, client api.BuilderAPI, archivePath, projectDir, buildName string, opts api.BuildOptions, sourceDigest string) (string, error) {
+	startedOn := time.Now()
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	stage := progress.Start("Uploading")
+	tarball := &countingReader{h: sha256.New(), r: f, size: info.Size()}
+	defer tarball.Close()
+	return submit(ctx, progress, client, stage, tarball, projectDir, buildName, opts, sourceDigest, startedOn)
+}
+
+// submit uploads tarball as the build's source, under the given stage, and
+// waits for the build to finish.
+func submit(ctx context.Context, progress *ui.Progress, client api.BuilderAPI, stage *ui.Stage, tarball *countingReader, projectDir, buildName string, opts api.BuildOptions, sourceDigest string, startedOn time.Time) (string, error) {
+	if opts.Machine != "" {
+		progress.Printf("Builder machine: %s\n", opts.Machine)
+	}
+
+	// Closing the stream fails the upload's request body
+	stopUpload := context.AfterFunc(ctx, func() { tarball.Close() })
+
+	tarballPath, err := uploadTarball(ctx, client, tarball, buildName, TarballPartSize, func(sent int64) {
+		stage.SetBytes(sent)
+		if tarball.size > 0 {
+			stage.SetPercent(int(sent * 100 / tarball.size))
+		}
+	})
+	stopUpload()
+	if err != nil {
+		err = interrupt.Check(ctx, fmt.Errorf("failed to upload build: %w", err), "the upload was stopped; no build was submitted")
+		return "", stage.Fail(err)
+	}
+	buildResp, err := client.CreateBuild(tarballPath, opts)
+	if err != nil {
+		return "", stage.Fail(fmt.Errorf("failed to create build: %w", err))
+	}
+	progress.Printf("Tarball size: %d bytes\n", tarball.n)
+	progress.Printf("Build submitted: ID=%s, Status=%s\n", buildResp.BuildID, buildResp.Status)
+	progress.SetID("build_id", buildResp.BuildID)
+	stage.Done()
 
 	// Poll for completion
-	fmt.Println("\nWaiting for build to complete...")
+	stage = progress.Start("Building")
 	pollInterval := 5 * time.Second
 	pollTimeout := 4 * time.Hour
 	deadline := time.Now().Add(pollTimeout)
 	lastStatus := ""
+	lastPosition := 0
 
 	for time.Now().Before(deadline) {
 		status, err := client.GetBuildStatus(buildResp.BuildID)
 		if err != nil {
-			fmt.Printf("  Warning: failed to get status: %v\n", err)
-			time.Sleep(pollInterval)
+			progress.Printf("  Warning: failed to get status: %v\n", err)
+			if err := interrupt.Sleep(ctx, pollInterval); err != nil {
+				return buildResp.BuildID, stage.Fail(stillBuilding(buildResp.BuildID, err))
+			}
 			continue
 		}
 
 		if status.Status != lastStatus {
-			fmt.Printf("  Status: %s\n", status.Status)
+			progress.Printf("  Status: %s\n", status.Status)
 			lastStatus = status.Status
 		}
+		if status.QueuePosition > 0 && status.QueuePosition != lastPosition {
+			progress.Printf("  %s\n", QueueMessage(status, time.Now()))
+		}
+		lastPosition = status.QueuePosition
 
 		switch status.Status {
 		case "success", "succeeded":
-			fmt.Printf("\nBuild completed successfully!\n")
-			fmt.Printf("  Build ID:  %s\n", status.ID)
-			fmt.Printf("  Image Tag: %s\n", status.ImageTag)
+			stage.Done()
+			progress.SetID("image_tag", status.ImageTag)
+			progress.Printf("\nBuild completed successfully!\n")
+			progress.Printf("  Build ID:  %s\n", status.ID)
+			progress.Printf("  Image Tag: %s\n", status.ImageTag)
 			if status.LogsPath != "" {
-				fmt.Printf("  Logs:      %s\n", status.LogsPath)
+				progress.Printf("  Logs:      %s\n", status.LogsPath)
 			}
-			return nil
+			recordProvenance(progress, client, projectDir, ProvenanceInput{
+				BuildID:       buildResp.BuildID,
+				BuildName:     buildName,
+				ImageTag:      status.ImageTag,
+				ImageDigest:   status.ImageDigest,
+				BuilderID:     status.BuilderID,
+				SourceDigest:  sourceDigest,
+				ArchiveDigest: fmt.Sprintf("sha256:%x", tarball.h.Sum(nil)),
+				BaseDigest:    status.BaseDigest,
+				StartedOn:     startedOn,
+				FinishedOn:    time.Now(),
+			})
+			progress.Println(progress.Summary())
+			return buildResp.BuildID, nil
 
 		case "failed":
 			errMsg := status.Error
 			if errMsg == "" {
 				errMsg = "unknown error"
 			}
-			return fmt.Errorf("build failed: %s", errMsg)
+			return buildResp.BuildID, stage.Fail(fmt.Errorf("build failed: %s", errMsg))
 
 		case "canceled":
-			return fmt.Errorf("build was canceled")
+			return buildResp.BuildID, stage.Fail(fmt.Errorf("build was canceled"))
 
 		case "pending", "queued", "running":
-			time.Sleep(pollInterval)
-			continue
+			// Still waiting
 
 		default:
-			fmt.Printf("  Unknown status: %s\n", status.Status)
-			time.Sleep(pollInterval)
+			progress.Printf("  Unknown status: %s\n", status.Status)
+		}
+
+		if err := interrupt.Sleep(ctx, pollInterval); err != nil {
+			return buildResp.BuildID, stage.Fail(stillBuilding(buildResp.BuildID, err))
+		}
+	}
+
+	return buildResp.BuildID, stage.Fail(fmt.Errorf("build timed out after %v (build ID: %s)", pollTimeout, buildResp.BuildID))
+}
+
+// stillBuilding reports that cozyctl stopped waiting for a server build that
+// carries on without it.
+func stillBuilding(buildID string, err error) error {
+	return &interrupt.Error{
+		Hint: fmt.Sprintf("build %s is still running server-side; follow it with `cozyctl builds logs -f %s` or stop it with `cozyctl builds cancel %s`", buildID, buildID, buildID),
+		Err:  err,
+	}
+}
+
+// projectBuildOptions fills in build settings from the project's
+// pyproject.toml that weren't given explicitly.
+func projectBuildOptions(projectDir string, opts api.BuildOptions) (api.BuildOptions, error) {
+	if opts.Machine != "" {
+		return opts, nil
+	}
+	pyprojectPath := filepath.Join(projectDir, PyProjectTomlPath)
+	if _, err := os.Stat(pyprojectPath); err != nil {
+		return opts, nil
+	}
+	cozyConfig, err := GetToolsCozyConfig(pyprojectPath)
+	if err != nil {
+		return opts, err
+	}
+	if opts.Machine, err = api.ParseBuildMachine(cozyConfig.BuildMachine); err != nil {
+		return opts, fmt.Errorf("%s: build-machine: %w", PyProjectTomlPath, err)
+	}
+	return opts, nil
+}
+
+// QueueMessage describes a queued build's place in the builder's queue,
+// e.g. "Queue position: 3 (priority high, estimated start in 4m)".
+func QueueMessage(status *api.BuildStatusResponse, now time.Time) string {
+	msg := fmt.Sprintf("Queue position: %d", status.QueuePosition)
+
+	var details []string
+	if status.Priority != "" {
+		details = append(details, "priority "+status.Priority)
+	}
+	if status.EstimatedStartAt != nil {
+		if start, err := time.Parse(time.RFC3339, *status.EstimatedStartAt); err == nil {
+			switch wait := start.Sub(now); {
+			case wait <= 0:
+				details = append(details, "starting soon")
+			case wait < time.Minute:
+				details = append(details, fmt.Sprintf("estimated start in %v", wait.Round(time.Second)))
+			default:
+				details = append(details, fmt.Sprintf("estimated start in %dm", int(wait.Round(time.Minute).Minutes())))
+			}
 		}
 	}
+	if len(details) > 0 {
+		msg += " (" + strings.Join(details, ", ") + ")"
+	}
+	return msg
+}
+
+// recordProvenance uploads the provenance statement of a finished build.
+// Provenance is best effort: failures are reported but don't fail the build.
+func recordProvenance(progress *ui.Progress, client api.BuilderAPI, projectDir string, in ProvenanceInput) {
+	if in.BuilderID == "" {
+		in.BuilderID = "cozy-hub"
+	}
+	if cfg, err := GetToolsCozyConfig(filepath.Join(projectDir, PyProjectTomlPath)); err == nil {
+		in.Config = cfg
+		in.BaseImage, _ = ResolveBaseImage(cfg)
+	}
+
+	if err := client.PutBuildProvenance(in.BuildID, NewProvenance(in)); err != nil {
+		progress.Warnf("failed to record provenance: %v", err)
+		return
+	}
+	progress.Printf("  Provenance: recorded (cozyctl builds provenance %s)\n", in.BuildID)
+}
+
+// countingReader counts and hashes the bytes read through it.
+type countingReader struct {
+	r    io.ReadCloser
+	n    int64
+	h    hash.Hash
+	size int64 // Total size, if known in advance
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	c.h.Write(p[:n])
+	return n, err
+}
 
-	return fmt.Errorf("build timed out after %v (build ID: %s)", pollTimeout, buildResp.BuildID)
+func (c *countingReader) Close() error {
+	return c.r.Close()
 }
@@ -0,0 +1,120 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestQueueMessage(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	at := func(d time.Duration) *string {
+		s := now.Add(d).Format(time.RFC3339)
+		return &s
+	}
+
+	tests := []struct {
+		name   string
+		status api.BuildStatusResponse
+		want   string
+	}{
+		{
+			name:   "position only",
+			status: api.BuildStatusResponse{QueuePosition: 3},
+			want:   "Queue position: 3",
+		},
+		{
+			name:   "minutes away",
+			status: api.BuildStatusResponse{QueuePosition: 2, Priority: "high", EstimatedStartAt: at(4*time.Minute + 20*time.Second)},
+			want:   "Queue position: 2 (priority high, estimated start in 4m)",
+		},
+		{
+			name:   "seconds away",
+			status: api.BuildStatusResponse{QueuePosition: 1, Priority: "low", EstimatedStartAt: at(45 * time.Second)},
+			want:   "Queue position: 1 (priority low, estimated start in 45s)",
+		},
+		{
+			name:   "overdue",
+			status: api.BuildStatusResponse{QueuePosition: 1, EstimatedStartAt: at(-time.Minute)},
+			want:   "Queue position: 1 (starting soon)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QueueMessage(&tt.status, now); got != tt.want {
+				t.Errorf("QueueMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectBuildOptions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, PyProjectTomlPath), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No pyproject.toml: left to the server
+	opts, err := projectBuildOptions(dir, api.BuildOptions{})
+	if err != nil || opts.Machine != "" {
+		t.Fatalf("projectBuildOptions() = %+v, %v", opts, err)
+	}
+
+	write("[tool.cozy]\nbuild-machine = \"Large\"\n")
+	if opts, err = projectBuildOptions(dir, api.BuildOptions{Priority: "high"}); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Machine != api.BuildMachineLarge || opts.Priority != "high" {
+		t.Errorf("pyproject machine: got %+v", opts)
+	}
+
+	// An explicit machine wins over pyproject.toml
+	if opts, _ = projectBuildOptions(dir, api.BuildOptions{Machine: api.BuildMachineGPU}); opts.Machine != api.BuildMachineGPU {
+		t.Errorf("explicit machine: got %+v", opts)
+	}
+
+	write("[tool.cozy]\nbuild-machine = \"huge\"\n")
+	if _, err := projectBuildOptions(dir, api.BuildOptions{}); err == nil || !strings.Contains(err.Error(), "build-machine") {
+		t.Errorf("expected an invalid build-machine error, got %v", err)
+	}
+}
+
+func TestSubmitBuildInterrupted(t *testing.T) {
+	srv := mockserver.New()
+	srv.BuildDuration = time.Hour
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.py"), []byte("print('hi')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	buildID, err := SubmitBuild(ctx, ui.New(&bytes.Buffer{}), client, dir, "demo", api.BuildOptions{})
+
+	var interrupted *interrupt.Error
+	if !errors.As(err, &interrupted) {
+		t.Fatalf("expected an interrupt error, got %v", err)
+	}
+	if buildID == "" || !strings.Contains(interrupted.Hint, "cozyctl builds logs -f "+buildID) {
+		t.Errorf("expected a hint to follow %q, got %q", buildID, interrupted.Hint)
+	}
+}
@@ -0,0 +1,66 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// deployedSourcesPath is where the source digest of the image last deployed
+// from a project is recorded, per deployment, relative to the project.
+var deployedSourcesPath = filepath.Join(".cozy", "deployed-sources.json")
+
+// DeployedSource is the source an image deployed from a project was built
+// from.
+type DeployedSource struct {
+	SourceDigest string `json:"source_digest"`
+	ImageURL     string `json:"image_url"`
+}
+
+// RecordDeployedSource records that deploymentID now runs imageURL, built
+// from the project at sourceDigest.
+func RecordDeployedSource(projectDir, deploymentID, imageURL, sourceDigest string) error {
+	sources := readDeployedSources(projectDir)
+	sources[deploymentID] = DeployedSource{SourceDigest: sourceDigest, ImageURL: imageURL}
+
+	data, err := json.MarshalIndent(sources, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(projectDir, deployedSourcesPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// SourceUnchanged reports whether deploymentID still runs imageURL as last
+// deployed from the project, and the project's source still has
+// sourceDigest, so rebuilding would produce the same image. A deployment
+// changed since, by another machine or a rollback, never matches.
+func SourceUnchanged(projectDir, deploymentID, imageURL, sourceDigest string) bool {
+	if sourceDigest == "" || imageURL == "" {
+		return false
+	}
+	last, ok := readDeployedSources(projectDir)[deploymentID]
+	return ok && last.ImageURL == imageURL && last.SourceDigest == sourceDigest
+}
+
+// readDeployedSources returns the recorded sources by deployment ID. A
+// missing or unreadable record is treated as empty, which only costs a
+// rebuild.
+func readDeployedSources(projectDir string) map[string]DeployedSource {
+	sources := map[string]DeployedSource{}
+	data, err := os.ReadFile(filepath.Join(projectDir, deployedSourcesPath))
+	if err != nil {
+		return sources
+	}
+	if err := json.Unmarshal(data, &sources); err != nil || sources == nil {
+		return map[string]DeployedSource{}
+	}
+	return sources
+}
@@ -0,0 +1,47 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	if SourceUnchanged(dir, "demo", "cozy/demo:1", "sha256:a") {
+		t.Error("a project without a record should not match")
+	}
+
+	if err := RecordDeployedSource(dir, "demo", "cozy/demo:1", "sha256:a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordDeployedSource(dir, "demo-staging", "cozy/demo:2", "sha256:b"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                           string
+		deploymentID, imageURL, digest string
+		want                           bool
+	}{
+		{"unchanged", "demo", "cozy/demo:1", "sha256:a", true},
+		{"other deployment", "demo-staging", "cozy/demo:2", "sha256:b", true},
+		{"source changed", "demo", "cozy/demo:1", "sha256:c", false},
+		{"deployment changed since", "demo", "cozy/demo:rolled-back", "sha256:a", false},
+		{"unknown deployment", "demo-prod", "cozy/demo:1", "sha256:a", false},
+		{"no digest", "demo", "cozy/demo:1", "", false},
+	}
+	for _, c := range cases {
+		if got := SourceUnchanged(dir, c.deploymentID, c.imageURL, c.digest); got != c.want {
+			t.Errorf("%s: SourceUnchanged = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// A corrupt record only costs a rebuild
+	if err := os.WriteFile(filepath.Join(dir, deployedSourcesPath), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if SourceUnchanged(dir, "demo", "cozy/demo:1", "sha256:a") {
+		t.Error("a corrupt record should not match")
+	}
+}
@@ -225,3 +225,90 @@ func (d *DockerBuilder) GetRegistryTag(localTag string) string {
 func (d *DockerBuilder) HasRegistryConfig() bool {
 	return d.registryPrefix != ""
 }
+
+// RunOptions configures a docker run invocation
+type RunOptions struct {
+	ImageTag string
+	Mounts   map[string]string // host path -> container path
+	Env      map[string]string
+	WorkDir  string
+	GPUs     string // Passed to --gpus (e.g. "all"); empty disables GPU access
+	Name     string // Container name (optional)
+	Command  []string
+	Stdout   io.Writer
+	Stderr   io.Writer
+	Timeout  time.Duration
+}
+
+// RunResult contains the result of a docker run
+type RunResult struct {
+	ExitCode int
+	Duration time.Duration
+	TimedOut bool
+	Error    error
+}
+
+// Run executes a container from an image and waits for it to exit.
+// A non-zero exit code is reported in ExitCode, not Error.
+func (d *DockerBuilder) Run(ctx context.Context, opts RunOptions) *RunResult {
+	result := &RunResult{}
+	start := time.Now()
+
+	runCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := []string{"run", "--rm"}
+	if opts.Name != "" {
+		args = append(args, "--name", opts.Name)
+	}
+	if opts.GPUs != "" {
+		args = append(args, "--gpus", opts.GPUs)
+	}
+	for host, container := range opts.Mounts {
+		args = append(args, "-v", host+":"+container)
+	}
+	for key, value := range opts.Env {
+		args = append(args, "-e", key+"="+value)
+	}
+	if opts.WorkDir != "" {
+		args = append(args, "-w", opts.WorkDir)
+	}
+	args = append(args, opts.ImageTag)
+	args = append(args, opts.Command...)
+
+	cmd := exec.CommandContext(runCtx, "docker", args...)
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+
+	if runCtx.Err() == context.DeadlineExceeded {
+		result.TimedOut = true
+		return result
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		result.ExitCode = exitErr.ExitCode()
+		return result
+	}
+	if err != nil {
+		result.Error = fmt.Errorf("docker run failed: %w", err)
+	}
+
+	return result
+}
+
+// RemoveContainer force-removes a container by name, ignoring missing containers
+func (d *DockerBuilder) RemoveContainer(ctx context.Context, name string) {
+	exec.CommandContext(ctx, "docker", "rm", "-f", name).Run()
+}
+
+// ImageExists reports whether an image tag is present in the local Docker daemon
+func (d *DockerBuilder) ImageExists(ctx context.Context, imageTag string) bool {
+	return exec.CommandContext(ctx, "docker", "image", "inspect", imageTag).Run() == nil
+}
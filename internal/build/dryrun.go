@@ -0,0 +1,79 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DryRunOutDir is where --dry-run writes its artifacts, relative to the project.
+// It lives under .cozy, so it is never packaged into the project archive.
+var DryRunOutDir = filepath.Join(".cozy", "out")
+
+// Dry-run artifact file names.
+const (
+	DryRunDockerfile    = "Dockerfile"
+	DryRunManifest      = "manifest.json"
+	DryRunCreateRequest = "create-deployment.json"
+	DryRunUpdateRequest = "update-deployment.json"
+)
+
+// dryRunManifest is the on-disk format of manifest.json.
+type dryRunManifest struct {
+	Files     []ManifestFile `json:"files"`
+	FileCount int            `json:"file_count"`
+	TotalSize int64          `json:"total_size"`
+}
+
+// WriteDryRunArtifacts writes what a deploy or update would do to the
+// project's DryRunOutDir: the generated Dockerfile, the deployment request
+// payload (as requestFile, e.g. "create-deployment.json"), and the manifest of
+// the project archive. Artifacts from a previous dry run are removed first.
+// It returns the paths written.
+func WriteDryRunArtifacts(projectDir, dockerfile, requestFile string, request any) ([]string, error) {
+	outDir := filepath.Join(projectDir, DryRunOutDir)
+	if err := os.RemoveAll(outDir); err != nil {
+		return nil, fmt.Errorf("failed to clear %s: %w", outDir, err)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", outDir, err)
+	}
+
+	files, err := ArchiveManifest(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	manifest := dryRunManifest{Files: files, FileCount: len(files)}
+	for _, f := range files {
+		manifest.TotalSize += f.Size
+	}
+
+	payload, err := json.MarshalIndent(request, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", requestFile, err)
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", DryRunManifest, err)
+	}
+
+	artifacts := []struct {
+		name string
+		data []byte
+	}{
+		{DryRunDockerfile, []byte(dockerfile)},
+		{requestFile, append(payload, '\n')},
+		{DryRunManifest, append(manifestJSON, '\n')},
+	}
+
+	var written []string
+	for _, a := range artifacts {
+		path := filepath.Join(outDir, a.name)
+		if err := os.WriteFile(path, a.data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
@@ -0,0 +1,70 @@
+package build
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteDryRunArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"pyproject.toml":         "[project]\nname = \"x\"\n",
+		"app/main.py":            "print('hi')\n",
+		"app/main.pyc":           "compiled",
+		".env":                   "SECRET=1",
+		".cozy/last-local-build": "old",
+	} {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Leftovers from a previous dry run are cleared
+	stale := filepath.Join(dir, DryRunOutDir, DryRunCreateRequest)
+	os.MkdirAll(filepath.Dir(stale), 0755)
+	os.WriteFile(stale, []byte("{}"), 0644)
+
+	paths, err := WriteDryRunArtifacts(dir, "FROM scratch\n", DryRunUpdateRequest, map[string]string{"image_url": "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 artifacts, got %v", paths)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale artifact was not removed")
+	}
+
+	outDir := filepath.Join(dir, DryRunOutDir)
+	if data, _ := os.ReadFile(filepath.Join(outDir, DryRunDockerfile)); string(data) != "FROM scratch\n" {
+		t.Errorf("unexpected Dockerfile: %q", data)
+	}
+
+	var request map[string]string
+	data, _ := os.ReadFile(filepath.Join(outDir, DryRunUpdateRequest))
+	if err := json.Unmarshal(data, &request); err != nil || request["image_url"] != "img" {
+		t.Errorf("unexpected request payload %q: %v", data, err)
+	}
+
+	var manifest dryRunManifest
+	data, _ = os.ReadFile(filepath.Join(outDir, DryRunManifest))
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range manifest.Files {
+		got = append(got, f.Path)
+	}
+	if len(got) != 2 || got[0] != "app/main.py" || got[1] != "pyproject.toml" {
+		t.Errorf("unexpected manifest files: %v", got)
+	}
+	if manifest.FileCount != 2 || manifest.TotalSize != int64(len("print('hi')\n")+len("[project]\nname = \"x\"\n")) {
+		t.Errorf("unexpected manifest totals: %+v", manifest)
+	}
+}
@@ -0,0 +1,112 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// DefaultEntrypointCheckDuration is how long the entrypoint is left running during the check.
+const DefaultEntrypointCheckDuration = 10 * time.Second
+
+// entrypointFailureMarkers indicate the worker failed to start (import errors, missing deps).
+var entrypointFailureMarkers = []string{
+	"Traceback (most recent call last)",
+	"ModuleNotFoundError",
+	"ImportError",
+	"SyntaxError",
+}
+
+// CheckEntrypoint runs a built image locally to catch startup failures before it is pushed.
+// It starts the image's default command for the given duration and fails if it crashes with
+// a Python traceback, then runs gen_worker.discover to verify that every expected function
+// is registered in the image.
+func (d *DockerBuilder) CheckEntrypoint(ctx context.Context, out io.Writer, imageTag string, functions []DetectedFunction, duration time.Duration) error {
+	if duration <= 0 {
+		duration = DefaultEntrypointCheckDuration
+	}
+
+	// 1. Start the entrypoint and let it run briefly
+	shortTag := imageTag
+	if i := strings.LastIndex(shortTag, "-"); i >= 0 {
+		shortTag = shortTag[i+1:]
+	}
+	name := fmt.Sprintf("cozy-entrypoint-check-%s-%d", shortTag, time.Now().Unix())
+
+	var output bytes.Buffer
+	result := d.Run(ctx, RunOptions{
+		ImageTag: imageTag,
+		Name:     name,
+		Stdout:   &output,
+		Stderr:   &output,
+		Timeout:  duration,
+	})
+	// The docker client is killed on timeout, but the container keeps running
+	d.RemoveContainer(context.Background(), name)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	logs := output.String()
+	for _, marker := range entrypointFailureMarkers {
+		if strings.Contains(logs, marker) {
+			return fmt.Errorf("entrypoint failed to start (%s):\n%s", marker, tail(logs, 30))
+		}
+	}
+	if !result.TimedOut {
+		if result.ExitCode != 0 {
+			fmt.Fprintf(out, "  Warning: entrypoint exited with code %d after %v (no orchestrator available?)\n",
+				result.ExitCode, result.Duration.Round(time.Millisecond))
+		} else {
+			fmt.Fprintf(out, "  Warning: entrypoint exited after %v\n", result.Duration.Round(time.Millisecond))
+		}
+	}
+
+	// 2. Verify the functions are discoverable in the image
+	if len(functions) == 0 {
+		return nil
+	}
+
+	var manifest bytes.Buffer
+	result = d.Run(ctx, RunOptions{
+		ImageTag: imageTag,
+		Command:  []string{"python", "-m", "gen_worker.discover"},
+		Stdout:   &manifest,
+		Stderr:   &manifest,
+		Timeout:  2 * time.Minute,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.TimedOut {
+		return fmt.Errorf("function discovery timed out")
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("function discovery failed (exit code %d):\n%s", result.ExitCode, tail(manifest.String(), 30))
+	}
+
+	var missing []string
+	for _, fn := range functions {
+		if !strings.Contains(manifest.String(), `"`+fn.Name+`"`) {
+			missing = append(missing, fn.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("functions not registered by the worker: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// tail returns the last n lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
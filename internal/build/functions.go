@@ -1,10 +1,15 @@
 package build
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
 )
 
 // DetectedFunction represents a detected worker function from Python source.
@@ -239,3 +244,85 @@ func ParseFunctionsFromFlag(spec string) ([]DetectedFunction, error) {
 
 	return functions, nil
 }
+
+// Function sources, in the priority order used by ResolveFunctions.
+const (
+	FunctionSourceFlag       = "flag"
+	FunctionSourcePyproject  = "pyproject.toml"
+	FunctionSourceAutoDetect = "auto-detect"
+)
+
+// ResolveFunctions determines a project's worker functions.
+// Priority: the --functions flag spec, then [tool.cozy.functions] in pyproject.toml,
+// then auto-detection from @worker_function() decorators.
+// It returns the functions along with the source they came from.
+func ResolveFunctions(projectDir string, cozyConfig *ToolsCozyConfig, flagSpec string) ([]DetectedFunction, string, error) {
+	if flagSpec != "" {
+		functions, err := ParseFunctionsFromFlag(flagSpec)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse --functions: %w", err)
+		}
+		return functions, FunctionSourceFlag, nil
+	}
+
+	if len(cozyConfig.Functions) > 0 {
+		var functions []DetectedFunction
+		for name, cfg := range cozyConfig.Functions {
+			functions = append(functions, DetectedFunction{
+				Name:        name,
+				RequiresGPU: cfg.RequiresGPU,
+			})
+		}
+		sort.Slice(functions, func(i, j int) bool {
+			return functions[i].Name < functions[j].Name
+		})
+		return functions, FunctionSourcePyproject, nil
+	}
+
+	functions, err := DetectWorkerFunctions(projectDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to detect functions: %w", err)
+	}
+	return functions, FunctionSourceAutoDetect, nil
+}
+
+// PrintFunctions prints a function list with its GPU/CPU requirement.
+func PrintFunctions(out io.Writer, functions []DetectedFunction) {
+	for _, fn := range functions {
+		gpuStr := "CPU"
+		if fn.RequiresGPU {
+			gpuStr = "GPU"
+		}
+		fmt.Fprintf(out, "  - %s (%s)\n", fn.Name, gpuStr)
+	}
+}
+
+// PrintResolvedFunctions reports which functions were resolved and where they came from.
+func PrintResolvedFunctions(out io.Writer, functions []DetectedFunction, source string) {
+	switch source {
+	case FunctionSourceFlag:
+		fmt.Fprintf(out, "Using functions from flag: %d function(s)\n", len(functions))
+	case FunctionSourcePyproject:
+		fmt.Fprintf(out, "Using functions from pyproject.toml: %d function(s)\n", len(functions))
+		PrintFunctions(out, functions)
+	default:
+		if len(functions) == 0 {
+			fmt.Fprintln(out, "Warning: No @worker_function() decorated functions detected")
+			return
+		}
+		fmt.Fprintf(out, "Auto-detected %d function(s):\n", len(functions))
+		PrintFunctions(out, functions)
+	}
+}
+
+// FunctionRequirements converts detected functions to API function requirements.
+func FunctionRequirements(functions []DetectedFunction) []api.FunctionRequirement {
+	funcReqs := make([]api.FunctionRequirement, len(functions))
+	for i, fn := range functions {
+		funcReqs[i] = api.FunctionRequirement{
+			Name:        fn.Name,
+			RequiresGPU: fn.RequiresGPU,
+		}
+	}
+	return funcReqs
+}
@@ -0,0 +1,156 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime/debug"
+	"strings"
+)
+
+// OCI labels cozyctl bakes into the images it generates Dockerfiles for, so
+// an image can be traced back to its source from a registry UI, 'docker
+// inspect', or 'cozyctl images inspect'. The org.opencontainers keys are the
+// standard annotations; the rest have no standard key and use ai.cozy.
+const (
+	LabelBaseImage      = "org.opencontainers.image.base.name"
+	LabelRevision       = "org.opencontainers.image.revision" // Git commit of the project
+	LabelSource         = "org.opencontainers.image.source"   // Git remote of the project
+	LabelBuildID        = "ai.cozy.build-id"
+	LabelDeploymentID   = "ai.cozy.deployment-id"
+	LabelSourceDigest   = "ai.cozy.source-digest"
+	LabelFunctions      = "ai.cozy.functions" // Comma-separated function names
+	LabelCozyctlVersion = "ai.cozy.cozyctl-version"
+)
+
+// ImageMetadata is the build metadata recorded in an image's labels.
+type ImageMetadata struct {
+	BuildID        string   `json:"build_id,omitempty"`
+	DeploymentID   string   `json:"deployment_id,omitempty"`
+	Revision       string   `json:"revision,omitempty"`
+	Source         string   `json:"source,omitempty"`
+	SourceDigest   string   `json:"source_digest,omitempty"`
+	BaseImage      string   `json:"base_image,omitempty"`
+	Functions      []string `json:"functions,omitempty"`
+	CozyctlVersion string   `json:"cozyctl_version,omitempty"`
+}
+
+// NewImageMetadata collects the metadata of a project's build. The source
+// digest and git details are best effort: they are left out if the project
+// can't be hashed or isn't a git checkout.
+func NewImageMetadata(projectDir, buildID string, functions []DetectedFunction) ImageMetadata {
+	meta := ImageMetadata{BuildID: buildID, CozyctlVersion: CozyctlVersion()}
+	meta.SourceDigest, _ = SourceDigest(projectDir)
+	meta.Revision = git(projectDir, "rev-parse", "HEAD")
+	meta.Source = redactURL(git(projectDir, "remote", "get-url", "origin"))
+	for _, fn := range functions {
+		meta.Functions = append(meta.Functions, fn.Name)
+	}
+	return meta
+}
+
+// CozyctlVersion is the version of the running cozyctl: its module version,
+// or for a development build the commit it was built from.
+func CozyctlVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+			return "devel+" + setting.Value[:12]
+		}
+	}
+	return "devel"
+}
+
+// git runs a git command in dir and returns its trimmed output, or "" if it fails.
+func git(dir string, args ...string) string {
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// redactURL drops credentials from a remote URL so they aren't published in
+// image labels. scp-style remotes (git@host:org/repo) are returned as-is.
+func redactURL(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil || u.Scheme == "" {
+		return remote
+	}
+	u.User = nil
+	return u.String()
+}
+
+// Labels returns the metadata as image labels; empty fields are left out.
+func (m ImageMetadata) Labels() map[string]string {
+	labels := map[string]string{}
+	for key, value := range map[string]string{
+		LabelBaseImage:      m.BaseImage,
+		LabelRevision:       m.Revision,
+		LabelSource:         m.Source,
+		LabelBuildID:        m.BuildID,
+		LabelDeploymentID:   m.DeploymentID,
+		LabelSourceDigest:   m.SourceDigest,
+		LabelFunctions:      strings.Join(m.Functions, ","),
+		LabelCozyctlVersion: m.CozyctlVersion,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// ParseImageLabels reads the metadata recorded in an image's labels. ok is
+// false when the image has no cozy labels, e.g. because it wasn't built by
+// cozyctl; the standard OCI labels alone are set by many other tools.
+func ParseImageLabels(labels map[string]string) (meta ImageMetadata, ok bool) {
+	meta = ImageMetadata{
+		BaseImage:      labels[LabelBaseImage],
+		Revision:       labels[LabelRevision],
+		Source:         labels[LabelSource],
+		BuildID:        labels[LabelBuildID],
+		DeploymentID:   labels[LabelDeploymentID],
+		SourceDigest:   labels[LabelSourceDigest],
+		CozyctlVersion: labels[LabelCozyctlVersion],
+	}
+	if fns := labels[LabelFunctions]; fns != "" {
+		meta.Functions = strings.Split(fns, ",")
+	}
+	ok = meta.BuildID != "" || meta.DeploymentID != "" || meta.SourceDigest != "" || len(meta.Functions) > 0 || meta.CozyctlVersion != ""
+	return meta, ok
+}
+
+// ImageLabels returns the labels of an image in the local Docker daemon.
+func (d *DockerBuilder) ImageLabels(ctx context.Context, imageTag string) (map[string]string, error) {
+	output, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{json .Config.Labels}}", imageTag).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("docker image inspect failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("docker image inspect failed: %w", err)
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal(output, &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse image labels: %w", err)
+	}
+	return labels, nil
+}
+
+// Pull pulls an image into the local Docker daemon.
+func (d *DockerBuilder) Pull(ctx context.Context, imageTag string) error {
+	output, err := exec.CommandContext(ctx, "docker", "pull", imageTag).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker pull failed: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
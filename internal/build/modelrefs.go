@@ -0,0 +1,55 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DetectedModelRef is a ModelRef(...) annotation found in Python source.
+type DetectedModelRef struct {
+	// Ref is the annotation's string argument: a key of [tool.cozy.models]
+	// such as "sdxl-turbo", or a model reference such as "hf:org/repo"
+	Ref  string
+	File string // Relative to the project directory
+	Line int
+}
+
+// IsKey reports whether the annotation names a [tool.cozy.models] key
+// rather than a model directly.
+func (r DetectedModelRef) IsKey() bool {
+	return !strings.ContainsAny(r.Ref, ":/")
+}
+
+// modelRefPattern matches ModelRef("ref") and ModelRef(Source.X, "ref").
+var modelRefPattern = regexp.MustCompile(`ModelRef\(\s*(?:[\w.]+\s*,\s*)?["']([^"']+)["']`)
+
+// DetectModelRefs scans the Python files of a project for ModelRef annotations.
+func DetectModelRefs(projectDir string) ([]DetectedModelRef, error) {
+	pythonFiles, err := findPythonFiles(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []DetectedModelRef
+	for _, pyFile := range pythonFiles {
+		content, err := os.ReadFile(pyFile)
+		if err != nil {
+			// Skip files that can't be read
+			continue
+		}
+		rel, err := filepath.Rel(projectDir, pyFile)
+		if err != nil {
+			rel = pyFile
+		}
+		for _, m := range modelRefPattern.FindAllSubmatchIndex(content, -1) {
+			refs = append(refs, DetectedModelRef{
+				Ref:  string(content[m[2]:m[3]]),
+				File: filepath.ToSlash(rel),
+				Line: 1 + strings.Count(string(content[:m[0]]), "\n"),
+			})
+		}
+	}
+	return refs, nil
+}
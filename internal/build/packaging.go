@@ -0,0 +1,182 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFilePath is the project file listing more exclude patterns, one per
+// line; blank lines and lines starting with "#" are skipped, and a pattern
+// starting with "!" is an include pattern instead.
+const IgnoreFilePath = ".cozyignore"
+
+// packagingRules are a project's [tool.cozy] include and exclude patterns
+// and those in its .cozyignore, which override the built-in skip lists when the project is packaged.
+// Patterns are slash-separated paths relative to the project; "*" matches
+// within a path segment and "**" matches any number of segments. A pattern
+// that matches a directory applies to everything under it. Exclude wins
+// when both match.
+type packagingRules struct {
+	include [][]string
+	exclude [][]string
+}
+
+// loadPackagingRules reads the include and exclude patterns from the
+// project's pyproject.toml and .cozyignore. A project without either has
+// no rules.
+func loadPackagingRules(absDir string) (*packagingRules, error) {
+	rules := &packagingRules{}
+	pyprojectPath := filepath.Join(absDir, PyProjectTomlPath)
+	if _, err := os.Stat(pyprojectPath); err == nil {
+		cfg, err := GetToolsCozyConfig(pyprojectPath)
+		if err != nil {
+			return nil, err
+		}
+		if rules, err = newPackagingRules(cfg.Include, cfg.Exclude); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(absDir, IgnoreFilePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return rules, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFilePath, err)
+	}
+	var include, exclude []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "!"):
+			include = append(include, strings.TrimPrefix(line, "!"))
+		default:
+			exclude = append(exclude, line)
+		}
+	}
+	ignored, err := parsePatterns(IgnoreFilePath, "include", include)
+	if err != nil {
+		return nil, err
+	}
+	rules.include = append(rules.include, ignored...)
+	if ignored, err = parsePatterns(IgnoreFilePath, "exclude", exclude); err != nil {
+		return nil, err
+	}
+	rules.exclude = append(rules.exclude, ignored...)
+	return rules, nil
+}
+
+func newPackagingRules(include, exclude []string) (*packagingRules, error) {
+	rules := &packagingRules{}
+	var err error
+	if rules.include, err = parsePatterns(PyProjectTomlPath, "include", include); err != nil {
+		return nil, err
+	}
+	if rules.exclude, err = parsePatterns(PyProjectTomlPath, "exclude", exclude); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// parsePatterns splits patterns into segments, naming the file they came
+// from in errors.
+func parsePatterns(file, key string, patterns []string) ([][]string, error) {
+	parsed := make([][]string, 0, len(patterns))
+	for _, p := range patterns {
+		clean := strings.Trim(strings.TrimPrefix(p, "./"), "/")
+		if clean == "" || path.IsAbs(p) || strings.Contains(p, "\\") || clean == ".." || strings.HasPrefix(clean, "../") {
+			return nil, fmt.Errorf("%s: %s pattern %q must be a slash-separated path inside the project", file, key, p)
+		}
+		segments := strings.Split(clean, "/")
+		for _, seg := range segments {
+			if _, err := path.Match(seg, ""); err != nil {
+				return nil, fmt.Errorf("%s: invalid %s pattern %q: %w", file, key, p, err)
+			}
+		}
+		parsed = append(parsed, segments)
+	}
+	return parsed, nil
+}
+
+// included reports whether an include pattern matches relPath or one of
+// its parent directories.
+func (r *packagingRules) included(relPath string) bool {
+	return matchesAny(r.include, relPath)
+}
+
+// excluded reports whether an exclude pattern matches relPath or one of
+// its parent directories.
+func (r *packagingRules) excluded(relPath string) bool {
+	return matchesAny(r.exclude, relPath)
+}
+
+// mayInclude reports whether an include pattern could match something
+// inside the directory dir, so a directory the built-in rules skip is
+// still searched.
+func (r *packagingRules) mayInclude(dir string) bool {
+	segments := strings.Split(dir, "/")
+	for _, pattern := range r.include {
+		if matchPrefix(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns [][]string, relPath string) bool {
+	segments := strings.Split(relPath, "/")
+	for _, pattern := range patterns {
+		// A match on a parent directory covers everything under it
+		for n := 1; n <= len(segments); n++ {
+			if matchSegments(pattern, segments[:n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchSegments matches a whole path against a pattern, with "**"
+// matching zero or more segments.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], segments[0])
+	return ok && matchSegments(pattern[1:], segments[1:])
+}
+
+// matchPrefix reports whether the directory segments could be the start
+// of a path the pattern matches.
+func matchPrefix(pattern, segments []string) bool {
+	if len(segments) == 0 {
+		return true
+	}
+	if len(pattern) == 0 {
+		return false
+	}
+	if pattern[0] == "**" {
+		return true
+	}
+	ok, _ := path.Match(pattern[0], segments[0])
+	return ok && matchPrefix(pattern[1:], segments[1:])
+}
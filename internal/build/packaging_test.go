@@ -0,0 +1,139 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestArchiveManifestIncludeExclude(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"pyproject.toml": `[tool.cozy]
+deployment-id = "demo"
+include = [".cache/models/**", "configs/*.yaml", "vendor/.dist"]
+exclude = ["data/raw/**", "configs/local.yaml"]
+`,
+		"main.py":                    "print('hi')\n",
+		".cache/models/weights.bin":  "weights",
+		".cache/tmp/scratch":         "scratch",
+		"configs/prod.yaml":          "prod",
+		"configs/local.yaml":         "local",
+		"vendor/.dist/lib.py":        "lib",
+		"vendor/.dist/__pycache__/x": "compiled",
+		"data/raw/big.csv":           "raw",
+		"data/clean.csv":             "clean",
+		".venv/lib/site.py":          "site",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest, err := ArchiveManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range manifest {
+		got = append(got, f.Path)
+	}
+	slices.Sort(got)
+
+	want := []string{
+		".cache/models/weights.bin",
+		"configs/prod.yaml",
+		"data/clean.csv",
+		"main.py",
+		"pyproject.toml",
+		"vendor/.dist/__pycache__/x",
+		"vendor/.dist/lib.py",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("packaged files:\n  %s\nwant:\n  %s", strings.Join(got, "\n  "), strings.Join(want, "\n  "))
+	}
+}
+
+func TestPackagingPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"assets/**", "assets/a/b.png", true},
+		{"assets/**", "assets", true},
+		{"**/*.yaml", "configs/prod.yaml", true},
+		{"**/*.yaml", "prod.yaml", true},
+		{"configs/*.yaml", "configs/nested/prod.yaml", false},
+		{"configs", "configs/nested/prod.yaml", true},
+		{"./models/", "models/a.bin", true},
+		{"models", "models2/a.bin", false},
+	}
+	for _, tt := range tests {
+		rules, err := newPackagingRules([]string{tt.pattern}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rules.included(tt.path); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"/etc/passwd", "../secrets", "[", "a\\b"} {
+		if _, err := newPackagingRules(nil, []string{bad}); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestArchiveManifestCozyignore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.py": "print('hi')\n",
+		".cozyignore": `# Local data
+data/**
+*.ckpt
+
+!.cache/models/**
+`,
+		"data/big.csv":              "raw",
+		"weights.ckpt":              "weights",
+		".cache/models/weights.bin": "weights",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest, err := ArchiveManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range manifest {
+		got = append(got, f.Path)
+	}
+	slices.Sort(got)
+
+	want := []string{".cache/models/weights.bin", ".cozyignore", "main.py"}
+	if !slices.Equal(got, want) {
+		t.Errorf("packaged files:\n  %s\nwant:\n  %s", strings.Join(got, "\n  "), strings.Join(want, "\n  "))
+	}
+
+	os.WriteFile(filepath.Join(dir, ".cozyignore"), []byte("../outside\n"), 0644)
+	if _, err := ArchiveManifest(dir); err == nil || !strings.Contains(err.Error(), ".cozyignore") {
+		t.Errorf("expected an error naming .cozyignore, got %v", err)
+	}
+}
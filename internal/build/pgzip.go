@@ -0,0 +1,223 @@
+package build
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"runtime"
+	"sync"
+)
+
+const (
+	// pgzipBlockSize is the amount of uncompressed input handed to each worker.
+	pgzipBlockSize = 1 << 20
+
+	// pgzipDictSize is the deflate window; each block is primed with the tail
+	// of the previous block so compression ratio stays close to single-threaded gzip.
+	pgzipDictSize = 32 << 10
+)
+
+// pgzipBlock is a unit of work: one block of input and its compressed output.
+type pgzipBlock struct {
+	input []byte
+	dict  []byte
+	last  bool
+	out   bytes.Buffer
+	err   error
+	done  chan struct{}
+}
+
+// ParallelGzipWriter is a gzip writer that compresses blocks concurrently.
+// The output is a single standard gzip member readable by any gzip decoder:
+// blocks are deflated independently (with the previous block as dictionary),
+// sync-flushed to a byte boundary, and concatenated in order.
+type ParallelGzipWriter struct {
+	w       io.Writer
+	level   int
+	buf     []byte
+	prev    []byte
+	crc     uint32
+	size    uint32
+	pending chan *pgzipBlock
+	sem     chan struct{}
+	wg      sync.WaitGroup
+	errMu   sync.Mutex
+	err     error
+	started bool
+	closed  bool
+}
+
+// NewParallelGzipWriter returns a writer that compresses with the given gzip level
+// using up to GOMAXPROCS workers.
+func NewParallelGzipWriter(w io.Writer, level int) (*ParallelGzipWriter, error) {
+	if level == gzip.DefaultCompression {
+		level = flate.DefaultCompression
+	}
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		return nil, err
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	z := &ParallelGzipWriter{
+		w:       w,
+		level:   level,
+		buf:     make([]byte, 0, pgzipBlockSize),
+		pending: make(chan *pgzipBlock, workers),
+		sem:     make(chan struct{}, workers),
+	}
+	return z, nil
+}
+
+// Write buffers p and dispatches full blocks to the compression workers.
+func (z *ParallelGzipWriter) Write(p []byte) (int, error) {
+	if err := z.firstErr(); err != nil {
+		return 0, err
+	}
+	if !z.started {
+		z.start()
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := min(cap(z.buf)-len(z.buf), len(p))
+		z.buf = append(z.buf, p[:n]...)
+		p = p[n:]
+		written += n
+
+		if len(z.buf) == cap(z.buf) {
+			z.dispatch(false)
+		}
+	}
+
+	return written, nil
+}
+
+// Close flushes the remaining input, writes the gzip trailer, and waits for
+// all workers. It does not close the underlying writer.
+func (z *ParallelGzipWriter) Close() error {
+	if z.closed {
+		return z.firstErr()
+	}
+	z.closed = true
+
+	if !z.started {
+		z.start()
+	}
+	z.dispatch(true)
+	close(z.pending)
+	z.wg.Wait()
+
+	if err := z.firstErr(); err != nil {
+		return err
+	}
+
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[0:4], z.crc)
+	binary.LittleEndian.PutUint32(trailer[4:8], z.size)
+	_, err := z.w.Write(trailer[:])
+	return err
+}
+
+// start writes the gzip header and launches the ordered output goroutine.
+func (z *ParallelGzipWriter) start() {
+	z.started = true
+
+	// Minimal gzip header: magic, deflate, no flags, no mtime, unknown OS.
+	header := []byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 255}
+	if _, err := z.w.Write(header); err != nil {
+		z.setErr(err)
+	}
+
+	z.wg.Add(1)
+	go z.writeLoop()
+}
+
+// dispatch hands the current buffer to a worker and starts a fresh buffer.
+func (z *ParallelGzipWriter) dispatch(last bool) {
+	input := z.buf
+	z.crc = crc32.Update(z.crc, crc32.IEEETable, input)
+	z.size += uint32(len(input))
+
+	block := &pgzipBlock{
+		input: input,
+		dict:  z.prev,
+		last:  last,
+		done:  make(chan struct{}),
+	}
+
+	if len(input) >= pgzipDictSize {
+		z.prev = input[len(input)-pgzipDictSize:]
+	} else {
+		z.prev = append(z.prev, input...)
+		if len(z.prev) > pgzipDictSize {
+			z.prev = z.prev[len(z.prev)-pgzipDictSize:]
+		}
+	}
+
+	z.sem <- struct{}{}
+	go func() {
+		defer func() { <-z.sem }()
+		z.compress(block)
+	}()
+	z.pending <- block
+
+	if !last {
+		z.buf = make([]byte, 0, pgzipBlockSize)
+	}
+}
+
+// compress deflates a single block, sync-flushing unless it is the final one.
+func (z *ParallelGzipWriter) compress(block *pgzipBlock) {
+	defer close(block.done)
+
+	fw, err := flate.NewWriterDict(&block.out, z.level, block.dict)
+	if err != nil {
+		block.err = err
+		return
+	}
+	if _, err := fw.Write(block.input); err != nil {
+		block.err = err
+		return
+	}
+	if block.last {
+		block.err = fw.Close()
+	} else {
+		block.err = fw.Flush()
+	}
+}
+
+// writeLoop writes compressed blocks to the underlying writer in input order.
+func (z *ParallelGzipWriter) writeLoop() {
+	defer z.wg.Done()
+
+	for block := range z.pending {
+		<-block.done
+		if z.firstErr() != nil {
+			continue
+		}
+		if block.err != nil {
+			z.setErr(block.err)
+			continue
+		}
+		if _, err := z.w.Write(block.out.Bytes()); err != nil {
+			z.setErr(err)
+		}
+	}
+}
+
+func (z *ParallelGzipWriter) setErr(err error) {
+	z.errMu.Lock()
+	defer z.errMu.Unlock()
+	if z.err == nil {
+		z.err = err
+	}
+}
+
+func (z *ParallelGzipWriter) firstErr() error {
+	z.errMu.Lock()
+	defer z.errMu.Unlock()
+	return z.err
+}
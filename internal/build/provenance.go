@@ -0,0 +1,165 @@
+package build
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+const (
+	// StatementType is the in-toto statement type of build provenance.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// ProvenancePredicateType is the SLSA provenance predicate type.
+	ProvenancePredicateType = "https://slsa.dev/provenance/v1"
+	// ProvenanceBuildType identifies builds submitted by cozyctl.
+	ProvenanceBuildType = "https://github.com/cozy-creator/cozyctl/build/v1"
+
+	// SourceDependency names the project source among resolved dependencies.
+	SourceDependency = "source"
+	// ArchiveDependency names the uploaded source archive among resolved dependencies.
+	ArchiveDependency = "source.tar.gz"
+)
+
+// ProvenanceInput is what went into a server build.
+type ProvenanceInput struct {
+	BuildID       string
+	BuildName     string
+	ImageTag      string
+	ImageDigest   string // sha256:<hex>, if the builder reported one
+	BuilderID     string
+	SourceDigest  string // See SourceDigest
+	ArchiveDigest string // sha256 of the uploaded archive
+	BaseImage     string
+	BaseDigest    string // sha256:<hex>, if the builder reported one
+	Config        *ToolsCozyConfig
+	StartedOn     time.Time
+	FinishedOn    time.Time
+}
+
+// NewProvenance builds the in-toto SLSA provenance statement for a build.
+func NewProvenance(in ProvenanceInput) *api.ProvenanceStatement {
+	subject := api.ResourceDescriptor{Name: in.ImageTag, Digest: splitDigest(in.ImageDigest)}
+
+	params := map[string]any{"build_name": in.BuildName}
+	if cfg := in.Config; cfg != nil {
+		params["deployment_id"] = cfg.DeploymentID
+		for key, value := range map[string]string{"python": cfg.Python, "pytorch": cfg.Pytorch, "cuda": cfg.Cuda} {
+			if value != "" {
+				params[key] = value
+			}
+		}
+		if len(cfg.Functions) > 0 {
+			functions := make([]string, 0, len(cfg.Functions))
+			for name := range cfg.Functions {
+				functions = append(functions, name)
+			}
+			slices.Sort(functions)
+			params["functions"] = functions
+		}
+	}
+
+	var deps []api.ResourceDescriptor
+	if in.SourceDigest != "" {
+		deps = append(deps, api.ResourceDescriptor{Name: SourceDependency, Digest: splitDigest(in.SourceDigest)})
+	}
+	if in.ArchiveDigest != "" {
+		deps = append(deps, api.ResourceDescriptor{Name: ArchiveDependency, Digest: splitDigest(in.ArchiveDigest)})
+	}
+	if in.BaseImage != "" {
+		deps = append(deps, api.ResourceDescriptor{Name: in.BaseImage, URI: "docker://" + in.BaseImage, Digest: splitDigest(in.BaseDigest)})
+	}
+
+	metadata := api.ProvenanceMetadata{InvocationID: in.BuildID}
+	if !in.StartedOn.IsZero() {
+		metadata.StartedOn = in.StartedOn.UTC().Format(time.RFC3339)
+	}
+	if !in.FinishedOn.IsZero() {
+		metadata.FinishedOn = in.FinishedOn.UTC().Format(time.RFC3339)
+	}
+
+	return &api.ProvenanceStatement{
+		Type:          StatementType,
+		Subject:       []api.ResourceDescriptor{subject},
+		PredicateType: ProvenancePredicateType,
+		Predicate: api.SLSAProvenance{
+			BuildDefinition: api.BuildDefinition{
+				BuildType:            ProvenanceBuildType,
+				ExternalParameters:   params,
+				ResolvedDependencies: deps,
+			},
+			RunDetails: api.RunDetails{
+				Builder:  api.ProvenanceBuilder{ID: in.BuilderID},
+				Metadata: metadata,
+			},
+		},
+	}
+}
+
+// splitDigest turns "sha256:<hex>" into an in-toto digest set.
+// A bare hex digest is taken to be sha256.
+func splitDigest(digest string) map[string]string {
+	if digest == "" {
+		return nil
+	}
+	algorithm, hex, ok := strings.Cut(digest, ":")
+	if !ok {
+		algorithm, hex = "sha256", digest
+	}
+	return map[string]string{algorithm: hex}
+}
+
+// SourceDigest returns a content digest of the files WriteTarball would
+// package for a project, as "sha256:<hex>". Unlike a digest of the archive
+// itself, it does not depend on file timestamps or compression, so it can be
+// recomputed from a checkout to verify a build's provenance.
+func SourceDigest(projectDir string) (string, error) {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	var lines []string
+	err = walkProject(absDir, func(path, relPath string, info os.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%x  %s\n", sum, filepath.ToSlash(relPath)))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash project files: %w", err)
+	}
+
+	// Sort by path so the digest doesn't depend on walk order
+	slices.SortFunc(lines, func(a, b string) int { return strings.Compare(a[66:], b[66:]) })
+	h := sha256.New()
+	for _, line := range lines {
+		io.WriteString(h, line)
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
+
+func fileSHA256(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,89 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSourceDigest(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("pyproject.toml", "[project]\nname = \"demo\"\n")
+	write("src/main.py", "print('hi')\n")
+
+	first, err := SourceDigest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Excluded files and timestamps don't change the digest
+	write(".env", "SECRET=1\n")
+	write("src/__pycache__/main.cpython-312.pyc", "bytecode")
+	if err := os.Chtimes(filepath.Join(dir, "src/main.py"), time.Unix(0, 0), time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := SourceDigest(dir); again != first {
+		t.Errorf("digest changed with only excluded files and mtimes: %s != %s", again, first)
+	}
+
+	write("src/main.py", "print('bye')\n")
+	if changed, _ := SourceDigest(dir); changed == first {
+		t.Error("digest did not change when a source file did")
+	}
+}
+
+func TestNewProvenance(t *testing.T) {
+	statement := NewProvenance(ProvenanceInput{
+		BuildID:      "build-1",
+		BuildName:    "demo",
+		ImageTag:     "registry/demo:build-1",
+		ImageDigest:  "sha256:abc",
+		BuilderID:    "https://builder.example",
+		SourceDigest: "sha256:def",
+		BaseImage:    "python:3.12-slim",
+		Config: &ToolsCozyConfig{
+			DeploymentID: "demo",
+			Python:       "3.12",
+			Functions:    map[string]FunctionConfig{"generate": {}, "health": {}},
+		},
+	})
+
+	if statement.Type != StatementType || statement.PredicateType != ProvenancePredicateType {
+		t.Errorf("unexpected types %q / %q", statement.Type, statement.PredicateType)
+	}
+	if got := statement.Subject[0]; got.Name != "registry/demo:build-1" || got.Digest["sha256"] != "abc" {
+		t.Errorf("unexpected subject %+v", got)
+	}
+	params := statement.Predicate.BuildDefinition.ExternalParameters
+	if params["deployment_id"] != "demo" || params["python"] != "3.12" {
+		t.Errorf("unexpected parameters %v", params)
+	}
+	if _, ok := params["cuda"]; ok {
+		t.Errorf("unset parameters should be omitted: %v", params)
+	}
+	if functions, _ := params["functions"].([]string); len(functions) != 2 || functions[0] != "generate" {
+		t.Errorf("expected sorted functions, got %v", params["functions"])
+	}
+
+	deps := statement.Predicate.BuildDefinition.ResolvedDependencies
+	if len(deps) != 2 || deps[0].Name != SourceDependency || deps[0].Digest["sha256"] != "def" || deps[1].URI != "docker://python:3.12-slim" {
+		t.Errorf("unexpected dependencies %+v", deps)
+	}
+	if deps[1].Digest != nil {
+		t.Errorf("base image without a reported digest should have none, got %v", deps[1].Digest)
+	}
+	if got := statement.Predicate.RunDetails.Metadata.InvocationID; got != "build-1" {
+		t.Errorf("expected invocation build-1, got %q", got)
+	}
+}
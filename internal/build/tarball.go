@@ -2,7 +2,6 @@ package build
 
 import (
 	"archive/tar"
-	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -32,47 +31,123 @@ var excludedFiles = map[string]bool{
 	"Thumbs.db":   true,
 }
 
-// CreateTarball creates a gzip-compressed tar archive from a project directory.
+// StreamTarball packages a project directory in the background and returns
+// a reader yielding the gzip-compressed tar archive as it is produced, so the
+// archive never has to fit in memory and uploads can start immediately.
+// Packaging errors are surfaced from Read.
+func StreamTarball(projectDir string) io.ReadCloser {
+	return StreamTarballWithProgress(projectDir, nil)
+}
+
+// StreamTarballWithProgress is like StreamTarball, but calls onProgress with the
+// number of file bytes packaged so far and the total to package. Because the
+// archive is produced only as fast as it is read, this tracks upload progress.
+func StreamTarballWithProgress(projectDir string, onProgress func(written, total int64)) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTarball(projectDir, pw, onProgress))
+	}()
+	return pr
+}
+
+// WriteTarball writes a gzip-compressed tar archive of a project directory to w.
+// Compression runs on all available cores.
 // It excludes common non-essential directories and files.
-func CreateTarball(projectDir string) (*bytes.Buffer, error) {
+func WriteTarball(projectDir string, w io.Writer) error {
+	return writeTarball(projectDir, w, nil)
+}
+
+func writeTarball(projectDir string, w io.Writer, onProgress func(written, total int64)) error {
 	absDir, err := filepath.Abs(projectDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve project path: %w", err)
+		return fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	var total, written int64
+	if onProgress != nil {
+		err := walkProject(absDir, func(path, relPath string, info os.FileInfo) error {
+			if !info.IsDir() {
+				total += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create tarball: %w", err)
+		}
+		onProgress(0, total)
 	}
 
-	var buf bytes.Buffer
-	gzw := gzip.NewWriter(&buf)
+	gzw, err := NewParallelGzipWriter(w, gzip.DefaultCompression)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip writer: %w", err)
+	}
 	tw := tar.NewWriter(gzw)
 
-	err = filepath.Walk(absDir, func(path string, info os.FileInfo, err error) error {
+	err = walkProject(absDir, func(path, relPath string, info os.FileInfo) error {
+		// Create tar header
+		header, err := tar.FileInfoHeader(info, "")
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to create tar header for %s: %w", relPath, err)
 		}
+		header.Name = filepath.ToSlash(relPath)
 
-		// Skip symlinks
-		if info.Mode()&os.ModeSymlink != 0 {
-			return nil
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write tar header for %s: %w", relPath, err)
 		}
 
-		name := info.Name()
+		// Write file content
+		if !info.IsDir() {
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", relPath, err)
+			}
 
-		// Skip excluded directories
-		if info.IsDir() && excludedDirs[name] {
-			return filepath.SkipDir
-		}
+			n, err := io.Copy(tw, f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("failed to write %s to tarball: %w", relPath, err)
+			}
 
-		// Skip hidden directories (except the root)
-		if info.IsDir() && strings.HasPrefix(name, ".") && path != absDir {
-			return filepath.SkipDir
+			if onProgress != nil {
+				written += n
+				onProgress(written, total)
+			}
 		}
 
-		// Skip excluded files
-		if !info.IsDir() && excludedFiles[name] {
-			return nil
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create tarball: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize tar: %w", err)
+	}
+	if err := gzw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize gzip: %w", err)
+	}
+
+	return nil
+}
+
+// walkProject calls fn for every directory and file under absDir that belongs
+// in the tarball, skipping symlinks and the entries the built-in skip lists
+// or the project's exclude patterns rule out, unless its include patterns
+// bring them back (see packagingRules).
+func walkProject(absDir string, fn func(path, relPath string, info os.FileInfo) error) error {
+	rules, err := loadPackagingRules(absDir)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(absDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
 		}
 
-		// Skip .pyc files
-		if !info.IsDir() && strings.HasSuffix(name, ".pyc") {
+		// Skip symlinks
+		if info.Mode()&os.ModeSymlink != 0 {
 			return nil
 		}
 
@@ -92,43 +167,74 @@ func CreateTarball(projectDir string) (*bytes.Buffer, error) {
 			return fmt.Errorf("path traversal detected: %s", relPath)
 		}
 
-		// Create tar header
-		header, err := tar.FileInfoHeader(info, "")
-		if err != nil {
-			return fmt.Errorf("failed to create tar header for %s: %w", relPath, err)
+		slashPath := filepath.ToSlash(relPath)
+		switch {
+		case rules.excluded(slashPath):
+		case rules.included(slashPath):
+			return fn(path, relPath, info)
+		case !skippedByDefault(slashPath, info.IsDir()):
+			return fn(path, relPath, info)
+		case info.IsDir() && rules.mayInclude(slashPath):
+			// Searched for included files, but not packaged itself
+			return nil
 		}
-		header.Name = relPath
 
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write tar header for %s: %w", relPath, err)
+		if info.IsDir() {
+			return filepath.SkipDir
 		}
+		return nil
+	})
+}
 
-		// Write file content
-		if !info.IsDir() {
-			f, err := os.Open(path)
-			if err != nil {
-				return fmt.Errorf("failed to open %s: %w", relPath, err)
-			}
-			defer f.Close()
-
-			if _, err := io.Copy(tw, f); err != nil {
-				return fmt.Errorf("failed to write %s to tarball: %w", relPath, err)
-			}
+// skippedByDefault reports whether the built-in skip lists leave out a
+// project entry: excluded and hidden directories and everything in them,
+// excluded files, and compiled Python files.
+func skippedByDefault(slashPath string, isDir bool) bool {
+	segments := strings.Split(slashPath, "/")
+	dirs := segments
+	if !isDir {
+		dirs = segments[:len(segments)-1]
+	}
+	for _, name := range dirs {
+		if excludedDirs[name] || strings.HasPrefix(name, ".") {
+			return true
 		}
+	}
+	if isDir {
+		return false
+	}
 
-		return nil
-	})
+	name := segments[len(segments)-1]
+	return excludedFiles[name] || strings.HasSuffix(name, ".pyc")
+}
+
+// ManifestFile is one file of a project archive.
+type ManifestFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Mode string `json:"mode"`
+}
 
+// ArchiveManifest lists the files WriteTarball would package for a project.
+func ArchiveManifest(projectDir string) ([]ManifestFile, error) {
+	absDir, err := filepath.Abs(projectDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create tarball: %w", err)
+		return nil, fmt.Errorf("failed to resolve project path: %w", err)
 	}
 
-	if err := tw.Close(); err != nil {
-		return nil, fmt.Errorf("failed to finalize tar: %w", err)
-	}
-	if err := gzw.Close(); err != nil {
-		return nil, fmt.Errorf("failed to finalize gzip: %w", err)
+	var files []ManifestFile
+	err = walkProject(absDir, func(path, relPath string, info os.FileInfo) error {
+		if !info.IsDir() {
+			files = append(files, ManifestFile{
+				Path: filepath.ToSlash(relPath),
+				Size: info.Size(),
+				Mode: info.Mode().String(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project files: %w", err)
 	}
-
-	return &buf, nil
+	return files, nil
 }
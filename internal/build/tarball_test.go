@@ -0,0 +1,126 @@
+package build
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParallelGzipWriter_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "smaller than dictionary", size: 1000},
+		{name: "single block", size: pgzipBlockSize},
+		{name: "multiple blocks", size: 3*pgzipBlockSize + 12345},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Compressible but non-trivial input
+			input := make([]byte, tt.size)
+			for i := range input {
+				input[i] = byte('a' + rng.Intn(4))
+			}
+
+			var compressed bytes.Buffer
+			zw, err := NewParallelGzipWriter(&compressed, gzip.DefaultCompression)
+			if err != nil {
+				t.Fatalf("NewParallelGzipWriter failed: %v", err)
+			}
+			// Write in odd-sized chunks to exercise block boundaries
+			for off := 0; off < len(input); off += 77777 {
+				end := min(off+77777, len(input))
+				if _, err := zw.Write(input[off:end]); err != nil {
+					t.Fatalf("Write failed: %v", err)
+				}
+			}
+			if err := zw.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			zr, err := gzip.NewReader(&compressed)
+			if err != nil {
+				t.Fatalf("gzip.NewReader failed: %v", err)
+			}
+			output, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("decompress failed: %v", err)
+			}
+			if !bytes.Equal(output, input) {
+				t.Errorf("round trip mismatch: got %d bytes, want %d", len(output), len(input))
+			}
+		})
+	}
+}
+
+func TestStreamTarball(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"pyproject.toml":  "[tool.cozy]\n",
+		"src/worker.py":   "print('hi')\n",
+		".git/HEAD":       "ref: refs/heads/main\n",
+		"src/cache.pyc":   "junk",
+		".env":            "SECRET=1",
+		"assets/data.txt": "data",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rc := StreamTarball(dir)
+	defer rc.Close()
+
+	zr, err := gzip.NewReader(rc)
+	if err != nil {
+		t.Fatalf("gzip.NewReader failed: %v", err)
+	}
+
+	got := make(map[string]bool)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading tar failed: %v", err)
+		}
+		got[hdr.Name] = true
+	}
+
+	for _, want := range []string{"pyproject.toml", "src/worker.py", "assets/data.txt"} {
+		if !got[want] {
+			t.Errorf("expected %s in tarball", want)
+		}
+	}
+	for _, unwanted := range []string{".git/HEAD", "src/cache.pyc", ".env"} {
+		if got[unwanted] {
+			t.Errorf("did not expect %s in tarball", unwanted)
+		}
+	}
+}
+
+func TestStreamTarball_MissingDirectory(t *testing.T) {
+	rc := StreamTarball(filepath.Join(t.TempDir(), "does-not-exist"))
+	defer rc.Close()
+
+	if _, err := io.ReadAll(rc); err == nil {
+		t.Error("expected error for missing directory, got nil")
+	}
+}
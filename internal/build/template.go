@@ -33,6 +33,12 @@ RUN mkdir -p /app/.cozy && \
 ENV {{ $key }}="{{ $value }}"
 {{- end }}
 
+# Build metadata, shown by 'cozyctl images inspect'. Last, so it doesn't
+# invalidate the cached layers above on every build
+{{- range $key, $value := .Labels }}
+LABEL {{ $key }}={{ printf "%q" $value }}
+{{- end }}
+
 # Default command - runs gen-worker entrypoint
 {{- if .Entrypoint }}
 CMD {{ .Entrypoint }}
@@ -77,6 +83,12 @@ ENV NVIDIA_DRIVER_CAPABILITIES=compute,utility
 ENV {{ $key }}="{{ $value }}"
 {{- end }}
 
+# Build metadata, shown by 'cozyctl images inspect'. Last, so it doesn't
+# invalidate the cached layers above on every build
+{{- range $key, $value := .Labels }}
+LABEL {{ $key }}={{ printf "%q" $value }}
+{{- end }}
+
 # Default command - runs gen-worker entrypoint
 {{- if .Entrypoint }}
 CMD {{ .Entrypoint }}
@@ -96,10 +108,13 @@ type DockerfileData struct {
 	IsGPU        bool
 	CudaVersion  string
 	Root         string
+	Labels       map[string]string
 }
 
-// GenerateDockerfile creates a Dockerfile from the template and cozy config
-func GenerateDockerfile(baseImage string, cozyConfig *ToolsCozyConfig) (string, error) {
+// GenerateDockerfile creates a Dockerfile from the template and cozy config.
+// The image is labeled with meta, completed with the base image and
+// deployment ID.
+func GenerateDockerfile(baseImage string, cozyConfig *ToolsCozyConfig, meta ImageMetadata) (string, error) {
 	isGPU := cozyConfig.Pytorch != "" || cozyConfig.Cuda != ""
 
 	cudaVersion := normalizeCuda(cozyConfig.Cuda)
@@ -128,6 +143,12 @@ func GenerateDockerfile(baseImage string, cozyConfig *ToolsCozyConfig) (string,
 		data.DeploymentID = cozyConfig.DeploymentID
 	}
 
+	meta.BaseImage = baseImage
+	if meta.DeploymentID == "" {
+		meta.DeploymentID = cozyConfig.DeploymentID
+	}
+	data.Labels = meta.Labels()
+
 	// Select template based on GPU configuration
 	templateStr := cpuDockerfileTemplate
 	if isGPU {
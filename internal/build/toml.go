@@ -26,6 +26,11 @@ type ToolsCozyConfig struct {
 	Root         string            `toml:"root"`
 	Environment  map[string]string `toml:"environment"`
 
+	// BuildMachine selects the server-side builder machine type (small,
+	// large, or gpu) for projects whose dependencies need more CPU and
+	// memory to compile; 'cozyctl build --build-machine' overrides it
+	BuildMachine string `toml:"build-machine"`
+
 	// Custom entrypoint command (optional)
 	// If empty, defaults to "python -m gen_worker.entrypoint" for gen-worker projects
 	Entrypoint string `toml:"entrypoint"`
@@ -36,6 +41,116 @@ type ToolsCozyConfig struct {
 	//   generate = { requires_gpu = true }
 	//   health = { requires_gpu = false }
 	Functions map[string]FunctionConfig `toml:"functions"`
+
+	// Models maps the keys functions pass to ModelRef to model references
+	// Example:
+	//   [tool.cozy.models]
+	//   sdxl-turbo = "hf:stabilityai/sdxl-turbo"
+	Models map[string]string `toml:"models"`
+
+	// Include and Exclude override the built-in rules for which files are
+	// packaged (which skip hidden directories, virtualenvs, caches, .env,
+	// and the like). Patterns are relative to the project; "**" matches
+	// any number of directories, and exclude wins over include.
+	// Example:
+	//   include = [".cache/models/**", "configs/*.yaml"]
+	//   exclude = ["data/raw/**"]
+	Include []string `toml:"include"`
+	Exclude []string `toml:"exclude"`
+
+	// Workers bounds the deployment's worker count (see WorkersConfig)
+	Workers *WorkersConfig `toml:"workers"`
+
+	// DependsOn lists the deployment IDs of other workspace members that
+	// must deploy successfully before this one (see WorkspaceConfig)
+	DependsOn []string `toml:"depends-on"`
+
+	// Workspace, in a root pyproject.toml, groups several projects so
+	// 'cozyctl deploy --all' can deploy them together
+	Workspace *WorkspaceConfig `toml:"workspace"`
+
+	// Hooks are commands run at points of a deploy (see HooksConfig)
+	Hooks HooksConfig `toml:"hooks"`
+
+	// Labels are free-form metadata, e.g. for policies that require an owner
+	Labels map[string]string `toml:"labels"`
+
+	// Policy enables checking deployment requests against Rego policies
+	// (see PolicyConfig)
+	Policy *PolicyConfig `toml:"policy"`
+
+	// Licenses configures 'cozyctl scan licenses' (see LicensesConfig)
+	Licenses *LicensesConfig `toml:"licenses"`
+}
+
+// WorkersConfig sets the worker bounds a deploy requests when
+// --min-workers and --max-workers aren't given.
+// Example:
+//
+//	[tool.cozy.workers]
+//	min = 0 # Scale to zero when idle
+//	max = 2
+type WorkersConfig struct {
+	Min *int `toml:"min"`
+	Max *int `toml:"max"`
+}
+
+// LicensesConfig sets which dependency licenses 'cozyctl scan licenses'
+// rejects. Entries match SPDX identifiers and their versions, so "AGPL"
+// matches AGPL-3.0-only and AGPL-3.0-or-later.
+// Example:
+//
+//	[tool.cozy.licenses]
+//	deny = ["AGPL", "SSPL", "GPL-3.0"]
+//	ignore = ["internal-package"]
+//	fail-on-unknown = true
+type LicensesConfig struct {
+	// Licenses that fail the scan; replaces the default of AGPL and SSPL
+	Deny []string `toml:"deny"`
+
+	// Packages exempt from the scan, e.g. after a legal review
+	Ignore []string `toml:"ignore"`
+
+	// Fail when a dependency's license cannot be determined
+	FailOnUnknown bool `toml:"fail-on-unknown"`
+}
+
+// PolicyConfig configures the policies deployment requests are checked
+// against before they are submitted. The bundled policies are always
+// loaded and take effect once their params are set.
+// Example:
+//
+//	[tool.cozy.policy]
+//	paths = ["policies/"]
+//	params = { max_workers = 10, allowed_base_images = ["ghcr.io/cozy-creator/"], required_labels = ["team"] }
+type PolicyConfig struct {
+	// Rego files or directories with org policies, relative to the project
+	Paths []string `toml:"paths"`
+
+	// Parameters available to policies as data.cozy.params
+	Params map[string]any `toml:"params"`
+}
+
+// HooksConfig lists commands run through the shell, in the project
+// directory, during a deploy.
+// Example:
+//
+//	[tool.cozy.hooks]
+//	pre-deploy = "./scripts/check.sh"
+type HooksConfig struct {
+	// Run after the image is built and before it is deployed; a non-zero
+	// exit aborts the deploy
+	PreDeploy string `toml:"pre-deploy"`
+}
+
+// WorkspaceConfig lists the projects of a multi-project workspace.
+// Example:
+//
+//	[tool.cozy.workspace]
+//	members = ["preprocess", "generate", "workers/*"]
+type WorkspaceConfig struct {
+	// Member project directories, relative to the workspace root; globs are allowed
+	Members []string `toml:"members"`
 }
 
 // Example pyproject.toml configuration:
@@ -47,11 +162,21 @@ type ToolsCozyConfig struct {
 //	cuda = "12.6"             # Enables CUDA support
 //	root = "src/app"          # Project root within tarball (optional)
 //	entrypoint = '["custom", "entrypoint"]'  # Optional custom entrypoint
+//	build-machine = "large"   # Server builder machine: small, large, or gpu (optional)
+//
+//	depends-on = ["preprocess"] # Workspace members to deploy first (optional)
 //
 //	[tool.cozy.functions]
 //	generate = { requires_gpu = true }
 //	health = { requires_gpu = false }
 //
+//	[tool.cozy.workers]
+//	min = 0
+//	max = 2
+//
+//	[tool.cozy.hooks]
+//	pre-deploy = "./scripts/check.sh" # Policy check before deploying (optional)
+//
 // GetToolsCozyConfig parses pyproject.toml and returns the [tool.cozy] configuration.
 func GetToolsCozyConfig(filepath string) (*ToolsCozyConfig, error) {
 	var config PyProjectToml
@@ -0,0 +1,114 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+)
+
+// TarballPartSize is the part size of source tarball uploads. Tarballs up to
+// this size are uploaded in a single request.
+const TarballPartSize = 16 << 20
+
+// uploadAttempts is how often a part is tried before the upload is abandoned.
+const uploadAttempts = 4
+
+// uploadRetryDelay is the wait before retrying a failed part. It doubles with
+// each attempt.
+var uploadRetryDelay = time.Second
+
+// uploadTarball uploads a source tarball to the file store and returns its
+// path, for creating the build. The stream is read one part at a time, so a
+// multi-GB tarball never has to fit in memory, and a part that fails is
+// retried on its own: the upload resumes from that part instead of starting
+// over. onProgress is called with the bytes uploaded so far after each part.
+func uploadTarball(ctx context.Context, client api.BuilderAPI, tarball io.Reader, buildName string, partSize int, onProgress func(sent int64)) (string, error) {
+	path := fmt.Sprintf("builds/%s/%d.tar.gz", buildName, time.Now().UnixNano())
+
+	buf := make([]byte, partSize)
+	n, err := io.ReadFull(tarball, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if n < partSize {
+		// Small enough for a single request
+		err := retryPart(ctx, func() error {
+			return client.UploadFile(path, bytes.NewReader(buf[:n]), "application/gzip")
+		})
+		if err != nil {
+			return "", err
+		}
+		onProgress(int64(n))
+		return path, nil
+	}
+
+	upload, err := client.CreateMultipartUpload(path)
+	if err != nil {
+		return "", err
+	}
+	if err := uploadTarballParts(ctx, client, tarball, path, upload.UploadID, buf, onProgress); err != nil {
+		if abortErr := client.AbortMultipartUpload(path, upload.UploadID); abortErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to abort upload: %w", abortErr))
+		}
+		return "", err
+	}
+	return path, nil
+}
+
+// uploadTarballParts uploads buf, which holds the first part, and the rest
+// of the tarball as the parts of a multipart upload, then completes it.
+func uploadTarballParts(ctx context.Context, client api.BuilderAPI, tarball io.Reader, path, uploadID string, buf []byte, onProgress func(int64)) error {
+	var parts []api.UploadedPart
+	var sent int64
+	chunk := buf
+	for number := 1; ; number++ {
+		var part *api.UploadedPart
+		err := retryPart(ctx, func() error {
+			var err error
+			part, err = client.UploadPart(path, uploadID, number, chunk)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("part %d: %w", number, err)
+		}
+		parts = append(parts, *part)
+		sent += int64(len(chunk))
+		onProgress(sent)
+
+		if len(chunk) < len(buf) {
+			break
+		}
+		n, err := io.ReadFull(tarball, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		chunk = buf[:n]
+	}
+	return client.CompleteMultipartUpload(path, uploadID, parts)
+}
+
+// retryPart calls upload until it succeeds, up to uploadAttempts times,
+// backing off between attempts. It gives up early when ctx is cancelled.
+func retryPart(ctx context.Context, upload func() error) error {
+	delay := uploadRetryDelay
+	var err error
+	for attempt := 1; attempt <= uploadAttempts; attempt++ {
+		if err = upload(); err == nil || attempt == uploadAttempts {
+			break
+		}
+		if sleepErr := interrupt.Sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+		delay *= 2
+	}
+	return err
+}
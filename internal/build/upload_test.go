@@ -0,0 +1,99 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+// flakyParts fails the first `failures` uploads of every part.
+type flakyParts struct {
+	api.BuilderAPI
+	failures int
+	tries    map[int]int
+	aborted  bool
+}
+
+func (f *flakyParts) UploadPart(path, uploadID string, partNumber int, data []byte) (*api.UploadedPart, error) {
+	f.tries[partNumber]++
+	if f.tries[partNumber] <= f.failures {
+		return nil, errors.New("connection reset")
+	}
+	return f.BuilderAPI.UploadPart(path, uploadID, partNumber, data)
+}
+
+func (f *flakyParts) AbortMultipartUpload(path, uploadID string) error {
+	f.aborted = true
+	return f.BuilderAPI.AbortMultipartUpload(path, uploadID)
+}
+
+func newFlakyParts(t *testing.T, failures int) *flakyParts {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	delay := uploadRetryDelay
+	uploadRetryDelay = 0
+	t.Cleanup(func() { uploadRetryDelay = delay })
+	return &flakyParts{BuilderAPI: api.NewBuilderClient(ts.URL, "token", nil), failures: failures, tries: map[int]int{}}
+}
+
+func TestUploadTarballResumesFailedParts(t *testing.T) {
+	client := newFlakyParts(t, 2)
+
+	var progress []int64
+	path, err := uploadTarball(context.Background(), client, strings.NewReader(strings.Repeat("t", 25)), "demo", 10, func(sent int64) {
+		progress = append(progress, sent)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(client.tries) != 3 || client.tries[3] != 3 {
+		t.Errorf("tries = %v, want 3 parts each uploaded on the third try", client.tries)
+	}
+	if len(progress) != 3 || progress[2] != 25 {
+		t.Errorf("progress = %v, want 10, 20, 25", progress)
+	}
+	files, err := client.ListFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Size != 25 {
+		t.Errorf("stored files = %+v, want the 25-byte tarball", files)
+	}
+	if _, err := client.CreateBuild(path, api.BuildOptions{}); err != nil {
+		t.Errorf("creating a build from the uploaded tarball: %v", err)
+	}
+}
+
+func TestUploadTarballSmallSingleRequest(t *testing.T) {
+	client := newFlakyParts(t, 0)
+
+	path, err := uploadTarball(context.Background(), client, strings.NewReader("tarball"), "demo", 10, func(int64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.tries) != 0 {
+		t.Errorf("a tarball smaller than a part was uploaded in parts: %v", client.tries)
+	}
+	if files, _ := client.ListFiles(path); len(files) != 1 || files[0].Size != 7 {
+		t.Errorf("stored files = %+v, want the 7-byte tarball", files)
+	}
+}
+
+func TestUploadTarballAbortsAfterRetries(t *testing.T) {
+	client := newFlakyParts(t, uploadAttempts)
+
+	_, err := uploadTarball(context.Background(), client, strings.NewReader(strings.Repeat("t", 25)), "demo", 10, func(int64) {})
+	if err == nil || !strings.Contains(err.Error(), "part 1: connection reset") {
+		t.Fatalf("err = %v, want part 1 to fail", err)
+	}
+	if client.tries[1] != uploadAttempts || !client.aborted {
+		t.Errorf("tries = %v, aborted = %v; want %d tries and the upload aborted", client.tries, client.aborted, uploadAttempts)
+	}
+}
@@ -0,0 +1,118 @@
+package builds
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ArtifactsOptions contains the options for downloading build artifacts.
+type ArtifactsOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	BuildID   string
+	OutDir    string   // Defaults to <build-id>-artifacts
+	Names     []string // Only these artifacts (default: all)
+	List      bool     // Only list the artifacts
+}
+
+// Artifacts downloads (or lists) the artifacts the builder attached to a build.
+func Artifacts(opts ArtifactsOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return artifacts(os.Stdout, client, opts)
+}
+
+func artifacts(out io.Writer, client api.BuilderAPI, opts ArtifactsOptions) error {
+	available, err := client.ListBuildArtifacts(opts.BuildID)
+	if err != nil {
+		return fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	if len(available) == 0 {
+		return fmt.Errorf("build %s has no artifacts (artifacts are attached once a build finishes)", opts.BuildID)
+	}
+
+	selected := available
+	if len(opts.Names) > 0 {
+		selected = nil
+		for _, name := range opts.Names {
+			i := slices.IndexFunc(available, func(a api.BuildArtifact) bool { return a.Name == name })
+			if i < 0 {
+				return fmt.Errorf("build %s has no artifact %q", opts.BuildID, name)
+			}
+			selected = append(selected, available[i])
+		}
+	}
+
+	if opts.List {
+		table := &ui.Table{Columns: []string{"NAME", "KIND", "SIZE"}}
+		for _, a := range selected {
+			table.Rows = append(table.Rows, ui.Row{Key: a.Name, Cells: []string{a.Name, orDash(a.Kind), ui.FormatBytes(a.Size)}})
+		}
+		return table.Write(out)
+	}
+
+	outDir := opts.OutDir
+	if outDir == "" {
+		outDir = opts.BuildID + "-artifacts"
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", outDir, err)
+	}
+
+	for _, a := range selected {
+		path, n, err := downloadArtifact(client, opts.BuildID, a, outDir)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Downloaded %s (%s)\n", path, ui.FormatBytes(n))
+	}
+	return nil
+}
+
+// downloadArtifact saves one artifact into dir, returning its path and size.
+// The file only appears once it is complete.
+func downloadArtifact(client api.BuilderAPI, buildID string, a api.BuildArtifact, dir string) (string, int64, error) {
+	// Artifact names come from the server; never let them escape dir
+	if a.Name == "" || a.Name != filepath.Base(a.Name) || a.Name == "." || a.Name == ".." {
+		return "", 0, fmt.Errorf("refusing to write artifact with unsafe name %q", a.Name)
+	}
+	path := filepath.Join(dir, a.Name)
+
+	body, err := client.DownloadBuildArtifact(buildID, a.Name)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to download %s: %w", a.Name, err)
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(dir, "."+a.Name+".*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to download %s: %w", a.Name, err)
+	}
+	if a.Size > 0 && n != a.Size {
+		return "", 0, fmt.Errorf("failed to download %s: got %d bytes, expected %d", a.Name, n, a.Size)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, n, nil
+}
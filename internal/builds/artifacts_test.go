@@ -0,0 +1,87 @@
+package builds
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+// fakeArtifacts serves a fixed artifact list.
+type fakeArtifacts struct {
+	api.BuilderAPI
+	list    []api.BuildArtifact
+	content string
+}
+
+func (f *fakeArtifacts) ListBuildArtifacts(string) ([]api.BuildArtifact, error) {
+	return f.list, nil
+}
+
+func (f *fakeArtifacts) DownloadBuildArtifact(string, string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(f.content)), nil
+}
+
+func TestDownloadArtifacts(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+	upload, err := client.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "out")
+	var out bytes.Buffer
+	if err := artifacts(&out, client, ArtifactsOptions{BuildID: upload.BuildID, OutDir: dir}); err != nil {
+		t.Fatalf("artifacts: %v\n%s", err, out.String())
+	}
+
+	for _, name := range []string{"Dockerfile", "requirements.lock", "sbom.spdx.json", "logs.tar.gz"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil || info.Size() == 0 {
+			t.Errorf("artifact %s not downloaded: %v", name, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 4 {
+		t.Errorf("expected only the 4 artifacts in %s, got %d entries", dir, len(entries))
+	}
+
+	out.Reset()
+	err = artifacts(&out, client, ArtifactsOptions{BuildID: upload.BuildID, Names: []string{"missing"}})
+	if err == nil || !strings.Contains(err.Error(), `no artifact "missing"`) {
+		t.Errorf("expected unknown artifact error, got %v", err)
+	}
+}
+
+func TestDownloadArtifactRejectsUnsafeNames(t *testing.T) {
+	dir := t.TempDir()
+	client := &fakeArtifacts{list: []api.BuildArtifact{{Name: "../escape"}}, content: "x"}
+
+	err := artifacts(&bytes.Buffer{}, client, ArtifactsOptions{BuildID: "b", OutDir: filepath.Join(dir, "out")})
+	if err == nil || !strings.Contains(err.Error(), "unsafe name") {
+		t.Fatalf("expected unsafe name error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape")); !os.IsNotExist(err) {
+		t.Error("artifact escaped the output directory")
+	}
+}
+
+func TestDownloadArtifactChecksSize(t *testing.T) {
+	dir := t.TempDir()
+	client := &fakeArtifacts{list: []api.BuildArtifact{{Name: "Dockerfile", Size: 100}}, content: "short"}
+
+	err := artifacts(&bytes.Buffer{}, client, ArtifactsOptions{BuildID: "b", OutDir: dir})
+	if err == nil || !strings.Contains(err.Error(), "expected 100") {
+		t.Fatalf("expected size mismatch error, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("partial download left files behind: %v", entries)
+	}
+}
@@ -0,0 +1,111 @@
+// Package builds inspects server-side builds in cozy-hub.
+package builds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ListOptions contains the options for listing builds.
+type ListOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string // Only builds of this deployment (optional)
+	Limit        int
+	Output       ui.Output
+	Watch        time.Duration // Refresh interval; zero lists once
+}
+
+// List prints the most recent builds, or keeps the list refreshed with
+// opts.Watch until ctx is cancelled.
+func List(ctx context.Context, opts ListOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	if opts.Watch > 0 {
+		return watch(ctx, os.Stdout, client, opts)
+	}
+	return list(os.Stdout, client, opts)
+}
+
+func list(w io.Writer, client api.BuilderAPI, opts ListOptions) error {
+	builds, err := client.ListBuilds(opts.DeploymentID, opts.Limit)
+	if err != nil {
+		return fmt.Errorf("failed to list builds: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, builds)
+	}
+
+	if len(builds) == 0 {
+		fmt.Fprintln(w, "No builds found.")
+		return nil
+	}
+	return buildTable(builds, opts.Output == ui.OutputWide, time.Now()).Write(w)
+}
+
+func watch(ctx context.Context, w io.Writer, client api.BuilderAPI, opts ListOptions) error {
+	if opts.Output.Structured() {
+		return fmt.Errorf("--watch cannot be combined with --output %s", opts.Output)
+	}
+	return ui.Watch(ctx, w, opts.Watch, func() (*ui.Table, error) {
+		builds, err := client.ListBuilds(opts.DeploymentID, opts.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list builds: %w", err)
+		}
+		return buildTable(builds, opts.Output == ui.OutputWide, time.Now()), nil
+	})
+}
+
+// buildTable lays out builds as table rows keyed by build ID.
+func buildTable(builds []api.Build, wide bool, now time.Time) *ui.Table {
+	table := &ui.Table{Columns: []string{"ID", "DEPLOYMENT", "STATUS", "CREATED"}}
+	if wide {
+		table.Columns = append(table.Columns, "IMAGE", "ERROR")
+	}
+
+	for _, b := range builds {
+		cells := []string{b.ID, orDash(b.DeploymentID), b.Status, formatAge(b.CreatedAt, now)}
+		if wide {
+			cells = append(cells, orDash(b.ImageTag), orDash(b.ErrorMessage))
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: b.ID, Status: b.Status, Cells: cells})
+	}
+	return table
+}
+
+// formatAge shows an RFC 3339 timestamp as an age; unparseable values are shown as-is.
+func formatAge(ts string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return orDash(ts)
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,51 @@
+package builds
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestListBuilds(t *testing.T) {
+	srv := mockserver.New()
+	srv.BuildDuration = time.Hour
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	for _, deployment := range []string{"sdxl", "flux", "sdxl"} {
+		if _, err := client.UploadBuild(strings.NewReader("tarball"), deployment, api.BuildOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := list(&out, client, ListOptions{DeploymentID: "sdxl", Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 builds:\n%s", out.String())
+	}
+	// Newest first
+	if !strings.HasPrefix(lines[1], "build-0003") || !strings.HasPrefix(lines[2], "build-0001") {
+		t.Errorf("unexpected order:\n%s", out.String())
+	}
+	if !strings.Contains(lines[1], "running") {
+		t.Errorf("expected running status:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := list(&out, client, ListOptions{Limit: 1, Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"id": "build-0003"`) || strings.Contains(out.String(), "build-0002") {
+		t.Errorf("unexpected JSON:\n%s", out.String())
+	}
+}
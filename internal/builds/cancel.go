@@ -0,0 +1,189 @@
+package builds
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// pendingStatuses are the build states that can still be canceled.
+var pendingStatuses = []string{"pending", "queued", "running"}
+
+// maxCancelRounds bounds how often the build list is re-read while canceling
+// --all-pending, in case builds keep arriving or the server truncates the list.
+const maxCancelRounds = 5
+
+// CancelOptions contains the options for canceling builds.
+type CancelOptions struct {
+	Profile    config.ProfileRef
+	Transport  http.RoundTripper
+	BuildIDs   []string
+	AllPending bool   // Cancel every pending or running build
+	Deployment string // Scope AllPending to one deployment
+	Yes        bool   // Skip the confirmation for AllPending
+	DryRun     bool   // Show what would be canceled without canceling it
+}
+
+// Cancel cancels the given builds, or every pending build with AllPending.
+func Cancel(opts CancelOptions) (err error) {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		return previewCancel(os.Stdout, client, opts)
+	}
+
+	var canceled []string
+	recorder := history.Start(opts.Profile, "builds cancel")
+	defer func() {
+		if len(canceled) > 0 || err != nil {
+			recorder.Finish(map[string]string{"build_ids": strings.Join(canceled, ",")}, err)
+		}
+	}()
+
+	if opts.AllPending {
+		canceled, err = cancelPending(os.Stdin, os.Stdout, client, opts)
+		return err
+	}
+	canceled, err = cancelBuilds(os.Stdout, client, opts.BuildIDs)
+	return err
+}
+
+// cancelPending cancels pending builds until none are left, confirming first
+// unless opts.Yes. It returns the IDs of the builds it canceled.
+func cancelPending(in io.Reader, out io.Writer, client api.BuilderAPI, opts CancelOptions) ([]string, error) {
+	var canceled []string
+	attempted := map[string]bool{}
+
+	for round := 0; round < maxCancelRounds; round++ {
+		builds, err := client.ListBuilds(opts.Deployment, 0)
+		if err != nil {
+			return canceled, fmt.Errorf("failed to list builds: %w", err)
+		}
+
+		var pending []api.Build
+		var ids []string
+		for _, b := range builds {
+			if slices.Contains(pendingStatuses, b.Status) && !attempted[b.ID] {
+				pending = append(pending, b)
+				ids = append(ids, b.ID)
+			}
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		if round == 0 && !opts.Yes {
+			fmt.Fprintf(out, "Pending builds%s:\n", scopeLabel(opts.Deployment))
+			if err := buildTable(pending, false, time.Now()).Write(out); err != nil {
+				return nil, err
+			}
+			ok, err := ui.Confirm(in, out, fmt.Sprintf("Cancel %d pending build(s)?", len(ids)))
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				fmt.Fprintln(out, "Aborted.")
+				return nil, nil
+			}
+		}
+
+		for _, id := range ids {
+			attempted[id] = true
+		}
+		done, err := cancelBuilds(out, client, ids)
+		canceled = append(canceled, done...)
+		if err != nil {
+			return canceled, err
+		}
+	}
+
+	if len(canceled) == 0 && len(attempted) == 0 {
+		fmt.Fprintf(out, "No pending builds%s.\n", scopeLabel(opts.Deployment))
+	}
+	return canceled, nil
+}
+
+// previewCancel shows which builds Cancel would cancel, touching none.
+func previewCancel(out io.Writer, client api.BuilderAPI, opts CancelOptions) error {
+	if opts.AllPending {
+		builds, err := client.ListBuilds(opts.Deployment, 0)
+		if err != nil {
+			return fmt.Errorf("failed to list builds: %w", err)
+		}
+		var pending []api.Build
+		for _, b := range builds {
+			if slices.Contains(pendingStatuses, b.Status) {
+				pending = append(pending, b)
+			}
+		}
+		if len(pending) == 0 {
+			fmt.Fprintf(out, "No pending builds%s.\n", scopeLabel(opts.Deployment))
+			return nil
+		}
+		fmt.Fprintf(out, "Would cancel %d pending build(s)%s:\n", len(pending), scopeLabel(opts.Deployment))
+		if err := buildTable(pending, false, time.Now()).Write(out); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "\nDry run: nothing was canceled. Builds queued meanwhile would be canceled too.")
+		return nil
+	}
+
+	var errs []error
+	for _, id := range opts.BuildIDs {
+		status, err := client.GetBuildStatus(id)
+		switch {
+		case err != nil:
+			fmt.Fprintf(out, "Can't cancel %s: %v\n", id, err)
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+		case slices.Contains(pendingStatuses, status.Status):
+			fmt.Fprintf(out, "Would cancel %s (%s)\n", id, status.Status)
+		default:
+			fmt.Fprintf(out, "%s is already %s; nothing to cancel\n", id, status.Status)
+		}
+	}
+	fmt.Fprintln(out, "\nDry run: nothing was canceled.")
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to look up %d of %d build(s): %w", len(errs), len(opts.BuildIDs), errors.Join(errs...))
+	}
+	return nil
+}
+
+// cancelBuilds cancels each build, reporting failures together at the end.
+func cancelBuilds(out io.Writer, client api.BuilderAPI, ids []string) ([]string, error) {
+	var canceled []string
+	var errs []error
+	for _, id := range ids {
+		if _, err := client.CancelBuild(id); err != nil {
+			fmt.Fprintf(out, "Failed to cancel %s: %v\n", id, err)
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		fmt.Fprintf(out, "Canceled %s\n", id)
+		canceled = append(canceled, id)
+	}
+
+	if len(errs) > 0 {
+		return canceled, fmt.Errorf("failed to cancel %d of %d build(s): %w", len(errs), len(ids), errors.Join(errs...))
+	}
+	return canceled, nil
+}
+
+func scopeLabel(deployment string) string {
+	if deployment == "" {
+		return ""
+	}
+	return " for " + deployment
+}
@@ -0,0 +1,118 @@
+package builds
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+// newMockBuilds starts a mock server with one build per deployment, all still running.
+func newMockBuilds(t *testing.T, deployments ...string) *api.BuilderClient {
+	t.Helper()
+	srv := mockserver.New()
+	srv.BuildDuration = time.Hour
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+	for _, deployment := range deployments {
+		if _, err := client.UploadBuild(strings.NewReader("tarball"), deployment, api.BuildOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return client
+}
+
+func TestCancelPendingScopedToDeployment(t *testing.T) {
+	client := newMockBuilds(t, "sdxl", "flux", "sdxl")
+
+	var out bytes.Buffer
+	canceled, err := cancelPending(strings.NewReader("y\n"), &out, client, CancelOptions{Deployment: "sdxl"})
+	if err != nil {
+		t.Fatalf("cancelPending: %v\n%s", err, out.String())
+	}
+	if len(canceled) != 2 {
+		t.Fatalf("expected 2 canceled builds, got %v\n%s", canceled, out.String())
+	}
+	if !strings.Contains(out.String(), "Cancel 2 pending build(s)?") {
+		t.Errorf("expected confirmation prompt:\n%s", out.String())
+	}
+
+	builds, err := client.ListBuilds("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range builds {
+		want := "canceled"
+		if b.DeploymentID == "flux" {
+			want = "running"
+		}
+		if b.Status != want {
+			t.Errorf("build %s (%s) is %s, want %s", b.ID, b.DeploymentID, b.Status, want)
+		}
+	}
+}
+
+func TestCancelPendingDeclined(t *testing.T) {
+	client := newMockBuilds(t, "sdxl")
+
+	canceled, err := cancelPending(strings.NewReader("n\n"), &bytes.Buffer{}, client, CancelOptions{})
+	if err != nil || len(canceled) != 0 {
+		t.Fatalf("expected nothing canceled, got %v, %v", canceled, err)
+	}
+	if builds, _ := client.ListBuilds("", 0); builds[0].Status != "running" {
+		t.Errorf("build was canceled after declining: %+v", builds[0])
+	}
+}
+
+func TestCancelBuildsReportsFailures(t *testing.T) {
+	client := newMockBuilds(t, "sdxl")
+
+	var out bytes.Buffer
+	canceled, err := cancelBuilds(&out, client, []string{"build-0001", "build-0001", "missing"})
+	if len(canceled) != 1 {
+		t.Errorf("expected one build canceled, got %v", canceled)
+	}
+	if err == nil || !strings.Contains(err.Error(), "failed to cancel 2 of 3") {
+		t.Errorf("expected an aggregated error, got %v", err)
+	}
+}
+
+func TestPreviewCancelTouchesNothing(t *testing.T) {
+	client := newMockBuilds(t, "sdxl", "flux")
+	builds, err := client.ListBuilds("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := previewCancel(&out, client, CancelOptions{AllPending: true, Deployment: "sdxl"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Would cancel 1 pending build(s) for sdxl") || !strings.Contains(out.String(), "Dry run") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := previewCancel(&out, client, CancelOptions{BuildIDs: []string{builds[0].ID}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Would cancel "+builds[0].ID) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	for _, b := range builds {
+		status, err := client.GetBuildStatus(b.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Status == "canceled" {
+			t.Errorf("dry run canceled %s", b.ID)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package builds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+)
+
+// followInterval is how often a followed build is polled for new lines.
+const followInterval = 2 * time.Second
+
+// LogsOptions contains the options for printing a build's logs.
+type LogsOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	BuildID   string
+	Follow    bool // Keep printing new lines until the build finishes
+}
+
+// Logs prints a build's logs. With Follow, new lines are printed as they
+// arrive until the build finishes or ctx is cancelled; the build itself is
+// not affected by stopping.
+func Logs(ctx context.Context, opts LogsOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return logs(ctx, os.Stdout, client, opts)
+}
+
+func logs(ctx context.Context, w io.Writer, client api.BuilderAPI, opts LogsOptions) error {
+	var afterID int64
+	for {
+		resp, err := client.GetBuildLogs(opts.BuildID, afterID, logPageSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to get logs: %w", err)
+		}
+		for _, l := range resp.Logs {
+			afterID = max(afterID, l.ID)
+			fmt.Fprintln(w, formatLogLine(l))
+		}
+		if len(resp.Logs) == logPageSize {
+			continue
+		}
+		if !opts.Follow {
+			return nil
+		}
+
+		status, err := client.GetBuildStatus(opts.BuildID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to get build status: %w", err)
+		}
+		if finished(status.Status) {
+			// Lines logged between the last page and the status check
+			resp, err := client.GetBuildLogs(opts.BuildID, afterID, 0)
+			if err == nil {
+				for _, l := range resp.Logs {
+					fmt.Fprintln(w, formatLogLine(l))
+				}
+			}
+			fmt.Fprintf(w, "Build %s %s\n", opts.BuildID, status.Status)
+			return nil
+		}
+		if interrupt.Sleep(ctx, followInterval) != nil {
+			return nil
+		}
+	}
+}
+
+// finished reports whether a build status is final.
+func finished(status string) bool {
+	switch status {
+	case "success", "succeeded", "failed", "canceled":
+		return true
+	}
+	return false
+}
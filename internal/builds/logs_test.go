@@ -0,0 +1,66 @@
+package builds
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestLogs(t *testing.T) {
+	srv := mockserver.New()
+	srv.BuildDuration = time.Millisecond
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	upload, err := client.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	var out bytes.Buffer
+	if err := logs(context.Background(), &out, client, LogsOptions{BuildID: upload.BuildID}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Build succeeded") || strings.Contains(out.String(), "Build "+upload.BuildID+" success") {
+		t.Errorf("expected the logs without a final status:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := logs(context.Background(), &out, client, LogsOptions{BuildID: upload.BuildID, Follow: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out.String(), "Build "+upload.BuildID+" success\n") {
+		t.Errorf("expected following a finished build to end with its status:\n%s", out.String())
+	}
+}
+
+func TestLogsFollowStopsOnCancel(t *testing.T) {
+	srv := mockserver.New()
+	srv.BuildDuration = time.Hour
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	upload, err := client.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	if err := logs(ctx, &out, client, LogsOptions{BuildID: upload.BuildID, Follow: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Building image") {
+		t.Errorf("expected the lines logged so far:\n%s", out.String())
+	}
+}
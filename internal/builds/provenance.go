@@ -0,0 +1,182 @@
+package builds
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ProvenanceOptions contains the options for retrieving build provenance.
+type ProvenanceOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	BuildID   string
+	Dir       string // Project checkout to check the source digest against (optional)
+	Output    ui.Output
+}
+
+// provenanceCheck is the outcome of one verification step.
+type provenanceCheck struct {
+	name   string
+	ok     bool
+	detail string
+}
+
+// Provenance prints the provenance statement of a build and verifies it
+// against the build record and, optionally, a source checkout.
+func Provenance(opts ProvenanceOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return provenance(os.Stdout, client, opts)
+}
+
+func provenance(out io.Writer, client api.BuilderAPI, opts ProvenanceOptions) error {
+	statement, err := client.GetBuildProvenance(opts.BuildID)
+	if err != nil {
+		return fmt.Errorf("failed to get provenance: %w", err)
+	}
+	if statement == nil {
+		return fmt.Errorf("build %s has no provenance (it is recorded when 'cozyctl build' finishes)", opts.BuildID)
+	}
+
+	status, err := client.GetBuildStatus(opts.BuildID)
+	if err != nil {
+		return fmt.Errorf("failed to get build %s: %w", opts.BuildID, err)
+	}
+
+	sourceDigest := ""
+	if opts.Dir != "" {
+		if sourceDigest, err = build.SourceDigest(opts.Dir); err != nil {
+			return err
+		}
+	}
+	checks := verifyProvenance(statement, status, sourceDigest)
+
+	if opts.Output.Structured() {
+		if err := ui.WriteStructured(out, opts.Output, statement); err != nil {
+			return err
+		}
+	} else {
+		writeProvenance(out, statement)
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Verification:")
+		for _, c := range checks {
+			mark := "✓"
+			if !c.ok {
+				mark = "✗"
+			}
+			fmt.Fprintf(out, "  %s %s: %s\n", mark, c.name, c.detail)
+		}
+	}
+
+	failed := 0
+	for _, c := range checks {
+		if !c.ok {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("provenance of build %s failed %d of %d checks", opts.BuildID, failed, len(checks))
+	}
+	return nil
+}
+
+// verifyProvenance checks that a statement is well-formed SLSA provenance that
+// describes the given build. The statement is not signed, so this checks
+// consistency with the build record rather than authenticity.
+func verifyProvenance(statement *api.ProvenanceStatement, status *api.BuildStatusResponse, sourceDigest string) []provenanceCheck {
+	var checks []provenanceCheck
+	add := func(name string, ok bool, detail string) {
+		checks = append(checks, provenanceCheck{name: name, ok: ok, detail: detail})
+	}
+
+	add("statement type", statement.Type == build.StatementType, statement.Type)
+	add("predicate type", statement.PredicateType == build.ProvenancePredicateType, statement.PredicateType)
+
+	invocation := statement.Predicate.RunDetails.Metadata.InvocationID
+	add("build ID", invocation == status.ID, fmt.Sprintf("invocation %s, build %s", orDash(invocation), status.ID))
+
+	switch {
+	case len(statement.Subject) != 1:
+		add("image", false, fmt.Sprintf("expected 1 subject, found %d", len(statement.Subject)))
+	case statement.Subject[0].Name != status.ImageTag:
+		add("image", false, fmt.Sprintf("statement names %s, build produced %s", orDash(statement.Subject[0].Name), orDash(status.ImageTag)))
+	case status.ImageDigest != "" && formatDigest(statement.Subject[0].Digest) != status.ImageDigest:
+		add("image", false, fmt.Sprintf("digest %s, build reports %s", orDash(formatDigest(statement.Subject[0].Digest)), status.ImageDigest))
+	default:
+		add("image", true, joinNonEmpty(status.ImageTag, formatDigest(statement.Subject[0].Digest)))
+	}
+
+	builder := statement.Predicate.RunDetails.Builder.ID
+	if status.BuilderID != "" {
+		add("builder", builder == status.BuilderID, fmt.Sprintf("statement names %s, build reports %s", orDash(builder), status.BuilderID))
+	}
+
+	if sourceDigest != "" {
+		recorded := ""
+		for _, dep := range statement.Predicate.BuildDefinition.ResolvedDependencies {
+			if dep.Name == build.SourceDependency {
+				recorded = formatDigest(dep.Digest)
+			}
+		}
+		add("source", recorded == sourceDigest, fmt.Sprintf("recorded %s, local %s", orDash(recorded), sourceDigest))
+	}
+
+	return checks
+}
+
+func writeProvenance(out io.Writer, statement *api.ProvenanceStatement) {
+	predicate := statement.Predicate
+	fmt.Fprintf(out, "Build:      %s\n", orDash(predicate.RunDetails.Metadata.InvocationID))
+	fmt.Fprintf(out, "Build type: %s\n", orDash(predicate.BuildDefinition.BuildType))
+	fmt.Fprintf(out, "Builder:    %s\n", orDash(predicate.RunDetails.Builder.ID))
+	if started, finished := predicate.RunDetails.Metadata.StartedOn, predicate.RunDetails.Metadata.FinishedOn; started != "" || finished != "" {
+		fmt.Fprintf(out, "Ran:        %s to %s\n", orDash(started), orDash(finished))
+	}
+	for _, subject := range statement.Subject {
+		fmt.Fprintf(out, "Image:      %s\n", joinNonEmpty(subject.Name, formatDigest(subject.Digest)))
+	}
+
+	if params := predicate.BuildDefinition.ExternalParameters; len(params) > 0 {
+		fmt.Fprintln(out, "Parameters:")
+		for _, key := range slices.Sorted(maps.Keys(params)) {
+			fmt.Fprintf(out, "  %s: %v\n", key, params[key])
+		}
+	}
+	if deps := predicate.BuildDefinition.ResolvedDependencies; len(deps) > 0 {
+		fmt.Fprintln(out, "Dependencies:")
+		for _, dep := range deps {
+			name := dep.Name
+			if name == "" {
+				name = dep.URI
+			}
+			fmt.Fprintf(out, "  %s\n", joinNonEmpty(name, formatDigest(dep.Digest)))
+		}
+	}
+}
+
+// formatDigest renders an in-toto digest set as "algorithm:hex", preferring sha256.
+func formatDigest(digest map[string]string) string {
+	if hex, ok := digest["sha256"]; ok {
+		return "sha256:" + hex
+	}
+	for _, algorithm := range slices.Sorted(maps.Keys(digest)) {
+		return algorithm + ":" + digest[algorithm]
+	}
+	return ""
+}
+
+func joinNonEmpty(parts ...string) string {
+	return strings.Join(slices.DeleteFunc(parts, func(s string) bool { return s == "" }), " ")
+}
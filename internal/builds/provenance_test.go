@@ -0,0 +1,102 @@
+package builds
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestProvenance(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	dir := t.TempDir()
+	pyproject := "[project]\nname = \"demo\"\n\n[tool.cozy]\ndeployment-id = \"demo\"\npython = \"3.12\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.py"), []byte("print('hi')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	progress := ui.NewWithMode(io.Discard, ui.ModePlain)
+	buildID, err := build.SubmitBuild(context.Background(), progress, client, dir, "demo", api.BuildOptions{})
+	progress.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := provenance(&out, client, ProvenanceOptions{BuildID: buildID, Dir: dir}); err != nil {
+		t.Fatalf("provenance: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"Builder:    " + mockserver.MockBuilderID, "python: 3.12", "✓ image: registry.mock/", "✓ source: "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// Changing the checkout no longer matches the recorded source
+	if err := os.WriteFile(filepath.Join(dir, "main.py"), []byte("print('bye')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	err = provenance(&out, client, ProvenanceOptions{BuildID: buildID, Dir: dir})
+	if err == nil || !strings.Contains(err.Error(), "failed 1 of") || !strings.Contains(out.String(), "✗ source") {
+		t.Errorf("expected a source mismatch, got %v\n%s", err, out.String())
+	}
+
+	out.Reset()
+	if err := provenance(&out, client, ProvenanceOptions{BuildID: buildID, Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"predicateType": "https://slsa.dev/provenance/v1"`) {
+		t.Errorf("expected the raw statement, got:\n%s", out.String())
+	}
+}
+
+func TestVerifyProvenanceMismatch(t *testing.T) {
+	statement := build.NewProvenance(build.ProvenanceInput{
+		BuildID:     "build-1",
+		ImageTag:    "registry/demo:build-1",
+		ImageDigest: "sha256:aaa",
+		BuilderID:   "https://builder.example",
+	})
+	status := &api.BuildStatusResponse{ID: "build-2", ImageTag: "registry/demo:build-1", ImageDigest: "sha256:bbb", BuilderID: "https://builder.example"}
+
+	failed := map[string]bool{}
+	for _, c := range verifyProvenance(statement, status, "") {
+		if !c.ok {
+			failed[c.name] = true
+		}
+	}
+	if !failed["build ID"] || !failed["image"] || failed["builder"] || len(failed) != 2 {
+		t.Errorf("expected build ID and image to fail, got %v", failed)
+	}
+}
+
+func TestProvenanceMissing(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+	upload, err := client.UploadBuild(strings.NewReader("tarball"), "demo", api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = provenance(io.Discard, client, ProvenanceOptions{BuildID: upload.BuildID})
+	if err == nil || !strings.Contains(err.Error(), "has no provenance") {
+		t.Errorf("expected missing provenance error, got %v", err)
+	}
+}
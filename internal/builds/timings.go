@@ -0,0 +1,401 @@
+package builds
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// DefaultTimingsLast is how many builds the timings report covers.
+const DefaultTimingsLast = 20
+
+// Build phases, in order. Packaging and uploading are one phase because the
+// source is compressed while it streams.
+const (
+	PhasePackage = "package & upload"
+	PhaseQueue   = "queue wait"
+	PhaseBuild   = "image build"
+	PhasePush    = "push"
+	PhaseDeploy  = "deploy"
+)
+
+var buildPhaseNames = []string{PhasePackage, PhaseQueue, PhaseBuild, PhasePush, PhaseDeploy}
+
+// Progress stages of build and deploy that aren't part of the deploy phase:
+// packaging and uploading are their own phase (packaged ahead of the upload
+// by 'deploy --queue'), and waiting for a server build is measured by the
+// builder.
+var packageStages = []string{"Packaging & uploading", "Packaging", "Uploading"}
+
+const stageBuild = "Building"
+
+// bigShare is the share of the total time, in percent, above which a phase
+// gets a suggestion.
+const bigShare = 40
+
+// TimingsOptions contains the options for reporting build timings.
+type TimingsOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	BuildID      string // One build; empty for the report over the last builds
+	DeploymentID string // Only builds of this deployment (report only)
+	Last         int    // Builds in the report
+	Output       ui.Output
+}
+
+// BuildPhase is how long one phase of a build took. The builder measures
+// queue wait, image build, and push; packaging and deploying are measured
+// by the cozyctl that ran them, so they are only known for builds submitted
+// or deployed from this machine.
+type BuildPhase struct {
+	Name       string `json:"name"`
+	Measured   bool   `json:"measured"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// BuildTimings are the phases of one build.
+type BuildTimings struct {
+	BuildID string       `json:"build_id"`
+	Status  string       `json:"status"`
+	Machine string       `json:"machine,omitempty"`
+	TotalMS int64        `json:"total_ms"`
+	Phases  []BuildPhase `json:"phases"`
+}
+
+// PhaseStats aggregates one phase over the builds it was measured for.
+type PhaseStats struct {
+	Name     string `json:"name"`
+	Builds   int    `json:"builds"`
+	TotalMS  int64  `json:"total_ms"`
+	MeanMS   int64  `json:"mean_ms"`
+	MedianMS int64  `json:"median_ms"`
+	MaxMS    int64  `json:"max_ms"`
+}
+
+// TimingsReport shows where time goes across recent builds.
+type TimingsReport struct {
+	Builds      []BuildTimings `json:"builds"`
+	TotalMS     int64          `json:"total_ms"`
+	Phases      []PhaseStats   `json:"phases"`
+	Suggestions []string       `json:"suggestions"`
+}
+
+// Timings prints the phases of one build, or with no build ID, a report of
+// where time goes across the last builds.
+func Timings(opts TimingsOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	entries, err := history.Read(opts.Profile)
+	if err != nil {
+		return err
+	}
+	return timings(os.Stdout, client, entries, opts)
+}
+
+func timings(w io.Writer, client api.BuilderAPI, entries []history.Entry, opts TimingsOptions) error {
+	if opts.BuildID != "" {
+		status, err := client.GetBuildStatus(opts.BuildID)
+		if err != nil {
+			return fmt.Errorf("failed to get build %s: %w", opts.BuildID, err)
+		}
+		b := api.Build{
+			ID:         status.ID,
+			Status:     status.Status,
+			Machine:    status.Machine,
+			CreatedAt:  status.CreatedAt,
+			StartedAt:  status.StartedAt,
+			FinishedAt: status.CompletedAt,
+		}
+		t, err := measureBuild(client, b, entries)
+		if err != nil {
+			return err
+		}
+		if opts.Output.Structured() {
+			return ui.WriteStructured(w, opts.Output, t)
+		}
+		writeBuildTimings(w, t)
+		return nil
+	}
+
+	if opts.Last <= 0 {
+		return fmt.Errorf("--last must be positive")
+	}
+	list, err := client.ListBuilds(opts.DeploymentID, opts.Last)
+	if err != nil {
+		return fmt.Errorf("failed to list builds: %w", err)
+	}
+	report := TimingsReport{Builds: []BuildTimings{}}
+	for _, b := range list {
+		t, err := measureBuild(client, b, entries)
+		if err != nil {
+			return err
+		}
+		report.Builds = append(report.Builds, t)
+	}
+	report.Phases, report.TotalMS = aggregatePhases(report.Builds)
+	report.Suggestions = timingsSuggestions(report.Phases, report.TotalMS)
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, report)
+	}
+	writeTimingsReport(w, report)
+	return nil
+}
+
+// measureBuild fetches a build's logs and works out its phases.
+func measureBuild(client api.BuilderAPI, b api.Build, entries []history.Entry) (BuildTimings, error) {
+	var logs []api.BuildLog
+	var afterID int64
+	for {
+		resp, err := client.GetBuildLogs(b.ID, afterID, logPageSize)
+		if err != nil {
+			return BuildTimings{}, fmt.Errorf("failed to get logs of %s: %w", b.ID, err)
+		}
+		for _, l := range resp.Logs {
+			afterID = max(afterID, l.ID)
+		}
+		logs = append(logs, resp.Logs...)
+		if len(resp.Logs) < logPageSize {
+			break
+		}
+	}
+
+	t := BuildTimings{BuildID: b.ID, Status: b.Status, Machine: b.Machine, Phases: buildPhases(b, logs, entries)}
+	for _, p := range t.Phases {
+		t.TotalMS += p.DurationMS
+	}
+	return t, nil
+}
+
+// buildPhases splits a build into phases. The builder's timestamps give the
+// queue wait and, split at the first "push" log line, the image build and
+// push; the local history gives packaging and deploying.
+func buildPhases(b api.Build, logs []api.BuildLog, entries []history.Entry) []BuildPhase {
+	phases := map[string]BuildPhase{}
+	measured := func(name string, d time.Duration) {
+		phases[name] = BuildPhase{Name: name, Measured: true, DurationMS: max(d, 0).Milliseconds()}
+	}
+
+	created, okCreated := parseTime(b.CreatedAt)
+	started, okStarted := parseTimePtr(b.StartedAt)
+	finished, okFinished := parseTimePtr(b.FinishedAt)
+	if okCreated && okStarted {
+		measured(PhaseQueue, started.Sub(created))
+	}
+	if okStarted && okFinished {
+		pushAt, ok := time.Time{}, false
+		for _, l := range logs {
+			if l.Phase == "push" {
+				pushAt, ok = parseTime(l.TS)
+				break
+			}
+		}
+		if ok {
+			pushAt = clampTime(pushAt, started, finished)
+			measured(PhaseBuild, pushAt.Sub(started))
+			measured(PhasePush, finished.Sub(pushAt))
+		} else {
+			measured(PhaseBuild, finished.Sub(started))
+		}
+	}
+
+	// The latest run of each local phase wins, e.g. a redeploy of the build
+	for _, e := range entries {
+		if e.IDs["build_id"] != b.ID {
+			continue
+		}
+		var pkg, deploy time.Duration
+		packaged, deployed := false, false
+		for _, s := range e.Stages {
+			d := time.Duration(s.DurationMS) * time.Millisecond
+			switch {
+			case slices.Contains(packageStages, s.Name):
+				pkg += d
+				packaged = true
+			case s.Name == stageBuild:
+			default:
+				deploy += d
+				deployed = true
+			}
+		}
+		if packaged {
+			measured(PhasePackage, pkg)
+		}
+		if deployed {
+			measured(PhaseDeploy, deploy)
+		}
+	}
+
+	out := make([]BuildPhase, 0, len(buildPhaseNames))
+	for _, name := range buildPhaseNames {
+		p, ok := phases[name]
+		if !ok {
+			p = BuildPhase{Name: name}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// aggregatePhases summarizes each phase over the builds it was measured
+// for, and returns the time of all measured phases.
+func aggregatePhases(builds []BuildTimings) ([]PhaseStats, int64) {
+	var stats []PhaseStats
+	var total int64
+	for i, name := range buildPhaseNames {
+		var durations []int64
+		for _, b := range builds {
+			if p := b.Phases[i]; p.Measured {
+				durations = append(durations, p.DurationMS)
+			}
+		}
+		s := PhaseStats{Name: name, Builds: len(durations)}
+		if len(durations) > 0 {
+			slices.Sort(durations)
+			for _, d := range durations {
+				s.TotalMS += d
+			}
+			s.MeanMS = s.TotalMS / int64(len(durations))
+			s.MedianMS = durations[len(durations)/2]
+			if len(durations)%2 == 0 {
+				s.MedianMS = (durations[len(durations)/2-1] + durations[len(durations)/2]) / 2
+			}
+			s.MaxMS = durations[len(durations)-1]
+		}
+		total += s.TotalMS
+		stats = append(stats, s)
+	}
+	return stats, total
+}
+
+// timingsSuggestions suggests where to save time for the phases that take
+// the biggest share of build time.
+func timingsSuggestions(stats []PhaseStats, totalMS int64) []string {
+	suggestions := []string{}
+	if totalMS == 0 {
+		return suggestions
+	}
+	for _, s := range stats {
+		share := s.TotalMS * 100 / totalMS
+		if share < bigShare {
+			continue
+		}
+		switch s.Name {
+		case PhasePackage:
+			suggestions = append(suggestions, fmt.Sprintf("Packaging and uploading takes %d%% of the time: leave data and weights out of the source with [tool.cozy] exclude, and publish weights with 'cozyctl models push'.", share))
+		case PhaseQueue:
+			suggestions = append(suggestions, fmt.Sprintf("Waiting for a builder takes %d%% of the time: submit urgent builds with --priority high, and lower the priority of background ones.", share))
+		case PhaseBuild:
+			suggestions = append(suggestions, fmt.Sprintf("Building the image takes %d%% of the time: a larger --build-machine compiles dependencies faster, and leaving dependencies unchanged between builds lets the builder reuse its layer cache.", share))
+		case PhasePush:
+			suggestions = append(suggestions, fmt.Sprintf("Pushing the image takes %d%% of the time: trim dependencies and build-only files to slim it (see 'cozyctl images inspect').", share))
+		case PhaseDeploy:
+			suggestions = append(suggestions, fmt.Sprintf("Deploying takes %d%% of the time, mostly waiting for new workers to start: see where with 'cozyctl coldstart'.", share))
+		}
+	}
+	return suggestions
+}
+
+func writeBuildTimings(w io.Writer, t BuildTimings) {
+	header := fmt.Sprintf("Build %s (%s", t.BuildID, t.Status)
+	if t.Machine != "" {
+		header += ", " + t.Machine + " machine"
+	}
+	fmt.Fprintf(w, "%s): %s measured\n\n", header, formatMS(t.TotalMS))
+
+	table := &ui.Table{Columns: []string{"PHASE", "TIME", "SHARE"}}
+	for _, p := range t.Phases {
+		took, share := "-", "-"
+		if p.Measured {
+			took = formatMS(p.DurationMS)
+			share = formatShare(p.DurationMS, t.TotalMS)
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: p.Name, Cells: []string{p.Name, took, share}})
+	}
+	table.Write(w)
+
+	if !t.Phases[0].Measured || !t.Phases[len(t.Phases)-1].Measured {
+		fmt.Fprintln(w, "\nPackaging and deploying are only measured for builds submitted or deployed from this machine.")
+	}
+}
+
+func writeTimingsReport(w io.Writer, r TimingsReport) {
+	if len(r.Builds) == 0 {
+		fmt.Fprintln(w, "No builds found.")
+		return
+	}
+	fmt.Fprintf(w, "Where time goes across the last %d builds (%s measured)\n\n", len(r.Builds), formatMS(r.TotalMS))
+
+	// The phase with the biggest share is marked
+	slowest := ""
+	var most int64
+	for _, s := range r.Phases {
+		if s.TotalMS > most {
+			slowest, most = s.Name, s.TotalMS
+		}
+	}
+
+	table := &ui.Table{Columns: []string{"PHASE", "BUILDS", "MEAN", "MEDIAN", "MAX", "SHARE"}}
+	for _, s := range r.Phases {
+		cells := []string{s.Name, fmt.Sprint(s.Builds), "-", "-", "-", "-"}
+		if s.Builds > 0 {
+			cells[2], cells[3], cells[4] = formatMS(s.MeanMS), formatMS(s.MedianMS), formatMS(s.MaxMS)
+			cells[5] = formatShare(s.TotalMS, r.TotalMS)
+		}
+		if s.Name == slowest {
+			cells[5] += " <- most time"
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: s.Name, Cells: cells})
+	}
+	table.Write(w)
+
+	if len(r.Suggestions) > 0 {
+		fmt.Fprintln(w, "\nSuggestions:")
+		for _, s := range r.Suggestions {
+			fmt.Fprintf(w, "  - %s\n", s)
+		}
+	}
+}
+
+func formatMS(ms int64) string {
+	return ui.FormatDuration(time.Duration(ms) * time.Millisecond)
+}
+
+func formatShare(part, total int64) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", part*100/total)
+}
+
+func parseTime(s string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, s)
+	return t, err == nil
+}
+
+func parseTimePtr(s *string) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	return parseTime(*s)
+}
+
+func clampTime(t, lo, hi time.Time) time.Time {
+	if t.Before(lo) {
+		return lo
+	}
+	if t.After(hi) {
+		return hi
+	}
+	return t
+}
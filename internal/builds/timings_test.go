@@ -0,0 +1,132 @@
+package builds
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestBuildPhases(t *testing.T) {
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	at := func(s int) string { return start.Add(time.Duration(s) * time.Second).Format(time.RFC3339) }
+	started, finished := at(30), at(150)
+	b := api.Build{ID: "b-1", Status: "success", CreatedAt: at(0), StartedAt: &started, FinishedAt: &finished}
+	logs := []api.BuildLog{
+		{Phase: "build", TS: at(30)},
+		{Phase: "push", TS: at(130)},
+		{Phase: "push", TS: at(150)},
+	}
+	entries := []history.Entry{
+		{Command: "build", IDs: map[string]string{"build_id": "b-1"}, Stages: []history.Stage{
+			{Name: "Packaging & uploading", DurationMS: 4000},
+			{Name: "Building", DurationMS: 150000},
+		}},
+		{Command: "deploy", IDs: map[string]string{"build_id": "other"}, Stages: []history.Stage{{Name: "Deploying", DurationMS: 99000}}},
+		{Command: "deploy", IDs: map[string]string{"build_id": "b-1"}, Stages: []history.Stage{
+			{Name: "Verifying build", DurationMS: 1000},
+			{Name: "Deploying", DurationMS: 9000},
+		}},
+	}
+
+	phases := buildPhases(b, logs, entries)
+	want := []BuildPhase{
+		{Name: PhasePackage, Measured: true, DurationMS: 4000},
+		{Name: PhaseQueue, Measured: true, DurationMS: 30000},
+		{Name: PhaseBuild, Measured: true, DurationMS: 100000},
+		{Name: PhasePush, Measured: true, DurationMS: 20000},
+		{Name: PhaseDeploy, Measured: true, DurationMS: 10000},
+	}
+	if len(phases) != len(want) {
+		t.Fatalf("got %+v", phases)
+	}
+	for i := range want {
+		if phases[i] != want[i] {
+			t.Errorf("phase %d = %+v, want %+v", i, phases[i], want[i])
+		}
+	}
+
+	// Without push lines or local history, only the builder's phases are known
+	phases = buildPhases(b, nil, nil)
+	if !phases[2].Measured || phases[2].DurationMS != 120000 || phases[3].Measured || phases[0].Measured || phases[4].Measured {
+		t.Errorf("unexpected phases without logs or history: %+v", phases)
+	}
+}
+
+func TestAggregatePhases(t *testing.T) {
+	timed := func(ms ...int64) BuildTimings {
+		var bt BuildTimings
+		for i, name := range buildPhaseNames {
+			bt.Phases = append(bt.Phases, BuildPhase{Name: name, Measured: ms[i] >= 0, DurationMS: max(ms[i], 0)})
+		}
+		return bt
+	}
+	builds := []BuildTimings{
+		timed(-1, 1000, 60000, 5000, -1),
+		timed(2000, 3000, 80000, 5000, 10000),
+		timed(-1, 2000, 100000, 5000, -1),
+	}
+
+	stats, total := aggregatePhases(builds)
+	if total != 273000 {
+		t.Errorf("total = %d", total)
+	}
+	if s := stats[2]; s.Builds != 3 || s.MeanMS != 80000 || s.MedianMS != 80000 || s.MaxMS != 100000 {
+		t.Errorf("image build stats = %+v", s)
+	}
+	if s := stats[0]; s.Builds != 1 || s.TotalMS != 2000 {
+		t.Errorf("package stats = %+v", s)
+	}
+
+	suggestions := strings.Join(timingsSuggestions(stats, total), "\n")
+	if !strings.Contains(suggestions, "--build-machine") || strings.Contains(suggestions, "--priority") {
+		t.Errorf("unexpected suggestions:\n%s", suggestions)
+	}
+}
+
+func TestTimingsAgainstMockServer(t *testing.T) {
+	srv := mockserver.New()
+	srv.BuildDuration = time.Millisecond
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	upload, err := client.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	buildID := upload.BuildID
+
+	entries := []history.Entry{{Command: "build", IDs: map[string]string{"build_id": buildID}, Stages: []history.Stage{
+		{Name: "Packaging & uploading", DurationMS: 1500},
+	}}}
+
+	var out bytes.Buffer
+	if err := timings(&out, client, entries, TimingsOptions{BuildID: buildID}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Build " + buildID + " (success", "package & upload", "1.5s", "queue wait", "push"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := timings(&out, client, entries, TimingsOptions{Last: 20, Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"build_id": "`+buildID+`"`) || !strings.Contains(out.String(), `"suggestions"`) {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+
+	if err := timings(&out, client, nil, TimingsOptions{}); err == nil {
+		t.Error("expected an error without a build ID or --last")
+	}
+}
@@ -0,0 +1,180 @@
+package builds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Defaults for the build board.
+const (
+	DefaultWatchLimit = 5
+	DefaultTailLines  = 10
+)
+
+// logPageSize is how many log lines are fetched per request while catching
+// up on the newest build.
+const logPageSize = 500
+
+// WatchOptions contains the options for watching a deployment's builds.
+type WatchOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Limit        int           // Builds shown
+	Interval     time.Duration // Refresh interval
+	Tail         int           // Log lines of the newest build shown
+}
+
+// Watch shows a deployment's recent builds with their elapsed times and
+// the last log lines of the newest build, refreshed until ctx is cancelled.
+func Watch(ctx context.Context, opts WatchOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return watchBoard(ctx, os.Stdout, client, opts)
+}
+
+func watchBoard(ctx context.Context, w io.Writer, client api.BuilderAPI, opts WatchOptions) error {
+	if opts.DeploymentID == "" {
+		return fmt.Errorf("a deployment is required")
+	}
+	if opts.Interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	view := ui.NewLiveView(w, fmt.Sprintf("Builds of %s, press Ctrl+C to stop", opts.DeploymentID))
+	tail := &logTail{size: opts.Tail}
+
+	// An error on the first refresh is returned; later ones are shown and
+	// the board keeps refreshing, like ui.Watch
+	table, footer, err := refreshBoard(client, tail, opts, time.Now())
+	if err != nil {
+		return err
+	}
+	view.DrawWithFooter(table, footer)
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next, footer, err := refreshBoard(client, tail, opts, time.Now())
+		if err != nil {
+			view.DrawWithFooter(table, fmt.Sprintf("error: %v", err))
+			continue
+		}
+		table = next
+		view.DrawWithFooter(table, footer)
+	}
+}
+
+// refreshBoard fetches the deployment's builds and the newest build's new
+// log lines, and renders them.
+func refreshBoard(client api.BuilderAPI, tail *logTail, opts WatchOptions, now time.Time) (*ui.Table, string, error) {
+	builds, err := client.ListBuilds(opts.DeploymentID, opts.Limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list builds: %w", err)
+	}
+
+	table := &ui.Table{Columns: []string{"ID", "STATUS", "ELAPSED", "CREATED"}}
+	for _, b := range builds {
+		table.Rows = append(table.Rows, ui.Row{Key: b.ID, Status: b.Status, Cells: []string{
+			b.ID, b.Status, formatElapsed(b, now), formatAge(b.CreatedAt, now),
+		}})
+	}
+	if len(builds) == 0 {
+		return table, "No builds yet.", nil
+	}
+
+	if tail.size <= 0 {
+		return table, "", nil
+	}
+	newest := builds[0]
+	if err := tail.update(client, newest.ID); err != nil {
+		return nil, "", err
+	}
+	return table, tail.render(newest), nil
+}
+
+// formatElapsed shows how long a build ran, or has been running or queued.
+func formatElapsed(b api.Build, now time.Time) string {
+	from := b.CreatedAt
+	if b.StartedAt != nil && *b.StartedAt != "" {
+		from = *b.StartedAt
+	}
+	start, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return "-"
+	}
+	end := now
+	if b.FinishedAt != nil && *b.FinishedAt != "" {
+		if t, err := time.Parse(time.RFC3339, *b.FinishedAt); err == nil {
+			end = t
+		}
+	}
+	return max(end.Sub(start), 0).Round(time.Second).String()
+}
+
+// logTail keeps the last size log lines of one build, fetching only the
+// lines after the last one seen. It starts over when a newer build appears.
+type logTail struct {
+	size    int
+	buildID string
+	afterID int64
+	lines   []string
+}
+
+func (t *logTail) update(client api.BuilderAPI, buildID string) error {
+	if buildID != t.buildID {
+		*t = logTail{size: t.size, buildID: buildID}
+	}
+	for {
+		resp, err := client.GetBuildLogs(buildID, t.afterID, logPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to get logs of %s: %w", buildID, err)
+		}
+		for _, l := range resp.Logs {
+			t.afterID = max(t.afterID, l.ID)
+			t.lines = append(t.lines, formatLogLine(l))
+		}
+		if len(t.lines) > t.size {
+			t.lines = t.lines[len(t.lines)-t.size:]
+		}
+		if len(resp.Logs) < logPageSize {
+			return nil
+		}
+	}
+}
+
+func (t *logTail) render(b api.Build) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "Logs of %s (%s):\n", b.ID, b.Status)
+	if len(t.lines) == 0 {
+		s.WriteString("  (no output yet)\n")
+	}
+	for _, line := range t.lines {
+		fmt.Fprintf(&s, "  %s\n", line)
+	}
+	return s.String()
+}
+
+func formatLogLine(l api.BuildLog) string {
+	if l.Phase != "" {
+		return fmt.Sprintf("[%s] %s", l.Phase, l.Message)
+	}
+	return l.Message
+}
@@ -0,0 +1,69 @@
+package builds
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestWatchBoard(t *testing.T) {
+	srv := mockserver.New()
+	srv.BuildDuration = time.Hour
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	for _, deployment := range []string{"sdxl", "flux", "sdxl"} {
+		if _, err := client.UploadBuild(strings.NewReader("tarball"), deployment, api.BuildOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	opts := WatchOptions{DeploymentID: "sdxl", Limit: 5, Interval: 10 * time.Millisecond, Tail: 1}
+	if err := watchBoard(ctx, &out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	frames := strings.Split(strings.TrimSpace(out.String()), "--- ")
+	if len(frames) < 3 {
+		t.Fatalf("expected the board to be redrawn:\n%s", out.String())
+	}
+	first := frames[1]
+	for _, want := range []string{"ELAPSED", "build-0003", "build-0001", "Logs of build-0003 (running):", "Building image build-0003"} {
+		if !strings.Contains(first, want) {
+			t.Errorf("expected %q in:\n%s", want, first)
+		}
+	}
+	if strings.Contains(first, "build-0002") || strings.Contains(first, "Build queued") {
+		t.Errorf("expected only sdxl builds and the last log line:\n%s", first)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	started, finished := "2026-01-01T11:58:00Z", "2026-01-01T11:59:30Z"
+
+	tests := []struct {
+		build api.Build
+		want  string
+	}{
+		{api.Build{CreatedAt: "2026-01-01T11:59:50Z"}, "10s"},
+		{api.Build{CreatedAt: "2026-01-01T11:50:00Z", StartedAt: &started}, "2m0s"},
+		{api.Build{CreatedAt: "2026-01-01T11:50:00Z", StartedAt: &started, FinishedAt: &finished}, "1m30s"},
+		{api.Build{CreatedAt: "garbage"}, "-"},
+	}
+	for _, tt := range tests {
+		if got := formatElapsed(tt.build, now); got != tt.want {
+			t.Errorf("formatElapsed(%+v) = %q, want %q", tt.build, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package capabilities shows which optional features a profile's servers
+// support.
+package capabilities
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Options contains the options for showing server capabilities.
+type Options struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Output    ui.Output
+}
+
+// Server is the capabilities of one of a profile's servers.
+type Server struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	api.Capabilities
+}
+
+// Show prints the capabilities of the profile's cozy-hub and orchestrator.
+func Show(opts Options) error {
+	profileCfg, err := config.LoadLoggedInProfile(opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	cfg := profileCfg.Config.WithDefaultURLs()
+	return show(os.Stdout, api.NewBuilderClient(cfg.BuilderURL, cfg.Token, opts.Transport), cfg.BuilderURL,
+		api.NewClient(cfg.OrchestratorURL, cfg.Token, opts.Transport), cfg.OrchestratorURL, opts)
+}
+
+func show(w io.Writer, hub api.BuilderAPI, hubURL string, orchestrator api.OrchestratorAPI, orchestratorURL string, opts Options) error {
+	hubCaps, err := hub.Capabilities()
+	if err != nil {
+		return fmt.Errorf("failed to get cozy-hub capabilities: %w", err)
+	}
+	orchestratorCaps, err := orchestrator.Capabilities()
+	if err != nil {
+		return fmt.Errorf("failed to get orchestrator capabilities: %w", err)
+	}
+	servers := []Server{
+		{Name: api.ServerHub, URL: hubURL, Capabilities: *hubCaps},
+		{Name: api.ServerOrchestrator, URL: orchestratorURL, Capabilities: *orchestratorCaps},
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, servers)
+	}
+	table := &ui.Table{Columns: []string{"SERVER", "URL", "VERSION", "FEATURES"}}
+	for _, s := range servers {
+		version, features := s.Version, strings.Join(s.Features, ", ")
+		if s.Legacy {
+			version = "(predates capability discovery)"
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: s.Name, Cells: []string{s.Name, s.URL, orDash(version), orDash(features)}})
+	}
+	return table.Write(w)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,43 @@
+package capabilities
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestShow(t *testing.T) {
+	srv := mockserver.New()
+	srv.Features = []string{api.FeatureTrafficSplit, api.FeatureAsyncJobs}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	hub, orchestrator := api.NewBuilderClient(ts.URL, "token", nil), api.NewClient(ts.URL, "token", nil)
+
+	var out bytes.Buffer
+	if err := show(&out, hub, ts.URL, orchestrator, ts.URL, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"cozy-hub", "orchestrator", mockserver.MockVersion, api.FeatureTrafficSplit, api.FeatureAsyncJobs} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := show(&out, hub, ts.URL, orchestrator, ts.URL, Options{Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var servers []Server
+	if err := json.Unmarshal(out.Bytes(), &servers); err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 2 || servers[0].Name != api.ServerHub || !servers[1].Supports(api.FeatureAsyncJobs) {
+		t.Errorf("got %+v", servers)
+	}
+}
@@ -0,0 +1,132 @@
+// Package ci generates CI pipeline files that build and deploy a project
+// with cozyctl.
+package ci
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+)
+
+// Supported CI providers.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Providers lists the supported CI providers.
+var Providers = []string{ProviderGitHub, ProviderGitLab}
+
+// pipelinePaths is where each provider expects its pipeline file, relative
+// to the repository root.
+var pipelinePaths = map[string]string{
+	ProviderGitHub: ".github/workflows/cozy-deploy.yml",
+	ProviderGitLab: ".gitlab-ci.yml",
+}
+
+// InitOptions contains the options for generating a pipeline.
+type InitOptions struct {
+	Provider       string
+	RepoRoot       string // Where the pipeline file is written
+	Dir            string // Project directory, relative to RepoRoot
+	Branch         string // Branch whose pushes deploy
+	CozyctlVersion string // Version of cozyctl the pipeline installs
+	Force          bool   // Overwrite an existing pipeline file
+}
+
+// pipelineData is the data pipeline templates are rendered with.
+type pipelineData struct {
+	Dir            string
+	Paths          string // Glob of files that trigger the pipeline, "" for any change
+	Branch         string
+	DeploymentID   string
+	Description    string
+	CozyctlVersion string
+}
+
+// Init writes a pipeline file for opts.Provider that logs in with the
+// COZY_API_KEY secret, builds the project on the server, and deploys the
+// build to the project's deployment-id.
+func Init(opts InitOptions) error {
+	return initPipeline(os.Stdout, opts)
+}
+
+func initPipeline(w io.Writer, opts InitOptions) error {
+	tmpl, ok := pipelineTemplates[opts.Provider]
+	if !ok {
+		return fmt.Errorf("unknown CI provider %q (supported: %s)", opts.Provider, strings.Join(Providers, ", "))
+	}
+
+	dir := path.Clean(filepath.ToSlash(opts.Dir))
+	if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return fmt.Errorf("project directory %s must be inside the repository", opts.Dir)
+	}
+
+	pyprojectPath := filepath.Join(opts.RepoRoot, filepath.FromSlash(dir), build.PyProjectTomlPath)
+	if _, err := os.Stat(pyprojectPath); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s not found (use --dir to point at the project)", pyprojectPath)
+	}
+	cozyConfig, err := build.GetToolsCozyConfig(pyprojectPath)
+	if err != nil {
+		return err
+	}
+	if cozyConfig.DeploymentID == "" {
+		return fmt.Errorf("%s: [tool.cozy] deployment-id is required to deploy from CI", pyprojectPath)
+	}
+
+	data := pipelineData{
+		Dir:            dir,
+		Branch:         opts.Branch,
+		DeploymentID:   cozyConfig.DeploymentID,
+		Description:    build.ImageDescription(cozyConfig),
+		CozyctlVersion: opts.CozyctlVersion,
+	}
+	if dir != "." {
+		data.Paths = dir + "/**"
+	}
+
+	var buf bytes.Buffer
+	if err := template.Must(template.New(opts.Provider).Parse(tmpl)).Execute(&buf, data); err != nil {
+		return err
+	}
+
+	target := filepath.Join(opts.RepoRoot, filepath.FromSlash(pipelinePaths[opts.Provider]))
+	if _, err := os.Stat(target); err == nil && !opts.Force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", target)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+	}
+	if err := os.WriteFile(target, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+
+	fmt.Fprintf(w, "Wrote %s (deploys %s on pushes to %s).\n\n", target, data.DeploymentID, data.Branch)
+	fmt.Fprintln(w, "Before the first run, configure in your CI settings:")
+	for _, line := range setupNotes[opts.Provider] {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	return nil
+}
+
+// setupNotes lists the secrets and variables each pipeline expects.
+var setupNotes = map[string][]string{
+	ProviderGitHub: {
+		"secret   COZY_API_KEY           an API key with the deploy scope (cozyctl keys create)",
+		"variable COZY_HUB_URL           the cozy-hub URL",
+		"variable COZY_ORCHESTRATOR_URL  the orchestrator URL (optional)",
+	},
+	ProviderGitLab: {
+		"masked variable COZY_API_KEY   an API key with the deploy scope (cozyctl keys create)",
+		"variable COZY_HUB_URL          the cozy-hub URL",
+		"variable COZY_ORCHESTRATOR_URL the orchestrator URL (optional)",
+	},
+}
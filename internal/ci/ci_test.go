@@ -0,0 +1,116 @@
+package ci
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newRepo(t *testing.T, dir, cozy string) string {
+	t.Helper()
+	root := t.TempDir()
+	projectDir := filepath.Join(root, dir)
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[project]\nname = \"worker\"\n\n[tool.cozy]\n" + cozy
+	if err := os.WriteFile(filepath.Join(projectDir, "pyproject.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestInitGitHub(t *testing.T) {
+	root := newRepo(t, "services/generate", "deployment-id = \"generate\"\ncuda = \"12.6\"\n")
+
+	var out bytes.Buffer
+	opts := InitOptions{Provider: ProviderGitHub, RepoRoot: root, Dir: "services/generate/", Branch: "release", CozyctlVersion: "v1.2.0"}
+	if err := initPipeline(&out, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, ".github", "workflows", "cozy-deploy.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := string(data)
+	for _, want := range []string{
+		"branches: [release]",
+		`- "services/generate/**"`,
+		"COZY_API_KEY: ${{ secrets.COZY_API_KEY }}",
+		"key: cozyctl-${{ runner.os }}-v1.2.0",
+		"go install github.com/cozy-creator/cozyctl@v1.2.0",
+		"cozyctl build --dir services/generate --progress json",
+		"cozyctl deploy --from-build \"$BUILD_ID\" --deployment generate",
+		"CUDA 12.6",
+	} {
+		if !strings.Contains(pipeline, want) {
+			t.Errorf("pipeline missing %q:\n%s", want, pipeline)
+		}
+	}
+	if !strings.Contains(out.String(), "COZY_API_KEY") {
+		t.Errorf("setup notes missing the API key secret:\n%s", out.String())
+	}
+
+	// An existing pipeline is only replaced with Force
+	if err := initPipeline(&out, opts); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("second init error = %v", err)
+	}
+	opts.Force = true
+	if err := initPipeline(&out, opts); err != nil {
+		t.Errorf("init with Force: %v", err)
+	}
+}
+
+func TestInitGitLab(t *testing.T) {
+	root := newRepo(t, ".", "deployment-id = \"my-model\"\n")
+
+	var out bytes.Buffer
+	if err := initPipeline(&out, InitOptions{Provider: ProviderGitLab, RepoRoot: root, Dir: ".", Branch: "main", CozyctlVersion: "latest"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, ".gitlab-ci.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := string(data)
+	for _, want := range []string{
+		`if: $CI_COMMIT_BRANCH == "main"`,
+		"key: cozyctl-latest",
+		"--deployment my-model",
+		"cozyctl build --dir . --progress json",
+	} {
+		if !strings.Contains(pipeline, want) {
+			t.Errorf("pipeline missing %q:\n%s", want, pipeline)
+		}
+	}
+	if strings.Contains(pipeline, "changes:") {
+		t.Errorf("a project at the repository root should deploy on any change:\n%s", pipeline)
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	root := newRepo(t, ".", "python = \"3.11\"\n")
+
+	tests := []struct {
+		name string
+		opts InitOptions
+		want string
+	}{
+		{"unknown provider", InitOptions{Provider: "jenkins", RepoRoot: root, Dir: "."}, "unknown CI provider"},
+		{"outside repository", InitOptions{Provider: ProviderGitHub, RepoRoot: root, Dir: "../other"}, "must be inside the repository"},
+		{"missing pyproject", InitOptions{Provider: ProviderGitHub, RepoRoot: root, Dir: "nope"}, "not found"},
+		{"missing deployment id", InitOptions{Provider: ProviderGitHub, RepoRoot: root, Dir: "."}, "deployment-id is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := initPipeline(&bytes.Buffer{}, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
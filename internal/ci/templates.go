@@ -0,0 +1,109 @@
+package ci
+
+// The pipelines install cozyctl with 'go install' and cache the Go module
+// and build caches, so later runs only rebuild cozyctl when its version
+// changes. The build ID is read from the JSON progress events of
+// 'cozyctl build'.
+
+const githubTemplate = `# Generated by cozyctl ci init
+# Builds {{ .Dir }} on cozy-hub and deploys it to {{ .DeploymentID }}.
+# Base image: {{ .Description }}
+name: cozy-deploy
+
+on:
+  push:
+    branches: [{{ .Branch }}]
+{{- if .Paths }}
+    paths:
+      - "{{ .Paths }}"
+{{- end }}
+  workflow_dispatch:
+
+concurrency:
+  group: cozy-deploy-{{ .DeploymentID }}
+  cancel-in-progress: false
+
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    env:
+      COZY_API_KEY: ${{"{{"}} secrets.COZY_API_KEY {{"}}"}}
+      COZY_HUB_URL: ${{"{{"}} vars.COZY_HUB_URL {{"}}"}}
+      COZY_ORCHESTRATOR_URL: ${{"{{"}} vars.COZY_ORCHESTRATOR_URL {{"}}"}}
+    steps:
+      - uses: actions/checkout@v4
+
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+          cache: false
+
+      - name: Cache cozyctl
+        uses: actions/cache@v4
+        with:
+          path: |
+            ~/go/pkg/mod
+            ~/.cache/go-build
+          key: cozyctl-${{"{{"}} runner.os {{"}}"}}-{{ .CozyctlVersion }}
+
+      - name: Install cozyctl
+        run: go install github.com/cozy-creator/cozyctl@{{ .CozyctlVersion }}
+
+      - name: Log in
+        run: cozyctl login --api-key "$COZY_API_KEY" --hub-url "$COZY_HUB_URL" --builder-url "$COZY_HUB_URL"
+
+      - name: Build
+        shell: bash
+        run: |
+          cozyctl build --dir {{ .Dir }} --progress json | tee build.jsonl
+          BUILD_ID=$(grep -o '"build_id":"[^"]*"' build.jsonl | tail -n 1 | cut -d '"' -f 4)
+          test -n "$BUILD_ID"
+          echo "BUILD_ID=$BUILD_ID" >> "$GITHUB_ENV"
+
+      - name: Deploy
+        run: cozyctl deploy --from-build "$BUILD_ID" --deployment {{ .DeploymentID }} --progress plain
+`
+
+const gitlabTemplate = `# Generated by cozyctl ci init
+# Builds {{ .Dir }} on cozy-hub and deploys it to {{ .DeploymentID }}.
+# Base image: {{ .Description }}
+# Set COZY_API_KEY (masked), COZY_HUB_URL, and optionally COZY_ORCHESTRATOR_URL
+# under Settings > CI/CD > Variables.
+stages:
+  - deploy
+
+cozy-deploy:
+  stage: deploy
+  image: golang:1
+  resource_group: cozy-deploy-{{ .DeploymentID }}
+  variables:
+    GOPATH: $CI_PROJECT_DIR/.go
+    GOCACHE: $CI_PROJECT_DIR/.go-build
+  cache:
+    key: cozyctl-{{ .CozyctlVersion }}
+    paths:
+      - .go/pkg/mod
+      - .go-build
+  rules:
+    - if: $CI_COMMIT_BRANCH == "{{ .Branch }}"
+{{- if .Paths }}
+      changes:
+        - "{{ .Paths }}"
+{{- end }}
+    - if: $CI_PIPELINE_SOURCE == "web"
+  before_script:
+    - go install github.com/cozy-creator/cozyctl@{{ .CozyctlVersion }}
+    - export PATH="$GOPATH/bin:$PATH"
+    - cozyctl login --api-key "$COZY_API_KEY" --hub-url "$COZY_HUB_URL" --builder-url "$COZY_HUB_URL"
+  script:
+    - set -o pipefail
+    - cozyctl build --dir {{ .Dir }} --progress json | tee build.jsonl
+    - BUILD_ID=$(grep -o '"build_id":"[^"]*"' build.jsonl | tail -n 1 | cut -d '"' -f 4)
+    - test -n "$BUILD_ID"
+    - cozyctl deploy --from-build "$BUILD_ID" --deployment {{ .DeploymentID }} --progress plain
+`
+
+var pipelineTemplates = map[string]string{
+	ProviderGitHub: githubTemplate,
+	ProviderGitLab: gitlabTemplate,
+}
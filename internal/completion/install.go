@@ -0,0 +1,180 @@
+// Package completion installs shell completion scripts where each shell
+// loads them automatically.
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Shells lists the shells Install supports.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// zshrcLines are appended to ~/.zshrc when the completion is installed in a
+// directory zsh does not search by default.
+const zshrcLines = `
+# cozyctl completion
+fpath=(~/.zsh/completions $fpath)
+autoload -Uz compinit && compinit
+`
+
+// InstallOptions contains the options for installing a completion script.
+type InstallOptions struct {
+	Shell      string // bash, zsh, or fish; detected from $SHELL if empty
+	Home       string // Defaults to the user's home directory
+	BrewPrefix string // Homebrew prefix; detected if empty
+
+	// Generate writes the completion script for shell to w.
+	Generate func(shell string, w io.Writer) error
+}
+
+// Install writes the completion script for the user's shell and prints every
+// file it created or changed.
+func Install(out io.Writer, opts InstallOptions) error {
+	shell := opts.Shell
+	if shell == "" {
+		shell = DetectShell()
+		if shell == "" {
+			return fmt.Errorf("could not detect your shell from $SHELL; pass --shell (one of %s)", strings.Join(Shells, ", "))
+		}
+	}
+
+	home := opts.Home
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+	}
+	brew := opts.BrewPrefix
+	if brew == "" {
+		brew = brewPrefix()
+	}
+
+	var path string
+	var zshrc bool
+	switch shell {
+	case "bash":
+		if dir := filepath.Join(brew, "etc", "bash_completion.d"); brew != "" && writable(dir) {
+			path = filepath.Join(dir, "cozyctl")
+		} else {
+			// bash-completion 2 loads completions from here on first use
+			path = filepath.Join(dataHome(home), "bash-completion", "completions", "cozyctl")
+		}
+	case "zsh":
+		if dir := filepath.Join(brew, "share", "zsh", "site-functions"); brew != "" && writable(dir) {
+			path = filepath.Join(dir, "_cozyctl")
+		} else {
+			path = filepath.Join(home, ".zsh", "completions", "_cozyctl")
+			zshrc = true
+		}
+	case "fish":
+		path = filepath.Join(configHome(home), "fish", "completions", "cozyctl.fish")
+	case "pwsh", "powershell":
+		return fmt.Errorf("cannot install PowerShell completion; add 'cozyctl completion powershell | Out-String | Invoke-Expression' to your $PROFILE instead")
+	default:
+		return fmt.Errorf("cannot install completion for %q (supported: %s)", shell, strings.Join(Shells, ", "))
+	}
+
+	var script bytes.Buffer
+	if err := opts.Generate(shell, &script); err != nil {
+		return fmt.Errorf("failed to generate %s completion: %w", shell, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, script.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write completion script: %w", err)
+	}
+	fmt.Fprintf(out, "Wrote %s completion to %s\n", shell, path)
+
+	if zshrc {
+		rc := filepath.Join(home, ".zshrc")
+		changed, err := appendOnce(rc, zshrcLines, "fpath=(~/.zsh/completions")
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Fprintf(out, "Added ~/.zsh/completions to fpath in %s\n", rc)
+		}
+	}
+
+	fmt.Fprintln(out, "Restart your shell (or open a new terminal) to enable completion.")
+	return nil
+}
+
+// DetectShell returns the name of the user's login shell, or "" if $SHELL is unset.
+func DetectShell() string {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		return ""
+	}
+	return filepath.Base(shell)
+}
+
+// brewPrefix returns the Homebrew prefix, or "" if Homebrew is not installed.
+func brewPrefix() string {
+	if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != "" {
+		return prefix
+	}
+	if _, err := exec.LookPath("brew"); err != nil {
+		return ""
+	}
+	out, err := exec.Command("brew", "--prefix").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func dataHome(home string) string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".local", "share")
+}
+
+func configHome(home string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".config")
+}
+
+// writable reports whether dir exists and files can be created in it.
+func writable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".cozyctl-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+// appendOnce appends text to the file at path unless it already contains marker.
+func appendOnce(path, text, marker string) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if bytes.Contains(existing, []byte(marker)) {
+		return false, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	return true, nil
+}
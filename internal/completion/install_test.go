@@ -0,0 +1,78 @@
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func generate(shell string, w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# %s completion\n", shell)
+	return err
+}
+
+func TestInstall(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	home := t.TempDir()
+	noBrew := filepath.Join(home, "no-brew")
+
+	cases := []struct{ shell, path string }{
+		{"bash", ".local/share/bash-completion/completions/cozyctl"},
+		{"zsh", ".zsh/completions/_cozyctl"},
+		{"fish", ".config/fish/completions/cozyctl.fish"},
+	}
+	for _, c := range cases {
+		var out bytes.Buffer
+		opts := InstallOptions{Shell: c.shell, Home: home, BrewPrefix: noBrew, Generate: generate}
+		if err := Install(&out, opts); err != nil {
+			t.Fatalf("%s: %v", c.shell, err)
+		}
+		data, err := os.ReadFile(filepath.Join(home, c.path))
+		if err != nil || string(data) != "# "+c.shell+" completion\n" {
+			t.Errorf("%s: script = %q, %v", c.shell, data, err)
+		}
+		if !strings.Contains(out.String(), filepath.Join(home, c.path)) {
+			t.Errorf("%s: output does not name the file:\n%s", c.shell, out.String())
+		}
+	}
+
+	// Installing again does not add fpath to .zshrc twice
+	if err := Install(io.Discard, InstallOptions{Shell: "zsh", Home: home, BrewPrefix: noBrew, Generate: generate}); err != nil {
+		t.Fatal(err)
+	}
+	rc, _ := os.ReadFile(filepath.Join(home, ".zshrc"))
+	if n := strings.Count(string(rc), "fpath=(~/.zsh/completions"); n != 1 {
+		t.Errorf(".zshrc has %d fpath lines:\n%s", n, rc)
+	}
+}
+
+func TestInstallPrefersHomebrew(t *testing.T) {
+	home := t.TempDir()
+	brew := t.TempDir()
+	siteFunctions := filepath.Join(brew, "share", "zsh", "site-functions")
+	if err := os.MkdirAll(siteFunctions, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Install(io.Discard, InstallOptions{Shell: "zsh", Home: home, BrewPrefix: brew, Generate: generate}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(siteFunctions, "_cozyctl")); err != nil {
+		t.Error("expected the script in Homebrew's site-functions")
+	}
+	if _, err := os.Stat(filepath.Join(home, ".zshrc")); err == nil {
+		t.Error(".zshrc should be left alone when Homebrew's directory is used")
+	}
+}
+
+func TestInstallUnsupportedShell(t *testing.T) {
+	err := Install(io.Discard, InstallOptions{Shell: "powershell", Home: t.TempDir(), Generate: generate})
+	if err == nil || !strings.Contains(err.Error(), "$PROFILE") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/cozy-creator/cozyctl/internal/redact"
 	"github.com/spf13/viper"
 )
 
@@ -19,6 +21,7 @@ type DefaultConfig struct {
 
 // ProfileConfig holds the complete configuration for a name+profile
 type ProfileConfig struct {
+	Version        int         `yaml:"version" mapstructure:"version"` // Layout version, see CurrentConfigVersion
 	CurrentName    string      `yaml:"current_name" mapstructure:"current_name"`
 	CurrentProfile string      `yaml:"current_profile" mapstructure:"current_profile"`
 	Config         *ConfigData `yaml:"config" mapstructure:"config"`
@@ -32,6 +35,20 @@ type ConfigData struct {
 	TenantID        string `yaml:"tenant_id" mapstructure:"tenant_id"`
 	Token           string `yaml:"token" mapstructure:"token"`
 	RefreshToken    string `yaml:"refresh_token,omitempty" mapstructure:"refresh_token"`
+	TokenExpiresAt  string `yaml:"token_expires_at,omitempty" mapstructure:"token_expires_at"` // RFC 3339; empty if the token does not expire
+
+	// Container registry used by local builds (deploy --local-build)
+	RegistryURL      string `yaml:"registry_url,omitempty" mapstructure:"registry_url"`
+	RegistryPrefix   string `yaml:"registry_prefix,omitempty" mapstructure:"registry_prefix"`
+	RegistryUser     string `yaml:"registry_user,omitempty" mapstructure:"registry_user"`
+	RegistryPassword string `yaml:"registry_password,omitempty" mapstructure:"registry_password"`
+
+	// Where the token, refresh token, and registry password are kept: empty
+	// for this file, or CredentialStoreKeychain
+	CredentialStore string `yaml:"credential_store,omitempty" mapstructure:"credential_store"`
+
+	// Refuse API requests that would change anything (see internal/readonly)
+	ReadOnly bool `yaml:"read_only,omitempty" mapstructure:"read_only"`
 }
 
 // BaseDir returns the base config directory (~/.cozy)
@@ -152,8 +169,74 @@ func SaveDefaultConfig(name, profile string) error {
 	return nil
 }
 
-// GetProfileConfig reads a profile config
+// ProfileRef selects a name/profile pair, e.g. from the --name and --profile flags.
+// Empty fields fall back to the current default.
+type ProfileRef struct {
+	Name    string
+	Profile string
+}
+
+// ResolveProfileRef fills in an empty name or profile from the current default
+func ResolveProfileRef(ref ProfileRef) (ProfileRef, error) {
+	if ref.Name == "" || ref.Profile == "" {
+		defaultCfg, err := GetDefaultConfig()
+		if err != nil {
+			return ref, fmt.Errorf("failed to load config: %w", err)
+		}
+		if ref.Name == "" {
+			ref.Name = defaultCfg.CurrentName
+		}
+		if ref.Profile == "" {
+			ref.Profile = defaultCfg.CurrentProfile
+		}
+	}
+	return ref, nil
+}
+
+// LoadProfileConfig reads the profile config selected by ref for a command
+// to use, recording the profile as last used now
+func LoadProfileConfig(ref ProfileRef) (*ProfileConfig, error) {
+	ref, err := ResolveProfileRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	profileCfg, err := GetProfileConfig(ref.Name, ref.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile config: %w", err)
+	}
+	TouchLastUsed(ref.Name, ref.Profile, time.Now())
+	return profileCfg, nil
+}
+
+// LoadLoggedInProfile reads the profile config selected by ref like
+// LoadProfileConfig, failing if the profile is not logged in
+func LoadLoggedInProfile(ref ProfileRef) (*ProfileConfig, error) {
+	profileCfg, err := LoadProfileConfig(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if profileCfg.Config == nil {
+		return nil, fmt.Errorf("not logged in (run 'cozyctl login' first)")
+	}
+
+	if err := profileCfg.Config.Validate(); err != nil {
+		return nil, err
+	}
+	return profileCfg, nil
+}
+
+// GetProfileConfig reads a profile config, with COZY_* environment
+// overrides applied
 func GetProfileConfig(name, profile string) (*ProfileConfig, error) {
+	return readProfileConfig(name, profile, true)
+}
+
+// readProfileConfig reads a profile config, fetching credentials kept in
+// the keychain. Environment overrides are applied only if env is set, so
+// configs read to be rewritten don't pick them up.
+func readProfileConfig(name, profile string, env bool) (*ProfileConfig, error) {
 	configPath, err := ProfileConfigPath(name, profile)
 	if err != nil {
 		return nil, err
@@ -164,20 +247,28 @@ func GetProfileConfig(name, profile string) (*ProfileConfig, error) {
 		return nil, fmt.Errorf("profile '%s/%s' not found (run 'cozyctl login --name %s --profile %s' first)", name, profile, name, profile)
 	}
 
+	// Upgrade configs written by older versions of cozyctl
+	if _, err := MigrateProfileConfig(name, profile, false); err != nil {
+		return nil, err
+	}
+
 	// Create Viper instance
 	v := viper.New()
 	v.SetConfigFile(configPath)
 	v.SetConfigType("yaml")
 
 	// Set environment variable support
-	v.SetEnvPrefix("COZY")
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	v.AutomaticEnv()
+	if env {
+		v.SetEnvPrefix("COZY")
+		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		v.AutomaticEnv()
+	}
 
 	// Set defaults
-	v.SetDefault("config.hub_url", "http://localhost:3001")
-	v.SetDefault("config.builder_url", "http://localhost:3001")
-	v.SetDefault("config.orchestrator_url", "http://localhost:8090")
+	defaults := DefaultConfigData()
+	v.SetDefault("config.hub_url", defaults.HubURL)
+	v.SetDefault("config.builder_url", defaults.BuilderURL)
+	v.SetDefault("config.orchestrator_url", defaults.OrchestratorURL)
 
 	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read profile config: %w", err)
@@ -188,8 +279,14 @@ func GetProfileConfig(name, profile string) (*ProfileConfig, error) {
 		return nil, fmt.Errorf("failed to parse profile config: %w", err)
 	}
 
-	// Apply environment variable overrides
 	if cfg.Config != nil {
+		if err := loadSecrets(name, profile, cfg.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply environment variable overrides
+	if cfg.Config != nil && env {
 		if v.IsSet("hub_url") {
 			cfg.Config.HubURL = v.GetString("hub_url")
 		}
@@ -208,6 +305,25 @@ func GetProfileConfig(name, profile string) (*ProfileConfig, error) {
 		if v.IsSet("refresh_token") {
 			cfg.Config.RefreshToken = v.GetString("refresh_token")
 		}
+		if v.IsSet("registry_url") {
+			cfg.Config.RegistryURL = v.GetString("registry_url")
+		}
+		if v.IsSet("registry_prefix") {
+			cfg.Config.RegistryPrefix = v.GetString("registry_prefix")
+		}
+		if v.IsSet("registry_user") {
+			cfg.Config.RegistryUser = v.GetString("registry_user")
+		}
+		if v.IsSet("registry_password") {
+			cfg.Config.RegistryPassword = v.GetString("registry_password")
+		}
+		// COZY_READ_ONLY can make a profile read-only, but not undo it
+		if v.GetBool("read_only") {
+			cfg.Config.ReadOnly = true
+		}
+	}
+	if cfg.Config != nil {
+		cfg.Config.registerSecrets()
 	}
 
 	return cfg, nil
@@ -232,16 +348,40 @@ func SaveProfileConfig(name, profile string, cfg *ProfileConfig) error {
 	v.SetConfigType("yaml")
 
 	// Set values
+	v.Set("version", CurrentConfigVersion)
 	v.Set("current_name", cfg.CurrentName)
 	v.Set("current_profile", cfg.CurrentProfile)
 	if cfg.Config != nil {
-		v.Set("config.hub_url", cfg.Config.HubURL)
-		v.Set("config.builder_url", cfg.Config.BuilderURL)
-		v.Set("config.orchestrator_url", cfg.Config.OrchestratorURL)
-		v.Set("config.tenant_id", cfg.Config.TenantID)
-		v.Set("config.token", cfg.Config.Token)
-		if cfg.Config.RefreshToken != "" {
-			v.Set("config.refresh_token", cfg.Config.RefreshToken)
+		cfg.Config.registerSecrets()
+		onDisk := storeSecrets(name, profile, cfg.Config)
+		v.Set("config.hub_url", onDisk.HubURL)
+		v.Set("config.builder_url", onDisk.BuilderURL)
+		v.Set("config.orchestrator_url", onDisk.OrchestratorURL)
+		v.Set("config.tenant_id", onDisk.TenantID)
+		v.Set("config.token", onDisk.Token)
+		if onDisk.RefreshToken != "" {
+			v.Set("config.refresh_token", onDisk.RefreshToken)
+		}
+		if onDisk.TokenExpiresAt != "" {
+			v.Set("config.token_expires_at", onDisk.TokenExpiresAt)
+		}
+		if onDisk.RegistryURL != "" {
+			v.Set("config.registry_url", onDisk.RegistryURL)
+		}
+		if onDisk.RegistryPrefix != "" {
+			v.Set("config.registry_prefix", onDisk.RegistryPrefix)
+		}
+		if onDisk.RegistryUser != "" {
+			v.Set("config.registry_user", onDisk.RegistryUser)
+		}
+		if onDisk.RegistryPassword != "" {
+			v.Set("config.registry_password", onDisk.RegistryPassword)
+		}
+		if onDisk.CredentialStore != "" {
+			v.Set("config.credential_store", onDisk.CredentialStore)
+		}
+		if onDisk.ReadOnly {
+			v.Set("config.read_only", true)
 		}
 	}
 
@@ -332,6 +472,15 @@ func DeleteProfile(name, profile string) error {
 		return fmt.Errorf("profile '%s/%s' does not exist", name, profile)
 	}
 
+	// Credentials kept in the keychain go with the profile
+	configPath, err := ProfileConfigPath(name, profile)
+	if err != nil {
+		return err
+	}
+	if values, err := readConfigSection(configPath); err == nil && values["credential_store"] == CredentialStoreKeychain {
+		deleteSecrets(name, profile)
+	}
+
 	if err := os.RemoveAll(dir); err != nil {
 		return fmt.Errorf("failed to delete profile: %w", err)
 	}
@@ -374,6 +523,11 @@ func ImportConfigFile(sourceFile, name, profile string) (*ProfileConfig, error)
 	return profileCfg, nil
 }
 
+// registerSecrets has the profile's credentials redacted from output.
+func (c *ConfigData) registerSecrets() {
+	redact.Register(c.Token, c.RefreshToken, c.RegistryPassword)
+}
+
 // Validate checks that required fields are set
 func (c *ConfigData) Validate() error {
 	if c.Token == "" {
@@ -385,13 +539,28 @@ func (c *ConfigData) Validate() error {
 	return nil
 }
 
-// DefaultConfigData returns default config values
-func DefaultConfigData() *ConfigData {
-	return &ConfigData{
-		HubURL:          "http://localhost:3001",
-		BuilderURL:      "http://localhost:3001",
-		OrchestratorURL: "http://localhost:8090",
+// WithDefaultURLs returns a copy of the config with the DefaultEnvironment's
+// URLs in place of any server URL left unset
+func (c *ConfigData) WithDefaultURLs() *ConfigData {
+	cfg := *c
+	defaults := DefaultConfigData()
+	if cfg.HubURL == "" {
+		cfg.HubURL = defaults.HubURL
+	}
+	if cfg.BuilderURL == "" {
+		cfg.BuilderURL = defaults.BuilderURL
 	}
+	if cfg.OrchestratorURL == "" {
+		cfg.OrchestratorURL = defaults.OrchestratorURL
+	}
+	return &cfg
+}
+
+// DefaultConfigData returns default config values: the URLs of the
+// DefaultEnvironment preset
+func DefaultConfigData() *ConfigData {
+	urls, _ := EnvironmentURLs(DefaultEnvironment, "", "", "")
+	return urls
 }
 
 // PromptOverwrite prompts user to confirm overwriting an existing profile
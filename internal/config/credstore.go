@@ -0,0 +1,235 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Credential stores: where profiles keep their tokens and passwords.
+const (
+	CredentialStoreFile     = "file"     // In the profile's config.yaml (the default)
+	CredentialStoreKeychain = "keychain" // In the OS keychain; config.yaml only records that
+)
+
+// keychainService names cozyctl's entries in the OS keychain.
+const keychainService = "cozyctl"
+
+// errSecretNotFound is returned by a keychain without the requested entry.
+var errSecretNotFound = errors.New("secret not found in keychain")
+
+// secretStore is an OS credential store: macOS Keychain, Windows Credential
+// Manager, or a Secret Service provider such as GNOME Keyring on Linux.
+type secretStore interface {
+	get(account string) (string, error) // errSecretNotFound if absent
+	set(account, secret string) error
+	delete(account string) error // No error if absent
+}
+
+// keychain is the platform's credential store; tests replace it.
+var keychain secretStore = osKeychain{}
+
+// secretFields are the profile settings kept in the keychain.
+var secretFields = []struct {
+	key string
+	ptr func(*ConfigData) *string
+}{
+	{"token", func(c *ConfigData) *string { return &c.Token }},
+	{"refresh_token", func(c *ConfigData) *string { return &c.RefreshToken }},
+	{"registry_password", func(c *ConfigData) *string { return &c.RegistryPassword }},
+}
+
+// keychainAccount names a profile setting's keychain entry.
+func keychainAccount(name, profile, key string) string {
+	return name + "/" + profile + ":" + key
+}
+
+// Settings are preferences for cozyctl as a whole, stored in
+// ~/.cozy/settings.yaml.
+type Settings struct {
+	CredentialStore string `yaml:"credential_store,omitempty"`
+}
+
+// SettingsPath returns the path of the settings file (~/.cozy/settings.yaml)
+func SettingsPath() (string, error) {
+	base, err := BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "settings.yaml"), nil
+}
+
+// GetSettings reads the settings, defaulting those not set.
+func GetSettings() (*Settings, error) {
+	path, err := SettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	settings := &Settings{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	if err := yaml.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if settings.CredentialStore == "" {
+		settings.CredentialStore = CredentialStoreFile
+	}
+	return settings, nil
+}
+
+func saveSettings(settings *Settings) error {
+	path, err := SettingsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
+
+// CheckKeychain reports whether the OS keychain can store credentials here,
+// by writing, reading back, and removing a test entry.
+func CheckKeychain() error {
+	const account = "cozyctl-check"
+	if err := keychain.set(account, "check"); err != nil {
+		return err
+	}
+	defer keychain.delete(account)
+	if got, err := keychain.get(account); err != nil {
+		return err
+	} else if got != "check" {
+		return fmt.Errorf("keychain returned a different value than was stored")
+	}
+	return nil
+}
+
+// SetCredentialStore selects where profiles keep their credentials and moves
+// every existing profile's credentials there, returning how many profiles
+// were moved. Choosing the keychain fails if it isn't usable.
+func SetCredentialStore(store string) (int, error) {
+	switch store {
+	case CredentialStoreFile:
+	case CredentialStoreKeychain:
+		if err := CheckKeychain(); err != nil {
+			return 0, fmt.Errorf("the OS keychain isn't available: %w", err)
+		}
+	default:
+		return 0, fmt.Errorf("unknown credential store '%s' (must be %s or %s)", store, CredentialStoreFile, CredentialStoreKeychain)
+	}
+
+	settings, err := GetSettings()
+	if err != nil {
+		return 0, err
+	}
+	settings.CredentialStore = store
+	if err := saveSettings(settings); err != nil {
+		return 0, err
+	}
+
+	profiles, err := ListAllProfiles()
+	if err != nil {
+		return 0, err
+	}
+	moved := 0
+	for _, p := range profiles {
+		cfg, err := readProfileConfig(p.Name, p.Profile, false)
+		if err != nil {
+			return moved, err
+		}
+		if cfg.Config == nil || cfg.Config.CredentialStore == store || (store == CredentialStoreFile && cfg.Config.CredentialStore == "") {
+			continue
+		}
+		if err := SaveProfileConfig(p.Name, p.Profile, cfg); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// loadSecrets fills in the credentials of a profile that keeps them in the
+// keychain. Values already set (e.g. from COZY_* variables) are kept.
+func loadSecrets(name, profile string, c *ConfigData) error {
+	if c.CredentialStore != CredentialStoreKeychain {
+		return nil
+	}
+	for _, f := range secretFields {
+		if *f.ptr(c) != "" {
+			continue
+		}
+		secret, err := keychain.get(keychainAccount(name, profile, f.key))
+		if errors.Is(err, errSecretNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s of profile '%s/%s' from the keychain: %w", f.key, name, profile, err)
+		}
+		*f.ptr(c) = secret
+	}
+	return nil
+}
+
+// storeSecrets moves c's credentials to the selected credential store,
+// returning the config to write to config.yaml: without the credentials when
+// they went to the keychain. When the keychain fails, the credentials are
+// written to the file as before and a warning is printed.
+func storeSecrets(name, profile string, c *ConfigData) *ConfigData {
+	store := CredentialStoreFile
+	if settings, err := GetSettings(); err == nil {
+		store = settings.CredentialStore
+	}
+
+	onDisk := *c
+	if store == CredentialStoreKeychain {
+		err := func() error {
+			for _, f := range secretFields {
+				account := keychainAccount(name, profile, f.key)
+				if value := *f.ptr(c); value != "" {
+					if err := keychain.set(account, value); err != nil {
+						return err
+					}
+				} else if err := keychain.delete(account); err != nil {
+					return err
+				}
+			}
+			return nil
+		}()
+		if err == nil {
+			for _, f := range secretFields {
+				*f.ptr(&onDisk) = ""
+			}
+			onDisk.CredentialStore = CredentialStoreKeychain
+			c.CredentialStore = CredentialStoreKeychain
+			return &onDisk
+		}
+		fmt.Fprintf(os.Stderr, "Warning: couldn't save credentials of '%s/%s' in the OS keychain (%v); keeping them in config.yaml\n", name, profile, err)
+	}
+
+	// Leaving the keychain: remove the entries the file no longer points to
+	if c.CredentialStore == CredentialStoreKeychain {
+		deleteSecrets(name, profile)
+	}
+	onDisk.CredentialStore = ""
+	c.CredentialStore = ""
+	return &onDisk
+}
+
+// deleteSecrets removes a profile's keychain entries. It is best effort.
+func deleteSecrets(name, profile string) {
+	for _, f := range secretFields {
+		keychain.delete(keychainAccount(name, profile, f.key))
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// memoryKeychain is an in-memory secretStore.
+type memoryKeychain struct {
+	secrets map[string]string
+	err     error // Returned by set, simulating a locked or missing keychain
+}
+
+func (k *memoryKeychain) get(account string) (string, error) {
+	secret, ok := k.secrets[account]
+	if !ok {
+		return "", errSecretNotFound
+	}
+	return secret, nil
+}
+
+func (k *memoryKeychain) set(account, secret string) error {
+	if k.err != nil {
+		return k.err
+	}
+	k.secrets[account] = secret
+	return nil
+}
+
+func (k *memoryKeychain) delete(account string) error {
+	delete(k.secrets, account)
+	return nil
+}
+
+func useMemoryKeychain(t *testing.T) *memoryKeychain {
+	t.Helper()
+	k := &memoryKeychain{secrets: map[string]string{}}
+	previous := keychain
+	keychain = k
+	t.Cleanup(func() { keychain = previous })
+	return k
+}
+
+func TestKeychainCredentialStore(t *testing.T) {
+	path := writeProfile(t, "work", "dev", "version: 2\nconfig:\n  hub_url: https://hub.example\n  token: tok-secret\n  refresh_token: refresh-secret\n")
+	k := useMemoryKeychain(t)
+
+	moved, err := SetCredentialStore(CredentialStoreKeychain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 1 {
+		t.Errorf("moved = %d, want 1", moved)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") || !strings.Contains(string(data), "credential_store: keychain") {
+		t.Errorf("config.yaml still holds credentials or lacks the marker:\n%s", data)
+	}
+	if k.secrets[keychainAccount("work", "dev", "token")] != "tok-secret" {
+		t.Errorf("keychain = %v, want the token", k.secrets)
+	}
+
+	cfg, err := GetProfileConfig("work", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Config.Token != "tok-secret" || cfg.Config.RefreshToken != "refresh-secret" || cfg.Config.HubURL != "https://hub.example" {
+		t.Errorf("loaded %+v, want the credentials read back from the keychain", cfg.Config)
+	}
+
+	// Environment variables still take precedence
+	t.Setenv("COZY_TOKEN", "env-token")
+	if cfg, err := GetProfileConfig("work", "dev"); err != nil || cfg.Config.Token != "env-token" {
+		t.Errorf("token = %q (err %v), want the COZY_TOKEN value", cfg.Config.Token, err)
+	}
+	os.Unsetenv("COZY_TOKEN")
+
+	// Moving back to the file removes the keychain entries
+	if _, err := SetCredentialStore(CredentialStoreFile); err != nil {
+		t.Fatal(err)
+	}
+	if len(k.secrets) != 0 {
+		t.Errorf("keychain entries left behind: %v", k.secrets)
+	}
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), "token: tok-secret") || strings.Contains(string(data), "credential_store") {
+		t.Errorf("credentials not written back to config.yaml:\n%s", data)
+	}
+}
+
+func TestKeychainFailureFallsBackToFile(t *testing.T) {
+	path := writeProfile(t, "work", "dev", "version: 2\nconfig:\n  token: tok-secret\n")
+	k := useMemoryKeychain(t)
+	if _, err := SetCredentialStore(CredentialStoreKeychain); err != nil {
+		t.Fatal(err)
+	}
+
+	k.err = errors.New("keychain is locked")
+	cfg, err := GetProfileConfig("work", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Config.Token = "tok-new"
+	if err := SaveProfileConfig("work", "dev", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "token: tok-new") || strings.Contains(string(data), "credential_store") {
+		t.Errorf("token not kept in config.yaml when the keychain failed:\n%s", data)
+	}
+	if cfg, err := GetProfileConfig("work", "dev"); err != nil || cfg.Config.Token != "tok-new" {
+		t.Errorf("token = %q (err %v), want tok-new", cfg.Config.Token, err)
+	}
+}
+
+func TestSetCredentialStoreRejectsUnknown(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if _, err := SetCredentialStore("vault"); err == nil || !strings.Contains(err.Error(), "unknown credential store") {
+		t.Errorf("err = %v, want an unknown credential store error", err)
+	}
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// DirectoryMapping selects a profile for commands run inside Dir or any of
+// its subdirectories.
+type DirectoryMapping struct {
+	Dir string
+	ProfileRef
+}
+
+// directoriesFile is the on-disk form of the directory mappings. Paths are
+// map keys, so it is read with yaml rather than viper, which lower-cases keys.
+type directoriesFile struct {
+	Directories map[string]string `yaml:"directories"` // dir -> "name/profile"
+}
+
+// DirectoriesPath returns the path of the directory mappings file (~/.cozy/directories.yaml)
+func DirectoriesPath() (string, error) {
+	base, err := BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "directories.yaml"), nil
+}
+
+// ParseProfileRef parses "name/profile".
+func ParseProfileRef(s string) (ProfileRef, error) {
+	name, profile, ok := strings.Cut(s, "/")
+	if !ok || name == "" || profile == "" || strings.Contains(profile, "/") {
+		return ProfileRef{}, fmt.Errorf("invalid profile %q (expected name/profile)", s)
+	}
+	return ProfileRef{Name: name, Profile: profile}, nil
+}
+
+// ListDirectoryMappings returns the directory mappings sorted by directory.
+func ListDirectoryMappings() ([]DirectoryMapping, error) {
+	dirs, err := loadDirectories()
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make([]DirectoryMapping, 0, len(dirs))
+	for dir, target := range dirs {
+		ref, err := ParseProfileRef(target)
+		if err != nil {
+			return nil, fmt.Errorf("directory mapping for %s: %w", dir, err)
+		}
+		mappings = append(mappings, DirectoryMapping{Dir: dir, ProfileRef: ref})
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].Dir < mappings[j].Dir })
+	return mappings, nil
+}
+
+// SaveDirectoryMapping maps dir (and its subdirectories) to a profile,
+// replacing any existing mapping for dir. It returns the normalized directory.
+func SaveDirectoryMapping(dir string, ref ProfileRef) (string, error) {
+	dir, err := normalizeDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if !ProfileExists(ref.Name, ref.Profile) {
+		return "", fmt.Errorf("profile '%s/%s' does not exist", ref.Name, ref.Profile)
+	}
+
+	dirs, err := loadDirectories()
+	if err != nil {
+		return "", err
+	}
+	dirs[dir] = ref.Name + "/" + ref.Profile
+	return dir, saveDirectories(dirs)
+}
+
+// RemoveDirectoryMapping removes the mapping for dir, reporting whether one existed.
+func RemoveDirectoryMapping(dir string) (bool, error) {
+	dir, err := normalizeDir(dir)
+	if err != nil {
+		return false, err
+	}
+
+	dirs, err := loadDirectories()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := dirs[dir]; !ok {
+		return false, nil
+	}
+	delete(dirs, dir)
+	return true, saveDirectories(dirs)
+}
+
+// ProfileForDir returns the mapping covering dir: the one for dir itself or
+// its closest mapped parent. ok is false when no mapping applies.
+func ProfileForDir(dir string) (mapping DirectoryMapping, ok bool, err error) {
+	dir, err = normalizeDir(dir)
+	if err != nil {
+		return mapping, false, err
+	}
+
+	mappings, err := ListDirectoryMappings()
+	if err != nil {
+		return mapping, false, err
+	}
+
+	for _, m := range mappings {
+		if !withinDir(dir, m.Dir) {
+			continue
+		}
+		if !ok || len(m.Dir) > len(mapping.Dir) {
+			mapping, ok = m, true
+		}
+	}
+	return mapping, ok, nil
+}
+
+// withinDir reports whether path is dir or lies below it.
+func withinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// normalizeDir makes dir absolute and resolves symlinks where possible, so
+// the same directory matches however it was reached.
+func normalizeDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory %s: %w", dir, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	return abs, nil
+}
+
+func loadDirectories() (map[string]string, error) {
+	path, err := DirectoriesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory mappings: %w", err)
+	}
+
+	var file directoriesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.Directories == nil {
+		file.Directories = map[string]string{}
+	}
+	return file.Directories, nil
+}
+
+func saveDirectories(dirs map[string]string) error {
+	path, err := DirectoriesPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(directoriesFile{Directories: dirs})
+	if err != nil {
+		return fmt.Errorf("failed to encode directory mappings: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write directory mappings: %w", err)
+	}
+	return nil
+}
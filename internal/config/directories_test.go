@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirectoryMappings(t *testing.T) {
+	writeProfile(t, "work", "prod", "version: 2\nconfig:\n  token: t\n")
+	writeProfileAt(t, "client", "dev")
+
+	root := t.TempDir()
+	project := filepath.Join(root, "client-a")
+	nested := filepath.Join(project, "services", "api")
+	if err := os.MkdirAll(nested, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SaveDirectoryMapping(root, ProfileRef{Name: "work", Profile: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveDirectoryMapping(project, ProfileRef{Name: "client", Profile: "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveDirectoryMapping(root, ProfileRef{Name: "missing", Profile: "dev"}); err == nil {
+		t.Error("expected mapping to a missing profile to fail")
+	}
+
+	cases := []struct {
+		dir  string
+		want string
+	}{
+		{root, "work/prod"},
+		{nested, "client/dev"}, // closest mapped parent wins
+		{root + "-other", ""},  // shared prefix but not below root
+	}
+	for _, c := range cases {
+		m, ok, err := ProfileForDir(c.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if ok {
+			got = m.Name + "/" + m.Profile
+		}
+		if got != c.want {
+			t.Errorf("ProfileForDir(%s) = %q, want %q", c.dir, got, c.want)
+		}
+	}
+
+	removed, err := RemoveDirectoryMapping(project)
+	if err != nil || !removed {
+		t.Fatalf("RemoveDirectoryMapping = %v, %v", removed, err)
+	}
+	if m, _, _ := ProfileForDir(nested); m.Name != "work" {
+		t.Errorf("after unmapping, nested dir maps to %+v, want work/prod", m)
+	}
+}
+
+func TestParseProfileRef(t *testing.T) {
+	if ref, err := ParseProfileRef("work/prod"); err != nil || ref.Name != "work" || ref.Profile != "prod" {
+		t.Errorf("ParseProfileRef(work/prod) = %+v, %v", ref, err)
+	}
+	for _, bad := range []string{"work", "/prod", "work/", "a/b/c"} {
+		if _, err := ParseProfileRef(bad); err == nil {
+			t.Errorf("ParseProfileRef(%q) should fail", bad)
+		}
+	}
+}
+
+// writeProfileAt adds another profile under the HOME set by writeProfile.
+func writeProfileAt(t *testing.T, name, profile string) {
+	t.Helper()
+	path, err := ProfileConfigPath(name, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("version: 2\nconfig:\n  token: t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Environment presets selectable with --env.
+const (
+	EnvProduction = "production"
+	EnvStaging    = "staging"
+	EnvLocalDev   = "local-dev"
+)
+
+// DefaultEnvironment is the preset used when none is chosen, and the source
+// of the URLs commands fall back to when a profile leaves them empty.
+const DefaultEnvironment = EnvLocalDev
+
+// Environment is a named set of canonical server URLs.
+type Environment struct {
+	Name            string `json:"name"`
+	HubURL          string `json:"hub_url"`
+	BuilderURL      string `json:"builder_url"`
+	OrchestratorURL string `json:"orchestrator_url"`
+}
+
+// environments lists the presets. The builder is part of cozy-hub, so the
+// two URLs are the same in each.
+var environments = []Environment{
+	{
+		Name:            EnvProduction,
+		HubURL:          "https://api.cozy.art",
+		BuilderURL:      "https://api.cozy.art",
+		OrchestratorURL: "https://orchestrator.cozy.art",
+	},
+	{
+		Name:            EnvStaging,
+		HubURL:          "https://api.staging.cozy.art",
+		BuilderURL:      "https://api.staging.cozy.art",
+		OrchestratorURL: "https://orchestrator.staging.cozy.art",
+	},
+	{
+		Name:            EnvLocalDev,
+		HubURL:          "http://localhost:3001",
+		BuilderURL:      "http://localhost:3001",
+		OrchestratorURL: "http://localhost:8090",
+	},
+}
+
+// Environments returns the presets.
+func Environments() []Environment {
+	return append([]Environment(nil), environments...)
+}
+
+// EnvironmentNames returns the preset names, for flag help and errors.
+func EnvironmentNames() []string {
+	names := make([]string, len(environments))
+	for i, env := range environments {
+		names[i] = env.Name
+	}
+	return names
+}
+
+// LookupEnvironment returns the named preset, or DefaultEnvironment when
+// name is empty.
+func LookupEnvironment(name string) (Environment, error) {
+	if name == "" {
+		name = DefaultEnvironment
+	}
+	for _, env := range environments {
+		if env.Name == name {
+			return env, nil
+		}
+	}
+	return Environment{}, fmt.Errorf("unknown environment '%s' (must be %s)", name, strings.Join(EnvironmentNames(), ", "))
+}
+
+// EnvironmentURLs returns the server URLs to use: those of the named preset
+// (DefaultEnvironment when empty), with any non-empty URL given explicitly
+// taking precedence.
+func EnvironmentURLs(env, hubURL, builderURL, orchestratorURL string) (*ConfigData, error) {
+	preset, err := LookupEnvironment(env)
+	if err != nil {
+		return nil, err
+	}
+	urls := &ConfigData{
+		HubURL:          preset.HubURL,
+		BuilderURL:      preset.BuilderURL,
+		OrchestratorURL: preset.OrchestratorURL,
+	}
+	if hubURL != "" {
+		urls.HubURL = hubURL
+	}
+	if builderURL != "" {
+		urls.BuilderURL = builderURL
+	}
+	if orchestratorURL != "" {
+		urls.OrchestratorURL = orchestratorURL
+	}
+	return urls, nil
+}
+
+// SelectURLs returns the server URLs chosen with --env and explicit URL
+// flags. Without env, only the explicit URLs are set, leaving the rest empty
+// for the caller to default (e.g. to an existing profile's).
+func SelectURLs(env, hubURL, builderURL, orchestratorURL string) (*ConfigData, error) {
+	if env != "" {
+		return EnvironmentURLs(env, hubURL, builderURL, orchestratorURL)
+	}
+	return &ConfigData{HubURL: hubURL, BuilderURL: builderURL, OrchestratorURL: orchestratorURL}, nil
+}
+
+// EnvironmentOf returns the name of the preset whose URLs cfg uses, or ""
+// if they don't all match one.
+func EnvironmentOf(cfg *ConfigData) string {
+	for _, env := range environments {
+		if cfg.HubURL == env.HubURL && cfg.BuilderURL == env.BuilderURL && cfg.OrchestratorURL == env.OrchestratorURL {
+			return env.Name
+		}
+	}
+	return ""
+}
+
+// SetProfileURLs replaces the server URLs of a saved profile with the
+// non-empty ones in urls and returns the profile's resulting config.
+func SetProfileURLs(ref ProfileRef, urls *ConfigData) (*ConfigData, error) {
+	ref, err := ResolveProfileRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if !ProfileExists(ref.Name, ref.Profile) {
+		return nil, fmt.Errorf("profile '%s/%s' does not exist (run 'cozyctl login' first)", ref.Name, ref.Profile)
+	}
+
+	profileCfg, err := GetProfileConfig(ref.Name, ref.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile config: %w", err)
+	}
+	if profileCfg.Config == nil {
+		profileCfg.Config = &ConfigData{}
+	}
+	if urls.HubURL != "" {
+		profileCfg.Config.HubURL = urls.HubURL
+	}
+	if urls.BuilderURL != "" {
+		profileCfg.Config.BuilderURL = urls.BuilderURL
+	}
+	if urls.OrchestratorURL != "" {
+		profileCfg.Config.OrchestratorURL = urls.OrchestratorURL
+	}
+
+	if err := SaveProfileConfig(ref.Name, ref.Profile, profileCfg); err != nil {
+		return nil, fmt.Errorf("failed to save profile config: %w", err)
+	}
+	return profileCfg.Config, nil
+}
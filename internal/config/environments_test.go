@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentURLs(t *testing.T) {
+	urls, err := EnvironmentURLs(EnvStaging, "", "", "http://localhost:9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	staging, _ := LookupEnvironment(EnvStaging)
+	if urls.HubURL != staging.HubURL || urls.BuilderURL != staging.BuilderURL {
+		t.Errorf("urls = %+v, want the staging hub and builder", urls)
+	}
+	if urls.OrchestratorURL != "http://localhost:9000" {
+		t.Errorf("orchestrator = %q, want the explicit URL", urls.OrchestratorURL)
+	}
+
+	if urls, _ := EnvironmentURLs("", "", "", ""); EnvironmentOf(urls) != DefaultEnvironment {
+		t.Errorf("empty env resolved to %+v, want %s", urls, DefaultEnvironment)
+	}
+	if EnvironmentOf(DefaultConfigData()) != DefaultEnvironment {
+		t.Errorf("DefaultConfigData doesn't match the %s preset", DefaultEnvironment)
+	}
+
+	if _, err := EnvironmentURLs("prod", "", "", ""); err == nil || !strings.Contains(err.Error(), "production, staging, local-dev") {
+		t.Errorf("err = %v, want an unknown environment error listing the presets", err)
+	}
+}
+
+func TestSetProfileURLs(t *testing.T) {
+	writeProfile(t, "work", "dev", `version: 2
+config:
+  hub_url: http://localhost:3001
+  builder_url: http://localhost:3001
+  orchestrator_url: http://localhost:8090
+  tenant_id: t-1
+  token: tok-0123456789abcdef
+`)
+	ref := ProfileRef{Name: "work", Profile: "dev"}
+
+	urls, _ := EnvironmentURLs(EnvProduction, "", "", "")
+	if _, err := SetProfileURLs(ref, urls); err != nil {
+		t.Fatal(err)
+	}
+	// Only the orchestrator changes; the rest stays on production
+	saved, err := SetProfileURLs(ref, &ConfigData{OrchestratorURL: "http://localhost:9000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := GetProfileConfig("work", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	production, _ := LookupEnvironment(EnvProduction)
+	if cfg.Config.HubURL != production.HubURL || cfg.Config.OrchestratorURL != "http://localhost:9000" {
+		t.Errorf("saved config = %+v", cfg.Config)
+	}
+	if cfg.Config.Token != "tok-0123456789abcdef" || cfg.Config.TenantID != "t-1" {
+		t.Errorf("credentials not kept: %+v", cfg.Config)
+	}
+	if EnvironmentOf(saved) != "" {
+		t.Errorf("mixed URLs reported as environment %q", EnvironmentOf(saved))
+	}
+
+	if _, err := SetProfileURLs(ProfileRef{Name: "work", Profile: "missing"}, urls); err == nil {
+		t.Error("expected an error for a missing profile")
+	}
+}
+
+func TestWithDefaultURLs(t *testing.T) {
+	cfg := &ConfigData{OrchestratorURL: "http://localhost:8090", Token: "tok"}
+	filled := cfg.WithDefaultURLs()
+	defaults := DefaultConfigData()
+	if filled.HubURL != defaults.HubURL || filled.BuilderURL != defaults.BuilderURL {
+		t.Errorf("urls = %+v, want the default hub and builder", filled)
+	}
+	if filled.OrchestratorURL != "http://localhost:8090" || filled.Token != "tok" {
+		t.Errorf("config = %+v, want the profile's own orchestrator and token kept", filled)
+	}
+	if cfg.HubURL != "" {
+		t.Error("WithDefaultURLs changed the profile's config")
+	}
+}
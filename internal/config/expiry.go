@@ -0,0 +1,47 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// TokenExpiryWarning is how close to expiry a token must be before commands warn.
+const TokenExpiryWarning = 24 * time.Hour
+
+// TokenExpiry returns when the access token expires: the expiry recorded at
+// login, or else the exp claim when the token is a JWT. ok is false if the
+// expiry is unknown or the token does not expire.
+func (c *ConfigData) TokenExpiry() (expires time.Time, ok bool) {
+	if c.TokenExpiresAt != "" {
+		if t, err := time.Parse(time.RFC3339, c.TokenExpiresAt); err == nil {
+			return t, true
+		}
+	}
+	return JWTExpiry(c.Token)
+}
+
+// JWTExpiry reads the exp claim of a JWT. The signature is not verified; the
+// result is only used to warn before the server starts rejecting the token.
+func JWTExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == "" {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0).UTC(), true
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"u-1","exp":1790000000}`))
+	jwt := "eyJhbGciOiJIUzI1NiJ9." + payload + ".c2ln"
+
+	got, ok := JWTExpiry(jwt)
+	if !ok || !got.Equal(time.Unix(1790000000, 0)) {
+		t.Errorf("JWTExpiry = %v, %v", got, ok)
+	}
+	if _, ok := JWTExpiry("sk_live_abc"); ok {
+		t.Error("an opaque API key should have no known expiry")
+	}
+
+	// The expiry recorded at login wins over the token's claim
+	cfg := &ConfigData{Token: jwt, TokenExpiresAt: "2030-01-02T03:04:05Z"}
+	if got, ok := cfg.TokenExpiry(); !ok || got.Format(time.RFC3339) != "2030-01-02T03:04:05Z" {
+		t.Errorf("TokenExpiry = %v, %v", got, ok)
+	}
+	cfg.TokenExpiresAt = ""
+	if got, ok := cfg.TokenExpiry(); !ok || got.Unix() != 1790000000 {
+		t.Errorf("TokenExpiry from JWT = %v, %v", got, ok)
+	}
+}
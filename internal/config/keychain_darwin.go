@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// osKeychain keeps credentials in the macOS login keychain through the
+// security tool, as generic passwords of the cozyctl service.
+type osKeychain struct{}
+
+// securityNotFound is the exit status of security for a missing item.
+const securityNotFound = 44
+
+func (osKeychain) get(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (osKeychain) set(account, secret string) error {
+	// Run interactively so the secret is passed on stdin rather than in the
+	// process's arguments, hex encoded so it needs no quoting
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		quoteSecurityArg(keychainService), quoteSecurityArg(account), hex.EncodeToString([]byte(secret))))
+	if out, err := cmd.CombinedOutput(); err != nil || len(strings.TrimSpace(string(out))) > 0 {
+		if err == nil {
+			err = errors.New(strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("security add-generic-password: %w", err)
+	}
+	return nil
+}
+
+func (osKeychain) delete(account string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", account).Run()
+	if err = securityError(err); err != nil && !errors.Is(err, errSecretNotFound) {
+		return err
+	}
+	return nil
+}
+
+func securityError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+		return errSecretNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("security: %w", err)
+	}
+	return nil
+}
+
+// quoteSecurityArg quotes an argument for security's interactive mode.
+func quoteSecurityArg(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
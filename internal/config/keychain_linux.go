@@ -0,0 +1,51 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// osKeychain keeps credentials with the Secret Service (GNOME Keyring,
+// KWallet, KeePassXC) through the secret-tool command, as items with the
+// attributes service=cozyctl and account=<name>/<profile>:<setting>.
+type osKeychain struct{}
+
+func (osKeychain) get(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", keychainService, "account", account).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		// secret-tool exits 1 without output when nothing matches
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) == 0 {
+			return "", errSecretNotFound
+		}
+		return "", secretToolError(err)
+	}
+	return string(out), nil
+}
+
+func (osKeychain) set(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", "cozyctl "+account, "service", keychainService, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if _, err := cmd.Output(); err != nil {
+		return secretToolError(err)
+	}
+	return nil
+}
+
+func (osKeychain) delete(account string) error {
+	// Clearing an item that doesn't exist succeeds
+	if _, err := exec.Command("secret-tool", "clear", "service", keychainService, "account", account).Output(); err != nil {
+		return secretToolError(err)
+	}
+	return nil
+}
+
+func secretToolError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("secret-tool: %s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return fmt.Errorf("secret-tool: %w", err)
+}
@@ -0,0 +1,16 @@
+//go:build !darwin && !linux && !windows
+
+package config
+
+import "errors"
+
+// osKeychain reports that this platform has no supported credential store.
+type osKeychain struct{}
+
+var errNoKeychain = errors.New("no supported OS keychain on this platform")
+
+func (osKeychain) get(account string) (string, error) { return "", errNoKeychain }
+
+func (osKeychain) set(account, secret string) error { return errNoKeychain }
+
+func (osKeychain) delete(account string) error { return errNoKeychain }
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LastUsedFile, inside a profile directory, holds when a command last
+// loaded the profile.
+const LastUsedFile = "last_used"
+
+// TouchLastUsed records that name/profile is in use at now. Recording is
+// best effort: a failure never stops the command.
+func TouchLastUsed(name, profile string, now time.Time) {
+	dir, err := ProfileDir(name, profile)
+	if err != nil {
+		return
+	}
+	os.WriteFile(filepath.Join(dir, LastUsedFile), []byte(now.UTC().Format(time.RFC3339)+"\n"), 0600)
+}
+
+// LastUsed returns when a command last loaded name/profile. Profiles not
+// used since tracking began fall back to when their config was last
+// written, reported with recorded false. The zero time means neither is
+// known.
+func LastUsed(name, profile string) (at time.Time, recorded bool) {
+	dir, err := ProfileDir(name, profile)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, LastUsedFile)); err == nil {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data))); err == nil {
+			return t, true
+		}
+	}
+	configPath, err := ProfileConfigPath(name, profile)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if info, err := os.Stat(configPath); err == nil {
+		return info.ModTime(), false
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// CurrentConfigVersion is the profile config layout written by this version of cozyctl.
+//
+//	1: flat layout, settings at the top level (hub_url, token, ...)
+//	2: nested layout, settings under config: alongside current_name/current_profile
+const CurrentConfigVersion = 2
+
+// migration upgrades a raw profile config from one version to the next.
+type migration struct {
+	from        int
+	description string
+	apply       func(raw map[string]any, name, profile string)
+}
+
+// migrations are applied in order, each moving the config up one version.
+var migrations = []migration{
+	{
+		from:        1,
+		description: "move top-level settings under config:",
+		apply:       migrateFlatToNested,
+	},
+}
+
+// configDataKeys are the settings that live under config: in version 2.
+var configDataKeys = []string{
+	"hub_url", "builder_url", "orchestrator_url", "tenant_id", "token", "refresh_token",
+	"registry_url", "registry_prefix", "registry_user", "registry_password", "gh_token",
+}
+
+func migrateFlatToNested(raw map[string]any, name, profile string) {
+	nested, _ := raw["config"].(map[string]any)
+	if nested == nil {
+		nested = map[string]any{}
+	}
+	for _, key := range configDataKeys {
+		if v, ok := raw[key]; ok {
+			if _, exists := nested[key]; !exists {
+				nested[key] = v
+			}
+			delete(raw, key)
+		}
+	}
+	raw["config"] = nested
+
+	if _, ok := raw["current_name"]; !ok {
+		raw["current_name"] = name
+	}
+	if _, ok := raw["current_profile"]; !ok {
+		raw["current_profile"] = profile
+	}
+}
+
+// MigrationResult describes the upgrade of one profile config.
+type MigrationResult struct {
+	Path    string
+	From    int
+	To      int
+	Changes []string // Descriptions of the migrations applied
+	Before  []byte
+	After   []byte
+}
+
+// Changed reports whether the config needed upgrading.
+func (r *MigrationResult) Changed() bool {
+	return r.From != r.To
+}
+
+// configVersion detects the layout version of a raw profile config.
+// Configs written before versioning have no version key.
+func configVersion(raw map[string]any) int {
+	if v, ok := raw["version"].(int); ok {
+		return v
+	}
+	if _, ok := raw["config"]; ok {
+		return 2
+	}
+	return 1
+}
+
+// MigrateProfileConfig upgrades a profile config file to CurrentConfigVersion.
+// With dryRun, the result is computed but the file is left untouched. When the
+// layout changes, the original is kept alongside as config.yaml.v<N>.bak.
+func MigrateProfileConfig(name, profile string, dryRun bool) (*MigrationResult, error) {
+	configPath, err := ProfileConfigPath(name, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("profile '%s/%s' not found", name, profile)
+		}
+		return nil, fmt.Errorf("failed to read profile config: %w", err)
+	}
+
+	raw := map[string]any{}
+	if err := yaml.Unmarshal(before, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse profile config: %w", err)
+	}
+	if raw == nil {
+		raw = map[string]any{}
+	}
+
+	result := &MigrationResult{Path: configPath, From: configVersion(raw), Before: before, After: before}
+	result.To = result.From
+	if result.From > CurrentConfigVersion {
+		return nil, fmt.Errorf("profile '%s/%s' uses config version %d, newer than this cozyctl supports (%d); please upgrade cozyctl",
+			name, profile, result.From, CurrentConfigVersion)
+	}
+
+	_, hasVersion := raw["version"]
+	if result.From == CurrentConfigVersion && hasVersion {
+		return result, nil
+	}
+
+	for _, m := range migrations {
+		if m.from < result.From {
+			continue
+		}
+		m.apply(raw, name, profile)
+		result.Changes = append(result.Changes, m.description)
+	}
+	if !hasVersion {
+		result.Changes = append(result.Changes, fmt.Sprintf("record version: %d", CurrentConfigVersion))
+	}
+	raw["version"] = CurrentConfigVersion
+	result.To = CurrentConfigVersion
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(raw); err != nil {
+		return nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	result.After = buf.Bytes()
+
+	if dryRun {
+		return result, nil
+	}
+
+	if result.Changed() {
+		backupPath := fmt.Sprintf("%s.v%d.bak", configPath, result.From)
+		if err := os.WriteFile(backupPath, before, 0600); err != nil {
+			return nil, fmt.Errorf("failed to back up profile config: %w", err)
+		}
+	}
+	if err := os.WriteFile(configPath, result.After, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+
+	return result, nil
+}
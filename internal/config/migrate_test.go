@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProfile(t *testing.T, name, profile, content string) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	path, err := ProfileConfigPath(name, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMigrateFlatConfig(t *testing.T) {
+	flat := "hub_url: https://hub.example\ntoken: secret\ntenant_id: t-1\ngh_token: gh\n"
+	path := writeProfile(t, "work", "dev", flat)
+
+	result, err := MigrateProfileConfig("work", "dev", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.From != 1 || result.To != CurrentConfigVersion || len(result.Changes) == 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if data, _ := os.ReadFile(path); string(data) != flat {
+		t.Fatal("dry run modified the config file")
+	}
+
+	cfg, err := GetProfileConfig("work", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != CurrentConfigVersion || cfg.CurrentName != "work" || cfg.CurrentProfile != "dev" {
+		t.Errorf("unexpected profile config: %+v", cfg)
+	}
+	if cfg.Config.HubURL != "https://hub.example" || cfg.Config.Token != "secret" || cfg.Config.TenantID != "t-1" {
+		t.Errorf("settings not moved under config: %+v", cfg.Config)
+	}
+	if backup, err := os.ReadFile(path + ".v1.bak"); err != nil || string(backup) != flat {
+		t.Errorf("expected original kept as backup, got %q (%v)", backup, err)
+	}
+
+	result, err = MigrateProfileConfig("work", "dev", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 0 {
+		t.Errorf("expected migrated config to be up to date, got %v", result.Changes)
+	}
+}
+
+func TestMigrateStampsUnversionedConfig(t *testing.T) {
+	path := writeProfile(t, "work", "dev", "current_name: work\ncurrent_profile: dev\nconfig:\n  token: secret\n")
+
+	result, err := MigrateProfileConfig("work", "dev", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Changed() || len(result.Changes) != 1 {
+		t.Errorf("expected only a version stamp, got %+v", result)
+	}
+	if _, err := os.Stat(path + ".v2.bak"); !os.IsNotExist(err) {
+		t.Error("expected no backup for a version stamp")
+	}
+}
+
+func TestMigrateRejectsNewerConfig(t *testing.T) {
+	writeProfile(t, "work", "dev", "version: 99\nconfig:\n  token: secret\n")
+
+	if _, err := GetProfileConfig("work", "dev"); err == nil {
+		t.Fatal("expected an error for a config from a newer cozyctl")
+	}
+}
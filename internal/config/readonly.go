@@ -0,0 +1,41 @@
+package config
+
+import "fmt"
+
+// SetProfileReadOnly marks a saved profile read-only, so commands using it
+// can't change anything on the server, or clears the mark.
+func SetProfileReadOnly(ref ProfileRef, readOnly bool) error {
+	ref, err := ResolveProfileRef(ref)
+	if err != nil {
+		return err
+	}
+	if !ProfileExists(ref.Name, ref.Profile) {
+		return fmt.Errorf("profile '%s/%s' does not exist (run 'cozyctl login' first)", ref.Name, ref.Profile)
+	}
+
+	// Read without environment overrides, which must not be saved
+	profileCfg, err := readProfileConfig(ref.Name, ref.Profile, false)
+	if err != nil {
+		return fmt.Errorf("failed to load profile config: %w", err)
+	}
+	if profileCfg.Config == nil {
+		profileCfg.Config = &ConfigData{}
+	}
+	profileCfg.Config.ReadOnly = readOnly
+
+	if err := SaveProfileConfig(ref.Name, ref.Profile, profileCfg); err != nil {
+		return fmt.Errorf("failed to save profile config: %w", err)
+	}
+	return nil
+}
+
+// ProfileReadOnly reports whether the profile selected by ref is read-only,
+// by its setting or COZY_READ_ONLY. Profiles that can't be read aren't.
+func ProfileReadOnly(ref ProfileRef) bool {
+	ref, err := ResolveProfileRef(ref)
+	if err != nil || !ProfileExists(ref.Name, ref.Profile) {
+		return false
+	}
+	profileCfg, err := GetProfileConfig(ref.Name, ref.Profile)
+	return err == nil && profileCfg.Config != nil && profileCfg.Config.ReadOnly
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Sources of a resolved setting
+const (
+	SourceFile     = "file"
+	SourceDefault  = "default"
+	SourceUnset    = "unset"
+	SourceKeychain = "keychain" // Credentials of profiles using the keychain store
+)
+
+// Setting is one resolved config value and where it came from.
+type Setting struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+	Source string `json:"source" yaml:"source"` // file, keychain, default, unset, or "env COZY_..."
+	Secret bool   `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// ResolvedConfig is the configuration a command would actually use for a
+// profile, after environment overrides and defaults are applied.
+type ResolvedConfig struct {
+	Name     string    `json:"name" yaml:"name"`
+	Profile  string    `json:"profile" yaml:"profile"`
+	Path     string    `json:"path" yaml:"path"`
+	Settings []Setting `json:"settings" yaml:"settings"`
+}
+
+// settingFields lists the profile settings in display order.
+var settingFields = []struct {
+	key    string
+	secret bool
+	get    func(*ConfigData) string
+}{
+	{"hub_url", false, func(c *ConfigData) string { return c.HubURL }},
+	{"builder_url", false, func(c *ConfigData) string { return c.BuilderURL }},
+	{"orchestrator_url", false, func(c *ConfigData) string { return c.OrchestratorURL }},
+	{"tenant_id", false, func(c *ConfigData) string { return c.TenantID }},
+	{"token", true, func(c *ConfigData) string { return c.Token }},
+	{"refresh_token", true, func(c *ConfigData) string { return c.RefreshToken }},
+	{"token_expires_at", false, func(c *ConfigData) string { return c.TokenExpiresAt }},
+	{"registry_url", false, func(c *ConfigData) string { return c.RegistryURL }},
+	{"registry_prefix", false, func(c *ConfigData) string { return c.RegistryPrefix }},
+	{"registry_user", false, func(c *ConfigData) string { return c.RegistryUser }},
+	{"registry_password", true, func(c *ConfigData) string { return c.RegistryPassword }},
+	{"read_only", false, func(c *ConfigData) string {
+		if c.ReadOnly {
+			return "true"
+		}
+		return ""
+	}},
+}
+
+// ResolveConfig loads the profile selected by ref and reports each setting's
+// effective value and source. Secret values are returned as-is; use
+// RedactSecrets before showing them.
+func ResolveConfig(ref ProfileRef) (*ResolvedConfig, error) {
+	ref, err := ResolveProfileRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	profileCfg, err := GetProfileConfig(ref.Name, ref.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile config: %w", err)
+	}
+	effective := profileCfg.Config
+	if effective == nil {
+		effective = &ConfigData{}
+	}
+
+	configPath, err := ProfileConfigPath(ref.Name, ref.Profile)
+	if err != nil {
+		return nil, err
+	}
+	fileValues, err := readConfigSection(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := DefaultConfigData()
+	resolved := &ResolvedConfig{Name: ref.Name, Profile: ref.Profile, Path: configPath}
+	for _, f := range settingFields {
+		setting := Setting{Key: f.key, Value: f.get(effective), Secret: f.secret}
+		switch {
+		case envOverride(f.key) != "":
+			setting.Source = "env " + envOverride(f.key)
+		case fileValues[f.key] != "":
+			setting.Source = SourceFile
+		case f.secret && effective.CredentialStore == CredentialStoreKeychain && setting.Value != "":
+			setting.Source = SourceKeychain
+		case f.get(defaults) != "":
+			// Commands fall back to the default when the profile leaves it empty
+			setting.Value = f.get(defaults)
+			setting.Source = SourceDefault
+		default:
+			setting.Source = SourceUnset
+		}
+		resolved.Settings = append(resolved.Settings, setting)
+	}
+
+	return resolved, nil
+}
+
+// RedactSecrets masks the values of secret settings.
+func (r *ResolvedConfig) RedactSecrets() {
+	for i, s := range r.Settings {
+		if s.Secret {
+			r.Settings[i].Value = RedactSecret(s.Value)
+		}
+	}
+}
+
+// RedactSecret masks a secret, keeping the last four characters of long
+// values so different tokens can still be told apart.
+func RedactSecret(value string) string {
+	switch {
+	case value == "":
+		return ""
+	case len(value) < 16:
+		return "********"
+	default:
+		return "********" + value[len(value)-4:]
+	}
+}
+
+// envOverride returns the name of the set environment variable overriding
+// key, or "". COZY_<KEY> takes precedence over COZY_CONFIG_<KEY>, matching
+// GetProfileConfig.
+func envOverride(key string) string {
+	for _, name := range []string{"COZY_" + strings.ToUpper(key), "COZY_CONFIG_" + strings.ToUpper(key)} {
+		if os.Getenv(name) != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// readConfigSection returns the values set in the config: section of a profile file.
+func readConfigSection(configPath string) (map[string]string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile config: %w", err)
+	}
+
+	var raw struct {
+		Config map[string]any `yaml:"config"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse profile config: %w", err)
+	}
+
+	values := map[string]string{}
+	for k, v := range raw.Config {
+		if v != nil {
+			values[k] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
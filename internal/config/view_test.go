@@ -0,0 +1,60 @@
+package config
+
+import "testing"
+
+func TestResolveConfigSources(t *testing.T) {
+	writeProfile(t, "work", "dev", `version: 2
+config:
+  hub_url: https://hub.example
+  orchestrator_url: ""
+  tenant_id: t-1
+  token: tok-0123456789abcdef
+`)
+	t.Setenv("COZY_BUILDER_URL", "https://builder.override")
+
+	resolved, err := ResolveConfig(ProfileRef{Name: "work", Profile: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := map[string]Setting{}
+	for _, s := range resolved.Settings {
+		settings[s.Key] = s
+	}
+
+	cases := []struct{ key, value, source string }{
+		{"hub_url", "https://hub.example", SourceFile},
+		{"builder_url", "https://builder.override", "env COZY_BUILDER_URL"},
+		{"orchestrator_url", DefaultConfigData().OrchestratorURL, SourceDefault},
+		{"token", "tok-0123456789abcdef", SourceFile},
+		{"registry_url", "", SourceUnset},
+	}
+	for _, c := range cases {
+		got := settings[c.key]
+		if got.Value != c.value || got.Source != c.source {
+			t.Errorf("%s = %q (%s), want %q (%s)", c.key, got.Value, got.Source, c.value, c.source)
+		}
+	}
+
+	resolved.RedactSecrets()
+	for _, s := range resolved.Settings {
+		if s.Key == "token" && s.Value != "********cdef" {
+			t.Errorf("token not redacted: %q", s.Value)
+		}
+		if s.Key == "hub_url" && s.Value != "https://hub.example" {
+			t.Errorf("non-secret redacted: %q", s.Value)
+		}
+	}
+}
+
+func TestRedactSecret(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                      "",
+		"short":                 "********",
+		"a-long-enough-token-1": "********en-1",
+	} {
+		if got := RedactSecret(in); got != want {
+			t.Errorf("RedactSecret(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -1,58 +1,277 @@
 package deploy
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/cozy-creator/cozyctl/internal/api"
 	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+	"github.com/cozy-creator/cozyctl/internal/rollout"
+	"github.com/cozy-creator/cozyctl/internal/smoke"
+	"github.com/cozy-creator/cozyctl/internal/ui"
 )
 
-// Run executes the deploy process: send build-id to cozy-hub for promotion.
-func Run(buildID string) error {
-	// Load config for tenant-id and builder URL
-	defaultCfg, err := config.GetDefaultConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
+// approvalPollInterval is how often --wait-for-approval checks a pending
+// deploy.
+var approvalPollInterval = 5 * time.Second
 
-	profileCfg, err := config.GetProfileConfig(defaultCfg.CurrentName, defaultCfg.CurrentProfile)
-	if err != nil {
-		return fmt.Errorf("failed to load profile config: %w", err)
-	}
+// Options contains the options for deploying an existing build.
+type Options struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	BuildID      string
+	DeploymentID string // Target deployment (optional; defaults to the build's deployment)
+
+	SmokeTest     *smoke.Test // Optional post-deploy check
+	SmokeRollback bool        // Re-activate the previous build if the smoke test fails
 
-	if profileCfg.Config == nil {
-		return fmt.Errorf("not logged in (run 'cozyctl login' first)")
+	AutoRollback *rollout.Policy // Watch the rollout and re-activate the previous build if it fails
+
+	WaitForApproval bool // If the deployment requires approval, wait for the decision
+
+	SummaryFile string    // Write a JSON summary of the result here (optional)
+	Output      ui.Output // Print the summary to stdout as JSON or YAML; progress goes to stderr
+	Progress    ui.Mode
+
+	policies *PolicyCheck // The project's policies, for deploys of a project (--all, --queue)
+}
+
+// Run executes the deploy process: send build-id to cozy-hub for promotion.
+// No packaging or rebuilding takes place.
+func Run(ctx context.Context, opts Options) error {
+	if opts.BuildID == "" {
+		return fmt.Errorf("build ID is required")
 	}
 
-	if err := profileCfg.Config.Validate(); err != nil {
+	// Load config for tenant-id and builder URL
+	profileCfg, err := config.LoadLoggedInProfile(opts.Profile)
+	if err != nil {
 		return err
 	}
 
-	tenantID := profileCfg.Config.TenantID
-	fmt.Printf("Tenant ID: %s\n", tenantID)
-	fmt.Printf("Build ID: %s\n", buildID)
-
 	// Get builder URL
 	builderURL := profileCfg.Config.BuilderURL
 	if builderURL == "" {
 		builderURL = config.DefaultConfigData().BuilderURL
 	}
 
-	// Create cozy-hub builder API client
-	client := api.NewBuilderClient(builderURL, profileCfg.Config.Token)
+	// Create cozy-hub builder and orchestrator API clients
+	builder := api.NewBuilderClient(builderURL, profileCfg.Config.Token, opts.Transport)
+	orchestrator := newOrchestratorClient(profileCfg.Config, opts.Transport)
 
-	// Deploy via cozy-hub
-	fmt.Println("\nDeploying via cozy-hub...")
-	deployment, err := client.DeployBuild(buildID, tenantID)
+	progress := ui.NewWithMode(ProgressOutput(opts.Output), opts.Progress)
+	defer progress.Close()
+
+	recorder := history.Start(opts.Profile, "deploy")
+	err = promote(ctx, progress, builder, orchestrator, profileCfg.Config.TenantID, opts)
+	recorder.FinishProgress(progress, err)
+	return WriteSummary(opts.SummaryFile, opts.Output, "deploy", progress, orchestrator, OrchestratorURL(profileCfg.Config), err)
+}
+
+// promote verifies and deploys a build, then runs the optional smoke test and
+// rollout watch.
+func promote(ctx context.Context, progress *ui.Progress, client api.BuilderAPI, orchestrator api.OrchestratorAPI, tenantID string, opts Options) error {
+	progress.Printf("Tenant ID: %s\n", tenantID)
+	progress.Printf("Build ID: %s\n", opts.BuildID)
+	progress.SetID("build_id", opts.BuildID)
+	if opts.DeploymentID != "" {
+		progress.Printf("Deployment: %s\n", opts.DeploymentID)
+	}
+
+	// Only successful builds can be deployed
+	stage := progress.Start("Verifying build")
+	status, err := client.GetBuildStatus(opts.BuildID)
 	if err != nil {
-		return fmt.Errorf("failed to deploy: %w", err)
+		return stage.Fail(fmt.Errorf("failed to get build %s: %w", opts.BuildID, err))
+	}
+	switch status.Status {
+	case "success", "succeeded":
+	case "pending", "queued", "running":
+		return stage.Fail(fmt.Errorf("build %s is still %s (wait for it to finish before deploying)", opts.BuildID, status.Status))
+	default:
+		return stage.Fail(fmt.Errorf("build %s has status %q; only successful builds can be deployed", opts.BuildID, status.Status))
 	}
+	stage.Done()
 
-	fmt.Printf("\nDeployment successful!\n")
-	fmt.Printf("  ID: %s\n", deployment.ID)
-	fmt.Printf("  Tenant: %s\n", deployment.TenantID)
-	fmt.Printf("  Active Build: %s\n", deployment.ActiveBuildID)
-	fmt.Printf("  Image: %s\n", deployment.ImageTag)
+	// Deploy via cozy-hub
+	stage = progress.Start("Deploying")
+	req := &api.DeployBuildRequest{
+		TenantID:     tenantID,
+		DeploymentID: opts.DeploymentID,
+	}
+	if opts.policies != nil {
+		existing, err := orchestrator.GetDeployment(opts.DeploymentID)
+		if err != nil {
+			return stage.Fail(fmt.Errorf("failed to check deployment: %w", err))
+		}
+		action := "create"
+		if existing != nil {
+			action = "update"
+		}
+		if err := opts.policies.Run(ctx, progress, action, opts.DeploymentID, req); err != nil {
+			return stage.Fail(err)
+		}
+	}
+	deployedAt := time.Now()
+	deployment, err := client.DeployBuild(opts.BuildID, req)
+	var pending *api.PendingApprovalError
+	if errors.As(err, &pending) {
+		stage.Done()
+		approval := pending.Approval
+		progress.SetID("approval_id", approval.ID)
+		progress.SetID("deployment_id", approval.DeploymentID)
+		progress.Printf("\n%s requires approval; the deploy is waiting for it.\n", approval.DeploymentID)
+		progress.Printf("  Approval: %s\n", approval.ID)
+		if approval.URL != "" {
+			progress.Printf("  Review: %s\n", approval.URL)
+		}
+		progress.Printf("  Approve with: cozyctl approvals approve %s\n", approval.ID)
+		if !opts.WaitForApproval {
+			return nil
+		}
 
+		stage = progress.Start("Waiting for approval")
+		deployment, err = awaitApproval(ctx, client, approval.ID)
+		if err != nil {
+			return stage.Fail(err)
+		}
+		stage.Done()
+	} else if err != nil {
+		return stage.Fail(fmt.Errorf("failed to deploy: %w", err))
+	} else {
+		stage.Done()
+	}
+	progress.SetID("deployment_id", deployment.ID)
+	progress.SetID("image_tag", deployment.ImageTag)
+
+	progress.Printf("\nDeployment successful!\n")
+	progress.Printf("  ID: %s\n", deployment.ID)
+	progress.Printf("  Tenant: %s\n", deployment.TenantID)
+	progress.Printf("  Active Build: %s\n", deployment.ActiveBuildID)
+	progress.Printf("  Image: %s\n", deployment.ImageTag)
+
+	if opts.SmokeTest != nil {
+		stage = progress.Start("Smoke test")
+		if err := opts.SmokeTest.Run(ctx, progress, orchestrator, deployment.ID, smoke.DefaultReadyTimeout); err != nil {
+			if ctx.Err() != nil {
+				return stage.Fail(Unverified(deployment.ID, err))
+			}
+			stage.Fail(err)
+			if !opts.SmokeRollback {
+				return err
+			}
+			return rollBack(progress, client, tenantID, deployment, err)
+		}
+		stage.Done()
+	}
+
+	if opts.AutoRollback != nil {
+		stage = progress.Start("Watching rollout")
+		report := rollout.Watch(ctx, progress, orchestrator, deployment.ID, deployedAt, *opts.AutoRollback)
+		if ctx.Err() != nil {
+			return stage.Fail(Unverified(deployment.ID, ctx.Err()))
+		}
+		report.Write(progress)
+		if !report.Healthy {
+			err := fmt.Errorf("rollout failed: %s", report.Reason)
+			stage.Fail(err)
+			return rollBack(progress, client, tenantID, deployment, err)
+		}
+		stage.Done()
+	}
+
+	progress.Println(progress.Summary())
 	return nil
 }
+
+// awaitApproval polls a pending deploy until it is decided, returning the
+// deployment once the build is active.
+func awaitApproval(ctx context.Context, client api.BuilderAPI, id string) (*api.BuilderDeployResponse, error) {
+	for {
+		approval, err := client.GetApproval(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check approval %s: %w", id, err)
+		}
+		switch approval.Status {
+		case api.ApprovalPending:
+		case api.ApprovalApproved:
+			hub, err := client.GetHubDeployment(approval.DeploymentID)
+			if err != nil {
+				return nil, fmt.Errorf("deploy was approved, but fetching %s failed: %w", approval.DeploymentID, err)
+			}
+			if hub == nil {
+				return nil, fmt.Errorf("deploy was approved, but deployment '%s' was not found", approval.DeploymentID)
+			}
+			return hub.DeployResponse(), nil
+		case api.ApprovalRejected:
+			msg := "deploy was rejected"
+			if approval.DecidedBy != "" {
+				msg += " by " + approval.DecidedBy
+			}
+			if approval.Comment != "" {
+				msg += ": " + approval.Comment
+			}
+			return nil, errors.New(msg)
+		default:
+			return nil, fmt.Errorf("approval %s is %s", id, approval.Status)
+		}
+
+		if err := interrupt.Sleep(ctx, approvalPollInterval); err != nil {
+			return nil, &interrupt.Error{
+				Hint: fmt.Sprintf("approval %s is still pending; check it with `cozyctl approvals get %s`", id, id),
+				Err:  err,
+			}
+		}
+	}
+}
+
+// rollBack re-activates the build that was live before deployment and
+// returns cause, noting if there was nothing to roll back to or the
+// rollback itself failed.
+func rollBack(progress *ui.Progress, client api.BuilderAPI, tenantID string, deployment *api.BuilderDeployResponse, cause error) error {
+	if deployment.PreviousBuildID == "" {
+		return fmt.Errorf("%w (no previous build to roll back to)", cause)
+	}
+
+	stage := progress.Start("Rolling back")
+	progress.Printf("Re-activating previous build %s...\n", deployment.PreviousBuildID)
+	if _, err := client.DeployBuild(deployment.PreviousBuildID, &api.DeployBuildRequest{
+		TenantID:     tenantID,
+		DeploymentID: deployment.ID,
+	}); err != nil {
+		stage.Fail(err)
+		return fmt.Errorf("%w (rollback failed: %v)", cause, err)
+	}
+	stage.Done()
+	progress.Printf("Rolled back to build %s\n", deployment.PreviousBuildID)
+
+	return cause
+}
+
+// Unverified reports that a deploy was interrupted after the deployment
+// changed but before it was checked. Nothing is rolled back: the change may
+// well be healthy, and rolling back is itself a deploy.
+func Unverified(deploymentID string, err error) error {
+	return &interrupt.Error{
+		Hint: fmt.Sprintf("%s was deployed but not verified, and was not rolled back; check it with `cozyctl status %s`", deploymentID, deploymentID),
+		Err:  err,
+	}
+}
+
+// newOrchestratorClient creates an orchestrator API client for a profile.
+func newOrchestratorClient(cfg *config.ConfigData, transport http.RoundTripper) *api.Client {
+	return api.NewClient(OrchestratorURL(cfg), cfg.Token, transport)
+}
+
+// OrchestratorURL is the orchestrator a profile deploys to.
+func OrchestratorURL(cfg *config.ConfigData) string {
+	if cfg.OrchestratorURL == "" {
+		return config.DefaultConfigData().OrchestratorURL
+	}
+	return cfg.OrchestratorURL
+}
@@ -0,0 +1,271 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/rollout"
+	"github.com/cozy-creator/cozyctl/internal/smoke"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// newMockClients starts a mock server and returns clients pointed at it.
+func newMockClients(t *testing.T) (*api.BuilderClient, *api.Client) {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	return api.NewBuilderClient(ts.URL, "token", nil), api.NewClient(ts.URL, "token", nil)
+}
+
+// uploadBuild creates a finished build of deployment on the mock server.
+func uploadBuild(t *testing.T, builder api.BuilderAPI, deployment string) string {
+	t.Helper()
+	resp, err := builder.UploadBuild(strings.NewReader("tarball"), deployment, api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.BuildID
+}
+
+func TestPromoteWithSmokeTest(t *testing.T) {
+	builder, orchestrator := newMockClients(t)
+	buildID := uploadBuild(t, builder, "my-model")
+
+	var out bytes.Buffer
+	expect, _ := smoke.ParseExpectation(`$.status == "ok"`)
+	err := promote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", Options{
+		BuildID:   buildID,
+		SmokeTest: &smoke.Test{Function: "generate", Expect: expect},
+	})
+	if err != nil {
+		t.Fatalf("promote: %v\n%s", err, out.String())
+	}
+
+	for _, want := range []string{"==> Verifying build", "==> Deploying", "Smoke test passed", "Completed in"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPromoteSmokeRollback(t *testing.T) {
+	builder, orchestrator := newMockClients(t)
+	first := uploadBuild(t, builder, "my-model")
+	second := uploadBuild(t, builder, "my-model")
+
+	var out bytes.Buffer
+	if err := promote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", Options{BuildID: first}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect, _ := smoke.ParseExpectation(`$.status == "broken"`)
+	err := promote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", Options{
+		BuildID:       second,
+		SmokeTest:     &smoke.Test{Function: "generate", Expect: expect},
+		SmokeRollback: true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "smoke test failed") {
+		t.Fatalf("expected smoke test failure, got %v", err)
+	}
+
+	hub, err := builder.GetHubDeployment("my-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hub.ActiveBuildID == nil || *hub.ActiveBuildID != first {
+		t.Errorf("active build = %v, want rollback to %s", hub.ActiveBuildID, first)
+	}
+}
+
+func TestPromoteAutoRollback(t *testing.T) {
+	builder, orchestrator := newMockClients(t)
+	first := uploadBuild(t, builder, "my-model")
+	// The mock's workers never become ready on images marked crashloop
+	second := uploadBuild(t, builder, "my-model-"+mockserver.CrashLoopImage)
+
+	var out bytes.Buffer
+	if err := promote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", Options{BuildID: first}); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err := promote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", Options{
+		BuildID:      second,
+		DeploymentID: "my-model",
+		AutoRollback: &rollout.Policy{Window: 100 * time.Millisecond, MaxErrorRate: 0.05, Interval: 20 * time.Millisecond},
+	})
+	if err == nil || !strings.Contains(err.Error(), "rollout failed: no worker became ready") {
+		t.Fatalf("expected a rollout failure, got %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"==> Watching rollout", "Worker failures:", "Rolled back to build " + first} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	deployment, err := orchestrator.GetDeployment("my-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deployment.Status != "ready" || strings.Contains(deployment.ImageURL, mockserver.CrashLoopImage) {
+		t.Errorf("deployment not restored: %+v", deployment)
+	}
+}
+
+func TestPromoteUnknownBuild(t *testing.T) {
+	builder, orchestrator := newMockClients(t)
+
+	err := promote(context.Background(), ui.New(&bytes.Buffer{}), builder, orchestrator, "tenant", Options{BuildID: "missing"})
+	if err == nil || !strings.Contains(err.Error(), "failed to get build missing") {
+		t.Fatalf("expected missing build error, got %v", err)
+	}
+}
+
+func TestPromoteWaitsForApproval(t *testing.T) {
+	server := mockserver.New()
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	builder, orchestrator := api.NewBuilderClient(ts.URL, "token", nil), api.NewClient(ts.URL, "token", nil)
+	server.RequireApproval("prod-model")
+	buildID := uploadBuild(t, builder, "prod-model")
+
+	// Without --wait-for-approval, the deploy is only submitted
+	var out bytes.Buffer
+	if err := promote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", Options{BuildID: buildID}); err != nil {
+		t.Fatalf("promote: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "cozyctl approvals approve approval-") {
+		t.Errorf("output missing the approve command:\n%s", out.String())
+	}
+	if hub, err := builder.GetHubDeployment("prod-model"); err != nil || hub.ActiveBuildID != nil {
+		t.Fatalf("deployed before approval: %+v, %v", hub, err)
+	}
+
+	approvalPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { approvalPollInterval = 5 * time.Second })
+
+	decide := func(approve bool) <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			for {
+				pending, err := builder.ListApprovals(api.ApprovalPending)
+				if err != nil {
+					done <- err
+					return
+				}
+				if len(pending) == 2 {
+					// The newest is the one being waited for
+					if approve {
+						_, err = builder.ApproveDeploy(pending[0].ID, "")
+					} else {
+						_, err = builder.RejectDeploy(pending[0].ID, "load test first")
+					}
+					done <- err
+					return
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}()
+		return done
+	}
+
+	out.Reset()
+	decided := decide(false)
+	err := promote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", Options{BuildID: buildID, WaitForApproval: true})
+	if err := <-decided; err != nil {
+		t.Fatal(err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "rejected") || !strings.Contains(err.Error(), "load test first") {
+		t.Fatalf("got %v, want the rejection", err)
+	}
+
+	out.Reset()
+	buildID = uploadBuild(t, builder, "prod-model")
+	decided = decide(true)
+	err = promote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", Options{BuildID: buildID, WaitForApproval: true})
+	if err := <-decided; err != nil {
+		t.Fatal(err)
+	}
+	if err != nil {
+		t.Fatalf("promote: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "Deployment successful!") || !strings.Contains(out.String(), "Active Build: "+buildID) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestDryRunLocalBuildWritesRequest(t *testing.T) {
+	_, orchestrator := newMockClients(t)
+	dir := t.TempDir()
+	cozyConfig := &build.ToolsCozyConfig{DeploymentID: "my-model", Python: "3.11"}
+	functions := []build.DetectedFunction{{Name: "generate", RequiresGPU: true}}
+	opts := LocalBuildOptions{MinWorkers: 1, MaxWorkers: -1}
+
+	var out bytes.Buffer
+	if err := dryRunLocalBuild(context.Background(), ui.New(&out), orchestrator, dir, "registry.example/me/", cozyConfig, functions, opts, nil); err != nil {
+		t.Fatalf("dryRunLocalBuild: %v\n%s", err, out.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, build.DryRunOutDir, build.DryRunCreateRequest))
+	if err != nil {
+		t.Fatalf("create request not written: %v", err)
+	}
+	var req api.CreateDeploymentRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.ID != "my-model" || !strings.HasPrefix(req.ImageURL, "registry.example/me/") ||
+		req.MinWorkers == nil || *req.MinWorkers != 1 || req.MaxWorkers != nil || len(req.FunctionRequirements) != 1 {
+		t.Errorf("unexpected create request: %s", data)
+	}
+
+	// Nothing was deployed
+	if existing, err := orchestrator.GetDeployment("my-model"); err != nil || existing != nil {
+		t.Errorf("dry run created the deployment (%v, %v)", existing, err)
+	}
+}
+
+func TestDeployImageDeniedByPolicy(t *testing.T) {
+	_, orchestrator := newMockClients(t)
+	dir := t.TempDir()
+	cozyConfig := &build.ToolsCozyConfig{
+		DeploymentID: "my-model",
+		Python:       "3.11",
+		Policy:       &build.PolicyConfig{Params: map[string]any{"max_workers": int64(4)}},
+	}
+	policies, err := NewPolicyCheck(context.Background(), dir, cozyConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = deployImage(context.Background(), ui.New(&out), orchestrator, "my-model", "registry.example/my-model:1", nil, LocalBuildOptions{MinWorkers: -1, MaxWorkers: 8}, policies)
+	if err == nil || !strings.Contains(err.Error(), "max_workers 8 exceeds the limit of 4") {
+		t.Fatalf("deployImage error = %v\n%s", err, out.String())
+	}
+	if existing, _ := orchestrator.GetDeployment("my-model"); existing != nil {
+		t.Error("a denied request must not be submitted")
+	}
+
+	out.Reset()
+	if err := deployImage(context.Background(), ui.New(&out), orchestrator, "my-model", "registry.example/my-model:1", nil, LocalBuildOptions{MinWorkers: -1, MaxWorkers: 2}, policies); err != nil {
+		t.Fatalf("deployImage within the limit: %v\n%s", err, out.String())
+	}
+}
+
+func TestNewPolicyCheckDisabled(t *testing.T) {
+	policies, err := NewPolicyCheck(context.Background(), t.TempDir(), &build.ToolsCozyConfig{DeploymentID: "my-model"}, nil)
+	if err != nil || policies != nil {
+		t.Fatalf("NewPolicyCheck = %v, %v; want nil without configured policies", policies, err)
+	}
+}
@@ -0,0 +1,90 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// FunctionChanges are the functions a deploy adds to and removes from a
+// deployment.
+type FunctionChanges struct {
+	Added   []string
+	Removed []string
+}
+
+// DiffFunctions compares the functions registered on a deployment with the
+// ones a deploy will register in their place. Deploys that register no
+// functions leave the deployment's as they are, so they change nothing.
+func DiffFunctions(live []api.FunctionRequirement, next []build.DetectedFunction) FunctionChanges {
+	var changes FunctionChanges
+	if len(next) == 0 {
+		return changes
+	}
+	for _, fn := range next {
+		if !slices.ContainsFunc(live, func(l api.FunctionRequirement) bool { return l.Name == fn.Name }) {
+			changes.Added = append(changes.Added, fn.Name)
+		}
+	}
+	for _, fn := range live {
+		if !slices.ContainsFunc(next, func(n build.DetectedFunction) bool { return n.Name == fn.Name }) {
+			changes.Removed = append(changes.Removed, fn.Name)
+		}
+	}
+	slices.Sort(changes.Added)
+	slices.Sort(changes.Removed)
+	return changes
+}
+
+// Empty reports whether the deploy keeps the deployment's functions.
+func (c FunctionChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// ErrFunctionChangesNotConfirmed is returned when --confirm-function-changes
+// is given and the changes are not confirmed.
+var ErrFunctionChangesNotConfirmed = errors.New("function changes were not confirmed; the deployment is unchanged")
+
+// CheckFunctionChanges warns about the functions a deploy adds to and
+// removes from deploymentID, since removing one takes its endpoint offline.
+// With confirm, the deploy only goes ahead if the user confirms the changes
+// on in; anything else, including no input, aborts it.
+func CheckFunctionChanges(progress *ui.Progress, in io.Reader, deploymentID string, changes FunctionChanges, confirm bool) error {
+	if changes.Empty() {
+		return nil
+	}
+	if len(changes.Removed) > 0 {
+		progress.Warnf("this deploy removes %s from %s; %s invocations will fail: %s",
+			plural(len(changes.Removed), "a live function", "live functions"), deploymentID,
+			plural(len(changes.Removed), "its", "their"), strings.Join(changes.Removed, ", "))
+	}
+	if len(changes.Added) > 0 {
+		progress.Warnf("this deploy adds %s to %s: %s",
+			plural(len(changes.Added), "a function", "functions"), deploymentID, strings.Join(changes.Added, ", "))
+	}
+	if !confirm {
+		return nil
+	}
+
+	ok, err := ui.Confirm(in, progress, fmt.Sprintf("Change the functions of %s?", deploymentID))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrFunctionChangesNotConfirmed
+	}
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
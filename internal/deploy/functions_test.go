@@ -0,0 +1,55 @@
+package deploy
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestFunctionChanges(t *testing.T) {
+	live := []api.FunctionRequirement{{Name: "generate"}, {Name: "health"}, {Name: "upscale", Disabled: true}}
+	next := []build.DetectedFunction{{Name: "generate"}, {Name: "inpaint"}}
+
+	changes := DiffFunctions(live, next)
+	if !slices.Equal(changes.Added, []string{"inpaint"}) || !slices.Equal(changes.Removed, []string{"health", "upscale"}) {
+		t.Fatalf("changes = %+v", changes)
+	}
+	if !DiffFunctions(live, nil).Empty() {
+		t.Error("a deploy without functions keeps the deployment's")
+	}
+
+	// Without --confirm-function-changes the changes are only warned about
+	var out bytes.Buffer
+	progress := ui.New(&out)
+	if err := CheckFunctionChanges(progress, strings.NewReader(""), "my-model", changes, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"removes live functions from my-model; their invocations will fail: health, upscale",
+		"adds a function to my-model: inpaint",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+	if len(progress.Warnings()) != 2 {
+		t.Errorf("expected the warnings in the summary, got %q", progress.Warnings())
+	}
+
+	// With it, anything but a yes aborts
+	if err := CheckFunctionChanges(ui.New(&out), strings.NewReader(""), "my-model", changes, true); !errors.Is(err, ErrFunctionChangesNotConfirmed) {
+		t.Errorf("no input: got %v, want ErrFunctionChangesNotConfirmed", err)
+	}
+	if err := CheckFunctionChanges(ui.New(&out), strings.NewReader("yes\n"), "my-model", changes, true); err != nil {
+		t.Errorf("confirmed: %v", err)
+	}
+	if err := CheckFunctionChanges(ui.New(&out), strings.NewReader(""), "my-model", FunctionChanges{}, true); err != nil {
+		t.Errorf("no changes need no confirmation, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/hooks"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// RunPreDeployHook runs the project's pre-deploy hook, if it has one, as its
+// own stage. An error means the deploy must not go ahead.
+func RunPreDeployHook(ctx context.Context, progress *ui.Progress, command string, hc hooks.Context) error {
+	if command == "" {
+		return nil
+	}
+
+	stage := progress.Start("Pre-deploy hook")
+	progress.Printf("Running %s\n", command)
+	if err := hooks.Run(ctx, progress, hooks.PreDeploy, command, hc); err != nil {
+		return stage.Fail(err)
+	}
+	stage.Done()
+	return nil
+}
+
+// FunctionNames lists the names of functions, for COZY_FUNCTIONS.
+func FunctionNames(functions []build.DetectedFunction) []string {
+	names := make([]string, len(functions))
+	for i, f := range functions {
+		names[i] = f.Name
+	}
+	return names
+}
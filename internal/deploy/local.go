@@ -0,0 +1,399 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/hooks"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+	"github.com/cozy-creator/cozyctl/internal/rollout"
+	"github.com/cozy-creator/cozyctl/internal/smoke"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/google/uuid"
+)
+
+// LocalBuildOptions contains the options for building locally and deploying.
+type LocalBuildOptions struct {
+	Profile     config.ProfileRef
+	Transport   http.RoundTripper
+	ProjectPath string
+	Registry    string // Registry prefix override (e.g. "docker.io/myuser/")
+	Functions   string
+	MinWorkers  int
+	MaxWorkers  int
+
+	CheckEntrypoint bool          // Run the image locally before pushing
+	CheckDuration   time.Duration // How long to leave the entrypoint running
+
+	SmokeTest     *smoke.Test // Optional post-deploy check
+	SmokeRollback bool        // Restore the previous image if the smoke test fails
+
+	AutoRollback *rollout.Policy // Watch the rollout and restore the previous image if it fails
+
+	Policies []string // Rego policy files or directories, in addition to [tool.cozy.policy]
+
+	ConfirmFunctionChanges bool // Ask before adding or removing the deployment's functions
+
+	DryRun      bool      // Write the Dockerfile, request payload, and archive manifest to .cozy/out instead of deploying
+	SummaryFile string    // Write a JSON summary of the result here (optional)
+	Output      ui.Output // Print the summary to stdout as JSON or YAML; progress goes to stderr
+	Progress    ui.Mode
+}
+
+// RunLocalBuild builds the project image with the local Docker daemon, pushes it
+// to the configured registry, and then creates or updates the deployment with the
+// pushed image. The server-side builder is not involved.
+func RunLocalBuild(ctx context.Context, opts LocalBuildOptions) (err error) {
+	absPath, err := filepath.Abs(opts.ProjectPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return fmt.Errorf("cannot access path: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", absPath)
+	}
+
+	pyprojectPath := filepath.Join(absPath, build.PyProjectTomlPath)
+	if _, err := os.Stat(pyprojectPath); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("pyproject.toml not found in %s", absPath)
+	}
+
+	cozyConfig, err := build.GetToolsCozyConfig(pyprojectPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse pyproject.toml: %w", err)
+	}
+
+	if cozyConfig.DeploymentID == "" {
+		return fmt.Errorf("[tool.cozy] deployment-id is required in pyproject.toml")
+	}
+
+	// [tool.cozy.workers] applies where the flags weren't given
+	if w := cozyConfig.Workers; w != nil {
+		if opts.MinWorkers < 0 && w.Min != nil {
+			opts.MinWorkers = *w.Min
+		}
+		if opts.MaxWorkers < 0 && w.Max != nil {
+			opts.MaxWorkers = *w.Max
+		}
+	}
+
+	profileCfg, err := config.LoadLoggedInProfile(opts.Profile)
+	if err != nil {
+		return err
+	}
+	cfg := profileCfg.Config
+
+	registryPrefix := opts.Registry
+	if registryPrefix == "" {
+		registryPrefix = cfg.RegistryPrefix
+	}
+	if registryPrefix == "" {
+		return fmt.Errorf("no registry configured for local builds (pass --registry or set registry_prefix in your profile)")
+	}
+
+	progress := ui.NewWithMode(ProgressOutput(opts.Output), opts.Progress)
+	defer progress.Close()
+
+	progress.Printf("Deployment ID: %s\n", cozyConfig.DeploymentID)
+	progress.SetID("deployment_id", cozyConfig.DeploymentID)
+
+	functions, source, err := build.ResolveFunctions(absPath, cozyConfig, opts.Functions)
+	if err != nil {
+		return err
+	}
+	build.PrintResolvedFunctions(progress, functions, source)
+
+	existing, err := newOrchestratorClient(cfg, opts.Transport).GetDeployment(cozyConfig.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to check deployment: %w", err)
+	}
+	if existing != nil {
+		changes := DiffFunctions(existing.FunctionRequirements, functions)
+		if err := CheckFunctionChanges(progress, os.Stdin, existing.ID, changes, opts.ConfirmFunctionChanges && !opts.DryRun); err != nil {
+			return err
+		}
+	}
+
+	policies, err := NewPolicyCheck(ctx, absPath, cozyConfig, opts.Policies)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		return dryRunLocalBuild(ctx, progress, newOrchestratorClient(cfg, opts.Transport), absPath, registryPrefix, cozyConfig, functions, opts, policies)
+	}
+
+	recorder := history.Start(opts.Profile, "deploy")
+	defer func() {
+		recorder.FinishProgress(progress, err)
+		err = WriteSummary(opts.SummaryFile, opts.Output, "deploy", progress, newOrchestratorClient(cfg, opts.Transport), OrchestratorURL(cfg), err)
+	}()
+
+	// Hashed before building, so edits during the build aren't attributed to the image
+	sourceDigest, _ := build.SourceDigest(absPath)
+
+	// Build the image locally
+	stage := progress.Start("Building")
+	result, err := build.BuildLocalImage(ctx, progress, absPath, cozyConfig, functions)
+	if err != nil {
+		return stage.Fail(err)
+	}
+	stage.Done()
+	progress.SetID("image_tag", result.ImageTag)
+
+	// Tag and push to the registry
+	builder := build.NewDockerBuilder(
+		build.WithRegistryURL(cfg.RegistryURL),
+		build.WithRegistryCredentials(cfg.RegistryUser, cfg.RegistryPassword),
+		build.WithRegistryPrefix(registryPrefix),
+	)
+
+	registryTag := builder.GetRegistryTag(result.ImageTag)
+
+	if opts.CheckEntrypoint {
+		stage = progress.Start("Checking entrypoint")
+		if err := builder.CheckEntrypoint(ctx, progress, result.ImageTag, functions, opts.CheckDuration); err != nil {
+			return stage.Fail(interrupt.Check(ctx, fmt.Errorf("entrypoint check failed: %w", err), "the deployment is unchanged"))
+		}
+		stage.Done()
+	}
+
+	// Policy checks run before the image leaves the machine
+	if err := RunPreDeployHook(ctx, progress, cozyConfig.Hooks.PreDeploy, hooks.Context{
+		ProjectDir:   absPath,
+		DeploymentID: cozyConfig.DeploymentID,
+		Image:        registryTag,
+		LocalImage:   result.ImageTag,
+		Functions:    FunctionNames(functions),
+	}); err != nil {
+		return err
+	}
+
+	stage = progress.Start("Pushing")
+	if err := builder.Login(ctx); err != nil {
+		return stage.Fail(err)
+	}
+
+	if tagResult := builder.Tag(ctx, result.ImageTag, registryTag); tagResult.Error != nil {
+		return stage.Fail(tagResult.Error)
+	}
+
+	progress.Printf("Pushing %s...\n", registryTag)
+	pushResult := builder.Push(ctx, registryTag, 30*time.Minute)
+	if pushResult.Error != nil {
+		return stage.Fail(interrupt.Check(ctx, pushResult.Error, fmt.Sprintf("image %s was built but not pushed; the deployment is unchanged", result.ImageTag)))
+	}
+	stage.Done()
+	progress.SetID("image_url", registryTag)
+
+	// Register or update the deployment with the orchestrator
+	if err := deployImage(ctx, progress, newOrchestratorClient(cfg, opts.Transport), cozyConfig.DeploymentID, registryTag, functions, opts, policies); err != nil {
+		return err
+	}
+	// Lets 'cozyctl update' skip the rebuild while the source is unchanged
+	if sourceDigest != "" {
+		if err := build.RecordDeployedSource(absPath, cozyConfig.DeploymentID, registryTag, sourceDigest); err != nil {
+			progress.Warnf("failed to record the deployed source: %v", err)
+		}
+	}
+	return nil
+}
+
+// deployImage creates or updates a deployment to run imageURL, once the
+// policies allow the request, then runs the optional smoke test and rollout
+// watch, restoring the previous image if either fails.
+func deployImage(ctx context.Context, progress *ui.Progress, client api.OrchestratorAPI, deploymentID, imageURL string, functions []build.DetectedFunction, opts LocalBuildOptions, policies *PolicyCheck) error {
+	stage := progress.Start("Deploying")
+	existing, err := client.GetDeployment(deploymentID)
+	if err != nil {
+		return stage.Fail(fmt.Errorf("failed to check deployment: %w", err))
+	}
+
+	var deployment *api.DeploymentResponse
+	deployedAt := time.Now()
+	if existing == nil {
+		req := createRequest(deploymentID, imageURL, functions, opts)
+		if err := policies.Run(ctx, progress, "create", deploymentID, req); err != nil {
+			return stage.Fail(err)
+		}
+		progress.Println("Creating deployment...")
+		deployment, err = client.CreateDeployment(req)
+	} else {
+		req := updateRequest(imageURL, functions, opts)
+		if err := policies.Run(ctx, progress, "update", deploymentID, req); err != nil {
+			return stage.Fail(err)
+		}
+		progress.Println("Updating deployment...")
+		deployment, err = client.UpdateDeployment(deploymentID, req)
+	}
+	if err != nil {
+		return stage.Fail(fmt.Errorf("failed to deploy: %w", err))
+	}
+	stage.Done()
+
+	progress.Printf("\nDeployment successful!\n")
+	progress.Printf("  ID: %s\n", deployment.ID)
+	progress.Printf("  Tenant: %s\n", deployment.TenantID)
+	progress.Printf("  Image: %s\n", deployment.ImageURL)
+	progress.Printf("  Functions: %d\n", len(deployment.FunctionRequirements))
+
+	if opts.SmokeTest != nil {
+		stage = progress.Start("Smoke test")
+		if err := opts.SmokeTest.Run(ctx, progress, client, deployment.ID, smoke.DefaultReadyTimeout); err != nil {
+			if ctx.Err() != nil {
+				return stage.Fail(Unverified(deployment.ID, err))
+			}
+			stage.Fail(err)
+			if !opts.SmokeRollback {
+				return err
+			}
+			return RestoreImage(progress, client, existing, err)
+		}
+		stage.Done()
+	}
+
+	if opts.AutoRollback != nil {
+		stage = progress.Start("Watching rollout")
+		report := rollout.Watch(ctx, progress, client, deployment.ID, deployedAt, *opts.AutoRollback)
+		if ctx.Err() != nil {
+			return stage.Fail(Unverified(deployment.ID, ctx.Err()))
+		}
+		report.Write(progress)
+		if !report.Healthy {
+			err := fmt.Errorf("rollout failed: %s", report.Reason)
+			stage.Fail(err)
+			return RestoreImage(progress, client, existing, err)
+		}
+		stage.Done()
+	}
+
+	progress.Println(progress.Summary())
+	return nil
+}
+
+// RestoreImage switches a deployment back to the image and functions it ran
+// before (previous, nil if the deployment was just created) and returns
+// cause, noting if there was nothing to restore or the rollback failed.
+func RestoreImage(progress *ui.Progress, client api.OrchestratorAPI, previous *api.DeploymentResponse, cause error) error {
+	if previous == nil {
+		return fmt.Errorf("%w (deployment was newly created; nothing to roll back to)", cause)
+	}
+
+	stage := progress.Start("Rolling back")
+	progress.Printf("Restoring previous image %s...\n", previous.ImageURL)
+	if _, err := client.UpdateDeployment(previous.ID, &api.UpdateDeploymentRequest{
+		ImageURL:             previous.ImageURL,
+		FunctionRequirements: previous.FunctionRequirements,
+	}); err != nil {
+		stage.Fail(err)
+		return fmt.Errorf("%w (rollback failed: %v)", cause, err)
+	}
+	stage.Done()
+	progress.Printf("Rolled back to %s\n", previous.ImageURL)
+
+	return cause
+}
+
+// dryRunLocalBuild writes the artifacts of a local build deploy to the
+// project's .cozy/out without building, pushing, or changing the deployment.
+// The deployment is looked up to decide between the create and update payloads.
+func dryRunLocalBuild(ctx context.Context, progress *ui.Progress, client api.OrchestratorAPI, projectDir, registryPrefix string, cozyConfig *build.ToolsCozyConfig, functions []build.DetectedFunction, opts LocalBuildOptions, policies *PolicyCheck) error {
+	baseImage, err := build.ResolveBaseImage(cozyConfig)
+	if err != nil {
+		return fmt.Errorf("failed to resolve base image: %w", err)
+	}
+	buildID := uuid.New().String()
+	dockerfile, err := build.GenerateDockerfile(baseImage, cozyConfig, build.NewImageMetadata(projectDir, buildID, functions))
+	if err != nil {
+		return fmt.Errorf("failed to generate Dockerfile: %w", err)
+	}
+
+	imageTag := build.GenerateImageTag(buildID, cozyConfig.DeploymentID)
+	imageURL := build.NewDockerBuilder(build.WithRegistryPrefix(registryPrefix)).GetRegistryTag(imageTag)
+
+	existing, err := client.GetDeployment(cozyConfig.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to check deployment: %w", err)
+	}
+
+	requestFile, action := build.DryRunCreateRequest, "create"
+	var request any = createRequest(cozyConfig.DeploymentID, imageURL, functions, opts)
+	if existing != nil {
+		requestFile, action = build.DryRunUpdateRequest, "update"
+		request = updateRequest(imageURL, functions, opts)
+	}
+
+	paths, err := build.WriteDryRunArtifacts(projectDir, dockerfile, requestFile, request)
+	if err != nil {
+		return err
+	}
+
+	progress.Println("\n--- Dry Run Mode ---")
+	progress.Println("Would build image:", imageTag)
+	progress.Println("Would push:", imageURL)
+	progress.Printf("Would %s deployment: %s\n", action, cozyConfig.DeploymentID)
+	progress.Println("\nWrote artifacts:")
+	for _, path := range paths {
+		progress.Printf("  %s\n", path)
+	}
+
+	if policies != nil {
+		progress.Println()
+		if err := policies.Run(ctx, progress, action, cozyConfig.DeploymentID, request); err != nil {
+			return err
+		}
+		progress.Println("Policies: request allowed")
+	}
+	return nil
+}
+
+// createRequest builds the CreateDeployment payload for a new deployment running imageURL.
+func createRequest(deploymentID, imageURL string, functions []build.DetectedFunction, opts LocalBuildOptions) *api.CreateDeploymentRequest {
+	minWorkers, maxWorkers := workerCounts(opts)
+	return &api.CreateDeploymentRequest{
+		ID:                   deploymentID,
+		Name:                 deploymentID,
+		ImageURL:             imageURL,
+		FunctionRequirements: build.FunctionRequirements(functions),
+		MinWorkers:           minWorkers,
+		MaxWorkers:           maxWorkers,
+	}
+}
+
+// updateRequest builds the UpdateDeployment payload switching a deployment to imageURL.
+func updateRequest(imageURL string, functions []build.DetectedFunction, opts LocalBuildOptions) *api.UpdateDeploymentRequest {
+	minWorkers, maxWorkers := workerCounts(opts)
+	req := &api.UpdateDeploymentRequest{
+		ImageURL:   imageURL,
+		MinWorkers: minWorkers,
+		MaxWorkers: maxWorkers,
+	}
+	if len(functions) > 0 {
+		req.FunctionRequirements = build.FunctionRequirements(functions)
+	}
+	return req
+}
+
+// workerCounts returns the requested worker bounds, nil where the server default applies.
+func workerCounts(opts LocalBuildOptions) (minWorkers, maxWorkers *int) {
+	if opts.MinWorkers >= 0 {
+		minWorkers = &opts.MinWorkers
+	}
+	if opts.MaxWorkers >= 0 {
+		maxWorkers = &opts.MaxWorkers
+	}
+	return minWorkers, maxWorkers
+}
@@ -0,0 +1,82 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/policy"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// PolicyCheck evaluates a project's deployment requests against its policies.
+type PolicyCheck struct {
+	engine  *policy.Engine
+	project policy.Project
+}
+
+// NewPolicyCheck loads the bundled policies, the project's [tool.cozy.policy]
+// paths (relative to the project), and extraPaths. It returns nil when
+// neither the project nor extraPaths enable policies.
+func NewPolicyCheck(ctx context.Context, projectDir string, cozyConfig *build.ToolsCozyConfig, extraPaths []string) (*PolicyCheck, error) {
+	if cozyConfig.Policy == nil && len(extraPaths) == 0 {
+		return nil, nil
+	}
+
+	var paths []string
+	var params map[string]any
+	if cozyConfig.Policy != nil {
+		paths = policy.ConfigPaths(projectDir, cozyConfig.Policy)
+		params = cozyConfig.Policy.Params
+	}
+	paths = append(paths, extraPaths...)
+
+	engine, err := policy.Load(ctx, paths, params)
+	if err != nil {
+		return nil, err
+	}
+	return &PolicyCheck{engine: engine, project: policyProject(projectDir, cozyConfig)}, nil
+}
+
+// policyProject describes a project to policies.
+func policyProject(projectDir string, cozyConfig *build.ToolsCozyConfig) policy.Project {
+	baseImage, _ := build.ResolveBaseImage(cozyConfig)
+	return policy.Project{
+		Dir:         projectDir,
+		BaseImage:   baseImage,
+		Python:      cozyConfig.Python,
+		Pytorch:     cozyConfig.Pytorch,
+		Cuda:        cozyConfig.Cuda,
+		Labels:      cozyConfig.Labels,
+		Environment: cozyConfig.Environment,
+	}
+}
+
+// Run evaluates a deployment request, printing warnings and violations, and
+// returns an error if a policy denies it. A nil check allows everything.
+func (c *PolicyCheck) Run(ctx context.Context, progress *ui.Progress, action, deploymentID string, request any) error {
+	if c == nil {
+		return nil
+	}
+
+	decision, err := c.engine.Evaluate(ctx, policy.Input{
+		Action:       action,
+		DeploymentID: deploymentID,
+		Request:      request,
+		Project:      c.project,
+	})
+	if err != nil {
+		return err
+	}
+	for _, msg := range decision.Warn {
+		progress.Warnf("policy: %s", msg)
+	}
+	for _, msg := range decision.Deny {
+		progress.Printf("Policy violation: %s\n", msg)
+	}
+	if !decision.Allowed() {
+		return fmt.Errorf("deployment request denied by policy: %s", strings.Join(decision.Deny, "; "))
+	}
+	return nil
+}
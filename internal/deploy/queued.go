@@ -0,0 +1,253 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/outbox"
+	"github.com/cozy-creator/cozyctl/internal/rollout"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/cozy-creator/cozyctl/internal/workspace"
+)
+
+// QueuedOptions contains the options for building a project on the server
+// and deploying it, queueing the deploy when cozy-hub can't be reached.
+type QueuedOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	ProjectPath  string
+	Build        api.BuildOptions
+	AutoRollback *rollout.Policy
+
+	Progress ui.Mode
+}
+
+// FlushOptions contains the options for submitting queued deploys.
+type FlushOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	List      bool // Only list the queued deploys
+	Output    ui.Output
+	Progress  ui.Mode
+}
+
+// RunQueued packages a project, builds it on the server, and deploys it.
+// If cozy-hub can't be reached to upload the package, the package and the
+// deploy request are kept in the profile's outbox for 'cozyctl flush' to
+// submit later, and the command succeeds.
+func RunQueued(ctx context.Context, opts QueuedOptions) (err error) {
+	absPath, err := filepath.Abs(opts.ProjectPath)
+	if err != nil {
+		return fmt.Errorf("invalid project path: %w", err)
+	}
+	pyprojectPath := filepath.Join(absPath, build.PyProjectTomlPath)
+	if _, err := os.Stat(pyprojectPath); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("pyproject.toml not found in %s", absPath)
+	}
+	cozyConfig, err := build.GetToolsCozyConfig(pyprojectPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse pyproject.toml: %w", err)
+	}
+	if cozyConfig.DeploymentID == "" {
+		return fmt.Errorf("[tool.cozy] deployment-id is required in pyproject.toml")
+	}
+	project := &workspace.Project{
+		Dir:          absPath,
+		Name:         filepath.Base(absPath),
+		DeploymentID: cozyConfig.DeploymentID,
+		PreDeploy:    cozyConfig.Hooks.PreDeploy,
+	}
+
+	profileCfg, err := config.LoadLoggedInProfile(opts.Profile)
+	if err != nil {
+		return err
+	}
+	builder, orchestrator := newQueueClients(profileCfg.Config, opts.Transport)
+
+	progress := ui.NewWithMode(os.Stdout, opts.Progress)
+	defer progress.Close()
+
+	recorder := history.Start(opts.Profile, "deploy")
+	defer func() { recorder.FinishProgress(progress, err) }()
+
+	progress.Printf("Deployment ID: %s\n", project.DeploymentID)
+	progress.SetID("deployment_id", project.DeploymentID)
+
+	// The package is kept if the upload can't reach cozy-hub
+	archive, err := os.CreateTemp("", "cozy-deploy-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	archive.Close()
+	defer os.Remove(archive.Name())
+
+	stage := progress.Start("Packaging")
+	buildOpts, sourceDigest, err := build.PackageArchive(absPath, archive.Name(), opts.Build)
+	if err != nil {
+		return stage.Fail(err)
+	}
+	stage.Done()
+
+	buildID, err := build.SubmitArchive(ctx, progress, builder, archive.Name(), absPath, project.Name, buildOpts, sourceDigest)
+	if err != nil {
+		if buildID != "" || !api.IsUnreachable(err) {
+			return err
+		}
+		info, statErr := os.Stat(archive.Name())
+		if statErr != nil {
+			return err
+		}
+		intent, queueErr := outbox.Add(opts.Profile, outbox.Intent{
+			ProjectDir:   absPath,
+			BuildName:    project.Name,
+			DeploymentID: project.DeploymentID,
+			PreDeploy:    project.PreDeploy,
+			Priority:     buildOpts.Priority,
+			Machine:      buildOpts.Machine,
+			SourceDigest: sourceDigest,
+			Size:         info.Size(),
+			AutoRollback: opts.AutoRollback,
+		}, archive.Name())
+		if queueErr != nil {
+			return fmt.Errorf("%w (and the deploy could not be queued: %v)", err, queueErr)
+		}
+		progress.SetID("queued", intent.ID)
+		progress.Printf("\ncozy-hub is unreachable: %v\n", err)
+		progress.Printf("Queued deploy %s (%s). Run 'cozyctl flush' once you're back online.\n", intent.ID, ui.FormatBytes(intent.Size))
+		return nil
+	}
+
+	return deployBuild(ctx, progress, builder, orchestrator, profileCfg.Config.TenantID, project, buildID, opts.AutoRollback)
+}
+
+// Flush submits the profile's queued deploys, oldest first. Each one is
+// taken out of the outbox once its archive is uploaded, whether or not the
+// build and deploy then succeed. Flushing stops, keeping the rest queued,
+// if cozy-hub still can't be reached.
+func Flush(ctx context.Context, opts FlushOptions) error {
+	intents, err := outbox.List(opts.Profile)
+	if err != nil {
+		return err
+	}
+	if opts.List {
+		return writeIntents(os.Stdout, intents, opts.Output, time.Now())
+	}
+	if len(intents) == 0 {
+		fmt.Println("No queued deploys.")
+		return nil
+	}
+
+	profileCfg, err := config.LoadLoggedInProfile(opts.Profile)
+	if err != nil {
+		return err
+	}
+	builder, orchestrator := newQueueClients(profileCfg.Config, opts.Transport)
+
+	return flush(ctx, os.Stdout, builder, orchestrator, profileCfg.Config.TenantID, intents, opts)
+}
+
+func flush(ctx context.Context, w io.Writer, builder api.BuilderAPI, orchestrator api.OrchestratorAPI, tenantID string, intents []outbox.Intent, opts FlushOptions) error {
+	failed := 0
+	for i, intent := range intents {
+		fmt.Fprintf(w, "==> Deploying queued %s to %s (queued %s)\n", intent.ID, intent.DeploymentID, intent.CreatedAt.Local().Format(time.DateTime))
+
+		progress := ui.NewWithMode(w, opts.Progress)
+		recorder := history.Start(opts.Profile, "deploy")
+		progress.SetID("deployment_id", intent.DeploymentID)
+		err := flushOne(ctx, progress, builder, orchestrator, tenantID, intent, opts.Profile)
+		recorder.FinishProgress(progress, err)
+		progress.Close()
+
+		var stillOffline *unreachableError
+		switch {
+		case errors.As(err, &stillOffline):
+			return fmt.Errorf("cozy-hub is still unreachable; %d deploys remain queued: %w", len(intents)-i, stillOffline.err)
+		case ctx.Err() != nil:
+			return err
+		case err != nil:
+			failed++
+			fmt.Fprintf(w, "Error: queued deploy %s failed: %v\n", intent.ID, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d queued deploys failed", failed, len(intents))
+	}
+	fmt.Fprintf(w, "Submitted %d queued deploys.\n", len(intents))
+	return nil
+}
+
+// unreachableError is returned by flushOne when the archive could not be
+// uploaded because cozy-hub was unreachable; the deploy stays queued.
+type unreachableError struct{ err error }
+
+func (e *unreachableError) Error() string { return e.err.Error() }
+
+func (e *unreachableError) Unwrap() error { return e.err }
+
+func flushOne(ctx context.Context, progress *ui.Progress, builder api.BuilderAPI, orchestrator api.OrchestratorAPI, tenantID string, intent outbox.Intent, ref config.ProfileRef) error {
+	archive, err := outbox.ArchivePath(ref, intent.ID)
+	if err != nil {
+		return err
+	}
+	buildOpts := api.BuildOptions{Priority: intent.Priority, Machine: intent.Machine}
+	buildID, err := build.SubmitArchive(ctx, progress, builder, archive, intent.ProjectDir, intent.BuildName, buildOpts, intent.SourceDigest)
+	if err != nil && buildID == "" && api.IsUnreachable(err) {
+		return &unreachableError{err: err}
+	}
+	// Once uploaded, the build exists on the server; retrying would build it again
+	if buildID != "" || err == nil {
+		if rmErr := outbox.Remove(ref, intent.ID); rmErr != nil {
+			progress.Warnf("failed to remove %s from the outbox: %v", intent.ID, rmErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	project := &workspace.Project{
+		Dir:          intent.ProjectDir,
+		Name:         intent.BuildName,
+		DeploymentID: intent.DeploymentID,
+		PreDeploy:    intent.PreDeploy,
+	}
+	return deployBuild(ctx, progress, builder, orchestrator, tenantID, project, buildID, intent.AutoRollback)
+}
+
+// writeIntents lists queued deploys.
+func writeIntents(w io.Writer, intents []outbox.Intent, output ui.Output, now time.Time) error {
+	if output.Structured() {
+		if intents == nil {
+			intents = []outbox.Intent{}
+		}
+		return ui.WriteStructured(w, output, intents)
+	}
+	if len(intents) == 0 {
+		fmt.Fprintln(w, "No queued deploys.")
+		return nil
+	}
+	table := &ui.Table{Columns: []string{"ID", "DEPLOYMENT", "PROJECT", "SIZE", "QUEUED"}}
+	for _, in := range intents {
+		table.Rows = append(table.Rows, ui.Row{Key: in.ID, Cells: []string{
+			in.ID, in.DeploymentID, in.ProjectDir, ui.FormatBytes(in.Size), ui.FormatDuration(now.Sub(in.CreatedAt).Truncate(time.Second)) + " ago",
+		}})
+	}
+	return table.Write(w)
+}
+
+func newQueueClients(cfg *config.ConfigData, transport http.RoundTripper) (*api.BuilderClient, *api.Client) {
+	builderURL := cfg.BuilderURL
+	if builderURL == "" {
+		builderURL = config.DefaultConfigData().BuilderURL
+	}
+	return api.NewBuilderClient(builderURL, cfg.Token, transport), newOrchestratorClient(cfg, transport)
+}
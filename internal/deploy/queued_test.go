@@ -0,0 +1,79 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/outbox"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestRunQueuedWhileOfflineThenFlush(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	offline := httptest.NewServer(nil)
+	offline.Close()
+	ref := config.ProfileRef{Name: "work", Profile: "prod"}
+	if err := config.SaveProfileConfig(ref.Name, ref.Profile, &config.ProfileConfig{
+		Config: &config.ConfigData{BuilderURL: offline.URL, OrchestratorURL: offline.URL, TenantID: "t-1", Token: "token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	pyproject := "[project]\nname = \"demo\"\n\n[tool.cozy]\ndeployment-id = \"demo\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunQueued(context.Background(), QueuedOptions{Profile: ref, ProjectPath: dir, Progress: ui.ModePlain}); err != nil {
+		t.Fatalf("RunQueued: %v", err)
+	}
+	intents, err := outbox.List(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 1 || intents[0].DeploymentID != "demo" || intents[0].Size == 0 {
+		t.Fatalf("expected one queued deploy of demo, got %+v", intents)
+	}
+
+	// Still offline: the deploy stays queued
+	builder, orchestrator := newQueueClients(&config.ConfigData{BuilderURL: offline.URL, OrchestratorURL: offline.URL, Token: "token"}, nil)
+	var out bytes.Buffer
+	err = flush(context.Background(), &out, builder, orchestrator, "t-1", intents, FlushOptions{Profile: ref, Progress: ui.ModePlain})
+	if err == nil || !strings.Contains(err.Error(), "1 deploys remain queued") {
+		t.Fatalf("flush error = %v\n%s", err, out.String())
+	}
+	if intents, _ := outbox.List(ref); len(intents) != 1 {
+		t.Fatalf("expected the deploy to stay queued, got %+v", intents)
+	}
+
+	// Back online
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	builder, orchestrator = newQueueClients(&config.ConfigData{BuilderURL: ts.URL, OrchestratorURL: ts.URL, Token: "token"}, nil)
+	out.Reset()
+	if err := flush(context.Background(), &out, builder, orchestrator, "t-1", intents, FlushOptions{Profile: ref, Progress: ui.ModePlain}); err != nil {
+		t.Fatalf("flush: %v\n%s", err, out.String())
+	}
+	if _, err := orchestrator.GetDeployment("demo"); err != nil {
+		t.Errorf("deployment demo not created: %v", err)
+	}
+	if intents, _ := outbox.List(ref); len(intents) != 0 {
+		t.Errorf("expected an empty outbox, got %+v", intents)
+	}
+	if archive, _ := outbox.ArchivePath(ref, intents[0].ID); fileExists(archive) {
+		t.Error("expected the archive to be removed")
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
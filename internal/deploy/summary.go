@@ -0,0 +1,162 @@
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Summary is the machine-readable result of a deploy or update, written
+// with --summary-file for later pipeline steps and release dashboards, or
+// printed with -o json|yaml.
+type Summary struct {
+	Command         string   `json:"command"`
+	Status          string   `json:"status"` // "succeeded", "failed", or "pending_approval"
+	Error           string   `json:"error,omitempty"`
+	BuildID         string   `json:"build_id,omitempty"`
+	ApprovalID      string   `json:"approval_id,omitempty"` // Set if the deploy required approval
+	ImageTag        string   `json:"image_tag,omitempty"`
+	ImageURL        string   `json:"image_url,omitempty"`
+	DeploymentID    string   `json:"deployment_id,omitempty"`
+	Endpoints       []string `json:"endpoints"`
+	Phases          []Phase  `json:"phases"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	Warnings        []string `json:"warnings"`
+}
+
+// Phase is the timing of one stage of a deploy.
+type Phase struct {
+	Name            string  `json:"name"`
+	Status          string  `json:"status"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// Summary and phase statuses.
+const (
+	summarySucceeded       = "succeeded"
+	summaryFailed          = "failed"
+	summaryPendingApproval = "pending_approval" // Submitted, but not deployed until approved
+)
+
+// NewSummary collects the result of a command from its progress: the IDs it
+// recorded, its stages, and its warnings. The progress is closed first so
+// the last stage has its duration. err is what the command returned.
+func NewSummary(command string, progress *ui.Progress, err error) *Summary {
+	progress.Close()
+
+	ids := progress.IDs()
+	s := &Summary{
+		Command:         command,
+		Status:          summarySucceeded,
+		BuildID:         ids["build_id"],
+		ApprovalID:      ids["approval_id"],
+		ImageTag:        ids["image_tag"],
+		ImageURL:        ids["image_url"],
+		DeploymentID:    ids["deployment_id"],
+		Endpoints:       []string{},
+		Phases:          []Phase{},
+		DurationSeconds: seconds(progress.Elapsed()),
+		Warnings:        progress.Warnings(),
+	}
+	if s.Warnings == nil {
+		s.Warnings = []string{}
+	}
+	if err != nil {
+		s.Status = summaryFailed
+		s.Error = err.Error()
+	} else if s.ApprovalID != "" && s.ImageTag == "" {
+		s.Status = summaryPendingApproval
+	}
+	for _, stage := range progress.Stages() {
+		phase := Phase{Name: stage.Name, Status: summarySucceeded, DurationSeconds: seconds(stage.Duration)}
+		if stage.Err != nil {
+			phase.Status = summaryFailed
+			phase.Error = stage.Err.Error()
+		}
+		s.Phases = append(s.Phases, phase)
+	}
+	return s
+}
+
+// AddEndpoints lists the invoke URL of each of the deployment's functions.
+// A failed lookup is recorded as a warning rather than failing the summary.
+func (s *Summary) AddEndpoints(client api.OrchestratorAPI, orchestratorURL string) {
+	if s.DeploymentID == "" {
+		return
+	}
+	deployment, err := client.GetDeployment(s.DeploymentID)
+	if err != nil {
+		s.Warnings = append(s.Warnings, fmt.Sprintf("failed to list endpoints: %v", err))
+		return
+	}
+	if deployment == nil {
+		return
+	}
+	for _, fn := range deployment.FunctionRequirements {
+		s.Endpoints = append(s.Endpoints, api.InvokeURL(orchestratorURL, deployment.ID, fn.Name))
+	}
+}
+
+// Write writes the summary to path as indented JSON.
+func (s *Summary) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+// ProgressOutput returns where a deploy or update prints its progress:
+// stdout, unless its summary is printed there in a structured format.
+func ProgressOutput(output ui.Output) io.Writer {
+	if output.Structured() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// WriteSummary writes the summary of a finished deploy or update to path,
+// if set, and prints it to stdout if output is a structured format. It
+// returns err, or the write's error if the command itself succeeded.
+func WriteSummary(path string, output ui.Output, command string, progress *ui.Progress, client api.OrchestratorAPI, orchestratorURL string, err error) error {
+	return writeSummary(os.Stdout, path, output, command, progress, client, orchestratorURL, err)
+}
+
+func writeSummary(w io.Writer, path string, output ui.Output, command string, progress *ui.Progress, client api.OrchestratorAPI, orchestratorURL string, err error) error {
+	if path == "" && !output.Structured() {
+		return err
+	}
+	s := NewSummary(command, progress, err)
+	s.AddEndpoints(client, orchestratorURL)
+
+	var writeErr error
+	if path != "" {
+		writeErr = s.Write(path)
+	}
+	if output.Structured() {
+		if printErr := ui.WriteStructured(w, output, s); printErr != nil && writeErr == nil {
+			writeErr = printErr
+		}
+	}
+	if writeErr != nil {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", writeErr)
+			return err
+		}
+		return writeErr
+	}
+	return err
+}
+
+func seconds(d time.Duration) float64 {
+	return d.Round(time.Millisecond).Seconds()
+}
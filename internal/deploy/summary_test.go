@@ -0,0 +1,117 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func readSummary(t *testing.T, path string) Summary {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Summary
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	return s
+}
+
+func TestWriteSummary(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	builder, orchestrator := api.NewBuilderClient(ts.URL, "token", nil), api.NewClient(ts.URL, "token", nil)
+	buildID := uploadBuild(t, builder, "my-model")
+
+	progress := ui.New(io.Discard)
+	progress.Warnf("policy: no GPU limit set")
+	err := promote(context.Background(), progress, builder, orchestrator, "tenant", Options{BuildID: buildID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	functions := []api.FunctionRequirement{{Name: "generate"}, {Name: "health"}}
+	if _, err := orchestrator.UpdateDeployment(progress.IDs()["deployment_id"], &api.UpdateDeploymentRequest{FunctionRequirements: functions}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := WriteSummary(path, "", "deploy", progress, orchestrator, ts.URL, err); err != nil {
+		t.Fatal(err)
+	}
+
+	s := readSummary(t, path)
+	if s.Status != "succeeded" || s.BuildID != buildID || s.DeploymentID == "" || s.ImageTag == "" {
+		t.Errorf("summary = %+v", s)
+	}
+	if len(s.Phases) != 2 || s.Phases[0].Name != "Verifying build" || s.Phases[1].Name != "Deploying" {
+		t.Errorf("phases = %+v", s.Phases)
+	}
+	if len(s.Warnings) != 1 || s.Warnings[0] != "policy: no GPU limit set" {
+		t.Errorf("warnings = %q", s.Warnings)
+	}
+	want := []string{api.InvokeURL(ts.URL, s.DeploymentID, "generate"), api.InvokeURL(ts.URL, s.DeploymentID, "health")}
+	if !slices.Equal(s.Endpoints, want) {
+		t.Errorf("endpoints = %q, want %q", s.Endpoints, want)
+	}
+}
+
+func TestWriteSummaryFailed(t *testing.T) {
+	_, orchestrator := newMockClients(t)
+
+	progress := ui.New(io.Discard)
+	stage := progress.Start("Deploying")
+	cause := errors.New("registry unreachable")
+	stage.Fail(cause)
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := WriteSummary(path, "", "update", progress, orchestrator, "http://orchestrator", cause); err != cause {
+		t.Fatalf("expected the command's error, got %v", err)
+	}
+	s := readSummary(t, path)
+	if s.Command != "update" || s.Status != "failed" || s.Error != "registry unreachable" {
+		t.Errorf("summary = %+v", s)
+	}
+	if len(s.Phases) != 1 || s.Phases[0].Status != "failed" || s.Phases[0].Error != "registry unreachable" {
+		t.Errorf("phases = %+v", s.Phases)
+	}
+	if s.Endpoints == nil || s.Warnings == nil {
+		t.Errorf("expected empty lists rather than null: %+v", s)
+	}
+
+	// Without a path nothing is written and the error passes through
+	if err := WriteSummary("", "", "update", progress, orchestrator, "", cause); err != cause {
+		t.Errorf("expected the command's error, got %v", err)
+	}
+}
+
+func TestWriteSummaryOutput(t *testing.T) {
+	_, orchestrator := newMockClients(t)
+
+	progress := ui.New(io.Discard)
+	progress.SetID("deployment_id", "my-model")
+	progress.Start("Deploying").Done()
+
+	var out bytes.Buffer
+	if err := writeSummary(&out, "", ui.OutputJSON, "deploy", progress, orchestrator, "http://orchestrator", nil); err != nil {
+		t.Fatal(err)
+	}
+	var s Summary
+	if err := json.Unmarshal(out.Bytes(), &s); err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	if s.Status != "succeeded" || s.DeploymentID != "my-model" || len(s.Phases) != 1 {
+		t.Errorf("summary = %+v", s)
+	}
+}
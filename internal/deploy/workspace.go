@@ -0,0 +1,349 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/hooks"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+	"github.com/cozy-creator/cozyctl/internal/rollout"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/cozy-creator/cozyctl/internal/workspace"
+)
+
+// AllOptions contains the options for deploying every project of a workspace.
+type AllOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Root      string // Workspace root, containing the pyproject.toml that lists the members
+
+	AutoRollback *rollout.Policy  // Applied to each project's rollout
+	Build        api.BuildOptions // Applied to each project's server build
+	Parallel     int              // Projects built and deployed at once; at least 1
+
+	Progress ui.Mode
+}
+
+// Outcomes of deploying a workspace project.
+const (
+	resultDeployed = "deployed"
+	resultFailed   = "failed"
+	resultSkipped  = "skipped"
+)
+
+// projectResult is the outcome of deploying one workspace project.
+type projectResult struct {
+	project  *workspace.Project
+	result   string
+	details  string
+	duration time.Duration // Zero for projects that didn't start
+	err      error
+}
+
+// RunAll builds every member of a workspace on the server and deploys it, in
+// depends-on order. When a project fails, the projects that depend on it
+// (directly or not) are skipped; the others still deploy.
+func RunAll(ctx context.Context, opts AllOptions) error {
+	ws, err := workspace.Load(opts.Root)
+	if err != nil {
+		return err
+	}
+	order, err := ws.Order()
+	if err != nil {
+		return err
+	}
+
+	profileCfg, err := config.LoadLoggedInProfile(opts.Profile)
+	if err != nil {
+		return err
+	}
+	builderURL := profileCfg.Config.BuilderURL
+	if builderURL == "" {
+		builderURL = config.DefaultConfigData().BuilderURL
+	}
+	builder := api.NewBuilderClient(builderURL, profileCfg.Config.Token, opts.Transport)
+	orchestrator := newOrchestratorClient(profileCfg.Config, opts.Transport)
+
+	return deployAll(os.Stdout, order, opts.Progress, opts.Parallel, func(progress *ui.Progress, p *workspace.Project, ready func() error) error {
+		recorder := history.Start(opts.Profile, "deploy")
+		err := buildAndPromote(ctx, progress, builder, orchestrator, profileCfg.Config.TenantID, p, opts.Build, opts.AutoRollback, ready)
+		recorder.FinishProgress(progress, err)
+		return err
+	})
+}
+
+// buildAndPromote builds a workspace project on the server and deploys the
+// build once ready, if set, allows it.
+func buildAndPromote(ctx context.Context, progress *ui.Progress, builder api.BuilderAPI, orchestrator api.OrchestratorAPI, tenantID string, p *workspace.Project, buildOpts api.BuildOptions, autoRollback *rollout.Policy, ready func() error) error {
+	buildID, err := build.SubmitBuild(ctx, progress, builder, p.Dir, filepath.Base(p.Dir), buildOpts)
+	if err != nil {
+		return err
+	}
+
+	// Builds run alongside those of dependencies; deploys wait for them
+	if ready != nil {
+		if err := ready(); err != nil {
+			return err
+		}
+	}
+
+	return deployBuild(ctx, progress, builder, orchestrator, tenantID, p, buildID, autoRollback)
+}
+
+// deployBuild runs the project's pre-deploy hook, if any, and deploys a
+// finished build of it once the project's policies allow the request.
+func deployBuild(ctx context.Context, progress *ui.Progress, builder api.BuilderAPI, orchestrator api.OrchestratorAPI, tenantID string, p *workspace.Project, buildID string, autoRollback *rollout.Policy) error {
+	cozyConfig, err := build.GetToolsCozyConfig(filepath.Join(p.Dir, "pyproject.toml"))
+	if err != nil {
+		return fmt.Errorf("failed to parse pyproject.toml: %w", err)
+	}
+	policies, err := NewPolicyCheck(ctx, p.Dir, cozyConfig, nil)
+	if err != nil {
+		return err
+	}
+
+	if p.PreDeploy != "" {
+		status, err := builder.GetBuildStatus(buildID)
+		if err != nil {
+			return fmt.Errorf("failed to get build %s: %w", buildID, err)
+		}
+		if err := RunPreDeployHook(ctx, progress, p.PreDeploy, hooks.Context{
+			ProjectDir:   p.Dir,
+			DeploymentID: p.DeploymentID,
+			Image:        status.ImageTag,
+			BuildID:      buildID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return promote(ctx, progress, builder, orchestrator, tenantID, Options{
+		BuildID:      buildID,
+		DeploymentID: p.DeploymentID,
+		AutoRollback: autoRollback,
+		policies:     policies,
+	})
+}
+
+// deployAll runs deployOne for each project in order, skipping projects
+// whose dependencies did not deploy, and prints a summary of every project.
+// Up to parallel projects run at once, each prefixing its output with its
+// name; deployOne calls ready before deploying, which waits for the
+// project's dependencies and fails with a *blockedError if one of them
+// did not deploy.
+func deployAll(w io.Writer, order []*workspace.Project, mode ui.Mode, parallel int, deployOne func(progress *ui.Progress, p *workspace.Project, ready func() error) error) error {
+	parallel = max(parallel, 1)
+	if parallel > 1 && mode == ui.ModeAuto {
+		// Spinners of concurrent projects would draw over each other
+		mode = ui.ModePlain
+	}
+
+	var (
+		mu          sync.Mutex            // Guards outcome, results, and interrupted
+		outMu       sync.Mutex            // Serializes lines written to w
+		outcome     = map[string]string{} // Result by deployment ID
+		results     = make([]projectResult, len(order))
+		interrupted error
+		wg          sync.WaitGroup
+	)
+	finished := map[string]chan struct{}{} // Closed once a project has a result
+	for _, p := range order {
+		finished[p.DeploymentID] = make(chan struct{})
+	}
+	record := func(i int, r projectResult) {
+		mu.Lock()
+		outcome[r.project.DeploymentID] = r.result
+		results[i] = r
+		if r.err != nil && errors.As(r.err, new(*interrupt.Error)) && interrupted == nil {
+			interrupted = r.err
+		}
+		mu.Unlock()
+		close(finished[r.project.DeploymentID])
+	}
+	printf := func(format string, args ...any) {
+		outMu.Lock()
+		defer outMu.Unlock()
+		fmt.Fprintf(w, format, args...)
+	}
+	ready := func(p *workspace.Project) error {
+		for _, dep := range p.DependsOn {
+			<-finished[dep]
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if blocked := blockedBy(p, outcome); blocked != "" {
+			return &blockedError{reason: blocked}
+		}
+		return nil
+	}
+
+	// Slots are taken in order, so a running project only ever waits on
+	// projects that are running or done
+	slots := make(chan struct{}, parallel)
+	for i, p := range order {
+		slots <- struct{}{}
+		mu.Lock()
+		stop, blocked := interrupted, blockedBy(p, outcome)
+		mu.Unlock()
+
+		if stop != nil {
+			record(i, projectResult{project: p, result: resultSkipped, details: "interrupted"})
+			<-slots
+			continue
+		}
+		if blocked != "" {
+			record(i, projectResult{project: p, result: resultSkipped, details: blocked})
+			printf("\n=== [%d/%d] %s: skipped (%s)\n", i+1, len(order), p.Name, blocked)
+			<-slots
+			continue
+		}
+
+		printf("\n=== [%d/%d] %s (deployment %s)\n", i+1, len(order), p.Name, p.DeploymentID)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			out := io.Writer(w)
+			var prefixed *prefixWriter
+			if parallel > 1 {
+				prefix := "[" + p.Name + "] "
+				if mode == ui.ModeJSON {
+					// Events carry the project in their ids instead
+					prefix = ""
+				}
+				prefixed = &prefixWriter{mu: &outMu, w: w, prefix: prefix}
+				out = prefixed
+			}
+			progress := ui.NewWithMode(out, mode)
+			if parallel > 1 {
+				progress.SetID("project", p.Name)
+			}
+			start := time.Now()
+			err := deployOne(progress, p, func() error { return ready(p) })
+			progress.Close()
+			if prefixed != nil {
+				prefixed.Flush()
+			}
+			record(i, newProjectResult(p, err, progress.IDs(), time.Since(start)))
+		}()
+	}
+	wg.Wait()
+
+	table := &ui.Table{Columns: []string{"PROJECT", "DEPLOYMENT", "RESULT", "DURATION", "DETAILS"}}
+	failed, skipped := 0, 0
+	for _, r := range results {
+		switch r.result {
+		case resultFailed:
+			failed++
+		case resultSkipped:
+			skipped++
+		}
+		duration := "-"
+		if r.duration > 0 {
+			duration = ui.FormatDuration(r.duration)
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: r.project.DeploymentID, Status: r.result, Cells: []string{
+			r.project.Name, r.project.DeploymentID, r.result, duration, r.details,
+		}})
+	}
+	fmt.Fprintln(w, "\nWorkspace deploy summary:")
+	if err := table.Write(w); err != nil {
+		return err
+	}
+
+	if interrupted != nil {
+		return interrupted
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d projects failed to deploy (%d skipped)", failed, len(order), skipped)
+	}
+	return nil
+}
+
+// newProjectResult describes how deploying a project that was started
+// went.
+func newProjectResult(p *workspace.Project, err error, ids map[string]string, duration time.Duration) projectResult {
+	r := projectResult{project: p, result: resultDeployed, details: "-", duration: duration, err: err}
+	var blocked *blockedError
+	switch {
+	case errors.As(err, &blocked):
+		r.result, r.details = resultSkipped, blocked.reason+"; built but not deployed"
+	case err != nil:
+		r.result, r.details = resultFailed, err.Error()
+	case ids["build_id"] != "":
+		r.details = "build " + ids["build_id"]
+	}
+	return r
+}
+
+// blockedError reports that a project was not deployed because one of its
+// dependencies did not deploy.
+type blockedError struct {
+	reason string
+}
+
+func (e *blockedError) Error() string { return e.reason }
+
+// prefixWriter writes whole lines to a writer shared with other projects,
+// each prefixed with the project's name, so the output of projects deploying
+// at once can be told apart.
+type prefixWriter struct {
+	mu     *sync.Mutex // Shared by every writer of w
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		p.mu.Lock()
+		_, err := fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buf[:i])
+		p.mu.Unlock()
+		p.buf = p.buf[i+1:]
+		if err != nil {
+			return len(b), err
+		}
+	}
+}
+
+// Flush writes a final line that didn't end in a newline.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.Write([]byte("\n"))
+	}
+}
+
+// blockedBy explains why p cannot deploy, or returns "" if none of its
+// dependencies failed or were skipped.
+func blockedBy(p *workspace.Project, outcome map[string]string) string {
+	var reasons []string
+	for _, dep := range p.DependsOn {
+		// Dependencies still deploying have no result yet
+		if result, ok := outcome[dep]; ok && result != resultDeployed {
+			reasons = append(reasons, fmt.Sprintf("%s (%s)", dep, result))
+		}
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return "depends on " + strings.Join(reasons, ", ")
+}
@@ -0,0 +1,191 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/cozy-creator/cozyctl/internal/workspace"
+)
+
+func TestDeployAllSkipsDownstreamOfFailure(t *testing.T) {
+	order := []*workspace.Project{
+		{Name: "embed", DeploymentID: "embedder"},
+		{Name: "rerank", DeploymentID: "reranker", DependsOn: []string{"embedder"}},
+		{Name: "api", DeploymentID: "api", DependsOn: []string{"reranker"}},
+		{Name: "jobs", DeploymentID: "jobs"},
+	}
+
+	var out bytes.Buffer
+	var deployed []string
+	err := deployAll(&out, order, ui.ModePlain, 1, func(progress *ui.Progress, p *workspace.Project, ready func() error) error {
+		deployed = append(deployed, p.DeploymentID)
+		if p.DeploymentID == "embedder" {
+			return errors.New("build failed")
+		}
+		progress.SetID("build_id", "build-"+p.DeploymentID)
+		return nil
+	})
+
+	if err == nil || err.Error() != "1 of 4 projects failed to deploy (2 skipped)" {
+		t.Fatalf("deployAll error = %v", err)
+	}
+	if got := strings.Join(deployed, ","); got != "embedder,jobs" {
+		t.Errorf("deployed %s, want embedder,jobs", got)
+	}
+	for _, want := range []string{
+		"[2/4] rerank: skipped (depends on embedder (failed))",
+		"[3/4] api: skipped (depends on reranker (skipped))",
+		"Workspace deploy summary:",
+		"build failed",
+		"build build-jobs",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDeployAllParallel(t *testing.T) {
+	order := []*workspace.Project{
+		{Name: "embed", DeploymentID: "embedder"},
+		{Name: "rerank", DeploymentID: "reranker", DependsOn: []string{"embedder"}},
+		{Name: "api", DeploymentID: "api", DependsOn: []string{"reranker"}},
+		{Name: "jobs", DeploymentID: "jobs"},
+	}
+
+	var (
+		mu               sync.Mutex
+		running, maxSeen int
+		deployed         []string
+	)
+	var out bytes.Buffer
+	err := deployAll(&out, order, ui.ModePlain, 2, func(progress *ui.Progress, p *workspace.Project, ready func() error) error {
+		mu.Lock()
+		running++
+		maxSeen = max(maxSeen, running)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		progress.Printf("building %s\n", p.DeploymentID)
+		time.Sleep(20 * time.Millisecond)
+		if p.DeploymentID == "embedder" {
+			return errors.New("build failed")
+		}
+		if err := ready(); err != nil {
+			return err
+		}
+		mu.Lock()
+		deployed = append(deployed, p.DeploymentID)
+		mu.Unlock()
+		return nil
+	})
+
+	if err == nil || err.Error() != "1 of 4 projects failed to deploy (2 skipped)" {
+		t.Fatalf("deployAll error = %v\n%s", err, out.String())
+	}
+	if maxSeen != 2 {
+		t.Errorf("ran %d projects at once, want 2", maxSeen)
+	}
+	if got := strings.Join(deployed, ","); got != "jobs" {
+		t.Errorf("deployed %s, want jobs", got)
+	}
+	for _, want := range []string{
+		"[embed] building embedder",
+		"[rerank] building reranker",
+		"depends on embedder (failed); built but not deployed",
+		"DURATION",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestBuildAndPromoteWorkspaceProject(t *testing.T) {
+	builder, orchestrator := newMockClients(t)
+
+	dir := filepath.Join(t.TempDir(), "embed")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pyproject := "[project]\nname = \"embed\"\n\n[tool.cozy]\ndeployment-id = \"embedder\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	progress := ui.New(&out)
+	p := &workspace.Project{Dir: dir, Name: "embed", DeploymentID: "embedder"}
+	if err := buildAndPromote(context.Background(), progress, builder, orchestrator, "tenant", p, api.BuildOptions{}, nil, nil); err != nil {
+		t.Fatalf("buildAndPromote: %v\n%s", err, out.String())
+	}
+	if _, err := orchestrator.GetDeployment("embedder"); err != nil {
+		t.Errorf("deployment embedder not created: %v", err)
+	}
+}
+
+func TestBuildAndPromotePreDeployHookAborts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts use sh")
+	}
+	builder, orchestrator := newMockClients(t)
+
+	dir := filepath.Join(t.TempDir(), "embed")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pyproject := "[project]\nname = \"embed\"\n\n[tool.cozy]\ndeployment-id = \"embedder\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	p := &workspace.Project{Dir: dir, Name: "embed", DeploymentID: "embedder", PreDeploy: `echo "build $COZY_BUILD_ID rejected"; exit 1`}
+	err := buildAndPromote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", p, api.BuildOptions{}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "pre-deploy hook") {
+		t.Fatalf("buildAndPromote error = %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "rejected") || strings.Contains(out.String(), "build  rejected") {
+		t.Errorf("hook output should include the build ID:\n%s", out.String())
+	}
+	if d, _ := orchestrator.GetDeployment("embedder"); d != nil {
+		t.Error("embedder should not be deployed when the hook fails")
+	}
+}
+
+func TestBuildAndPromoteDeniedByPolicy(t *testing.T) {
+	builder, orchestrator := newMockClients(t)
+
+	dir := filepath.Join(t.TempDir(), "embed")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pyproject := "[project]\nname = \"embed\"\n\n[tool.cozy]\ndeployment-id = \"embedder\"\n\n[tool.cozy.policy]\nparams = { required_labels = [\"team\"] }\n"
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	p := &workspace.Project{Dir: dir, Name: "embed", DeploymentID: "embedder"}
+	err := buildAndPromote(context.Background(), ui.New(&out), builder, orchestrator, "tenant", p, api.BuildOptions{}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), `label "team" is required`) {
+		t.Fatalf("buildAndPromote error = %v\n%s", err, out.String())
+	}
+	if d, _ := orchestrator.GetDeployment("embedder"); d != nil {
+		t.Error("embedder should not be deployed when a policy denies it")
+	}
+}
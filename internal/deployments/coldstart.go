@@ -0,0 +1,283 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+const (
+	// DefaultColdStartTimeout bounds how long the first invocation after
+	// scaling to zero may take.
+	DefaultColdStartTimeout = 15 * time.Minute
+
+	// scaleDownTimeout bounds how long to wait for workers to stop.
+	scaleDownTimeout      = 2 * time.Minute
+	scaleDownPollInterval = 2 * time.Second
+
+	// clockSkew is how far orchestrator event timestamps may run behind
+	// the local clock and still count towards the measured cold start.
+	clockSkew = 5 * time.Second
+
+	// slowPhase is how long a startup phase can take before a remedy is
+	// suggested even when it isn't the largest share.
+	slowPhase = 30 * time.Second
+)
+
+// Cold start phases, in order.
+const (
+	PhaseSchedule       = "schedule"
+	PhaseImagePull      = "image pull"
+	PhaseModelLoad      = "model load"
+	PhaseFirstInference = "first inference"
+)
+
+// ColdStartOptions contains the options for analyzing a cold start.
+type ColdStartOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Function     string // Defaults to the deployment's first function
+	Payload      []byte
+	Timeout      time.Duration // For the first invocation
+	Yes          bool          // Scale to zero without prompting
+	Output       ui.Output
+}
+
+// ColdStartReport is a measured cold start, broken into phases.
+type ColdStartReport struct {
+	DeploymentID string           `json:"deployment_id"`
+	Function     string           `json:"function"`
+	InvocationID string           `json:"invocation_id,omitempty"`
+	TotalMS      int64            `json:"total_ms"`
+	Phases       []ColdStartPhase `json:"phases"`
+	Suggestions  []string         `json:"suggestions"`
+}
+
+// ColdStartPhase is one phase of a cold start. A phase whose end the
+// orchestrator didn't report is not measured; its time counts towards the
+// next measured phase.
+type ColdStartPhase struct {
+	Name       string `json:"name"`
+	Measured   bool   `json:"measured"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// ColdStart scales a deployment to zero, invokes it, and reports how long
+// the first successful invocation took from scheduling a worker to the
+// response, with remedies for the slowest phases.
+func ColdStart(opts ColdStartOptions) (err error) {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	ids := map[string]string{"deployment_id": opts.DeploymentID}
+	recorder := history.Start(opts.Profile, "coldstart")
+	defer func() { recorder.Finish(ids, err) }()
+
+	return coldStart(os.Stdin, os.Stdout, client, opts)
+}
+
+func coldStart(in io.Reader, out io.Writer, client api.OrchestratorAPI, opts ColdStartOptions) error {
+	d, err := client.GetDeployment(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	if d == nil {
+		return fmt.Errorf("deployment '%s' not found", opts.DeploymentID)
+	}
+
+	function := opts.Function
+	if function == "" {
+		if len(d.FunctionRequirements) == 0 {
+			return fmt.Errorf("deployment '%s' lists no functions; pass --function", opts.DeploymentID)
+		}
+		function = d.FunctionRequirements[0].Name
+	}
+
+	// Progress goes to stderr when stdout carries structured output
+	progress := out
+	if opts.Output.Structured() {
+		progress = os.Stderr
+	}
+
+	if !opts.Yes {
+		ok, err := ui.Confirm(in, progress, fmt.Sprintf("Stop all %d workers of %s to measure a cold start? Invocations wait for a new worker meanwhile", d.ReadyWorkers, d.ID))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(progress, "Aborted.")
+			return nil
+		}
+	}
+
+	fmt.Fprintf(progress, "Scaling %s to zero...\n", d.ID)
+	if _, err := client.ScaleToZero(d.ID); err != nil {
+		return fmt.Errorf("failed to scale to zero: %w", err)
+	}
+	if err := waitForZero(client, d.ID); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(progress, "Invoking %s...\n", function)
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultColdStartTimeout
+	}
+	start := time.Now()
+	resp, err := client.InvokeWithOptions(d.ID, function, opts.Payload, api.InvokeOptions{Timeout: timeout})
+	end := time.Now()
+	if err != nil {
+		return fmt.Errorf("first invocation failed after %s: %w", end.Sub(start).Round(time.Millisecond), err)
+	}
+
+	events, err := client.ListDeploymentEvents(d.ID, 50)
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+
+	report := ColdStartReport{
+		DeploymentID: d.ID,
+		Function:     function,
+		InvocationID: resp.InvocationID,
+		TotalMS:      end.Sub(start).Milliseconds(),
+		Phases:       coldStartPhases(events, start, end),
+	}
+	report.Suggestions = coldStartSuggestions(report, d)
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(out, opts.Output, report)
+	}
+	writeColdStart(out, report)
+	return nil
+}
+
+// waitForZero waits until the deployment reports no ready workers.
+func waitForZero(client api.OrchestratorAPI, deploymentID string) error {
+	deadline := time.Now().Add(scaleDownTimeout)
+	for {
+		d, err := client.GetDeployment(deploymentID)
+		if err != nil {
+			return fmt.Errorf("failed to get deployment: %w", err)
+		}
+		if d.ReadyWorkers == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s still has %d ready workers after %s", deploymentID, d.ReadyWorkers, scaleDownTimeout)
+		}
+		time.Sleep(scaleDownPollInterval)
+	}
+}
+
+// coldStartPhases splits the time from start to end at the worker startup
+// events the orchestrator reported in between.
+func coldStartPhases(events []api.DeploymentEvent, start, end time.Time) []ColdStartPhase {
+	// Events are newest first; keep the latest of each startup type
+	seen := map[string]time.Time{}
+	for _, e := range events {
+		if e.Timestamp.Before(start.Add(-clockSkew)) {
+			continue
+		}
+		if _, ok := seen[e.Type]; !ok {
+			seen[e.Type] = e.Timestamp
+		}
+	}
+
+	ends := []struct {
+		phase string
+		event string
+	}{
+		{PhaseSchedule, api.EventWorkerScheduled},
+		{PhaseImagePull, api.EventImagePulled},
+		{PhaseModelLoad, api.EventModelsLoaded},
+	}
+
+	var phases []ColdStartPhase
+	from := start
+	for _, p := range ends {
+		at, ok := seen[p.event]
+		if !ok {
+			phases = append(phases, ColdStartPhase{Name: p.phase})
+			continue
+		}
+		at = clampTime(at, from, end)
+		phases = append(phases, ColdStartPhase{Name: p.phase, Measured: true, DurationMS: at.Sub(from).Milliseconds()})
+		from = at
+	}
+	phases = append(phases, ColdStartPhase{Name: PhaseFirstInference, Measured: true, DurationMS: end.Sub(from).Milliseconds()})
+	return phases
+}
+
+func clampTime(t, lo, hi time.Time) time.Time {
+	if t.Before(lo) {
+		return lo
+	}
+	if t.After(hi) {
+		return hi
+	}
+	return t
+}
+
+// coldStartSuggestions suggests remedies for the phases that dominate a
+// cold start.
+func coldStartSuggestions(r ColdStartReport, d *api.DeploymentResponse) []string {
+	suggestions := []string{}
+	total := time.Duration(r.TotalMS) * time.Millisecond
+	for _, p := range r.Phases {
+		if !p.Measured {
+			continue
+		}
+		took := time.Duration(p.DurationMS) * time.Millisecond
+		if took < slowPhase && took*100 < total*40 {
+			continue
+		}
+		switch p.Name {
+		case PhaseSchedule:
+			suggestions = append(suggestions, fmt.Sprintf("Waiting for a machine took %s: GPUs of the requested type were scarce; a more widely available GPU type schedules faster.", took.Round(time.Second)))
+		case PhaseImagePull:
+			suggestions = append(suggestions, fmt.Sprintf("Pulling the image took %s: slim it by trimming dependencies and build-only files (see 'cozyctl images inspect').", took.Round(time.Second)))
+		case PhaseModelLoad:
+			suggestions = append(suggestions, fmt.Sprintf("Loading models took %s: bake the weights into the image so workers read them from local disk instead of downloading them at startup.", took.Round(time.Second)))
+		}
+	}
+	if d.MinWorkers == 0 {
+		suggestions = append(suggestions, "The deployment scales to zero when idle: set min workers to 1 ('cozyctl update --min-workers 1') to keep a worker warm and skip cold starts.")
+	}
+	return suggestions
+}
+
+func writeColdStart(w io.Writer, r ColdStartReport) {
+	total := time.Duration(r.TotalMS) * time.Millisecond
+	fmt.Fprintf(w, "\nCold start of %s/%s: %s to the first successful response\n\n", r.DeploymentID, r.Function, total.Round(time.Millisecond))
+
+	table := &ui.Table{Columns: []string{"PHASE", "TIME", "SHARE"}}
+	for _, p := range r.Phases {
+		took, share := "-", "-"
+		if p.Measured {
+			d := time.Duration(p.DurationMS) * time.Millisecond
+			took = d.Round(time.Millisecond).String()
+			if r.TotalMS > 0 {
+				share = fmt.Sprintf("%d%%", p.DurationMS*100/r.TotalMS)
+			}
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: p.Name, Cells: []string{p.Name, took, share}})
+	}
+	table.Write(w)
+
+	if len(r.Suggestions) > 0 {
+		fmt.Fprintln(w, "\nSuggestions:")
+		for _, s := range r.Suggestions {
+			fmt.Fprintf(w, "  - %s\n", s)
+		}
+	}
+}
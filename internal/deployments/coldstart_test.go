@@ -0,0 +1,79 @@
+package deployments
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func TestColdStartPhases(t *testing.T) {
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+	events := []api.DeploymentEvent{ // Newest first
+		{Type: api.EventModelsLoaded, Timestamp: at(70)},
+		{Type: api.EventWorkerScheduled, Timestamp: at(5)},
+		{Type: api.EventModelsLoaded, Timestamp: at(-600)}, // An earlier start
+	}
+
+	phases := coldStartPhases(events, start, at(75))
+	want := []ColdStartPhase{
+		{Name: PhaseSchedule, Measured: true, DurationMS: 5000},
+		{Name: PhaseImagePull},
+		{Name: PhaseModelLoad, Measured: true, DurationMS: 65000},
+		{Name: PhaseFirstInference, Measured: true, DurationMS: 5000},
+	}
+	if len(phases) != len(want) {
+		t.Fatalf("got %+v", phases)
+	}
+	for i := range want {
+		if phases[i] != want[i] {
+			t.Errorf("phase %d = %+v, want %+v", i, phases[i], want[i])
+		}
+	}
+
+	r := ColdStartReport{TotalMS: 75000, Phases: phases}
+	suggestions := strings.Join(coldStartSuggestions(r, &api.DeploymentResponse{MinWorkers: 0}), "\n")
+	for _, want := range []string{"bake the weights", "--min-workers 1"} {
+		if !strings.Contains(suggestions, want) {
+			t.Errorf("expected %q in suggestions:\n%s", want, suggestions)
+		}
+	}
+	if strings.Contains(suggestions, "GPU type") {
+		t.Errorf("did not expect a scheduling suggestion:\n%s", suggestions)
+	}
+}
+
+func TestColdStartAgainstMockServer(t *testing.T) {
+	client := newMockClient(t)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-model",
+		ImageURL:             "registry.example/my-model:1",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := coldStart(strings.NewReader("y\n"), &out, client, ColdStartOptions{DeploymentID: "my-model"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Scaling my-model to zero", "Cold start of my-model/generate", "image pull", "first inference"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "  -  ") {
+		t.Errorf("expected every phase to be measured:\n%s", out.String())
+	}
+
+	d, err := client.GetDeployment("my-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ReadyWorkers != 1 {
+		t.Errorf("expected the invocation to start a worker, got %d ready", d.ReadyWorkers)
+	}
+}
@@ -0,0 +1,187 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// CompareOptions contains the options for comparing two deployments.
+type CompareOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	ProfileB  *config.ProfileRef // Look up the second deployment with another profile
+	A, B      string
+	All       bool // Include fields that are the same
+	Output    ui.Output
+}
+
+// FieldDiff is one field of two compared deployments.
+type FieldDiff struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Same  bool   `json:"same"`
+}
+
+// Comparison is the field-by-field comparison of two deployments.
+type Comparison struct {
+	A      string      `json:"a"`
+	B      string      `json:"b"`
+	Fields []FieldDiff `json:"fields"`
+}
+
+// Compare prints a field-by-field diff of two deployments' specs.
+func Compare(opts CompareOptions) error {
+	clientA, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	clientB := clientA
+	if opts.ProfileB != nil {
+		if clientB, err = api.NewProfileClient(*opts.ProfileB, opts.Transport); err != nil {
+			return err
+		}
+	}
+	return compare(os.Stdout, clientA, clientB, opts)
+}
+
+func compare(w io.Writer, clientA, clientB api.OrchestratorAPI, opts CompareOptions) error {
+	a, err := getDeployment(clientA, opts.A)
+	if err != nil {
+		return err
+	}
+	b, err := getDeployment(clientB, opts.B)
+	if err != nil {
+		return err
+	}
+
+	c := Comparison{A: opts.A, B: opts.B, Fields: compareDeployments(a, b)}
+	if !opts.All {
+		c.Fields = slices.DeleteFunc(c.Fields, func(f FieldDiff) bool { return f.Same })
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, c)
+	}
+
+	differing := 0
+	for _, f := range c.Fields {
+		if !f.Same {
+			differing++
+		}
+	}
+	if differing == 0 && !opts.All {
+		fmt.Fprintf(w, "%s and %s have the same spec.\n", opts.A, opts.B)
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"FIELD", opts.A, opts.B, ""}}
+	for _, f := range c.Fields {
+		marker := "≠"
+		if f.Same {
+			marker = ""
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: f.Field, Cells: []string{f.Field, f.A, f.B, marker}})
+	}
+	if err := table.Write(w); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%d %s differ\n", differing, plural(differing, "field", "fields"))
+	return nil
+}
+
+func getDeployment(client api.OrchestratorAPI, id string) (*api.DeploymentResponse, error) {
+	d, err := client.GetDeployment(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment %s: %w", id, err)
+	}
+	if d == nil {
+		return nil, fmt.Errorf("deployment '%s' not found", id)
+	}
+	return d, nil
+}
+
+// compareDeployments lists the spec fields of two deployments side by
+// side: one row per function and per secret, so a missing GPU flag or
+// secret stands out. What secrets map to is never shown, only whether the
+// mappings match.
+func compareDeployments(a, b *api.DeploymentResponse) []FieldDiff {
+	var fields []FieldDiff
+	add := func(field, va, vb string) {
+		fields = append(fields, FieldDiff{Field: field, A: va, B: vb, Same: va == vb})
+	}
+
+	add("image", orDash(a.ImageURL), orDash(b.ImageURL))
+	add("min workers", strconv.Itoa(a.MinWorkers), strconv.Itoa(b.MinWorkers))
+	add("max workers", strconv.Itoa(a.MaxWorkers), strconv.Itoa(b.MaxWorkers))
+
+	for _, name := range unionKeys(functionsByName(a.FunctionRequirements), functionsByName(b.FunctionRequirements)) {
+		add("function "+name, describeFunction(a.FunctionRequirements, name), describeFunction(b.FunctionRequirements, name))
+	}
+
+	modelsA, modelsB := slices.Sorted(slices.Values(a.SupportedModelIDs)), slices.Sorted(slices.Values(b.SupportedModelIDs))
+	add("models", joinOrDash(modelsA), joinOrDash(modelsB))
+
+	for _, name := range unionKeys(a.RunpodSecretMapping, b.RunpodSecretMapping) {
+		va, inA := a.RunpodSecretMapping[name]
+		vb, inB := b.RunpodSecretMapping[name]
+		switch {
+		case inA && inB && va != vb:
+			add("secret "+name, "set", "set (mapped differently)")
+		default:
+			add("secret "+name, setOrDash(inA), setOrDash(inB))
+		}
+	}
+	return fields
+}
+
+func functionsByName(fns []api.FunctionRequirement) map[string]string {
+	m := make(map[string]string, len(fns))
+	for _, f := range fns {
+		m[f.Name] = f.Name
+	}
+	return m
+}
+
+// describeFunction summarizes a function's requirements, or "-" when the
+// deployment doesn't have it.
+func describeFunction(fns []api.FunctionRequirement, name string) string {
+	i := slices.IndexFunc(fns, func(f api.FunctionRequirement) bool { return f.Name == name })
+	if i < 0 {
+		return "-"
+	}
+	desc := "cpu"
+	if fns[i].RequiresGPU {
+		desc = "gpu"
+	}
+	if fns[i].Disabled {
+		desc += ", disabled"
+	}
+	return desc
+}
+
+func unionKeys(a, b map[string]string) []string {
+	keys := sortedKeys(a)
+	for _, k := range sortedKeys(b) {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func setOrDash(set bool) string {
+	if set {
+		return "set"
+	}
+	return "-"
+}
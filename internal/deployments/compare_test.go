@@ -0,0 +1,62 @@
+package deployments
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func TestCompareShowsDrift(t *testing.T) {
+	client := newMockClient(t)
+	one, four, two := 1, 4, 2
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "staging",
+		ImageURL:             "registry.example/my-model:2",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate", RequiresGPU: true}, {Name: "embed"}},
+		SupportedModelIDs:    []string{"sdxl"},
+		RunpodSecretMapping:  map[string]string{"HF_TOKEN": "hf-staging", "API_KEY": "key"},
+		MinWorkers:           &one,
+		MaxWorkers:           &four,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "prod",
+		ImageURL:             "registry.example/my-model:1",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate"}},
+		SupportedModelIDs:    []string{"sdxl"},
+		RunpodSecretMapping:  map[string]string{"HF_TOKEN": "hf-prod", "API_KEY": "key"},
+		MinWorkers:           &one,
+		MaxWorkers:           &two,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := compare(&out, client, client, CompareOptions{A: "staging", B: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"registry.example/my-model:2", "max workers", "function embed", "function generate",
+		"set (mapped differently)", "5 fields differ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+	for _, unwanted := range []string{"min workers", "models", "API_KEY", "hf-prod"} {
+		if strings.Contains(out.String(), unwanted) {
+			t.Errorf("did not expect %q in:\n%s", unwanted, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := compare(&out, client, client, CompareOptions{A: "prod", B: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "same spec") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
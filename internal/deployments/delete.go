@@ -0,0 +1,225 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// DeleteOptions contains the options for deleting a deployment.
+type DeleteOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Yes          bool // Delete without prompting
+	DryRun       bool // Show what would be deleted without deleting it
+}
+
+// DeletePlan is what deleting a deployment takes with it, or leaves
+// pointing at nothing.
+type DeletePlan struct {
+	Deployment *api.DeploymentResponse
+
+	// Deleted with the deployment
+	Snapshots     []api.DeploymentSnapshot
+	Traffic       *api.TrafficSplit  // Only when split between builds
+	RebuildPolicy *api.RebuildPolicy // Scheduled rebuilds stop
+
+	// Kept, but useless without the deployment
+	NotificationRules []api.NotificationRule // Rules for this deployment only
+	APIKeys           []api.APIKey           // Keys scoped to this deployment only
+
+	// Dependents that couldn't be listed, with why
+	Unchecked []string
+}
+
+// keyLister lists the account's API keys.
+type keyLister interface {
+	ListAPIKeys() ([]api.APIKey, error)
+}
+
+// deleteClients are the APIs a deletion consults. builder and keys may be
+// nil, leaving their dependents unchecked.
+type deleteClients struct {
+	orchestrator api.OrchestratorAPI
+	builder      api.BuilderAPI
+	keys         keyLister
+}
+
+// Delete removes a deployment from the orchestrator after confirmation,
+// stopping its workers. Its builds stay in cozy-hub. With DryRun it only
+// shows what would be removed.
+func Delete(opts DeleteOptions) (err error) {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	clients := deleteClients{orchestrator: client}
+	if builder, auth, err := newHubClients(opts.Profile, opts.Transport); err == nil {
+		clients.builder, clients.keys = builder, auth
+	}
+
+	if !opts.DryRun {
+		ids := map[string]string{"deployment_id": opts.DeploymentID}
+		recorder := history.Start(opts.Profile, "deployments delete")
+		defer func() { recorder.Finish(ids, err) }()
+	}
+
+	return deleteDeployment(os.Stdin, os.Stdout, clients, opts)
+}
+
+func deleteDeployment(in io.Reader, out io.Writer, clients deleteClients, opts DeleteOptions) error {
+	plan, err := planDelete(clients, opts.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun || !opts.Yes {
+		writePlan(out, plan)
+	}
+	if opts.DryRun {
+		fmt.Fprintln(out, "\nDry run: nothing was deleted.")
+		return nil
+	}
+
+	if !opts.Yes {
+		ok, err := ui.Confirm(in, out, fmt.Sprintf("Delete deployment %s?", plan.Deployment.ID))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Aborted.")
+			return nil
+		}
+	}
+
+	if err := clients.orchestrator.DeleteDeployment(plan.Deployment.ID); err != nil {
+		return fmt.Errorf("failed to delete deployment: %w", err)
+	}
+	fmt.Fprintf(out, "Deleted deployment %s\n", plan.Deployment.ID)
+	return nil
+}
+
+// planDelete looks up the deployment and what depends on it. Dependents
+// that can't be listed are noted in the plan rather than failing it.
+func planDelete(clients deleteClients, id string) (*DeletePlan, error) {
+	deployment, err := clients.orchestrator.GetDeployment(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
+	}
+	if deployment == nil {
+		return nil, fmt.Errorf("deployment '%s' not found", id)
+	}
+	plan := &DeletePlan{Deployment: deployment}
+	unchecked := func(what string, err error) {
+		plan.Unchecked = append(plan.Unchecked, fmt.Sprintf("%s: %v", what, err))
+	}
+
+	if plan.Snapshots, err = clients.orchestrator.ListDeploymentSnapshots(id); err != nil {
+		unchecked("snapshots", err)
+	}
+
+	if clients.builder == nil {
+		plan.Unchecked = append(plan.Unchecked, "traffic split, rebuild schedule, and notification rules: no cozy-hub client")
+	} else {
+		// Deployments made without a build have no traffic split in cozy-hub
+		if split, err := clients.builder.GetTraffic(id); err == nil && len(split.Routes) > 1 {
+			plan.Traffic = split
+		}
+		if policies, err := clients.builder.ListRebuildPolicies(); err != nil {
+			unchecked("rebuild schedule", err)
+		} else {
+			for i := range policies {
+				if policies[i].DeploymentID == id {
+					plan.RebuildPolicy = &policies[i]
+				}
+			}
+		}
+		if rules, err := clients.builder.ListNotificationRules(); err != nil {
+			unchecked("notification rules", err)
+		} else {
+			for _, r := range rules {
+				if r.Deployment == id {
+					plan.NotificationRules = append(plan.NotificationRules, r)
+				}
+			}
+		}
+	}
+
+	if clients.keys == nil {
+		plan.Unchecked = append(plan.Unchecked, "API keys: no cozy-hub client")
+	} else if keys, err := clients.keys.ListAPIKeys(); err != nil {
+		unchecked("API keys", err)
+	} else {
+		for _, k := range keys {
+			if scopedTo(k, id) {
+				plan.APIKeys = append(plan.APIKeys, k)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// scopedTo reports whether every scope of key is limited to the deployment,
+// so the key grants nothing once it is deleted.
+func scopedTo(key api.APIKey, deployment string) bool {
+	if len(key.Scopes) == 0 {
+		return false
+	}
+	for _, s := range key.Scopes {
+		scope, err := api.ParseScope(s)
+		if err != nil || scope.Deployment != deployment {
+			return false
+		}
+	}
+	return true
+}
+
+// writePlan describes what deleting the deployment will do.
+func writePlan(out io.Writer, plan *DeletePlan) {
+	d := plan.Deployment
+	fmt.Fprintf(out, "Deleting deployment %s will:\n", d.ID)
+	if d.ReadyWorkers > 0 {
+		fmt.Fprintf(out, "  - stop its %d ready worker(s) and fail new invocations\n", d.ReadyWorkers)
+	} else {
+		fmt.Fprintf(out, "  - remove it from the orchestrator (no workers are ready)\n")
+	}
+	for _, s := range plan.Snapshots {
+		label := s.ID
+		if s.Description != "" {
+			label += ", " + s.Description
+		}
+		fmt.Fprintf(out, "  - delete snapshot %s (%s)\n", orDash(s.Name), label)
+	}
+	if plan.Traffic != nil {
+		var routes []string
+		for _, r := range plan.Traffic.Routes {
+			routes = append(routes, fmt.Sprintf("%s %d%%", r.BuildID, r.Percent))
+		}
+		fmt.Fprintf(out, "  - delete its traffic split (%s)\n", strings.Join(routes, ", "))
+	}
+	if p := plan.RebuildPolicy; p != nil {
+		fmt.Fprintf(out, "  - delete its rebuild schedule (%s, rebuilding build %s)\n", p.Schedule, p.SourceBuildID)
+	}
+	for _, r := range plan.NotificationRules {
+		fmt.Fprintf(out, "  - leave notification rule %s (%s to %s) with nothing to report on\n", r.ID, r.Channel, orDash(r.Target))
+	}
+	for _, k := range plan.APIKeys {
+		fmt.Fprintf(out, "  - leave API key %s (%s, %s) granting nothing\n", orDash(k.Name), k.Prefix, strings.Join(k.Scopes, " "))
+	}
+	fmt.Fprintln(out, "Its builds stay in cozy-hub, so it can be deployed again from one of them.")
+	if d.Lock != nil {
+		fmt.Fprintf(out, "It is locked (%s), so the delete needs --unlock-reason.\n", d.Lock.Reason)
+	}
+	for _, u := range plan.Unchecked {
+		fmt.Fprintf(out, "Couldn't check %s\n", u)
+	}
+}
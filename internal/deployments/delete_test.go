@@ -0,0 +1,113 @@
+package deployments
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestGetAndDelete(t *testing.T) {
+	client := newMockClient(t)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:       "my-model",
+		ImageURL: "registry.example/my-model:1",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	clients := deleteClients{orchestrator: client}
+
+	var out bytes.Buffer
+	if err := get(&out, client, "my-model", ui.OutputWide, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"ID", "my-model", "registry.example/my-model:1"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("get output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// Declining keeps the deployment
+	out.Reset()
+	if err := deleteDeployment(strings.NewReader("n\n"), &out, clients, DeleteOptions{DeploymentID: "my-model"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Aborted.") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+	if _, err := client.GetDeployment("my-model"); err != nil {
+		t.Fatalf("deployment deleted after declining: %v", err)
+	}
+
+	out.Reset()
+	if err := deleteDeployment(strings.NewReader("y\n"), &out, clients, DeleteOptions{DeploymentID: "my-model"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Deleted deployment my-model") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+	if err := get(&out, client, "my-model", ui.OutputDefault, time.Now()); err == nil {
+		t.Error("expected an error getting the deleted deployment")
+	}
+}
+
+func TestDeleteDryRunListsDependents(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	orchestrator := api.NewClient(ts.URL, "token", nil)
+	builder := api.NewBuilderClient(ts.URL, "token", nil)
+	auth := api.NewAuthClient(ts.URL, "token", nil)
+
+	upload, err := builder.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.DeployBuild(upload.BuildID, &api.DeployBuildRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.SetRebuildPolicy("my-model", api.RebuildWeekly); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.SetNotificationRule(&api.SetNotificationRuleRequest{
+		Events: api.NotificationEvents[:1], Channel: api.ChannelEmail, Target: "ops@example.com", Deployment: "my-model",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.CreateAPIKey(&api.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"deploy:my-model"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.CreateAPIKey(&api.CreateAPIKeyRequest{Name: "admin", Scopes: []string{"manage"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orchestrator.CreateDeploymentSnapshot("my-model", &api.CreateDeploymentSnapshotRequest{Description: "before-upgrade"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	clients := deleteClients{orchestrator: orchestrator, builder: builder, keys: auth}
+	if err := deleteDeployment(strings.NewReader(""), &out, clients, DeleteOptions{DeploymentID: "my-model", DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"before-upgrade",
+		"delete its rebuild schedule (" + api.RebuildWeekly,
+		"notification rule", "ops@example.com",
+		"API key ci", "deploy:my-model",
+		"Dry run: nothing was deleted.",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("dry run output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "admin") || strings.Contains(out.String(), "Couldn't check") {
+		t.Errorf("unexpected dependents:\n%s", out.String())
+	}
+	if _, err := orchestrator.GetDeployment("my-model"); err != nil {
+		t.Errorf("dry run deleted the deployment: %v", err)
+	}
+}
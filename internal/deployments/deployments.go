@@ -0,0 +1,21 @@
+// Package deployments inspects the deployments registered with the orchestrator.
+package deployments
+
+import (
+	"net/http"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+)
+
+// newHubClients creates the cozy-hub builder and account API clients for a
+// profile.
+func newHubClients(ref config.ProfileRef, transport http.RoundTripper) (api.BuilderAPI, *api.AuthClient, error) {
+	profileCfg, err := config.LoadLoggedInProfile(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := profileCfg.Config.WithDefaultURLs()
+	return api.NewBuilderClient(cfg.BuilderURL, cfg.Token, transport), api.NewAuthClient(cfg.HubURL, cfg.Token, transport), nil
+}
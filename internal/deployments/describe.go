@@ -0,0 +1,189 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// DescribeOptions contains the options for describing a deployment.
+type DescribeOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Output       ui.Output
+}
+
+// Describe prints the full spec of a deployment, with the invocation
+// counters of its functions if the orchestrator keeps them.
+func Describe(opts DescribeOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return describe(os.Stdout, client, opts.DeploymentID, opts.Output)
+}
+
+// describeReport is the structured output of Describe: the deployment, plus
+// its function stats when available.
+type describeReport struct {
+	*api.DeploymentResponse
+	FunctionStats *api.DeploymentStats `json:"function_stats,omitempty"`
+}
+
+func describe(w io.Writer, client api.OrchestratorAPI, id string, output ui.Output) error {
+	deployment, err := client.GetDeployment(id)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	if deployment == nil {
+		return fmt.Errorf("deployment '%s' not found", id)
+	}
+
+	// The stats are extra detail, so failing to get them doesn't fail the command
+	stats, statsErr := functionStats(client, id)
+
+	if output.Structured() {
+		return ui.WriteStructured(w, output, describeReport{DeploymentResponse: deployment, FunctionStats: stats})
+	}
+	if err := renderDeployment(w, deployment, stats, output == ui.OutputWide, time.Now()); err != nil {
+		return err
+	}
+	if statsErr != nil {
+		fmt.Fprintf(w, "\nFunction stats unavailable: %v\n", statsErr)
+	}
+	return nil
+}
+
+// maxListed is how many models or secrets the default view shows before
+// summarizing the rest; the wide view lists them all.
+const maxListed = 5
+
+// renderDeployment writes the human-readable view of a deployment. stats,
+// if not nil, adds invocation counters to its functions.
+func renderDeployment(w io.Writer, d *api.DeploymentResponse, stats *api.DeploymentStats, wide bool, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	status := d.Status
+	if status == "" {
+		status = "unknown"
+	}
+
+	fmt.Fprintf(tw, "ID:\t%s\n", d.ID)
+	if d.Name != "" && d.Name != d.ID {
+		fmt.Fprintf(tw, "Name:\t%s\n", d.Name)
+	}
+	fmt.Fprintf(tw, "Tenant:\t%s\n", d.TenantID)
+	fmt.Fprintf(tw, "Status:\t%s\n", status)
+	if d.Lock != nil {
+		by := ""
+		if d.Lock.LockedBy != "" {
+			by = " by " + d.Lock.LockedBy
+		}
+		fmt.Fprintf(tw, "Locked:\t%s (%s%s)\n", d.Lock.Reason, formatTime(d.Lock.LockedAt, wide, now), by)
+	}
+	fmt.Fprintf(tw, "Image:\t%s\n", d.ImageURL)
+	fmt.Fprintf(tw, "Workers:\t%d ready (min %d, max %d)\n", d.ReadyWorkers, d.MinWorkers, d.MaxWorkers)
+	fmt.Fprintf(tw, "Created:\t%s\n", formatTime(d.CreatedAt, wide, now))
+	fmt.Fprintf(tw, "Updated:\t%s\n", formatTime(d.UpdatedAt, wide, now))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nFunctions (%d):\n", len(d.FunctionRequirements))
+	if len(d.FunctionRequirements) > 0 {
+		byName := statsByFunction(stats)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := "  NAME\tGPU\tSTATUS"
+		if stats != nil {
+			header += "\tINVOCATIONS\tERRORS\tAVG LATENCY\tLAST INVOKED"
+		}
+		fmt.Fprintln(tw, header)
+		for _, f := range d.FunctionRequirements {
+			gpu := "no"
+			if f.RequiresGPU {
+				gpu = "yes"
+			}
+			status := "enabled"
+			if f.Disabled {
+				status = "disabled"
+			}
+			cells := []string{f.Name, gpu, status}
+			if stats != nil {
+				cells = append(cells, statsCells(byName[f.Name], wide, now)...)
+			}
+			fmt.Fprintf(tw, "  %s\n", strings.Join(cells, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if stats != nil {
+			fmt.Fprintf(w, "  Stats cover the last %s.\n", formatAge(now.Sub(stats.Since)))
+			if names := uninvoked(d, stats); len(names) > 0 {
+				fmt.Fprintf(w, "  Not invoked in that time, so possibly safe to remove: %s\n", strings.Join(names, ", "))
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\nModels (%d):\n", len(d.SupportedModelIDs))
+	writeLimited(w, d.SupportedModelIDs, wide)
+
+	envVars := make([]string, 0, len(d.RunpodSecretMapping))
+	for env := range d.RunpodSecretMapping {
+		envVars = append(envVars, env)
+	}
+	sort.Strings(envVars)
+	secrets := make([]string, len(envVars))
+	for i, env := range envVars {
+		secrets[i] = fmt.Sprintf("%s <- %s", env, d.RunpodSecretMapping[env])
+	}
+	fmt.Fprintf(w, "\nSecrets (%d):\n", len(secrets))
+	writeLimited(w, secrets, wide)
+
+	return nil
+}
+
+// writeLimited writes one item per line, summarizing after maxListed unless wide.
+func writeLimited(w io.Writer, items []string, wide bool) {
+	for i, item := range items {
+		if !wide && i == maxListed {
+			fmt.Fprintf(w, "  ... and %d more (use --output wide)\n", len(items)-maxListed)
+			return
+		}
+		fmt.Fprintf(w, "  %s\n", item)
+	}
+}
+
+// formatTime shows a timestamp as an age, or in full for the wide view.
+func formatTime(t time.Time, wide bool, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	if wide {
+		return t.Local().Format(time.RFC3339)
+	}
+	return formatAge(now.Sub(t)) + " ago"
+}
+
+// formatAge renders a duration in its largest whole unit (e.g. "3d", "5m").
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
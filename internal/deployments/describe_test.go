@@ -0,0 +1,138 @@
+package deployments
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func newMockClient(t *testing.T) *api.Client {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	return api.NewClient(ts.URL, "token", nil)
+}
+
+func TestDescribeOutputs(t *testing.T) {
+	client := newMockClient(t)
+	minWorkers := 1
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-model",
+		ImageURL:             "registry.example/my-model:1",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate", RequiresGPU: true}},
+		SupportedModelIDs:    []string{"sdxl"},
+		RunpodSecretMapping:  map[string]string{"HF_TOKEN": "hf-secret"},
+		MinWorkers:           &minWorkers,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := describe(&out, client, "my-model", ui.OutputDefault); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"registry.example/my-model:1", "generate", "yes", "sdxl", "HF_TOKEN <- hf-secret"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("describe output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := describe(&out, client, "my-model", ui.OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	var got api.DeploymentResponse
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if got.ID != "my-model" || got.MinWorkers != 1 || got.RunpodSecretMapping["HF_TOKEN"] != "hf-secret" {
+		t.Errorf("unexpected JSON: %+v", got)
+	}
+
+	out.Reset()
+	if err := describe(&out, client, "my-model", ui.OutputYAML); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "id: my-model\n") || !strings.Contains(out.String(), "image_url: registry.example/my-model:1") {
+		t.Errorf("unexpected YAML:\n%s", out.String())
+	}
+}
+
+func TestDescribeNotFound(t *testing.T) {
+	err := describe(&bytes.Buffer{}, newMockClient(t), "missing", ui.OutputDefault)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestRenderDeploymentLimitsLists(t *testing.T) {
+	d := &api.DeploymentResponse{ID: "m", CreatedAt: time.Now().Add(-2 * time.Hour)}
+	for i := range 8 {
+		d.SupportedModelIDs = append(d.SupportedModelIDs, fmt.Sprintf("model-%d", i))
+	}
+
+	var out bytes.Buffer
+	renderDeployment(&out, d, nil, false, time.Now())
+	if strings.Contains(out.String(), "model-7") || !strings.Contains(out.String(), "... and 3 more") {
+		t.Errorf("default view should summarize long lists:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "2h ago") {
+		t.Errorf("expected relative creation time:\n%s", out.String())
+	}
+
+	out.Reset()
+	renderDeployment(&out, d, nil, true, time.Now())
+	if !strings.Contains(out.String(), "model-7") {
+		t.Errorf("wide view should list everything:\n%s", out.String())
+	}
+}
+
+func TestDescribeFunctionStats(t *testing.T) {
+	client := newMockClient(t)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-model",
+		ImageURL:             "registry.example/my-model:1",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate"}, {Name: "health"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if _, err := client.Invoke("my-model", "generate", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := describe(&out, client, "my-model", ui.OutputDefault); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"INVOCATIONS", "LAST INVOKED", "never", "possibly safe to remove: health"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("describe output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := describe(&out, client, "my-model", ui.OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	var got describeReport
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if got.ID != "my-model" || got.FunctionStats == nil {
+		t.Fatalf("unexpected JSON:\n%s", out.String())
+	}
+	counts := statsByFunction(got.FunctionStats)
+	if counts["generate"].Invocations != 2 || counts["health"].Invocations != 0 {
+		t.Errorf("unexpected function stats: %+v", got.FunctionStats.Functions)
+	}
+}
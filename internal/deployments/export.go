@@ -0,0 +1,207 @@
+package deployments
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+)
+
+// Export formats.
+const (
+	FormatTerraform = "terraform"
+)
+
+// ExportFormats lists the supported export formats.
+var ExportFormats = []string{FormatTerraform}
+
+// terraformResource is the resource type of the cozy Terraform provider.
+const terraformResource = "cozy_deployment"
+
+// ExportOptions contains the options for exporting deployments.
+type ExportOptions struct {
+	Profile       config.ProfileRef
+	Transport     http.RoundTripper
+	DeploymentIDs []string
+	All           bool // Export every deployment instead of DeploymentIDs
+	Format        string
+}
+
+// Export renders deployments as infrastructure-as-code, so resources created
+// with the CLI can be brought under the management of another tool.
+func Export(opts ExportOptions) error {
+	if !slices.Contains(ExportFormats, opts.Format) {
+		return fmt.Errorf("unknown export format %q (supported: %s)", opts.Format, strings.Join(ExportFormats, ", "))
+	}
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return export(os.Stdout, client, opts)
+}
+
+func export(w io.Writer, client api.OrchestratorAPI, opts ExportOptions) error {
+	var deployments []api.DeploymentResponse
+	if opts.All {
+		all, err := listAllDeployments(client)
+		if err != nil {
+			return fmt.Errorf("failed to list deployments: %w", err)
+		}
+		deployments = all
+	} else {
+		for _, id := range opts.DeploymentIDs {
+			d, err := client.GetDeployment(id)
+			if err != nil {
+				return fmt.Errorf("failed to get deployment: %w", err)
+			}
+			if d == nil {
+				return fmt.Errorf("deployment '%s' not found", id)
+			}
+			deployments = append(deployments, *d)
+		}
+	}
+
+	var buf bytes.Buffer
+	for i := range deployments {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		writeTerraform(&buf, &deployments[i])
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// listAllDeployments fetches every deployment of the tenant, page by page.
+func listAllDeployments(client api.OrchestratorAPI) ([]api.DeploymentResponse, error) {
+	var deployments []api.DeploymentResponse
+	var query api.ListDeploymentsOptions
+	for {
+		page, err := client.ListDeploymentsPage(query)
+		if err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, page.Items...)
+		if page.NextCursor == "" || len(page.Items) == 0 {
+			return deployments, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// writeTerraform writes a deployment as a cozy_deployment resource, with an
+// import block that adopts the existing deployment instead of creating a
+// new one (Terraform 1.5 or later).
+func writeTerraform(b *bytes.Buffer, d *api.DeploymentResponse) {
+	name := terraformName(d.ID)
+	address := terraformResource + "." + name
+
+	fmt.Fprintf(b, "# Exported by cozyctl from deployment %s\n\n", d.ID)
+	b.WriteString("import {\n")
+	writeAttributes(b, "  ", [][2]string{
+		{"to", address},
+		{"id", hclString(d.ID)},
+	})
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(b, "resource %q %q {\n", terraformResource, name)
+	attrs := [][2]string{{"deployment_id", hclString(d.ID)}}
+	if d.Name != "" && d.Name != d.ID {
+		attrs = append(attrs, [2]string{"name", hclString(d.Name)})
+	}
+	attrs = append(attrs,
+		[2]string{"image_url", hclString(d.ImageURL)},
+		[2]string{"min_workers", strconv.Itoa(d.MinWorkers)},
+		[2]string{"max_workers", strconv.Itoa(d.MaxWorkers)},
+	)
+	writeAttributes(b, "  ", attrs)
+
+	if len(d.SupportedModelIDs) > 0 {
+		b.WriteString("\n  supported_model_ids = [\n")
+		for _, id := range d.SupportedModelIDs {
+			fmt.Fprintf(b, "    %s,\n", hclString(id))
+		}
+		b.WriteString("  ]\n")
+	}
+
+	if len(d.RunpodSecretMapping) > 0 {
+		keys := make([]string, 0, len(d.RunpodSecretMapping))
+		for k := range d.RunpodSecretMapping {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		secrets := make([][2]string, len(keys))
+		for i, k := range keys {
+			key := k
+			if !hclIdentifier.MatchString(k) {
+				key = hclString(k)
+			}
+			secrets[i] = [2]string{key, hclString(d.RunpodSecretMapping[k])}
+		}
+		b.WriteString("\n  secret_mapping = {\n")
+		writeAttributes(b, "    ", secrets)
+		b.WriteString("  }\n")
+	}
+
+	for _, f := range d.FunctionRequirements {
+		attrs := [][2]string{
+			{"name", hclString(f.Name)},
+			{"requires_gpu", strconv.FormatBool(f.RequiresGPU)},
+		}
+		if f.Disabled {
+			attrs = append(attrs, [2]string{"enabled", "false"})
+		}
+		b.WriteString("\n  function {\n")
+		writeAttributes(b, "    ", attrs)
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+}
+
+// writeAttributes writes key = value lines with the equals signs aligned,
+// as terraform fmt does.
+func writeAttributes(b *bytes.Buffer, indent string, attrs [][2]string) {
+	width := 0
+	for _, attr := range attrs {
+		width = max(width, len(attr[0]))
+	}
+	for _, attr := range attrs {
+		fmt.Fprintf(b, "%s%-*s = %s\n", indent, width, attr[0], attr[1])
+	}
+}
+
+var (
+	hclIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	nonNameChars  = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// terraformName turns a deployment ID into a resource name.
+func terraformName(id string) string {
+	name := nonNameChars.ReplaceAllString(id, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "deployment_" + name
+	}
+	return name
+}
+
+// hclString quotes s as an HCL string literal. Template sequences are
+// escaped so values are taken literally.
+func hclString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	quoted := strings.TrimSuffix(buf.String(), "\n")
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
@@ -0,0 +1,101 @@
+package deployments
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestExportTerraform(t *testing.T) {
+	client := newMockClient(t)
+	minWorkers, maxWorkers := 1, 3
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-model",
+		Name:                 "My ${model}",
+		ImageURL:             "registry.example/my-model:1",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate", RequiresGPU: true}, {Name: "health"}},
+		SupportedModelIDs:    []string{"sdxl", "flux"},
+		RunpodSecretMapping:  map[string]string{"HF_TOKEN": "hf-secret", "API.KEY": "api-secret"},
+		MinWorkers:           &minWorkers,
+		MaxWorkers:           &maxWorkers,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := export(&out, client, ExportOptions{DeploymentIDs: []string{"my-model"}, Format: FormatTerraform}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Exported by cozyctl from deployment my-model
+
+import {
+  to = cozy_deployment.my_model
+  id = "my-model"
+}
+
+resource "cozy_deployment" "my_model" {
+  deployment_id = "my-model"
+  name          = "My $${model}"
+  image_url     = "registry.example/my-model:1"
+  min_workers   = 1
+  max_workers   = 3
+
+  supported_model_ids = [
+    "sdxl",
+    "flux",
+  ]
+
+  secret_mapping = {
+    "API.KEY" = "api-secret"
+    HF_TOKEN  = "hf-secret"
+  }
+
+  function {
+    name         = "generate"
+    requires_gpu = true
+  }
+
+  function {
+    name         = "health"
+    requires_gpu = false
+  }
+}
+`
+	if out.String() != want {
+		t.Errorf("export output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestExportAllPagesAndMissing(t *testing.T) {
+	// One deployment per page, so --all has to follow the cursor
+	server := mockserver.New()
+	server.PageSize = 1
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	client := api.NewClient(ts.URL, "token", nil)
+	for _, id := range []string{"a-model", "2nd-model"} {
+		if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: id, ImageURL: "img"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := export(&out, client, ExportOptions{All: true, Format: FormatTerraform}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`resource "cozy_deployment" "a_model"`, `resource "cozy_deployment" "deployment_2nd_model"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %s:\n%s", want, out.String())
+		}
+	}
+
+	err := export(&out, client, ExportOptions{DeploymentIDs: []string{"missing"}, Format: FormatTerraform})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("export missing = %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// GetOptions contains the options for getting a deployment.
+type GetOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Output       ui.Output
+}
+
+// Get prints a deployment as a single row of the list table.
+func Get(opts GetOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return get(os.Stdout, client, opts.DeploymentID, opts.Output, time.Now())
+}
+
+func get(w io.Writer, client api.OrchestratorAPI, id string, output ui.Output, now time.Time) error {
+	deployment, err := client.GetDeployment(id)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	if deployment == nil {
+		return fmt.Errorf("deployment '%s' not found", id)
+	}
+
+	if output.Structured() {
+		return ui.WriteStructured(w, output, deployment)
+	}
+	return deploymentTable([]api.DeploymentResponse{*deployment}, output == ui.OutputWide, now).Write(w)
+}
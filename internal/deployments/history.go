@@ -0,0 +1,98 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// revisionPageSize is how many revisions are fetched per request; tests
+// lower it.
+var revisionPageSize = 100
+
+// HistoryOptions contains the options for listing a deployment's revisions.
+type HistoryOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Limit        int // Newest revisions to list; all if zero
+	Output       ui.Output
+}
+
+// History lists every build that has been active on a deployment, newest
+// first.
+func History(opts HistoryOptions) error {
+	builder, _, err := newHubClients(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return revisionHistory(os.Stdout, builder, opts, time.Now())
+}
+
+func revisionHistory(w io.Writer, client api.BuilderAPI, opts HistoryOptions, now time.Time) error {
+	revisions, err := listRevisions(client, opts.DeploymentID, opts.Limit)
+	if err != nil {
+		return fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, revisions)
+	}
+
+	if len(revisions) == 0 {
+		fmt.Fprintf(w, "%s has not been deployed yet.\n", opts.DeploymentID)
+		return nil
+	}
+
+	wide := opts.Output == ui.OutputWide
+	table := &ui.Table{Columns: []string{"REVISION", "BUILD", "IMAGE", "STATUS", "CAUSE", "DEPLOYED BY", "DEPLOYED"}}
+	for _, r := range revisions {
+		cause := r.Cause
+		if r.ApprovalID != "" {
+			cause += " (" + r.ApprovalID + ")"
+		}
+		deployedAt, _ := time.Parse(time.RFC3339, r.DeployedAt)
+		number := fmt.Sprint(r.Number)
+		table.Rows = append(table.Rows, ui.Row{Key: number, Cells: []string{
+			number,
+			r.BuildID,
+			r.ImageTag,
+			r.Status,
+			cause,
+			orDash(r.DeployedBy),
+			formatTime(deployedAt, wide, now),
+		}})
+	}
+	return table.Write(w)
+}
+
+// listRevisions fetches a deployment's revisions page by page, newest first,
+// stopping after limit of them unless limit is zero.
+func listRevisions(client api.BuilderAPI, deploymentID string, limit int) ([]api.Revision, error) {
+	revisions := []api.Revision{}
+	query := api.ListRevisionsOptions{Limit: revisionPageSize}
+	for {
+		if limit > 0 {
+			query.Limit = min(revisionPageSize, limit-len(revisions))
+		}
+		page, err := client.ListRevisions(deploymentID, query)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, page.Revisions...)
+		if page.NextCursor == "" || len(page.Revisions) == 0 || (limit > 0 && len(revisions) >= limit) {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if limit > 0 && len(revisions) > limit {
+		revisions = revisions[:limit]
+	}
+	return revisions, nil
+}
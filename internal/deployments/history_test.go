@@ -0,0 +1,69 @@
+package deployments
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestHistoryPagesThroughRevisions(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	builder := api.NewBuilderClient(ts.URL, "token", nil)
+
+	var builds []string
+	for range 5 {
+		upload, err := builder.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := builder.DeployBuild(upload.BuildID, &api.DeployBuildRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		builds = append(builds, upload.BuildID)
+	}
+	if _, err := builder.RollbackDeployment("my-model", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	revisionPageSize = 2
+	t.Cleanup(func() { revisionPageSize = 100 })
+
+	var out bytes.Buffer
+	if err := revisionHistory(&out, builder, HistoryOptions{DeploymentID: "my-model", Output: ui.OutputJSON}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	var revisions []api.Revision
+	if err := json.Unmarshal(out.Bytes(), &revisions); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(revisions) != 6 {
+		t.Fatalf("got %d revisions, want 6", len(revisions))
+	}
+	newest, rolledBack := revisions[0], revisions[1]
+	if newest.Number != 6 || newest.BuildID != builds[3] || newest.Cause != api.RevisionCauseRollback || newest.Status != api.RevisionActive {
+		t.Errorf("newest revision = %+v, want the rollback to %s", newest, builds[3])
+	}
+	if rolledBack.BuildID != builds[4] || rolledBack.Status != api.RevisionRolledBack || rolledBack.ReplacedAt == "" {
+		t.Errorf("revision 5 = %+v, want it rolled back", rolledBack)
+	}
+	if revisions[5].Number != 1 || revisions[5].Status != api.RevisionSuperseded {
+		t.Errorf("oldest revision = %+v, want revision 1 superseded", revisions[5])
+	}
+
+	out.Reset()
+	if err := revisionHistory(&out, builder, HistoryOptions{DeploymentID: "my-model", Limit: 3}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "6 ") || !strings.Contains(lines[1], "rollback") {
+		t.Errorf("unexpected table:\n%s", out.String())
+	}
+}
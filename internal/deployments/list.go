@@ -0,0 +1,113 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ListOptions contains the options for listing deployments.
+type ListOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Query     api.ListDeploymentsOptions
+	Output    ui.Output
+	Watch     time.Duration // Refresh interval; zero lists once
+}
+
+// List prints one page of deployments, or keeps it refreshed with opts.Watch
+// until ctx is cancelled.
+func List(ctx context.Context, opts ListOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	if opts.Watch > 0 {
+		return watch(ctx, os.Stdout, client, opts)
+	}
+	return list(os.Stdout, client, opts)
+}
+
+func watch(ctx context.Context, w io.Writer, client api.OrchestratorAPI, opts ListOptions) error {
+	if opts.Output.Structured() {
+		return fmt.Errorf("--watch cannot be combined with --output %s", opts.Output)
+	}
+	return ui.Watch(ctx, w, opts.Watch, func() (*ui.Table, error) {
+		page, err := client.ListDeploymentsPage(opts.Query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		return deploymentTable(page.Items, opts.Output == ui.OutputWide, time.Now()), nil
+	})
+}
+
+func list(w io.Writer, client api.OrchestratorAPI, opts ListOptions) error {
+	page, err := client.ListDeploymentsPage(opts.Query)
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, page)
+	}
+	return renderList(w, page, opts.Output == ui.OutputWide, time.Now())
+}
+
+// renderList writes a page of deployments as a table, followed by a hint for
+// fetching the next page.
+func renderList(w io.Writer, page *api.ListDeploymentsResponse, wide bool, now time.Time) error {
+	if len(page.Items) == 0 {
+		fmt.Fprintln(w, "No deployments found.")
+		return nil
+	}
+
+	if err := deploymentTable(page.Items, wide, now).Write(w); err != nil {
+		return err
+	}
+
+	if page.Total > len(page.Items) {
+		fmt.Fprintf(w, "\nShowing %d of %d deployments.", len(page.Items), page.Total)
+		if page.NextCursor != "" {
+			fmt.Fprintf(w, " Next page: --cursor %s", page.NextCursor)
+		}
+		fmt.Fprintln(w)
+	} else if page.NextCursor != "" {
+		fmt.Fprintf(w, "\nMore deployments available. Next page: --cursor %s\n", page.NextCursor)
+	}
+
+	return nil
+}
+
+// deploymentTable lays out deployments as table rows keyed by ID.
+func deploymentTable(items []api.DeploymentResponse, wide bool, now time.Time) *ui.Table {
+	table := &ui.Table{Columns: []string{"ID", "STATUS", "WORKERS", "FUNCTIONS", "UPDATED"}}
+	if wide {
+		table.Columns = append(table.Columns, "IMAGE")
+	}
+
+	for _, d := range items {
+		status := d.Status
+		if status == "" {
+			status = "unknown"
+		}
+		cells := []string{
+			d.ID,
+			status,
+			fmt.Sprintf("%d/%d-%d", d.ReadyWorkers, d.MinWorkers, d.MaxWorkers),
+			fmt.Sprint(len(d.FunctionRequirements)),
+			formatTime(d.UpdatedAt, false, now),
+		}
+		if wide {
+			cells = append(cells, d.ImageURL)
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: d.ID, Status: status, Cells: cells})
+	}
+	return table
+}
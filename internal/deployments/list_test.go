@@ -0,0 +1,58 @@
+package deployments
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestListPagesAndFilters(t *testing.T) {
+	client := newMockClient(t)
+	for _, id := range []string{"sdxl-a", "sdxl-b", "sdxl-c", "flux"} {
+		if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: id, ImageURL: "img"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	opts := ListOptions{Query: api.ListDeploymentsOptions{Limit: 2, NameFilter: "sdxl", Sort: "-name"}}
+	if err := list(&out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "sdxl-c") || !strings.Contains(got, "sdxl-b") || strings.Contains(got, "sdxl-a") || strings.Contains(got, "flux") {
+		t.Errorf("unexpected first page:\n%s", got)
+	}
+	if !strings.Contains(got, "Showing 2 of 3 deployments. Next page: --cursor offset-2") {
+		t.Errorf("missing next page hint:\n%s", got)
+	}
+
+	out.Reset()
+	opts.Query.Cursor = "offset-2"
+	if err := list(&out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "sdxl-a") || strings.Contains(got, "--cursor") {
+		t.Errorf("unexpected last page:\n%s", got)
+	}
+
+	out.Reset()
+	opts.Query = api.ListDeploymentsOptions{Page: 2, Limit: 3}
+	opts.Output = ui.OutputJSON
+	if err := list(&out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, `"id": "sdxl-c"`) || !strings.Contains(got, `"total": 4`) {
+		t.Errorf("unexpected JSON page:\n%s", got)
+	}
+}
+
+func TestListRejectsUnknownSort(t *testing.T) {
+	err := list(&bytes.Buffer{}, newMockClient(t), ListOptions{Query: api.ListDeploymentsOptions{Sort: "size"}})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("expected a 400 error, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+)
+
+// LockOptions contains the options for locking or unlocking a deployment.
+type LockOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Reason       string // Required to lock; recorded with an unlock if given
+}
+
+// Lock protects a deployment on the orchestrator: updates, scaling, and
+// deletes are refused until it is unlocked, unless they give an unlock
+// reason, which is recorded in the deployment's events.
+func Lock(opts LockOptions) (err error) {
+	if opts.Reason == "" {
+		return fmt.Errorf("--reason is required: say why the deployment is locked, e.g. --reason \"prod freeze\"")
+	}
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	ids := map[string]string{"deployment_id": opts.DeploymentID}
+	recorder := history.Start(opts.Profile, "deployments lock")
+	defer func() { recorder.Finish(ids, err) }()
+
+	return lock(os.Stdout, client, opts)
+}
+
+func lock(w io.Writer, client api.OrchestratorAPI, opts LockOptions) error {
+	d, err := client.LockDeployment(opts.DeploymentID, opts.Reason)
+	if err != nil {
+		return fmt.Errorf("failed to lock deployment: %w", err)
+	}
+	fmt.Fprintf(w, "Locked %s: %s\n", d.ID, opts.Reason)
+	fmt.Fprintln(w, "Updates, scaling, and deletes now need --unlock-reason.")
+	return nil
+}
+
+// Unlock removes a deployment's protection.
+func Unlock(opts LockOptions) (err error) {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	ids := map[string]string{"deployment_id": opts.DeploymentID}
+	recorder := history.Start(opts.Profile, "deployments unlock")
+	defer func() { recorder.Finish(ids, err) }()
+
+	return unlock(os.Stdout, client, opts)
+}
+
+func unlock(w io.Writer, client api.OrchestratorAPI, opts LockOptions) error {
+	d, err := client.GetDeployment(opts.DeploymentID)
+	if err != nil {
+		return err
+	}
+	if d.Lock == nil {
+		fmt.Fprintf(w, "%s is not locked.\n", d.ID)
+		return nil
+	}
+	if _, err := client.UnlockDeployment(opts.DeploymentID, opts.Reason); err != nil {
+		return fmt.Errorf("failed to unlock deployment: %w", err)
+	}
+	fmt.Fprintf(w, "Unlocked %s (was locked: %s)\n", d.ID, d.Lock.Reason)
+	return nil
+}
@@ -0,0 +1,69 @@
+package deployments
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestLockRefusesChangesWithoutReason(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewClient(ts.URL, "token", nil)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-model", ImageURL: "registry.example/my-model:1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := lock(&out, client, LockOptions{DeploymentID: "my-model", Reason: "prod freeze"}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := client.GetDeployment("my-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Lock == nil || d.Lock.Reason != "prod freeze" {
+		t.Fatalf("lock = %+v, want reason 'prod freeze'", d.Lock)
+	}
+
+	if _, err := client.ScaleToZero("my-model"); !errors.Is(err, api.ErrDeploymentLocked) {
+		t.Errorf("scale of a locked deployment: got %v, want ErrDeploymentLocked", err)
+	}
+	if err := client.DeleteDeployment("my-model"); !errors.Is(err, api.ErrDeploymentLocked) {
+		t.Errorf("delete of a locked deployment: got %v, want ErrDeploymentLocked", err)
+	}
+
+	// With --unlock-reason the change goes through and is recorded
+	unlocking := api.NewClient(ts.URL, "token", &api.UnlockTransport{Base: http.DefaultTransport, Reason: "hotfix for INC-142"})
+	if _, err := unlocking.ScaleToZero("my-model"); err != nil {
+		t.Fatal(err)
+	}
+	events, err := client.ListDeploymentEvents("my-model", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, e := range events {
+		found = found || (e.Type == api.EventLockOverridden && e.Reason == "hotfix for INC-142")
+	}
+	if !found {
+		t.Errorf("override not in the events: %+v", events)
+	}
+
+	out.Reset()
+	if err := unlock(&out, client, LockOptions{DeploymentID: "my-model", Reason: "freeze over"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Unlocked my-model (was locked: prod freeze)") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+	if err := client.DeleteDeployment("my-model"); err != nil {
+		t.Errorf("delete after unlock: %v", err)
+	}
+}
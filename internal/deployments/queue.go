@@ -0,0 +1,167 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+const (
+	// DefaultStuckAfter is how long an invocation can run before its worker
+	// is reported as possibly stuck.
+	DefaultStuckAfter = 10 * time.Minute
+
+	// waitingAfter is how long invocations can wait before idle workers
+	// are reported as not picking them up.
+	waitingAfter = time.Minute
+)
+
+// QueueOptions contains the options for showing a deployment's queue.
+type QueueOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	StuckAfter   time.Duration // Flag invocations running longer than this
+	Output       ui.Output
+}
+
+// queueReport is the structured output of Queue.
+type queueReport struct {
+	*api.DeploymentQueue
+	Warnings []string `json:"warnings"`
+}
+
+// Queue prints the pending and in-flight invocations of each of a
+// deployment's functions, with hints on whether to scale up or look for a
+// stuck worker.
+func Queue(opts QueueOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return queue(os.Stdout, client, opts, time.Now())
+}
+
+func queue(w io.Writer, client api.OrchestratorAPI, opts QueueOptions, now time.Time) error {
+	q, err := client.GetDeploymentQueue(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get queue: %w", err)
+	}
+
+	warnings := queueWarnings(q, opts, now)
+
+	if opts.Output.Structured() {
+		if q.Functions == nil {
+			q.Functions = []api.FunctionQueue{}
+		}
+		if warnings == nil {
+			warnings = []string{}
+		}
+		return ui.WriteStructured(w, opts.Output, queueReport{DeploymentQueue: q, Warnings: warnings})
+	}
+
+	pending, inFlight := 0, 0
+	for _, f := range q.Functions {
+		pending += f.Pending
+		inFlight += f.InFlight
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Deployment:\t%s\n", q.DeploymentID)
+	fmt.Fprintf(tw, "Workers:\t%d ready, %d busy (max %d)\n", q.ReadyWorkers, q.BusyWorkers, q.MaxWorkers)
+	fmt.Fprintf(tw, "Invocations:\t%d pending, %d in flight\n", pending, inFlight)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(q.Functions) > 0 {
+		fmt.Fprintln(w)
+		table := &ui.Table{Columns: []string{"FUNCTION", "PENDING", "IN FLIGHT", "OLDEST PENDING", "LONGEST RUNNING"}}
+		for _, f := range q.Functions {
+			table.Rows = append(table.Rows, ui.Row{Key: f.Function, Cells: []string{
+				f.Function,
+				fmt.Sprint(f.Pending),
+				fmt.Sprint(f.InFlight),
+				queueAge(f.OldestPendingAt, now),
+				queueAge(f.OldestInFlightAt, now),
+			}})
+		}
+		if err := table.Write(w); err != nil {
+			return err
+		}
+	}
+
+	if len(warnings) > 0 {
+		fmt.Fprintln(w)
+		for _, warning := range warnings {
+			fmt.Fprintf(w, "Warning: %s\n", warning)
+		}
+	}
+	return nil
+}
+
+// queueWarnings points out a backlog that needs more workers, invocations
+// idle workers aren't picking up, and invocations running for suspiciously long.
+func queueWarnings(q *api.DeploymentQueue, opts QueueOptions, now time.Time) []string {
+	stuckAfter := opts.StuckAfter
+	if stuckAfter <= 0 {
+		stuckAfter = DefaultStuckAfter
+	}
+
+	var warnings []string
+	pending := 0
+	var oldestPending time.Duration
+	for _, f := range q.Functions {
+		pending += f.Pending
+		if f.Pending > 0 && f.OldestPendingAt != nil {
+			oldestPending = max(oldestPending, now.Sub(*f.OldestPendingAt))
+		}
+	}
+
+	if pending > 0 {
+		waiting := fmt.Sprintf("%d %s waiting", pending, plural(pending, "invocation is", "invocations are"))
+		if oldestPending > 0 {
+			waiting += fmt.Sprintf(" (oldest %s)", formatAge(oldestPending))
+		}
+		switch {
+		case q.ReadyWorkers > 0 && q.BusyWorkers >= q.ReadyWorkers && q.MaxWorkers > 0 && q.ReadyWorkers >= q.MaxWorkers:
+			warnings = append(warnings, fmt.Sprintf("%s with %d of %d workers busy at the maximum; raise it with 'cozyctl update --max-workers'", waiting, q.BusyWorkers, q.ReadyWorkers))
+		case q.ReadyWorkers > 0 && q.BusyWorkers >= q.ReadyWorkers:
+			warnings = append(warnings, fmt.Sprintf("%s with %d of %d workers busy; more workers should be starting (max %d)", waiting, q.BusyWorkers, q.ReadyWorkers, q.MaxWorkers))
+		case q.ReadyWorkers == 0:
+			warnings = append(warnings, fmt.Sprintf("%s and no workers are ready (see 'cozyctl status %s')", waiting, q.DeploymentID))
+		case oldestPending > waitingAfter:
+			warnings = append(warnings, fmt.Sprintf("%s while %d %s idle; workers may not be picking up work", waiting, q.ReadyWorkers-q.BusyWorkers, plural(q.ReadyWorkers-q.BusyWorkers, "worker is", "workers are")))
+		}
+	}
+
+	for _, f := range q.Functions {
+		if f.InFlight > 0 && f.OldestInFlightAt != nil {
+			if running := now.Sub(*f.OldestInFlightAt); running > stuckAfter {
+				warnings = append(warnings, fmt.Sprintf("%s: an invocation has been running for %s; its worker may be stuck (see 'cozyctl workers list %s')", f.Function, formatAge(running), q.DeploymentID))
+			}
+		}
+	}
+	return warnings
+}
+
+func queueAge(t *time.Time, now time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return formatAge(now.Sub(*t))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
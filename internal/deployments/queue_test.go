@@ -0,0 +1,136 @@
+package deployments
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// fakeQueue serves a fixed queue.
+type fakeQueue struct {
+	api.OrchestratorAPI
+	queue api.DeploymentQueue
+}
+
+func (f *fakeQueue) GetDeploymentQueue(string) (*api.DeploymentQueue, error) {
+	q := f.queue
+	return &q, nil
+}
+
+func TestQueueBacklog(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewClient(ts.URL, "token", nil)
+
+	workers := 1
+	_, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-model",
+		ImageURL:             "registry.example/my-model-backlog:1",
+		MinWorkers:           &workers,
+		MaxWorkers:           &workers,
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate"}, {Name: "upscale"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := queue(&out, client, QueueOptions{DeploymentID: "my-model"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"50 pending, 1 in flight",
+		"generate  25       1          5m",
+		"upscale   25       0          5m              -",
+		"with 1 of 1 workers busy at the maximum",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("queue output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := queue(&out, client, QueueOptions{DeploymentID: "my-model", Output: ui.OutputJSON}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	var report queueReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Functions) != 2 || report.Functions[0].InFlight != 1 || len(report.Warnings) != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if err := queue(&out, client, QueueOptions{DeploymentID: "missing"}, time.Now()); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestQueueWarnings(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+
+	tests := []struct {
+		name  string
+		queue api.DeploymentQueue
+		want  []string
+	}{
+		{
+			name:  "empty",
+			queue: api.DeploymentQueue{ReadyWorkers: 1, MaxWorkers: 4, Functions: []api.FunctionQueue{{Function: "generate"}}},
+		},
+		{
+			name: "scaling",
+			queue: api.DeploymentQueue{ReadyWorkers: 2, BusyWorkers: 2, MaxWorkers: 4, Functions: []api.FunctionQueue{
+				{Function: "generate", Pending: 3, InFlight: 2, OldestPendingAt: ago(20 * time.Second), OldestInFlightAt: ago(time.Minute)},
+			}},
+			want: []string{"3 invocations are waiting (oldest 20s) with 2 of 2 workers busy; more workers should be starting (max 4)"},
+		},
+		{
+			name: "no workers",
+			queue: api.DeploymentQueue{DeploymentID: "my-model", MaxWorkers: 4, Functions: []api.FunctionQueue{
+				{Function: "generate", Pending: 1, OldestPendingAt: ago(time.Minute)},
+			}},
+			want: []string{"1 invocation is waiting (oldest 1m) and no workers are ready (see 'cozyctl status my-model')"},
+		},
+		{
+			name: "stuck",
+			queue: api.DeploymentQueue{DeploymentID: "my-model", ReadyWorkers: 3, BusyWorkers: 1, MaxWorkers: 4, Functions: []api.FunctionQueue{
+				{Function: "generate", Pending: 4, InFlight: 1, OldestPendingAt: ago(5 * time.Minute), OldestInFlightAt: ago(25 * time.Minute)},
+			}},
+			want: []string{
+				"4 invocations are waiting (oldest 5m) while 2 workers are idle; workers may not be picking up work",
+				"generate: an invocation has been running for 25m; its worker may be stuck (see 'cozyctl workers list my-model')",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := queueWarnings(&tt.queue, QueueOptions{}, now)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("warnings:\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+
+	// A longer --stuck-after tolerates long-running invocations
+	var out bytes.Buffer
+	client := &fakeQueue{queue: tests[3].queue}
+	if err := queue(&out, client, QueueOptions{StuckAfter: time.Hour}, now); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "stuck") {
+		t.Errorf("expected no stuck warning with --stuck-after 1h:\n%s", out.String())
+	}
+}
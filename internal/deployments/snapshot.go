@@ -0,0 +1,260 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// SnapshotOptions contains the options for snapshotting a deployment.
+type SnapshotOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Description  string
+	Output       ui.Output
+}
+
+// Snapshot saves a copy of a deployment's current spec on the orchestrator,
+// so it can be restored after a bad update.
+func Snapshot(opts SnapshotOptions) (err error) {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	ids := map[string]string{"deployment_id": opts.DeploymentID}
+	recorder := history.Start(opts.Profile, "deployments snapshot")
+	defer func() { recorder.Finish(ids, err) }()
+
+	return snapshot(os.Stdout, client, opts, ids)
+}
+
+func snapshot(w io.Writer, client api.OrchestratorAPI, opts SnapshotOptions, ids map[string]string) error {
+	snap, err := client.CreateDeploymentSnapshot(opts.DeploymentID, &api.CreateDeploymentSnapshotRequest{
+		Description: opts.Description,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to snapshot deployment: %w", err)
+	}
+	ids["snapshot_id"] = snap.ID
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, snap)
+	}
+	fmt.Fprintf(w, "Saved snapshot %s of %s\n", snap.ID, snap.DeploymentID)
+	fmt.Fprintf(w, "Restore it with: cozyctl deployments restore %s --from %s\n", snap.DeploymentID, snap.ID)
+	return nil
+}
+
+// SnapshotsOptions contains the options for listing a deployment's snapshots.
+type SnapshotsOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Output       ui.Output
+}
+
+// Snapshots lists the snapshots saved for a deployment, newest first.
+func Snapshots(opts SnapshotsOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return snapshots(os.Stdout, client, opts, time.Now())
+}
+
+func snapshots(w io.Writer, client api.OrchestratorAPI, opts SnapshotsOptions, now time.Time) error {
+	items, err := client.ListDeploymentSnapshots(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		if items == nil {
+			items = []api.DeploymentSnapshot{}
+		}
+		return ui.WriteStructured(w, opts.Output, items)
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintf(w, "No snapshots of %s. Save one with 'cozyctl deployments snapshot %s'.\n", opts.DeploymentID, opts.DeploymentID)
+		return nil
+	}
+
+	wide := opts.Output == ui.OutputWide
+	table := &ui.Table{Columns: []string{"ID", "CREATED", "FUNCTIONS", "WORKERS", "DESCRIPTION"}}
+	for _, s := range items {
+		description := s.Description
+		if description == "" {
+			description = "-"
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: s.ID, Cells: []string{
+			s.ID,
+			formatTime(s.CreatedAt, wide, now),
+			fmt.Sprint(len(s.FunctionRequirements)),
+			fmt.Sprintf("%d-%d", s.MinWorkers, s.MaxWorkers),
+			description,
+		}})
+	}
+	return table.Write(w)
+}
+
+// RestoreOptions contains the options for restoring a deployment snapshot.
+type RestoreOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	SnapshotID   string
+	Yes          bool // Restore without prompting
+}
+
+// Restore replaces a deployment's spec with a snapshot of it, after showing
+// what will change. The image the deployment runs is left as it is.
+func Restore(opts RestoreOptions) (err error) {
+	if opts.SnapshotID == "" {
+		return fmt.Errorf("snapshot ID is required")
+	}
+
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	ids := map[string]string{"deployment_id": opts.DeploymentID, "snapshot_id": opts.SnapshotID}
+	recorder := history.Start(opts.Profile, "deployments restore")
+	defer func() { recorder.Finish(ids, err) }()
+
+	return restore(os.Stdin, os.Stdout, client, opts)
+}
+
+func restore(in io.Reader, out io.Writer, client api.OrchestratorAPI, opts RestoreOptions) error {
+	current, err := client.GetDeployment(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	items, err := client.ListDeploymentSnapshots(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	i := slices.IndexFunc(items, func(s api.DeploymentSnapshot) bool { return s.ID == opts.SnapshotID })
+	if i < 0 {
+		return fmt.Errorf("snapshot '%s' not found for deployment '%s' (see 'cozyctl deployments snapshots %s')",
+			opts.SnapshotID, opts.DeploymentID, opts.DeploymentID)
+	}
+
+	changes := snapshotChanges(current, &items[i])
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "%s already matches snapshot %s; nothing to restore.\n", opts.DeploymentID, opts.SnapshotID)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Restoring %s from snapshot %s will change:\n", opts.DeploymentID, opts.SnapshotID)
+	for _, c := range changes {
+		fmt.Fprintf(out, "  %s\n", c)
+	}
+
+	if !opts.Yes {
+		ok, err := ui.Confirm(in, out, fmt.Sprintf("Restore %s?", opts.DeploymentID))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Aborted.")
+			return nil
+		}
+	}
+
+	if _, err := client.RestoreDeploymentSnapshot(opts.DeploymentID, opts.SnapshotID); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	fmt.Fprintf(out, "Restored %s from snapshot %s\n", opts.DeploymentID, opts.SnapshotID)
+	return nil
+}
+
+// snapshotChanges lists the spec fields a restore would change, as
+// "field: current → snapshot" lines. Secrets are listed by variable name;
+// what they map to is never shown.
+func snapshotChanges(d *api.DeploymentResponse, s *api.DeploymentSnapshot) []string {
+	var changes []string
+	if d.Name != s.Name {
+		changes = append(changes, fmt.Sprintf("name: %s → %s", orDash(d.Name), orDash(s.Name)))
+	}
+
+	if !slices.Equal(d.FunctionRequirements, s.FunctionRequirements) {
+		changes = append(changes, fmt.Sprintf("functions: %s → %s",
+			joinOrDash(functionNames(d.FunctionRequirements)), joinOrDash(functionNames(s.FunctionRequirements))))
+	}
+
+	if !slices.Equal(d.SupportedModelIDs, s.SupportedModelIDs) {
+		changes = append(changes, fmt.Sprintf("models: %s → %s", joinOrDash(d.SupportedModelIDs), joinOrDash(s.SupportedModelIDs)))
+	}
+
+	currentSecrets, snapshotSecrets := sortedKeys(d.RunpodSecretMapping), sortedKeys(s.RunpodSecretMapping)
+	if !slices.Equal(currentSecrets, snapshotSecrets) {
+		changes = append(changes, fmt.Sprintf("secrets: %s → %s", joinOrDash(currentSecrets), joinOrDash(snapshotSecrets)))
+	} else {
+		for _, k := range currentSecrets {
+			if d.RunpodSecretMapping[k] != s.RunpodSecretMapping[k] {
+				changes = append(changes, fmt.Sprintf("secret %s: remapped", k))
+			}
+		}
+	}
+
+	if d.MinWorkers != s.MinWorkers {
+		changes = append(changes, fmt.Sprintf("min workers: %d → %d", d.MinWorkers, s.MinWorkers))
+	}
+	if d.MaxWorkers != s.MaxWorkers {
+		changes = append(changes, fmt.Sprintf("max workers: %d → %d", d.MaxWorkers, s.MaxWorkers))
+	}
+	return changes
+}
+
+// functionNames lists functions by name, marking those that need a GPU or
+// are disabled.
+func functionNames(fns []api.FunctionRequirement) []string {
+	names := make([]string, 0, len(fns))
+	for _, f := range fns {
+		name := f.Name
+		if f.RequiresGPU {
+			name += " (gpu)"
+		}
+		if f.Disabled {
+			name += " (disabled)"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func joinOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ", ")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
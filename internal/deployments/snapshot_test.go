@@ -0,0 +1,129 @@
+package deployments
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestSnapshotAndRestore(t *testing.T) {
+	client := newMockClient(t)
+	minWorkers, maxWorkers := 1, 4
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-model",
+		ImageURL:             "registry.example/my-model:1",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate", RequiresGPU: true}},
+		SupportedModelIDs:    []string{"sdxl"},
+		RunpodSecretMapping:  map[string]string{"HF_TOKEN": "hf-secret"},
+		MinWorkers:           &minWorkers,
+		MaxWorkers:           &maxWorkers,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]string{}
+	var out bytes.Buffer
+	if err := snapshot(&out, client, SnapshotOptions{DeploymentID: "my-model", Description: "known good"}, ids); err != nil {
+		t.Fatal(err)
+	}
+	snapshotID := ids["snapshot_id"]
+	if snapshotID == "" {
+		t.Fatal("snapshot ID not recorded")
+	}
+	if !strings.Contains(out.String(), "cozyctl deployments restore my-model --from "+snapshotID) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	// A botched update: new image, GPU dropped, scaled to zero
+	zero := 0
+	if _, err := client.UpdateDeployment("my-model", &api.UpdateDeploymentRequest{
+		ImageURL:             "registry.example/my-model:2",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate"}, {Name: "embed"}},
+		SupportedModelIDs:    []string{"sdxl", "flux"},
+		MaxWorkers:           &zero,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := snapshots(&out, client, SnapshotsOptions{DeploymentID: "my-model"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), snapshotID) || !strings.Contains(out.String(), "known good") {
+		t.Errorf("snapshot not listed:\n%s", out.String())
+	}
+
+	// Declining leaves the deployment alone
+	out.Reset()
+	opts := RestoreOptions{DeploymentID: "my-model", SnapshotID: snapshotID}
+	if err := restore(strings.NewReader("n\n"), &out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"functions: generate, embed → generate (gpu)",
+		"models: sdxl, flux → sdxl",
+		"max workers: 0 → 4",
+		"Aborted.",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "hf-secret") {
+		t.Errorf("secret mapping shown:\n%s", out.String())
+	}
+
+	out.Reset()
+	opts.Yes = true
+	if err := restore(strings.NewReader(""), &out, client, opts); err != nil {
+		t.Fatalf("restore: %v\n%s", err, out.String())
+	}
+	d, err := client.GetDeployment("my-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.FunctionRequirements) != 1 || !d.FunctionRequirements[0].RequiresGPU || len(d.SupportedModelIDs) != 1 || d.MaxWorkers != 4 {
+		t.Errorf("spec not restored: %+v", d)
+	}
+	if d.ImageURL != "registry.example/my-model:2" {
+		t.Errorf("image = %q, want it left unchanged", d.ImageURL)
+	}
+
+	// Restoring again is a no-op
+	out.Reset()
+	if err := restore(strings.NewReader(""), &out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "nothing to restore") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestRestoreUnknownSnapshot(t *testing.T) {
+	client := newMockClient(t)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-model", ImageURL: "img"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := restore(strings.NewReader(""), &out, client, RestoreOptions{DeploymentID: "my-model", SnapshotID: "snapshot-99", Yes: true})
+	if err == nil || !strings.Contains(err.Error(), "snapshot 'snapshot-99' not found") {
+		t.Errorf("err = %v, want snapshot not found", err)
+	}
+
+	if _, err := client.RestoreDeploymentSnapshot("my-model", "snapshot-99"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("err = %v, want snapshot not found", err)
+	}
+
+	out.Reset()
+	if err := snapshots(&out, client, SnapshotsOptions{DeploymentID: "my-model", Output: ui.OutputJSON}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("expected an empty JSON list, got %s", out.String())
+	}
+}
@@ -0,0 +1,65 @@
+package deployments
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// functionStats fetches a deployment's per-function invocation counters,
+// or nil if the orchestrator doesn't keep them.
+func functionStats(client api.OrchestratorAPI, id string) (*api.DeploymentStats, error) {
+	stats, err := client.GetDeploymentStats(id)
+	var unsupported *api.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return nil, nil
+	}
+	return stats, err
+}
+
+// statsByFunction indexes stats by function name.
+func statsByFunction(stats *api.DeploymentStats) map[string]api.FunctionStats {
+	byName := map[string]api.FunctionStats{}
+	if stats != nil {
+		for _, fs := range stats.Functions {
+			byName[fs.Function] = fs
+		}
+	}
+	return byName
+}
+
+// statsCells are the INVOCATIONS, ERRORS, AVG LATENCY, and LAST INVOKED
+// cells of a function.
+func statsCells(fs api.FunctionStats, wide bool, now time.Time) []string {
+	if fs.Invocations == 0 {
+		return []string{"0", "0", "-", "never"}
+	}
+	last := "-"
+	if fs.LastInvokedAt != nil {
+		last = formatTime(*fs.LastInvokedAt, wide, now)
+	}
+	return []string{fmt.Sprint(fs.Invocations), fmt.Sprint(fs.Errors), formatLatency(fs.AvgLatencyMs), last}
+}
+
+// formatLatency shows milliseconds, switching to seconds from one second.
+func formatLatency(ms float64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%.0fms", ms)
+	}
+	return fmt.Sprintf("%.1fs", ms/1000)
+}
+
+// uninvoked lists the registered functions that have no invocations in
+// stats: candidates for removal.
+func uninvoked(d *api.DeploymentResponse, stats *api.DeploymentStats) []string {
+	byName := statsByFunction(stats)
+	var names []string
+	for _, f := range d.FunctionRequirements {
+		if byName[f.Name].Invocations == 0 {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,151 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// StatusOptions contains the options for showing a deployment's status.
+type StatusOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Events       int // How many recent events to show
+	Output       ui.Output
+}
+
+// statusReport is the structured output of Status.
+type statusReport struct {
+	Deployment    *api.DeploymentResponse `json:"deployment"`
+	FunctionStats *api.DeploymentStats    `json:"function_stats,omitempty"`
+	Events        []api.DeploymentEvent   `json:"events"`
+}
+
+// eventLabels are the short names shown for each event type.
+var eventLabels = map[string]string{
+	api.EventScaledUp:       "scaled up",
+	api.EventScaledDown:     "scaled down",
+	api.EventImageUpdated:   "image switched",
+	api.EventWorkerCrashed:  "worker crashed",
+	api.EventWorkerOOM:      "out of memory",
+	api.EventLocked:         "locked",
+	api.EventUnlocked:       "unlocked",
+	api.EventLockOverridden: "changed while locked",
+}
+
+// Status prints a deployment's current state and the invocation counters of
+// its functions, followed by its recent events, so a failed rollout can be
+// diagnosed from the timeline of scale changes, image switches, and worker
+// crashes.
+func Status(opts StatusOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return status(os.Stdout, client, opts, time.Now())
+}
+
+func status(w io.Writer, client api.OrchestratorAPI, opts StatusOptions, now time.Time) error {
+	deployment, err := client.GetDeployment(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	if deployment == nil {
+		return fmt.Errorf("deployment '%s' not found", opts.DeploymentID)
+	}
+
+	events, err := client.ListDeploymentEvents(opts.DeploymentID, opts.Events)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment events: %w", err)
+	}
+	stats, statsErr := functionStats(client, opts.DeploymentID)
+
+	if opts.Output.Structured() {
+		if events == nil {
+			events = []api.DeploymentEvent{}
+		}
+		return ui.WriteStructured(w, opts.Output, statusReport{Deployment: deployment, FunctionStats: stats, Events: events})
+	}
+
+	state := deployment.Status
+	if state == "" {
+		state = "unknown"
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Deployment:\t%s\n", deployment.ID)
+	fmt.Fprintf(tw, "Status:\t%s\n", state)
+	if deployment.Lock != nil {
+		fmt.Fprintf(tw, "Locked:\t%s\n", deployment.Lock.Reason)
+	}
+	fmt.Fprintf(tw, "Image:\t%s\n", deployment.ImageURL)
+	fmt.Fprintf(tw, "Workers:\t%d ready (min %d, max %d)\n", deployment.ReadyWorkers, deployment.MinWorkers, deployment.MaxWorkers)
+	fmt.Fprintf(tw, "Updated:\t%s\n", formatTime(deployment.UpdatedAt, false, now))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	switch {
+	case statsErr != nil:
+		fmt.Fprintf(w, "\nFunction stats unavailable: %v\n", statsErr)
+	case stats != nil && len(stats.Functions) > 0:
+		fmt.Fprintf(w, "\nFunctions (last %s):\n", formatAge(now.Sub(stats.Since)))
+		table := &ui.Table{Columns: []string{"FUNCTION", "INVOCATIONS", "ERRORS", "AVG LATENCY", "LAST INVOKED"}}
+		for _, fs := range stats.Functions {
+			table.Rows = append(table.Rows, ui.Row{Key: fs.Function, Cells: append([]string{fs.Function}, statsCells(fs, false, now)...)})
+		}
+		if err := table.Write(w); err != nil {
+			return err
+		}
+	}
+
+	if len(events) == 0 {
+		fmt.Fprintln(w, "\nRecent events: none")
+		return nil
+	}
+	fmt.Fprintln(w, "\nRecent events:")
+	return eventsTable(events, now).Write(w)
+}
+
+// eventsTable lists events newest first, as the orchestrator returns them.
+func eventsTable(events []api.DeploymentEvent, now time.Time) *ui.Table {
+	table := &ui.Table{Columns: []string{"AGE", "EVENT", "WORKER", "DETAILS"}}
+	for _, e := range events {
+		label, ok := eventLabels[e.Type]
+		if !ok {
+			label = e.Type
+		}
+		worker := e.WorkerID
+		if worker == "" {
+			worker = "-"
+		}
+		table.Rows = append(table.Rows, ui.Row{Cells: []string{
+			formatTime(e.Timestamp, false, now),
+			label,
+			worker,
+			eventDetails(e),
+		}})
+	}
+	return table
+}
+
+// eventDetails is the event's message, plus how the worker exited if it did.
+func eventDetails(e api.DeploymentEvent) string {
+	details := e.Message
+	switch {
+	case e.ExitCode != nil && e.Reason != "":
+		details += fmt.Sprintf(" (exit code %d, %s)", *e.ExitCode, e.Reason)
+	case e.ExitCode != nil:
+		details += fmt.Sprintf(" (exit code %d)", *e.ExitCode)
+	case e.Reason != "":
+		details += fmt.Sprintf(" (%s)", e.Reason)
+	}
+	return details
+}
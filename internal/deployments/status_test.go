@@ -0,0 +1,101 @@
+package deployments
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestStatusEvents(t *testing.T) {
+	server := mockserver.New()
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewClient(ts.URL, "token", nil)
+
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-model", ImageURL: "registry.example/my-model:1"}); err != nil {
+		t.Fatal(err)
+	}
+	minWorkers := 3
+	if _, err := client.UpdateDeployment("my-model", &api.UpdateDeploymentRequest{ImageURL: "registry.example/my-model:2", MinWorkers: &minWorkers}); err != nil {
+		t.Fatal(err)
+	}
+	exitCode := 137
+	server.RecordEvent("my-model", api.DeploymentEvent{
+		Type: api.EventWorkerOOM, WorkerID: "my-model-worker-2", Message: "Worker ran out of GPU memory",
+		ExitCode: &exitCode, Reason: "OOMKilled",
+	})
+
+	var out bytes.Buffer
+	if err := status(&out, client, StatusOptions{DeploymentID: "my-model", Output: ui.OutputDefault}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"registry.example/my-model:2",
+		"Switched image from registry.example/my-model:1 to registry.example/my-model:2",
+		"Scaled up from 1 to 3 workers",
+		"out of memory",
+		"my-model-worker-2",
+		"(exit code 137, OOMKilled)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("status output missing %q:\n%s", want, out.String())
+		}
+	}
+	// Newest first
+	if strings.Index(out.String(), "OOMKilled") > strings.Index(out.String(), "Scaled up from 1") {
+		t.Errorf("events not newest first:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := status(&out, client, StatusOptions{DeploymentID: "my-model", Events: 1, Output: ui.OutputJSON}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	var report statusReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Events) != 1 || report.Events[0].Type != api.EventWorkerOOM {
+		t.Errorf("got events %+v, want only the OOM event", report.Events)
+	}
+}
+
+func TestStatusNotFound(t *testing.T) {
+	client := newMockClient(t)
+
+	err := status(&bytes.Buffer{}, client, StatusOptions{DeploymentID: "missing"}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("got %v, want not found", err)
+	}
+}
+
+func TestStatusFunctionStats(t *testing.T) {
+	client := newMockClient(t)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-model",
+		ImageURL:             "registry.example/my-model:1",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate"}, {Name: "health"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Invoke("my-model", "generate", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := status(&out, client, StatusOptions{DeploymentID: "my-model", Output: ui.OutputDefault}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Functions (last") || !strings.Contains(out.String(), "never") {
+		t.Errorf("status output missing function stats:\n%s", out.String())
+	}
+	if strings.Index(out.String(), "INVOCATIONS") > strings.Index(out.String(), "Recent events") {
+		t.Errorf("function stats should come before the events:\n%s", out.String())
+	}
+}
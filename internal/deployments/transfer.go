@@ -0,0 +1,86 @@
+package deployments
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// TransferOptions contains the options for moving a deployment to another tenant.
+type TransferOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	ToTenantID   string
+	Yes          bool // Confirm without prompting
+}
+
+// Transfer moves a deployment, with its build history and endpoint
+// configuration, to another tenant. The orchestrator opens a pending transfer
+// that is only carried out once confirmed; declining withdraws it.
+func Transfer(opts TransferOptions) (err error) {
+	if opts.ToTenantID == "" {
+		return fmt.Errorf("target tenant is required")
+	}
+
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	ids := map[string]string{"deployment_id": opts.DeploymentID, "to_tenant_id": opts.ToTenantID}
+	recorder := history.Start(opts.Profile, "deployments transfer")
+	defer func() { recorder.Finish(ids, err) }()
+
+	return transfer(os.Stdin, os.Stdout, client, opts, ids)
+}
+
+// transfer requests the transfer, confirms it with the user unless opts.Yes,
+// and then confirms or withdraws it on the server. The transfer ID is added to ids.
+func transfer(in io.Reader, out io.Writer, client api.OrchestratorAPI, opts TransferOptions, ids map[string]string) error {
+	t, err := client.RequestDeploymentTransfer(opts.DeploymentID, &api.TransferDeploymentRequest{
+		ToTenantID: opts.ToTenantID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to request transfer: %w", err)
+	}
+	ids["transfer_id"] = t.ID
+
+	fmt.Fprintf(out, "Transfer %s from tenant %s to tenant %s\n", t.DeploymentID, t.FromTenantID, t.ToTenantID)
+	fmt.Fprintf(out, "  Transfer ID: %s\n", t.ID)
+	fmt.Fprintf(out, "  Build records: %d\n", t.Builds)
+	fmt.Fprintf(out, "  Endpoints: %d\n", t.Endpoints)
+	if !t.ExpiresAt.IsZero() {
+		fmt.Fprintf(out, "  Expires: %s\n", t.ExpiresAt.Local().Format(time.RFC3339))
+	}
+
+	if !opts.Yes {
+		ok, err := ui.Confirm(in, out, fmt.Sprintf("Move %s to tenant %s? It will no longer be visible to this tenant.", t.DeploymentID, t.ToTenantID))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if _, err := client.CancelDeploymentTransfer(t.DeploymentID, t.ID); err != nil {
+				return fmt.Errorf("aborted, but failed to withdraw transfer %s: %w", t.ID, err)
+			}
+			fmt.Fprintf(out, "Aborted; transfer %s withdrawn.\n", t.ID)
+			return nil
+		}
+	}
+
+	done, err := client.ConfirmDeploymentTransfer(t.DeploymentID, t.ID, t.ConfirmationToken)
+	if err != nil {
+		return fmt.Errorf("failed to confirm transfer %s: %w", t.ID, err)
+	}
+
+	fmt.Fprintf(out, "Transferred %s to tenant %s (%d build record(s), %d endpoint(s))\n",
+		done.DeploymentID, done.ToTenantID, done.Builds, done.Endpoints)
+	return nil
+}
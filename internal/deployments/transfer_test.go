@@ -0,0 +1,84 @@
+package deployments
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func TestTransferConfirmed(t *testing.T) {
+	client := newMockClient(t)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-model",
+		ImageURL:             "registry.example/my-model:1",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate"}, {Name: "embed"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]string{}
+	var out bytes.Buffer
+	opts := TransferOptions{DeploymentID: "my-model", ToTenantID: "research", Yes: true}
+	if err := transfer(strings.NewReader(""), &out, client, opts, ids); err != nil {
+		t.Fatalf("transfer: %v\n%s", err, out.String())
+	}
+	if ids["transfer_id"] == "" {
+		t.Error("transfer ID not recorded")
+	}
+	if !strings.Contains(out.String(), "Transferred my-model to tenant research (0 build record(s), 2 endpoint(s))") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	d, err := client.GetDeployment("my-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.TenantID != "research" {
+		t.Errorf("tenant = %q, want research", d.TenantID)
+	}
+
+	// Moving it to the tenant that already owns it is rejected
+	if err := transfer(strings.NewReader(""), &out, client, opts, ids); err == nil {
+		t.Error("expected an error transferring to the current tenant")
+	}
+}
+
+func TestTransferDeclinedWithdraws(t *testing.T) {
+	client := newMockClient(t)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-model", ImageURL: "img"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]string{}
+	var out bytes.Buffer
+	opts := TransferOptions{DeploymentID: "my-model", ToTenantID: "research"}
+	if err := transfer(strings.NewReader("n\n"), &out, client, opts, ids); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "withdrawn") {
+		t.Errorf("expected the transfer to be withdrawn:\n%s", out.String())
+	}
+
+	d, err := client.GetDeployment("my-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.TenantID == "research" {
+		t.Error("declined transfer moved the deployment")
+	}
+
+	// The withdrawn transfer no longer blocks a new one
+	if err := transfer(strings.NewReader("y\n"), &out, client, opts, ids); err != nil {
+		t.Fatalf("second transfer: %v", err)
+	}
+}
+
+func TestTransferUnknownDeployment(t *testing.T) {
+	client := newMockClient(t)
+	err := transfer(strings.NewReader(""), &bytes.Buffer{}, client, TransferOptions{DeploymentID: "missing", ToTenantID: "research", Yes: true}, map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "deployment 'missing' not found") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultDockerHubURL is the Docker Hub API base images are looked up on.
+const DefaultDockerHubURL = "https://hub.docker.com"
+
+// maxTagPages bounds how many pages of tags are read for one repository.
+const maxTagPages = 10
+
+// imageTag is a tag of a Docker Hub repository.
+type imageTag struct {
+	Name        string `json:"name"`
+	Digest      string `json:"digest"`
+	LastUpdated string `json:"last_updated"`
+}
+
+// dockerHub lists repository tags with the Docker Hub API.
+type dockerHub struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func newDockerHub(baseURL string) *dockerHub {
+	if baseURL == "" {
+		baseURL = DefaultDockerHubURL
+	}
+	return &dockerHub{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// tags lists the tags of repo (e.g. "library/python") whose names contain filter.
+func (h *dockerHub) tags(ctx context.Context, repo, filter string) ([]imageTag, error) {
+	next := fmt.Sprintf("%s/v2/repositories/%s/tags?page_size=100&name=%s", h.baseURL, repo, url.QueryEscape(filter))
+
+	var tags []imageTag
+	for page := 0; next != "" && page < maxTagPages; page++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", next, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := h.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Docker Hub returned %d for %s", resp.StatusCode, repo)
+		}
+
+		var list struct {
+			Next    string     `json:"next"`
+			Results []imageTag `json:"results"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		tags = append(tags, list.Results...)
+		next = list.Next
+	}
+	return tags, nil
+}
@@ -0,0 +1,337 @@
+// Package deps checks how current a project's base image and dependencies are.
+package deps
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/pypi"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Update kinds, from most to least urgent.
+const (
+	UpdateSecurity = "security" // The current version has known advisories
+	UpdateMajor    = "major"
+	UpdateMinor    = "minor"
+	UpdatePatch    = "patch"
+	UpdateUnpinned = "unpinned" // No version is pinned, so a rebuild may pick up anything
+	UpdateUnknown  = "unknown"  // The index or registry couldn't be checked
+	UpdateNone     = "none"
+)
+
+var updatePriority = []string{UpdateSecurity, UpdateMajor, UpdateMinor, UpdatePatch, UpdateUnpinned, UpdateUnknown, UpdateNone}
+
+// Dependency kinds.
+const (
+	KindImage   = "image"
+	KindPackage = "package"
+)
+
+// lookupConcurrency bounds the concurrent requests to the package index.
+const lookupConcurrency = 8
+
+// OutdatedOptions contains the options for checking dependency freshness.
+type OutdatedOptions struct {
+	ProjectDir       string
+	IndexURL         string // PyPI JSON API (default pypi.DefaultIndexURL)
+	DockerHubURL     string // Default DefaultDockerHubURL
+	All              bool   // Also list dependencies that are up to date
+	FailOnAdvisories bool
+	Output           ui.Output
+}
+
+// Update is the freshness of one dependency.
+type Update struct {
+	Kind       string   `json:"kind"`
+	Name       string   `json:"name"`
+	Current    string   `json:"current,omitempty"`
+	Latest     string   `json:"latest,omitempty"`
+	Update     string   `json:"update"`
+	Advisories []string `json:"advisories,omitempty"`
+	Detail     string   `json:"detail,omitempty"`
+}
+
+// Outdated checks whether the project's base image and direct Python
+// dependencies have newer releases or security advisories, and prints them
+// most urgent first.
+func Outdated(ctx context.Context, opts OutdatedOptions) error {
+	return outdated(ctx, os.Stdout, pypi.NewClient(opts.IndexURL), newDockerHub(opts.DockerHubURL), opts)
+}
+
+func outdated(ctx context.Context, w io.Writer, index *pypi.Client, hub *dockerHub, opts OutdatedOptions) error {
+	cfg, err := build.GetToolsCozyConfig(filepath.Join(opts.ProjectDir, build.PyProjectTomlPath))
+	if err != nil {
+		return err
+	}
+	image, err := build.ResolveBaseImage(cfg)
+	if err != nil {
+		return err
+	}
+
+	packages, err := pinnedDependencies(ctx, opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	updates := []Update{checkBaseImage(ctx, hub, image)}
+	updates = append(updates, checkPackages(ctx, index, packages)...)
+	slices.SortStableFunc(updates, func(a, b Update) int {
+		return cmp.Or(
+			cmp.Compare(slices.Index(updatePriority, a.Update), slices.Index(updatePriority, b.Update)),
+			cmp.Compare(a.Kind, b.Kind),
+			strings.Compare(a.Name, b.Name),
+		)
+	})
+	if !opts.All {
+		updates = slices.DeleteFunc(updates, func(u Update) bool { return u.Update == UpdateNone })
+	}
+
+	if opts.Output.Structured() {
+		if err := ui.WriteStructured(w, opts.Output, updates); err != nil {
+			return err
+		}
+	} else {
+		writeUpdates(w, updates, opts.Output == ui.OutputWide)
+	}
+
+	if opts.FailOnAdvisories {
+		var vulnerable []string
+		for _, u := range updates {
+			if u.Update == UpdateSecurity {
+				vulnerable = append(vulnerable, u.Name)
+			}
+		}
+		if len(vulnerable) > 0 {
+			return fmt.Errorf("%d %s security advisories: %s", len(vulnerable), plural(len(vulnerable), "dependency has", "dependencies have"), strings.Join(vulnerable, ", "))
+		}
+	}
+	return nil
+}
+
+// pinnedDependencies returns the project's direct dependencies, with the
+// versions locked by its lock file or, failing that, pinned in pyproject.toml.
+func pinnedDependencies(ctx context.Context, dir string) ([]pypi.Package, error) {
+	direct, err := pypi.ProjectDependencies(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	locked := map[string]string{}
+	if res, err := pypi.Resolve(ctx, dir); err == nil {
+		for _, p := range res.Packages {
+			locked[p.Name] = p.Version
+		}
+	}
+	for i, p := range direct {
+		if v := locked[p.Name]; v != "" {
+			direct[i].Version = v
+		}
+	}
+	return direct, nil
+}
+
+func checkPackages(ctx context.Context, index *pypi.Client, packages []pypi.Package) []Update {
+	updates := make([]Update, len(packages))
+	sem := make(chan struct{}, lookupConcurrency)
+	var wg sync.WaitGroup
+	for i, p := range packages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			updates[i] = checkPackage(ctx, index, p)
+		}()
+	}
+	wg.Wait()
+	return updates
+}
+
+func checkPackage(ctx context.Context, index *pypi.Client, p pypi.Package) Update {
+	u := Update{Kind: KindPackage, Name: p.Name, Current: p.Version, Update: UpdateUnknown}
+
+	latest, err := index.Release(ctx, p.Name, "")
+	switch {
+	case err != nil:
+		u.Detail = err.Error()
+		return u
+	case latest == nil:
+		u.Detail = "not found on the package index"
+		return u
+	}
+	u.Latest = latest.Info.Version
+
+	if p.Version == "" {
+		u.Update = UpdateUnpinned
+		return u
+	}
+
+	// Advisories are reported per release, so look up the pinned one
+	current := latest
+	if p.Version != latest.Info.Version {
+		if current, err = index.Release(ctx, p.Name, p.Version); err != nil || current == nil {
+			current = nil
+			u.Detail = "pinned release not found on the package index"
+		}
+	}
+	if current != nil && len(current.Vulnerabilities) > 0 {
+		var fixedIn []string
+		for _, v := range current.Vulnerabilities {
+			u.Advisories = append(u.Advisories, advisoryID(v))
+			fixedIn = append(fixedIn, v.FixedIn...)
+		}
+		u.Update = UpdateSecurity
+		if len(fixedIn) > 0 {
+			slices.SortFunc(fixedIn, func(a, b string) int { c, _ := pypi.CompareVersions(a, b); return c })
+			u.Detail = "fixed in " + fixedIn[len(fixedIn)-1]
+		}
+		return u
+	}
+
+	u.Update = updateKind(p.Version, u.Latest)
+	return u
+}
+
+// advisoryID prefers a CVE alias over the index's own advisory ID.
+func advisoryID(v pypi.Vulnerability) string {
+	for _, alias := range v.Aliases {
+		if strings.HasPrefix(alias, "CVE-") {
+			return alias
+		}
+	}
+	return v.ID
+}
+
+// updateKind classifies the update from current to latest.
+func updateKind(current, latest string) string {
+	c, segment := pypi.CompareVersions(current, latest)
+	switch {
+	case c >= 0:
+		return UpdateNone
+	case segment == 0:
+		return UpdateMajor
+	case segment == 1:
+		return UpdateMinor
+	default:
+		return UpdatePatch
+	}
+}
+
+// digitRuns matches the numbers in an image tag.
+var digitRuns = regexp.MustCompile(`\d+`)
+
+// checkBaseImage looks for a newer tag of the same shape as the base image's:
+// python:3.13-slim for python:3.11-slim, or cuda12.6-torch2.10 for cuda12.6-torch2.9.
+func checkBaseImage(ctx context.Context, hub *dockerHub, image string) Update {
+	repo, tag, ok := strings.Cut(image, ":")
+	if !ok {
+		tag = "latest"
+	}
+	u := Update{Kind: KindImage, Name: repo, Current: tag, Update: UpdateUnknown}
+	if !strings.Contains(repo, "/") {
+		repo = "library/" + repo
+	}
+
+	tags, err := hub.tags(ctx, repo, tagFilter(tag))
+	if err != nil {
+		u.Detail = err.Error()
+		return u
+	}
+
+	shape, version := tagShape(tag)
+	latestVersion := version
+	u.Latest = tag
+	for _, t := range tags {
+		if t.Name == tag {
+			u.Detail = strings.TrimSpace(shortDigest(t.Digest) + " updated " + t.LastUpdated)
+			continue
+		}
+		if s, v := tagShape(t.Name); s == shape {
+			if c, _ := pypi.CompareVersions(v, latestVersion); c > 0 {
+				u.Latest, latestVersion = t.Name, v
+			}
+		}
+	}
+	u.Update = updateKind(version, latestVersion)
+	return u
+}
+
+// tagShape splits a tag into its non-numeric skeleton and its numbers as a
+// dotted version: "cuda12.6-torch2.9" is ("cuda#.#-torch#.#", "12.6.2.9").
+func tagShape(tag string) (string, string) {
+	return digitRuns.ReplaceAllString(tag, "#"), strings.Join(digitRuns.FindAllString(tag, -1), ".")
+}
+
+// tagFilter is the longest word of a tag, used to narrow the tag listing.
+func tagFilter(tag string) string {
+	filter := ""
+	for _, word := range strings.FieldsFunc(tag, func(r rune) bool { return r == '-' || r == '.' || (r >= '0' && r <= '9') }) {
+		if len(word) > len(filter) {
+			filter = word
+		}
+	}
+	return filter
+}
+
+func shortDigest(digest string) string {
+	if len(digest) > len("sha256:")+12 {
+		return digest[:len("sha256:")+12]
+	}
+	return digest
+}
+
+func writeUpdates(w io.Writer, updates []Update, wide bool) {
+	if len(updates) == 0 {
+		fmt.Fprintln(w, "Everything is up to date.")
+		return
+	}
+
+	table := &ui.Table{Columns: []string{"TYPE", "NAME", "CURRENT", "LATEST", "UPDATE", "ADVISORIES"}}
+	if wide {
+		table.Columns = append(table.Columns, "DETAIL")
+	}
+	counts := map[string]int{}
+	for _, u := range updates {
+		counts[u.Update]++
+		cells := []string{u.Kind, u.Name, orDash(u.Current), orDash(u.Latest), u.Update, orDash(strings.Join(u.Advisories, ","))}
+		if wide {
+			cells = append(cells, orDash(u.Detail))
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: u.Kind + "/" + u.Name, Status: u.Update, Cells: cells})
+	}
+	table.Write(w)
+
+	var summary []string
+	for _, kind := range updatePriority {
+		if counts[kind] > 0 && kind != UpdateNone {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	if len(summary) > 0 {
+		fmt.Fprintf(w, "\n%s\n", strings.Join(summary, ", "))
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package deps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/pypi"
+)
+
+func TestOutdated(t *testing.T) {
+	index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		releases := map[string]pypi.Release{
+			"/torch/json":           {Info: pypi.Info{Version: "2.9.1"}},
+			"/torch/2.5.1/json":     {Info: pypi.Info{Version: "2.5.1"}},
+			"/pillow/json":          {Info: pypi.Info{Version: "11.0.0"}},
+			"/pillow/10.2.0/json":   {Info: pypi.Info{Version: "10.2.0"}, Vulnerabilities: []pypi.Vulnerability{{ID: "PYSEC-2024-1", Aliases: []string{"CVE-2024-28219"}, FixedIn: []string{"10.3.0"}}}},
+			"/requests/json":        {Info: pypi.Info{Version: "2.32.3"}},
+			"/requests/2.32.3/json": {Info: pypi.Info{Version: "2.32.3"}},
+			"/numpy/json":           {Info: pypi.Info{Version: "2.1.0"}},
+		}
+		release, ok := releases[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(release)
+	}))
+	defer index.Close()
+
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/repositories/library/python/tags" || r.URL.Query().Get("name") != "slim" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": []imageTag{
+			{Name: "3.11-slim", Digest: "sha256:0123456789abcdef0123", LastUpdated: "2026-09-01"},
+			{Name: "3.13-slim"},
+			{Name: "3.12-slim"},
+			{Name: "3.13.1-slim"},
+			{Name: "3.14-rc-slim"},
+		}})
+	}))
+	defer hub.Close()
+
+	t.Setenv("PATH", "") // Don't resolve with uv
+	dir := t.TempDir()
+	pyproject := `[project]
+name = "demo"
+dependencies = ["torch==2.5.1", "pillow", "requests==2.32.3", "numpy"]
+
+[tool.cozy]
+python = "3.11"
+`
+	os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644)
+	os.WriteFile(filepath.Join(dir, "requirements.lock"), []byte("pillow==10.2.0\n"), 0644)
+
+	var out bytes.Buffer
+	opts := OutdatedOptions{ProjectDir: dir, FailOnAdvisories: true}
+	err := outdated(context.Background(), &out, pypi.NewClient(index.URL), newDockerHub(hub.URL), opts)
+	if err == nil || err.Error() != "1 dependency has security advisories: pillow" {
+		t.Errorf("expected pillow's advisory to fail, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		"TYPE     NAME    CURRENT    LATEST     UPDATE    ADVISORIES",
+		"package  pillow  10.2.0     11.0.0     security  CVE-2024-28219",
+		"image    python  3.11-slim  3.13-slim  minor     -",
+		"package  torch   2.5.1      2.9.1      minor     -",
+		"package  numpy   -          2.1.0      unpinned  -",
+		"",
+		"1 security, 2 minor, 1 unpinned",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestUpdateKind(t *testing.T) {
+	for _, tc := range []struct{ current, latest, want string }{
+		{"1.2.3", "2.0.0", UpdateMajor},
+		{"1.2.3", "1.3.0", UpdateMinor},
+		{"1.2.3", "1.2.10", UpdatePatch},
+		{"1.2", "1.2.0", UpdateNone},
+		{"2.0.0rc1", "1.9.0", UpdateNone},
+	} {
+		if got := updateKind(tc.current, tc.latest); got != tc.want {
+			t.Errorf("updateKind(%s, %s) = %s, want %s", tc.current, tc.latest, got, tc.want)
+		}
+	}
+}
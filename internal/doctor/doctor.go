@@ -0,0 +1,179 @@
+// Package doctor checks that the machine cozyctl runs on supports what it
+// needs: a supported platform, a writable config directory, a terminal it
+// can draw on, and the external tools some commands call. It also checks
+// that a project's Python setup matches what its deploys will run.
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Check results.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// supportedPlatforms are the GOOS/GOARCH pairs cozyctl is released and
+// tested on.
+var supportedPlatforms = []string{
+	"darwin/amd64", "darwin/arm64",
+	"linux/amd64", "linux/arm64",
+	"windows/amd64", "windows/arm64",
+}
+
+// Options contains the options for running the checks.
+type Options struct {
+	Output ui.Output
+}
+
+// Check is the result of one check.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+// env is what the checks inspect, so tests can substitute it.
+type env struct {
+	goos, goarch string
+	configDir    func() (string, error)
+	stdout       *os.File
+	lookPath     func(string) (string, error)
+
+	// pythonVersion reports the version of the local Python interpreter
+	pythonVersion func() (string, error)
+}
+
+// defaultEnv is the machine cozyctl is running on.
+func defaultEnv() env {
+	return env{
+		goos:          runtime.GOOS,
+		goarch:        runtime.GOARCH,
+		configDir:     config.BaseDir,
+		stdout:        os.Stdout,
+		lookPath:      exec.LookPath,
+		pythonVersion: localPythonVersion,
+	}
+}
+
+// Run runs the checks and prints the results. It fails if any check failed.
+func Run(opts Options) error {
+	return run(os.Stdout, defaultEnv(), opts)
+}
+
+func run(w io.Writer, e env, opts Options) error {
+	checks := []Check{
+		checkPlatform(e),
+		checkConfigDir(e),
+		checkTerminal(e),
+		checkTool(e, "docker", "local builds (build --local, deploy --local-build, update)", "Install Docker Desktop or Docker Engine"),
+		checkTool(e, "git", "template repositories and image source labels", "Install git"),
+	}
+	return report(w, checks, opts.Output)
+}
+
+// report prints the results of checks, and fails if any check failed.
+func report(w io.Writer, checks []Check, output ui.Output) error {
+	if output.Structured() {
+		if err := ui.WriteStructured(w, output, checks); err != nil {
+			return err
+		}
+	} else {
+		table := &ui.Table{Columns: []string{"CHECK", "STATUS", "DETAILS"}}
+		for _, c := range checks {
+			table.Rows = append(table.Rows, ui.Row{Key: c.Name, Status: c.Status, Cells: []string{c.Name, c.Status, c.Detail}})
+		}
+		if err := table.Write(w); err != nil {
+			return err
+		}
+		if slices.ContainsFunc(checks, func(c Check) bool { return c.Fix != "" }) {
+			fmt.Fprintln(w)
+			for _, c := range checks {
+				if c.Fix != "" {
+					fmt.Fprintf(w, "%s: %s\n", c.Name, c.Fix)
+				}
+			}
+		}
+	}
+
+	failed := 0
+	for _, c := range checks {
+		if c.Status == StatusFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+func checkPlatform(e env) Check {
+	platform := e.goos + "/" + e.goarch
+	if slices.Contains(supportedPlatforms, platform) {
+		return Check{Name: "platform", Status: StatusOK, Detail: platform}
+	}
+	return Check{
+		Name:   "platform",
+		Status: StatusWarn,
+		Detail: platform + " is an unsupported environment",
+		Fix:    "cozyctl is tested on macOS, Linux, and Windows (amd64 and arm64); other platforms may work but aren't supported",
+	}
+}
+
+// checkConfigDir checks that the config directory (~/.cozy, under
+// %USERPROFILE% on Windows) exists or can be created, and is writable.
+func checkConfigDir(e env) Check {
+	dir, err := e.configDir()
+	if err != nil {
+		return Check{Name: "config directory", Status: StatusFail, Detail: err.Error(), Fix: "Set HOME (or USERPROFILE on Windows) to your home directory"}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return Check{Name: "config directory", Status: StatusFail, Detail: fmt.Sprintf("cannot create %s: %v", dir, err)}
+	}
+	probe, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return Check{Name: "config directory", Status: StatusFail, Detail: fmt.Sprintf("%s is not writable: %v", dir, err), Fix: "Fix the permissions of " + dir}
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return Check{Name: "config directory", Status: StatusOK, Detail: filepath.Clean(dir)}
+}
+
+// checkTerminal checks whether live views and hidden password prompts can
+// use the terminal.
+func checkTerminal(e env) Check {
+	switch {
+	case e.stdout == nil || !ui.IsTerminal(e.stdout):
+		return Check{Name: "terminal", Status: StatusOK, Detail: "not a terminal; progress is printed as plain lines"}
+	case !ui.SupportsANSI(e.stdout):
+		return Check{
+			Name:   "terminal",
+			Status: StatusWarn,
+			Detail: "console doesn't support ANSI escape sequences; live views fall back to plain lines",
+			Fix:    "Use Windows Terminal or a recent PowerShell console for live progress and watch views",
+		}
+	default:
+		return Check{Name: "terminal", Status: StatusOK, Detail: "interactive, ANSI supported"}
+	}
+}
+
+func checkTool(e env, name, usedFor, fix string) Check {
+	path, err := e.lookPath(name)
+	if err != nil {
+		return Check{Name: name, Status: StatusWarn, Detail: "not found; needed for " + usedFor, Fix: fix}
+	}
+	return Check{Name: name, Status: StatusOK, Detail: path}
+}
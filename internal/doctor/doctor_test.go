@@ -0,0 +1,87 @@
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func testEnv(t *testing.T) env {
+	dir := t.TempDir()
+	return env{
+		goos:          "windows",
+		goarch:        "amd64",
+		configDir:     func() (string, error) { return dir, nil },
+		lookPath:      func(name string) (string, error) { return "/usr/bin/" + name, nil },
+		pythonVersion: func() (string, error) { return "3.11.9", nil },
+	}
+}
+
+func TestRunAllOK(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(&out, testEnv(t), Options{}); err != nil {
+		t.Fatalf("run: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"platform", "windows/amd64", "config directory", "terminal", "docker", "git"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), StatusWarn) || strings.Contains(out.String(), StatusFail) {
+		t.Errorf("expected every check to pass:\n%s", out.String())
+	}
+}
+
+func TestRunUnsupportedPlatform(t *testing.T) {
+	e := testEnv(t)
+	e.goos, e.goarch = "plan9", "386"
+
+	check := checkPlatform(e)
+	if check.Status != StatusWarn || !strings.Contains(check.Detail, "unsupported environment") {
+		t.Errorf("expected an unsupported environment warning, got %+v", check)
+	}
+
+	// A warning alone doesn't fail the command
+	var out bytes.Buffer
+	if err := run(&out, e, Options{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(out.String(), "platform: cozyctl is tested on") {
+		t.Errorf("expected a fix line for the platform:\n%s", out.String())
+	}
+}
+
+func TestRunMissingTool(t *testing.T) {
+	e := testEnv(t)
+	e.lookPath = func(name string) (string, error) {
+		if name == "docker" {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + name, nil
+	}
+
+	check := checkTool(e, "docker", "local builds", "Install Docker")
+	if check.Status != StatusWarn || check.Fix != "Install Docker" {
+		t.Errorf("expected a warning with a fix, got %+v", check)
+	}
+	var out bytes.Buffer
+	if err := run(&out, e, Options{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
+
+func TestRunConfigDirFails(t *testing.T) {
+	e := testEnv(t)
+	e.configDir = func() (string, error) { return "", errors.New("home directory not set") }
+
+	var out bytes.Buffer
+	err := run(&out, e, Options{})
+	if err == nil || !strings.Contains(err.Error(), "1 of 5 checks failed") {
+		t.Fatalf("expected one failed check, got %v", err)
+	}
+	if !strings.Contains(out.String(), "home directory not set") {
+		t.Errorf("expected the error in the output:\n%s", out.String())
+	}
+}
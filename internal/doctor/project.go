@@ -0,0 +1,369 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/pypi"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// RuntimePackage is the worker runtime the default entrypoint runs
+// (python -m gen_worker.entrypoint), so projects must depend on it.
+const RuntimePackage = "gen-worker"
+
+// lockFiles are the lock files dependency versions are read from, in the
+// order pypi.Resolve prefers them.
+var lockFiles = []string{"uv.lock", "requirements.lock", "requirements.txt"}
+
+// ProjectOptions contains the options for checking a project.
+type ProjectOptions struct {
+	Dir    string
+	Output ui.Output
+}
+
+// project is what the project checks read from pyproject.toml.
+type project struct {
+	dir            string
+	cozy           *build.ToolsCozyConfig
+	requiresPython string
+}
+
+// RunProject checks the project in opts.Dir and prints the results, with
+// the commands that fix what's wrong. It fails if any check failed.
+func RunProject(opts ProjectOptions) error {
+	return runProject(os.Stdout, defaultEnv(), opts)
+}
+
+func runProject(w io.Writer, e env, opts ProjectOptions) error {
+	p, err := loadProject(opts.Dir)
+	if err != nil {
+		return err
+	}
+	checks := []Check{
+		checkRequiresPython(p),
+		checkLocalPython(e, p),
+		checkTool(e, "uv", "lock files and dependency resolution", "Install uv: curl -LsSf https://astral.sh/uv/install.sh | sh"),
+		checkRuntime(p),
+		checkLockFile(p),
+		checkFunctions(p),
+	}
+	return report(w, checks, opts.Output)
+}
+
+func loadProject(dir string) (*project, error) {
+	path := filepath.Join(dir, build.PyProjectTomlPath)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s has no %s; run 'cozyctl init' to start a project", dir, build.PyProjectTomlPath)
+	}
+	cozy, err := build.GetToolsCozyConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	var pyproject struct {
+		Project struct {
+			RequiresPython string `toml:"requires-python"`
+		} `toml:"project"`
+	}
+	if _, err := toml.DecodeFile(path, &pyproject); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &project{dir: dir, cozy: cozy, requiresPython: pyproject.Project.RequiresPython}, nil
+}
+
+// checkRequiresPython checks that the base image's Python satisfies the
+// project's requires-python; pip refuses to install the project otherwise.
+func checkRequiresPython(p *project) Check {
+	const name = "python version"
+	image := build.ImagePython(p.cozy)
+	switch {
+	case image == "":
+		return Check{Name: name, Status: StatusOK, Detail: "the PyTorch base image provides Python"}
+	case p.requiresPython == "":
+		return Check{Name: name, Status: StatusOK, Detail: "image uses Python " + image + "; pyproject.toml sets no requires-python"}
+	}
+	ok, err := admits(p.requiresPython, image)
+	if err != nil {
+		return Check{Name: name, Status: StatusWarn, Detail: err.Error()}
+	}
+	if !ok {
+		return Check{
+			Name:   name,
+			Status: StatusFail,
+			Detail: fmt.Sprintf("image uses Python %s, but requires-python is %q", image, p.requiresPython),
+			Fix:    fmt.Sprintf("Set python = \"X.Y\" under [tool.cozy] to a version matching %q, or widen requires-python in pyproject.toml", p.requiresPython),
+		}
+	}
+	return Check{Name: name, Status: StatusOK, Detail: fmt.Sprintf("image uses Python %s (requires-python %q)", image, p.requiresPython)}
+}
+
+// checkLocalPython checks that the Python used to run the project locally
+// (the version pinned in .python-version, or the one on the PATH) matches
+// the one deploys run, so local tests mean something.
+func checkLocalPython(e env, p *project) Check {
+	const name = "local python"
+	local, source := "", ".python-version"
+	if data, err := os.ReadFile(filepath.Join(p.dir, ".python-version")); err == nil {
+		local, _, _ = strings.Cut(strings.TrimSpace(string(data)), "\n")
+	} else {
+		version, err := e.pythonVersion()
+		if err != nil {
+			return Check{Name: name, Status: StatusWarn, Detail: "no Python interpreter found", Fix: "Install Python with: uv python install"}
+		}
+		local, source = version, "python3"
+	}
+	local = strings.TrimSpace(local)
+
+	image := build.ImagePython(p.cozy)
+	if image != "" {
+		if !sameMinor(local, image) {
+			return Check{
+				Name:   name,
+				Status: StatusWarn,
+				Detail: fmt.Sprintf("%s is Python %s, but deploys run Python %s", source, local, image),
+				Fix:    "Pin the deployed version locally: uv python pin " + image,
+			}
+		}
+	} else if p.requiresPython != "" {
+		if ok, err := admits(p.requiresPython, local); err == nil && !ok {
+			return Check{
+				Name:   name,
+				Status: StatusWarn,
+				Detail: fmt.Sprintf("%s is Python %s, outside requires-python %q", source, local, p.requiresPython),
+				Fix:    "Pin a matching version locally, e.g.: uv python pin " + build.DefaultPython,
+			}
+		}
+	}
+	return Check{Name: name, Status: StatusOK, Detail: fmt.Sprintf("%s is Python %s", source, local)}
+}
+
+// checkRuntime checks that the project depends on the worker runtime,
+// unless it replaces the entrypoint that runs it.
+func checkRuntime(p *project) Check {
+	const name = "worker runtime"
+	if p.cozy.Entrypoint != "" {
+		return Check{Name: name, Status: StatusOK, Detail: "custom entrypoint; " + RuntimePackage + " not required"}
+	}
+	deps, err := pypi.ProjectDependencies(p.dir)
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Detail: err.Error()}
+	}
+	if !slices.ContainsFunc(deps, func(d pypi.Package) bool { return d.Name == RuntimePackage }) {
+		return Check{
+			Name:   name,
+			Status: StatusFail,
+			Detail: RuntimePackage + " is not a dependency; the worker entrypoint can't start without it",
+			Fix:    "uv add " + RuntimePackage,
+		}
+	}
+	return Check{Name: name, Status: StatusOK, Detail: RuntimePackage + " is a dependency"}
+}
+
+// checkLockFile checks that the project's lock file lists every direct
+// dependency at the version pyproject.toml pins.
+func checkLockFile(p *project) Check {
+	const name = "lock file"
+	lock := ""
+	for _, f := range lockFiles {
+		if _, err := os.Stat(filepath.Join(p.dir, f)); err == nil {
+			lock = f
+			break
+		}
+	}
+	if lock == "" {
+		return Check{Name: name, Status: StatusWarn, Detail: "none, so dependency versions aren't pinned", Fix: "uv lock"}
+	}
+	relock := "uv lock"
+	if lock != "uv.lock" {
+		relock = "uv pip compile pyproject.toml -o " + lock
+	}
+
+	res, err := pypi.Resolve(context.Background(), p.dir)
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Detail: err.Error(), Fix: relock}
+	}
+	deps, err := pypi.ProjectDependencies(p.dir)
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Detail: err.Error()}
+	}
+	locked := map[string]string{}
+	for _, pkg := range res.Packages {
+		locked[pkg.Name] = pkg.Version
+	}
+	var drift []string
+	for _, d := range deps {
+		v, ok := locked[d.Name]
+		switch {
+		case !ok:
+			drift = append(drift, d.Name+" is missing")
+		case d.Version != "" && v != "" && v != d.Version:
+			drift = append(drift, fmt.Sprintf("%s is locked at %s, pinned at %s", d.Name, v, d.Version))
+		}
+	}
+	if len(drift) > 0 {
+		return Check{
+			Name:   name,
+			Status: StatusWarn,
+			Detail: fmt.Sprintf("%s is out of date with pyproject.toml: %s", lock, strings.Join(drift, "; ")),
+			Fix:    relock,
+		}
+	}
+	return Check{Name: name, Status: StatusOK, Detail: lock + " matches pyproject.toml"}
+}
+
+// checkFunctions checks that the functions declared in [tool.cozy.functions]
+// are defined with @worker_function() in the project's source.
+func checkFunctions(p *project) Check {
+	const name = "functions"
+	detected, err := build.DetectWorkerFunctions(p.dir)
+	if err != nil {
+		return Check{Name: name, Status: StatusWarn, Detail: "failed to scan the source: " + err.Error()}
+	}
+	if len(p.cozy.Functions) == 0 {
+		if len(detected) == 0 {
+			return Check{
+				Name:   name,
+				Status: StatusWarn,
+				Detail: "no @worker_function() found in the source",
+				Fix:    "Decorate the functions to serve with @worker_function() from gen_worker",
+			}
+		}
+		return Check{Name: name, Status: StatusOK, Detail: fmt.Sprintf("%d detected in the source", len(detected))}
+	}
+
+	var missing []string
+	for declared := range p.cozy.Functions {
+		if !slices.ContainsFunc(detected, func(f build.DetectedFunction) bool { return f.Name == declared }) {
+			missing = append(missing, declared)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return Check{
+			Name:   name,
+			Status: StatusFail,
+			Detail: "declared in [tool.cozy.functions] but not found in the source: " + strings.Join(missing, ", "),
+			Fix:    "Decorate them with @worker_function(), or remove them from [tool.cozy.functions]",
+		}
+	}
+	return Check{Name: name, Status: StatusOK, Detail: fmt.Sprintf("all %d declared functions found in the source", len(p.cozy.Functions))}
+}
+
+// localPythonVersion runs the Python on the PATH (python3, or python on
+// Windows) and returns its version.
+func localPythonVersion() (string, error) {
+	for _, name := range []string{"python3", "python"} {
+		out, err := exec.Command(name, "--version").Output()
+		if err != nil {
+			continue
+		}
+		if v, ok := strings.CutPrefix(strings.TrimSpace(string(out)), "Python "); ok {
+			return v, nil
+		}
+	}
+	return "", errors.New("python not found")
+}
+
+// sameMinor reports whether two Python versions share their major and
+// minor version.
+func sameMinor(a, b string) bool {
+	pa, pb := pypi.ReleaseParts(a), pypi.ReleaseParts(b)
+	return len(pa) >= 2 && len(pb) >= 2 && pa[0] == pb[0] && pa[1] == pb[1]
+}
+
+// admits reports whether a requires-python specifier such as ">=3.10,<3.13"
+// allows version. A major.minor version stands for its whole release
+// series, so ">=3.11.4" admits "3.11".
+func admits(spec, version string) (bool, error) {
+	parts := pypi.ReleaseParts(version)
+	if len(parts) == 0 {
+		return false, fmt.Errorf("invalid Python version %q", version)
+	}
+	lo := version
+	hi := version // Exclusive upper bound of a series, inclusive for a full version
+	series := len(parts) == 2
+	if series {
+		hi = fmt.Sprintf("%d.%d", parts[0], parts[1]+1)
+	}
+	cmp := func(a, b string) int { c, _ := pypi.CompareVersions(a, b); return c }
+	atLeast := func(v string) bool { // Some version in the series is >= v
+		if series {
+			return cmp(hi, v) > 0
+		}
+		return cmp(lo, v) >= 0
+	}
+	matches := func(prefix []int) bool { // Some version in the series has prefix
+		for i := 0; i < min(len(prefix), len(parts)); i++ {
+			if prefix[i] != parts[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, clause := range strings.Split(spec, ",") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+		op := ""
+		for _, candidate := range []string{"===", "~=", "==", "!=", "<=", ">=", "<", ">"} {
+			if strings.HasPrefix(clause, candidate) {
+				op = candidate
+				break
+			}
+		}
+		v := strings.TrimSpace(strings.TrimPrefix(clause, op))
+		wildcard := strings.HasSuffix(v, ".*")
+		v = strings.TrimSuffix(v, ".*")
+		vParts := pypi.ReleaseParts(v)
+		if op == "" || len(vParts) == 0 || (wildcard && op != "==" && op != "!=") {
+			return false, fmt.Errorf("can't parse requires-python %q", spec)
+		}
+
+		var ok bool
+		switch op {
+		case ">=":
+			ok = atLeast(v)
+		case ">":
+			ok = atLeast(v) && (series || cmp(lo, v) != 0)
+		case "<=":
+			ok = cmp(lo, v) <= 0
+		case "<":
+			ok = cmp(lo, v) < 0
+		case "==", "===":
+			switch {
+			case wildcard:
+				ok = matches(vParts)
+			case series:
+				ok = cmp(lo, v) <= 0 && cmp(hi, v) > 0
+			default:
+				ok = cmp(lo, v) == 0
+			}
+		case "!=":
+			if wildcard {
+				ok = !(matches(vParts) && len(vParts) <= len(parts))
+			} else {
+				ok = series || cmp(lo, v) != 0
+			}
+		case "~=":
+			if len(vParts) < 2 {
+				return false, fmt.Errorf("can't parse requires-python %q", spec)
+			}
+			ok = atLeast(v) && matches(vParts[:len(vParts)-1])
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
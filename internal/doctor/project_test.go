@@ -0,0 +1,138 @@
+package doctor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const workerSource = `from gen_worker import worker_function, ActionContext
+
+@worker_function()
+def generate(ctx: ActionContext, prompt: str) -> str:
+    return prompt
+`
+
+func TestRunProjectHealthy(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"pyproject.toml": `[project]
+name = "demo"
+requires-python = ">=3.10,<3.13"
+dependencies = ["gen-worker", "pillow==10.4.0"]
+
+[tool.cozy]
+python = "3.11"
+
+[tool.cozy.functions]
+generate = { requires_gpu = false }
+`,
+		"requirements.lock":  "gen-worker==0.3.0\npillow==10.4.0\nnumpy==2.1.0\n",
+		"src/demo/worker.py": workerSource,
+	})
+
+	var out bytes.Buffer
+	if err := runProject(&out, testEnv(t), ProjectOptions{Dir: dir}); err != nil {
+		t.Fatalf("runProject: %v\n%s", err, out.String())
+	}
+	if strings.Contains(out.String(), StatusWarn) || strings.Contains(out.String(), StatusFail) {
+		t.Errorf("expected every check to pass:\n%s", out.String())
+	}
+}
+
+func TestRunProjectProblems(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"pyproject.toml": `[project]
+name = "demo"
+requires-python = ">=3.12"
+dependencies = ["pillow==11.0.0", "requests"]
+
+[tool.cozy]
+python = "3.11"
+
+[tool.cozy.functions]
+generate = { requires_gpu = false }
+upscale = { requires_gpu = true }
+`,
+		"requirements.lock":  "pillow==10.4.0\n",
+		".python-version":    "3.12\n",
+		"src/demo/worker.py": workerSource,
+	})
+
+	var out bytes.Buffer
+	err := runProject(&out, testEnv(t), ProjectOptions{Dir: dir})
+	if err == nil || !strings.Contains(err.Error(), "3 of 6 checks failed") {
+		t.Fatalf("expected three failed checks, got %v\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		`image uses Python 3.11, but requires-python is ">=3.12"`,
+		".python-version is Python 3.12, but deploys run Python 3.11",
+		"local python: Pin the deployed version locally: uv python pin 3.11",
+		"worker runtime: uv add gen-worker",
+		"pillow is locked at 10.4.0, pinned at 11.0.0; requests is missing",
+		"lock file: uv pip compile pyproject.toml -o requirements.lock",
+		"not found in the source: upscale",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunProjectWithoutPyproject(t *testing.T) {
+	err := runProject(&bytes.Buffer{}, testEnv(t), ProjectOptions{Dir: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "cozyctl init") {
+		t.Errorf("expected a missing pyproject.toml error, got %v", err)
+	}
+}
+
+func TestAdmits(t *testing.T) {
+	tests := []struct {
+		spec, version string
+		want          bool
+	}{
+		{">=3.10", "3.11", true},
+		{">=3.12", "3.11", false},
+		{">=3.11.4", "3.11", true},
+		{">=3.11.4", "3.11.2", false},
+		{">=3.10,<3.12", "3.12", false},
+		{"<3.11.5", "3.11", true},
+		{"<3.11", "3.11", false},
+		{"==3.11.*", "3.11.9", true},
+		{"==3.11.*", "3.12", false},
+		{"==3.11", "3.11.0", true},
+		{"!=3.11.*", "3.11", false},
+		{"!=3.11.2", "3.11", true},
+		{"~=3.10", "3.12", true},
+		{"~=3.10", "4.0", false},
+		{">3.11", "3.11", true},
+		{">3.11.0", "3.11.0", false},
+	}
+	for _, tt := range tests {
+		got, err := admits(tt.spec, tt.version)
+		if err != nil {
+			t.Errorf("admits(%q, %q): %v", tt.spec, tt.version, err)
+		} else if got != tt.want {
+			t.Errorf("admits(%q, %q) = %v, want %v", tt.spec, tt.version, got, tt.want)
+		}
+	}
+	if _, err := admits("at least 3.10", "3.11"); err == nil {
+		t.Error("expected an error for an invalid specifier")
+	}
+}
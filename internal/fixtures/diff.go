@@ -0,0 +1,114 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// maxValueWidth truncates values shown in a change.
+const maxValueWidth = 40
+
+// diffJSON lists the differences between two JSON documents as
+// "$.path: old → new" lines. Paths in ignore (and everything below them)
+// are skipped, for fields such as seeds or timings that change every run.
+func diffJSON(old, cur json.RawMessage, ignore []string) []string {
+	var a, b any
+	errA := json.Unmarshal(old, &a)
+	errB := json.Unmarshal(cur, &b)
+	if errA != nil || errB != nil {
+		// Not JSON (or empty): compare the raw bytes
+		if string(old) != string(cur) {
+			return []string{fmt.Sprintf("$: %s → %s", truncate(string(old)), truncate(string(cur)))}
+		}
+		return nil
+	}
+
+	var changes []string
+	diffValues("$", a, b, ignore, &changes)
+	return changes
+}
+
+func diffValues(path string, a, b any, ignore []string, changes *[]string) {
+	if ignored(path, ignore) {
+		return
+	}
+
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			child := path + "." + k
+			if ignored(child, ignore) {
+				continue
+			}
+			oldValue, inOld := av[k]
+			newValue, inNew := bv[k]
+			switch {
+			case !inOld:
+				*changes = append(*changes, fmt.Sprintf("%s: added %s", child, formatValue(newValue)))
+			case !inNew:
+				*changes = append(*changes, fmt.Sprintf("%s: removed", child))
+			default:
+				diffValues(child, oldValue, newValue, ignore, changes)
+			}
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		if len(av) != len(bv) {
+			*changes = append(*changes, fmt.Sprintf("%s: length %d → %d", path, len(av), len(bv)))
+		}
+		for i := range min(len(av), len(bv)) {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), av[i], bv[i], ignore, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, fmt.Sprintf("%s: %s → %s", path, formatValue(a), formatValue(b)))
+	}
+}
+
+// ignored reports whether path is, or is below, one of the ignored paths.
+func ignored(path string, ignore []string) bool {
+	for _, p := range ignore {
+		if path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return truncate(string(data))
+}
+
+func truncate(s string) string {
+	if len(s) <= maxValueWidth {
+		return s
+	}
+	return s[:maxValueWidth-3] + "..."
+}
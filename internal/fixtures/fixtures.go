@@ -0,0 +1,210 @@
+// Package fixtures manages named sample payloads for a project's worker
+// functions, so the same inputs can be replayed against each build and the
+// outputs compared.
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Dir is where fixtures are stored, relative to the project directory:
+// one <function>/<name>.json payload per fixture.
+const Dir = ".cozy/fixtures"
+
+// runsDir holds the recorded outputs of each run, keyed by label.
+const runsDir = ".runs"
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Fixture is a named sample payload for one worker function.
+type Fixture struct {
+	Function string          `json:"function"`
+	Name     string          `json:"name"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// ID is the fixture's "function/name" identifier.
+func (f *Fixture) ID() string {
+	return f.Function + "/" + f.Name
+}
+
+// AddOptions contains the options for adding a fixture.
+type AddOptions struct {
+	ProjectDir string
+	Function   string
+	Name       string
+	Payload    []byte
+	Force      bool // Replace an existing fixture
+}
+
+// Add stores a payload as a named fixture for a function.
+func Add(opts AddOptions) error {
+	return add(os.Stdout, opts)
+}
+
+func add(w io.Writer, opts AddOptions) error {
+	for _, name := range []string{opts.Function, opts.Name} {
+		if !namePattern.MatchString(name) {
+			return fmt.Errorf("invalid name %q: use letters, digits, '.', '_' and '-'", name)
+		}
+	}
+
+	payload := bytes.TrimSpace(opts.Payload)
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+	if !json.Valid(payload) {
+		return fmt.Errorf("payload for %s/%s is not valid JSON", opts.Function, opts.Name)
+	}
+
+	// Catch typos in the function name early; the fixture is still saved,
+	// since detection can miss functions registered dynamically
+	if detected, err := build.DetectWorkerFunctions(opts.ProjectDir); err == nil && len(detected) > 0 {
+		if !slices.ContainsFunc(detected, func(f build.DetectedFunction) bool { return f.Name == opts.Function }) {
+			fmt.Fprintf(w, "Warning: no @worker_function() named %s found in %s\n", opts.Function, opts.ProjectDir)
+		}
+	}
+
+	path := filepath.Join(opts.ProjectDir, Dir, opts.Function, opts.Name+".json")
+	if _, err := os.Stat(path); err == nil && !opts.Force {
+		return fmt.Errorf("fixture %s/%s already exists (use --force to replace it)", opts.Function, opts.Name)
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, payload, "", "  "); err != nil {
+		return fmt.Errorf("payload for %s/%s is not valid JSON", opts.Function, opts.Name)
+	}
+	indented.WriteByte('\n')
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+	if err := os.WriteFile(path, indented.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	rel, _ := filepath.Rel(opts.ProjectDir, path)
+	fmt.Fprintf(w, "Saved fixture %s/%s to %s\n", opts.Function, opts.Name, rel)
+	return nil
+}
+
+// Load reads all of a project's fixtures, sorted by function and name.
+func Load(projectDir string) ([]Fixture, error) {
+	root := filepath.Join(projectDir, Dir)
+	functions, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var fixtures []Fixture
+	for _, fn := range functions {
+		if !fn.IsDir() || strings.HasPrefix(fn.Name(), ".") {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(root, fn.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixtures: %w", err)
+		}
+		for _, file := range files {
+			name, ok := strings.CutSuffix(file.Name(), ".json")
+			if file.IsDir() || !ok {
+				continue
+			}
+			payload, err := os.ReadFile(filepath.Join(root, fn.Name(), file.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read fixture: %w", err)
+			}
+			if !json.Valid(payload) {
+				return nil, fmt.Errorf("fixture %s/%s is not valid JSON", fn.Name(), name)
+			}
+			fixtures = append(fixtures, Fixture{Function: fn.Name(), Name: name, Payload: bytes.TrimSpace(payload)})
+		}
+	}
+	return fixtures, nil
+}
+
+// Select filters fixtures by "function" or "function/name" patterns.
+// No patterns selects every fixture.
+func Select(fixtures []Fixture, patterns []string) ([]Fixture, error) {
+	if len(patterns) == 0 {
+		return fixtures, nil
+	}
+
+	var selected []Fixture
+	for _, pattern := range patterns {
+		function, name, _ := strings.Cut(pattern, "/")
+		matched := false
+		for _, f := range fixtures {
+			if f.Function == function && (name == "" || f.Name == name) {
+				matched = true
+				if !slices.ContainsFunc(selected, func(s Fixture) bool { return s.ID() == f.ID() }) {
+					selected = append(selected, f)
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no fixture matches %q", pattern)
+		}
+	}
+	return selected, nil
+}
+
+// ListOptions contains the options for listing fixtures.
+type ListOptions struct {
+	ProjectDir string
+	Output     ui.Output
+}
+
+// List prints a project's fixtures and the runs recorded for them.
+func List(opts ListOptions) error {
+	return list(os.Stdout, opts)
+}
+
+func list(w io.Writer, opts ListOptions) error {
+	fixtures, err := Load(opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	if opts.Output.Structured() {
+		if fixtures == nil {
+			fixtures = []Fixture{}
+		}
+		return ui.WriteStructured(w, opts.Output, fixtures)
+	}
+
+	if len(fixtures) == 0 {
+		fmt.Fprintf(w, "No fixtures in %s. Add one with 'cozyctl fixtures add'.\n", filepath.Join(opts.ProjectDir, Dir))
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"FUNCTION", "NAME", "SIZE"}}
+	for _, f := range fixtures {
+		table.Rows = append(table.Rows, ui.Row{Key: f.ID(), Cells: []string{
+			f.Function, f.Name, ui.FormatBytes(int64(len(f.Payload))),
+		}})
+	}
+	if err := table.Write(w); err != nil {
+		return err
+	}
+
+	if labels, err := Runs(opts.ProjectDir); err == nil && len(labels) > 0 {
+		fmt.Fprintf(w, "\nRecorded runs: %s\n", strings.Join(labels, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestAddAndSelect(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, opts := range []AddOptions{
+		{ProjectDir: dir, Function: "generate", Name: "cat", Payload: []byte(`{"prompt":"a cat"}`)},
+		{ProjectDir: dir, Function: "generate", Name: "dog", Payload: []byte(`{"prompt":"a dog"}`)},
+		{ProjectDir: dir, Function: "upscale", Name: "small"},
+	} {
+		if err := add(io.Discard, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := add(io.Discard, AddOptions{ProjectDir: dir, Function: "generate", Name: "cat"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an already exists error, got %v", err)
+	}
+	if err := add(io.Discard, AddOptions{ProjectDir: dir, Function: "generate", Name: "bad", Payload: []byte("{")}); err == nil {
+		t.Error("expected invalid JSON to be rejected")
+	}
+	if err := add(io.Discard, AddOptions{ProjectDir: dir, Function: "../escape", Name: "x"}); err == nil {
+		t.Error("expected an invalid function name to be rejected")
+	}
+
+	all, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].ID() != "generate/cat" || string(all[2].Payload) != "{}" {
+		t.Fatalf("unexpected fixtures %+v", all)
+	}
+
+	selected, err := Select(all, []string{"generate", "generate/cat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 2 {
+		t.Errorf("expected 2 fixtures, got %d", len(selected))
+	}
+	if _, err := Select(all, []string{"upscale/large"}); err == nil {
+		t.Error("expected an error for an unknown fixture")
+	}
+}
+
+func TestRunAndCompare(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	client := api.NewClient(ts.URL, "token", nil)
+	_, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-app",
+		Name:                 "my-app",
+		ImageURL:             "registry.example.com/cozy-build-my-app:1a2b3c4d",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	add(io.Discard, AddOptions{ProjectDir: dir, Function: "generate", Name: "cat", Payload: []byte(`{"prompt":"a cat","seed":1}`)})
+
+	if err := run(io.Discard, client, RunOptions{ProjectDir: dir, DeploymentID: "my-app"}); err != nil {
+		t.Fatal(err)
+	}
+	if labels, _ := Runs(dir); len(labels) != 1 || labels[0] != "1a2b3c4d" {
+		t.Fatalf("expected a run labeled with the image tag, got %v", labels)
+	}
+
+	// Same payload: unchanged
+	var buf bytes.Buffer
+	if err := run(&buf, client, RunOptions{ProjectDir: dir, DeploymentID: "my-app", Label: "v2", Against: "1a2b3c4d", Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Status != StatusUnchanged {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	// The mock echoes its input, so a new seed changes the output
+	add(io.Discard, AddOptions{ProjectDir: dir, Function: "generate", Name: "cat", Payload: []byte(`{"prompt":"a cat","seed":2}`), Force: true})
+	buf.Reset()
+	err = run(&buf, client, RunOptions{ProjectDir: dir, DeploymentID: "my-app", Label: "v3", Against: "v2"})
+	if err == nil || !strings.Contains(err.Error(), "1 fixture changed since v2: generate/cat") {
+		t.Errorf("expected a change, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "$.input.seed: 1 → 2") {
+		t.Errorf("expected the change in the output, got:\n%s", buf.String())
+	}
+
+	if err := diff(io.Discard, DiffOptions{ProjectDir: dir, From: "v2", To: "v3", Ignore: []string{"$.input.seed"}}); err != nil {
+		t.Errorf("expected ignored paths to be left out, got %v", err)
+	}
+
+	// Unknown functions fail
+	add(io.Discard, AddOptions{ProjectDir: dir, Function: "missing", Name: "x"})
+	if err := run(io.Discard, client, RunOptions{ProjectDir: dir, DeploymentID: "my-app", Label: "v4", Fixtures: []string{"missing"}}); err == nil || !strings.Contains(err.Error(), "1 fixture failed") {
+		t.Errorf("expected a failure, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, Dir, runsDir, "v4", "missing", "x.json")); err != nil {
+		t.Errorf("expected failed runs to be recorded: %v", err)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	old := json.RawMessage(`{"images":["a","b"],"meta":{"seed":1,"model":"sdxl"},"gone":true}`)
+	cur := json.RawMessage(`{"images":["a","c","d"],"meta":{"seed":2,"model":"sdxl"},"new":1}`)
+
+	got := diffJSON(old, cur, []string{"$.meta.seed"})
+	want := []string{
+		"$.gone: removed",
+		"$.images: length 2 → 3",
+		`$.images[1]: "b" → "c"`,
+		"$.new: added 1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected diff:\n got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,435 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// LocalLabel labels runs against a local dev stack.
+const LocalLabel = "local"
+
+// maxChangesShown bounds the changes printed per fixture.
+const maxChangesShown = 10
+
+// Fixture run statuses.
+const (
+	StatusPassed    = "passed"    // Invoked successfully, nothing to compare against
+	StatusUnchanged = "unchanged" // Same output as the baseline run
+	StatusChanged   = "changed"   // Output differs from the baseline run
+	StatusNew       = "new"       // The baseline run has no output for this fixture
+	StatusMissing   = "missing"   // The compared run has no output for this fixture
+	StatusFailed    = "failed"    // The invocation failed
+)
+
+var labelPattern = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Result is the recorded output of one fixture in a run.
+type Result struct {
+	Function     string          `json:"function"`
+	Name         string          `json:"name"`
+	InvocationID string          `json:"invocation_id,omitempty"`
+	StatusCode   int             `json:"status_code,omitempty"`
+	DurationMS   int64           `json:"duration_ms"`
+	Error        string          `json:"error,omitempty"`
+	Output       json.RawMessage `json:"output,omitempty"`
+}
+
+// Comparison is a fixture's result compared against a baseline run.
+type Comparison struct {
+	Function     string   `json:"function"`
+	Name         string   `json:"name"`
+	Status       string   `json:"status"`
+	InvocationID string   `json:"invocation_id,omitempty"` // For 'cozyctl artifacts'
+	StatusCode   int      `json:"status_code,omitempty"`
+	DurationMS   int64    `json:"duration_ms"`
+	Error        string   `json:"error,omitempty"`
+	Changes      []string `json:"changes,omitempty"`
+}
+
+// Report is the result of running or diffing fixtures.
+type Report struct {
+	Label    string       `json:"label"`
+	Baseline string       `json:"baseline,omitempty"`
+	Results  []Comparison `json:"results"`
+}
+
+// RunOptions contains the options for running fixtures.
+type RunOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	ProjectDir   string
+	DeploymentID string
+	LocalURL     string   // Invoke a local dev stack at this orchestrator URL instead of the profile's
+	Label        string   // Name the run is recorded under (default: the deployment's image tag, or "local")
+	Against      string   // Compare outputs with this recorded run
+	Fixtures     []string // "function" or "function/name"; empty runs all
+	Ignore       []string // JSON paths left out of comparisons
+	Output       ui.Output
+}
+
+// Run invokes each selected fixture against a deployment, records the
+// outputs under a label, and compares them with an earlier run.
+func Run(opts RunOptions) error {
+	var client api.OrchestratorAPI
+	var err error
+	if opts.LocalURL != "" {
+		client = newLocalClient(opts.Profile, opts.Transport, opts.LocalURL)
+	} else if client, err = api.NewProfileClient(opts.Profile, opts.Transport); err != nil {
+		return err
+	}
+	return run(os.Stdout, client, opts)
+}
+
+func run(w io.Writer, client api.OrchestratorAPI, opts RunOptions) error {
+	all, err := Load(opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		return fmt.Errorf("no fixtures in %s (add one with 'cozyctl fixtures add')", filepath.Join(opts.ProjectDir, Dir))
+	}
+	selected, err := Select(all, opts.Fixtures)
+	if err != nil {
+		return err
+	}
+
+	var baseline map[string]Result
+	if opts.Against != "" {
+		if baseline, err = loadRun(opts.ProjectDir, opts.Against); err != nil {
+			return err
+		}
+	}
+
+	label := opts.Label
+	if label == "" {
+		label = defaultLabel(client, opts)
+	}
+	if label = sanitizeLabel(label); label == "" {
+		return fmt.Errorf("invalid run label %q", opts.Label)
+	}
+	if label == opts.Against {
+		return fmt.Errorf("run label %s is the run being compared against; pass --label to record under another name", label)
+	}
+
+	report := Report{Label: label, Baseline: opts.Against}
+	for _, f := range selected {
+		if !opts.Output.Structured() {
+			fmt.Fprintf(w, "Running %s...\n", f.ID())
+		}
+		result := invoke(client, opts.DeploymentID, f)
+		if err := saveResult(opts.ProjectDir, label, result); err != nil {
+			return err
+		}
+
+		var old *Result
+		if prev, ok := baseline[f.ID()]; ok {
+			old = &prev
+		}
+		report.Results = append(report.Results, compare(old, &result, baseline != nil, opts.Ignore))
+	}
+
+	return writeReport(w, report, opts.Output)
+}
+
+// invoke runs one fixture and records its output.
+func invoke(client api.OrchestratorAPI, deploymentID string, f Fixture) Result {
+	result := Result{Function: f.Function, Name: f.Name}
+	resp, err := client.Invoke(deploymentID, f.Function, f.Payload)
+	if resp != nil {
+		result.InvocationID = resp.InvocationID
+		result.StatusCode = resp.StatusCode
+		result.DurationMS = resp.Duration.Milliseconds()
+		if json.Valid(resp.Body) {
+			result.Output = resp.Body
+		} else if len(resp.Body) > 0 {
+			result.Output, _ = json.Marshal(string(resp.Body))
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// compare checks a result against its baseline. hasBaseline distinguishes a
+// fixture the baseline run didn't cover from a run with nothing to compare.
+func compare(old, cur *Result, hasBaseline bool, ignore []string) Comparison {
+	if cur == nil {
+		return Comparison{Function: old.Function, Name: old.Name, Status: StatusMissing}
+	}
+
+	c := Comparison{
+		Function:     cur.Function,
+		Name:         cur.Name,
+		InvocationID: cur.InvocationID,
+		StatusCode:   cur.StatusCode,
+		DurationMS:   cur.DurationMS,
+		Error:        cur.Error,
+	}
+
+	switch {
+	case cur.Error != "":
+		c.Status = StatusFailed
+	case !hasBaseline:
+		c.Status = StatusPassed
+	case old == nil:
+		c.Status = StatusNew
+	default:
+		if old.StatusCode != cur.StatusCode {
+			c.Changes = append(c.Changes, fmt.Sprintf("status: %d → %d", old.StatusCode, cur.StatusCode))
+		}
+		c.Changes = append(c.Changes, diffJSON(old.Output, cur.Output, ignore)...)
+		c.Status = StatusUnchanged
+		if len(c.Changes) > 0 {
+			c.Status = StatusChanged
+		}
+	}
+	return c
+}
+
+func writeReport(w io.Writer, report Report, output ui.Output) error {
+	if output.Structured() {
+		if err := ui.WriteStructured(w, output, report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(w)
+		table := &ui.Table{Columns: []string{"FUNCTION", "NAME", "STATUS", "CODE", "TIME", "CHANGES"}}
+		for _, c := range report.Results {
+			code, elapsed := "-", "-"
+			if c.StatusCode != 0 {
+				code = fmt.Sprint(c.StatusCode)
+			}
+			if c.Status != StatusMissing {
+				elapsed = (time.Duration(c.DurationMS) * time.Millisecond).String()
+			}
+			changes := "-"
+			if len(c.Changes) > 0 {
+				changes = fmt.Sprint(len(c.Changes))
+			}
+			table.Rows = append(table.Rows, ui.Row{Key: c.Function + "/" + c.Name, Status: c.Status, Cells: []string{
+				c.Function, c.Name, c.Status, code, elapsed, changes,
+			}})
+		}
+		if err := table.Write(w); err != nil {
+			return err
+		}
+
+		for _, c := range report.Results {
+			if c.Error == "" && len(c.Changes) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "\n%s/%s:\n", c.Function, c.Name)
+			if c.Error != "" {
+				fmt.Fprintf(w, "  %s\n", c.Error)
+			}
+			for i, change := range c.Changes {
+				if i == maxChangesShown {
+					fmt.Fprintf(w, "  ... and %d more\n", len(c.Changes)-maxChangesShown)
+					break
+				}
+				fmt.Fprintf(w, "  %s\n", change)
+			}
+		}
+
+		fmt.Fprintf(w, "\nRecorded as %s", report.Label)
+		if report.Baseline != "" {
+			fmt.Fprintf(w, ", compared against %s", report.Baseline)
+		}
+		fmt.Fprintln(w)
+	}
+
+	var failed, changed []string
+	for _, c := range report.Results {
+		switch c.Status {
+		case StatusFailed:
+			failed = append(failed, c.Function+"/"+c.Name)
+		case StatusChanged, StatusMissing:
+			changed = append(changed, c.Function+"/"+c.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d %s failed: %s", len(failed), plural(len(failed), "fixture", "fixtures"), strings.Join(failed, ", "))
+	}
+	if len(changed) > 0 {
+		return fmt.Errorf("%d %s changed since %s: %s", len(changed), plural(len(changed), "fixture", "fixtures"), report.Baseline, strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// DiffOptions contains the options for comparing two recorded runs.
+type DiffOptions struct {
+	ProjectDir string
+	From       string
+	To         string
+	Fixtures   []string
+	Ignore     []string
+	Output     ui.Output
+}
+
+// Diff compares the outputs of two recorded runs, e.g. of two builds.
+func Diff(opts DiffOptions) error {
+	return diff(os.Stdout, opts)
+}
+
+func diff(w io.Writer, opts DiffOptions) error {
+	from, err := loadRun(opts.ProjectDir, opts.From)
+	if err != nil {
+		return err
+	}
+	to, err := loadRun(opts.ProjectDir, opts.To)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(from)+len(to))
+	for id := range from {
+		ids = append(ids, id)
+	}
+	for id := range to {
+		if _, ok := from[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	if len(opts.Fixtures) > 0 {
+		var selected []string
+		for _, id := range ids {
+			function, name, _ := strings.Cut(id, "/")
+			if slices.ContainsFunc(opts.Fixtures, func(p string) bool { return p == function || p == function+"/"+name }) {
+				selected = append(selected, id)
+			}
+		}
+		ids = selected
+	}
+
+	report := Report{Label: opts.To, Baseline: opts.From}
+	for _, id := range ids {
+		var old, cur *Result
+		if r, ok := from[id]; ok {
+			old = &r
+		}
+		if r, ok := to[id]; ok {
+			cur = &r
+		}
+		report.Results = append(report.Results, compare(old, cur, true, opts.Ignore))
+	}
+
+	return writeReport(w, report, opts.Output)
+}
+
+// Runs lists the labels of a project's recorded runs.
+func Runs(projectDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(projectDir, Dir, runsDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded runs: %w", err)
+	}
+
+	var labels []string
+	for _, e := range entries {
+		if e.IsDir() {
+			labels = append(labels, e.Name())
+		}
+	}
+	return labels, nil
+}
+
+func saveResult(projectDir, label string, result Result) error {
+	path := filepath.Join(projectDir, Dir, runsDir, label, result.Function, result.Name+".json")
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+	return nil
+}
+
+// loadRun reads a recorded run's results, keyed by fixture ID.
+func loadRun(projectDir, label string) (map[string]Result, error) {
+	root := filepath.Join(projectDir, Dir, runsDir, label)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("no recorded run %q in %s", label, filepath.Join(projectDir, Dir))
+	}
+
+	results := map[string]Result{}
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var result Result
+		if err := json.Unmarshal(data, &result); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		results[result.Function+"/"+result.Name] = result
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run %s: %w", label, err)
+	}
+	return results, nil
+}
+
+// defaultLabel names a run after the image the deployment is running, so
+// runs of different builds are kept apart.
+func defaultLabel(client api.OrchestratorAPI, opts RunOptions) string {
+	if opts.LocalURL != "" {
+		return LocalLabel
+	}
+	if d, err := client.GetDeployment(opts.DeploymentID); err == nil && d != nil && d.ImageURL != "" {
+		image := d.ImageURL[strings.LastIndex(d.ImageURL, "/")+1:]
+		if name, tag, ok := strings.Cut(image, ":"); ok && tag != "latest" {
+			image = tag
+		} else {
+			image = name
+		}
+		return image
+	}
+	return time.Now().UTC().Format("20060102-150405")
+}
+
+func sanitizeLabel(label string) string {
+	return strings.Trim(labelPattern.ReplaceAllString(label, "-"), "-.")
+}
+
+// newLocalClient creates a client for a local dev stack, which usually
+// doesn't need the profile's token but is sent it when there is one.
+func newLocalClient(ref config.ProfileRef, transport http.RoundTripper, url string) *api.Client {
+	token := ""
+	if profileCfg, err := config.LoadProfileConfig(ref); err == nil && profileCfg.Config != nil {
+		token = profileCfg.Config.Token
+	}
+	return api.NewClient(strings.TrimSuffix(url, "/"), token, transport)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
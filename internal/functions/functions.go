@@ -0,0 +1,89 @@
+// Package functions takes individual worker functions of a deployment out of
+// rotation and puts them back.
+package functions
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+)
+
+// Options contains the options for enabling or disabling a function.
+type Options struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Function     string
+	Enabled      bool
+}
+
+// SetEnabled enables or disables one function of a deployment. Disabled
+// functions refuse invocations while the deployment's other functions keep
+// serving; nothing is redeployed.
+func SetEnabled(opts Options) (err error) {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	command := "functions disable"
+	if opts.Enabled {
+		command = "functions enable"
+	}
+	ids := map[string]string{"deployment_id": opts.DeploymentID, "function": opts.Function}
+	recorder := history.Start(opts.Profile, command)
+	defer func() { recorder.Finish(ids, err) }()
+
+	return setEnabled(os.Stdout, client, opts)
+}
+
+func setEnabled(w io.Writer, client api.OrchestratorAPI, opts Options) error {
+	d, err := client.GetDeployment(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	i := slices.IndexFunc(d.FunctionRequirements, func(f api.FunctionRequirement) bool { return f.Name == opts.Function })
+	if i < 0 {
+		return fmt.Errorf("function '%s' not found on deployment '%s'", opts.Function, opts.DeploymentID)
+	}
+
+	state, done := "disabled", "Disabled"
+	if opts.Enabled {
+		state, done = "enabled", "Enabled"
+	}
+	if d.FunctionRequirements[i].Disabled != opts.Enabled {
+		fmt.Fprintf(w, "%s on %s is already %s\n", opts.Function, opts.DeploymentID, state)
+		return nil
+	}
+
+	d, err = client.SetFunctionEnabled(opts.DeploymentID, opts.Function, opts.Enabled)
+	if err != nil {
+		return fmt.Errorf("failed to update function: %w", err)
+	}
+
+	serving := 0
+	for _, f := range d.FunctionRequirements {
+		if !f.Disabled {
+			serving++
+		}
+	}
+	fmt.Fprintf(w, "%s %s on %s (%d of %d %s serving)\n", done, opts.Function, opts.DeploymentID,
+		serving, len(d.FunctionRequirements), plural(len(d.FunctionRequirements), "function", "functions"))
+	if serving == 0 {
+		fmt.Fprintf(w, "Warning: every function of %s is disabled; it will refuse all invocations\n", opts.DeploymentID)
+	}
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,74 @@
+package functions
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestDisableAndEnable(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewClient(ts.URL, "token", nil)
+
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID:                   "my-model",
+		ImageURL:             "registry.example/my-model:1",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate", RequiresGPU: true}, {Name: "upscale"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	opts := Options{DeploymentID: "my-model", Function: "upscale"}
+	if err := setEnabled(&out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Disabled upscale on my-model (1 of 2 functions serving)") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	// The disabled function refuses invocations; its sibling keeps serving
+	if _, err := client.Invoke("my-model", "upscale", nil); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("invoke upscale: err = %v, want disabled", err)
+	}
+	if _, err := client.Invoke("my-model", "generate", nil); err != nil {
+		t.Errorf("invoke generate: %v", err)
+	}
+
+	// A redeploy replacing the function list keeps it disabled
+	if _, err := client.UpdateDeployment("my-model", &api.UpdateDeploymentRequest{
+		ImageURL:             "registry.example/my-model:2",
+		FunctionRequirements: []api.FunctionRequirement{{Name: "generate", RequiresGPU: true}, {Name: "upscale"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := setEnabled(&out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "already disabled") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	opts.Enabled = true
+	if err := setEnabled(&out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Enabled upscale on my-model (2 of 2 functions serving)") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+	if _, err := client.Invoke("my-model", "upscale", nil); err != nil {
+		t.Errorf("invoke upscale after enabling: %v", err)
+	}
+
+	if err := setEnabled(&out, client, Options{DeploymentID: "my-model", Function: "missing"}); err == nil ||
+		!strings.Contains(err.Error(), "function 'missing' not found") {
+		t.Errorf("err = %v, want function not found", err)
+	}
+}
@@ -0,0 +1,178 @@
+// Package history keeps a local, append-only log of the mutating commands run
+// against each profile (~/.cozy/<name>/<profile>/history.jsonl), so users can
+// review what was done from this machine with `cozyctl activity`.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/redact"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// FileName is the history log inside a profile directory.
+const FileName = "history.jsonl"
+
+// Entry statuses.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Entry is one line of the history log.
+type Entry struct {
+	Time       time.Time         `json:"time"`
+	Command    string            `json:"command"`
+	Args       []string          `json:"args,omitempty"`
+	Status     string            `json:"status"`
+	Error      string            `json:"error,omitempty"`
+	DurationMS int64             `json:"duration_ms"`
+	IDs        map[string]string `json:"ids,omitempty"` // Result identifiers (build_id, deployment_id, ...)
+	Stages     []Stage           `json:"stages,omitempty"`
+}
+
+// Stage is how long one step of a command took, as shown in its progress.
+type Stage struct {
+	Name       string `json:"name"`
+	DurationMS int64  `json:"duration_ms"`
+	Failed     bool   `json:"failed,omitempty"`
+}
+
+// Path returns the history log of a profile.
+func Path(name, profile string) (string, error) {
+	dir, err := config.ProfileDir(name, profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Append adds an entry to the history log of the profile selected by ref.
+func Append(ref config.ProfileRef, e Entry) error {
+	ref, err := config.ResolveProfileRef(ref)
+	if err != nil {
+		return err
+	}
+	if !config.ProfileExists(ref.Name, ref.Profile) {
+		return fmt.Errorf("profile '%s/%s' not found", ref.Name, ref.Profile)
+	}
+
+	path, err := Path(ref.Name, ref.Profile)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Read returns the history of the profile selected by ref, oldest first.
+// A profile without history returns no entries.
+func Read(ref config.ProfileRef) ([]Entry, error) {
+	ref, err := config.ResolveProfileRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := Path(ref.Name, ref.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		// Skip lines that can't be parsed (e.g. a write cut short) rather than losing the rest
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// Recorder times a command and appends its outcome to the history log.
+type Recorder struct {
+	ref     config.ProfileRef
+	command string
+	start   time.Time
+}
+
+// Start begins recording command for the profile selected by ref.
+func Start(ref config.ProfileRef, command string) *Recorder {
+	return &Recorder{ref: ref, command: command, start: time.Now()}
+}
+
+// Finish appends the outcome of the command. The command line is taken from
+// os.Args; it and the error are redacted like everything else cozyctl
+// writes. Failing to write history only prints a warning: it must never fail
+// an operation that already happened.
+func (r *Recorder) Finish(ids map[string]string, err error) {
+	r.finish(ids, nil, err)
+}
+
+// FinishProgress appends the outcome of a command that reported its steps
+// on progress, with the IDs it set and how long each finished stage took.
+func (r *Recorder) FinishProgress(progress *ui.Progress, err error) {
+	var stages []Stage
+	for _, s := range progress.Stages() {
+		if !s.Ended() {
+			continue
+		}
+		stages = append(stages, Stage{Name: s.Name, DurationMS: s.Duration.Milliseconds(), Failed: s.Err != nil})
+	}
+	r.finish(progress.IDs(), stages, err)
+}
+
+func (r *Recorder) finish(ids map[string]string, stages []Stage, err error) {
+	e := Entry{
+		Time:       r.start.UTC(),
+		Command:    r.command,
+		Args:       redact.Args(os.Args[1:]),
+		Status:     StatusOK,
+		DurationMS: time.Since(r.start).Milliseconds(),
+		IDs:        ids,
+		Stages:     stages,
+	}
+	if err != nil {
+		e.Status = StatusFailed
+		e.Error = redact.String(err.Error())
+	}
+
+	if err := Append(r.ref, e); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record command history: %v\n", err)
+	}
+}
@@ -0,0 +1,136 @@
+package history
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func setupProfile(t *testing.T) config.ProfileRef {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	ref := config.ProfileRef{Name: "work", Profile: "dev"}
+	if err := config.SaveProfileConfig(ref.Name, ref.Profile, &config.ProfileConfig{
+		CurrentName:    ref.Name,
+		CurrentProfile: ref.Profile,
+		Config:         &config.ConfigData{Token: "secret"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func TestRecorderAppendsEntries(t *testing.T) {
+	ref := setupProfile(t)
+
+	Start(ref, "deploy").Finish(map[string]string{"build_id": "b-1"}, nil)
+	Start(ref, "update").Finish(nil, errors.New("boom"))
+
+	entries, err := Read(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Command != "deploy" || e.Status != StatusOK || e.IDs["build_id"] != "b-1" {
+		t.Errorf("unexpected first entry: %+v", e)
+	}
+	if e := entries[1]; e.Command != "update" || e.Status != StatusFailed || e.Error != "boom" {
+		t.Errorf("unexpected second entry: %+v", e)
+	}
+}
+
+func TestFinishProgressRecordsStages(t *testing.T) {
+	ref := setupProfile(t)
+
+	progress := ui.NewWithMode(io.Discard, ui.ModePlain)
+	progress.Start("Packaging & uploading").Done()
+	progress.SetID("build_id", "b-1")
+	err := progress.Start("Building").Fail(errors.New("build failed"))
+	progress.Start("Never finished")
+	Start(ref, "build").FinishProgress(progress, err)
+	progress.Close()
+
+	entries, err := Read(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.IDs["build_id"] != "b-1" || e.Status != StatusFailed {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if len(e.Stages) != 2 || e.Stages[0].Name != "Packaging & uploading" || e.Stages[0].Failed || !e.Stages[1].Failed {
+		t.Errorf("unexpected stages: %+v", e.Stages)
+	}
+}
+
+func TestReadSkipsCorruptLines(t *testing.T) {
+	ref := setupProfile(t)
+	Start(ref, "deploy").Finish(nil, nil)
+
+	path, err := Path(ref.Name, ref.Profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{\"time\":\n")
+	f.Close()
+	Start(ref, "build").Finish(nil, nil)
+
+	entries, err := Read(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Command != "build" {
+		t.Errorf("expected corrupt line to be skipped, got %+v", entries)
+	}
+}
+
+func TestAppendRequiresProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ref := config.ProfileRef{Name: "missing", Profile: "dev"}
+
+	if err := Append(ref, Entry{Command: "deploy"}); err == nil {
+		t.Fatal("expected an error for a missing profile")
+	}
+	dir, _ := config.ProfileDir(ref.Name, ref.Profile)
+	if _, err := os.Stat(filepath.Join(dir, FileName)); !os.IsNotExist(err) {
+		t.Error("history written for a missing profile")
+	}
+}
+
+func TestFinishRedactsCredentials(t *testing.T) {
+	ref := setupProfile(t)
+	args := os.Args
+	os.Args = []string{"cozyctl", "invoke", "my-model", "generate", "--data", `{"token": "hf_abcdefgh1234"}`, "--api-key", "sk_live_abcdefgh1234"}
+	t.Cleanup(func() { os.Args = args })
+
+	Start(ref, "invoke").Finish(nil, errors.New("server said: Authorization: Bearer abcdefgh12345678"))
+
+	entries, err := Read(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := strings.Join(entries[0].Args, " ") + " " + entries[0].Error
+	for _, secret := range []string{"hf_abcdefgh1234", "sk_live_abcdefgh1234", "abcdefgh12345678"} {
+		if strings.Contains(line, secret) {
+			t.Errorf("history entry contains %q: %+v", secret, entries[0])
+		}
+	}
+	if entries[0].Args[0] != "invoke" || entries[0].Args[5] != "--api-key" {
+		t.Errorf("unexpected args: %q", entries[0].Args)
+	}
+}
@@ -0,0 +1,74 @@
+// Package hooks runs the commands a project configures under
+// [tool.cozy.hooks], such as org policy checks before a deploy.
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// PreDeploy runs after the image is built and before anything is deployed.
+// A non-zero exit aborts the deploy.
+const PreDeploy = "pre-deploy"
+
+// Context describes the deploy a hook runs for. It is passed to the hook
+// as COZY_* environment variables; empty fields are left unset.
+type Context struct {
+	ProjectDir   string   // COZY_PROJECT_DIR, also the hook's working directory
+	DeploymentID string   // COZY_DEPLOYMENT_ID
+	Image        string   // COZY_IMAGE: the image the deployment will run
+	LocalImage   string   // COZY_LOCAL_IMAGE: the local Docker tag (local builds)
+	BuildID      string   // COZY_BUILD_ID: the server-side build (server builds)
+	Functions    []string // COZY_FUNCTIONS, comma-separated
+}
+
+// Env returns the environment variables describing the context.
+func (c Context) Env(hook string) []string {
+	env := []string{"COZY_HOOK=" + hook}
+	for _, v := range []struct{ name, value string }{
+		{"COZY_PROJECT_DIR", c.ProjectDir},
+		{"COZY_DEPLOYMENT_ID", c.DeploymentID},
+		{"COZY_IMAGE", c.Image},
+		{"COZY_LOCAL_IMAGE", c.LocalImage},
+		{"COZY_BUILD_ID", c.BuildID},
+		{"COZY_FUNCTIONS", strings.Join(c.Functions, ",")},
+	} {
+		if v.value != "" {
+			env = append(env, v.name+"="+v.value)
+		}
+	}
+	return env
+}
+
+// Run runs command through the shell in the project directory, with the
+// context in its environment and its output written to out. A non-zero
+// exit is returned as an error.
+func Run(ctx context.Context, out io.Writer, hook, command string, hc Context) error {
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = hc.ProjectDir
+	cmd.Env = append(os.Environ(), hc.Env(hook)...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("%s hook %q exited with status %d", hook, command, exitErr.ExitCode())
+		}
+		return fmt.Errorf("failed to run %s hook %q: %w", hook, command, err)
+	}
+	return nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
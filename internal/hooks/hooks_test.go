@@ -0,0 +1,63 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunPassesContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts use sh")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$COZY_HOOK $COZY_DEPLOYMENT_ID $COZY_IMAGE $COZY_FUNCTIONS $(pwd)\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "check.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := Run(context.Background(), &out, PreDeploy, "./check.sh", Context{
+		ProjectDir:   dir,
+		DeploymentID: "my-model",
+		Image:        "registry.example/my-model:abc",
+		Functions:    []string{"generate", "health"},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v\n%s", err, out.String())
+	}
+	want := "pre-deploy my-model registry.example/my-model:abc generate,health "
+	if !strings.HasPrefix(out.String(), want) {
+		t.Errorf("output = %q, want prefix %q", out.String(), want)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts use sh")
+	}
+
+	var out bytes.Buffer
+	err := Run(context.Background(), &out, PreDeploy, `case "$COZY_IMAGE" in *:latest) echo "no :latest tags"; exit 3;; esac`, Context{
+		ProjectDir: t.TempDir(),
+		Image:      "registry.example/my-model:latest",
+	})
+	if err == nil || !strings.Contains(err.Error(), "exited with status 3") {
+		t.Fatalf("Run error = %v", err)
+	}
+	if !strings.Contains(out.String(), "no :latest tags") {
+		t.Errorf("hook output not forwarded: %q", out.String())
+	}
+}
+
+func TestEnvSkipsEmptyFields(t *testing.T) {
+	env := Context{DeploymentID: "my-model"}.Env(PreDeploy)
+	got := strings.Join(env, " ")
+	if got != "COZY_HOOK=pre-deploy COZY_DEPLOYMENT_ID=my-model" {
+		t.Errorf("Env = %s", got)
+	}
+}
@@ -0,0 +1,189 @@
+// Package httpcache keeps deployment and build GET responses on disk and
+// revalidates them with If-None-Match, so repeated reads of unchanged data
+// (watch loops, completion lookups, dashboard refreshes) get a 304 without
+// a body instead of the full response.
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+)
+
+// DirName is the cache inside the config directory (~/.cozy/cache/http).
+const DirName = "cache/http"
+
+// maxBody is the largest response body that is cached.
+const maxBody = 1 << 20
+
+// cachedSegments are the path segments of cacheable resources; only GETs
+// under one of them are cached.
+var cachedSegments = []string{"deployments", "builds"}
+
+// entry is a cached response.
+type entry struct {
+	URL         string    `json:"url"`
+	ETag        string    `json:"etag"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        []byte    `json:"body"`
+	StoredAt    time.Time `json:"stored_at"`
+}
+
+// Transport is an http.RoundTripper that answers deployment and build GETs
+// from Dir when the server confirms, with a 304, that the cached ETag is
+// still current. Responses without an ETag, and anything that isn't JSON,
+// go through uncached. Entries are keyed by URL and credentials, so
+// profiles never see each other's responses.
+type Transport struct {
+	Base http.RoundTripper
+	Dir  string
+}
+
+// New returns a Transport caching in ~/.cozy/cache/http in front of base.
+func New(base http.RoundTripper) (*Transport, error) {
+	dir, err := config.BaseDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Transport{Base: base, Dir: filepath.Join(dir, filepath.FromSlash(DirName))}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !cacheable(req.URL.Path) || req.Header.Get("If-None-Match") != "" {
+		return t.Base.RoundTrip(req)
+	}
+
+	path := t.path(req)
+	cached := t.load(path, req.URL.String())
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return cached.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		if cached != nil {
+			os.Remove(path)
+		}
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxBody {
+		// Too large to keep; hand back the whole body unread
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.store(path, &entry{
+		URL:         req.URL.String(),
+		ETag:        etag,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+		StoredAt:    time.Now().UTC(),
+	})
+	return resp, nil
+}
+
+// cacheable reports whether GETs of path are cached.
+func cacheable(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if slices.Contains(cachedSegments, segment) {
+			return true
+		}
+	}
+	return false
+}
+
+// path returns the file of req's entry, named by a hash of the URL and the
+// credentials so tokens never reach the disk.
+func (t *Transport) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Authorization")))
+	return filepath.Join(t.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the entry at path for url, or nil if there is none.
+func (t *Transport) load(path, url string) *entry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var e entry
+	if json.Unmarshal(data, &e) != nil || e.URL != url || e.ETag == "" {
+		return nil
+	}
+	return &e
+}
+
+// store writes e to path. The cache is best effort: failures are ignored.
+func (t *Transport) store(path string, e *entry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(t.Dir, 0700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(t.Dir, ".entry-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// response rebuilds the cached response to req.
+func (e *entry) response(req *http.Request) *http.Response {
+	header := http.Header{}
+	header.Set("ETag", e.ETag)
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	header.Set("X-Cozy-Cache", "hit")
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,74 @@
+package httpcache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	version, full := 1, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version)
+		if r.URL.Path == "/v1/deployments/my-model" {
+			w.Header().Set("ETag", etag)
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"version":%d}`, version)
+	}))
+	t.Cleanup(ts.Close)
+
+	transport := &Transport{Base: http.DefaultTransport, Dir: t.TempDir()}
+	client := &http.Client{Transport: transport}
+	get := func(path, token string) (string, bool) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", ts.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: status %d", path, resp.StatusCode)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body), resp.Header.Get("X-Cozy-Cache") == "hit"
+	}
+
+	if body, hit := get("/v1/deployments/my-model", "a"); hit || body != `{"version":1}` {
+		t.Errorf("first GET: %s (hit %v)", body, hit)
+	}
+	if body, hit := get("/v1/deployments/my-model", "a"); !hit || body != `{"version":1}` {
+		t.Errorf("unchanged GET: %s (hit %v), want the cached body", body, hit)
+	}
+	if full != 1 {
+		t.Errorf("server sent %d full responses, want 1", full)
+	}
+
+	// Another token never gets the first one's entry
+	if _, hit := get("/v1/deployments/my-model", "b"); hit {
+		t.Error("GET with another token was answered from the cache")
+	}
+
+	version = 2
+	if body, hit := get("/v1/deployments/my-model", "a"); hit || body != `{"version":2}` {
+		t.Errorf("changed GET: %s (hit %v), want the new body", body, hit)
+	}
+
+	// Responses without an ETag, and other resources, aren't kept
+	get("/v1/deployments/other", "a")
+	get("/v1/me", "a")
+	entries, _ := os.ReadDir(transport.Dir)
+	if len(entries) != 2 {
+		t.Errorf("cache has %d entries, want 2 (one per token)", len(entries))
+	}
+}
@@ -0,0 +1,296 @@
+// Package httprecord captures HTTP interactions to a session file and replays
+// them offline. Credentials are redacted before anything is written, so
+// recordings can be attached to bug reports and used as test fixtures.
+package httprecord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/cozy-creator/cozyctl/internal/redact"
+	"go.yaml.in/yaml/v3"
+)
+
+// Redacted replaces sensitive header and body values in recordings.
+const Redacted = redact.Redacted
+
+// Session is the on-disk format of a recording.
+type Session struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is a single recorded request and its response.
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+// Request is a recorded HTTP request.
+type Request struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response.
+type Response struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that forwards requests to Base and appends
+// each interaction to a session file. The file is rewritten after every
+// interaction, so the recording survives a failing or interrupted command.
+type Recorder struct {
+	Base http.RoundTripper
+
+	path    string
+	mu      sync.Mutex
+	session Session
+}
+
+// NewRecorder creates a Recorder writing to path. A nil base uses http.DefaultTransport.
+func NewRecorder(path string, base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{Base: base, path: path}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := Request{
+		Method:  req.Method,
+		URL:     redact.String(req.URL.String()),
+		Headers: recordHeaders(req.Header),
+	}
+
+	// Buffer text bodies for the recording; stream anything else (tarballs) and only count it
+	var counter *countingReader
+	if req.Body != nil {
+		if isText(req.Header.Get("Content-Type")) {
+			body, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			recorded.Body = redact.Body(body)
+		} else {
+			counter = &countingReader{ReadCloser: req.Body}
+			req.Body = counter
+		}
+	}
+
+	resp, err := r.Base.RoundTrip(req)
+	if counter != nil {
+		recorded.Body = fmt.Sprintf("<%d bytes of %s>", counter.n, req.Header.Get("Content-Type"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.session.Interactions = append(r.session.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: recordHeaders(resp.Header),
+			Body:    responseBody(req, body),
+		},
+	})
+	if err := r.save(); err != nil {
+		return nil, fmt.Errorf("failed to write recording: %w", err)
+	}
+
+	return resp, nil
+}
+
+// keyCreationPath answers with a new API key's secret in its "key" field,
+// which elsewhere is an ordinary name (a config entry, a model reference).
+const keyCreationPath = "/api/v1/auth/keys"
+
+// responseBody redacts a response body for the recording, including the
+// secret of a newly created API key.
+func responseBody(req *http.Request, body []byte) string {
+	if req.Method == http.MethodPost && req.URL.Path == keyCreationPath {
+		var created map[string]any
+		if json.Unmarshal(body, &created) == nil && created["key"] != nil {
+			created["key"] = Redacted
+			if redacted, err := json.Marshal(created); err == nil {
+				body = redacted
+			}
+		}
+	}
+	return redact.Body(body)
+}
+
+func (r *Recorder) save() error {
+	data, err := yaml.Marshal(&r.session)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0600)
+}
+
+// Replayer is an http.RoundTripper that answers requests from a recorded
+// session without touching the network.
+//
+// Requests are matched to unused interactions in recorded order by method and
+// path (scheme, host, and query are ignored, so a replay works against any
+// profile). If no path matches exactly, the next interaction with the same
+// method and parent path is used, which covers generated names such as upload
+// paths containing timestamps.
+type Replayer struct {
+	mu      sync.Mutex
+	session Session
+	used    []bool
+}
+
+// LoadReplayer reads a session recorded by a Recorder.
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	var session Session
+	if err := yaml.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %s: %w", path, err)
+	}
+
+	return &Replayer{session: session, used: make([]bool, len(session.Interactions))}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.match(req.Method, req.URL.Path)
+	if i < 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.Path)
+	}
+	r.used[i] = true
+
+	recorded := r.session.Interactions[i].Response
+	header := http.Header{}
+	for k, v := range recorded.Headers {
+		header.Set(k, v)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the number of recorded interactions not yet replayed.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Replayer) match(method, reqPath string) int {
+	fallback := -1
+	for i, in := range r.session.Interactions {
+		if r.used[i] || in.Request.Method != method {
+			continue
+		}
+		recordedPath := requestPath(in.Request.URL)
+		if recordedPath == reqPath {
+			return i
+		}
+		if fallback < 0 && path.Dir(recordedPath) == path.Dir(reqPath) {
+			fallback = i
+		}
+	}
+	return fallback
+}
+
+// requestPath returns the path of a recorded URL.
+func requestPath(rawURL string) string {
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		rawURL = rawURL[i+3:]
+		if j := strings.Index(rawURL, "/"); j >= 0 {
+			rawURL = rawURL[j:]
+		} else {
+			rawURL = "/"
+		}
+	}
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	return rawURL
+}
+
+// recordHeaders flattens headers for recording, redacting credentials.
+func recordHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if redact.Header(k) {
+			out[k] = Redacted
+			continue
+		}
+		out[k] = redact.String(strings.Join(v, ", "))
+	}
+	return out
+}
+
+func isText(contentType string) bool {
+	return contentType == "" ||
+		strings.Contains(contentType, "json") ||
+		strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "x-www-form-urlencoded")
+}
+
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,103 @@
+package httprecord
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/login":
+			io.WriteString(w, `{"access_token":"secret-token","user":"me"}`)
+		case "/api/v1/auth/keys":
+			io.WriteString(w, `{"id":"key-1","name":"ci","key":"ck_unprefixed_secret"}`)
+		default:
+			io.WriteString(w, `{"path":"`+r.URL.Path+`"}`)
+		}
+	}))
+}
+
+func do(t *testing.T, client *http.Client, method, url, contentType, body string) string {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer sk_live_123")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRecordRedactsAndReplays(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	session := filepath.Join(t.TempDir(), "session.yaml")
+	recording := &http.Client{Transport: NewRecorder(session, nil)}
+
+	do(t, recording, "POST", ts.URL+"/login", "application/json", `{"login":"me","password":"hunter2"}`)
+	do(t, recording, "PUT", ts.URL+"/files/builds/app/1700000000.tar.gz", "application/gzip", "binary-tarball")
+	do(t, recording, "GET", ts.URL+"/builds/b-1?verbose=1", "", "")
+	do(t, recording, "POST", ts.URL+"/api/v1/auth/keys", "application/json", `{"name":"ci"}`)
+	do(t, recording, "GET", ts.URL+"/config", "", "")
+
+	data, err := os.ReadFile(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"sk_live_123", "hunter2", "secret-token", "binary-tarball", "ck_unprefixed_secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("recording contains %q:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "<14 bytes of application/gzip>") {
+		t.Errorf("recording missing binary body summary:\n%s", data)
+	}
+
+	replayer, err := LoadReplayer(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaying := &http.Client{Transport: replayer}
+
+	// Different host, query, and generated upload name still match
+	if got := do(t, replaying, "GET", "http://offline.invalid/builds/b-1", "", ""); got != `{"path":"/builds/b-1"}` {
+		t.Errorf("replayed GET = %s", got)
+	}
+	if got := do(t, replaying, "PUT", "http://offline.invalid/files/builds/app/1800000000.tar.gz", "application/gzip", "other"); !strings.Contains(got, "/files/builds/app/1700000000.tar.gz") {
+		t.Errorf("replayed PUT = %s", got)
+	}
+	if got := do(t, replaying, "POST", "http://offline.invalid/login", "application/json", "{}"); !strings.Contains(got, Redacted) {
+		t.Errorf("replayed login = %s", got)
+	}
+	do(t, replaying, "POST", "http://offline.invalid/api/v1/auth/keys", "application/json", "{}")
+	do(t, replaying, "GET", "http://offline.invalid/config", "", "")
+
+	if n := replayer.Remaining(); n != 0 {
+		t.Errorf("Remaining() = %d, want 0", n)
+	}
+
+	req, _ := http.NewRequest("GET", "http://offline.invalid/builds/b-1", nil)
+	if _, err := replaying.Do(req); err == nil || !strings.Contains(err.Error(), "no recorded response for GET /builds/b-1") {
+		t.Errorf("expected exhausted replay error, got %v", err)
+	}
+}
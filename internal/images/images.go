@@ -0,0 +1,87 @@
+// Package images inspects container images built by cozyctl.
+package images
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// InspectOptions contains the options for inspecting an image.
+type InspectOptions struct {
+	Image  string
+	Pull   bool // Pull the image if it isn't in the local Docker daemon
+	Output ui.Output
+}
+
+// Inspection is the build metadata recorded in an image.
+type Inspection struct {
+	Image string `json:"image"`
+	build.ImageMetadata
+	Labels map[string]string `json:"labels"` // Every label of the image
+}
+
+// docker is the part of *build.DockerBuilder used to read image labels.
+type docker interface {
+	ImageExists(ctx context.Context, imageTag string) bool
+	Pull(ctx context.Context, imageTag string) error
+	ImageLabels(ctx context.Context, imageTag string) (map[string]string, error)
+}
+
+// Inspect prints the build metadata cozyctl recorded in an image's labels.
+func Inspect(opts InspectOptions) error {
+	return inspect(context.Background(), os.Stdout, build.NewDockerBuilder(), opts)
+}
+
+func inspect(ctx context.Context, w io.Writer, d docker, opts InspectOptions) error {
+	if !d.ImageExists(ctx, opts.Image) {
+		if !opts.Pull {
+			return fmt.Errorf("image %s is not in the local Docker daemon (pass --pull to pull it)", opts.Image)
+		}
+		if !opts.Output.Structured() {
+			fmt.Fprintf(w, "Pulling %s...\n", opts.Image)
+		}
+		if err := d.Pull(ctx, opts.Image); err != nil {
+			return err
+		}
+	}
+
+	labels, err := d.ImageLabels(ctx, opts.Image)
+	if err != nil {
+		return err
+	}
+	meta, ok := build.ParseImageLabels(labels)
+	if !ok {
+		return fmt.Errorf("image %s has no cozy build labels (it was not built by cozyctl, or by a version that didn't record them)", opts.Image)
+	}
+
+	if opts.Output.Structured() {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		return ui.WriteStructured(w, opts.Output, Inspection{Image: opts.Image, ImageMetadata: meta, Labels: labels})
+	}
+
+	fmt.Fprintf(w, "Image:          %s\n", opts.Image)
+	fmt.Fprintf(w, "Build ID:       %s\n", orDash(meta.BuildID))
+	fmt.Fprintf(w, "Deployment:     %s\n", orDash(meta.DeploymentID))
+	fmt.Fprintf(w, "Base image:     %s\n", orDash(meta.BaseImage))
+	fmt.Fprintf(w, "Source:         %s\n", orDash(meta.Source))
+	fmt.Fprintf(w, "Revision:       %s\n", orDash(meta.Revision))
+	fmt.Fprintf(w, "Source digest:  %s\n", orDash(meta.SourceDigest))
+	fmt.Fprintf(w, "Functions:      %s\n", orDash(strings.Join(meta.Functions, ", ")))
+	fmt.Fprintf(w, "Built with:     cozyctl %s\n", orDash(meta.CozyctlVersion))
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
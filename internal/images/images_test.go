@@ -0,0 +1,80 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+type fakeDocker struct {
+	images map[string]map[string]string // Labels by image, for images "pulled" on demand
+	local  map[string]bool
+}
+
+func (d *fakeDocker) ImageExists(ctx context.Context, imageTag string) bool {
+	return d.local[imageTag]
+}
+
+func (d *fakeDocker) Pull(ctx context.Context, imageTag string) error {
+	d.local[imageTag] = true
+	return nil
+}
+
+func (d *fakeDocker) ImageLabels(ctx context.Context, imageTag string) (map[string]string, error) {
+	return d.images[imageTag], nil
+}
+
+func TestInspect(t *testing.T) {
+	meta := build.ImageMetadata{
+		BuildID:      "1a2b3c4d",
+		DeploymentID: "my-model",
+		SourceDigest: "sha256:abc",
+		BaseImage:    "python:3.11-slim",
+		Functions:    []string{"generate", "health"},
+	}
+	d := &fakeDocker{
+		images: map[string]map[string]string{
+			"cozy-build-my-model-1a2b3c4d": meta.Labels(),
+			"python:3.11-slim":             {"maintainer": "someone"},
+		},
+		local: map[string]bool{"python:3.11-slim": true},
+	}
+	ctx := context.Background()
+
+	err := inspect(ctx, &bytes.Buffer{}, d, InspectOptions{Image: "cozy-build-my-model-1a2b3c4d"})
+	if err == nil || !strings.Contains(err.Error(), "--pull") {
+		t.Errorf("got %v, want an error suggesting --pull", err)
+	}
+
+	var out bytes.Buffer
+	if err := inspect(ctx, &out, d, InspectOptions{Image: "cozy-build-my-model-1a2b3c4d", Pull: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Pulling", "1a2b3c4d", "sha256:abc", "python:3.11-slim", "generate, health"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := inspect(ctx, &out, d, InspectOptions{Image: "cozy-build-my-model-1a2b3c4d", Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var got Inspection
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.BuildID != meta.BuildID || len(got.Functions) != 2 || got.Labels[build.LabelSourceDigest] != "sha256:abc" {
+		t.Errorf("unexpected inspection: %+v", got)
+	}
+
+	err = inspect(ctx, &bytes.Buffer{}, d, InspectOptions{Image: "python:3.11-slim"})
+	if err == nil || !strings.Contains(err.Error(), "no cozy build labels") {
+		t.Errorf("got %v, want no labels error", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package interrupt stops long-running commands cleanly on Ctrl+C or
+// SIGTERM: the command's context is cancelled, in-flight requests and docker
+// commands are aborted, and the command reports what was left running and
+// how to pick it up again.
+package interrupt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ExitCode is the exit status of an interrupted command, as a shell reports
+// a process killed by SIGINT.
+const ExitCode = 130
+
+// Error reports that a command was interrupted. Hint says what is still
+// running or left behind, and how to resume or inspect it.
+type Error struct {
+	Hint string
+	Err  error // What the interrupted operation returned, if anything
+}
+
+func (e *Error) Error() string {
+	if e.Hint == "" {
+		return "interrupted"
+	}
+	return "interrupted: " + e.Hint
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ExitCode makes cozyctl exit with ExitCode.
+func (e *Error) ExitCode() int { return ExitCode }
+
+// Check returns an *Error with hint wrapping err when err is set and ctx
+// has been cancelled, and err unchanged otherwise. An error that already is
+// an *Error is kept, so the innermost hint wins.
+func Check(ctx context.Context, err error, hint string) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	var interrupted *Error
+	if errors.As(err, &interrupted) {
+		return err
+	}
+	return &Error{Hint: hint, Err: err}
+}
+
+// Sleep waits for d, returning early with ctx's error when it is cancelled.
+func Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// NotifyContext returns a copy of parent that is cancelled on the first
+// SIGINT or SIGTERM. The signals are then released, so a second Ctrl+C
+// kills the process without waiting for cleanup.
+func NotifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+// Transport wraps base so that requests are aborted when ctx is cancelled,
+// including responses whose body is still being read. API clients don't take
+// a context, so this is how a command's cancellation reaches them.
+func Transport(ctx context.Context, base http.RoundTripper) http.RoundTripper {
+	return &transport{ctx: ctx, base: base}
+}
+
+type transport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, err
+	}
+	reqCtx, cancel := context.WithCancel(req.Context())
+	stop := context.AfterFunc(t.ctx, cancel)
+	resp, err := t.base.RoundTrip(req.WithContext(reqCtx))
+	if err != nil {
+		stop()
+		cancel()
+		return nil, err
+	}
+	resp.Body = &body{ReadCloser: resp.Body, release: func() { stop(); cancel() }}
+	return resp, nil
+}
+
+// body releases the request's cancellation once the response is closed.
+type body struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *body) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,63 @@
+package interrupt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransportAbortsOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &http.Client{Transport: Transport(ctx, http.DefaultTransport)}
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := client.Get(ts.URL); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the request to be canceled, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("request was not aborted promptly")
+	}
+
+	if _, err := client.Get(ts.URL); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected requests after cancellation to fail, got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cause := errors.New("docker build failed")
+	if err := Check(context.Background(), cause, "hint"); err != cause {
+		t.Errorf("expected errors to pass through while running, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Check(ctx, nil, "hint"); err != nil {
+		t.Errorf("expected nil to pass through, got %v", err)
+	}
+
+	err := Check(ctx, cause, "the image was not pushed")
+	var interrupted *Error
+	if !errors.As(err, &interrupted) || !errors.Is(err, cause) {
+		t.Fatalf("expected an interrupt error wrapping the cause, got %v", err)
+	}
+	if err.Error() != "interrupted: the image was not pushed" || interrupted.ExitCode() != ExitCode {
+		t.Errorf("unexpected error %q (exit %d)", err, interrupted.ExitCode())
+	}
+	if again := Check(ctx, err, "outer"); again != err {
+		t.Errorf("expected the inner hint to be kept, got %v", again)
+	}
+}
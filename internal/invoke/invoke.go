@@ -0,0 +1,283 @@
+// Package invoke calls deployment functions from the command line, retrying
+// failed attempts under an idempotency key so a retry never runs a function
+// twice.
+package invoke
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/google/uuid"
+)
+
+// DefaultRetryOn is what failed attempts are retried on by default.
+const DefaultRetryOn = "5xx,timeout"
+
+// maxBackoff caps the wait between attempts.
+const maxBackoff = 30 * time.Second
+
+// sleep waits between attempts; tests replace it.
+var sleep = time.Sleep
+
+// RetryPolicy decides which failed attempts are retried.
+type RetryPolicy struct {
+	ServerErrors bool         // Any 5xx response
+	Timeouts     bool         // The request timed out before a response
+	Codes        map[int]bool // Specific response codes, such as 429
+}
+
+// ParseRetryOn parses a comma-separated list of retry conditions: "5xx",
+// "timeout", or specific status codes such as "429".
+func ParseRetryOn(s string) (RetryPolicy, error) {
+	p := RetryPolicy{Codes: map[int]bool{}}
+	for _, cond := range strings.Split(s, ",") {
+		cond = strings.ToLower(strings.TrimSpace(cond))
+		switch cond {
+		case "":
+		case "5xx":
+			p.ServerErrors = true
+		case "timeout":
+			p.Timeouts = true
+		default:
+			code, err := strconv.Atoi(cond)
+			if err != nil || code < 400 || code > 599 {
+				return p, fmt.Errorf("invalid retry condition %q: expected 5xx, timeout, or a 4xx/5xx status code", cond)
+			}
+			p.Codes[code] = true
+		}
+	}
+	return p, nil
+}
+
+// Retryable reports whether an attempt that returned resp and err should be
+// retried.
+func (p RetryPolicy) Retryable(resp *api.InvokeResponse, err error) bool {
+	if err == nil {
+		return false
+	}
+	if resp == nil {
+		var netErr net.Error
+		return p.Timeouts && errors.As(err, &netErr) && netErr.Timeout()
+	}
+	if p.Codes[resp.StatusCode] {
+		return true
+	}
+	return p.ServerErrors && resp.StatusCode >= 500
+}
+
+// Options contains the options for invoking a function.
+type Options struct {
+	Profile        config.ProfileRef
+	Transport      http.RoundTripper
+	DeploymentID   string
+	Function       string
+	Payload        []byte
+	Batch          [][]byte // Invoke once per payload instead of with Payload
+	Retries        int      // Attempts after the first
+	RetryOn        RetryPolicy
+	IdempotencyKey string        // Generated when empty; suffixed with the input index in a batch
+	Timeout        time.Duration // Per attempt; zero uses the client default
+	OutputTemplate string        // Write outputs to files named by this template instead of stdout
+	Manifest       string        // Where to write the manifest (default: next to the outputs)
+	Output         ui.Output
+}
+
+// Result is the outcome of an invocation.
+type Result struct {
+	InvocationID   string          `json:"invocation_id,omitempty"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	StatusCode     int             `json:"status_code,omitempty"`
+	DurationMS     int64           `json:"duration_ms"`
+	Attempts       int             `json:"attempts"`
+	Error          string          `json:"error,omitempty"`
+	Output         json.RawMessage `json:"output,omitempty"`
+}
+
+// Run invokes a function, printing its response to stdout and progress to
+// stderr, or with an output template, writing the outputs of each input
+// to files.
+func Run(opts Options) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return run(os.Stdout, os.Stderr, client, opts)
+}
+
+func run(w, errw io.Writer, client api.OrchestratorAPI, opts Options) error {
+	if opts.OutputTemplate != "" {
+		return runBatch(w, errw, client, opts)
+	}
+	if len(opts.Batch) > 0 {
+		return fmt.Errorf("a batch of inputs needs an output template to name the results")
+	}
+
+	key := opts.IdempotencyKey
+	if key == "" {
+		key = uuid.New().String()
+	}
+	result, resp, err := invoke(errw, client, opts, opts.Payload, key)
+
+	if opts.Output.Structured() {
+		if werr := ui.WriteStructured(w, opts.Output, result); werr != nil {
+			return werr
+		}
+	} else {
+		if err == nil && resp != nil {
+			w.Write(resp.Body)
+			if len(resp.Body) > 0 && resp.Body[len(resp.Body)-1] != '\n' {
+				fmt.Fprintln(w)
+			}
+		}
+		fmt.Fprintf(errw, "Invocation %s (idempotency key %s)\n", summarize(result, resp), key)
+	}
+
+	if err != nil {
+		if result.Attempts > 1 {
+			return fmt.Errorf("invocation failed after %d attempts: %w", result.Attempts, err)
+		}
+		return fmt.Errorf("invocation failed: %w", err)
+	}
+	return nil
+}
+
+// invoke calls the function with a payload, retrying failed attempts the
+// retry policy allows under the same idempotency key.
+func invoke(errw io.Writer, client api.OrchestratorAPI, opts Options, payload []byte, key string) (Result, *api.InvokeResponse, error) {
+	result := Result{IdempotencyKey: key}
+
+	var resp *api.InvokeResponse
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = client.InvokeWithOptions(opts.DeploymentID, opts.Function, payload, api.InvokeOptions{
+			IdempotencyKey: key,
+			Timeout:        opts.Timeout,
+		})
+		result.Attempts = attempt + 1
+		if attempt >= opts.Retries || !opts.RetryOn.Retryable(resp, err) {
+			break
+		}
+		wait := backoff(attempt)
+		fmt.Fprintf(errw, "Attempt %d failed: %v; retrying in %s...\n", attempt+1, err, wait)
+		sleep(wait)
+	}
+
+	if resp != nil {
+		result.InvocationID = resp.InvocationID
+		result.StatusCode = resp.StatusCode
+		result.DurationMS = resp.Duration.Milliseconds()
+		if json.Valid(resp.Body) {
+			result.Output = resp.Body
+		} else if len(resp.Body) > 0 {
+			result.Output, _ = json.Marshal(string(resp.Body))
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, resp, err
+}
+
+// summarize describes the outcome of an invocation in one line.
+func summarize(result Result, resp *api.InvokeResponse) string {
+	summary := fmt.Sprintf("status %d in %s", result.StatusCode, time.Duration(result.DurationMS)*time.Millisecond)
+	if resp == nil {
+		summary = "no response"
+	}
+	if result.InvocationID != "" {
+		summary = result.InvocationID + ": " + summary
+	}
+	if result.Attempts > 1 {
+		summary += fmt.Sprintf(", %d attempts", result.Attempts)
+	}
+	return summary
+}
+
+// backoff is the wait before retrying after a failed attempt, doubling
+// from one second.
+func backoff(attempt int) time.Duration {
+	return min(time.Second<<min(attempt, 5), maxBackoff)
+}
+
+// ReadPayload returns the JSON payload given inline or in a file ("-"
+// reads stdin). With neither, the function is invoked with an empty object.
+func ReadPayload(data, file string, stdin io.Reader) ([]byte, error) {
+	if data != "" && file != "" {
+		return nil, fmt.Errorf("--data and --file cannot be used together")
+	}
+
+	payload := []byte(data)
+	if file == "-" {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payload from stdin: %w", err)
+		}
+		payload = b
+	} else if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payload: %w", err)
+		}
+		payload = b
+	}
+
+	if len(payload) > 0 && !json.Valid(payload) {
+		return nil, fmt.Errorf("payload is not valid JSON")
+	}
+	return payload, nil
+}
+
+// ReadBatch reads a batch of JSON payloads from a file ("-" reads stdin):
+// either a JSON array or one payload per line (JSON Lines).
+func ReadBatch(file string, stdin io.Reader) ([][]byte, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch: %w", err)
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var payloads []json.RawMessage
+		if err := json.Unmarshal(trimmed, &payloads); err != nil {
+			return nil, fmt.Errorf("failed to parse batch: %w", err)
+		}
+		batch := make([][]byte, len(payloads))
+		for i, p := range payloads {
+			batch[i] = p
+		}
+		return batch, nil
+	}
+
+	var batch [][]byte
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, fmt.Errorf("failed to parse batch: line %d is not valid JSON", i+1)
+		}
+		batch = append(batch, line)
+	}
+	if len(batch) == 0 {
+		return nil, fmt.Errorf("batch %s has no inputs", file)
+	}
+	return batch, nil
+}
@@ -0,0 +1,124 @@
+package invoke
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// flaky fails a set number of attempts with a status code before
+// succeeding, recording the idempotency key of each attempt.
+type flaky struct {
+	api.OrchestratorAPI
+	failures int
+	status   int
+	keys     []string
+}
+
+func (f *flaky) InvokeWithOptions(_, _ string, _ []byte, opts api.InvokeOptions) (*api.InvokeResponse, error) {
+	f.keys = append(f.keys, opts.IdempotencyKey)
+	if len(f.keys) <= f.failures {
+		return &api.InvokeResponse{StatusCode: f.status, Body: []byte(`{"error":"busy"}`)}, fmt.Errorf("API error (%d): busy", f.status)
+	}
+	return &api.InvokeResponse{StatusCode: 200, Body: []byte(`{"ok":true}`), InvocationID: "inv-1"}, nil
+}
+
+func noSleep(t *testing.T) *[]time.Duration {
+	var waits []time.Duration
+	old := sleep
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	t.Cleanup(func() { sleep = old })
+	return &waits
+}
+
+func TestParseRetryOn(t *testing.T) {
+	p, err := ParseRetryOn("5xx, timeout,429")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.ServerErrors || !p.Timeouts || !p.Codes[429] {
+		t.Errorf("unexpected policy %+v", p)
+	}
+	for _, bad := range []string{"4xx", "200", "soon"} {
+		if _, err := ParseRetryOn(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestRetriesWithSameIdempotencyKey(t *testing.T) {
+	waits := noSleep(t)
+	client := &flaky{failures: 2, status: 503}
+	policy, _ := ParseRetryOn(DefaultRetryOn)
+
+	var out, errOut bytes.Buffer
+	if err := run(&out, &errOut, client, Options{Retries: 3, RetryOn: policy}); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.keys) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(client.keys))
+	}
+	if client.keys[0] == "" || client.keys[1] != client.keys[0] || client.keys[2] != client.keys[0] {
+		t.Errorf("expected one idempotency key across attempts, got %v", client.keys)
+	}
+	if fmt.Sprint(*waits) != "[1s 2s]" {
+		t.Errorf("unexpected backoff %v", *waits)
+	}
+	if out.String() != "{\"ok\":true}\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "inv-1: status 200") || !strings.Contains(errOut.String(), "3 attempts") {
+		t.Errorf("unexpected summary:\n%s", errOut.String())
+	}
+}
+
+func TestDoesNotRetryUnlistedFailures(t *testing.T) {
+	noSleep(t)
+	client := &flaky{failures: 5, status: 429}
+	policy, _ := ParseRetryOn(DefaultRetryOn)
+
+	var out, errOut bytes.Buffer
+	err := run(&out, &errOut, client, Options{Retries: 3, RetryOn: policy, IdempotencyKey: "job-1", Output: ui.OutputJSON})
+	if err == nil {
+		t.Fatal("expected the invocation to fail")
+	}
+	if len(client.keys) != 1 || client.keys[0] != "job-1" {
+		t.Errorf("expected a single attempt with key job-1, got %v", client.keys)
+	}
+	if !strings.Contains(out.String(), `"status_code": 429`) || !strings.Contains(out.String(), `"attempts": 1`) {
+		t.Errorf("unexpected result:\n%s", out.String())
+	}
+}
+
+func TestMockServerHonorsIdempotencyKey(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+
+	client := api.NewClient(ts.URL, "token", nil)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-app", Name: "my-app", ImageURL: "img:1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	invoke := func(key, payload string) string {
+		t.Helper()
+		resp, err := client.InvokeWithOptions("my-app", "generate", []byte(payload), api.InvokeOptions{IdempotencyKey: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.InvocationID
+	}
+	first := invoke("k1", `{"n":1}`)
+	if again := invoke("k1", `{"n":1}`); again != first {
+		t.Errorf("expected a repeated key to return invocation %s, got %s", first, again)
+	}
+	if other := invoke("k2", `{"n":1}`); other == first {
+		t.Error("expected a new key to run a new invocation")
+	}
+}
@@ -0,0 +1,254 @@
+package invoke
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/artifacts"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+	"github.com/google/uuid"
+)
+
+// ManifestFile is the name of the manifest written next to templated outputs.
+const ManifestFile = "manifest.json"
+
+var placeholderPattern = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// placeholders are the names an output template can use: the input's
+// position in the batch, the function, deployment and invocation IDs, and
+// the artifact's name, extension and position in the invocation's outputs.
+var placeholders = []string{"index", "function", "deployment", "invocation", "name", "ext", "n"}
+
+// Template names output files, such as "out/{index}_{function}.{ext}".
+type Template string
+
+// ParseTemplate checks that an output template only uses known placeholders.
+func ParseTemplate(s string) (Template, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", fmt.Errorf("output template is empty")
+	}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(s, -1) {
+		if !slices.Contains(placeholders, m[1]) {
+			return "", fmt.Errorf("unknown placeholder {%s} in output template (known: {%s})", m[1], strings.Join(placeholders, "}, {"))
+		}
+	}
+	return Template(s), nil
+}
+
+// Uses reports whether the template contains a placeholder.
+func (t Template) Uses(name string) bool {
+	return strings.Contains(string(t), "{"+name+"}")
+}
+
+// Render fills in the template's placeholders.
+func (t Template) Render(vars map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(string(t), func(m string) string {
+		return vars[m[1:len(m)-1]]
+	})
+}
+
+// Dir is the directory the template writes into, before any placeholder.
+func (t Template) Dir() string {
+	prefix, _, _ := strings.Cut(string(t), "{")
+	return filepath.Dir(prefix)
+}
+
+// Manifest maps each input of a batch to the files its outputs were written to.
+type Manifest struct {
+	DeploymentID string          `json:"deployment_id"`
+	Function     string          `json:"function"`
+	Template     string          `json:"template"`
+	Entries      []ManifestEntry `json:"entries"`
+}
+
+// ManifestEntry is one input of a batch and its outputs.
+type ManifestEntry struct {
+	Index          int             `json:"index"`
+	Input          json.RawMessage `json:"input"`
+	InvocationID   string          `json:"invocation_id,omitempty"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	StatusCode     int             `json:"status_code,omitempty"`
+	DurationMS     int64           `json:"duration_ms"`
+	Attempts       int             `json:"attempts"`
+	Error          string          `json:"error,omitempty"`
+	Outputs        []string        `json:"outputs"`
+}
+
+// runBatch invokes the function once per input and writes each
+// invocation's artifacts (or its response, when it stored none) to files
+// named by the output template, then a manifest of the mapping.
+func runBatch(w, errw io.Writer, client api.OrchestratorAPI, opts Options) error {
+	tmpl, err := ParseTemplate(opts.OutputTemplate)
+	if err != nil {
+		return err
+	}
+	payloads := opts.Batch
+	if len(payloads) == 0 {
+		payloads = [][]byte{opts.Payload}
+	}
+	if len(payloads) > 1 && !tmpl.Uses("index") && !tmpl.Uses("invocation") {
+		return fmt.Errorf("output template %q must use {index} or {invocation} to name the outputs of %d inputs apart", tmpl, len(payloads))
+	}
+
+	manifestPath := opts.Manifest
+	if manifestPath == "" {
+		manifestPath = filepath.Join(tmpl.Dir(), ManifestFile)
+	}
+
+	manifest := Manifest{DeploymentID: opts.DeploymentID, Function: opts.Function, Template: string(tmpl)}
+	written := map[string]bool{manifestPath: true}
+	failed := 0
+	for i, payload := range payloads {
+		key := opts.IdempotencyKey
+		switch {
+		case key == "":
+			key = uuid.New().String()
+		case len(payloads) > 1:
+			key = fmt.Sprintf("%s-%d", key, i)
+		}
+
+		input := json.RawMessage(payload)
+		if len(payload) == 0 {
+			input = json.RawMessage("{}")
+		}
+		result, resp, err := invoke(errw, client, opts, payload, key)
+		entry := ManifestEntry{
+			Index:          i,
+			Input:          input,
+			InvocationID:   result.InvocationID,
+			IdempotencyKey: key,
+			StatusCode:     result.StatusCode,
+			DurationMS:     result.DurationMS,
+			Attempts:       result.Attempts,
+			Error:          result.Error,
+			Outputs:        []string{},
+		}
+		if err == nil {
+			vars := map[string]string{
+				"index":      strconv.Itoa(i),
+				"function":   opts.Function,
+				"deployment": opts.DeploymentID,
+				"invocation": result.InvocationID,
+			}
+			if entry.Outputs, err = saveOutputs(client, tmpl, vars, resp, written); err != nil {
+				entry.Error = err.Error()
+			}
+		}
+		if entry.Error != "" {
+			failed++
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+
+		if !opts.Output.Structured() {
+			status := fmt.Sprintf("%d %s", len(entry.Outputs), plural(len(entry.Outputs), "output", "outputs"))
+			if entry.Error != "" {
+				status = "failed: " + entry.Error
+			}
+			fmt.Fprintf(errw, "[%d/%d] %s: %s\n", i+1, len(payloads), summarize(result, resp), status)
+		}
+	}
+
+	if err := writeManifest(manifestPath, manifest); err != nil {
+		return err
+	}
+
+	if opts.Output.Structured() {
+		if err := ui.WriteStructured(w, opts.Output, manifest); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(w, "Wrote the outputs of %d %s; manifest: %s\n", len(payloads)-failed, plural(len(payloads)-failed, "input", "inputs"), manifestPath)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %s failed (see %s)", failed, len(payloads), plural(len(payloads), "invocation", "invocations"), manifestPath)
+	}
+	return nil
+}
+
+// saveOutputs writes an invocation's artifacts to the paths the template
+// gives them. An invocation without artifacts has its response written
+// instead, as "output".
+func saveOutputs(client api.OrchestratorAPI, tmpl Template, vars map[string]string, resp *api.InvokeResponse, written map[string]bool) ([]string, error) {
+	var stored []api.InvocationArtifact
+	if resp.InvocationID != "" {
+		invocation, err := client.GetInvocation(resp.InvocationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get invocation: %w", err)
+		}
+		stored = invocation.Artifacts
+	}
+
+	var paths []string
+	target := func(n int, name string) (string, error) {
+		ext := strings.TrimPrefix(filepath.Ext(name), ".")
+		vars["name"] = strings.TrimSuffix(name, filepath.Ext(name))
+		vars["ext"] = ext
+		vars["n"] = strconv.Itoa(n)
+		path := filepath.Clean(tmpl.Render(vars))
+		if written[path] {
+			return "", fmt.Errorf("output template names two outputs %s; use {name} or {n} to tell an invocation's outputs apart", path)
+		}
+		written[path] = true
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+		return path, nil
+	}
+
+	if len(stored) == 0 {
+		ext := "json"
+		if !json.Valid(resp.Body) {
+			ext = "txt"
+		}
+		path, err := target(0, "output."+ext)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, resp.Body, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		return []string{path}, nil
+	}
+
+	for n, a := range stored {
+		path, err := target(n, filepath.Base(a.Name))
+		if err != nil {
+			return paths, err
+		}
+		if _, err := artifacts.Save(client, resp.InvocationID, a, path); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func writeManifest(path string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
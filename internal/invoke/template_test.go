@@ -0,0 +1,110 @@
+package invoke
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func mockClient(t *testing.T) *api.Client {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+
+	client := api.NewClient(ts.URL, "token", nil)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-app", Name: "my-app", ImageURL: "img:1"}); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestParseTemplate(t *testing.T) {
+	if _, err := ParseTemplate("out/{index}_{colour}.{ext}"); err == nil || !strings.Contains(err.Error(), "{colour}") {
+		t.Errorf("expected an unknown placeholder error, got %v", err)
+	}
+	tmpl, err := ParseTemplate("out/runs/{index}_{function}.{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tmpl.Render(map[string]string{"index": "3", "function": "generate", "ext": "png"}); got != "out/runs/3_generate.png" {
+		t.Errorf("unexpected render %q", got)
+	}
+	if tmpl.Dir() != filepath.Join("out", "runs") {
+		t.Errorf("unexpected dir %q", tmpl.Dir())
+	}
+}
+
+func TestBatchWritesTemplatedOutputsAndManifest(t *testing.T) {
+	client := mockClient(t)
+	dir := t.TempDir()
+	batch, err := ReadBatch(writeFile(t, dir, "inputs.jsonl", `{"prompt":"a cat","num_images":2}
+{"prompt":"a dog","num_images":1}
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	err = run(&out, &errOut, client, Options{
+		DeploymentID:   "my-app",
+		Function:       "generate",
+		Batch:          batch,
+		OutputTemplate: filepath.Join(dir, "out", "{index}_{function}_{name}.{ext}"),
+	})
+	if err != nil {
+		t.Fatalf("%v\n%s", err, errOut.String())
+	}
+
+	for _, name := range []string{"0_generate_output.json", "0_generate_image-0.png", "0_generate_image-1.png", "1_generate_image-0.png"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out", ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Entries) != 2 || len(manifest.Entries[0].Outputs) != 3 || len(manifest.Entries[1].Outputs) != 2 {
+		t.Fatalf("unexpected manifest:\n%s", data)
+	}
+	if !strings.Contains(string(manifest.Entries[1].Input), "a dog") || manifest.Entries[1].InvocationID == "" {
+		t.Errorf("expected the second entry to map the second input to its invocation:\n%s", data)
+	}
+}
+
+func TestTemplateCollisionFails(t *testing.T) {
+	client := mockClient(t)
+	dir := t.TempDir()
+
+	var out, errOut bytes.Buffer
+	err := run(&out, &errOut, client, Options{
+		DeploymentID:   "my-app",
+		Function:       "generate",
+		Payload:        []byte(`{"num_images":2}`),
+		OutputTemplate: filepath.Join(dir, "{index}.{ext}"),
+	})
+	if err == nil || !strings.Contains(errOut.String(), "use {name} or {n}") {
+		t.Errorf("expected a collision error, got %v:\n%s", err, errOut.String())
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
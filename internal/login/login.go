@@ -9,7 +9,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
-	"syscall"
+	"time"
 
 	"github.com/cozy-creator/cozyctl/internal/config"
 	"golang.org/x/term"
@@ -27,14 +27,27 @@ type AuthResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// ExpiresAt returns when the access token expires as RFC 3339, or "" if the
+// server did not report a lifetime.
+func (a *AuthResponse) ExpiresAt(issued time.Time) string {
+	if a.ExpiresIn <= 0 {
+		return ""
+	}
+	return issued.Add(time.Duration(a.ExpiresIn) * time.Second).UTC().Format(time.RFC3339)
+}
+
 type UserInfo struct {
 	ID       string  `json:"id"`
 	Username string  `json:"username"`
 	Email    *string `json:"email"`
 }
 
-// RunLogin handles the login flow with name and profile
-func RunLogin(apiKey, hubURL, builderURL, tenantID, name, profile string) error {
+// RunLogin handles the login flow with name and profile. Logging into an
+// existing profile only replaces its credentials and tenant, keeping its
+// other settings, unless reset is set. Empty URLs default to the profile's,
+// or for a new profile to the default environment's. Requests go through
+// transport (nil means http.DefaultTransport).
+func RunLogin(apiKey, hubURL, builderURL, orchestratorURL, tenantID, name, profile string, reset bool, transport http.RoundTripper) error {
 	// Get API key from various sources
 	if apiKey == "" {
 		apiKey = os.Getenv("COZY_API_KEY")
@@ -47,6 +60,49 @@ func RunLogin(apiKey, hubURL, builderURL, tenantID, name, profile string) error
 		}
 	}
 
+	name, profile, existing, err := openProfile(name, profile, reset)
+	if err != nil {
+		return err
+	}
+	data := profileData(existing, hubURL, builderURL, orchestratorURL)
+
+	fmt.Println("Authenticating...")
+
+	// Validate the API key with cozy-hub
+	tenant, err := ValidateAPIKey(data.HubURL, apiKey, transport)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	// Use provided tenant ID or the one from validation
+	if tenantID == "" {
+		tenantID = tenant.ID
+	}
+
+	data.TenantID = tenantID
+	data.Token = apiKey
+	data.RefreshToken = ""
+	data.TokenExpiresAt = ""
+	if expires, ok := config.JWTExpiry(apiKey); ok {
+		data.TokenExpiresAt = expires.Format(time.RFC3339)
+	}
+
+	configPath, err := saveProfile(name, profile, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Logged in as %s (tenant: %s)\n", tenant.Name, tenant.ID)
+	printSaved(name, profile, configPath, existing != nil)
+
+	return nil
+}
+
+// openProfile defaults name and profile and returns the settings of the
+// profile a login updates, or nil for a new one. Unless reset, an existing
+// profile is updated in place; with reset, it is replaced after
+// confirmation.
+func openProfile(name, profile string, reset bool) (string, string, *config.ConfigData, error) {
 	// Set defaults for name and profile
 	if name == "" {
 		name = "default"
@@ -55,59 +111,83 @@ func RunLogin(apiKey, hubURL, builderURL, tenantID, name, profile string) error
 		profile = "default"
 	}
 
-	// Check if profile already exists
-	if config.ProfileExists(name, profile) {
+	if !config.ProfileExists(name, profile) {
+		return name, profile, nil, nil
+	}
+
+	if reset {
 		overwrite, err := config.PromptOverwrite(name, profile)
 		if err != nil {
-			return err
+			return "", "", nil, err
 		}
 		if !overwrite {
-			return fmt.Errorf("login cancelled")
+			return "", "", nil, fmt.Errorf("login cancelled")
 		}
+		return name, profile, nil, nil
 	}
 
-	fmt.Println("Authenticating...")
-
-	// Validate the API key with cozy-hub
-	tenant, err := ValidateAPIKey(hubURL, apiKey)
+	profileCfg, err := config.GetProfileConfig(name, profile)
 	if err != nil {
-		return fmt.Errorf("authentication failed: %w", err)
+		return "", "", nil, fmt.Errorf("failed to load profile config: %w", err)
+	}
+	if profileCfg.Config == nil {
+		return name, profile, &config.ConfigData{}, nil
 	}
+	return name, profile, profileCfg.Config, nil
+}
 
-	// Use provided tenant ID or the one from validation
-	if tenantID == "" {
-		tenantID = tenant.ID
+// profileData returns the config a login saves, before credentials are
+// added: a copy of the existing profile's settings, or the defaults for a
+// new profile, with the non-empty URLs given replacing theirs.
+func profileData(existing *config.ConfigData, hubURL, builderURL, orchestratorURL string) *config.ConfigData {
+	data := config.DefaultConfigData()
+	if existing != nil {
+		kept := *existing
+		data = &kept
+	}
+	if hubURL != "" {
+		data.HubURL = hubURL
+	}
+	if builderURL != "" {
+		data.BuilderURL = builderURL
 	}
+	if orchestratorURL != "" {
+		data.OrchestratorURL = orchestratorURL
+	}
+	return data
+}
 
-	// Create profile config
+// saveProfile saves data to name/profile and makes it the current profile,
+// returning the config file's path.
+func saveProfile(name, profile string, data *config.ConfigData) (string, error) {
 	profileCfg := &config.ProfileConfig{
 		CurrentName:    name,
 		CurrentProfile: profile,
-		Config: &config.ConfigData{
-			HubURL:          hubURL,
-			BuilderURL:      builderURL,
-			OrchestratorURL: config.DefaultConfigData().OrchestratorURL,
-			TenantID:        tenantID,
-			Token:           apiKey,
-		},
+		Config:         data,
 	}
 
 	// Save profile config
 	if err := config.SaveProfileConfig(name, profile, profileCfg); err != nil {
-		return fmt.Errorf("failed to save profile config: %w", err)
+		return "", fmt.Errorf("failed to save profile config: %w", err)
 	}
 
 	// Update default pointer to this profile
 	if err := config.SaveDefaultConfig(name, profile); err != nil {
-		return fmt.Errorf("failed to save default config: %w", err)
+		return "", fmt.Errorf("failed to save default config: %w", err)
 	}
 
 	configPath, _ := config.ProfileConfigPath(name, profile)
-	fmt.Printf("Logged in as %s (tenant: %s)\n", tenant.Name, tenant.ID)
-	fmt.Printf("Profile '%s/%s' saved to %s\n", name, profile, configPath)
-	fmt.Printf("Set as current profile\n")
+	return configPath, nil
+}
 
-	return nil
+// printSaved reports where a login saved the profile.
+func printSaved(name, profile, configPath string, updated bool) {
+	if updated {
+		fmt.Printf("Credentials of profile '%s/%s' updated in %s (other settings kept; --reset replaces them)\n", name, profile, configPath)
+	} else {
+		fmt.Printf("Profile '%s/%s' saved to %s\n", name, profile, configPath)
+	}
+	fmt.Printf("Set as current profile\n")
 }
 
 // ImportConfig imports a config file into a profile
@@ -158,8 +238,8 @@ func PromptAPIKey() (string, error) {
 	fmt.Print("API Key: ")
 
 	// Try to read password without echo
-	if term.IsTerminal(int(syscall.Stdin)) {
-		password, err := term.ReadPassword(int(syscall.Stdin))
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		password, err := term.ReadPassword(int(os.Stdin.Fd()))
 		fmt.Println() // newline after hidden input
 		if err != nil {
 			return "", fmt.Errorf("failed to read API key: %w", err)
@@ -176,7 +256,7 @@ func PromptAPIKey() (string, error) {
 	return strings.TrimSpace(key), nil
 }
 
-func ValidateAPIKey(hubURL, apiKey string) (*TenantInfo, error) {
+func ValidateAPIKey(hubURL, apiKey string, transport http.RoundTripper) (*TenantInfo, error) {
 	url := strings.TrimRight(hubURL, "/") + "/api/v1/auth/me"
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -184,7 +264,7 @@ func ValidateAPIKey(hubURL, apiKey string) (*TenantInfo, error) {
 	}
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient(transport).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to %s: %w", hubURL, err)
 	}
@@ -205,8 +285,10 @@ func ValidateAPIKey(hubURL, apiKey string) (*TenantInfo, error) {
 	return &tenant, nil
 }
 
-// RunPasswordLogin handles the email/password login flow
-func RunPasswordLogin(email, password, hubURL, builderURL, tenantID, name, profile string) error {
+// RunPasswordLogin handles the email/password login flow. Like RunLogin,
+// it keeps an existing profile's settings other than credentials unless
+// reset is set.
+func RunPasswordLogin(email, password, hubURL, builderURL, orchestratorURL, tenantID, name, profile string, reset bool, transport http.RoundTripper) error {
 	// Get email/username from user
 	if email == "" {
 		var err error
@@ -235,35 +317,34 @@ func RunPasswordLogin(email, password, hubURL, builderURL, tenantID, name, profi
 		return fmt.Errorf("invalid password: %w", err)
 	}
 
-	// Set defaults for name and profile
-	if name == "" {
-		name = "default"
-	}
-	if profile == "" {
-		profile = "default"
+	name, profile, existing, err := openProfile(name, profile, reset)
+	if err != nil {
+		return err
 	}
 
-	// Check if profile already exists
-	if config.ProfileExists(name, profile) {
-		overwrite, err := config.PromptOverwrite(name, profile)
-		if err != nil {
-			return err
-		}
-		if !overwrite {
-			return fmt.Errorf("login cancelled")
-		}
-	}
+	return completePasswordLogin(email, password, profileData(existing, hubURL, builderURL, orchestratorURL), existing != nil, tenantID, name, profile, transport)
+}
 
+// completePasswordLogin authenticates with AuthKit and saves the tokens to
+// name/profile, making it the current profile.
+func completePasswordLogin(email, password string, data *config.ConfigData, updated bool, tenantID, name, profile string, transport http.RoundTripper) error {
 	fmt.Println("Authenticating...")
 
 	// Authenticate with AuthKit
-	auth, err := PasswordLogin(hubURL, email, password)
+	auth, err := PasswordLogin(data.HubURL, email, password, transport)
 	if err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
+	return saveLogin(auth, data, updated, tenantID, name, profile, transport)
+}
+
+// saveLogin saves AuthKit tokens to name/profile, making it the current
+// profile. data holds the profile's other settings, and updated is set when
+// the profile already existed. The tenant defaults to the signed-in user's ID.
+func saveLogin(auth *AuthResponse, data *config.ConfigData, updated bool, tenantID, name, profile string, transport http.RoundTripper) error {
 	// Get user info to retrieve tenant ID
-	userInfo, err := GetUserInfo(hubURL, auth.AccessToken)
+	userInfo, err := GetUserInfo(data.HubURL, auth.AccessToken, transport)
 	if err != nil {
 		return fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -273,38 +354,22 @@ func RunPasswordLogin(email, password, hubURL, builderURL, tenantID, name, profi
 		tenantID = userInfo.ID
 	}
 
-	// Create profile config
-	profileCfg := &config.ProfileConfig{
-		CurrentName:    name,
-		CurrentProfile: profile,
-		Config: &config.ConfigData{
-			HubURL:          hubURL,
-			BuilderURL:      builderURL,
-			OrchestratorURL: config.DefaultConfigData().OrchestratorURL,
-			TenantID:        tenantID,
-			Token:           auth.AccessToken,
-			RefreshToken:    auth.RefreshToken,
-		},
-	}
+	data.TenantID = tenantID
+	data.Token = auth.AccessToken
+	data.RefreshToken = auth.RefreshToken
+	data.TokenExpiresAt = auth.ExpiresAt(time.Now())
 
-	// Save profile config
-	if err := config.SaveProfileConfig(name, profile, profileCfg); err != nil {
-		return fmt.Errorf("failed to save profile config: %w", err)
-	}
-
-	// Update default pointer to this profile
-	if err := config.SaveDefaultConfig(name, profile); err != nil {
-		return fmt.Errorf("failed to save default config: %w", err)
+	configPath, err := saveProfile(name, profile, data)
+	if err != nil {
+		return err
 	}
 
-	configPath, _ := config.ProfileConfigPath(name, profile)
 	displayName := userInfo.Username
 	if userInfo.Email != nil && *userInfo.Email != "" {
 		displayName = *userInfo.Email
 	}
 	fmt.Printf("Logged in as %s (user: %s)\n", displayName, userInfo.ID)
-	fmt.Printf("Profile '%s/%s' saved to %s\n", name, profile, configPath)
-	fmt.Printf("Set as current profile\n")
+	printSaved(name, profile, configPath, updated)
 
 	return nil
 }
@@ -325,8 +390,8 @@ func PromptPassword() (string, error) {
 	fmt.Print("Password: ")
 
 	// Try to read password without echo
-	if term.IsTerminal(int(syscall.Stdin)) {
-		password, err := term.ReadPassword(int(syscall.Stdin))
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		password, err := term.ReadPassword(int(os.Stdin.Fd()))
 		fmt.Println() // newline after hidden input
 		if err != nil {
 			return "", fmt.Errorf("failed to read password: %w", err)
@@ -400,7 +465,7 @@ func ValidatePassword(password string) error {
 }
 
 // PasswordLogin authenticates with AuthKit using username/password
-func PasswordLogin(hubURL, login, password string) (*AuthResponse, error) {
+func PasswordLogin(hubURL, login, password string, transport http.RoundTripper) (*AuthResponse, error) {
 	url := strings.TrimRight(hubURL, "/") + "/api/v1/auth/password/login"
 
 	payload := map[string]string{
@@ -418,7 +483,7 @@ func PasswordLogin(hubURL, login, password string) (*AuthResponse, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient(transport).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to %s: %w", hubURL, err)
 	}
@@ -453,7 +518,7 @@ func PasswordLogin(hubURL, login, password string) (*AuthResponse, error) {
 }
 
 // GetUserInfo retrieves user information using the access token
-func GetUserInfo(hubURL, accessToken string) (*UserInfo, error) {
+func GetUserInfo(hubURL, accessToken string, transport http.RoundTripper) (*UserInfo, error) {
 	url := strings.TrimRight(hubURL, "/") + "/api/v1/auth/user/me"
 
 	req, err := http.NewRequest("GET", url, nil)
@@ -462,7 +527,7 @@ func GetUserInfo(hubURL, accessToken string) (*UserInfo, error) {
 	}
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient(transport).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to %s: %w", hubURL, err)
 	}
@@ -479,3 +544,9 @@ func GetUserInfo(hubURL, accessToken string) (*UserInfo, error) {
 
 	return &user, nil
 }
+
+// httpClient returns a client sending requests through transport; nil means
+// http.DefaultTransport.
+func httpClient(transport http.RoundTripper) *http.Client {
+	return &http.Client{Transport: transport}
+}
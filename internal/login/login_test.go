@@ -0,0 +1,104 @@
+package login
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/httprecord"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/readonly"
+)
+
+func TestRunLoginKeepsProfileSettings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+
+	if err := config.SaveProfileConfig("work", "dev", &config.ProfileConfig{
+		CurrentName:    "work",
+		CurrentProfile: "dev",
+		Config: &config.ConfigData{
+			HubURL:          ts.URL,
+			BuilderURL:      ts.URL,
+			OrchestratorURL: "http://orchestrator.internal:9000",
+			TenantID:        "old-tenant",
+			Token:           "old-token",
+			RefreshToken:    "old-refresh-token",
+			RegistryPrefix:  "registry.example/team/",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// No URLs given: the profile's hub is used and its settings kept
+	if err := RunLogin("new-api-key", "", "", "", "", "work", "dev", false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.GetProfileConfig("work", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cfg.Config
+	if got.Token != "new-api-key" || got.RefreshToken != "" {
+		t.Errorf("credentials not replaced: token %q, refresh token %q", got.Token, got.RefreshToken)
+	}
+	if got.TenantID == "old-tenant" || got.TenantID == "" {
+		t.Errorf("tenant not refreshed: %q", got.TenantID)
+	}
+	if got.OrchestratorURL != "http://orchestrator.internal:9000" || got.RegistryPrefix != "registry.example/team/" || got.HubURL != ts.URL {
+		t.Errorf("settings not kept: %+v", got)
+	}
+}
+
+func TestRunLoginNewProfileUsesDefaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+
+	if err := RunLogin("new-api-key", ts.URL, "", "", "", "work", "dev", false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.GetProfileConfig("work", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := config.DefaultConfigData()
+	if cfg.Config.HubURL != ts.URL || cfg.Config.BuilderURL != defaults.BuilderURL || cfg.Config.OrchestratorURL != defaults.OrchestratorURL {
+		t.Errorf("urls = %+v, want the given hub and default builder and orchestrator", cfg.Config)
+	}
+}
+
+func TestPasswordLoginRecordsAndReplays(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	session := filepath.Join(t.TempDir(), "session.yaml")
+
+	// Signing in is allowed in read-only mode
+	transport := &readonly.Transport{Base: httprecord.NewRecorder(session, nil), Reason: "--read-only"}
+	auth, err := PasswordLogin(ts.URL, "tester", "password123", transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetUserInfo(ts.URL, auth.AccessToken, transport); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	replayer, err := httprecord.LoadReplayer(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err = PasswordLogin(ts.URL, "tester", "password123", replayer)
+	if err != nil {
+		t.Fatalf("replayed login: %v", err)
+	}
+	if _, err := GetUserInfo(ts.URL, auth.AccessToken, replayer); err != nil {
+		t.Fatalf("replayed user info: %v", err)
+	}
+	if n := replayer.Remaining(); n != 0 {
+		t.Errorf("%d recorded interactions not replayed", n)
+	}
+}
@@ -0,0 +1,109 @@
+package login
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+)
+
+// RunRefresh exchanges the profile's refresh token for a new access token,
+// saves both to the profile, and prints the new expiry. The request goes
+// through transport (nil means http.DefaultTransport).
+func RunRefresh(ref config.ProfileRef, transport http.RoundTripper) error {
+	ref, err := config.ResolveProfileRef(ref)
+	if err != nil {
+		return err
+	}
+
+	profileCfg, err := config.GetProfileConfig(ref.Name, ref.Profile)
+	if err != nil {
+		return err
+	}
+	if profileCfg.Config == nil || profileCfg.Config.Token == "" {
+		return fmt.Errorf("profile '%s/%s' is not logged in (run 'cozyctl login' first)", ref.Name, ref.Profile)
+	}
+	if profileCfg.Config.RefreshToken == "" {
+		return fmt.Errorf("profile '%s/%s' has no refresh token (API key logins do not expire; email/password logins store one)", ref.Name, ref.Profile)
+	}
+
+	hubURL := profileCfg.Config.HubURL
+	if hubURL == "" {
+		hubURL = config.DefaultConfigData().HubURL
+	}
+
+	issued := time.Now()
+	auth, err := RefreshToken(hubURL, profileCfg.Config.RefreshToken, transport)
+	if err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	profileCfg.Config.Token = auth.AccessToken
+	if auth.RefreshToken != "" {
+		// The server may rotate the refresh token
+		profileCfg.Config.RefreshToken = auth.RefreshToken
+	}
+	profileCfg.Config.TokenExpiresAt = auth.ExpiresAt(issued)
+
+	if err := config.SaveProfileConfig(ref.Name, ref.Profile, profileCfg); err != nil {
+		return fmt.Errorf("failed to save profile config: %w", err)
+	}
+
+	fmt.Printf("Refreshed access token for profile '%s/%s'\n", ref.Name, ref.Profile)
+	if auth.ExpiresIn > 0 {
+		fmt.Printf("Expires at %s (in %s)\n", profileCfg.Config.TokenExpiresAt, time.Duration(auth.ExpiresIn)*time.Second)
+	} else {
+		fmt.Println("The server did not report an expiry")
+	}
+
+	return nil
+}
+
+// RefreshToken exchanges a refresh token for a new access token with AuthKit
+func RefreshToken(hubURL, refreshToken string, transport http.RoundTripper) (*AuthResponse, error) {
+	url := strings.TrimRight(hubURL, "/") + "/api/v1/auth/refresh"
+
+	body, err := json.Marshal(map[string]string{"refresh_token": refreshToken})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient(transport).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", hubURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Error != "" {
+			return nil, fmt.Errorf("%s", errResp.Error)
+		}
+		if resp.StatusCode == 401 {
+			return nil, fmt.Errorf("refresh token is invalid or expired (run 'cozyctl login' again)")
+		}
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var auth AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if auth.AccessToken == "" {
+		return nil, fmt.Errorf("response did not include an access token")
+	}
+
+	return &auth, nil
+}
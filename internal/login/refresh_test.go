@@ -0,0 +1,119 @@
+package login
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/httprecord"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestRunRefreshPersistsToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+
+	if err := config.SaveProfileConfig("work", "prod", &config.ProfileConfig{
+		CurrentName:    "work",
+		CurrentProfile: "prod",
+		Config: &config.ConfigData{
+			HubURL:       ts.URL,
+			TenantID:     "t-1",
+			Token:        "old-token",
+			RefreshToken: "mock-refresh-token",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunRefresh(config.ProfileRef{Name: "work", Profile: "prod"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.GetProfileConfig("work", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Config.Token == "old-token" || cfg.Config.Token == "" {
+		t.Errorf("token not replaced: %q", cfg.Config.Token)
+	}
+	if cfg.Config.RefreshToken == "mock-refresh-token" {
+		t.Error("rotated refresh token not saved")
+	}
+	expires, err := time.Parse(time.RFC3339, cfg.Config.TokenExpiresAt)
+	if err != nil {
+		t.Fatalf("token_expires_at = %q: %v", cfg.Config.TokenExpiresAt, err)
+	}
+	if until := time.Until(expires); until < 59*time.Minute || until > time.Hour+time.Minute {
+		t.Errorf("token expires in %s, want about an hour", until)
+	}
+}
+
+func TestRunRefreshReplaysRecording(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(mockserver.New().Handler())
+	session := filepath.Join(t.TempDir(), "session.yaml")
+	ref := config.ProfileRef{Name: "work", Profile: "prod"}
+	save := func() {
+		if err := config.SaveProfileConfig("work", "prod", &config.ProfileConfig{
+			Config: &config.ConfigData{
+				HubURL:       ts.URL,
+				TenantID:     "t-1",
+				Token:        "old-token",
+				RefreshToken: "mock-refresh-token",
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	save()
+	if err := RunRefresh(ref, httprecord.NewRecorder(session, nil)); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	save()
+	replayer, err := httprecord.LoadReplayer(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RunRefresh(ref, replayer); err != nil {
+		t.Fatalf("replayed refresh: %v", err)
+	}
+	cfg, err := config.GetProfileConfig("work", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Config.Token == "old-token" || cfg.Config.Token == "" {
+		t.Errorf("token not replaced: %q", cfg.Config.Token)
+	}
+	if n := replayer.Remaining(); n != 0 {
+		t.Errorf("%d recorded interactions not replayed", n)
+	}
+}
+
+func TestRefreshTokenRejected(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+
+	if _, err := RefreshToken(ts.URL, "stolen", nil); err == nil {
+		t.Error("expected an invalid refresh token to be rejected")
+	}
+}
+
+func TestRunRefreshWithoutRefreshToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := config.SaveProfileConfig("work", "prod", &config.ProfileConfig{
+		Config: &config.ConfigData{TenantID: "t-1", Token: "sk_live_key"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunRefresh(config.ProfileRef{Name: "work", Profile: "prod"}, nil); err == nil {
+		t.Error("expected an error for a profile without a refresh token")
+	}
+}
@@ -0,0 +1,163 @@
+package login
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// maxVerifyAttempts bounds how many wrong verification codes are accepted
+// before signup gives up.
+const maxVerifyAttempts = 3
+
+// SignupOptions contains the options for creating an account.
+type SignupOptions struct {
+	Email           string // Prompted for when empty
+	Username        string // Prompted for when empty
+	Password        string // Prompted for (twice) when empty
+	HubURL          string
+	BuilderURL      string
+	OrchestratorURL string
+	Name            string // Profile to create (default: 'default')
+	Profile         string
+	Transport       http.RoundTripper
+}
+
+// RunSignup registers an account with cozy-hub, verifies its email with the
+// code sent to it, then logs in and saves the first profile.
+func RunSignup(opts SignupOptions) error {
+	return signup(bufio.NewReader(os.Stdin), os.Stdout, opts)
+}
+
+func signup(in *bufio.Reader, out io.Writer, opts SignupOptions) error {
+	// Set defaults for name and profile
+	if opts.Name == "" {
+		opts.Name = "default"
+	}
+	if opts.Profile == "" {
+		opts.Profile = "default"
+	}
+
+	// Ask before registering, so declining doesn't leave an unused account behind
+	if config.ProfileExists(opts.Name, opts.Profile) {
+		overwrite, err := ui.Confirm(in, out, fmt.Sprintf("Profile '%s/%s' already exists. Overwrite?", opts.Name, opts.Profile))
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			return fmt.Errorf("signup cancelled")
+		}
+	}
+
+	if err := promptSignup(in, out, &opts); err != nil {
+		return err
+	}
+
+	client := api.NewAuthClient(opts.HubURL, "", opts.Transport)
+	fmt.Fprintln(out, "Creating account...")
+	registered, err := client.Register(&api.RegisterRequest{
+		Email:    opts.Email,
+		Username: opts.Username,
+		Password: opts.Password,
+	})
+	if err != nil {
+		return fmt.Errorf("signup failed: %w", err)
+	}
+
+	if registered.VerificationRequired {
+		if err := verifyEmail(in, out, client, opts.Email); err != nil {
+			return err
+		}
+	}
+
+	// A new account replaces whatever the profile held
+	data := profileData(nil, opts.HubURL, opts.BuilderURL, opts.OrchestratorURL)
+	return completePasswordLogin(opts.Email, opts.Password, data, false, "", opts.Name, opts.Profile, opts.Transport)
+}
+
+// promptSignup fills in and validates the email, username, and password.
+func promptSignup(in *bufio.Reader, out io.Writer, opts *SignupOptions) error {
+	var err error
+	if opts.Email == "" {
+		if opts.Email, err = ui.Prompt(in, out, "Email: "); err != nil {
+			return err
+		}
+	}
+	if !strings.Contains(opts.Email, "@") {
+		return fmt.Errorf("invalid email: %q", opts.Email)
+	}
+	if err := ValidateIdentifier(opts.Email); err != nil {
+		return fmt.Errorf("invalid email: %w", err)
+	}
+
+	if opts.Username == "" {
+		if opts.Username, err = ui.Prompt(in, out, "Username: "); err != nil {
+			return err
+		}
+	}
+	if strings.ContainsAny(opts.Username, "@+") {
+		return fmt.Errorf("invalid username: only letters, numbers, and underscores are allowed")
+	}
+	if err := ValidateIdentifier(opts.Username); err != nil {
+		return fmt.Errorf("invalid username: %w", err)
+	}
+
+	if opts.Password == "" {
+		if opts.Password, err = ui.PromptSecret(in, out, "Password: "); err != nil {
+			return err
+		}
+		if err := ValidatePassword(opts.Password); err != nil {
+			return fmt.Errorf("invalid password: %w", err)
+		}
+		confirm, err := ui.PromptSecret(in, out, "Confirm password: ")
+		if err != nil {
+			return err
+		}
+		if confirm != opts.Password {
+			return fmt.Errorf("passwords do not match")
+		}
+	}
+	if err := ValidatePassword(opts.Password); err != nil {
+		return fmt.Errorf("invalid password: %w", err)
+	}
+
+	return nil
+}
+
+// verifyEmail prompts for the emailed verification code until it is
+// accepted. An empty entry sends a new code.
+func verifyEmail(in *bufio.Reader, out io.Writer, client *api.AuthClient, email string) error {
+	fmt.Fprintf(out, "A verification code was sent to %s.\n", email)
+
+	for attempts := 0; attempts < maxVerifyAttempts; {
+		code, err := ui.Prompt(in, out, "Verification code (Enter to resend): ")
+		if err != nil {
+			return err
+		}
+
+		if code == "" {
+			if err := client.ResendVerification(email); err != nil {
+				return fmt.Errorf("failed to resend verification code: %w", err)
+			}
+			fmt.Fprintf(out, "Sent a new code to %s.\n", email)
+			continue
+		}
+
+		if err := client.VerifyEmail(email, code); err != nil {
+			attempts++
+			fmt.Fprintf(out, "Verification failed: %v\n", err)
+			continue
+		}
+		fmt.Fprintln(out, "Email verified.")
+		return nil
+	}
+
+	return fmt.Errorf("email not verified after %d attempts; your account was created, so verify it from the email and then run 'cozyctl login'", maxVerifyAttempts)
+}
@@ -0,0 +1,74 @@
+package login
+
+import (
+	"bufio"
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestSignupVerifiesAndCreatesProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+
+	// Wrong code, resend, then the right one
+	input := "new@example.com\nnewuser\nsecret-pass\nsecret-pass\n000000\n\n" + mockserver.VerificationCode + "\n"
+	var out bytes.Buffer
+	err := signup(bufio.NewReader(strings.NewReader(input)), &out, SignupOptions{
+		HubURL:     ts.URL,
+		BuilderURL: ts.URL,
+		Name:       "me",
+		Profile:    "dev",
+	})
+	if err != nil {
+		t.Fatalf("signup: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"Verification failed", "Sent a new code", "Email verified."} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	cfg, err := config.GetProfileConfig("me", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Config.Token == "" || cfg.Config.RefreshToken == "" {
+		t.Errorf("tokens not saved: %+v", cfg.Config)
+	}
+	current, err := config.GetDefaultConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.CurrentName != "me" || current.CurrentProfile != "dev" {
+		t.Errorf("current profile = %s/%s, want me/dev", current.CurrentName, current.CurrentProfile)
+	}
+
+	// The account now exists
+	err = signup(bufio.NewReader(strings.NewReader("")), &out, SignupOptions{
+		Email: "new@example.com", Username: "other", Password: "secret-pass",
+		HubURL: ts.URL, Name: "me", Profile: "other",
+	})
+	if err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("err = %v, want an already registered error", err)
+	}
+}
+
+func TestSignupRejectsBadInputBeforeRegistering(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, opts := range []SignupOptions{
+		{Email: "not-an-email", Username: "newuser", Password: "secret-pass"},
+		{Email: "new@example.com", Username: "1bad", Password: "secret-pass"},
+		{Email: "new@example.com", Username: "newuser", Password: "short"},
+	} {
+		opts.HubURL = "http://127.0.0.1:1" // never contacted
+		if err := signup(bufio.NewReader(strings.NewReader("")), &bytes.Buffer{}, opts); err == nil || strings.Contains(err.Error(), "signup failed") {
+			t.Errorf("%+v: err = %v, want a validation error", opts, err)
+		}
+	}
+}
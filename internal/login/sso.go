@@ -0,0 +1,240 @@
+package login
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ssoClientID identifies cozyctl to the hub's OIDC broker.
+const ssoClientID = "cozyctl"
+
+// DefaultSSOTimeout is how long to wait for the browser sign-in to finish.
+const DefaultSSOTimeout = 5 * time.Minute
+
+// SSOOptions contains the options for logging in through an organization's identity provider.
+type SSOOptions struct {
+	Org             string // Organization slug whose IdP to use
+	HubURL          string
+	BuilderURL      string
+	OrchestratorURL string
+	TenantID        string // Optional; defaults to the user's tenant
+	Name            string
+	Profile         string
+	Reset           bool          // Replace an existing profile's settings instead of only its credentials
+	NoBrowser       bool          // Print the sign-in URL instead of opening a browser
+	Timeout         time.Duration // How long to wait for the sign-in; zero means DefaultSSOTimeout
+	Transport       http.RoundTripper
+}
+
+// RunSSOLogin signs in with the organization's identity provider using the
+// OIDC authorization code flow with PKCE, brokered by cozy-hub, and saves the
+// resulting access and refresh tokens to the profile.
+func RunSSOLogin(opts SSOOptions) error {
+	if opts.Org == "" {
+		return fmt.Errorf("organization slug is required")
+	}
+
+	name, profile, existing, err := openProfile(opts.Name, opts.Profile, opts.Reset)
+	if err != nil {
+		return err
+	}
+	data := profileData(existing, opts.HubURL, opts.BuilderURL, opts.OrchestratorURL)
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultSSOTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	open := openBrowser
+	if opts.NoBrowser {
+		open = nil
+	}
+	auth, err := ssoAuthorize(ctx, os.Stdout, data.HubURL, opts.Org, open, opts.Transport)
+	if err != nil {
+		return fmt.Errorf("SSO login failed: %w", err)
+	}
+
+	return saveLogin(auth, data, existing != nil, opts.TenantID, name, profile, opts.Transport)
+}
+
+// ssoCallback is what the browser redirect delivers to the loopback listener.
+type ssoCallback struct {
+	code string
+	err  error
+}
+
+// ssoAuthorize runs the authorization code flow: it listens on a loopback
+// port for the redirect, sends the user to the hub's authorize endpoint (via
+// open, if non-nil), and exchanges the returned code together with the PKCE
+// verifier for tokens.
+func ssoAuthorize(ctx context.Context, out io.Writer, hubURL, org string, open func(string) error, transport http.RoundTripper) (*AuthResponse, error) {
+	verifier, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the sign-in redirect: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr())
+
+	callbacks := make(chan ssoCallback, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var result ssoCallback
+		switch {
+		case q.Get("state") != state:
+			result.err = fmt.Errorf("sign-in response did not match this login (state mismatch)")
+		case q.Get("error") != "":
+			result.err = fmt.Errorf("identity provider returned %s: %s", q.Get("error"), q.Get("error_description"))
+		case q.Get("code") == "":
+			result.err = fmt.Errorf("sign-in response did not include an authorization code")
+		default:
+			result.code = q.Get("code")
+		}
+
+		if result.err != nil {
+			http.Error(w, "Sign-in failed: "+result.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Signed in to cozyctl. You can close this window.")
+		}
+		select {
+		case callbacks <- result:
+		default: // Only the first redirect counts
+		}
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	base := strings.TrimRight(hubURL, "/") + "/api/v1/auth/sso/" + url.PathEscape(org)
+	authURL := base + "/authorize?" + url.Values{
+		"response_type":         {"code"},
+		"client_id":             {ssoClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid profile email offline_access"},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+
+	if open != nil {
+		fmt.Fprintf(out, "Opening your browser to sign in with %s...\n", org)
+		if err := open(authURL); err != nil {
+			fmt.Fprintf(out, "Could not open a browser (%v).\n", err)
+		}
+		fmt.Fprintf(out, "If the browser does not open, visit:\n  %s\n", authURL)
+	} else {
+		fmt.Fprintf(out, "Visit this URL to sign in with %s:\n  %s\n", org, authURL)
+	}
+	fmt.Fprintln(out, "Waiting for sign-in...")
+
+	var callback ssoCallback
+	select {
+	case callback = <-callbacks:
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timed out waiting for the browser sign-in")
+		}
+		return nil, ctx.Err()
+	}
+	if callback.err != nil {
+		return nil, callback.err
+	}
+
+	return exchangeSSOCode(base+"/token", callback.code, redirectURI, verifier, transport)
+}
+
+// exchangeSSOCode redeems an authorization code for tokens.
+func exchangeSSOCode(tokenURL, code, redirectURI, verifier string, transport http.RoundTripper) (*AuthResponse, error) {
+	body, err := json.Marshal(map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     ssoClientID,
+		"code":          code,
+		"redirect_uri":  redirectURI,
+		"code_verifier": verifier,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", tokenURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient(transport).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Error != "" {
+			return nil, fmt.Errorf("%s", errResp.Error)
+		}
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var auth AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if auth.AccessToken == "" {
+		return nil, fmt.Errorf("response did not include an access token")
+	}
+
+	return &auth, nil
+}
+
+// randomToken returns 32 random bytes, base64url-encoded, for PKCE verifiers and state.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// openBrowser opens url in the user's default browser.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
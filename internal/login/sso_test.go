@@ -0,0 +1,108 @@
+package login
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+// browse follows the sign-in URL the way a browser would.
+func browse(t *testing.T) func(string) error {
+	return func(u string) error {
+		go func() {
+			resp, err := http.Get(u)
+			if err != nil {
+				t.Errorf("browser: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+		return nil
+	}
+}
+
+func TestSSOAuthorize(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var out bytes.Buffer
+	auth, err := ssoAuthorize(ctx, &out, ts.URL, "acme", browse(t), nil)
+	if err != nil {
+		t.Fatalf("ssoAuthorize: %v\n%s", err, out.String())
+	}
+	if auth.AccessToken == "" || auth.RefreshToken == "" || auth.ExpiresIn == 0 {
+		t.Errorf("incomplete tokens: %+v", auth)
+	}
+	if !strings.Contains(out.String(), "/api/v1/auth/sso/acme/authorize?") {
+		t.Errorf("sign-in URL not printed:\n%s", out.String())
+	}
+
+	// The refresh token works with the regular refresh flow
+	if _, err := RefreshToken(ts.URL, auth.RefreshToken, nil); err != nil {
+		t.Errorf("RefreshToken: %v", err)
+	}
+}
+
+func TestSSOAuthorizeRejectsForgedRedirect(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Deliver a code to the callback with the wrong state
+	forge := func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		callback := u.Query().Get("redirect_uri") + "?code=stolen&state=wrong"
+		return browse(t)(callback)
+	}
+
+	_, err := ssoAuthorize(ctx, &bytes.Buffer{}, ts.URL, "acme", forge, nil)
+	if err == nil || !strings.Contains(err.Error(), "state mismatch") {
+		t.Errorf("err = %v, want a state mismatch", err)
+	}
+}
+
+func TestExchangeSSOCodeRequiresVerifier(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+
+	// Get a code for a known challenge without following the redirect
+	redirectURI := "http://127.0.0.1:1/callback"
+	authURL := ts.URL + "/api/v1/auth/sso/acme/authorize?" + url.Values{
+		"response_type":         {"code"},
+		"redirect_uri":          {redirectURI},
+		"state":                 {"s"},
+		"code_challenge":        {"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"}, // RFC 7636 example
+		"code_challenge_method": {"S256"},
+	}.Encode()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := location.Query().Get("code")
+
+	tokenURL := ts.URL + "/api/v1/auth/sso/acme/token"
+	if _, err := exchangeSSOCode(tokenURL, code, redirectURI, "wrong-verifier", nil); err == nil || !strings.Contains(err.Error(), "PKCE") {
+		t.Errorf("err = %v, want a PKCE failure", err)
+	}
+}
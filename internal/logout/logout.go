@@ -108,6 +108,7 @@ func clearProfileToken(name, profile string) error {
 	// Clear the tokens
 	profileCfg.Config.Token = ""
 	profileCfg.Config.RefreshToken = ""
+	profileCfg.Config.TokenExpiresAt = ""
 
 	// Save the updated config
 	if err := config.SaveProfileConfig(name, profile, profileCfg); err != nil {
@@ -0,0 +1,136 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// RequireApproval makes deploys to a deployment wait for approval, as an
+// admin would configure for a production deployment in the dashboard.
+func (s *Server) RequireApproval(deploymentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hub, ok := s.hubDeploys[deploymentID]
+	if !ok {
+		now := time.Now().UTC().Format(time.RFC3339)
+		hub = &api.HubDeployment{ID: deploymentID, TenantID: s.TenantID, Name: deploymentID, CreatedAt: now, UpdatedAt: now}
+		s.hubDeploys[deploymentID] = hub
+	}
+	hub.RequiresApproval = true
+}
+
+// mockApproval is a deploy held for approval, with the cause of the
+// revision approving it adds.
+type mockApproval struct {
+	api.Approval
+	cause string
+}
+
+// requestApproval holds a deploy of a build for approval. Callers must hold
+// s.mu.
+func (s *Server) requestApproval(r *http.Request, deploymentID, buildID, cause string) *api.Approval {
+	id := s.newID("approval")
+	approval := &mockApproval{
+		Approval: api.Approval{
+			ID:           id,
+			DeploymentID: deploymentID,
+			BuildID:      buildID,
+			Status:       api.ApprovalPending,
+			URL:          fmt.Sprintf("http://%s/approvals/%s", r.Host, id),
+			RequestedBy:  s.caller(r),
+			CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+		},
+		cause: cause,
+	}
+	s.approvals[id] = approval
+	return &approval.Approval
+}
+
+func (s *Server) handleListApprovals(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approvals := []api.Approval{}
+	for _, a := range s.approvals {
+		if status == "" || a.Status == status {
+			approvals = append(approvals, a.Approval)
+		}
+	}
+	// IDs are sequential, so the newest has the greatest
+	slices.SortFunc(approvals, func(a, b api.Approval) int {
+		return strings.Compare(b.ID, a.ID)
+	})
+	writeJSON(w, http.StatusOK, api.ListApprovalsResponse{Approvals: approvals})
+}
+
+func (s *Server) handleGetApproval(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approval, ok := s.approvals[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "approval not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, approval.Approval)
+}
+
+// handleDecideApproval approves or rejects a pending deploy. Approving it
+// deploys the build, so it is refused like the deploy itself while the
+// deployment is locked.
+func (s *Server) handleDecideApproval(approve bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req api.DecideApprovalRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		approval, ok := s.approvals[r.PathValue("id")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "approval not found")
+			return
+		}
+		if approval.Status != api.ApprovalPending {
+			writeError(w, http.StatusConflict, fmt.Sprintf("approval %s was already %s", approval.ID, approval.Status))
+			return
+		}
+
+		if approve {
+			b, ok := s.builds[approval.BuildID]
+			if !ok {
+				writeError(w, http.StatusConflict, fmt.Sprintf("build %s no longer exists", approval.BuildID))
+				return
+			}
+			if !s.checkLock(w, r, s.deployments[approval.DeploymentID]) {
+				return
+			}
+			s.activateBuild(approval.DeploymentID, b, api.Revision{
+				Cause:      approval.cause,
+				DeployedBy: approval.RequestedBy,
+				ApprovalID: approval.ID,
+			})
+			approval.Status = api.ApprovalApproved
+		} else {
+			approval.Status = api.ApprovalRejected
+		}
+		approval.Comment = req.Comment
+		approval.DecidedAt = time.Now().UTC().Format(time.RFC3339)
+		approval.DecidedBy = s.caller(r)
+		writeJSON(w, http.StatusOK, approval.Approval)
+	}
+}
@@ -0,0 +1,143 @@
+package mockserver
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// advance brings a build up to date with the build queue and returns it.
+// Callers must hold s.mu.
+func (s *Server) advance(b *mockBuild) api.Build {
+	s.schedule(time.Now().UTC())
+	return b.Build
+}
+
+// schedule moves the builds along: running builds succeed once
+// BuildDuration has elapsed, and queued builds start, highest priority and
+// then oldest first, as BuildSlots free up. Builds still queued get their
+// queue position and an estimated start. Callers must hold s.mu.
+func (s *Server) schedule(now time.Time) {
+	for {
+		// Finish running builds, freeing their slots at the time they ended
+		var freed []time.Time
+		running := 0
+		for _, b := range s.builds {
+			if b.Status != "running" {
+				continue
+			}
+			if end := b.started.Add(s.BuildDuration); !now.Before(end) {
+				s.finish(b, end)
+				freed = append(freed, end)
+			} else {
+				running++
+			}
+		}
+
+		queued := s.queuedBuilds()
+		started := false
+		for _, b := range queued {
+			if s.BuildSlots > 0 && running >= s.BuildSlots {
+				break
+			}
+			// Without a slot limit builds start as soon as they are created;
+			// otherwise when the earliest freed slot opened up
+			at := b.created
+			if s.BuildSlots > 0 {
+				at = now
+				if len(freed) > 0 {
+					at = slices.MinFunc(freed, func(a, b time.Time) int { return a.Compare(b) })
+					freed = slices.DeleteFunc(freed, func(t time.Time) bool { return t.Equal(at) })
+					at = later(at, b.created)
+				}
+			}
+			s.start(b, at)
+			running++
+			started = true
+		}
+		if !started {
+			break
+		}
+	}
+
+	// Estimate when each queued build starts from when the running builds end
+	queued := s.queuedBuilds()
+	if len(queued) == 0 || s.BuildSlots == 0 {
+		return
+	}
+	var slots []time.Time
+	for _, b := range s.builds {
+		if b.Status == "running" {
+			slots = append(slots, b.started.Add(s.BuildDuration))
+		}
+	}
+	for len(slots) < s.BuildSlots {
+		slots = append(slots, now)
+	}
+	for i, b := range queued {
+		slices.SortFunc(slots, func(a, b time.Time) int { return a.Compare(b) })
+		start := slots[0].Format(time.RFC3339)
+		b.QueuePosition = i + 1
+		b.EstimatedStartAt = &start
+		slots[0] = slots[0].Add(s.BuildDuration)
+	}
+}
+
+// queuedBuilds returns the queued builds in the order they will start.
+// Callers must hold s.mu.
+func (s *Server) queuedBuilds() []*mockBuild {
+	var queued []*mockBuild
+	for _, b := range s.builds {
+		if b.Status == "queued" {
+			queued = append(queued, b)
+		}
+	}
+	slices.SortFunc(queued, func(a, b *mockBuild) int {
+		return cmp.Or(
+			cmp.Compare(slices.Index(api.BuildPriorities, a.Priority), slices.Index(api.BuildPriorities, b.Priority)),
+			a.created.Compare(b.created),
+			strings.Compare(a.ID, b.ID),
+		)
+	})
+	return queued
+}
+
+func (s *Server) start(b *mockBuild, at time.Time) {
+	started := at.Format(time.RFC3339)
+	b.Status = "running"
+	b.started = at
+	b.StartedAt = &started
+	b.UpdatedAt = started
+	b.QueuePosition = 0
+	b.EstimatedStartAt = nil
+}
+
+func (s *Server) finish(b *mockBuild, at time.Time) {
+	finished := at.Format(time.RFC3339)
+	b.Status = "success"
+	b.ImageTag = fmt.Sprintf("registry.mock/%s/%s:%s", s.TenantID, b.DeploymentID, b.ID)
+	b.ImageDigest = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(b.ImageTag)))
+	b.BuilderID = MockBuilderID
+	b.FinishedAt = &finished
+	b.UpdatedAt = finished
+	s.storeFile(buildLogPath(b.ID), mockBuildLogSize)
+}
+
+// mockBuildLogSize is the size of the log stored for each finished build.
+const mockBuildLogSize = 4096
+
+func buildLogPath(buildID string) string {
+	return "logs/builds/" + buildID + ".log"
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,18 @@
+package mockserver
+
+import (
+	"net/http"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// MockVersion is the server version the capabilities endpoints report.
+const MockVersion = "mock"
+
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	features := s.Features
+	if features == nil {
+		features = api.Features
+	}
+	writeJSON(w, http.StatusOK, api.Capabilities{Version: MockVersion, Features: features})
+}
@@ -0,0 +1,45 @@
+package mockserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// StatusScaledToZero is the status of a deployment whose workers were all
+// stopped; its next invocation starts one.
+const StatusScaledToZero = "scaled_to_zero"
+
+func (s *Server) handleScaleToZero(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deployments[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	if !s.checkLock(w, r, d) {
+		return
+	}
+
+	s.recordScale(d.ID, d.ReadyWorkers, 0)
+	d.Status = StatusScaledToZero
+	d.ReadyWorkers = 0
+	d.UpdatedAt = time.Now().UTC()
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+// startFromZero starts a worker for a deployment scaled to zero, recording
+// each startup phase. Callers must hold s.mu.
+func (s *Server) startFromZero(d *api.DeploymentResponse) {
+	workerID := d.ID + workerInfix + "0"
+	s.recordEvent(d.ID, api.DeploymentEvent{Type: api.EventWorkerScheduled, WorkerID: workerID, Message: "Worker scheduled on mock-node-0"})
+	s.recordEvent(d.ID, api.DeploymentEvent{Type: api.EventImagePulled, WorkerID: workerID, Message: "Pulled image " + d.ImageURL})
+	s.recordEvent(d.ID, api.DeploymentEvent{Type: api.EventModelsLoaded, WorkerID: workerID, Message: "Loaded models, worker ready"})
+	s.recordScale(d.ID, 0, 1)
+	d.Status = "ready"
+	d.ReadyWorkers = 1
+}
@@ -0,0 +1,87 @@
+package mockserver
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// defaultEventLimit is how many events are returned when no limit is given.
+const defaultEventLimit = 20
+
+// RecordEvent adds an event to a deployment's timeline, for tests and demos
+// of failures the mock cannot produce on its own (crashes, OOM kills). A
+// zero Timestamp is set to now.
+func (s *Server) RecordEvent(deploymentID string, e api.DeploymentEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordEvent(deploymentID, e)
+}
+
+// recordEvent appends to a deployment's timeline. Callers must hold s.mu.
+func (s *Server) recordEvent(deploymentID string, e api.DeploymentEvent) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	s.events[deploymentID] = append(s.events[deploymentID], e)
+}
+
+// recordScale records a change of the worker count. Callers must hold s.mu.
+func (s *Server) recordScale(deploymentID string, from, to int) {
+	switch {
+	case to > from:
+		s.recordEvent(deploymentID, api.DeploymentEvent{
+			Type:    api.EventScaledUp,
+			Message: fmt.Sprintf("Scaled up from %d to %d workers", from, to),
+		})
+	case to < from:
+		s.recordEvent(deploymentID, api.DeploymentEvent{
+			Type:    api.EventScaledDown,
+			Message: fmt.Sprintf("Scaled down from %d to %d workers", from, to),
+		})
+	}
+}
+
+// recordImage records a switch to a new image. Callers must hold s.mu.
+func (s *Server) recordImage(deploymentID, from, to string) {
+	if from == to || to == "" {
+		return
+	}
+	msg := "Switched image to " + to
+	if from != "" {
+		msg = fmt.Sprintf("Switched image from %s to %s", from, to)
+	}
+	s.recordEvent(deploymentID, api.DeploymentEvent{Type: api.EventImageUpdated, Message: msg})
+}
+
+func (s *Server) handleListEvents(w http.ResponseWriter, r *http.Request) {
+	limit := defaultEventLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	if _, ok := s.deployments[id]; !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	events := slices.Clone(s.events[id])
+	slices.Reverse(events)
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	writeJSON(w, http.StatusOK, api.ListDeploymentEventsResponse{Items: events})
+}
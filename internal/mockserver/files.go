@@ -0,0 +1,103 @@
+package mockserver
+
+import (
+	"cmp"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+type mockFile struct {
+	size    int64
+	created time.Time
+}
+
+// storeFile records a file written to the file store. Callers must hold s.mu.
+func (s *Server) storeFile(path string, size int64) {
+	s.files[path] = &mockFile{size: size, created: time.Now().UTC()}
+}
+
+func (s *Server) handleListFiles(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := r.URL.Query().Get("prefix")
+	files := []api.StoredFile{}
+	for path, f := range s.files {
+		if strings.HasPrefix(path, prefix) {
+			files = append(files, api.StoredFile{Path: path, Size: f.size, CreatedAt: f.created.Format(time.RFC3339)})
+		}
+	}
+	slices.SortFunc(files, func(a, b api.StoredFile) int { return strings.Compare(a.Path, b.Path) })
+	writeJSON(w, http.StatusOK, api.ListFilesResponse{Files: files})
+}
+
+func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("upload_id") {
+		s.handleAbortUpload(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := r.PathValue("path")
+	if _, ok := s.files[path]; !ok {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+	delete(s.files, path)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleStorageUsage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type key struct{ deployment, category string }
+	totals := map[key]*api.StorageUsageEntry{}
+	usage := api.StorageUsage{Entries: []api.StorageUsageEntry{}}
+	for p, f := range s.files {
+		var k key
+		parts := strings.Split(p, "/")
+		switch {
+		case parts[0] == "builds" && len(parts) == 3:
+			k = key{parts[1], api.StorageTarballs}
+		case strings.HasPrefix(p, "logs/builds/"):
+			k.category = api.StorageBuildLogs
+			if b, ok := s.builds[strings.TrimSuffix(path.Base(p), ".log")]; ok {
+				k.deployment = b.DeploymentID
+			}
+		case parts[0] == "models":
+			k.category = api.StorageModels
+		case parts[0] == "outputs" && len(parts) >= 3:
+			k.category = api.StorageArtifacts
+			if res, ok := s.results[parts[1]]; ok {
+				k.deployment = res.DeploymentID
+			}
+		default:
+			continue
+		}
+
+		entry, ok := totals[k]
+		if !ok {
+			entry = &api.StorageUsageEntry{DeploymentID: k.deployment, Category: k.category}
+			totals[k] = entry
+		}
+		entry.Files++
+		entry.Bytes += f.size
+		usage.TotalBytes += f.size
+	}
+
+	for _, entry := range totals {
+		usage.Entries = append(usage.Entries, *entry)
+	}
+	slices.SortFunc(usage.Entries, func(a, b api.StorageUsageEntry) int {
+		return cmp.Or(strings.Compare(a.DeploymentID, b.DeploymentID), strings.Compare(a.Category, b.Category))
+	})
+	writeJSON(w, http.StatusOK, usage)
+}
@@ -0,0 +1,52 @@
+package mockserver
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// handleSetFunctionEnabled takes a single function out of rotation, or
+// puts it back, leaving the rest of the deployment alone.
+func (s *Server) handleSetFunctionEnabled(enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		d, ok := s.deployments[r.PathValue("id")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "deployment not found")
+			return
+		}
+		if !s.checkLock(w, r, d) {
+			return
+		}
+		i := slices.IndexFunc(d.FunctionRequirements, func(f api.FunctionRequirement) bool {
+			return f.Name == r.PathValue("function")
+		})
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "function not found")
+			return
+		}
+
+		d.FunctionRequirements[i].Disabled = !enabled
+		d.UpdatedAt = time.Now().UTC()
+
+		writeJSON(w, http.StatusOK, d)
+	}
+}
+
+// keepDisabled carries the disabled flag of functions that are still
+// present over to a replacement function list, so a redeploy doesn't put a
+// disabled function back into rotation.
+func keepDisabled(current, next []api.FunctionRequirement) []api.FunctionRequirement {
+	next = slices.Clone(next)
+	for i := range next {
+		if slices.ContainsFunc(current, func(f api.FunctionRequirement) bool { return f.Name == next[i].Name && f.Disabled }) {
+			next[i].Disabled = true
+		}
+	}
+	return next
+}
@@ -0,0 +1,139 @@
+package mockserver
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// Images whose URL contains one of these markers misbehave after a rollout,
+// so failed deploys and rollbacks can be demonstrated against the mock.
+const (
+	// BrokenImage marks images whose functions fail every invocation with a 500.
+	BrokenImage = "broken"
+	// CrashLoopImage marks images whose workers crash on start and never become ready.
+	CrashLoopImage = "crashloop"
+	// BacklogImage marks images whose queue is backed up, with every worker busy.
+	BacklogImage = "backlog"
+)
+
+// healthWindow is the trailing window invocations are counted over.
+const healthWindow = time.Minute
+
+// invocation is one function call, kept for the health endpoint.
+type invocation struct {
+	at     time.Time
+	failed bool
+}
+
+// rollOut starts the deployment's workers on its current image and forgets
+// the invocations of the previous one. Callers must hold s.mu.
+func (s *Server) rollOut(d *api.DeploymentResponse) {
+	delete(s.invocations, d.ID)
+	d.UpdatedAt = time.Now().UTC()
+
+	if !strings.Contains(d.ImageURL, CrashLoopImage) {
+		d.Status = "ready"
+		d.ReadyWorkers = 1
+		return
+	}
+
+	d.Status = "starting"
+	d.ReadyWorkers = 0
+	exitCode := 1
+	s.recordEvent(d.ID, api.DeploymentEvent{
+		Type:     api.EventWorkerCrashed,
+		WorkerID: d.ID + workerInfix + "0",
+		Message:  "Worker exited during startup: ModuleNotFoundError: No module named 'torch'",
+		ExitCode: &exitCode,
+		Reason:   "Error",
+	})
+}
+
+// recordInvocation counts a function call towards the health endpoint.
+// Callers must hold s.mu.
+func (s *Server) recordInvocation(deploymentID string, failed bool) {
+	s.invocations[deploymentID] = append(s.invocations[deploymentID], invocation{at: time.Now(), failed: failed})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deployments[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	health := api.DeploymentHealth{
+		DeploymentID:   d.ID,
+		Status:         d.Status,
+		ReadyWorkers:   d.ReadyWorkers,
+		DesiredWorkers: max(d.MinWorkers, 1),
+		WindowSeconds:  int(healthWindow.Seconds()),
+	}
+	since := time.Now().Add(-healthWindow)
+	for _, inv := range s.invocations[d.ID] {
+		if inv.at.Before(since) {
+			continue
+		}
+		health.Requests++
+		if inv.failed {
+			health.Errors++
+		}
+	}
+	writeJSON(w, http.StatusOK, health)
+}
+
+// mockBacklog is the queue reported for each function of a BacklogImage.
+const (
+	mockBacklogPending = 25
+	mockBacklogAge     = 5*time.Minute + 30*time.Second
+)
+
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deployments[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	// Invocations against the mock complete immediately, so the queue is
+	// empty unless the image asks for a backlog
+	backlog := strings.Contains(d.ImageURL, BacklogImage)
+	queue := api.DeploymentQueue{
+		DeploymentID: d.ID,
+		ReadyWorkers: d.ReadyWorkers,
+		MaxWorkers:   d.MaxWorkers,
+		Functions:    []api.FunctionQueue{},
+	}
+	if backlog {
+		queue.BusyWorkers = d.ReadyWorkers
+	}
+
+	now := time.Now().UTC()
+	for i, f := range d.FunctionRequirements {
+		fq := api.FunctionQueue{Function: f.Name}
+		if backlog {
+			pendingSince, runningSince := now.Add(-mockBacklogAge), now.Add(-mockBacklogAge/2)
+			fq.Pending = mockBacklogPending
+			fq.OldestPendingAt = &pendingSince
+			// Spread the busy workers over the functions
+			fq.InFlight = d.ReadyWorkers / len(d.FunctionRequirements)
+			if i < d.ReadyWorkers%len(d.FunctionRequirements) {
+				fq.InFlight++
+			}
+			if fq.InFlight > 0 {
+				fq.OldestInFlightAt = &runningSince
+			}
+		}
+		queue.Functions = append(queue.Functions, fq)
+	}
+	writeJSON(w, http.StatusOK, queue)
+}
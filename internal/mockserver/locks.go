@@ -0,0 +1,87 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func (s *Server) handleLockDeployment(w http.ResponseWriter, r *http.Request) {
+	var req api.LockDeploymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Reason == "" {
+		writeError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deployments[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	lock := &api.DeploymentLock{Reason: req.Reason, LockedAt: time.Now().UTC()}
+	if key := s.keys[bearerToken(r)]; key != nil {
+		lock.LockedBy = key.ID
+	}
+	d.Lock = lock
+	s.recordEvent(d.ID, api.DeploymentEvent{Type: api.EventLocked, Message: "Deployment locked", Reason: req.Reason})
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (s *Server) handleUnlockDeployment(w http.ResponseWriter, r *http.Request) {
+	var req api.LockDeploymentRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deployments[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	if d.Lock != nil {
+		s.recordEvent(d.ID, api.DeploymentEvent{Type: api.EventUnlocked, Message: "Deployment unlocked", Reason: req.Reason})
+		d.Lock = nil
+	}
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+// checkLock lets a request change deployment d if it isn't locked, or if
+// the request gives an unlock reason, which is recorded in the deployment's
+// events. Otherwise it answers 423 Locked and returns false. Callers must
+// hold s.mu.
+func (s *Server) checkLock(w http.ResponseWriter, r *http.Request, d *api.DeploymentResponse) bool {
+	if d == nil || d.Lock == nil {
+		return true
+	}
+	reason := r.Header.Get(api.UnlockReasonHeader)
+	if reason == "" {
+		writeError(w, http.StatusLocked, fmt.Sprintf("deployment %s is locked: %s", d.ID, d.Lock.Reason))
+		return false
+	}
+	s.recordEvent(d.ID, api.DeploymentEvent{
+		Type:    api.EventLockOverridden,
+		Message: fmt.Sprintf("%s %s while locked for %q", r.Method, r.URL.Path, d.Lock.Reason),
+		Reason:  reason,
+	})
+	return true
+}
@@ -0,0 +1,1669 @@
+// Package mockserver implements an in-process fake of the cozy-hub, builder,
+// and orchestrator APIs, for demos, local development of the CLI, and
+// end-to-end tests. All state is kept in memory.
+package mockserver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+const (
+	// TransferTTL is how long a deployment transfer waits for confirmation.
+	TransferTTL = 10 * time.Minute
+
+	// DefaultTenantID is the tenant every token authenticates as.
+	DefaultTenantID = "mock-tenant"
+	// Token is the access token returned by password login.
+	Token = "mock-token"
+	// VerificationCode is the email verification code "sent" on registration.
+	VerificationCode = "123456"
+	// MockBuilderID is the builder identity reported for every build.
+	MockBuilderID = "https://builder.mock/cozy-hub"
+)
+
+// Server is a fake cozy-hub/builder/orchestrator. Hub and builder routes live
+// under /api/v1 and orchestrator routes under /v1, so one server can stand in
+// for all three.
+type Server struct {
+	// BuildDuration is how long builds report "running" before succeeding.
+	BuildDuration time.Duration
+	// BuildSlots is how many builds run at once; the rest wait in a queue
+	// ordered by priority. Zero runs every build immediately.
+	BuildSlots int
+	// TenantID is the tenant reported for every token.
+	TenantID string
+	// Features are the optional features the capabilities endpoints report;
+	// nil reports all of them. Requests for others are still served.
+	Features []string
+	// PageSize is the most deployments a list returns per page, and the
+	// page size when the request gives no limit. Zero lists them all at once.
+	PageSize int
+
+	mu          sync.Mutex
+	nextID      int
+	password    string // Set by a password change; until then any password logs in
+	sessions    map[string]*mockSession
+	keys        map[string]*mockKey         // API keys by secret
+	users       map[string]*mockUser        // Registered accounts by email
+	ssoCodes    map[string]*ssoGrant        // Outstanding SSO authorization codes
+	files       map[string]*mockFile        // File store contents by path
+	uploads     map[string]*multipartUpload // In-progress multipart uploads by ID
+	builds      map[string]*mockBuild
+	hubDeploys  map[string]*api.HubDeployment
+	deployments map[string]*api.DeploymentResponse
+	transfers   map[string]*api.DeploymentTransfer
+	events      map[string][]api.DeploymentEvent     // Oldest first, by deployment ID
+	invocations map[string][]invocation              // Since the last rollout, by deployment ID
+	results     map[string]*mockResult               // Recorded invocations by invocation ID
+	idempotent  map[string]string                    // Invocation IDs by deployment/function/idempotency key
+	traffic     map[string]*api.TrafficSplit         // Traffic splits by deployment ID
+	rebuilds    map[string]*api.RebuildPolicy        // Rebuild policies by deployment ID
+	snapshots   map[string][]*api.DeploymentSnapshot // Oldest first, by deployment ID
+	models      map[string]*api.Model                // Registered models by reference
+	approvals   map[string]*mockApproval             // Deploys submitted for approval by ID
+	revisions   map[string][]*api.Revision           // Oldest first, by deployment ID
+	members     []*api.OrgMember                     // Organization members, oldest first
+
+	notifications []*api.NotificationRule // Oldest first
+}
+
+type mockUser struct {
+	id       string
+	email    string
+	username string
+	password string
+	verified bool
+}
+
+// ssoGrant is an authorization code issued by the mock SSO authorize endpoint.
+type ssoGrant struct {
+	org         string
+	redirectURI string
+	challenge   string
+}
+
+type mockSession struct {
+	api.AuthSession
+	token   string
+	refresh string
+}
+
+type mockKey struct {
+	api.APIKey
+	scopes  []api.Scope
+	expires time.Time // Zero if the key does not expire
+	revoked bool
+}
+
+type mockBuild struct {
+	api.Build
+	created    time.Time
+	started    time.Time // Zero while queued
+	provenance *api.ProvenanceStatement
+}
+
+// New creates an empty mock server.
+func New() *Server {
+	return &Server{
+		TenantID:    DefaultTenantID,
+		files:       map[string]*mockFile{},
+		uploads:     map[string]*multipartUpload{},
+		sessions:    map[string]*mockSession{},
+		keys:        map[string]*mockKey{},
+		users:       map[string]*mockUser{},
+		ssoCodes:    map[string]*ssoGrant{},
+		builds:      map[string]*mockBuild{},
+		hubDeploys:  map[string]*api.HubDeployment{},
+		deployments: map[string]*api.DeploymentResponse{},
+		transfers:   map[string]*api.DeploymentTransfer{},
+		events:      map[string][]api.DeploymentEvent{},
+		invocations: map[string][]invocation{},
+		results:     map[string]*mockResult{},
+		idempotent:  map[string]string{},
+		traffic:     map[string]*api.TrafficSplit{},
+		rebuilds:    map[string]*api.RebuildPolicy{},
+		snapshots:   map[string][]*api.DeploymentSnapshot{},
+		models:      map[string]*api.Model{},
+		approvals:   map[string]*mockApproval{},
+		revisions:   map[string][]*api.Revision{},
+		members: []*api.OrgMember{{
+			ID:        ownerMemberID,
+			Email:     "mock@example.com",
+			Username:  "mock",
+			Role:      api.RoleAdmin,
+			Status:    api.MemberActive,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		}},
+	}
+}
+
+// Handler returns the HTTP handler serving all mock APIs.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/capabilities", s.handleCapabilities)
+	mux.HandleFunc("GET /v1/capabilities", s.handleCapabilities)
+
+	// cozy-hub auth
+	mux.HandleFunc("GET /api/v1/auth/me", s.authed(s.handleTenant))
+	mux.HandleFunc("POST /api/v1/auth/password/login", s.handlePasswordLogin)
+	mux.HandleFunc("POST /api/v1/auth/refresh", s.handleRefresh)
+	mux.HandleFunc("POST /api/v1/auth/register", s.handleRegister)
+	mux.HandleFunc("POST /api/v1/auth/email/verify", s.handleVerifyEmail)
+	mux.HandleFunc("POST /api/v1/auth/email/resend", s.handleResendVerification)
+	mux.HandleFunc("GET /api/v1/auth/sso/{org}/authorize", s.handleSSOAuthorize)
+	mux.HandleFunc("POST /api/v1/auth/sso/{org}/token", s.handleSSOToken)
+	mux.HandleFunc("GET /api/v1/auth/user/me", s.authed(s.handleUser))
+	mux.HandleFunc("POST /api/v1/auth/password/change", s.authed(s.handleChangePassword))
+	mux.HandleFunc("GET /api/v1/auth/sessions", s.authed(s.handleListSessions))
+	mux.HandleFunc("DELETE /api/v1/auth/sessions/{id}", s.authed(s.handleRevokeSession))
+	mux.HandleFunc("POST /api/v1/auth/keys", s.authed(s.handleCreateKey))
+	mux.HandleFunc("GET /api/v1/auth/keys", s.authed(s.handleListKeys))
+	mux.HandleFunc("DELETE /api/v1/auth/keys/{id}", s.authed(s.handleRevokeKey))
+	mux.HandleFunc("POST /api/v1/auth/can-i", s.authed(s.handleCanI))
+	mux.HandleFunc("GET /api/v1/org/members", s.scoped(api.ScopeRead, s.handleListOrgMembers))
+	mux.HandleFunc("POST /api/v1/org/members", s.scoped(api.ScopeManage, s.handleInviteOrgMember))
+	mux.HandleFunc("PUT /api/v1/org/members/{id}/role", s.scoped(api.ScopeManage, s.handleSetOrgMemberRole))
+	mux.HandleFunc("DELETE /api/v1/org/members/{id}", s.scoped(api.ScopeManage, s.handleRemoveOrgMember))
+
+	// cozy-hub builder
+	mux.HandleFunc("PUT /api/v1/file/{path...}", s.scoped(api.ScopeDeploy, s.handleUpload))
+	mux.HandleFunc("POST /api/v1/file/{path...}", s.scoped(api.ScopeDeploy, s.handleMultipartUpload))
+	mux.HandleFunc("DELETE /api/v1/file/{path...}", s.scoped(api.ScopeDeploy, s.handleDeleteFile))
+	mux.HandleFunc("GET /api/v1/files", s.scoped(api.ScopeRead, s.handleListFiles))
+	mux.HandleFunc("GET /api/v1/storage/usage", s.scoped(api.ScopeRead, s.handleStorageUsage))
+	mux.HandleFunc("POST /api/v1/builds", s.scoped(api.ScopeDeploy, s.handleCreateBuild))
+	mux.HandleFunc("GET /api/v1/builds", s.scoped(api.ScopeRead, s.handleListBuilds))
+	mux.HandleFunc("GET /api/v1/builds/{id}", s.scoped(api.ScopeRead, s.handleGetBuild))
+	mux.HandleFunc("GET /api/v1/builds/{id}/logs", s.scoped(api.ScopeRead, s.handleBuildLogs))
+	mux.HandleFunc("POST /api/v1/builds/{id}/cancel", s.scoped(api.ScopeDeploy, s.handleCancelBuild))
+	mux.HandleFunc("GET /api/v1/builds/{id}/artifacts", s.scoped(api.ScopeRead, s.handleListArtifacts))
+	mux.HandleFunc("GET /api/v1/builds/{id}/artifacts/{name}", s.scoped(api.ScopeRead, s.handleGetArtifact))
+	mux.HandleFunc("GET /api/v1/builds/{id}/provenance", s.scoped(api.ScopeRead, s.handleGetProvenance))
+	mux.HandleFunc("PUT /api/v1/builds/{id}/provenance", s.scoped(api.ScopeDeploy, s.handlePutProvenance))
+	mux.HandleFunc("POST /api/v1/builds/{id}/deploy", s.scoped(api.ScopeDeploy, s.handleDeployBuild))
+	mux.HandleFunc("POST /api/v1/models", s.scoped(api.ScopeDeploy, s.handleRegisterModel))
+	mux.HandleFunc("GET /api/v1/deployments/{id}", s.scoped(api.ScopeRead, s.handleGetHubDeployment))
+	mux.HandleFunc("GET /api/v1/deployments/{id}/revisions", s.scoped(api.ScopeRead, s.handleListRevisions))
+	mux.HandleFunc("POST /api/v1/deployments/{id}/rollback", s.scoped(api.ScopeDeploy, s.handleRollback))
+	mux.HandleFunc("GET /api/v1/deployments/{id}/traffic", s.scoped(api.ScopeRead, s.handleGetTraffic))
+	mux.HandleFunc("PUT /api/v1/deployments/{id}/traffic", s.scoped(api.ScopeDeploy, s.handleSetTraffic))
+	mux.HandleFunc("PUT /api/v1/deployments/{id}/rebuild-policy", s.scoped(api.ScopeDeploy, s.handleSetRebuildPolicy))
+	mux.HandleFunc("DELETE /api/v1/deployments/{id}/rebuild-policy", s.scoped(api.ScopeDeploy, s.handleDeleteRebuildPolicy))
+	mux.HandleFunc("GET /api/v1/rebuild-policies", s.scoped(api.ScopeRead, s.handleListRebuildPolicies))
+	mux.HandleFunc("GET /api/v1/approvals", s.scoped(api.ScopeRead, s.handleListApprovals))
+	mux.HandleFunc("GET /api/v1/approvals/{id}", s.scoped(api.ScopeRead, s.handleGetApproval))
+	mux.HandleFunc("POST /api/v1/approvals/{id}/approve", s.scoped(api.ScopeManage, s.handleDecideApproval(true)))
+	mux.HandleFunc("POST /api/v1/approvals/{id}/reject", s.scoped(api.ScopeManage, s.handleDecideApproval(false)))
+	mux.HandleFunc("POST /api/v1/notifications", s.scoped(api.ScopeManage, s.handleSetNotificationRule))
+	mux.HandleFunc("GET /api/v1/notifications", s.scoped(api.ScopeRead, s.handleListNotificationRules))
+	mux.HandleFunc("DELETE /api/v1/notifications/{id}", s.scoped(api.ScopeManage, s.handleDeleteNotificationRule))
+	mux.HandleFunc("GET /api/v1/templates", s.scoped(api.ScopeRead, s.handleListTemplates))
+	mux.HandleFunc("GET /api/v1/templates/{name}/archive", s.scoped(api.ScopeRead, s.handleDownloadTemplate))
+
+	// orchestrator
+	mux.HandleFunc("POST /v1/deployments", s.scoped(api.ScopeDeploy, s.handleCreateDeployment))
+	mux.HandleFunc("GET /v1/deployments", s.scoped(api.ScopeRead, s.handleListDeployments))
+	mux.HandleFunc("GET /v1/deployments/{id}", s.scoped(api.ScopeRead, s.handleGetDeployment))
+	mux.HandleFunc("PUT /v1/deployments/{id}", s.scoped(api.ScopeDeploy, s.handleUpdateDeployment))
+	mux.HandleFunc("DELETE /v1/deployments/{id}", s.scoped(api.ScopeManage, s.handleDeleteDeployment))
+	mux.HandleFunc("POST /v1/deployments/{id}/functions/{function}/invoke", s.scoped(api.ScopeInvoke, s.handleInvoke))
+	mux.HandleFunc("POST /v1/deployments/{id}/functions/{function}/enable", s.scoped(api.ScopeDeploy, s.handleSetFunctionEnabled(true)))
+	mux.HandleFunc("POST /v1/deployments/{id}/functions/{function}/disable", s.scoped(api.ScopeDeploy, s.handleSetFunctionEnabled(false)))
+	mux.HandleFunc("POST /v1/deployments/{id}/scale-to-zero", s.scoped(api.ScopeDeploy, s.handleScaleToZero))
+	mux.HandleFunc("POST /v1/deployments/{id}/lock", s.scoped(api.ScopeManage, s.handleLockDeployment))
+	mux.HandleFunc("POST /v1/deployments/{id}/unlock", s.scoped(api.ScopeManage, s.handleUnlockDeployment))
+	mux.HandleFunc("POST /v1/deployments/{id}/transfers", s.scoped(api.ScopeManage, s.handleRequestTransfer))
+	mux.HandleFunc("POST /v1/deployments/{id}/transfers/{transfer}/confirm", s.scoped(api.ScopeManage, s.handleConfirmTransfer))
+	mux.HandleFunc("DELETE /v1/deployments/{id}/transfers/{transfer}", s.scoped(api.ScopeManage, s.handleCancelTransfer))
+	mux.HandleFunc("POST /v1/deployments/{id}/snapshots", s.scoped(api.ScopeDeploy, s.handleCreateSnapshot))
+	mux.HandleFunc("GET /v1/deployments/{id}/snapshots", s.scoped(api.ScopeRead, s.handleListSnapshots))
+	mux.HandleFunc("POST /v1/deployments/{id}/snapshots/{snapshot}/restore", s.scoped(api.ScopeDeploy, s.handleRestoreSnapshot))
+	mux.HandleFunc("GET /v1/deployments/{id}/health", s.scoped(api.ScopeRead, s.handleHealth))
+	mux.HandleFunc("GET /v1/deployments/{id}/queue", s.scoped(api.ScopeRead, s.handleQueue))
+	mux.HandleFunc("GET /v1/deployments/{id}/stats", s.scoped(api.ScopeRead, s.handleStats))
+	mux.HandleFunc("GET /v1/deployments/{id}/events", s.scoped(api.ScopeRead, s.handleListEvents))
+	mux.HandleFunc("GET /v1/invocations/{id}", s.scoped(api.ScopeRead, s.handleGetInvocation))
+	mux.HandleFunc("GET /v1/invocations/{id}/artifacts/{name}", s.scoped(api.ScopeRead, s.handleGetInvocationArtifact))
+	mux.HandleFunc("GET /v1/deployments/{id}/workers", s.scoped(api.ScopeRead, s.handleListWorkers))
+	mux.HandleFunc("GET /v1/deployments/{id}/metrics/stream", s.scoped(api.ScopeRead, s.handleMetricsStream))
+	mux.HandleFunc("GET /v1/workers/{id}/exec", s.scoped(api.ScopeManage, s.handleExec))
+
+	return mux
+}
+
+// authed rejects requests without a bearer token or with a revoked or
+// expired API key. Any other token is accepted.
+func (s *Server) authed(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || token == r.Header.Get("Authorization") {
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		s.mu.Lock()
+		key := s.keys[token]
+		var problem string
+		switch {
+		case key == nil:
+		case key.revoked:
+			problem = "API key has been revoked"
+		case !key.expires.IsZero() && time.Now().After(key.expires):
+			problem = "API key has expired"
+		default:
+			key.LastUsedAt = time.Now().UTC().Format(time.RFC3339)
+		}
+		s.mu.Unlock()
+		if problem != "" {
+			writeError(w, http.StatusUnauthorized, problem)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// scoped is authed, also requiring an API key to have a scope that grants op
+// on the deployment the request targets. Other tokens have full access.
+func (s *Server) scoped(op string, h http.HandlerFunc) http.HandlerFunc {
+	return s.authed(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		key := s.keys[bearerToken(r)]
+		deployment := s.targetDeployment(r)
+		s.mu.Unlock()
+
+		if key != nil && !slices.ContainsFunc(key.scopes, func(sc api.Scope) bool { return sc.Allows(op, deployment) }) {
+			target := "all deployments"
+			if deployment != "" {
+				target = "deployment " + deployment
+			}
+			writeError(w, http.StatusForbidden, fmt.Sprintf("insufficient scope: key %s does not allow %s on %s", key.ID, op, target))
+			return
+		}
+		h(w, r)
+	})
+}
+
+// targetDeployment returns the deployment a request acts on, or "" if it is
+// not tied to one. Callers must hold s.mu.
+func (s *Server) targetDeployment(r *http.Request) string {
+	id := r.PathValue("id")
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/v1/builds/"):
+		if b, ok := s.builds[id]; ok {
+			return b.DeploymentID
+		}
+		return ""
+	case strings.HasPrefix(r.URL.Path, "/v1/workers/"):
+		return workerDeployment(id)
+	case strings.HasPrefix(r.URL.Path, "/api/v1/org/"), strings.HasPrefix(r.URL.Path, "/api/v1/notifications/"):
+		return ""
+	case id != "":
+		return id
+	default:
+		return r.URL.Query().Get("deployment_id")
+	}
+}
+
+func (s *Server) handleTenant(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"id": s.TenantID, "name": "Mock Tenant"})
+}
+
+func (s *Server) handlePasswordLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Login    string `json:"login"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Login == "" || req.Password == "" {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Registered accounts need their own password and a verified email;
+	// any other login is accepted with any password until it is changed
+	if user := s.findUser(req.Login); user != nil {
+		if req.Password != user.password {
+			writeError(w, http.StatusUnauthorized, "invalid credentials")
+			return
+		}
+		if !user.verified {
+			writeError(w, http.StatusForbidden, "email not verified")
+			return
+		}
+	} else if s.password != "" && req.Password != s.password {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	// Each login is its own session; the first keeps the well-known Token
+	suffix := ""
+	if len(s.sessions) > 0 {
+		suffix = s.newID("")
+	}
+	session := s.addSession(r, Token+suffix, "mock-refresh-token"+suffix)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  session.token,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": session.refresh,
+	})
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req api.RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	switch {
+	case !strings.Contains(req.Email, "@"):
+		writeError(w, http.StatusBadRequest, "a valid email is required")
+		return
+	case req.Username == "":
+		writeError(w, http.StatusBadRequest, "username is required")
+		return
+	case len(req.Password) < 8:
+		writeError(w, http.StatusBadRequest, "password must be at least 8 characters")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findUser(req.Email) != nil {
+		writeError(w, http.StatusConflict, "email is already registered")
+		return
+	}
+	if s.findUser(req.Username) != nil {
+		writeError(w, http.StatusConflict, "username is taken")
+		return
+	}
+
+	user := &mockUser{id: s.newID("user"), email: req.Email, username: req.Username, password: req.Password}
+	s.users[user.email] = user
+
+	writeJSON(w, http.StatusCreated, api.RegisterResponse{UserID: user.id, VerificationRequired: true})
+}
+
+func (s *Server) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req api.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[req.Email]
+	if !ok {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+	if req.Code != VerificationCode {
+		writeError(w, http.StatusBadRequest, "invalid verification code")
+		return
+	}
+	user.verified = true
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "verified"})
+}
+
+func (s *Server) handleResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[req.Email]
+	if !ok {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+	if user.verified {
+		writeError(w, http.StatusConflict, "email is already verified")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "sent"})
+}
+
+// handleSSOAuthorize stands in for the hub redirecting to the org's IdP and
+// back: the user is approved immediately and sent to redirect_uri with a code.
+func (s *Server) handleSSOAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	switch {
+	case q.Get("response_type") != "code":
+		writeError(w, http.StatusBadRequest, "response_type must be code")
+		return
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		writeError(w, http.StatusBadRequest, "PKCE with S256 is required")
+		return
+	case err != nil || redirect.Scheme != "http" || (redirect.Hostname() != "127.0.0.1" && redirect.Hostname() != "localhost"):
+		writeError(w, http.StatusBadRequest, "redirect_uri must be a loopback address")
+		return
+	}
+
+	s.mu.Lock()
+	code := s.newID("sso-code")
+	s.ssoCodes[code] = &ssoGrant{
+		org:         r.PathValue("org"),
+		redirectURI: redirect.String(),
+		challenge:   q.Get("code_challenge"),
+	}
+	s.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) handleSSOToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GrantType    string `json:"grant_type"`
+		Code         string `json:"code"`
+		RedirectURI  string `json:"redirect_uri"`
+		CodeVerifier string `json:"code_verifier"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GrantType != "authorization_code" {
+		writeError(w, http.StatusBadRequest, "unsupported grant")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Codes are single use
+	grant, ok := s.ssoCodes[req.Code]
+	delete(s.ssoCodes, req.Code)
+	if !ok || grant.org != r.PathValue("org") || grant.redirectURI != req.RedirectURI {
+		writeError(w, http.StatusBadRequest, "invalid_grant: unknown or expired authorization code")
+		return
+	}
+	sum := sha256.Sum256([]byte(req.CodeVerifier))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeError(w, http.StatusBadRequest, "invalid_grant: PKCE verification failed")
+		return
+	}
+
+	suffix := s.newID("")
+	session := s.addSession(r, Token+suffix, "mock-refresh-token"+suffix)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  session.token,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": session.refresh,
+	})
+}
+
+// findUser looks up a registered account by email or username. Callers must hold s.mu.
+func (s *Server) findUser(login string) *mockUser {
+	for _, user := range s.users {
+		if user.email == login || user.username == login {
+			return user
+		}
+	}
+	return nil
+}
+
+// handleRefresh issues a fresh access token and rotates the refresh token.
+// Only refresh tokens issued by this server are accepted.
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !strings.HasPrefix(req.RefreshToken, "mock-refresh-token") {
+		writeError(w, http.StatusUnauthorized, "invalid refresh token")
+		return
+	}
+
+	s.mu.Lock()
+	n := s.newID("")
+	token, refresh := Token+n, "mock-refresh-token"+n
+	for _, session := range s.sessions {
+		if session.refresh == req.RefreshToken {
+			session.token, session.refresh = token, refresh
+			session.LastUsedAt = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  token,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": refresh,
+	})
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":             s.TenantID,
+		"username":       "mock",
+		"email":          "mock@example.com",
+		"email_verified": true,
+	})
+}
+
+func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req api.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.NewPassword) < 8 {
+		writeError(w, http.StatusBadRequest, "new password must be at least 8 characters")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.CurrentPassword == "" || (s.password != "" && req.CurrentPassword != s.password) {
+		writeError(w, http.StatusForbidden, "current password is incorrect")
+		return
+	}
+	s.password = req.NewPassword
+
+	// Changing the password signs out every other session
+	token := bearerToken(r)
+	revoked := 0
+	for id, session := range s.sessions {
+		if session.token != token {
+			delete(s.sessions, id)
+			revoked++
+		}
+	}
+
+	writeJSON(w, http.StatusOK, api.ChangePasswordResponse{RevokedSessions: revoked})
+}
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := bearerToken(r)
+	sessions := []api.AuthSession{}
+	for _, session := range s.sessions {
+		listed := session.AuthSession
+		listed.Current = session.token == token
+		sessions = append(sessions, listed)
+	}
+	slices.SortFunc(sessions, func(a, b api.AuthSession) int { return strings.Compare(a.ID, b.ID) })
+
+	writeJSON(w, http.StatusOK, api.ListSessionsResponse{Sessions: sessions})
+}
+
+func (s *Server) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	if _, ok := s.sessions[id]; !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	delete(s.sessions, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addSession records a signed-in session. Callers must hold s.mu.
+func (s *Server) addSession(r *http.Request, token, refresh string) *mockSession {
+	now := time.Now().UTC().Format(time.RFC3339)
+	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
+	session := &mockSession{
+		AuthSession: api.AuthSession{
+			ID:         s.newID("session"),
+			UserAgent:  r.UserAgent(),
+			IPAddress:  ip,
+			CreatedAt:  now,
+			LastUsedAt: now,
+		},
+		token:   token,
+		refresh: refresh,
+	}
+	s.sessions[session.ID] = session
+	return session
+}
+
+func (s *Server) handleCreateKey(w http.ResponseWriter, r *http.Request) {
+	var req api.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		writeError(w, http.StatusBadRequest, "at least one scope is required")
+		return
+	}
+	scopes := make([]api.Scope, 0, len(req.Scopes))
+	for _, raw := range req.Scopes {
+		scope, err := api.ParseScope(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+	var expires time.Time
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil || !t.After(time.Now()) {
+			writeError(w, http.StatusBadRequest, "expires_at must be a future RFC 3339 time")
+			return
+		}
+		expires = t
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[bearerToken(r)]; ok {
+		writeError(w, http.StatusForbidden, "API keys cannot create API keys; log in as a user")
+		return
+	}
+
+	id := s.newID("key")
+	secret := "sk_mock_" + strings.ReplaceAll(id, "-", "_")
+	key := &mockKey{
+		APIKey: api.APIKey{
+			ID:        id,
+			Name:      req.Name,
+			Prefix:    secret[:len(secret)-4],
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+			ExpiresAt: req.ExpiresAt,
+		},
+		scopes:  scopes,
+		expires: expires,
+	}
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, scope.String())
+	}
+	s.keys[secret] = key
+
+	writeJSON(w, http.StatusCreated, api.CreateAPIKeyResponse{APIKey: key.APIKey, Key: secret})
+}
+
+func (s *Server) handleListKeys(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := []api.APIKey{}
+	for _, key := range s.keys {
+		if !key.revoked {
+			keys = append(keys, key.APIKey)
+		}
+	}
+	slices.SortFunc(keys, func(a, b api.APIKey) int { return strings.Compare(a.ID, b.ID) })
+	writeJSON(w, http.StatusOK, api.ListAPIKeysResponse{Keys: keys})
+}
+
+func (s *Server) handleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if key.ID == r.PathValue("id") && !key.revoked {
+			key.revoked = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "API key not found")
+}
+
+func bearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// caller names who made a request: its API key, or else the account every
+// other token signs in as. Callers must hold s.mu.
+func (s *Server) caller(r *http.Request) string {
+	if key := s.keys[bearerToken(r)]; key != nil {
+		return key.ID
+	}
+	for _, m := range s.members {
+		if m.ID == ownerMemberID {
+			return m.Email
+		}
+	}
+	return ""
+}
+
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("upload_id") {
+		s.handleUploadPart(w, r)
+		return
+	}
+
+	n, err := io.Copy(io.Discard, r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read upload: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.storeFile(r.PathValue("path"), n)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]any{"path": r.PathValue("path"), "size": n})
+}
+
+func (s *Server) handleCreateBuild(w http.ResponseWriter, r *http.Request) {
+	var req api.CreateBuildRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	priority, err := api.ParseBuildPriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if priority == "" {
+		priority = api.BuildPriorityNormal
+	}
+	machine, err := api.ParseBuildMachine(req.Machine)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if machine == "" {
+		machine = api.BuildMachineSmall
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.files[req.TarballPath]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("tarball %q not uploaded", req.TarballPath))
+		return
+	}
+
+	// Tarballs are uploaded to builds/<name>/<timestamp>.tar.gz
+	deploymentID := path.Base(path.Dir(req.TarballPath))
+
+	now := time.Now().UTC()
+	b := &mockBuild{
+		Build: api.Build{
+			ID:           s.newID("build"),
+			TenantID:     s.TenantID,
+			DeploymentID: deploymentID,
+			Status:       "queued",
+			TarballPath:  req.TarballPath,
+			Priority:     priority,
+			Machine:      machine,
+			CreatedAt:    now.Format(time.RFC3339),
+			UpdatedAt:    now.Format(time.RFC3339),
+		},
+		created: now,
+	}
+	s.builds[b.ID] = b
+
+	// Submissions are accepted as queued; scheduling afterwards lets the
+	// build take a free slot before anything submitted after it
+	accepted := b.Build
+	s.schedule(now)
+
+	writeJSON(w, http.StatusCreated, accepted)
+}
+
+func (s *Server) handleGetBuild(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.builds[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "build not found")
+		return
+	}
+	writeTaggedJSON(w, r, s.advance(b))
+}
+
+func (s *Server) handleListBuilds(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deploymentID := r.URL.Query().Get("deployment_id")
+	builds := make([]api.Build, 0, len(s.builds))
+	for _, b := range s.builds {
+		if deploymentID != "" && b.DeploymentID != deploymentID {
+			continue
+		}
+		builds = append(builds, s.advance(b))
+	}
+	// Build IDs are sequential, so newest first is descending ID order
+	slices.SortFunc(builds, func(a, b api.Build) int {
+		return strings.Compare(b.ID, a.ID)
+	})
+
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit < len(builds) {
+		builds = builds[:limit]
+	}
+
+	writeTaggedJSON(w, r, api.ListBuildsResponse{Builds: builds, Count: len(builds)})
+}
+
+func (s *Server) handleCancelBuild(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.builds[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "build not found")
+		return
+	}
+	if build := s.advance(b); build.Status != "queued" && build.Status != "running" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("build is already %s", build.Status))
+		return
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	b.Status = "canceled"
+	b.FinishedAt = &now
+	b.UpdatedAt = now
+	writeJSON(w, http.StatusOK, b.Build)
+}
+
+// mockArtifact is a generated build artifact.
+type mockArtifact struct {
+	api.BuildArtifact
+	content []byte
+}
+
+// artifacts returns the artifacts of a build: none until it succeeds.
+// Callers must hold s.mu.
+func (s *Server) artifacts(b *mockBuild) []mockArtifact {
+	build := s.advance(b)
+	if build.Status != "success" {
+		return nil
+	}
+
+	var logs bytes.Buffer
+	gzw := gzip.NewWriter(&logs)
+	tw := tar.NewWriter(gzw)
+	buildLog := []byte(fmt.Sprintf("Build queued\nBuilding image %s\nPushed %s\nBuild succeeded\n", build.ID, build.ImageTag))
+	tw.WriteHeader(&tar.Header{Name: "build.log", Mode: 0644, Size: int64(len(buildLog)), ModTime: b.created})
+	tw.Write(buildLog)
+	tw.Close()
+	gzw.Close()
+
+	sbom := fmt.Sprintf(`{"spdxVersion":"SPDX-2.3","name":%q,"packages":[{"name":"gen-worker","versionInfo":"0.0.0"}]}`, build.ImageTag)
+
+	artifacts := []mockArtifact{
+		{api.BuildArtifact{Name: "Dockerfile", Kind: "dockerfile", ContentType: "text/plain"}, []byte("FROM cozycreator/gen-worker:cpu-torch2.9\nCOPY . /app\n")},
+		{api.BuildArtifact{Name: "requirements.lock", Kind: "lockfile", ContentType: "text/plain"}, []byte("gen-worker==0.0.0\n")},
+		{api.BuildArtifact{Name: "sbom.spdx.json", Kind: "sbom", ContentType: "application/json"}, []byte(sbom)},
+		{api.BuildArtifact{Name: "logs.tar.gz", Kind: "logs", ContentType: "application/gzip"}, logs.Bytes()},
+	}
+	for i := range artifacts {
+		artifacts[i].Size = int64(len(artifacts[i].content))
+	}
+	return artifacts
+}
+
+func (s *Server) handleListArtifacts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.builds[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "build not found")
+		return
+	}
+
+	resp := api.ListBuildArtifactsResponse{Artifacts: []api.BuildArtifact{}}
+	for _, a := range s.artifacts(b) {
+		resp.Artifacts = append(resp.Artifacts, a.BuildArtifact)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGetArtifact(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.builds[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "build not found")
+		return
+	}
+
+	for _, a := range s.artifacts(b) {
+		if a.Name == r.PathValue("name") {
+			w.Header().Set("Content-Type", a.ContentType)
+			w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+			w.Write(a.content)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "artifact not found")
+}
+
+func (s *Server) handleBuildLogs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var b api.Build
+	mb, ok := s.builds[r.PathValue("id")]
+	if ok {
+		b = s.advance(mb)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "build not found")
+		return
+	}
+
+	afterID, _ := strconv.ParseInt(r.URL.Query().Get("after_id"), 10, 64)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	// Each line is stamped with the time its phase began
+	type line struct{ phase, ts, message string }
+	started, finished := b.CreatedAt, b.CreatedAt
+	if b.StartedAt != nil {
+		started = *b.StartedAt
+	}
+	if b.FinishedAt != nil {
+		finished = *b.FinishedAt
+	}
+	lines := []line{{"queue", b.CreatedAt, "Build queued"}, {"build", started, "Building image " + b.ID}}
+	switch b.Status {
+	case "success":
+		lines = append(lines, line{"push", finished, "Pushed " + b.ImageTag}, line{"push", finished, "Build succeeded"})
+	case "canceled":
+		lines = append(lines, line{"build", finished, "Build canceled"})
+	}
+
+	resp := api.BuildLogsResponse{Logs: []api.BuildLog{}}
+	for i, l := range lines {
+		id := int64(i + 1)
+		if id <= afterID {
+			continue
+		}
+		if limit > 0 && len(resp.Logs) >= limit {
+			break
+		}
+		resp.Logs = append(resp.Logs, api.BuildLog{
+			ID:      id,
+			BuildID: b.ID,
+			TS:      l.ts,
+			Level:   "info",
+			Phase:   l.phase,
+			Message: l.message,
+		})
+	}
+	resp.Count = len(resp.Logs)
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleDeployBuild(w http.ResponseWriter, r *http.Request) {
+	var req api.DeployBuildRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.builds[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "build not found")
+		return
+	}
+	if s.advance(b).Status != "success" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("build %s is %s", b.ID, b.Status))
+		return
+	}
+
+	deploymentID := req.DeploymentID
+	if deploymentID == "" {
+		deploymentID = b.DeploymentID
+	}
+	if !s.checkLock(w, r, s.deployments[deploymentID]) {
+		return
+	}
+
+	if hub, ok := s.hubDeploys[deploymentID]; ok && hub.RequiresApproval {
+		writeJSON(w, http.StatusAccepted, s.requestApproval(r, deploymentID, b.ID, api.RevisionCauseDeploy))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.activateBuild(deploymentID, b, api.Revision{
+		Cause:      api.RevisionCauseDeploy,
+		DeployedBy: s.caller(r),
+	}))
+}
+
+// activateBuild makes b the active build of a deployment, creating the
+// deployment if needed, and records the change as a new revision. rev gives
+// its cause and who deployed it. Callers must hold s.mu.
+func (s *Server) activateBuild(deploymentID string, b *mockBuild, rev api.Revision) *api.HubDeployment {
+	now := time.Now().UTC().Format(time.RFC3339)
+	hub, ok := s.hubDeploys[deploymentID]
+	if !ok {
+		hub = &api.HubDeployment{ID: deploymentID, TenantID: s.TenantID, Name: deploymentID, CreatedAt: now}
+		s.hubDeploys[deploymentID] = hub
+	}
+
+	revisions := s.revisions[deploymentID]
+	if n := len(revisions); n > 0 && revisions[n-1].Status == api.RevisionActive {
+		revisions[n-1].Status = api.RevisionSuperseded
+		if rev.Cause == api.RevisionCauseRollback {
+			revisions[n-1].Status = api.RevisionRolledBack
+		}
+		revisions[n-1].ReplacedAt = now
+	}
+	rev.Number = len(revisions) + 1
+	rev.DeploymentID = deploymentID
+	rev.BuildID = b.ID
+	rev.ImageTag = b.ImageTag
+	rev.Status = api.RevisionActive
+	rev.DeployedAt = now
+	s.revisions[deploymentID] = append(revisions, &rev)
+
+	hub.PreviousBuildID = hub.ActiveBuildID
+	hub.ActiveBuildID = &b.ID
+	hub.ImageURL = b.ImageTag
+	hub.UpdatedAt = now
+
+	// Cozy-hub registers the promoted image with the orchestrator
+	s.upsertDeployment(deploymentID, b.ImageTag)
+	return hub
+}
+
+func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
+	var req api.RollbackRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	hub, ok := s.hubDeploys[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	target := req.BuildID
+	if target == "" {
+		if hub.PreviousBuildID == nil {
+			writeError(w, http.StatusConflict, fmt.Sprintf("deployment %s has no previous build", id))
+			return
+		}
+		target = *hub.PreviousBuildID
+	}
+	b, ok := s.builds[target]
+	switch {
+	case !ok:
+		writeError(w, http.StatusConflict, fmt.Sprintf("build %s not found", target))
+		return
+	case b.DeploymentID != id:
+		writeError(w, http.StatusConflict, fmt.Sprintf("build %s is a build of %s, not %s", target, b.DeploymentID, id))
+		return
+	case s.advance(b).Status != "success":
+		writeError(w, http.StatusConflict, fmt.Sprintf("build %s is %s", target, b.Status))
+		return
+	case hub.ActiveBuildID != nil && *hub.ActiveBuildID == target:
+		writeError(w, http.StatusConflict, fmt.Sprintf("build %s is already active", target))
+		return
+	}
+
+	if !s.checkLock(w, r, s.deployments[id]) {
+		return
+	}
+	if hub.RequiresApproval {
+		writeJSON(w, http.StatusAccepted, s.requestApproval(r, id, b.ID, api.RevisionCauseRollback))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.activateBuild(id, b, api.Revision{
+		Cause:      api.RevisionCauseRollback,
+		DeployedBy: s.caller(r),
+	}))
+}
+
+func (s *Server) handleGetHubDeployment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hub, ok := s.hubDeploys[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	writeTaggedJSON(w, r, hub)
+}
+
+func (s *Server) handleCreateDeployment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		api.CreateDeploymentRequest
+		BuildID string `json:"build_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// DeployWithBuildID: resolve the image from the build
+	if req.BuildID != "" {
+		b, ok := s.builds[req.BuildID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "build not found")
+			return
+		}
+		req.ID = b.DeploymentID
+		req.ImageURL = s.advance(b).ImageTag
+	}
+
+	if req.ID == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	if _, exists := s.deployments[req.ID]; exists {
+		writeError(w, http.StatusConflict, "deployment already exists")
+		return
+	}
+
+	d := s.upsertDeployment(req.ID, req.ImageURL)
+	if req.Name != "" {
+		d.Name = req.Name
+	}
+	d.FunctionRequirements = req.FunctionRequirements
+	d.SupportedModelIDs = req.SupportedModelIDs
+	d.RunpodSecretMapping = req.RunpodSecretMapping
+	if req.MinWorkers != nil {
+		d.MinWorkers = *req.MinWorkers
+	}
+	if req.MaxWorkers != nil {
+		d.MaxWorkers = *req.MaxWorkers
+	}
+	s.recordScale(d.ID, 0, max(d.MinWorkers, 1))
+
+	writeJSON(w, http.StatusCreated, d)
+}
+
+func (s *Server) handleListDeployments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	nameFilter := query.Get("name")
+
+	items := make([]api.DeploymentResponse, 0, len(s.deployments))
+	for _, d := range s.deployments {
+		if nameFilter != "" && !strings.Contains(d.ID, nameFilter) && !strings.Contains(d.Name, nameFilter) {
+			continue
+		}
+		items = append(items, *d)
+	}
+
+	sortField, descending := strings.CutPrefix(query.Get("sort"), "-")
+	var compare func(a, b api.DeploymentResponse) int
+	switch sortField {
+	case "", "id", "name":
+		compare = func(a, b api.DeploymentResponse) int { return strings.Compare(a.ID, b.ID) }
+	case "created_at":
+		compare = func(a, b api.DeploymentResponse) int { return a.CreatedAt.Compare(b.CreatedAt) }
+	case "updated_at":
+		compare = func(a, b api.DeploymentResponse) int { return a.UpdatedAt.Compare(b.UpdatedAt) }
+	default:
+		writeError(w, http.StatusBadRequest, "invalid sort field: "+sortField)
+		return
+	}
+	slices.SortStableFunc(items, func(a, b api.DeploymentResponse) int {
+		if c := compare(a, b); c != 0 {
+			if descending {
+				return -c
+			}
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	// Page by limit with either a 1-based page number or an opaque cursor (the offset)
+	total := len(items)
+	limit, offset := total, 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit: "+v)
+			return
+		}
+		limit = n
+	}
+	if s.PageSize > 0 && limit > s.PageSize {
+		limit = s.PageSize
+	}
+	if v := query.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid page: "+v)
+			return
+		}
+		offset = (n - 1) * limit
+	}
+	if v := query.Get("cursor"); v != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(v, "offset-"))
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid cursor: "+v)
+			return
+		}
+		offset = n
+	}
+
+	resp := api.ListDeploymentsResponse{Total: total}
+	if offset < total {
+		end := min(offset+limit, total)
+		resp.Items = items[offset:end]
+		if end < total {
+			resp.NextCursor = fmt.Sprintf("offset-%d", end)
+		}
+	} else {
+		resp.Items = []api.DeploymentResponse{}
+	}
+
+	writeTaggedJSON(w, r, resp)
+}
+
+func (s *Server) handleGetDeployment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deployments[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	writeTaggedJSON(w, r, d)
+}
+
+func (s *Server) handleUpdateDeployment(w http.ResponseWriter, r *http.Request) {
+	var req api.UpdateDeploymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deployments[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	if !s.checkLock(w, r, d) {
+		return
+	}
+
+	if req.Name != "" {
+		d.Name = req.Name
+	}
+	if req.ImageURL != "" && req.ImageURL != d.ImageURL {
+		s.recordImage(d.ID, d.ImageURL, req.ImageURL)
+		d.ImageURL = req.ImageURL
+		s.rollOut(d)
+	}
+	if req.FunctionRequirements != nil {
+		d.FunctionRequirements = keepDisabled(d.FunctionRequirements, req.FunctionRequirements)
+	}
+	if req.SupportedModelIDs != nil {
+		d.SupportedModelIDs = req.SupportedModelIDs
+	}
+	if req.RunpodSecretMapping != nil {
+		d.RunpodSecretMapping = req.RunpodSecretMapping
+	}
+	if req.MinWorkers != nil {
+		s.recordScale(d.ID, max(d.MinWorkers, 1), max(*req.MinWorkers, 1))
+		d.MinWorkers = *req.MinWorkers
+	}
+	if req.MaxWorkers != nil {
+		d.MaxWorkers = *req.MaxWorkers
+	}
+	d.UpdatedAt = time.Now().UTC()
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (s *Server) handleDeleteDeployment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	d, ok := s.deployments[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	if !s.checkLock(w, r, d) {
+		return
+	}
+	delete(s.deployments, id)
+	delete(s.hubDeploys, id)
+	delete(s.revisions, id)
+	delete(s.events, id)
+	delete(s.invocations, id)
+	delete(s.traffic, id)
+	delete(s.rebuilds, id)
+	delete(s.snapshots, id)
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (s *Server) handleInvoke(w http.ResponseWriter, r *http.Request) {
+	input, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(input) {
+		writeError(w, http.StatusBadRequest, "payload must be valid JSON")
+		return
+	}
+
+	id, function := r.PathValue("id"), r.PathValue("function")
+
+	s.mu.Lock()
+	d, ok := s.deployments[id]
+	known := ok && (len(d.FunctionRequirements) == 0 || slices.ContainsFunc(d.FunctionRequirements, func(f api.FunctionRequirement) bool {
+		return f.Name == function
+	}))
+	disabled := known && slices.ContainsFunc(d.FunctionRequirements, func(f api.FunctionRequirement) bool {
+		return f.Name == function && f.Disabled
+	})
+	if disabled {
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "function is disabled")
+		return
+	}
+	key := r.Header.Get(api.IdempotencyKeyHeader)
+	if key != "" {
+		key = id + "/" + function + "/" + key
+	}
+	if res, ok := s.results[s.idempotent[key]]; known && key != "" && ok {
+		s.mu.Unlock()
+		w.Header().Set(api.InvocationIDHeader, res.ID)
+		if res.Error != "" {
+			writeError(w, http.StatusInternalServerError, res.Error)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(res.content["output.json"])
+		return
+	}
+	broken := known && strings.Contains(d.ImageURL, BrokenImage)
+	output := map[string]any{
+		"status":        "ok",
+		"deployment_id": id,
+		"function":      function,
+		"input":         json.RawMessage(input),
+	}
+	failure := ""
+	if broken {
+		failure = "worker raised an exception: CUDA error: out of memory"
+	}
+	invocationID := ""
+	cold := known && (len(s.invocations[id]) == 0 || d.Status == StatusScaledToZero)
+	if known && d.Status == StatusScaledToZero {
+		s.startFromZero(d)
+	}
+	if known {
+		s.recordInvocation(id, broken)
+		invocationID = s.recordResult(id, function, input, output, failure)
+		if key != "" {
+			s.idempotent[key] = invocationID
+		}
+	}
+	s.mu.Unlock()
+
+	if !known {
+		writeError(w, http.StatusNotFound, "function not found")
+		return
+	}
+	w.Header().Set(api.InvocationIDHeader, invocationID)
+	if cold {
+		w.Header().Set(api.ColdStartHeader, "true")
+	}
+	if broken {
+		writeError(w, http.StatusInternalServerError, failure)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, output)
+}
+
+func (s *Server) handleRequestTransfer(w http.ResponseWriter, r *http.Request) {
+	var req api.TransferDeploymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ToTenantID == "" {
+		writeError(w, http.StatusBadRequest, "to_tenant_id is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	d, ok := s.deployments[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	if req.ToTenantID == d.TenantID {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("deployment already belongs to tenant %s", d.TenantID))
+		return
+	}
+	for _, t := range s.transfers {
+		if t.DeploymentID == id && s.expire(t).Status == "pending" {
+			writeError(w, http.StatusConflict, fmt.Sprintf("transfer %s is already pending for this deployment", t.ID))
+			return
+		}
+	}
+
+	builds := 0
+	for _, b := range s.builds {
+		if b.DeploymentID == id {
+			builds++
+		}
+	}
+
+	transferID := s.newID("transfer")
+	t := &api.DeploymentTransfer{
+		ID:                transferID,
+		DeploymentID:      id,
+		FromTenantID:      d.TenantID,
+		ToTenantID:        req.ToTenantID,
+		Status:            "pending",
+		ConfirmationToken: "confirm-" + transferID,
+		Builds:            builds,
+		Endpoints:         len(d.FunctionRequirements),
+		ExpiresAt:         time.Now().UTC().Add(TransferTTL),
+	}
+	s.transfers[t.ID] = t
+
+	writeJSON(w, http.StatusAccepted, t)
+}
+
+func (s *Server) handleConfirmTransfer(w http.ResponseWriter, r *http.Request) {
+	var req api.ConfirmTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.transfers[r.PathValue("transfer")]
+	if !ok || t.DeploymentID != r.PathValue("id") {
+		writeError(w, http.StatusNotFound, "transfer not found")
+		return
+	}
+	if s.expire(t).Status != "pending" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("transfer %s is %s", t.ID, t.Status))
+		return
+	}
+	if req.ConfirmationToken != t.ConfirmationToken {
+		writeError(w, http.StatusForbidden, "invalid confirmation token")
+		return
+	}
+
+	d, ok := s.deployments[t.DeploymentID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	// The deployment record, its build history, and its endpoints move together
+	now := time.Now().UTC()
+	d.TenantID = t.ToTenantID
+	d.UpdatedAt = now
+	if hub, ok := s.hubDeploys[t.DeploymentID]; ok {
+		hub.TenantID = t.ToTenantID
+		hub.UpdatedAt = now.Format(time.RFC3339)
+	}
+	for _, b := range s.builds {
+		if b.DeploymentID == t.DeploymentID {
+			b.TenantID = t.ToTenantID
+		}
+	}
+
+	t.Status = "completed"
+	t.CompletedAt = &now
+	t.ConfirmationToken = ""
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleCancelTransfer(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.transfers[r.PathValue("transfer")]
+	if !ok || t.DeploymentID != r.PathValue("id") {
+		writeError(w, http.StatusNotFound, "transfer not found")
+		return
+	}
+	if s.expire(t).Status != "pending" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("transfer %s is %s", t.ID, t.Status))
+		return
+	}
+
+	t.Status = "canceled"
+	t.ConfirmationToken = ""
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+// expire marks a pending transfer expired once TransferTTL has passed.
+// Callers must hold s.mu.
+func (s *Server) expire(t *api.DeploymentTransfer) *api.DeploymentTransfer {
+	if t.Status == "pending" && time.Now().After(t.ExpiresAt) {
+		t.Status = "expired"
+		t.ConfirmationToken = ""
+	}
+	return t
+}
+
+// upsertDeployment records a ready deployment running image.
+// Callers must hold s.mu.
+func (s *Server) upsertDeployment(id, image string) *api.DeploymentResponse {
+	now := time.Now().UTC()
+	d, ok := s.deployments[id]
+	if !ok {
+		d = &api.DeploymentResponse{
+			ID:         id,
+			TenantID:   s.TenantID,
+			Name:       id,
+			MaxWorkers: 1,
+			CreatedAt:  now,
+		}
+		s.deployments[id] = d
+	}
+	s.recordImage(id, d.ImageURL, image)
+	d.ImageURL = image
+	s.rollOut(d)
+	return d
+}
+
+// newID returns a unique, readable ID. Callers must hold s.mu.
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%04d", prefix, s.nextID)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeTaggedJSON writes v with an ETag of its content, or just a 304 when
+// the request's If-None-Match already has it, like the real servers do for
+// deployment and build reads.
+func writeTaggedJSON(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := fmt.Sprintf(`"%x"`, sum[:8])
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n'))
+}
+
+// writeError writes an error in the shape both cozy-hub and the orchestrator use.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, api.ErrorResponse{Error: msg, Message: msg})
+}
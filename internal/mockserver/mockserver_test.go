@@ -0,0 +1,168 @@
+package mockserver
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func TestBuildAndDeployRoundTrip(t *testing.T) {
+	srv := New()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	builder := api.NewBuilderClient(ts.URL, "token", nil)
+	orchestrator := api.NewClient(ts.URL, "token", nil)
+
+	upload, err := builder.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+	if err != nil {
+		t.Fatalf("UploadBuild: %v", err)
+	}
+	if upload.BuildID == "" || upload.Status != "queued" {
+		t.Fatalf("unexpected upload response: %+v", upload)
+	}
+
+	status, err := builder.GetBuildStatus(upload.BuildID)
+	if err != nil {
+		t.Fatalf("GetBuildStatus: %v", err)
+	}
+	if status.Status != "success" || status.ImageTag == "" {
+		t.Fatalf("unexpected build status: %+v", status)
+	}
+
+	deployed, err := builder.DeployBuild(upload.BuildID, nil)
+	if err != nil {
+		t.Fatalf("DeployBuild: %v", err)
+	}
+	if deployed.ID != "my-model" || deployed.ActiveBuildID != upload.BuildID {
+		t.Fatalf("unexpected deploy response: %+v", deployed)
+	}
+
+	deployment, err := orchestrator.GetDeployment("my-model")
+	if err != nil || deployment == nil {
+		t.Fatalf("GetDeployment: %v, %v", deployment, err)
+	}
+	if deployment.ImageURL != status.ImageTag || deployment.Status != "ready" {
+		t.Fatalf("unexpected deployment: %+v", deployment)
+	}
+
+	resp, err := orchestrator.Invoke("my-model", "generate", []byte(`{"prompt":"hi"}`))
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if !strings.Contains(string(resp.Body), `"prompt":"hi"`) {
+		t.Errorf("invoke did not echo input: %s", resp.Body)
+	}
+
+	if err := orchestrator.DeleteDeployment("my-model"); err != nil {
+		t.Fatalf("DeleteDeployment: %v", err)
+	}
+	items, err := orchestrator.ListDeployments()
+	if err != nil || len(items) != 0 {
+		t.Fatalf("ListDeployments after delete: %v, %v", items, err)
+	}
+}
+
+func TestBuildRunsForBuildDuration(t *testing.T) {
+	srv := New()
+	srv.BuildDuration = time.Hour
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	builder := api.NewBuilderClient(ts.URL, "token", nil)
+	upload, err := builder.UploadBuild(strings.NewReader("tarball"), "slow", api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := builder.GetBuildStatus(upload.BuildID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != "running" {
+		t.Errorf("status = %q, want running", status.Status)
+	}
+
+	if _, err := builder.DeployBuild(upload.BuildID, nil); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("expected conflict deploying unfinished build, got %v", err)
+	}
+}
+
+func TestBuildQueueOrdersByPriority(t *testing.T) {
+	srv := New()
+	srv.BuildDuration = time.Hour
+	srv.BuildSlots = 1
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	builder := api.NewBuilderClient(ts.URL, "token", nil)
+	submit := func(name, priority string) string {
+		t.Helper()
+		upload, err := builder.UploadBuild(strings.NewReader("tarball"), name, api.BuildOptions{Priority: priority})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return upload.BuildID
+	}
+	running := submit("first", "")
+	bulk := submit("bulk", api.BuildPriorityLow)
+	ci := submit("ci", api.BuildPriorityNormal)
+	hotfix := submit("hotfix", api.BuildPriorityHigh)
+
+	want := map[string]int{running: 0, hotfix: 1, ci: 2, bulk: 3}
+	for id, position := range want {
+		status, err := builder.GetBuildStatus(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.QueuePosition != position {
+			t.Errorf("%s: queue position = %d, want %d", id, status.QueuePosition, position)
+		}
+		if position > 0 && (status.Status != "queued" || status.EstimatedStartAt == nil) {
+			t.Errorf("%s: unexpected queued build: %+v", id, status)
+		}
+		if position == 0 && status.Status != "running" {
+			t.Errorf("%s: status = %q, want running", id, status.Status)
+		}
+	}
+
+	if _, err := builder.UploadBuild(strings.NewReader("tarball"), "bad", api.BuildOptions{Priority: "urgent"}); err == nil {
+		t.Error("expected an unknown priority to be rejected")
+	}
+}
+
+func TestBuildMachine(t *testing.T) {
+	ts := httptest.NewServer(New().Handler())
+	defer ts.Close()
+
+	builder := api.NewBuilderClient(ts.URL, "token", nil)
+	for machine, want := range map[string]string{"": api.BuildMachineSmall, api.BuildMachineGPU: api.BuildMachineGPU} {
+		upload, err := builder.UploadBuild(strings.NewReader("tarball"), "torch-app", api.BuildOptions{Machine: machine})
+		if err != nil {
+			t.Fatal(err)
+		}
+		status, err := builder.GetBuildStatus(upload.BuildID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Machine != want {
+			t.Errorf("machine %q: build ran on %q, want %q", machine, status.Machine, want)
+		}
+	}
+
+	if _, err := builder.UploadBuild(strings.NewReader("tarball"), "bad", api.BuildOptions{Machine: "huge"}); err == nil {
+		t.Error("expected an unknown machine to be rejected")
+	}
+}
+
+func TestRequiresBearerToken(t *testing.T) {
+	ts := httptest.NewServer(New().Handler())
+	defer ts.Close()
+
+	if _, err := api.NewClient(ts.URL, "", nil).ListDeployments(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401 without a token, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func (s *Server) handleRegisterModel(w http.ResponseWriter, r *http.Request) {
+	var req api.RegisterModelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ID == "" || (req.Repo == "") == (req.Path == "") {
+		writeError(w, http.StatusBadRequest, "id and exactly one of repo or path are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Uploaded weights must be in the file store
+	for _, f := range req.Files {
+		full := req.Path + "/" + f.Path
+		if file, ok := s.files[full]; !ok || file.size != f.Size {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("file %q is not uploaded", full))
+			return
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	status := http.StatusOK
+	model, ok := s.models[req.ID]
+	if !ok {
+		model = &api.Model{ID: req.ID, CreatedAt: now}
+		s.models[req.ID] = model
+		status = http.StatusCreated
+	}
+	model.Source = req.Source
+	model.Repo = req.Repo
+	model.Revision = req.Revision
+	model.SHA = req.SHA
+	model.SizeBytes = req.SizeBytes
+	model.Gated = req.Gated
+	model.Path = req.Path
+	model.Files = req.Files
+	model.UpdatedAt = now
+	writeJSON(w, status, model)
+}
@@ -0,0 +1,85 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func (s *Server) handleSetNotificationRule(w http.ResponseWriter, r *http.Request) {
+	var req api.SetNotificationRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Events) == 0 {
+		writeError(w, http.StatusBadRequest, "at least one event is required")
+		return
+	}
+	for _, e := range req.Events {
+		if !slices.Contains(api.NotificationEvents, e) {
+			writeError(w, http.StatusBadRequest, "invalid event "+e)
+			return
+		}
+	}
+	switch req.Channel {
+	case api.ChannelEmail:
+	case api.ChannelWebhook:
+		if u, err := url.Parse(req.Target); err != nil || u.Scheme != "https" || u.Host == "" {
+			writeError(w, http.StatusBadRequest, "webhook target must be an https URL")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "invalid channel "+req.Channel)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rule := range s.notifications {
+		if rule.Channel == req.Channel && rule.Target == req.Target && rule.Deployment == req.Deployment {
+			rule.Events = req.Events
+			writeJSON(w, http.StatusOK, rule)
+			return
+		}
+	}
+	rule := &api.NotificationRule{
+		ID:         s.newID("notify"),
+		Events:     req.Events,
+		Channel:    req.Channel,
+		Target:     req.Target,
+		Deployment: req.Deployment,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	s.notifications = append(s.notifications, rule)
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+func (s *Server) handleListNotificationRules(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules := []api.NotificationRule{}
+	for _, rule := range s.notifications {
+		rules = append(rules, *rule)
+	}
+	writeJSON(w, http.StatusOK, api.ListNotificationRulesResponse{Rules: rules})
+}
+
+func (s *Server) handleDeleteNotificationRule(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.notifications, func(rule *api.NotificationRule) bool { return rule.ID == r.PathValue("id") })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "notification rule not found")
+		return
+	}
+	s.notifications = slices.Delete(s.notifications, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
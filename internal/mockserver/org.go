@@ -0,0 +1,166 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// ownerMemberID is the organization member every password login signs in as.
+const ownerMemberID = "member-owner"
+
+func (s *Server) handleListOrgMembers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := []api.OrgMember{}
+	for _, m := range s.members {
+		members = append(members, *m)
+	}
+	writeJSON(w, http.StatusOK, api.ListOrgMembersResponse{Members: members})
+}
+
+func (s *Server) handleInviteOrgMember(w http.ResponseWriter, r *http.Request) {
+	var req api.InviteOrgMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !strings.Contains(req.Email, "@") {
+		writeError(w, http.StatusBadRequest, "a valid email is required")
+		return
+	}
+	if !slices.Contains(api.OrgRoles, req.Role) {
+		writeError(w, http.StatusBadRequest, "invalid role "+req.Role)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.members, func(m *api.OrgMember) bool { return strings.EqualFold(m.Email, req.Email) }) {
+		writeError(w, http.StatusConflict, req.Email+" is already a member or invited")
+		return
+	}
+	member := &api.OrgMember{
+		ID:        s.newID("member"),
+		Email:     req.Email,
+		Role:      req.Role,
+		Status:    api.MemberInvited,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	s.members = append(s.members, member)
+	writeJSON(w, http.StatusCreated, member)
+}
+
+func (s *Server) handleSetOrgMemberRole(w http.ResponseWriter, r *http.Request) {
+	var req api.SetOrgMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !slices.Contains(api.OrgRoles, req.Role) {
+		writeError(w, http.StatusBadRequest, "invalid role "+req.Role)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.members, func(m *api.OrgMember) bool { return m.ID == r.PathValue("id") })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "member not found")
+		return
+	}
+	if req.Role != api.RoleAdmin && s.lastAdmin(s.members[i]) {
+		writeError(w, http.StatusConflict, "the organization must keep at least one admin")
+		return
+	}
+	s.members[i].Role = req.Role
+	writeJSON(w, http.StatusOK, s.members[i])
+}
+
+func (s *Server) handleRemoveOrgMember(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.members, func(m *api.OrgMember) bool { return m.ID == r.PathValue("id") })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "member not found")
+		return
+	}
+	if s.lastAdmin(s.members[i]) {
+		writeError(w, http.StatusConflict, "the organization must keep at least one admin")
+		return
+	}
+	s.members = slices.Delete(s.members, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lastAdmin reports whether m is the only active admin. Callers must hold s.mu.
+func (s *Server) lastAdmin(m *api.OrgMember) bool {
+	if m.Role != api.RoleAdmin || m.Status != api.MemberActive {
+		return false
+	}
+	for _, other := range s.members {
+		if other != m && other.Role == api.RoleAdmin && other.Status == api.MemberActive {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) handleCanI(w http.ResponseWriter, r *http.Request) {
+	var req api.PermissionCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !slices.Contains(api.ScopeOperations, req.Operation) {
+		writeError(w, http.StatusBadRequest, "invalid operation "+req.Operation)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := "all deployments"
+	if req.Deployment != "" {
+		target = "deployment " + req.Deployment
+	}
+	check := api.PermissionCheck{Operation: req.Operation, Deployment: req.Deployment}
+
+	if key := s.keys[bearerToken(r)]; key != nil {
+		check.Scopes = key.Scopes
+		i := slices.IndexFunc(key.scopes, func(sc api.Scope) bool { return sc.Allows(req.Operation, req.Deployment) })
+		if i >= 0 {
+			check.Allowed = true
+			check.Reason = fmt.Sprintf("API key %s has scope %s", key.ID, key.scopes[i])
+		} else {
+			check.Reason = fmt.Sprintf("API key %s has no scope that allows %s on %s", key.ID, req.Operation, target)
+		}
+		writeJSON(w, http.StatusOK, check)
+		return
+	}
+
+	// Other tokens are the owner's sessions
+	i := slices.IndexFunc(s.members, func(m *api.OrgMember) bool { return m.ID == ownerMemberID })
+	if i < 0 {
+		check.Reason = "you are not a member of the organization"
+		writeJSON(w, http.StatusOK, check)
+		return
+	}
+	check.Role = s.members[i].Role
+	check.Allowed = api.RoleAllows(check.Role, req.Operation)
+	if check.Allowed {
+		check.Reason = fmt.Sprintf("role %s allows %s on %s", check.Role, req.Operation, target)
+	} else {
+		check.Reason = fmt.Sprintf("role %s does not allow %s", check.Role, req.Operation)
+	}
+	writeJSON(w, http.StatusOK, check)
+}
@@ -0,0 +1,47 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func (s *Server) handleGetProvenance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.builds[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "build not found")
+		return
+	}
+	if b.provenance == nil {
+		writeError(w, http.StatusNotFound, "build has no provenance")
+		return
+	}
+	writeJSON(w, http.StatusOK, b.provenance)
+}
+
+func (s *Server) handlePutProvenance(w http.ResponseWriter, r *http.Request) {
+	var statement api.ProvenanceStatement
+	if err := json.NewDecoder(r.Body).Decode(&statement); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.builds[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "build not found")
+		return
+	}
+	if b.Status != "success" {
+		writeError(w, http.StatusConflict, "provenance can only be attached to a successful build")
+		return
+	}
+	b.provenance = &statement
+	w.WriteHeader(http.StatusNoContent)
+}
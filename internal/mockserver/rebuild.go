@@ -0,0 +1,102 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func (s *Server) handleSetRebuildPolicy(w http.ResponseWriter, r *http.Request) {
+	var req api.SetRebuildPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var interval time.Duration
+	switch req.Schedule {
+	case api.RebuildDaily:
+		interval = 24 * time.Hour
+	case api.RebuildWeekly:
+		interval = 7 * 24 * time.Hour
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid schedule %q", req.Schedule))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	hub, ok := s.hubDeploys[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	// Rebuild from the active build's tarball, or else the newest successful one
+	source := ""
+	if hub.ActiveBuildID != nil {
+		source = *hub.ActiveBuildID
+	} else {
+		var latest *mockBuild
+		for _, b := range s.builds {
+			if b.DeploymentID == id && s.advance(b).Status == "success" && (latest == nil || b.created.After(latest.created)) {
+				latest = b
+			}
+		}
+		if latest == nil {
+			writeError(w, http.StatusConflict, "deployment has no successful build to rebuild from")
+			return
+		}
+		source = latest.ID
+	}
+
+	now := time.Now().UTC()
+	policy := &api.RebuildPolicy{
+		DeploymentID:  id,
+		Schedule:      req.Schedule,
+		SourceBuildID: source,
+		NextCheckAt:   now.Add(interval).Format(time.RFC3339),
+		CreatedAt:     now.Format(time.RFC3339),
+	}
+	if prev, ok := s.rebuilds[id]; ok {
+		policy.CreatedAt = prev.CreatedAt
+		policy.LastCheckedAt = prev.LastCheckedAt
+		policy.LastRebuildID = prev.LastRebuildID
+	}
+	s.rebuilds[id] = policy
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (s *Server) handleListRebuildPolicies(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policies := make([]api.RebuildPolicy, 0, len(s.rebuilds))
+	for _, p := range s.rebuilds {
+		policies = append(policies, *p)
+	}
+	slices.SortFunc(policies, func(a, b api.RebuildPolicy) int {
+		return strings.Compare(a.DeploymentID, b.DeploymentID)
+	})
+	writeJSON(w, http.StatusOK, api.ListRebuildPoliciesResponse{Policies: policies})
+}
+
+func (s *Server) handleDeleteRebuildPolicy(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	if _, ok := s.rebuilds[id]; !ok {
+		writeError(w, http.StatusNotFound, "rebuild policy not found")
+		return
+	}
+	delete(s.rebuilds, id)
+	w.WriteHeader(http.StatusNoContent)
+}
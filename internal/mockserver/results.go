@@ -0,0 +1,116 @@
+package mockserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// maxMockImages bounds the images an invocation can ask the mock to generate.
+const maxMockImages = 8
+
+// mockResult is a recorded invocation and the content of its artifacts.
+type mockResult struct {
+	api.Invocation
+	content map[string][]byte // By artifact name
+}
+
+// recordResult stores an invocation and its artifacts in the file store: the
+// response as output.json, plus one placeholder image per "num_images"
+// requested in the input. Callers must hold s.mu.
+func (s *Server) recordResult(deploymentID, function string, input []byte, output any, failure string) string {
+	now := time.Now().UTC()
+	res := &mockResult{
+		Invocation: api.Invocation{
+			ID:           s.newID("inv"),
+			DeploymentID: deploymentID,
+			Function:     function,
+			Status:       "succeeded",
+			Error:        failure,
+			Artifacts:    []api.InvocationArtifact{},
+			CreatedAt:    now,
+			CompletedAt:  &now,
+		},
+		content: map[string][]byte{},
+	}
+	s.results[res.ID] = res
+
+	if failure != "" {
+		res.Status = "failed"
+		return res.ID
+	}
+
+	add := func(name, contentType string, data []byte) {
+		path := fmt.Sprintf("outputs/%s/%s", res.ID, name)
+		res.Artifacts = append(res.Artifacts, api.InvocationArtifact{Name: name, Path: path, Size: int64(len(data)), ContentType: contentType})
+		res.content[name] = data
+		s.storeFile(path, int64(len(data)))
+	}
+
+	data, _ := json.Marshal(output)
+	add("output.json", "application/json", data)
+
+	var req struct {
+		NumImages int `json:"num_images"`
+	}
+	json.Unmarshal(input, &req)
+	for i := range min(req.NumImages, maxMockImages) {
+		add(fmt.Sprintf("image-%d.png", i), "image/png", placeholderPNG(i))
+	}
+	return res.ID
+}
+
+// placeholderPNG renders a small solid-colored PNG, a different color per index.
+func placeholderPNG(i int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	c := color.RGBA{R: uint8(40 * i), G: 120, B: 200, A: 255}
+	for y := range 8 {
+		for x := range 8 {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func (s *Server) handleGetInvocation(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.results[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "invocation not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, res.Invocation)
+}
+
+func (s *Server) handleGetInvocationArtifact(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.results[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "invocation not found")
+		return
+	}
+
+	for _, a := range res.Artifacts {
+		if a.Name == r.PathValue("name") {
+			w.Header().Set("Content-Type", a.ContentType)
+			w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+			w.Write(res.content[a.Name])
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "artifact not found")
+}
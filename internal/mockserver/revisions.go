@@ -0,0 +1,54 @@
+package mockserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// defaultRevisionLimit is how many revisions a page has when no limit is given.
+const defaultRevisionLimit = 20
+
+func (s *Server) handleListRevisions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, offset := defaultRevisionLimit, 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit: "+v)
+			return
+		}
+		limit = n
+	}
+	if v := query.Get("cursor"); v != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(v, "offset-"))
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid cursor: "+v)
+			return
+		}
+		offset = n
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	if _, ok := s.hubDeploys[id]; !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	// Newest first
+	revisions := s.revisions[id]
+	resp := api.ListRevisionsResponse{Revisions: []api.Revision{}}
+	for i := len(revisions) - 1 - offset; i >= 0 && len(resp.Revisions) < limit; i-- {
+		resp.Revisions = append(resp.Revisions, *revisions[i])
+	}
+	if end := offset + len(resp.Revisions); end < len(revisions) {
+		resp.NextCursor = fmt.Sprintf("offset-%d", end)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
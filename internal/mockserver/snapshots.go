@@ -0,0 +1,98 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func (s *Server) handleCreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	var req api.CreateDeploymentSnapshotRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	d, ok := s.deployments[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	snapshot := &api.DeploymentSnapshot{
+		ID:                   s.newID("snapshot"),
+		DeploymentID:         id,
+		Description:          req.Description,
+		Name:                 d.Name,
+		FunctionRequirements: slices.Clone(d.FunctionRequirements),
+		SupportedModelIDs:    slices.Clone(d.SupportedModelIDs),
+		RunpodSecretMapping:  maps.Clone(d.RunpodSecretMapping),
+		MinWorkers:           d.MinWorkers,
+		MaxWorkers:           d.MaxWorkers,
+		CreatedAt:            time.Now().UTC(),
+	}
+	s.snapshots[id] = append(s.snapshots[id], snapshot)
+
+	writeJSON(w, http.StatusCreated, snapshot)
+}
+
+func (s *Server) handleListSnapshots(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	if _, ok := s.deployments[id]; !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	items := []api.DeploymentSnapshot{}
+	for _, snapshot := range slices.Backward(s.snapshots[id]) {
+		items = append(items, *snapshot)
+	}
+	writeJSON(w, http.StatusOK, api.ListDeploymentSnapshotsResponse{Items: items})
+}
+
+func (s *Server) handleRestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	d, ok := s.deployments[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	i := slices.IndexFunc(s.snapshots[id], func(snap *api.DeploymentSnapshot) bool {
+		return snap.ID == r.PathValue("snapshot")
+	})
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "snapshot not found")
+		return
+	}
+	snapshot := s.snapshots[id][i]
+	if !s.checkLock(w, r, d) {
+		return
+	}
+
+	s.recordScale(id, max(d.MinWorkers, 1), max(snapshot.MinWorkers, 1))
+	d.Name = snapshot.Name
+	d.FunctionRequirements = slices.Clone(snapshot.FunctionRequirements)
+	d.SupportedModelIDs = slices.Clone(snapshot.SupportedModelIDs)
+	d.RunpodSecretMapping = maps.Clone(snapshot.RunpodSecretMapping)
+	d.MinWorkers = snapshot.MinWorkers
+	d.MaxWorkers = snapshot.MaxWorkers
+	d.UpdatedAt = time.Now().UTC()
+
+	writeJSON(w, http.StatusOK, d)
+}
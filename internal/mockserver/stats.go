@@ -0,0 +1,60 @@
+package mockserver
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// handleStats counts the recorded invocations of each of a deployment's
+// functions since it was created. Functions it no longer registers are
+// listed while they have invocations.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deployments[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	byName := map[string]*api.FunctionStats{}
+	for _, f := range d.FunctionRequirements {
+		byName[f.Name] = &api.FunctionStats{Function: f.Name}
+	}
+	latency := map[string]float64{}
+	for _, res := range s.results {
+		if res.DeploymentID != d.ID || res.CreatedAt.Before(d.CreatedAt) {
+			continue
+		}
+		fs, ok := byName[res.Function]
+		if !ok {
+			fs = &api.FunctionStats{Function: res.Function}
+			byName[res.Function] = fs
+		}
+		fs.Invocations++
+		if res.Status == "failed" {
+			fs.Errors++
+		}
+		if res.CompletedAt != nil {
+			latency[res.Function] += float64(res.CompletedAt.Sub(res.CreatedAt).Milliseconds())
+		}
+		if fs.LastInvokedAt == nil || res.CreatedAt.After(*fs.LastInvokedAt) {
+			at := res.CreatedAt
+			fs.LastInvokedAt = &at
+		}
+	}
+
+	stats := api.DeploymentStats{DeploymentID: d.ID, Since: d.CreatedAt, Functions: []api.FunctionStats{}}
+	for name, fs := range byName {
+		if fs.Invocations > 0 {
+			fs.AvgLatencyMs = latency[name] / float64(fs.Invocations)
+		}
+		stats.Functions = append(stats.Functions, *fs)
+	}
+	slices.SortFunc(stats.Functions, func(a, b api.FunctionStats) int { return strings.Compare(a.Function, b.Function) })
+	writeJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,140 @@
+package mockserver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// mockTemplate is a gallery template and its files, keyed by path.
+type mockTemplate struct {
+	api.ProjectTemplate
+	files map[string]string
+}
+
+// templateVars are the variables every curated template declares.
+var templateVars = []api.TemplateVariable{
+	{Name: "project_name", Description: "Python project name"},
+	{Name: "deployment_id", Description: "Deployment to deploy to"},
+}
+
+// galleryTemplates are the curated starter templates the mock serves.
+var galleryTemplates = []mockTemplate{
+	{
+		ProjectTemplate: api.ProjectTemplate{
+			Name:        "sdxl-worker",
+			Description: "Text-to-image generation with SDXL-Turbo",
+			Tags:        []string{"image", "gpu"},
+			Variables:   append(slices.Clone(templateVars), api.TemplateVariable{Name: "model", Description: "Hugging Face model", Default: "stabilityai/sdxl-turbo"}),
+		},
+		files: map[string]string{
+			"pyproject.toml": templatePyproject("diffusers>=0.25.0", "generate"),
+			"src/{{ project_name }}/worker.py": `from gen_worker import worker_function, ActionContext
+
+MODEL = "{{ model }}"
+
+
+@worker_function()
+def generate(ctx: ActionContext, payload: dict) -> dict:
+    """Generate an image for payload["prompt"] with {{ model }}."""
+    return {"prompt": payload["prompt"], "model": MODEL}
+`,
+			"README.md": "# {{ project_name }}\n\nSDXL worker deployed as {{ deployment_id }}.\n",
+		},
+	},
+	{
+		ProjectTemplate: api.ProjectTemplate{
+			Name:        "whisper-transcription",
+			Description: "Speech-to-text transcription with Whisper",
+			Tags:        []string{"audio", "gpu"},
+			Variables:   append(slices.Clone(templateVars), api.TemplateVariable{Name: "model", Description: "Hugging Face model", Default: "openai/whisper-large-v3"}),
+		},
+		files: map[string]string{
+			"pyproject.toml": templatePyproject("transformers>=4.36.0", "transcribe"),
+			"src/{{ project_name }}/worker.py": `from gen_worker import worker_function, ActionContext
+
+MODEL = "{{ model }}"
+
+
+@worker_function()
+def transcribe(ctx: ActionContext, payload: dict) -> dict:
+    """Transcribe the audio at payload["audio_url"] with {{ model }}."""
+    return {"audio_url": payload["audio_url"], "model": MODEL}
+`,
+			"README.md": "# {{ project_name }}\n\nWhisper transcription deployed as {{ deployment_id }}.\n",
+		},
+	},
+	{
+		ProjectTemplate: api.ProjectTemplate{
+			Name:        "llm-chat",
+			Description: "Chat completions with an open LLM",
+			Tags:        []string{"text", "gpu"},
+			Variables:   append(slices.Clone(templateVars), api.TemplateVariable{Name: "model", Description: "Hugging Face model", Default: "Qwen/Qwen2.5-7B-Instruct"}),
+		},
+		files: map[string]string{
+			"pyproject.toml": templatePyproject("vllm>=0.6.0", "chat"),
+			"src/{{ project_name }}/worker.py": `from gen_worker import worker_function, ActionContext
+
+MODEL = "{{ model }}"
+
+
+@worker_function()
+def chat(ctx: ActionContext, payload: dict) -> dict:
+    """Answer payload["messages"] with {{ model }}."""
+    return {"messages": payload["messages"], "model": MODEL}
+`,
+			"README.md": "# {{ project_name }}\n\nLLM chat deployed as {{ deployment_id }}.\n",
+		},
+	},
+}
+
+func templatePyproject(dependency, function string) string {
+	return `[project]
+name = "{{ project_name }}"
+version = "0.1.0"
+requires-python = ">=3.10"
+dependencies = ["gen-worker", "` + dependency + `"]
+
+[tool.cozy]
+deployment-id = "{{ deployment_id }}"
+
+[tool.cozy.functions]
+` + function + ` = { requires_gpu = true }
+`
+}
+
+func (s *Server) handleListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates := []api.ProjectTemplate{}
+	for _, t := range galleryTemplates {
+		templates = append(templates, t.ProjectTemplate)
+	}
+	writeJSON(w, http.StatusOK, api.ListTemplatesResponse{Templates: templates})
+}
+
+func (s *Server) handleDownloadTemplate(w http.ResponseWriter, r *http.Request) {
+	i := slices.IndexFunc(galleryTemplates, func(t mockTemplate) bool { return t.Name == r.PathValue("name") })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "template not found")
+		return
+	}
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	files := galleryTemplates[i].files
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), ModTime: time.Unix(0, 0)})
+		tw.Write([]byte(files[name]))
+	}
+	tw.Close()
+	gzw.Close()
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,88 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func (s *Server) handleGetTraffic(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	hub, ok := s.hubDeploys[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	if split, ok := s.traffic[id]; ok {
+		writeJSON(w, http.StatusOK, split)
+		return
+	}
+
+	// Without a split, everything goes to the active build
+	split := api.TrafficSplit{DeploymentID: id, Routes: []api.TrafficRoute{}, UpdatedAt: hub.UpdatedAt}
+	if hub.ActiveBuildID != nil {
+		split.Routes = append(split.Routes, api.TrafficRoute{BuildID: *hub.ActiveBuildID, Percent: 100, ImageTag: hub.ImageURL})
+	}
+	writeJSON(w, http.StatusOK, split)
+}
+
+func (s *Server) handleSetTraffic(w http.ResponseWriter, r *http.Request) {
+	var req api.SetTrafficRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	if _, ok := s.hubDeploys[id]; !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	if len(req.Routes) == 0 {
+		writeError(w, http.StatusBadRequest, "at least one route is required")
+		return
+	}
+
+	total := 0
+	seen := map[string]bool{}
+	routes := make([]api.TrafficRoute, len(req.Routes))
+	for i, route := range req.Routes {
+		b, ok := s.builds[route.BuildID]
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("build %s not found", route.BuildID))
+			return
+		}
+		if s.advance(b).Status != "success" {
+			writeError(w, http.StatusConflict, fmt.Sprintf("build %s is %s", b.ID, b.Status))
+			return
+		}
+		if seen[route.BuildID] {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("build %s is listed twice", route.BuildID))
+			return
+		}
+		if route.Percent < 0 || route.Percent > 100 {
+			writeError(w, http.StatusBadRequest, "percent must be between 0 and 100")
+			return
+		}
+		seen[route.BuildID] = true
+		total += route.Percent
+		routes[i] = api.TrafficRoute{BuildID: b.ID, Percent: route.Percent, ImageTag: b.ImageTag}
+	}
+	if total != 100 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("percentages add up to %d, not 100", total))
+		return
+	}
+
+	split := &api.TrafficSplit{DeploymentID: id, Routes: routes, UpdatedAt: time.Now().UTC().Format(time.RFC3339)}
+	s.traffic[id] = split
+	writeJSON(w, http.StatusOK, split)
+}
@@ -0,0 +1,121 @@
+package mockserver
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// multipartUpload is a file store upload sent in parts.
+type multipartUpload struct {
+	path  string
+	parts map[int]uploadedPart
+}
+
+type uploadedPart struct {
+	size int64
+	etag string
+}
+
+// handleMultipartUpload starts (?uploads) or completes (?upload_id=) a multipart upload.
+func (s *Server) handleMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	path := r.PathValue("path")
+	query := r.URL.Query()
+
+	if query.Has("uploads") {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.nextID++
+		id := fmt.Sprintf("upload-%d", s.nextID)
+		s.uploads[id] = &multipartUpload{path: path, parts: map[int]uploadedPart{}}
+		writeJSON(w, http.StatusOK, api.MultipartUpload{UploadID: id, Path: path})
+		return
+	}
+
+	var req api.CompleteMultipartUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := query.Get("upload_id")
+	upload, ok := s.uploads[id]
+	if !ok || upload.path != path {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+	if len(req.Parts) == 0 {
+		writeError(w, http.StatusBadRequest, "at least one part is required")
+		return
+	}
+
+	var size int64
+	for i, p := range req.Parts {
+		uploaded, ok := upload.parts[p.PartNumber]
+		switch {
+		case p.PartNumber != i+1:
+			writeError(w, http.StatusBadRequest, "parts must be listed in order, starting at 1")
+			return
+		case !ok:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("part %d was not uploaded", p.PartNumber))
+			return
+		case uploaded.etag != p.ETag:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("part %d has etag %s, not %s", p.PartNumber, uploaded.etag, p.ETag))
+			return
+		}
+		size += uploaded.size
+	}
+
+	delete(s.uploads, id)
+	s.storeFile(path, size)
+	writeJSON(w, http.StatusCreated, map[string]any{"path": path, "size": size})
+}
+
+func (s *Server) handleUploadPart(w http.ResponseWriter, r *http.Request) {
+	partNumber, err := strconv.Atoi(r.URL.Query().Get("part_number"))
+	if err != nil || partNumber < 1 {
+		writeError(w, http.StatusBadRequest, "part_number must be a positive integer")
+		return
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(h, r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read part: "+err.Error())
+		return
+	}
+	etag := fmt.Sprintf("%x", h.Sum(nil))[:16]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	upload, ok := s.uploads[r.URL.Query().Get("upload_id")]
+	if !ok || upload.path != r.PathValue("path") {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+	upload.parts[partNumber] = uploadedPart{size: n, etag: etag}
+	writeJSON(w, http.StatusOK, api.UploadedPart{PartNumber: partNumber, ETag: etag})
+}
+
+func (s *Server) handleAbortUpload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.URL.Query().Get("upload_id")
+	if upload, ok := s.uploads[id]; !ok || upload.path != r.PathValue("path") {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+	delete(s.uploads, id)
+	w.WriteHeader(http.StatusNoContent)
+}
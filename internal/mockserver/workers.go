@@ -0,0 +1,254 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/websocket"
+)
+
+// Every deployment has max(min_workers, 1) ready workers, named
+// "<deployment>-worker-<n>".
+const workerInfix = "-worker-"
+
+// workers returns a deployment's fake workers. Callers must hold s.mu.
+func (s *Server) workers(d *api.DeploymentResponse) []api.Worker {
+	n := max(d.MinWorkers, 1)
+	workers := make([]api.Worker, n)
+	for i := range workers {
+		workers[i] = api.Worker{
+			ID:           d.ID + workerInfix + strconv.Itoa(i),
+			DeploymentID: d.ID,
+			Status:       "ready",
+			GPU:          "NVIDIA A100 80GB",
+			Node:         fmt.Sprintf("mock-node-%d", i+1),
+			StartedAt:    d.UpdatedAt,
+		}
+	}
+	return workers
+}
+
+// workerDeployment returns the deployment a worker ID belongs to.
+func workerDeployment(workerID string) string {
+	i := strings.LastIndex(workerID, workerInfix)
+	if i < 0 {
+		return ""
+	}
+	return workerID[:i]
+}
+
+func (s *Server) handleListWorkers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deployments[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, api.ListWorkersResponse{Items: s.workers(d)})
+}
+
+// handleMetricsStream sends a snapshot of synthetic GPU metrics for every
+// worker each interval (default 2s) until the client disconnects.
+func (s *Server) handleMetricsStream(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	interval := 2 * time.Second
+	if v := r.URL.Query().Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 100*time.Millisecond {
+			writeError(w, http.StatusBadRequest, "interval must be a duration of at least 100ms")
+			return
+		}
+		interval = d
+	}
+
+	s.mu.Lock()
+	_, ok := s.deployments[id]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	flusher := http.NewResponseController(w)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for tick := 0; ; tick++ {
+		s.mu.Lock()
+		d, ok := s.deployments[id]
+		var snapshot api.MetricsSnapshot
+		if ok {
+			snapshot = s.metrics(d, tick)
+		}
+		s.mu.Unlock()
+		if !ok {
+			return // Deleted while streaming
+		}
+
+		if encoder.Encode(snapshot) != nil || flusher.Flush() != nil {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// metrics makes up a snapshot in which workers take turns serving requests.
+// Callers must hold s.mu.
+func (s *Server) metrics(d *api.DeploymentResponse, tick int) api.MetricsSnapshot {
+	function := "predict"
+	if len(d.FunctionRequirements) > 0 {
+		function = d.FunctionRequirements[0].Name
+	}
+
+	snapshot := api.MetricsSnapshot{DeploymentID: d.ID, Timestamp: time.Now().UTC()}
+	for i, worker := range s.workers(d) {
+		m := api.WorkerMetrics{
+			WorkerID:      worker.ID,
+			Status:        worker.Status,
+			GPU:           worker.GPU,
+			MemoryTotalMB: 81920,
+			MemoryUsedMB:  18432,
+			TemperatureC:  38,
+		}
+		if (tick+i)%3 != 0 {
+			load := float64((tick*37+i*53)%60 + 40)
+			m.Status = "busy"
+			m.CurrentFunction = function
+			m.UtilizationPercent = load
+			m.MemoryUsedMB += int(load) * 600
+			m.TemperatureC += load / 2
+		}
+		snapshot.Workers = append(snapshot.Workers, m)
+	}
+	return snapshot
+}
+
+// handleExec runs a fake command in a worker over a websocket. Shells (bash,
+// sh) read command lines from stdin until "exit"; anything else runs once.
+func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
+	workerID := r.PathValue("id")
+	command := r.URL.Query()["command"]
+	if len(command) == 0 {
+		writeError(w, http.StatusBadRequest, "command is required")
+		return
+	}
+
+	s.mu.Lock()
+	found := false
+	if d, ok := s.deployments[workerDeployment(workerID)]; ok {
+		for _, worker := range s.workers(d) {
+			found = found || worker.ID == workerID
+		}
+	}
+	s.mu.Unlock()
+	if !found {
+		writeError(w, http.StatusNotFound, "worker not found")
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	session := &mockExec{conn: conn, worker: workerID, tty: r.URL.Query().Get("tty") == "true"}
+	code := session.run(command)
+	result, _ := json.Marshal(api.ExecResult{ExitCode: code})
+	session.send(api.ExecStatus, string(result))
+}
+
+type mockExec struct {
+	conn   *websocket.Conn
+	worker string
+	tty    bool
+}
+
+func (e *mockExec) run(command []string) int {
+	if command[0] != "bash" && command[0] != "sh" {
+		return e.exec(strings.Join(command, " "))
+	}
+
+	prompt := fmt.Sprintf("root@%s:/app# ", e.worker)
+	e.send(api.ExecStdout, prompt)
+	var line []byte
+	for {
+		_, msg, err := e.conn.ReadMessage()
+		if err != nil {
+			return 0
+		}
+		if len(msg) == 0 || msg[0] != api.ExecStdin {
+			continue // Resize and unknown channels
+		}
+		for _, b := range msg[1:] {
+			if b != '\r' && b != '\n' {
+				line = append(line, b)
+				if e.tty {
+					e.send(api.ExecStdout, string(b)) // A terminal echoes what is typed
+				}
+				continue
+			}
+			if e.tty {
+				e.send(api.ExecStdout, "\n")
+			}
+			cmd := strings.TrimSpace(string(line))
+			line = line[:0]
+			if cmd == "exit" {
+				return 0
+			}
+			if cmd != "" {
+				e.exec(cmd)
+			}
+			e.send(api.ExecStdout, prompt)
+		}
+	}
+}
+
+// exec runs one fake command line and returns its exit code.
+func (e *mockExec) exec(line string) int {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return 0
+	}
+	switch fields[0] {
+	case "echo":
+		e.send(api.ExecStdout, strings.Join(fields[1:], " ")+"\n")
+	case "hostname":
+		e.send(api.ExecStdout, e.worker+"\n")
+	case "nvidia-smi":
+		e.send(api.ExecStdout, "NVIDIA-SMI 550.54.15    Driver Version: 550.54.15    CUDA Version: 12.4\n"+
+			"GPU 0: NVIDIA A100 80GB (mock)    0MiB / 81920MiB    0%\n")
+	case "sleep":
+		d, _ := time.ParseDuration(strings.Join(fields[1:], "") + "s")
+		time.Sleep(min(d, 5*time.Second))
+	case "false":
+		return 1
+	case "true":
+	default:
+		e.send(api.ExecStderr, fields[0]+": command not found\n")
+		return 127
+	}
+	return 0
+}
+
+// send writes data on a channel, with CRLF line endings on a terminal.
+func (e *mockExec) send(channel byte, data string) {
+	if e.tty && channel != api.ExecStatus {
+		data = strings.ReplaceAll(data, "\n", "\r\n")
+	}
+	e.conn.WriteMessage(websocket.BinaryMessage, append([]byte{channel}, data...))
+}
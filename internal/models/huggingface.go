@@ -0,0 +1,150 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultHFEndpoint is the Hugging Face Hub. Like the huggingface_hub
+// library, HF_ENDPOINT overrides it.
+const DefaultHFEndpoint = "https://huggingface.co"
+
+// hfModel is the subset of the Hub's model info used to resolve a reference.
+type hfModel struct {
+	ID       string      `json:"id"`
+	SHA      string      `json:"sha"`
+	Private  bool        `json:"private"`
+	Gated    gatedStatus `json:"gated"`
+	Siblings []struct {
+		Name string `json:"rfilename"`
+		Size int64  `json:"size"`
+	} `json:"siblings"`
+}
+
+// size is the total size of the model's files.
+func (m *hfModel) size() int64 {
+	var total int64
+	for _, s := range m.Siblings {
+		total += s.Size
+	}
+	return total
+}
+
+// gatedStatus is the Hub's gated field: false, or the approval mode
+// ("auto" or "manual") of a gated model.
+type gatedStatus string
+
+func (g *gatedStatus) UnmarshalJSON(data []byte) error {
+	var mode string
+	if json.Unmarshal(data, &mode) == nil {
+		*g = gatedStatus(mode)
+		return nil
+	}
+	var gated bool
+	if err := json.Unmarshal(data, &gated); err != nil {
+		return err
+	}
+	if gated {
+		*g = "auto"
+	} else {
+		*g = ""
+	}
+	return nil
+}
+
+// errNoAccess is returned for models that don't exist or that the token
+// (if any) can't see; the Hub doesn't tell the two apart.
+var errNoAccess = fmt.Errorf("not found or not accessible")
+
+// hfClient queries the Hugging Face Hub API.
+type hfClient struct {
+	endpoint   string
+	token      string
+	httpClient *http.Client
+}
+
+func newHFClient(endpoint, token string) *hfClient {
+	if endpoint == "" {
+		endpoint = DefaultHFEndpoint
+	}
+	return &hfClient{
+		endpoint:   strings.TrimRight(endpoint, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// model fetches a model's info at a revision (the default branch if empty).
+func (c *hfClient) model(ctx context.Context, repo, revision string) (*hfModel, error) {
+	url := fmt.Sprintf("%s/api/models/%s", c.endpoint, repo)
+	if revision != "" {
+		url += "/revision/" + revision
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url+"?blobs=true", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return nil, errNoAccess
+	default:
+		return nil, fmt.Errorf("Hugging Face Hub returned %d for %s", resp.StatusCode, repo)
+	}
+
+	var m hfModel
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &m, nil
+}
+
+// HFToken returns the Hugging Face access token the way huggingface_hub
+// finds it: HF_TOKEN, then HUGGING_FACE_HUB_TOKEN, then the token saved by
+// 'huggingface-cli login' under HF_HOME (default ~/.cache/huggingface).
+func HFToken() string {
+	for _, name := range []string{"HF_TOKEN", "HUGGING_FACE_HUB_TOKEN"} {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return token
+		}
+	}
+
+	home := os.Getenv("HF_HOME")
+	if home == "" {
+		cache := os.Getenv("XDG_CACHE_HOME")
+		if cache == "" {
+			userHome, err := os.UserHomeDir()
+			if err != nil {
+				return ""
+			}
+			cache = filepath.Join(userHome, ".cache")
+		}
+		home = filepath.Join(cache, "huggingface")
+	}
+	data, err := os.ReadFile(filepath.Join(home, "token"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
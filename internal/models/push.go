@@ -0,0 +1,254 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// SourceFileStore is the registration source of models uploaded to the
+// cozy-hub file store. They are referenced as "cozy:<name>".
+const SourceFileStore = "cozy"
+
+// DefaultChunkSize is the part size of multipart uploads. Files up to this
+// size are uploaded in a single request.
+const DefaultChunkSize = 64 << 20
+
+// partAttempts is how often a part is tried before the upload is abandoned.
+const partAttempts = 3
+
+// modelName matches the names of pushed models.
+var modelName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// PushOptions contains the options for pushing local model weights.
+type PushOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	Path         string // Weights file or directory
+	Name         string
+	ChunkSize    int64 // Default DefaultChunkSize
+	ProgressMode ui.Mode
+}
+
+// Push uploads local model weights to the cozy-hub file store and registers
+// them as the model "cozy:<name>", which functions can load with ModelRef.
+func Push(opts PushOptions) (err error) {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	progress := ui.NewWithMode(os.Stdout, opts.ProgressMode)
+	defer progress.Close()
+
+	recorder := history.Start(opts.Profile, "models push")
+	defer func() { recorder.FinishProgress(progress, err) }()
+
+	_, err = push(progress, client, opts)
+	return err
+}
+
+func push(progress *ui.Progress, client api.BuilderAPI, opts PushOptions) (*api.Model, error) {
+	if !modelName.MatchString(opts.Name) {
+		return nil, fmt.Errorf("invalid model name %q (use lowercase letters, digits, '.', '_', and '-')", opts.Name)
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	files, err := weightFiles(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	modelFiles := make([]api.ModelFile, len(files))
+	progress.Printf("Pushing %d %s (%s) as cozy:%s\n", len(files), plural(len(files), "file", "files"), ui.FormatBytes(total), opts.Name)
+
+	prefix := "models/" + opts.Name
+	stage := progress.Start("Uploading")
+	var sent int64
+	onProgress := func(n int64) {
+		sent += n
+		if total > 0 {
+			stage.SetPercent(int(sent * 100 / total))
+		}
+	}
+	for i, f := range files {
+		sum, err := uploadWeights(client, f.local, prefix+"/"+f.Path, f.Size, chunkSize, onProgress)
+		if err != nil {
+			return nil, stage.Fail(fmt.Errorf("failed to upload %s: %w", f.Path, err))
+		}
+		f.SHA256 = sum
+		modelFiles[i] = f.ModelFile
+	}
+	stage.Done()
+
+	stage = progress.Start("Registering")
+	model, err := client.RegisterModel(&api.RegisterModelRequest{
+		ID:        SourceFileStore + ":" + opts.Name,
+		Source:    SourceFileStore,
+		Path:      prefix,
+		Files:     modelFiles,
+		SizeBytes: total,
+	})
+	if err != nil {
+		return nil, stage.Fail(fmt.Errorf("failed to register model: %w", err))
+	}
+	stage.Done()
+	progress.SetID("model_id", model.ID)
+
+	progress.Printf("\nRegistered %s. Load it in a function by adding it to pyproject.toml:\n\n", model.ID)
+	progress.Printf("  [tool.cozy.models]\n  %s = %q\n\n", opts.Name, model.ID)
+	progress.Printf("and annotating a parameter with ModelRef(Src.DEPLOYMENT, %q).\n", opts.Name)
+	progress.Println(progress.Summary())
+	return model, nil
+}
+
+// weightFile is a local file to push.
+type weightFile struct {
+	api.ModelFile
+	local string
+}
+
+// weightFiles lists the files to push: path itself, or the files under it.
+// Hidden files and directories are skipped; symlinked files are followed, so
+// a Hugging Face cache snapshot can be pushed as is.
+func weightFiles(path string) ([]weightFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []weightFile{{ModelFile: api.ModelFile{Path: filepath.Base(path), Size: info.Size()}, local: path}}, nil
+	}
+
+	var files []weightFile
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != path && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, weightFile{ModelFile: api.ModelFile{Path: filepath.ToSlash(rel), Size: info.Size()}, local: p})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", path, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s has no files to push", path)
+	}
+	return files, nil
+}
+
+// uploadWeights uploads one file to the file store, in parts if it is larger
+// than chunkSize, and returns its sha256.
+func uploadWeights(client api.BuilderAPI, local, remote string, size, chunkSize int64, onProgress func(int64)) (string, error) {
+	f, err := os.Open(local)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if size <= chunkSize {
+		data, err := io.ReadAll(io.TeeReader(f, h))
+		if err != nil {
+			return "", err
+		}
+		if err := client.UploadFile(remote, bytes.NewReader(data), "application/octet-stream"); err != nil {
+			return "", err
+		}
+		onProgress(int64(len(data)))
+		return fmt.Sprintf("%x", h.Sum(nil)), nil
+	}
+
+	upload, err := client.CreateMultipartUpload(remote)
+	if err != nil {
+		return "", err
+	}
+	if err := uploadParts(client, f, h, remote, upload.UploadID, chunkSize, onProgress); err != nil {
+		if abortErr := client.AbortMultipartUpload(remote, upload.UploadID); abortErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to abort upload: %w", abortErr))
+		}
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func uploadParts(client api.BuilderAPI, r io.Reader, h hash.Hash, remote, uploadID string, chunkSize int64, onProgress func(int64)) error {
+	buf := make([]byte, chunkSize)
+	var parts []api.UploadedPart
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		chunk := buf[:n]
+		h.Write(chunk)
+
+		var part *api.UploadedPart
+		for attempt := 1; attempt <= partAttempts; attempt++ {
+			if part, err = client.UploadPart(remote, uploadID, number, chunk); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("part %d: %w", number, err)
+		}
+		parts = append(parts, *part)
+		onProgress(int64(n))
+
+		if n < len(buf) {
+			break
+		}
+	}
+	return client.CompleteMultipartUpload(remote, uploadID, parts)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
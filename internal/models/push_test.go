@@ -0,0 +1,89 @@
+package models
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// flakyParts fails the first upload of every part.
+type flakyParts struct {
+	api.BuilderAPI
+	tried map[int]bool
+}
+
+func (f *flakyParts) UploadPart(path, uploadID string, partNumber int, data []byte) (*api.UploadedPart, error) {
+	if !f.tried[partNumber] {
+		f.tried[partNumber] = true
+		return nil, errors.New("connection reset")
+	}
+	return f.BuilderAPI.UploadPart(path, uploadID, partNumber, data)
+}
+
+func TestPush(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	client := &flakyParts{BuilderAPI: api.NewBuilderClient(ts.URL, "token", nil), tried: map[int]bool{}}
+
+	dir := t.TempDir()
+	weights := strings.Repeat("w", 25)
+	os.MkdirAll(filepath.Join(dir, "unet"), 0755)
+	os.MkdirAll(filepath.Join(dir, ".cache"), 0755)
+	os.WriteFile(filepath.Join(dir, "unet", "model.safetensors"), []byte(weights), 0644)
+	os.WriteFile(filepath.Join(dir, "config.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(dir, ".cache", "lock"), []byte("x"), 0644)
+
+	progress := ui.NewWithMode(io.Discard, ui.ModePlain)
+	defer progress.Close()
+	model, err := push(progress, client, PushOptions{Path: dir, Name: "my-lora", ChunkSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if model.ID != "cozy:my-lora" || model.Path != "models/my-lora" || model.SizeBytes != 27 {
+		t.Errorf("unexpected model %+v", model)
+	}
+	want := []api.ModelFile{
+		{Path: "config.json", Size: 2, SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte("{}")))},
+		{Path: "unet/model.safetensors", Size: 25, SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte(weights)))},
+	}
+	if fmt.Sprint(model.Files) != fmt.Sprint(want) {
+		t.Errorf("unexpected files:\n got %v\nwant %v", model.Files, want)
+	}
+	if len(client.tried) != 3 {
+		t.Errorf("expected the 25-byte file in 3 parts, got %d", len(client.tried))
+	}
+}
+
+func TestPushSingleFile(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "lora.safetensors")
+	os.WriteFile(path, []byte("weights"), 0644)
+
+	progress := ui.NewWithMode(io.Discard, ui.ModePlain)
+	defer progress.Close()
+	model, err := push(progress, api.NewBuilderClient(ts.URL, "token", nil), PushOptions{Path: path, Name: "lora"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Files) != 1 || model.Files[0].Path != "lora.safetensors" {
+		t.Errorf("unexpected files %v", model.Files)
+	}
+
+	_, err = push(progress, api.NewBuilderClient(ts.URL, "token", nil), PushOptions{Path: path, Name: "My LoRA"})
+	if err == nil || !strings.Contains(err.Error(), "invalid model name") {
+		t.Errorf("expected invalid name error, got %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+// Package models validates the models a project references before it is deployed.
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Resolution statuses.
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+	StatusError   = "error"
+	StatusSkipped = "skipped" // Not a Hugging Face reference
+)
+
+// SourceHuggingFace is the registration source of Hugging Face models.
+const SourceHuggingFace = "huggingface"
+
+// ResolveOptions contains the options for resolving model references.
+type ResolveOptions struct {
+	Profile    config.ProfileRef
+	Transport  http.RoundTripper
+	ProjectDir string
+	Refs       []string // Resolve these instead of the project's references
+	Register   bool     // Pre-register resolved models with cozy-hub
+	HFEndpoint string   // Default HF_ENDPOINT, then DefaultHFEndpoint
+	Output     ui.Output
+}
+
+// Result is the resolution of one model reference.
+type Result struct {
+	Key        string   `json:"key,omitempty"` // [tool.cozy.models] key
+	Ref        string   `json:"ref,omitempty"`
+	Repo       string   `json:"repo,omitempty"`
+	Revision   string   `json:"revision,omitempty"`
+	SHA        string   `json:"sha,omitempty"`
+	Gated      string   `json:"gated,omitempty"` // Approval mode of a gated model: auto or manual
+	Private    bool     `json:"private,omitempty"`
+	SizeBytes  int64    `json:"size_bytes,omitempty"`
+	Status     string   `json:"status"`
+	Problems   []string `json:"problems,omitempty"`
+	Registered bool     `json:"registered,omitempty"`
+}
+
+func (r *Result) fail(status, problem string) {
+	// Never downgrade an error to a warning
+	if r.Status != StatusError {
+		r.Status = status
+	}
+	r.Problems = append(r.Problems, problem)
+}
+
+// Resolve checks that the models a project references exist on the
+// Hugging Face Hub and are accessible, and optionally registers them with
+// cozy-hub, so deploys fail fast instead of at cold start.
+func Resolve(ctx context.Context, opts ResolveOptions) (err error) {
+	endpoint := opts.HFEndpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("HF_ENDPOINT")
+	}
+	hf := newHFClient(endpoint, HFToken())
+
+	var hub api.BuilderAPI
+	if opts.Register {
+		if hub, err = api.NewProfileBuilderClient(opts.Profile, opts.Transport); err != nil {
+			return err
+		}
+		recorder := history.Start(opts.Profile, "models resolve")
+		defer func() { recorder.Finish(nil, err) }()
+	}
+	return resolve(ctx, os.Stdout, hf, hub, opts)
+}
+
+func resolve(ctx context.Context, w io.Writer, hf *hfClient, hub api.BuilderAPI, opts ResolveOptions) error {
+	results, err := collect(opts)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No model references found (add [tool.cozy.models] to pyproject.toml, or pass references).")
+		return nil
+	}
+
+	for _, r := range results {
+		if r.Status == "" {
+			resolveOne(ctx, hf, r)
+		}
+		if hub != nil && r.Repo != "" && r.Status != StatusError {
+			register(hub, r)
+		}
+	}
+
+	if opts.Output.Structured() {
+		if err := ui.WriteStructured(w, opts.Output, results); err != nil {
+			return err
+		}
+	} else {
+		writeResults(w, results, opts.Output == ui.OutputWide)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Status == StatusError {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d model references failed to resolve", failed, len(results))
+	}
+	return nil
+}
+
+// collect lists the references to resolve: opts.Refs, or the project's
+// [tool.cozy.models] plus the references its ModelRef annotations make.
+// Annotations naming undefined keys are reported as errors up front.
+func collect(opts ResolveOptions) ([]*Result, error) {
+	var results []*Result
+	if len(opts.Refs) > 0 {
+		for _, ref := range opts.Refs {
+			results = append(results, &Result{Ref: ref})
+		}
+		return results, nil
+	}
+
+	cfg, err := build.GetToolsCozyConfig(filepath.Join(opts.ProjectDir, build.PyProjectTomlPath))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range slices.Sorted(maps.Keys(cfg.Models)) {
+		results = append(results, &Result{Key: key, Ref: cfg.Models[key]})
+	}
+
+	detected, err := build.DetectModelRefs(opts.ProjectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for ModelRef annotations: %w", err)
+	}
+	for _, d := range detected {
+		if d.IsKey() {
+			if _, ok := cfg.Models[d.Ref]; ok || slices.ContainsFunc(results, func(r *Result) bool { return r.Key == d.Ref }) {
+				continue
+			}
+			r := &Result{Key: d.Ref}
+			r.fail(StatusError, fmt.Sprintf("used by ModelRef at %s:%d but not defined in [tool.cozy.models]", d.File, d.Line))
+			results = append(results, r)
+		} else if !slices.ContainsFunc(results, func(r *Result) bool { return r.Ref == d.Ref }) {
+			results = append(results, &Result{Ref: d.Ref})
+		}
+	}
+	return results, nil
+}
+
+func resolveOne(ctx context.Context, hf *hfClient, r *Result) {
+	repo, revision, ok, err := ParseRef(r.Ref)
+	switch {
+	case err != nil:
+		r.fail(StatusError, err.Error())
+		return
+	case !ok:
+		r.Status = StatusSkipped
+		r.Problems = append(r.Problems, "not a Hugging Face reference")
+		return
+	}
+	r.Repo, r.Revision = repo, revision
+
+	m, err := hf.model(ctx, repo, revision)
+	if errors.Is(err, errNoAccess) {
+		problem := "not found on the Hugging Face Hub"
+		if hf.token == "" {
+			problem += " (if it is private, set HF_TOKEN)"
+		} else {
+			problem += " (or HF_TOKEN can't access it)"
+		}
+		if revision != "" {
+			problem = fmt.Sprintf("revision %q %s", revision, problem)
+		}
+		r.fail(StatusError, problem)
+		return
+	}
+	if err != nil {
+		r.fail(StatusError, err.Error())
+		return
+	}
+
+	r.Status = StatusOK
+	r.SHA = m.SHA
+	r.Gated = string(m.Gated)
+	r.Private = m.Private
+	r.SizeBytes = m.size()
+	if r.Gated != "" && hf.token == "" {
+		r.fail(StatusWarning, fmt.Sprintf("gated (%s approval): set HF_TOKEN to a token whose account accepted the model's terms, and give workers the same token", r.Gated))
+	}
+}
+
+func register(hub api.BuilderAPI, r *Result) {
+	id := r.Ref
+	if !strings.HasPrefix(id, "hf:") {
+		id = "hf:" + id
+	}
+	_, err := hub.RegisterModel(&api.RegisterModelRequest{
+		ID:        id,
+		Source:    SourceHuggingFace,
+		Repo:      r.Repo,
+		Revision:  r.Revision,
+		SHA:       r.SHA,
+		SizeBytes: r.SizeBytes,
+		Gated:     r.Gated != "",
+	})
+	if err != nil {
+		r.fail(StatusError, fmt.Sprintf("failed to register with cozy-hub: %v", err))
+		return
+	}
+	r.Registered = true
+}
+
+// hfRepo matches a Hub repository ID: "name" or "owner/name".
+var hfRepo = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*(/[A-Za-z0-9][A-Za-z0-9._-]*)?$`)
+
+// ParseRef parses a Hugging Face model reference: "hf:owner/name", with an
+// optional "@revision", or a bare "owner/name". ok is false for references
+// with another scheme, which are left to the worker to resolve.
+func ParseRef(ref string) (repo, revision string, ok bool, err error) {
+	rest := ref
+	if scheme, after, found := strings.Cut(ref, ":"); found {
+		if scheme != "hf" {
+			return "", "", false, nil
+		}
+		rest = after
+	}
+	repo, revision, _ = strings.Cut(rest, "@")
+	if !hfRepo.MatchString(repo) {
+		return "", "", false, fmt.Errorf("invalid Hugging Face model ID %q (expected owner/name)", repo)
+	}
+	return repo, revision, true, nil
+}
+
+func writeResults(w io.Writer, results []*Result, wide bool) {
+	table := &ui.Table{Columns: []string{"KEY", "REF", "STATUS", "SIZE", "GATED", "REGISTERED"}}
+	if wide {
+		table.Columns = append(table.Columns, "SHA")
+	}
+	for _, r := range results {
+		size := "-"
+		if r.SizeBytes > 0 {
+			size = ui.FormatBytes(r.SizeBytes)
+		}
+		registered := "-"
+		if r.Registered {
+			registered = "yes"
+		}
+		cells := []string{orDash(r.Key), orDash(r.Ref), r.Status, size, orDash(r.Gated), registered}
+		if wide {
+			cells = append(cells, orDash(r.SHA))
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: r.Key + "/" + r.Ref, Status: r.Status, Cells: cells})
+	}
+	table.Write(w)
+
+	var notes []string
+	for _, r := range results {
+		name := r.Key
+		if name == "" {
+			name = r.Ref
+		}
+		for _, p := range r.Problems {
+			notes = append(notes, fmt.Sprintf("  %s: %s", name, p))
+		}
+	}
+	if len(notes) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, strings.Join(notes, "\n"))
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+// fakeHub serves model info for a public and a gated model.
+func fakeHub(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/models/stabilityai/sdxl-turbo":
+			w.Write([]byte(`{"id": "stabilityai/sdxl-turbo", "sha": "abc123", "gated": false,
+				"siblings": [{"rfilename": "model.safetensors", "size": 2000000000}, {"rfilename": "README.md", "size": 1000}]}`))
+		case "/api/models/black-forest-labs/FLUX.1-dev/revision/main":
+			json.NewEncoder(w).Encode(map[string]any{"id": "black-forest-labs/FLUX.1-dev", "sha": "def456", "gated": "manual"})
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestResolveProject(t *testing.T) {
+	dir := t.TempDir()
+	pyproject := `[tool.cozy.models]
+sdxl-turbo = "hf:stabilityai/sdxl-turbo"
+flux = "hf:black-forest-labs/FLUX.1-dev@main"
+`
+	functions := `@worker_function()
+def generate(
+    pipeline: Annotated[Pipeline, ModelRef(Src.DEPLOYMENT, "sdxl-turbo")],
+    upscaler: Annotated[Pipeline, ModelRef(Src.DEPLOYMENT, "upscaler")],
+):
+    pass
+`
+	os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644)
+	os.WriteFile(filepath.Join(dir, "functions.py"), []byte(functions), 0644)
+
+	hub := mockserver.New()
+	ts := httptest.NewServer(hub.Handler())
+	defer ts.Close()
+
+	var out bytes.Buffer
+	hf := newHFClient(fakeHub(t).URL, "")
+	err := resolve(context.Background(), &out, hf, api.NewBuilderClient(ts.URL, "token", nil), ResolveOptions{ProjectDir: dir, Register: true})
+	if err == nil || err.Error() != "1 of 3 model references failed to resolve" {
+		t.Errorf("expected the undefined key to fail, got %v", err)
+	}
+
+	for _, want := range []string{
+		"flux        hf:black-forest-labs/FLUX.1-dev@main  warning  -        manual  yes",
+		"sdxl-turbo  hf:stabilityai/sdxl-turbo             ok       1.9 GiB  -       yes",
+		"upscaler    -                                     error    -        -       -",
+		"flux: gated (manual approval): set HF_TOKEN",
+		"upscaler: used by ModelRef at functions.py:4 but not defined in [tool.cozy.models]",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	hf := newHFClient(fakeHub(t).URL, "hf_token")
+
+	var out bytes.Buffer
+	err := resolve(context.Background(), &out, hf, nil, ResolveOptions{Refs: []string{"hf:black-forest-labs/FLUX.1-dev@main", "hf:someone/missing", "cozy:sdxl"}})
+	if err == nil || err.Error() != "1 of 3 model references failed to resolve" {
+		t.Errorf("expected the missing model to fail, got %v\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		"hf:black-forest-labs/FLUX.1-dev@main  ok",
+		"hf:someone/missing: not found on the Hugging Face Hub (or HF_TOKEN can't access it)",
+		"cozy:sdxl: not a Hugging Face reference",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	for _, tc := range []struct {
+		ref, repo, revision string
+		ok, err             bool
+	}{
+		{"hf:stabilityai/sdxl-turbo", "stabilityai/sdxl-turbo", "", true, false},
+		{"hf:org/model@v1.0", "org/model", "v1.0", true, false},
+		{"gpt2", "gpt2", "", true, false},
+		{"s3:bucket/model", "", "", false, false},
+		{"hf:org/model/extra", "", "", false, true},
+	} {
+		repo, revision, ok, err := ParseRef(tc.ref)
+		if repo != tc.repo || revision != tc.revision || ok != tc.ok || (err != nil) != tc.err {
+			t.Errorf("ParseRef(%q) = %q, %q, %v, %v", tc.ref, repo, revision, ok, err)
+		}
+	}
+}
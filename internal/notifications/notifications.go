@@ -0,0 +1,216 @@
+// Package notifications configures the tenant's server-side notifications,
+// which email or call a webhook when builds and deploys finish, so failures
+// are noticed without polling.
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// SetOptions contains the options for setting a notification rule.
+type SetOptions struct {
+	Profile    config.ProfileRef
+	Transport  http.RoundTripper
+	Events     []string
+	Channel    string
+	Target     string // Email address or webhook URL
+	Deployment string // Empty for every deployment
+	Output     ui.Output
+}
+
+// ListOptions contains the options for listing notification rules.
+type ListOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Output    ui.Output
+}
+
+// ClearOptions contains the options for deleting notification rules.
+type ClearOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	IDs       []string // Every rule when empty
+	Yes       bool     // Delete without prompting
+}
+
+// ParseEvents parses a comma-separated list of events; "all" means every event.
+func ParseEvents(s string) ([]string, error) {
+	var events []string
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		switch {
+		case e == "":
+			continue
+		case e == "all":
+			return slices.Clone(api.NotificationEvents), nil
+		case !slices.Contains(api.NotificationEvents, e):
+			return nil, fmt.Errorf("invalid event %q: must be one of %s, or all", e, strings.Join(api.NotificationEvents, ", "))
+		case !slices.Contains(events, e):
+			events = append(events, e)
+		}
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("at least one event is required (--on)")
+	}
+	return events, nil
+}
+
+// Set creates a notification rule, or updates the events of the rule with
+// the same channel, target, and deployment.
+func Set(opts SetOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return set(os.Stdout, client, opts)
+}
+
+func set(w io.Writer, client api.BuilderAPI, opts SetOptions) error {
+	switch opts.Channel {
+	case api.ChannelEmail:
+	case api.ChannelWebhook:
+		if opts.Target == "" {
+			return fmt.Errorf("--target is required for webhooks (an https URL)")
+		}
+	default:
+		return fmt.Errorf("invalid channel %q: must be %s or %s", opts.Channel, api.ChannelEmail, api.ChannelWebhook)
+	}
+
+	rule, err := client.SetNotificationRule(&api.SetNotificationRuleRequest{
+		Events:     opts.Events,
+		Channel:    opts.Channel,
+		Target:     opts.Target,
+		Deployment: opts.Deployment,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set notification rule: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, rule)
+	}
+	fmt.Fprintf(w, "Notifying %s on %s for %s (%s)\n", describeChannel(*rule), strings.Join(rule.Events, ", "), describeDeployment(*rule), rule.ID)
+	return nil
+}
+
+// List prints the tenant's notification rules.
+func List(opts ListOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return list(os.Stdout, client, opts)
+}
+
+func list(w io.Writer, client api.BuilderAPI, opts ListOptions) error {
+	rules, err := client.ListNotificationRules()
+	if err != nil {
+		return fmt.Errorf("failed to list notification rules: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, rules)
+	}
+
+	if len(rules) == 0 {
+		fmt.Fprintln(w, "No notifications configured. Set one with 'cozyctl notifications set --on build-failed,deploy-failed --channel email'.")
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"ID", "EVENTS", "CHANNEL", "TARGET", "DEPLOYMENT"}}
+	for _, r := range rules {
+		target := r.Target
+		if target == "" && r.Channel == api.ChannelEmail {
+			target = "(account email)"
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: r.ID, Cells: []string{
+			r.ID, strings.Join(r.Events, ","), r.Channel, target, describeDeployment(r),
+		}})
+	}
+	return table.Write(w)
+}
+
+// Clear deletes notification rules, or all of them.
+func Clear(opts ClearOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return clearRules(os.Stdin, os.Stdout, client, opts)
+}
+
+func clearRules(in io.Reader, out io.Writer, client api.BuilderAPI, opts ClearOptions) error {
+	ids := opts.IDs
+	if len(ids) == 0 {
+		rules, err := client.ListNotificationRules()
+		if err != nil {
+			return fmt.Errorf("failed to list notification rules: %w", err)
+		}
+		if len(rules) == 0 {
+			fmt.Fprintln(out, "No notifications configured.")
+			return nil
+		}
+		for _, r := range rules {
+			ids = append(ids, r.ID)
+		}
+		if !opts.Yes {
+			ok, err := ui.Confirm(in, out, fmt.Sprintf("Delete all %d notification %s?", len(ids), plural(len(ids), "rule", "rules")))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(out, "Aborted.")
+				return nil
+			}
+		}
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := client.DeleteNotificationRule(id); err != nil {
+			fmt.Fprintf(out, "Failed to delete %s: %v\n", id, err)
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		fmt.Fprintf(out, "Deleted %s\n", id)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete %d of %d notification %s: %w", len(errs), len(ids), plural(len(ids), "rule", "rules"), errors.Join(errs...))
+	}
+	return nil
+}
+
+func describeChannel(r api.NotificationRule) string {
+	switch {
+	case r.Channel == api.ChannelEmail && r.Target == "":
+		return "your account email"
+	case r.Channel == api.ChannelEmail:
+		return r.Target
+	default:
+		return "webhook " + r.Target
+	}
+}
+
+func describeDeployment(r api.NotificationRule) string {
+	if r.Deployment == "" {
+		return "all deployments"
+	}
+	return r.Deployment
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,96 @@
+package notifications
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestSetListClear(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	var out bytes.Buffer
+	if err := set(&out, client, SetOptions{Events: []string{api.NotifyBuildFailed}, Channel: api.ChannelEmail}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Notifying your account email on build-failed for all deployments") {
+		t.Errorf("unexpected set output:\n%s", out.String())
+	}
+
+	// The same channel, target, and deployment updates the rule
+	opts := SetOptions{Events: []string{api.NotifyBuildFailed, api.NotifyDeployFailed}, Channel: api.ChannelEmail}
+	if err := set(&out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	hook := SetOptions{Events: []string{api.NotifyDeployFailed}, Channel: api.ChannelWebhook, Target: "https://hooks.example.com/cozy", Deployment: "my-model"}
+	if err := set(&out, client, hook); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := client.ListNotificationRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || len(rules[0].Events) != 2 || rules[1].Deployment != "my-model" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+
+	out.Reset()
+	if err := list(&out, client, ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "(account email)") || !strings.Contains(out.String(), "https://hooks.example.com/cozy") {
+		t.Errorf("unexpected list output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := clearRules(strings.NewReader("y\n"), &out, client, ClearOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if rules, _ := client.ListNotificationRules(); len(rules) != 0 {
+		t.Errorf("rules left after clear: %+v", rules)
+	}
+}
+
+func TestSetValidation(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	tests := []struct {
+		name string
+		opts SetOptions
+		want string
+	}{
+		{"webhook without target", SetOptions{Events: []string{api.NotifyBuildFailed}, Channel: api.ChannelWebhook}, "--target is required"},
+		{"plain http webhook", SetOptions{Events: []string{api.NotifyBuildFailed}, Channel: api.ChannelWebhook, Target: "http://example.com"}, "https URL"},
+		{"unknown channel", SetOptions{Events: []string{api.NotifyBuildFailed}, Channel: "sms"}, "invalid channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := set(&bytes.Buffer{}, client, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	events, err := ParseEvents("build-failed, deploy-failed,build-failed")
+	if err != nil || strings.Join(events, ",") != "build-failed,deploy-failed" {
+		t.Errorf("got %v, %v", events, err)
+	}
+	if events, _ := ParseEvents("all"); len(events) != len(api.NotificationEvents) {
+		t.Errorf("all = %v", events)
+	}
+	if _, err := ParseEvents("build-exploded"); err == nil {
+		t.Error("expected an error for an unknown event")
+	}
+}
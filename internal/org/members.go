@@ -0,0 +1,249 @@
+// Package org manages who belongs to the tenant's organization and with
+// which role.
+package org
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ListOptions contains the options for listing members.
+type ListOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Output    ui.Output
+}
+
+// InviteOptions contains the options for inviting members.
+type InviteOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Emails    []string
+	Role      string
+	Output    ui.Output
+}
+
+// RemoveOptions contains the options for removing members.
+type RemoveOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Members   []string // Member IDs or emails
+	Yes       bool     // Remove without prompting
+}
+
+// SetRoleOptions contains the options for changing a member's role.
+type SetRoleOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Member    string // Member ID or email
+	Role      string
+}
+
+// ParseRole checks that role is an organization role.
+func ParseRole(role string) (string, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if !slices.Contains(api.OrgRoles, role) {
+		return "", fmt.Errorf("invalid role %q: must be one of %s", role, strings.Join(api.OrgRoles, ", "))
+	}
+	return role, nil
+}
+
+// List prints the organization's members and pending invitations.
+func List(opts ListOptions) error {
+	client, err := api.NewProfileAuthClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return list(os.Stdout, client, opts)
+}
+
+func list(w io.Writer, client *api.AuthClient, opts ListOptions) error {
+	members, err := client.ListOrgMembers()
+	if err != nil {
+		return fmt.Errorf("failed to list members: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, members)
+	}
+
+	table := &ui.Table{Columns: []string{"ID", "EMAIL", "USERNAME", "ROLE", "STATUS", "SINCE"}}
+	for _, m := range members {
+		table.Rows = append(table.Rows, ui.Row{Key: m.ID, Status: m.Status, Cells: []string{
+			m.ID, m.Email, orDash(m.Username), m.Role, m.Status, formatTime(m.CreatedAt),
+		}})
+	}
+	return table.Write(w)
+}
+
+// Invite emails invitations to join the organization with a role.
+func Invite(opts InviteOptions) error {
+	client, err := api.NewProfileAuthClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return invite(os.Stdout, client, opts)
+}
+
+func invite(w io.Writer, client *api.AuthClient, opts InviteOptions) error {
+	role, err := ParseRole(opts.Role)
+	if err != nil {
+		return err
+	}
+
+	var invited []api.OrgMember
+	var errs []error
+	for _, email := range opts.Emails {
+		member, err := client.InviteOrgMember(email, role)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", email, err))
+			if !opts.Output.Structured() {
+				fmt.Fprintf(w, "Failed to invite %s: %v\n", email, err)
+			}
+			continue
+		}
+		invited = append(invited, *member)
+		if !opts.Output.Structured() {
+			fmt.Fprintf(w, "Invited %s as %s (%s)\n", member.Email, member.Role, member.ID)
+		}
+	}
+
+	if opts.Output.Structured() {
+		if err := ui.WriteStructured(w, opts.Output, invited); err != nil {
+			return err
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to invite %d of %d: %w", len(errs), len(opts.Emails), errors.Join(errs...))
+	}
+	return nil
+}
+
+// Remove removes members from the organization or withdraws invitations.
+func Remove(opts RemoveOptions) error {
+	client, err := api.NewProfileAuthClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return remove(os.Stdin, os.Stdout, client, opts)
+}
+
+func remove(in io.Reader, out io.Writer, client *api.AuthClient, opts RemoveOptions) error {
+	members, err := client.ListOrgMembers()
+	if err != nil {
+		return fmt.Errorf("failed to list members: %w", err)
+	}
+
+	var targets []api.OrgMember
+	for _, ref := range opts.Members {
+		m, err := findMember(members, ref)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, *m)
+	}
+
+	if !opts.Yes {
+		emails := make([]string, len(targets))
+		for i, m := range targets {
+			emails[i] = m.Email
+		}
+		ok, err := ui.Confirm(in, out, fmt.Sprintf("Remove %s from the organization? They lose access to its deployments", strings.Join(emails, ", ")))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Aborted.")
+			return nil
+		}
+	}
+
+	var errs []error
+	for _, m := range targets {
+		if err := client.RemoveOrgMember(m.ID); err != nil {
+			fmt.Fprintf(out, "Failed to remove %s: %v\n", m.Email, err)
+			errs = append(errs, fmt.Errorf("%s: %w", m.Email, err))
+			continue
+		}
+		if m.Status == api.MemberInvited {
+			fmt.Fprintf(out, "Withdrew the invitation of %s\n", m.Email)
+		} else {
+			fmt.Fprintf(out, "Removed %s\n", m.Email)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove %d of %d: %w", len(errs), len(targets), errors.Join(errs...))
+	}
+	return nil
+}
+
+// SetRole changes a member's role.
+func SetRole(opts SetRoleOptions) error {
+	client, err := api.NewProfileAuthClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return setRole(os.Stdout, client, opts)
+}
+
+func setRole(w io.Writer, client *api.AuthClient, opts SetRoleOptions) error {
+	role, err := ParseRole(opts.Role)
+	if err != nil {
+		return err
+	}
+	members, err := client.ListOrgMembers()
+	if err != nil {
+		return fmt.Errorf("failed to list members: %w", err)
+	}
+	m, err := findMember(members, opts.Member)
+	if err != nil {
+		return err
+	}
+	if m.Role == role {
+		fmt.Fprintf(w, "%s is already %s\n", m.Email, role)
+		return nil
+	}
+
+	updated, err := client.SetOrgMemberRole(m.ID, role)
+	if err != nil {
+		return fmt.Errorf("failed to change the role of %s: %w", m.Email, err)
+	}
+	fmt.Fprintf(w, "%s is now %s (was %s)\n", updated.Email, updated.Role, m.Role)
+	return nil
+}
+
+// findMember finds a member by ID or, case-insensitively, by email.
+func findMember(members []api.OrgMember, ref string) (*api.OrgMember, error) {
+	for i, m := range members {
+		if m.ID == ref || strings.EqualFold(m.Email, ref) {
+			return &members[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no member or invitation matches '%s' (see 'cozyctl org members list')", ref)
+}
+
+// formatTime shows an RFC 3339 timestamp in local time; other values are shown as-is.
+func formatTime(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return orDash(ts)
+	}
+	return t.Local().Format("2006-01-02 15:04 MST")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package org
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func newMockClient(t *testing.T) *api.AuthClient {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	return api.NewAuthClient(ts.URL, "token", nil)
+}
+
+func TestInviteSetRoleRemove(t *testing.T) {
+	client := newMockClient(t)
+
+	var out bytes.Buffer
+	if err := invite(&out, client, InviteOptions{Emails: []string{"alice@example.com", "bob@example.com"}, Role: "Deployer"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Invited alice@example.com as deployer") {
+		t.Errorf("unexpected invite output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := setRole(&out, client, SetRoleOptions{Member: "ALICE@example.com", Role: api.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "alice@example.com is now admin (was deployer)") {
+		t.Errorf("unexpected set-role output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := remove(strings.NewReader("y\n"), &out, client, RemoveOptions{Members: []string{"bob@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Withdrew the invitation of bob@example.com") {
+		t.Errorf("unexpected remove output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := list(&out, client, ListOptions{Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var members []api.OrgMember
+	if err := json.Unmarshal(out.Bytes(), &members); err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 || members[1].Email != "alice@example.com" || members[1].Status != api.MemberInvited {
+		t.Errorf("unexpected members: %+v", members)
+	}
+}
+
+func TestLastAdminStays(t *testing.T) {
+	client := newMockClient(t)
+
+	err := remove(strings.NewReader(""), &bytes.Buffer{}, client, RemoveOptions{Members: []string{"mock@example.com"}, Yes: true})
+	if err == nil || !strings.Contains(err.Error(), "at least one admin") {
+		t.Errorf("got %v, want the last admin kept", err)
+	}
+	err = setRole(&bytes.Buffer{}, client, SetRoleOptions{Member: "mock@example.com", Role: api.RoleViewer})
+	if err == nil || !strings.Contains(err.Error(), "at least one admin") {
+		t.Errorf("got %v, want the last admin kept", err)
+	}
+}
+
+func TestMemberErrors(t *testing.T) {
+	client := newMockClient(t)
+
+	if err := invite(&bytes.Buffer{}, client, InviteOptions{Emails: []string{"a@example.com"}, Role: "owner"}); err == nil || !strings.Contains(err.Error(), "invalid role") {
+		t.Errorf("got %v, want invalid role", err)
+	}
+	if err := remove(strings.NewReader(""), &bytes.Buffer{}, client, RemoveOptions{Members: []string{"nobody@example.com"}, Yes: true}); err == nil || !strings.Contains(err.Error(), "no member") {
+		t.Errorf("got %v, want no member", err)
+	}
+}
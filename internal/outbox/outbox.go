@@ -0,0 +1,168 @@
+// Package outbox keeps deploys that couldn't reach cozy-hub, each a packaged
+// project archive and the request to build and deploy it, in the profile
+// directory (~/.cozy/<name>/<profile>/outbox) until `cozyctl flush` submits
+// them.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/rollout"
+)
+
+// DirName is the outbox inside a profile directory.
+const DirName = "outbox"
+
+// Intent is a queued deploy: build the archive on the server and deploy it.
+type Intent struct {
+	ID           string          `json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ProjectDir   string          `json:"project_dir"`
+	BuildName    string          `json:"build_name"`
+	DeploymentID string          `json:"deployment_id"`
+	PreDeploy    string          `json:"pre_deploy,omitempty"` // [tool.cozy.hooks] pre-deploy command when queued
+	Priority     string          `json:"priority,omitempty"`
+	Machine      string          `json:"machine,omitempty"`
+	SourceDigest string          `json:"source_digest,omitempty"`
+	Size         int64           `json:"size"` // Of the archive, in bytes
+	AutoRollback *rollout.Policy `json:"auto_rollback,omitempty"`
+}
+
+// Dir returns the outbox of the profile selected by ref.
+func Dir(ref config.ProfileRef) (string, error) {
+	ref, err := config.ResolveProfileRef(ref)
+	if err != nil {
+		return "", err
+	}
+	dir, err := config.ProfileDir(ref.Name, ref.Profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DirName), nil
+}
+
+// Add moves the archive at archivePath into the outbox with the intent to
+// deploy it, and returns the intent with its ID and creation time set.
+func Add(ref config.ProfileRef, intent Intent, archivePath string) (Intent, error) {
+	dir, err := Dir(ref)
+	if err != nil {
+		return intent, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return intent, fmt.Errorf("failed to create outbox: %w", err)
+	}
+
+	intent.CreatedAt = time.Now().UTC()
+	intent.ID = intent.CreatedAt.Format("20060102-150405") + "-" + intent.DeploymentID
+	for n := 2; exists(filepath.Join(dir, intent.ID+".json")); n++ {
+		intent.ID = fmt.Sprintf("%s-%s-%d", intent.CreatedAt.Format("20060102-150405"), intent.DeploymentID, n)
+	}
+
+	if err := moveFile(archivePath, filepath.Join(dir, intent.ID+".tar.gz")); err != nil {
+		return intent, fmt.Errorf("failed to queue archive: %w", err)
+	}
+	data, err := json.MarshalIndent(intent, "", "  ")
+	if err != nil {
+		return intent, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, intent.ID+".json"), append(data, '\n'), 0600); err != nil {
+		os.Remove(filepath.Join(dir, intent.ID+".tar.gz"))
+		return intent, fmt.Errorf("failed to queue deploy: %w", err)
+	}
+	return intent, nil
+}
+
+// List returns the queued deploys of the profile selected by ref, oldest
+// first. Intents that can't be read are skipped.
+func List(ref config.ProfileRef) ([]Intent, error) {
+	dir, err := Dir(ref)
+	if err != nil {
+		return nil, err
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var intents []Intent
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		var intent Intent
+		if json.Unmarshal(data, &intent) != nil || intent.ID != strings.TrimSuffix(filepath.Base(name), ".json") {
+			continue
+		}
+		intents = append(intents, intent)
+	}
+	slices.SortFunc(intents, func(a, b Intent) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return intents, nil
+}
+
+// ArchivePath returns where the archive of a queued deploy is kept.
+func ArchivePath(ref config.ProfileRef, id string) (string, error) {
+	dir, err := Dir(ref)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, id+".tar.gz"), nil
+}
+
+// Remove takes a deploy out of the outbox, deleting its archive.
+func Remove(ref config.ProfileRef, id string) error {
+	dir, err := Dir(ref)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, id+".json")); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no queued deploy %s", id)
+		}
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, id+".tar.gz")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// moveFile renames src to dst, copying when they are on different file
+// systems (e.g. an archive packaged in the temp directory).
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+)
+
+func TestAddListRemove(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ref := config.ProfileRef{Name: "work", Profile: "dev"}
+
+	add := func() Intent {
+		t.Helper()
+		archive := filepath.Join(t.TempDir(), "source.tar.gz")
+		if err := os.WriteFile(archive, []byte("tarball"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		intent, err := Add(ref, Intent{DeploymentID: "demo", Size: 7}, archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(archive); !os.IsNotExist(err) {
+			t.Error("expected the archive to be moved into the outbox")
+		}
+		return intent
+	}
+	first, second := add(), add()
+	if first.ID == second.ID {
+		t.Fatalf("expected distinct IDs, got %s twice", first.ID)
+	}
+
+	// A corrupt intent is skipped
+	dir, _ := Dir(ref)
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	intents, err := List(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 2 || intents[0].ID != first.ID || intents[1].ID != second.ID {
+		t.Fatalf("List() = %+v", intents)
+	}
+
+	if err := Remove(ref, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if archive, _ := ArchivePath(ref, first.ID); fileExists(archive) {
+		t.Error("expected the archive to be removed")
+	}
+	if err := Remove(ref, first.ID); err == nil {
+		t.Error("expected an error removing a deploy that isn't queued")
+	}
+	if intents, _ := List(ref); len(intents) != 1 {
+		t.Errorf("expected one queued deploy left, got %+v", intents)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
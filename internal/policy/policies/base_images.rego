@@ -0,0 +1,16 @@
+# Restricts the base images projects may build on.
+#
+# Params: allowed_base_images (list of image prefixes, e.g. "ghcr.io/cozy-creator/")
+package cozy.deploy
+
+deny contains msg if {
+	allowed := data.cozy.params.allowed_base_images
+	image := input.project.base_image
+	not has_prefix(image, allowed)
+	msg := sprintf("base image %s is not allowed (allowed: %s)", [image, concat(", ", allowed)])
+}
+
+has_prefix(s, prefixes) if {
+	some prefix in prefixes
+	startswith(s, prefix)
+}
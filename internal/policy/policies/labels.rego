@@ -0,0 +1,10 @@
+# Requires projects to set labels under [tool.cozy.labels].
+#
+# Params: required_labels (list of label names)
+package cozy.deploy
+
+deny contains msg if {
+	some label in data.cozy.params.required_labels
+	not input.project.labels[label]
+	msg := sprintf("label %q is required in [tool.cozy.labels]", [label])
+}
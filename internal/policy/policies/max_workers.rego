@@ -0,0 +1,10 @@
+# Limits how many workers a deployment may scale to.
+#
+# Params: max_workers (number)
+package cozy.deploy
+
+deny contains msg if {
+	limit := data.cozy.params.max_workers
+	input.request.max_workers > limit
+	msg := sprintf("max_workers %d exceeds the limit of %d", [input.request.max_workers, limit])
+}
@@ -0,0 +1,224 @@
+// Package policy evaluates deployment requests against Rego policies before
+// they are submitted, so organizations can enforce rules such as worker
+// limits or allowed base images without changes to the CLI.
+//
+// Policies are Rego v1 modules in package cozy.deploy that add messages to
+// the deny (blocking) and warn (reported only) sets. They see the request
+// as input and their parameters as data.cozy.params. A few common policies
+// are bundled; they only take effect once their parameters are set.
+package policy
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/loader"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/storage"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+	"github.com/open-policy-agent/opa/v1/tester"
+)
+
+//go:embed policies/*.rego
+var bundled embed.FS
+
+// query reads the decisions of every policy.
+const query = "data.cozy.deploy"
+
+// Input is the document policies are evaluated against.
+type Input struct {
+	Action       string  `json:"action"` // "create" or "update"
+	DeploymentID string  `json:"deployment_id"`
+	Request      any     `json:"request"` // The CreateDeployment or UpdateDeployment payload
+	Project      Project `json:"project"`
+}
+
+// Project describes the project a deployment is built from.
+type Project struct {
+	Dir         string            `json:"dir"`
+	BaseImage   string            `json:"base_image,omitempty"`
+	Python      string            `json:"python,omitempty"`
+	Pytorch     string            `json:"pytorch,omitempty"`
+	Cuda        string            `json:"cuda,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
+}
+
+// Decision is the outcome of evaluating the policies.
+type Decision struct {
+	Deny []string `json:"deny,omitempty"`
+	Warn []string `json:"warn,omitempty"`
+}
+
+// Allowed reports whether no policy denied the request.
+func (d *Decision) Allowed() bool {
+	return len(d.Deny) == 0
+}
+
+// Engine evaluates inputs against a fixed set of policies.
+type Engine struct {
+	prepared rego.PreparedEvalQuery
+}
+
+// Load compiles the bundled policies together with the Rego files found in
+// paths (files or directories), with params as data.cozy.params.
+func Load(ctx context.Context, paths []string, params map[string]any) (*Engine, error) {
+	modules, err := Modules(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := Store(params)
+	if err != nil {
+		return nil, err
+	}
+	options := []func(*rego.Rego){rego.Query(query), rego.Store(store)}
+	for _, m := range modules {
+		options = append(options, rego.ParsedModule(m))
+	}
+	prepared, err := rego.New(options...).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile policies: %w", err)
+	}
+	return &Engine{prepared: prepared}, nil
+}
+
+// Evaluate returns the decision of the policies on input.
+func (e *Engine) Evaluate(ctx context.Context, input Input) (*Decision, error) {
+	// Round-trip through JSON so policies see the same field names as the API
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return e.EvaluateDocument(ctx, doc)
+}
+
+// EvaluateDocument returns the decision of the policies on a raw input
+// document, e.g. one written by hand while authoring a policy.
+func (e *Engine) EvaluateDocument(ctx context.Context, doc any) (*Decision, error) {
+	results, err := e.prepared.Eval(ctx, rego.EvalInput(doc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate policies: %w", err)
+	}
+
+	decision := &Decision{}
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
+		return decision, nil
+	}
+	rules, _ := results[0].Expressions[0].Value.(map[string]any)
+	if decision.Deny, err = messages(rules, "deny"); err != nil {
+		return nil, err
+	}
+	if decision.Warn, err = messages(rules, "warn"); err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+// messages reads a set of strings from the policy results.
+func messages(rules map[string]any, name string) ([]string, error) {
+	values, _ := rules[name].([]any)
+	msgs := make([]string, 0, len(values))
+	for _, v := range values {
+		msg, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("policy rule %s.%s must produce strings, got %v", query, name, v)
+		}
+		msgs = append(msgs, msg)
+	}
+	slices.Sort(msgs)
+	return msgs, nil
+}
+
+// Modules parses the bundled policies and the Rego files in paths.
+func Modules(paths []string) (map[string]*ast.Module, error) {
+	modules := map[string]*ast.Module{}
+
+	entries, err := fs.ReadDir(bundled, "policies")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := path.Join("policies", entry.Name())
+		src, err := fs.ReadFile(bundled, name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := ast.ParseModuleWithOpts("bundled/"+entry.Name(), string(src), ast.ParserOptions{RegoVersion: ast.RegoV1})
+		if err != nil {
+			return nil, fmt.Errorf("bundled policy %s: %w", entry.Name(), err)
+		}
+		modules["bundled/"+entry.Name()] = m
+	}
+
+	if len(paths) == 0 {
+		return modules, nil
+	}
+	result, err := loader.NewFileLoader().
+		WithRegoVersion(ast.RegoV1).
+		Filtered(paths, func(_ string, info fs.FileInfo, _ int) bool {
+			return !info.IsDir() && !strings.HasSuffix(info.Name(), ".rego")
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policies: %w", err)
+	}
+	for name, m := range result.ParsedModules() {
+		modules[name] = m
+	}
+	return modules, nil
+}
+
+// Store returns a policy data store holding params as data.cozy.params.
+func Store(params map[string]any) (storage.Store, error) {
+	// Normalize values decoded from TOML to the JSON types policies expect
+	data, err := json.Marshal(map[string]any{"cozy": map[string]any{"params": params}})
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy params: %w", err)
+	}
+	var doc map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid policy params: %w", err)
+	}
+	return inmem.NewFromObject(doc), nil
+}
+
+// RunTests runs the Rego unit tests (rules named test_*) found in paths, with
+// the bundled policies loaded so they can be tested too.
+func RunTests(ctx context.Context, paths []string, params map[string]any) ([]*tester.Result, error) {
+	modules, err := Modules(paths)
+	if err != nil {
+		return nil, err
+	}
+	store, err := Store(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := tester.NewRunner().
+		SetStore(store).
+		SetDefaultRegoVersion(ast.RegoV1).
+		CapturePrintOutput(true).
+		Run(ctx, modules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run policy tests: %w", err)
+	}
+	var results []*tester.Result
+	for r := range ch {
+		results = append(results, r)
+	}
+	return results, nil
+}
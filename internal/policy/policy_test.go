@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundledPolicies(t *testing.T) {
+	ctx := context.Background()
+	input := Input{
+		Action:       "create",
+		DeploymentID: "my-model",
+		Request:      map[string]any{"id": "my-model", "max_workers": 20},
+		Project: Project{
+			BaseImage: "docker.io/library/python:3.11-slim",
+			Labels:    map[string]string{"team": "ml"},
+		},
+	}
+
+	// Without params the bundled policies allow everything
+	engine, err := Load(ctx, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decision, err := engine.Evaluate(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decision.Allowed() || len(decision.Warn) > 0 {
+		t.Errorf("decision without params = %+v", decision)
+	}
+
+	engine, err = Load(ctx, nil, map[string]any{
+		"max_workers":         int64(10),
+		"allowed_base_images": []any{"ghcr.io/cozy-creator/"},
+		"required_labels":     []any{"team", "cost-center"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decision, err = engine.Evaluate(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"base image docker.io/library/python:3.11-slim is not allowed (allowed: ghcr.io/cozy-creator/)",
+		`label "cost-center" is required in [tool.cozy.labels]`,
+		"max_workers 20 exceeds the limit of 10",
+	}
+	if strings.Join(decision.Deny, "\n") != strings.Join(want, "\n") {
+		t.Errorf("deny = %q, want %q", decision.Deny, want)
+	}
+}
+
+func TestOrgPolicyWarnings(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "latest.rego"), `package cozy.deploy
+
+warn contains msg if {
+	endswith(input.request.image_url, ":latest")
+	msg := "image uses the :latest tag"
+}
+`)
+
+	engine, err := Load(context.Background(), []string{dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decision, err := engine.EvaluateDocument(context.Background(), map[string]any{
+		"request": map[string]any{"image_url": "registry.example/my-model:latest"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decision.Allowed() || len(decision.Warn) != 1 || decision.Warn[0] != "image uses the :latest tag" {
+		t.Errorf("decision = %+v", decision)
+	}
+}
+
+func TestLoadInvalidPolicy(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "broken.rego"), "package cozy.deploy\n\ndeny contains msg if {\n")
+	if _, err := Load(context.Background(), []string{dir}, nil); err == nil {
+		t.Fatal("expected an error for a policy that does not parse")
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "limits_test.rego"), `package cozy.deploy_test
+
+import data.cozy.deploy
+
+test_over_limit if {
+	count(deploy.deny) == 1 with input as {"request": {"max_workers": 50}}
+}
+
+test_fails if {
+	count(deploy.deny) == 1 with input as {"request": {"max_workers": 1}}
+}
+`)
+
+	results, err := RunTests(context.Background(), []string{dir}, map[string]any{"max_workers": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcomes := map[string]bool{}
+	for _, r := range results {
+		outcomes[r.Name] = r.Pass()
+	}
+	if len(outcomes) != 2 || !outcomes["test_over_limit"] || outcomes["test_fails"] {
+		t.Errorf("outcomes = %v", outcomes)
+	}
+}
@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+)
+
+// TestOptions contains the options for testing policies locally.
+type TestOptions struct {
+	ProjectDir string   // Project whose [tool.cozy.policy] is loaded, if it has one
+	Paths      []string // Additional Rego files or directories
+	InputFile  string   // Evaluate this input document instead of running unit tests
+}
+
+// Test runs the Rego unit tests of the policies, or with InputFile
+// evaluates the policies against an input document, for authoring policies
+// without deploying.
+func Test(opts TestOptions) error {
+	return test(context.Background(), os.Stdout, opts)
+}
+
+func test(ctx context.Context, w io.Writer, opts TestOptions) error {
+	paths, params, err := projectPolicies(opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	paths = append(paths, opts.Paths...)
+
+	if opts.InputFile != "" {
+		return evaluateFile(ctx, w, paths, params, opts.InputFile)
+	}
+
+	results, err := RunTests(ctx, paths, params)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No policy tests found (test rules are named test_*).")
+		return nil
+	}
+
+	failed := 0
+	for _, r := range results {
+		outcome := "PASS"
+		switch {
+		case r.Error != nil:
+			outcome = "ERROR"
+		case r.Skip:
+			outcome = "SKIP"
+		case r.Fail:
+			outcome = "FAIL"
+		}
+		fmt.Fprintf(w, "%-5s %s.%s (%s)\n", outcome, r.Package, r.Name, r.Duration.Round(time.Microsecond))
+		if r.Error != nil {
+			fmt.Fprintf(w, "      %v\n", r.Error)
+		}
+		if !r.Pass() && len(r.Output) > 0 {
+			fmt.Fprintf(w, "      %s", r.Output)
+		}
+		if r.Fail || r.Error != nil {
+			failed++
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d of %d policy tests passed\n", len(results)-failed, len(results))
+	if failed > 0 {
+		return fmt.Errorf("%d policy tests failed", failed)
+	}
+	return nil
+}
+
+// evaluateFile prints the decision of the policies on the input document in path.
+func evaluateFile(ctx context.Context, w io.Writer, paths []string, params map[string]any, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("input %s is not valid JSON: %w", path, err)
+	}
+
+	engine, err := Load(ctx, paths, params)
+	if err != nil {
+		return err
+	}
+	decision, err := engine.EvaluateDocument(ctx, doc)
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range decision.Warn {
+		fmt.Fprintf(w, "WARN  %s\n", msg)
+	}
+	for _, msg := range decision.Deny {
+		fmt.Fprintf(w, "DENY  %s\n", msg)
+	}
+	if !decision.Allowed() {
+		return fmt.Errorf("input denied by %d policy violations", len(decision.Deny))
+	}
+	fmt.Fprintln(w, "Allowed.")
+	return nil
+}
+
+// projectPolicies returns the policy paths (resolved against dir) and
+// params configured in dir's pyproject.toml. A directory without a
+// pyproject.toml has none.
+func projectPolicies(dir string) ([]string, map[string]any, error) {
+	if dir == "" {
+		return nil, nil, nil
+	}
+	pyprojectPath := filepath.Join(dir, build.PyProjectTomlPath)
+	if _, err := os.Stat(pyprojectPath); errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	cozyConfig, err := build.GetToolsCozyConfig(pyprojectPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cozyConfig.Policy == nil {
+		return nil, nil, nil
+	}
+	return ConfigPaths(dir, cozyConfig.Policy), cozyConfig.Policy.Params, nil
+}
+
+// ConfigPaths returns the policy paths of a project's [tool.cozy.policy],
+// with relative paths resolved against the project directory.
+func ConfigPaths(projectDir string, cfg *build.PolicyConfig) []string {
+	paths := make([]string, 0, len(cfg.Paths))
+	for _, p := range cfg.Paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(projectDir, p)
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
@@ -0,0 +1,124 @@
+package profiles
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// PruneOptions contains the options for deleting stale profiles.
+type PruneOptions struct {
+	UnusedFor time.Duration // Profiles not used for this long are stale
+	Yes       bool          // Delete without prompting
+}
+
+// StaleProfile is a profile not used within the pruning window.
+type StaleProfile struct {
+	Name     string
+	Profile  string
+	LastUsed time.Time // Zero if unknown
+	Recorded bool      // LastUsed was recorded, rather than the config's modification time
+}
+
+// Prune offers to delete each profile not used for opts.UnusedFor. The
+// default/default profile and the current profile are never deleted.
+func Prune(opts PruneOptions) error {
+	return prune(os.Stdin, os.Stdout, opts, time.Now())
+}
+
+func prune(in io.Reader, out io.Writer, opts PruneOptions, now time.Time) error {
+	if opts.UnusedFor <= 0 {
+		return fmt.Errorf("--unused-for must be positive")
+	}
+
+	stale, err := StaleProfiles(opts.UnusedFor, now)
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		fmt.Fprintf(out, "No profiles unused for %s.\n", formatAge(opts.UnusedFor))
+		return nil
+	}
+
+	// One reader for every prompt, so buffered answers aren't lost between them
+	reader := bufio.NewReader(in)
+	deleted := 0
+	for _, p := range stale {
+		label := fmt.Sprintf("%s/%s", p.Name, p.Profile)
+		if !opts.Yes {
+			ok, err := ui.Confirm(reader, out, fmt.Sprintf("Delete %s (%s)?", label, describeLastUse(p, now)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+		if err := config.DeleteProfile(p.Name, p.Profile); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Deleted %s\n", label)
+		deleted++
+	}
+
+	fmt.Fprintf(out, "Deleted %d of %d stale profile(s).\n", deleted, len(stale))
+	return nil
+}
+
+// StaleProfiles lists the profiles not used for unusedFor, least recently
+// used first, leaving out default/default and the current profile.
+func StaleProfiles(unusedFor time.Duration, now time.Time) ([]StaleProfile, error) {
+	all, err := config.ListAllProfiles()
+	if err != nil {
+		return nil, err
+	}
+	defaultCfg, err := config.GetDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []StaleProfile
+	for _, p := range all {
+		if p.Name == "default" && p.Profile == "default" {
+			continue
+		}
+		if p.Name == defaultCfg.CurrentName && p.Profile == defaultCfg.CurrentProfile {
+			continue
+		}
+		lastUsed, recorded := config.LastUsed(p.Name, p.Profile)
+		if !lastUsed.IsZero() && now.Sub(lastUsed) < unusedFor {
+			continue
+		}
+		stale = append(stale, StaleProfile{Name: p.Name, Profile: p.Profile, LastUsed: lastUsed, Recorded: recorded})
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		if !stale[i].LastUsed.Equal(stale[j].LastUsed) {
+			return stale[i].LastUsed.Before(stale[j].LastUsed)
+		}
+		if stale[i].Name != stale[j].Name {
+			return stale[i].Name < stale[j].Name
+		}
+		return stale[i].Profile < stale[j].Profile
+	})
+	return stale, nil
+}
+
+// describeLastUse says when a stale profile was last used, or, for profiles
+// without a record, last saved.
+func describeLastUse(p StaleProfile, now time.Time) string {
+	switch {
+	case p.LastUsed.IsZero():
+		return "never used"
+	case p.Recorded:
+		return "last used " + formatAge(now.Sub(p.LastUsed)) + " ago"
+	default:
+		return "not used since it was saved " + formatAge(now.Sub(p.LastUsed)) + " ago"
+	}
+}
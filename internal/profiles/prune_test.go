@@ -0,0 +1,72 @@
+package profiles
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+)
+
+func TestPrune(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Now()
+	day := 24 * time.Hour
+
+	for _, p := range []struct{ name, profile string }{
+		{"default", "default"}, {"work", "old"}, {"work", "recent"}, {"work", "legacy"}, {"work", "current"},
+	} {
+		if err := config.SaveProfileConfig(p.name, p.profile, &config.ProfileConfig{
+			CurrentName: p.name, CurrentProfile: p.profile, Config: &config.ConfigData{Token: "tok"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config.TouchLastUsed("work", "old", now.Add(-100*day))
+	config.TouchLastUsed("work", "recent", now.Add(-day))
+	config.TouchLastUsed("work", "current", now.Add(-300*day))
+	// Saved before last-used tracking: falls back to the config's modification time
+	legacyPath, _ := config.ProfileConfigPath("work", "legacy")
+	if err := os.Chtimes(legacyPath, now.Add(-200*day), now.Add(-200*day)); err != nil {
+		t.Fatal(err)
+	}
+	defaultPath, _ := config.ProfileConfigPath("default", "default")
+	os.Chtimes(defaultPath, now.Add(-400*day), now.Add(-400*day))
+	if err := config.SaveDefaultConfig("work", "current"); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := StaleProfiles(90*day, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	for _, p := range stale {
+		labels = append(labels, p.Name+"/"+p.Profile)
+	}
+	if strings.Join(labels, ",") != "work/legacy,work/old" {
+		t.Fatalf("stale = %v, want work/legacy then work/old", labels)
+	}
+
+	// Delete the first, keep the second
+	var out bytes.Buffer
+	if err := prune(strings.NewReader("y\nn\n"), &out, PruneOptions{UnusedFor: 90 * day}, now); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "not used since it was saved 200d ago") || !strings.Contains(out.String(), "last used 100d ago") {
+		t.Errorf("unexpected prompts:\n%s", out.String())
+	}
+	if config.ProfileExists("work", "legacy") {
+		t.Error("work/legacy not deleted")
+	}
+	for _, p := range []string{"old", "recent", "current"} {
+		if !config.ProfileExists("work", p) {
+			t.Errorf("work/%s deleted", p)
+		}
+	}
+	if !config.ProfileExists("default", "default") {
+		t.Error("default/default deleted")
+	}
+}
@@ -0,0 +1,253 @@
+// Package profiles reports on the saved profiles themselves, as opposed to
+// the resources they give access to.
+package profiles
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Token states.
+const (
+	TokenMissing  = "missing"
+	TokenValid    = "valid"
+	TokenExpiring = "expiring"
+	TokenExpired  = "expired"
+	TokenUnknown  = "unknown" // No recorded expiry and not a JWT
+)
+
+// ShowOptions contains the options for showing a profile.
+type ShowOptions struct {
+	Name    string
+	Profile string
+	Output  ui.Output
+}
+
+// Detail is a profile's resolved settings and state.
+type Detail struct {
+	Name            string   `json:"name"`
+	Profile         string   `json:"profile"`
+	Path            string   `json:"path"`
+	Current         bool     `json:"current"`
+	Environment     string   `json:"environment,omitempty"` // Preset whose URLs the profile uses
+	HubURL          string   `json:"hub_url"`
+	BuilderURL      string   `json:"builder_url"`
+	OrchestratorURL string   `json:"orchestrator_url"`
+	TenantID        string   `json:"tenant_id"`
+	Token           string   `json:"token"` // One of the Token* states
+	TokenExpiresAt  string   `json:"token_expires_at,omitempty"`
+	HasRefreshToken bool     `json:"has_refresh_token"`
+	CredentialStore string   `json:"credential_store"`
+	ReadOnly        bool     `json:"read_only"`
+	LastUsedAt      string   `json:"last_used_at,omitempty"` // When a command last loaded the profile
+	LastCommand     string   `json:"last_command,omitempty"` // Last command in the profile's history
+	LastCommandAt   string   `json:"last_command_at,omitempty"`
+	RegistryPrefix  string   `json:"registry_prefix,omitempty"`
+	EnvOverrides    []string `json:"env_overrides,omitempty"` // COZY_* variables changing settings
+}
+
+// Show prints one profile's resolved settings without switching to it.
+func Show(opts ShowOptions) error {
+	now := time.Now()
+	detail, err := Describe(config.ProfileRef{Name: opts.Name, Profile: opts.Profile}, now)
+	if err != nil {
+		return err
+	}
+	if opts.Output.Structured() {
+		return ui.WriteStructured(os.Stdout, opts.Output, detail)
+	}
+	return render(os.Stdout, detail, now)
+}
+
+// Describe gathers the resolved settings and state of the profile selected
+// by ref.
+func Describe(ref config.ProfileRef, now time.Time) (*Detail, error) {
+	ref, err := config.ResolveProfileRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if !config.ProfileExists(ref.Name, ref.Profile) {
+		return nil, fmt.Errorf("profile '%s/%s' not found (see 'cozyctl profiles')", ref.Name, ref.Profile)
+	}
+
+	resolved, err := config.ResolveConfig(ref)
+	if err != nil {
+		return nil, err
+	}
+	profileCfg, err := config.GetProfileConfig(ref.Name, ref.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile config: %w", err)
+	}
+	cfg := profileCfg.Config
+	if cfg == nil {
+		cfg = &config.ConfigData{}
+	}
+
+	detail := &Detail{
+		Name:            ref.Name,
+		Profile:         ref.Profile,
+		Path:            resolved.Path,
+		HasRefreshToken: cfg.RefreshToken != "",
+		ReadOnly:        cfg.ReadOnly,
+		CredentialStore: config.CredentialStoreFile,
+	}
+	if cfg.CredentialStore == config.CredentialStoreKeychain {
+		detail.CredentialStore = config.CredentialStoreKeychain
+	}
+	values := map[string]string{}
+	for _, s := range resolved.Settings {
+		values[s.Key] = s.Value
+		if strings.HasPrefix(s.Source, "env ") {
+			detail.EnvOverrides = append(detail.EnvOverrides, strings.TrimPrefix(s.Source, "env "))
+		}
+	}
+	detail.HubURL = values["hub_url"]
+	detail.BuilderURL = values["builder_url"]
+	detail.OrchestratorURL = values["orchestrator_url"]
+	detail.TenantID = values["tenant_id"]
+	detail.RegistryPrefix = values["registry_prefix"]
+	detail.Environment = config.EnvironmentOf(&config.ConfigData{
+		HubURL: detail.HubURL, BuilderURL: detail.BuilderURL, OrchestratorURL: detail.OrchestratorURL,
+	})
+
+	detail.Token = TokenMissing
+	if cfg.Token != "" {
+		detail.Token = TokenUnknown
+		if expires, ok := cfg.TokenExpiry(); ok {
+			detail.TokenExpiresAt = expires.UTC().Format(time.RFC3339)
+			switch {
+			case !expires.After(now):
+				detail.Token = TokenExpired
+			case expires.Sub(now) < config.TokenExpiryWarning:
+				detail.Token = TokenExpiring
+			default:
+				detail.Token = TokenValid
+			}
+		}
+	}
+
+	if defaultCfg, err := config.GetDefaultConfig(); err == nil {
+		detail.Current = defaultCfg.CurrentName == ref.Name && defaultCfg.CurrentProfile == ref.Profile
+	}
+
+	if lastUsed, recorded := config.LastUsed(ref.Name, ref.Profile); recorded {
+		detail.LastUsedAt = lastUsed.UTC().Format(time.RFC3339)
+	}
+	entries, err := history.Read(ref)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		detail.LastCommand = last.Command
+		detail.LastCommandAt = last.Time.UTC().Format(time.RFC3339)
+	}
+
+	return detail, nil
+}
+
+// render writes the human-readable view of a profile.
+func render(w io.Writer, d *Detail, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	label := d.Name + "/" + d.Profile
+	if d.Current {
+		label += " (current)"
+	}
+	environment := d.Environment
+	if environment == "" {
+		environment = "custom"
+	}
+
+	fmt.Fprintf(tw, "Profile:\t%s\n", label)
+	fmt.Fprintf(tw, "File:\t%s\n", d.Path)
+	fmt.Fprintf(tw, "Environment:\t%s\n", environment)
+	fmt.Fprintf(tw, "Hub URL:\t%s\n", d.HubURL)
+	fmt.Fprintf(tw, "Builder URL:\t%s\n", d.BuilderURL)
+	fmt.Fprintf(tw, "Orchestrator URL:\t%s\n", d.OrchestratorURL)
+	fmt.Fprintf(tw, "Tenant:\t%s\n", orDash(d.TenantID))
+	fmt.Fprintf(tw, "Token:\t%s\n", tokenSummary(d, now))
+	fmt.Fprintf(tw, "Refresh token:\t%s\n", yesNo(d.HasRefreshToken))
+	fmt.Fprintf(tw, "Credential store:\t%s\n", d.CredentialStore)
+	fmt.Fprintf(tw, "Read-only:\t%s\n", yesNo(d.ReadOnly))
+	if d.RegistryPrefix != "" {
+		fmt.Fprintf(tw, "Registry prefix:\t%s\n", d.RegistryPrefix)
+	}
+	if d.LastUsedAt != "" {
+		fmt.Fprintf(tw, "Last used:\t%s\n", formatWhen(d.LastUsedAt, now))
+	} else {
+		fmt.Fprintf(tw, "Last used:\tnot recorded\n")
+	}
+	if d.LastCommand != "" {
+		fmt.Fprintf(tw, "Last command:\t%s, %s\n", d.LastCommand, formatWhen(d.LastCommandAt, now))
+	}
+	for _, name := range d.EnvOverrides {
+		fmt.Fprintf(tw, "Override:\t%s is set in the environment\n", name)
+	}
+
+	return tw.Flush()
+}
+
+// tokenSummary describes the access token's state and expiry.
+func tokenSummary(d *Detail, now time.Time) string {
+	switch d.Token {
+	case TokenMissing:
+		return "none (run 'cozyctl login')"
+	case TokenUnknown:
+		return "present, expiry unknown"
+	case TokenExpired:
+		return "expired " + formatWhen(d.TokenExpiresAt, now)
+	default:
+		return fmt.Sprintf("%s, expires %s", d.Token, formatWhen(d.TokenExpiresAt, now))
+	}
+}
+
+// formatWhen renders an RFC 3339 time with how far it is from now.
+func formatWhen(value string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	local := t.Local().Format("2006-01-02 15:04")
+	d := t.Sub(now)
+	if d >= 0 {
+		return fmt.Sprintf("%s (in %s)", local, formatAge(d))
+	}
+	return fmt.Sprintf("%s (%s ago)", local, formatAge(-d))
+}
+
+// formatAge renders a duration in its largest whole unit (e.g. "3d", "5m").
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package profiles
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+)
+
+func TestDescribe(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	production, _ := config.LookupEnvironment(config.EnvProduction)
+
+	if err := config.SaveProfileConfig("work", "prod", &config.ProfileConfig{
+		CurrentName:    "work",
+		CurrentProfile: "prod",
+		Config: &config.ConfigData{
+			HubURL:          production.HubURL,
+			BuilderURL:      production.BuilderURL,
+			OrchestratorURL: production.OrchestratorURL,
+			TenantID:        "t-1",
+			Token:           "secret-access-token",
+			RefreshToken:    "secret-refresh-token",
+			TokenExpiresAt:  now.Add(2 * time.Hour).Format(time.RFC3339),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.Append(config.ProfileRef{Name: "work", Profile: "prod"}, history.Entry{
+		Time: now.Add(-time.Hour), Command: "deploy", Status: history.StatusOK,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	detail, err := Describe(config.ProfileRef{Name: "work", Profile: "prod"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Environment != config.EnvProduction || detail.TenantID != "t-1" {
+		t.Errorf("detail = %+v", detail)
+	}
+	if detail.Token != TokenExpiring || !detail.HasRefreshToken || detail.CredentialStore != config.CredentialStoreFile {
+		t.Errorf("token state = %s, refresh %v, store %s", detail.Token, detail.HasRefreshToken, detail.CredentialStore)
+	}
+	if detail.LastCommand != "deploy" || detail.LastCommandAt != now.Add(-time.Hour).Format(time.RFC3339) {
+		t.Errorf("last command = %s at %s", detail.LastCommand, detail.LastCommandAt)
+	}
+	if detail.LastUsedAt != "" {
+		t.Errorf("last used = %s, want none before a command loads the profile", detail.LastUsedAt)
+	}
+
+	var out bytes.Buffer
+	if err := render(&out, detail, now); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "secret-") {
+		t.Errorf("output shows a token:\n%s", out.String())
+	}
+	for _, want := range []string{"work/prod", "production", "expiring, expires", "deploy, "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := Describe(config.ProfileRef{Name: "work", Profile: "missing"}, now); err == nil {
+		t.Error("expected an error for a missing profile")
+	}
+}
@@ -0,0 +1,95 @@
+// Package pypi resolves a project's Python dependencies and looks up their
+// metadata on a PyPI-compatible index.
+package pypi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultIndexURL is the PyPI JSON API.
+const DefaultIndexURL = "https://pypi.org/pypi"
+
+// Client queries the JSON API of a PyPI-compatible index.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the JSON API at baseURL.
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultIndexURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Info is the metadata of one release of a project.
+type Info struct {
+	Name              string   `json:"name"`
+	Version           string   `json:"version"`
+	License           string   `json:"license"`
+	LicenseExpression string   `json:"license_expression"` // SPDX, set by newer packages (PEP 639)
+	Classifiers       []string `json:"classifiers"`
+}
+
+// Vulnerability is a known advisory affecting a release.
+type Vulnerability struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases"`
+	Summary string   `json:"summary"`
+	FixedIn []string `json:"fixed_in"`
+}
+
+// Release is the index's view of one release of a project.
+type Release struct {
+	Info            Info            `json:"info"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// Release fetches a release of a project; an empty version fetches the latest.
+// Returns nil if the index doesn't have it.
+func (c *Client) Release(ctx context.Context, name, version string) (*Release, error) {
+	u := fmt.Sprintf("%s/%s/json", c.baseURL, url.PathEscape(name))
+	if version != "" {
+		u = fmt.Sprintf("%s/%s/%s/json", c.baseURL, url.PathEscape(name), url.PathEscape(version))
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("index returned %d for %s", resp.StatusCode, name)
+	}
+
+	var release Release
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &release, nil
+}
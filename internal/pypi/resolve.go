@@ -0,0 +1,201 @@
+package pypi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Package is a resolved dependency. Version is empty when the project
+// doesn't pin it.
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Resolution is the dependency set of a project.
+type Resolution struct {
+	// Source is where the packages came from: uv.lock, requirements.lock,
+	// requirements.txt, "uv pip compile", or pyproject.toml
+	Source string `json:"source"`
+	// Transitive reports whether the packages include indirect dependencies.
+	// Only direct dependencies are known when Source is pyproject.toml.
+	Transitive bool      `json:"transitive"`
+	Packages   []Package `json:"packages"`
+}
+
+// requirementFiles are pinned requirement lists, in order of preference.
+var requirementFiles = []string{"requirements.lock", "requirements.txt"}
+
+// Resolve returns the dependencies of the Python project in dir. It prefers a
+// lock file (uv.lock, then requirements.lock or requirements.txt), then
+// resolves pyproject.toml with 'uv pip compile' if uv is installed, and
+// otherwise falls back to the direct dependencies declared in pyproject.toml.
+func Resolve(ctx context.Context, dir string) (*Resolution, error) {
+	if data, err := os.ReadFile(filepath.Join(dir, "uv.lock")); err == nil {
+		packages, err := parseUVLock(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse uv.lock: %w", err)
+		}
+		return newResolution("uv.lock", true, packages), nil
+	}
+
+	for _, name := range requirementFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		return newResolution(name, true, ParseRequirements(bytes.NewReader(data))), nil
+	}
+
+	pyproject := filepath.Join(dir, "pyproject.toml")
+	if _, err := os.Stat(pyproject); err != nil {
+		return nil, fmt.Errorf("%s has no uv.lock, requirements file, or pyproject.toml", dir)
+	}
+
+	if uv, err := exec.LookPath("uv"); err == nil {
+		cmd := exec.CommandContext(ctx, uv, "pip", "compile", "pyproject.toml", "--quiet", "--no-header", "--no-annotate")
+		cmd.Dir = dir
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("uv pip compile failed: %w\n%s", err, strings.TrimSpace(stderr.String()))
+		}
+		return newResolution("uv pip compile", true, ParseRequirements(bytes.NewReader(out))), nil
+	}
+
+	packages, err := ProjectDependencies(dir)
+	if err != nil {
+		return nil, err
+	}
+	return newResolution("pyproject.toml", false, packages), nil
+}
+
+func newResolution(source string, transitive bool, packages []Package) *Resolution {
+	slices.SortFunc(packages, func(a, b Package) int { return strings.Compare(a.Name, b.Name) })
+	packages = slices.CompactFunc(packages, func(a, b Package) bool { return a.Name == b.Name })
+	return &Resolution{Source: source, Transitive: transitive, Packages: packages}
+}
+
+// parseUVLock lists the packages of a uv.lock, leaving out the project itself.
+func parseUVLock(data []byte) ([]Package, error) {
+	var lock struct {
+		Package []struct {
+			Name    string         `toml:"name"`
+			Version string         `toml:"version"`
+			Source  map[string]any `toml:"source"`
+		} `toml:"package"`
+	}
+	if _, err := toml.Decode(string(data), &lock); err != nil {
+		return nil, err
+	}
+
+	var packages []Package
+	for _, p := range lock.Package {
+		if _, ok := p.Source["editable"]; ok {
+			continue
+		}
+		if _, ok := p.Source["virtual"]; ok {
+			continue
+		}
+		packages = append(packages, Package{Name: NormalizeName(p.Name), Version: p.Version})
+	}
+	return packages, nil
+}
+
+// ProjectDependencies lists the direct dependencies declared in the
+// [project] table of dir's pyproject.toml.
+func ProjectDependencies(dir string) ([]Package, error) {
+	path := filepath.Join(dir, "pyproject.toml")
+	var pyproject struct {
+		Project struct {
+			Dependencies []string `toml:"dependencies"`
+		} `toml:"project"`
+	}
+	if _, err := toml.DecodeFile(path, &pyproject); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var packages []Package
+	for _, req := range pyproject.Project.Dependencies {
+		if p, ok := parseRequirement(req); ok {
+			packages = append(packages, p)
+		}
+	}
+	return packages, nil
+}
+
+// ParseRequirements parses a pip requirements file. Options, editable
+// installs, and URLs are skipped; versions are only kept when pinned with ==.
+func ParseRequirements(r io.Reader) []Package {
+	var packages []Package
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSuffix(strings.TrimSpace(line), "\\")
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if p, ok := parseRequirement(line); ok {
+			packages = append(packages, p)
+		}
+	}
+	return packages
+}
+
+// requirementName matches the distribution name at the start of a PEP 508 requirement.
+var requirementName = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// parseRequirement parses a PEP 508 requirement such as
+// "torch[cuda]==2.5.1; sys_platform == 'linux'".
+func parseRequirement(req string) (Package, bool) {
+	req, _, _ = strings.Cut(req, ";")
+	req = strings.TrimSpace(req)
+	if strings.Contains(req, "://") && !strings.Contains(req, "@") {
+		return Package{}, false
+	}
+
+	m := requirementName.FindString(req)
+	if m == "" {
+		return Package{}, false
+	}
+	p := Package{Name: NormalizeName(m)}
+
+	spec := strings.TrimSpace(req[len(m):])
+	if i := strings.Index(spec, "]"); strings.HasPrefix(spec, "[") && i >= 0 {
+		spec = strings.TrimSpace(spec[i+1:])
+	}
+	if strings.HasPrefix(spec, "@") {
+		// A direct URL reference; there's no index release to look up
+		return p, true
+	}
+	for _, clause := range strings.Split(spec, ",") {
+		clause = strings.TrimSpace(clause)
+		if v, ok := strings.CutPrefix(clause, "=="); ok && !strings.Contains(v, "*") {
+			p.Version = strings.TrimSpace(v)
+		}
+	}
+	return p, true
+}
+
+// separators are the runs of characters PEP 503 normalizes to "-".
+var separators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizeName returns the PEP 503 normalized form of a distribution name.
+func NormalizeName(name string) string {
+	return strings.ToLower(separators.ReplaceAllString(name, "-"))
+}
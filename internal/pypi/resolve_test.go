@@ -0,0 +1,84 @@
+package pypi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRequirements(t *testing.T) {
+	got := ParseRequirements(strings.NewReader(`# pinned
+--index-url https://example.invalid/simple
+Torch[cuda]==2.5.1 ; sys_platform == "linux" \
+    --hash=sha256:abc
+numpy>=1.26,<2
+-e ./local-package
+typing_extensions==4.12.*  # wildcard isn't a pin
+gen-worker @ https://example.invalid/gen_worker.whl
+`))
+	want := []Package{
+		{Name: "torch", Version: "2.5.1"},
+		{Name: "numpy"},
+		{Name: "typing-extensions"},
+		{Name: "gen-worker"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRequirements:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without uv or a lock file, only direct dependencies are known
+	t.Setenv("PATH", "")
+	write("pyproject.toml", "[project]\nname = \"demo\"\ndependencies = [\"Pillow>=10\", \"requests==2.32.3\"]\n")
+	res, err := Resolve(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Source != "pyproject.toml" || res.Transitive || !reflect.DeepEqual(res.Packages, []Package{{Name: "pillow"}, {Name: "requests", Version: "2.32.3"}}) {
+		t.Errorf("unexpected resolution from pyproject.toml: %+v", res)
+	}
+
+	write("requirements.lock", "requests==2.32.3\nurllib3==2.2.3\n")
+	if res, err = Resolve(context.Background(), dir); err != nil || res.Source != "requirements.lock" || len(res.Packages) != 2 {
+		t.Errorf("expected requirements.lock to be preferred, got %+v, %v", res, err)
+	}
+
+	// uv.lock wins over everything, and leaves out the project itself
+	write("uv.lock", `version = 1
+
+[[package]]
+name = "demo"
+version = "0.1.0"
+source = { editable = "." }
+
+[[package]]
+name = "Requests"
+version = "2.32.3"
+source = { registry = "https://pypi.org/simple" }
+
+[[package]]
+name = "certifi"
+version = "2024.8.30"
+source = { registry = "https://pypi.org/simple" }
+`)
+	res, err = Resolve(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Package{{Name: "certifi", Version: "2024.8.30"}, {Name: "requests", Version: "2.32.3"}}
+	if res.Source != "uv.lock" || !res.Transitive || !reflect.DeepEqual(res.Packages, want) {
+		t.Errorf("unexpected resolution from uv.lock: %+v", res)
+	}
+}
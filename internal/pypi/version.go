@@ -0,0 +1,56 @@
+package pypi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ReleaseParts returns the numeric release segments of a version, e.g.
+// [2 5 1] for "2.5.1" or "v2.5.1rc1". Pre-release, post-release, and local
+// suffixes are ignored.
+func ReleaseParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if _, rest, ok := strings.Cut(version, "!"); ok {
+		version = rest
+	}
+
+	var parts []int
+	for _, segment := range strings.Split(version, ".") {
+		end := 0
+		for end < len(segment) && segment[end] >= '0' && segment[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		n, _ := strconv.Atoi(segment[:end])
+		parts = append(parts, n)
+		if end < len(segment) {
+			break
+		}
+	}
+	return parts
+}
+
+// CompareVersions compares the release segments of two versions, treating
+// missing segments as zero. It returns -1, 0, or +1, and the index of the
+// first segment that differs (0 for major, 1 for minor, ...), or -1 if none.
+func CompareVersions(a, b string) (int, int) {
+	pa, pb := ReleaseParts(a), ReleaseParts(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1, i
+		case x > y:
+			return 1, i
+		}
+	}
+	return 0, -1
+}
@@ -0,0 +1,207 @@
+// Package ratelimit paces cozyctl's API requests: it bounds how many are in
+// flight at once and holds requests while the server says the rate limit is
+// used up, so watch loops, dashboards, and batch commands slow down instead
+// of failing with 429s.
+package ratelimit
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBudget is how many requests may be in flight at once.
+const DefaultBudget = 8
+
+// MaxWait is the longest a request is held for the rate limit to reset.
+// Beyond it the request goes ahead and the server's 429 is returned; the
+// wait counts toward the API clients' 30 second request timeout.
+const MaxWait = 20 * time.Second
+
+// maxRetries is how often a GET that got a 429 is retried.
+const maxRetries = 3
+
+// Transport is an http.RoundTripper that bounds concurrent requests to
+// Budget and waits out the server's rate limit: after a 429 or a response
+// reporting no requests remaining, requests are held until the limit
+// resets, and GETs that got a 429 are retried. Function invocations don't
+// count toward the budget, since invoke and bench set their own
+// concurrency, but they wait for the rate limit like everything else.
+type Transport struct {
+	Base http.RoundTripper
+	// Notify is called once each time requests start waiting for the rate
+	// limit, with how long the wait is.
+	Notify func(wait time.Duration)
+
+	sem chan struct{}
+
+	mu       sync.Mutex
+	until    time.Time // No requests before this
+	notified time.Time // The until Notify was last called for
+
+	now   func() time.Time
+	sleep func(req *http.Request, d time.Duration) error
+}
+
+// New returns a Transport in front of base allowing budget requests in
+// flight at once; a budget below 1 is unlimited.
+func New(base http.RoundTripper, budget int, notify func(time.Duration)) *Transport {
+	t := &Transport{Base: base, Notify: notify, now: time.Now, sleep: sleepContext}
+	if budget > 0 {
+		t.sem = make(chan struct{}, budget)
+	}
+	return t
+}
+
+// NotifyWriter returns a Notify function printing a waiting message to w.
+func NotifyWriter(w io.Writer) func(time.Duration) {
+	return func(wait time.Duration) {
+		fmt.Fprintf(w, "Waiting for rate limit (%s)...\n", wait.Round(time.Second))
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.sem != nil && !isInvocation(req) {
+		select {
+		case t.sem <- struct{}{}:
+			defer func() { <-t.sem }()
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req); err != nil {
+			return nil, err
+		}
+		resp, err := t.Base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		limited := t.observe(resp)
+		if !limited || !retryable(req) || attempt == maxRetries || t.holdFor() > MaxWait {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// wait holds req until the rate limit resets, unless that is more than
+// MaxWait away.
+func (t *Transport) wait(req *http.Request) error {
+	t.mu.Lock()
+	until := t.until
+	wait := until.Sub(t.now())
+	if wait <= 0 || wait > MaxWait {
+		t.mu.Unlock()
+		return nil
+	}
+	notify := t.Notify != nil && !t.notified.Equal(until)
+	if notify {
+		t.notified = until
+	}
+	t.mu.Unlock()
+
+	if notify {
+		t.Notify(wait)
+	}
+	return t.sleep(req, wait)
+}
+
+// holdFor returns how long until requests may be sent again.
+func (t *Transport) holdFor() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.until.Sub(t.now())
+}
+
+// observe records the rate limit resp reports and whether it was refused
+// for exceeding it.
+func (t *Transport) observe(resp *http.Response) bool {
+	now := t.now()
+	var until time.Time
+	limited := resp.StatusCode == http.StatusTooManyRequests
+
+	if wait, ok := retryAfter(resp.Header.Get("Retry-After"), now); ok && (limited || resp.StatusCode == http.StatusServiceUnavailable) {
+		until = now.Add(wait)
+	} else if remaining, ok := headerInt(resp.Header, "RateLimit-Remaining", "X-RateLimit-Remaining"); ok && remaining <= 0 {
+		if reset, ok := headerInt(resp.Header, "RateLimit-Reset", "X-RateLimit-Reset"); ok {
+			until = resetTime(reset, now)
+		}
+	}
+	if limited && until.IsZero() {
+		until = now.Add(time.Second) // Refused without saying for how long
+	}
+
+	t.mu.Lock()
+	if until.After(t.until) {
+		t.until = until
+	}
+	t.mu.Unlock()
+	return limited
+}
+
+// isInvocation reports whether req invokes a deployment's function.
+func isInvocation(req *http.Request) bool {
+	return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/invoke")
+}
+
+// retryable reports whether req can be sent again as is: it is idempotent
+// and has no body.
+func retryable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+// retryAfter parses a Retry-After header: seconds, or an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// resetTime interprets a rate limit reset header, which servers send either
+// as seconds until the reset or as the Unix time of the reset.
+func resetTime(reset int64, now time.Time) time.Time {
+	if reset > 1_000_000_000 {
+		return time.Unix(reset, 0)
+	}
+	return now.Add(time.Duration(min(reset, math.MaxInt32)) * time.Second)
+}
+
+// headerInt returns the first of names set to an integer.
+func headerInt(h http.Header, names ...string) (int64, bool) {
+	for _, name := range names {
+		if v, err := strconv.ParseInt(strings.TrimSpace(h.Get(name)), 10, 64); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func sleepContext(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock replaces the transport's clock; sleeping advances it.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	slept  []time.Duration
+	notice []time.Duration
+}
+
+func newTestTransport(base http.RoundTripper, budget int) (*Transport, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	t := New(base, budget, func(d time.Duration) { clock.notice = append(clock.notice, d) })
+	t.now = func() time.Time {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		return clock.now
+	}
+	t.sleep = func(_ *http.Request, d time.Duration) error {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		clock.slept = append(clock.slept, d)
+		clock.now = clock.now.Add(d)
+		return nil
+	}
+	return t, clock
+}
+
+func TestTransport_RetriesGETAfterRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+
+	transport, clock := newTestTransport(http.DefaultTransport, DefaultBudget)
+	resp, err := (&http.Client{Transport: transport}).Get(ts.URL + "/v1/deployments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("got %d after %d calls, want 200 after 2", resp.StatusCode, calls.Load())
+	}
+	if len(clock.slept) != 1 || clock.slept[0] != 2*time.Second {
+		t.Errorf("slept %v, want [2s]", clock.slept)
+	}
+	if len(clock.notice) != 1 {
+		t.Errorf("notified %d times, want once", len(clock.notice))
+	}
+}
+
+func TestTransport_HoldsRequestsWhenNoneRemain(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "5")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+
+	transport, clock := newTestTransport(http.DefaultTransport, DefaultBudget)
+	client := &http.Client{Transport: transport}
+	resp, err := client.Post(ts.URL+"/v1/deployments", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(clock.slept) != 0 {
+		t.Fatalf("slept %v before the limit was reached", clock.slept)
+	}
+
+	for range 2 {
+		resp, err := client.Get(ts.URL + "/v1/deployments")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if len(clock.slept) != 1 || clock.slept[0] != 5*time.Second {
+		t.Errorf("slept %v, want [5s] before the first request after the reset", clock.slept)
+	}
+}
+
+func TestTransport_DoesNotWaitBeyondMaxWait(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(ts.Close)
+
+	transport, clock := newTestTransport(http.DefaultTransport, DefaultBudget)
+	resp, err := (&http.Client{Transport: transport}).Get(ts.URL + "/v1/deployments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 || len(clock.slept) != 0 {
+		t.Errorf("got %d after %d calls, slept %v; want the 429 returned at once", resp.StatusCode, calls.Load(), clock.slept)
+	}
+}
+
+func TestTransport_BoundsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		inFlight.Add(-1)
+	}))
+	t.Cleanup(ts.Close)
+
+	transport, _ := newTestTransport(http.DefaultTransport, 2)
+	client := &http.Client{Transport: transport}
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, err := client.Get(ts.URL + "/v1/deployments"); err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	for inFlight.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if peak.Load() != 2 {
+		t.Errorf("peak of %d requests in flight, want 2", peak.Load())
+	}
+}
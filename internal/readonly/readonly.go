@@ -0,0 +1,64 @@
+// Package readonly stops cozyctl from changing anything on the server: in
+// read-only mode every request that could deploy, update, scale, cancel, or
+// delete is refused before it leaves the machine, so a profile kept for
+// looking at production can't change it by accident.
+package readonly
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error is returned for a request refused in read-only mode.
+type Error struct {
+	Method string
+	Path   string
+	Reason string // Why the session is read-only, e.g. "--read-only is set"
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s refused: %s", e.Method, e.Path, e.Reason)
+}
+
+// allowedPosts are the paths of requests that send a body without changing
+// anything: signing in, renewing a token, and asking what a key may do.
+var allowedPosts = []string{
+	"/api/v1/auth/password/login",
+	"/api/v1/auth/refresh",
+	"/api/v1/auth/can-i",
+	"/api/v1/auth/sso/",
+}
+
+// Allowed reports whether req may be sent in read-only mode.
+func Allowed(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		for _, path := range allowedPosts {
+			if req.URL.Path == path || (strings.HasSuffix(path, "/") && strings.HasPrefix(req.URL.Path, path)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Transport is an http.RoundTripper that passes the requests Allowed lets
+// through to Base and refuses the rest with an *Error.
+type Transport struct {
+	Base   http.RoundTripper
+	Reason string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !Allowed(req) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, &Error{Method: req.Method, Path: req.URL.Path, Reason: t.Reason}
+	}
+	return t.Base.RoundTrip(req)
+}
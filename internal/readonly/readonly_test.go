@@ -0,0 +1,57 @@
+package readonly
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var sent []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Method+" "+r.URL.Path)
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: &Transport{Base: http.DefaultTransport, Reason: "--read-only is set"}}
+
+	tests := []struct {
+		method, path string
+		allowed      bool
+	}{
+		{"GET", "/v1/deployments", true},
+		{"POST", "/v1/deployments", false},
+		{"PUT", "/v1/deployments/my-model", false},
+		{"DELETE", "/v1/deployments/my-model", false},
+		{"POST", "/v1/deployments/my-model/scale-to-zero", false},
+		{"POST", "/api/v1/builds/b-1/cancel", false},
+		{"PUT", "/api/v1/file/builds/demo/1.tar.gz", false},
+		{"POST", "/api/v1/auth/refresh", true},
+		{"POST", "/api/v1/auth/sso/acme/token", true},
+		{"POST", "/api/v1/auth/can-i", true},
+		{"POST", "/api/v1/auth/keys", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader("{}"))
+		resp, err := client.Do(req)
+		if tt.allowed {
+			if err != nil {
+				t.Errorf("%s %s: %v", tt.method, tt.path, err)
+				continue
+			}
+			resp.Body.Close()
+			continue
+		}
+		var refused *Error
+		if !errors.As(err, &refused) || !strings.Contains(err.Error(), "--read-only is set") {
+			t.Errorf("%s %s: err = %v, want it refused", tt.method, tt.path, err)
+		}
+	}
+
+	for _, s := range sent {
+		if !strings.HasPrefix(s, "GET") && !strings.Contains(s, "/auth/") {
+			t.Errorf("%s reached the server", s)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package rebuild manages server-side policies that rebuild deployments when
+// their base image gets security updates.
+package rebuild
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ScheduleOptions contains the options for scheduling rebuilds.
+type ScheduleOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Schedule     string // api.RebuildDaily or api.RebuildWeekly
+}
+
+// ListOptions contains the options for listing rebuild policies.
+type ListOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Output    ui.Output
+}
+
+// RemoveOptions contains the options for removing a rebuild policy.
+type RemoveOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+}
+
+// Schedule registers a policy that rebuilds a deployment from its last
+// source tarball when its base image gets security updates.
+func Schedule(opts ScheduleOptions) (err error) {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	recorder := history.Start(opts.Profile, "rebuild schedule")
+	defer func() { recorder.Finish(map[string]string{"deployment_id": opts.DeploymentID}, err) }()
+
+	return schedule(os.Stdout, client, opts)
+}
+
+func schedule(w io.Writer, client api.BuilderAPI, opts ScheduleOptions) error {
+	policy, err := client.SetRebuildPolicy(opts.DeploymentID, opts.Schedule)
+	if err != nil {
+		return fmt.Errorf("failed to schedule rebuilds: %w", err)
+	}
+
+	fmt.Fprintf(w, "Scheduled %s rebuilds of %s from the source of build %s.\n", policy.Schedule, policy.DeploymentID, policy.SourceBuildID)
+	fmt.Fprintf(w, "When a check finds security updates to its base image, the deployment is rebuilt and redeployed.\n")
+	if policy.NextCheckAt != "" {
+		fmt.Fprintf(w, "Next check: %s\n", formatTime(policy.NextCheckAt))
+	}
+	return nil
+}
+
+// List prints the tenant's rebuild policies.
+func List(opts ListOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return list(os.Stdout, client, opts)
+}
+
+func list(w io.Writer, client api.BuilderAPI, opts ListOptions) error {
+	policies, err := client.ListRebuildPolicies()
+	if err != nil {
+		return fmt.Errorf("failed to list rebuild policies: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		if policies == nil {
+			policies = []api.RebuildPolicy{}
+		}
+		return ui.WriteStructured(w, opts.Output, policies)
+	}
+	if len(policies) == 0 {
+		fmt.Fprintln(w, "No rebuild policies. Add one with 'cozyctl rebuild schedule'.")
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"DEPLOYMENT", "SCHEDULE", "SOURCE BUILD", "LAST REBUILD", "NEXT CHECK"}}
+	for _, p := range policies {
+		table.Rows = append(table.Rows, ui.Row{Key: p.DeploymentID, Cells: []string{
+			p.DeploymentID, p.Schedule, p.SourceBuildID, orDash(p.LastRebuildID), formatTime(p.NextCheckAt),
+		}})
+	}
+	return table.Write(w)
+}
+
+// Remove stops scheduled rebuilds of a deployment.
+func Remove(opts RemoveOptions) (err error) {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	recorder := history.Start(opts.Profile, "rebuild remove")
+	defer func() { recorder.Finish(map[string]string{"deployment_id": opts.DeploymentID}, err) }()
+
+	return remove(os.Stdout, client, opts)
+}
+
+func remove(w io.Writer, client api.BuilderAPI, opts RemoveOptions) error {
+	if err := client.DeleteRebuildPolicy(opts.DeploymentID); err != nil {
+		return fmt.Errorf("failed to remove rebuild policy: %w", err)
+	}
+	fmt.Fprintf(w, "Removed the rebuild policy of %s.\n", opts.DeploymentID)
+	return nil
+}
+
+// formatTime shows an RFC 3339 timestamp in local time; other values are shown as-is.
+func formatTime(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return orDash(ts)
+	}
+	return t.Local().Format("2006-01-02 15:04 MST")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
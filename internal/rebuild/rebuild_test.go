@@ -0,0 +1,73 @@
+package rebuild
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestScheduleListRemove(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	err := schedule(&bytes.Buffer{}, client, ScheduleOptions{DeploymentID: "my-model", Schedule: api.RebuildWeekly})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("got %v, want deployment not found", err)
+	}
+
+	upload, err := client.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DeployBuild(upload.BuildID, &api.DeployBuildRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := schedule(&out, client, ScheduleOptions{DeploymentID: "my-model", Schedule: api.RebuildWeekly}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "weekly rebuilds of my-model") || !strings.Contains(out.String(), upload.BuildID) {
+		t.Errorf("unexpected schedule output:\n%s", out.String())
+	}
+
+	// Scheduling again changes the schedule
+	if err := schedule(&bytes.Buffer{}, client, ScheduleOptions{DeploymentID: "my-model", Schedule: api.RebuildDaily}); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := list(&out, client, ListOptions{Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var policies []api.RebuildPolicy
+	if err := json.Unmarshal(out.Bytes(), &policies); err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 1 || policies[0].Schedule != api.RebuildDaily || policies[0].SourceBuildID != upload.BuildID {
+		t.Errorf("unexpected policies: %+v", policies)
+	}
+
+	out.Reset()
+	if err := remove(&out, client, RemoveOptions{DeploymentID: "my-model"}); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := list(&out, client, ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "No rebuild policies") {
+		t.Errorf("expected no policies after remove:\n%s", out.String())
+	}
+
+	err = remove(&bytes.Buffer{}, client, RemoveOptions{DeploymentID: "my-model"})
+	if err == nil || !strings.Contains(err.Error(), "has no rebuild policy") {
+		t.Errorf("got %v, want no policy error", err)
+	}
+}
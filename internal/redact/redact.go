@@ -0,0 +1,212 @@
+// Package redact strips credentials (bearer tokens, API keys, refresh
+// tokens, passwords) from text before it reaches the terminal or a file.
+// Besides recognizing credentials by shape, it replaces the exact secrets
+// of loaded profiles wherever they appear, such as a server echoing a
+// request back in an error body.
+package redact
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces sensitive values.
+const Redacted = "REDACTED"
+
+// minSecret is the shortest registered secret that is replaced; shorter
+// values would match ordinary text.
+const minSecret = 8
+
+// sensitiveHeaders are replaced with Redacted.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// sensitiveFields are JSON object keys and query parameters whose values are
+// replaced with Redacted.
+var sensitiveFields = map[string]bool{
+	"token":             true,
+	"access_token":      true,
+	"refresh_token":     true,
+	"password":          true,
+	"current_password":  true,
+	"new_password":      true,
+	"code_verifier":     true,
+	"api_key":           true,
+	"secret":            true,
+	"registry_password": true,
+}
+
+var (
+	// Authorization: Bearer <token>, including in echoed headers
+	authPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{8,}`)
+	// "field": "value" in JSON
+	jsonPattern = regexp.MustCompile(`(?i)"(` + fieldAlternation() + `)"\s*:\s*"(?:[^"\\]|\\.)*"`)
+	// field=value in URLs and form bodies
+	queryPattern = regexp.MustCompile(`(?i)\b(` + fieldAlternation() + `)=[^\s&"']+`)
+	// API key secrets
+	keyPattern = regexp.MustCompile(`\bsk_[A-Za-z0-9_]{8,}`)
+)
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Register adds exact secrets, such as a profile's token, to replace
+// wherever they appear. Empty and very short values are ignored.
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if len(v) >= minSecret && !slices.Contains(secrets, v) {
+			secrets = append(secrets, v)
+		}
+	}
+}
+
+// String returns s with credentials replaced by Redacted.
+func String(s string) string {
+	mu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	mu.RUnlock()
+
+	s = authPattern.ReplaceAllString(s, "$1 "+Redacted)
+	s = jsonPattern.ReplaceAllString(s, `"$1":"`+Redacted+`"`)
+	s = queryPattern.ReplaceAllString(s, "$1="+Redacted)
+	return keyPattern.ReplaceAllString(s, Redacted)
+}
+
+// Args returns command-line arguments with credentials replaced: the value
+// of a flag named like a sensitive field (--password, --api-key), whether
+// given as --flag=value or as the next argument, and anything String finds.
+func Args(args []string) []string {
+	out := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(args[i], "=")
+		switch {
+		case !sensitiveFlag(name):
+			out[i] = String(args[i])
+		case hasValue:
+			out[i] = name + "=" + Redacted
+		default:
+			out[i] = name
+			if i+1 < len(args) {
+				i++
+				out[i] = Redacted
+			}
+		}
+	}
+	return out
+}
+
+// sensitiveFlag reports whether arg is a long flag such as --api-key whose
+// value is a credential.
+func sensitiveFlag(arg string) bool {
+	name, ok := strings.CutPrefix(arg, "--")
+	return ok && sensitiveFields[strings.ReplaceAll(strings.ToLower(name), "-", "_")]
+}
+
+// Error returns err with a redacted message. It still unwraps to err, so
+// errors.Is and errors.As see through it.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if redacted := String(msg); redacted != msg {
+		return &redactedError{msg: redacted, err: err}
+	}
+	return err
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// Header reports whether a header carries credentials.
+func Header(name string) bool {
+	return sensitiveHeaders[http.CanonicalHeaderKey(name)]
+}
+
+// Body returns a request or response body with credentials replaced. JSON
+// bodies have sensitive fields replaced structurally, so the result is
+// still valid JSON; other bodies are redacted as text.
+func Body(body []byte) string {
+	var doc any
+	if json.Unmarshal(body, &doc) != nil {
+		return String(string(body))
+	}
+	if !redactJSON(doc) {
+		return String(string(body))
+	}
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return String(string(body))
+	}
+	return String(string(redacted))
+}
+
+// redactJSON replaces sensitive values in a decoded JSON document,
+// reporting whether any were found.
+func redactJSON(doc any) bool {
+	found := false
+	switch v := doc.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if sensitiveFields[strings.ToLower(k)] {
+				if s, ok := val.(string); !ok || s != "" {
+					v[k] = Redacted
+					found = true
+				}
+				continue
+			}
+			found = redactJSON(val) || found
+		}
+	case []any:
+		for _, val := range v {
+			found = redactJSON(val) || found
+		}
+	}
+	return found
+}
+
+// Writer returns a writer that redacts each write before passing it to w.
+// Credentials split across writes are missed, so it suits writers that
+// get whole lines, such as cobra's error output.
+func Writer(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+type writer struct{ w io.Writer }
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func fieldAlternation() string {
+	fields := make([]string, 0, len(sensitiveFields))
+	for f := range sensitiveFields {
+		fields = append(fields, regexp.QuoteMeta(f))
+	}
+	return strings.Join(fields, "|")
+}
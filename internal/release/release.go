@@ -0,0 +1,287 @@
+// Package release generates the distribution metadata for a cozyctl
+// release: a Homebrew formula, a Scoop manifest, and nfpm configs for deb
+// and rpm packages, from the archives and binaries the release build
+// produced.
+package release
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Package metadata shared by every generated file.
+const (
+	description = "Deploy and manage machine learning functions on the Cozy platform"
+	homepage    = "https://github.com/cozy-creator/cozyctl"
+)
+
+// DefaultMaintainer is the deb and rpm packages' maintainer.
+const DefaultMaintainer = "Cozy Creator"
+
+// DefaultBaseURL is where release archives are downloaded from; {version}
+// is replaced with the release's tag.
+const DefaultBaseURL = "https://github.com/cozy-creator/cozyctl/releases/download/{version}"
+
+// platforms are the GOOS/GOARCH pairs a release is built for.
+var platforms = []struct{ os, arch string }{
+	{"darwin", "amd64"}, {"darwin", "arm64"},
+	{"linux", "amd64"}, {"linux", "arm64"},
+	{"windows", "amd64"}, {"windows", "arm64"},
+}
+
+// completionFiles are the completion scripts written for the deb and rpm
+// packages, and where each shell loads them from.
+var completionFiles = []struct{ shell, name, dst string }{
+	{"bash", "cozyctl.bash", "/usr/share/bash-completion/completions/cozyctl"},
+	{"zsh", "_cozyctl", "/usr/share/zsh/vendor-completions/_cozyctl"},
+	{"fish", "cozyctl.fish", "/usr/share/fish/vendor_completions.d/cozyctl.fish"},
+}
+
+// Options contains the options for generating the packaging metadata.
+type Options struct {
+	Version    string // Release tag, e.g. v1.4.0
+	DistDir    string // Directory holding the release's archives and binaries
+	OutDir     string // Where the metadata is written
+	BaseURL    string // Download URL prefix; defaults to DefaultBaseURL
+	Maintainer string // Defaults to DefaultMaintainer
+
+	// Generate writes the completion script for shell to w.
+	Generate func(shell string, w io.Writer) error
+}
+
+// archive is one platform's release archive.
+type archive struct {
+	OS, Arch string
+	Name     string
+	URL      string
+	SHA256   string
+}
+
+// BrewArch is the name of the formula's on_<arch> block.
+func (a archive) BrewArch() string {
+	if a.Arch == "arm64" {
+		return "arm"
+	}
+	return "intel"
+}
+
+// ArchiveName is the name the release build gives a platform's archive:
+// a zip on Windows and a gzipped tarball elsewhere.
+func ArchiveName(version, goos, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("cozyctl_%s_%s_%s%s", strings.TrimPrefix(version, "v"), goos, goarch, ext)
+}
+
+// BinaryPath is where the release build leaves a platform's binary,
+// relative to the dist directory.
+func BinaryPath(goos, goarch string) string {
+	name := "cozyctl"
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(goos+"_"+goarch, name)
+}
+
+// Generate writes the Homebrew formula, the Scoop manifest, an nfpm config
+// per Linux architecture, and the completion scripts those packages ship,
+// and prints every file it wrote.
+func Generate(w io.Writer, opts Options) error {
+	if opts.Version == "" || opts.Version == "devel" || strings.HasPrefix(opts.Version, "devel+") {
+		return fmt.Errorf("a release version is required (e.g. --version v1.4.0)")
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	if opts.Maintainer == "" {
+		opts.Maintainer = DefaultMaintainer
+	}
+	baseURL := strings.TrimSuffix(strings.ReplaceAll(opts.BaseURL, "{version}", opts.Version), "/")
+
+	archives := make([]archive, 0, len(platforms))
+	for _, p := range platforms {
+		name := ArchiveName(opts.Version, p.os, p.arch)
+		sum, err := fileSHA256(filepath.Join(opts.DistDir, name))
+		if err != nil {
+			return err
+		}
+		archives = append(archives, archive{OS: p.os, Arch: p.arch, Name: name, URL: baseURL + "/" + name, SHA256: sum})
+	}
+
+	files := map[string][]byte{}
+	var order []string
+	add := func(name string, data []byte) {
+		files[name] = data
+		order = append(order, name)
+	}
+
+	for _, c := range completionFiles {
+		var buf bytes.Buffer
+		if err := opts.Generate(c.shell, &buf); err != nil {
+			return fmt.Errorf("failed to generate %s completion: %w", c.shell, err)
+		}
+		add(filepath.Join("completions", c.name), buf.Bytes())
+	}
+
+	formula, err := renderFormula(opts.Version, archives)
+	if err != nil {
+		return err
+	}
+	add(filepath.Join("homebrew", "cozyctl.rb"), formula)
+
+	manifest, err := renderScoop(opts.Version, baseURL, archives)
+	if err != nil {
+		return err
+	}
+	add(filepath.Join("scoop", "cozyctl.json"), manifest)
+
+	for _, arch := range []string{"amd64", "arm64"} {
+		binary := filepath.Join(opts.DistDir, BinaryPath("linux", arch))
+		if _, err := os.Stat(binary); err != nil {
+			return fmt.Errorf("%s not found; build the release binaries first", binary)
+		}
+		config, err := renderNfpm(opts, arch)
+		if err != nil {
+			return err
+		}
+		add(filepath.Join("nfpm", "nfpm-"+arch+".yaml"), config)
+	}
+
+	for _, name := range order {
+		target := filepath.Join(opts.OutDir, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+		}
+		if err := os.WriteFile(target, files[name], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		fmt.Fprintf(w, "Wrote %s\n", target)
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%s not found; build the release archives first", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func renderFormula(version string, archives []archive) ([]byte, error) {
+	type formulaOS struct {
+		Block    string
+		Archives []archive
+	}
+	oses := []formulaOS{{Block: "macos"}, {Block: "linux"}}
+	for _, a := range archives {
+		switch a.OS {
+		case "darwin":
+			oses[0].Archives = append(oses[0].Archives, a)
+		case "linux":
+			oses[1].Archives = append(oses[1].Archives, a)
+		}
+	}
+	return render("formula", formulaTemplate, map[string]any{
+		"Version":        version,
+		"PackageVersion": strings.TrimPrefix(version, "v"),
+		"Description":    description,
+		"Homepage":       homepage,
+		"FormulaOSes":    oses,
+	})
+}
+
+// scoopManifest is the subset of Scoop's app manifest a release fills in.
+type scoopManifest struct {
+	Version      string                   `json:"version"`
+	Description  string                   `json:"description"`
+	Homepage     string                   `json:"homepage"`
+	Architecture map[string]scoopDownload `json:"architecture"`
+	Bin          string                   `json:"bin"`
+	CheckVer     map[string]string        `json:"checkver"`
+	AutoUpdate   map[string]any           `json:"autoupdate"`
+}
+
+type scoopDownload struct {
+	URL  string `json:"url"`
+	Hash string `json:"hash"`
+}
+
+// scoopArchs maps GOARCH to Scoop's architecture names.
+var scoopArchs = map[string]string{"amd64": "64bit", "arm64": "arm64"}
+
+func renderScoop(version, baseURL string, archives []archive) ([]byte, error) {
+	m := scoopManifest{
+		Version:      strings.TrimPrefix(version, "v"),
+		Description:  description,
+		Homepage:     homepage,
+		Architecture: map[string]scoopDownload{},
+		Bin:          "cozyctl.exe",
+		CheckVer:     map[string]string{"github": homepage},
+	}
+	autoArchs := map[string]any{}
+	for _, a := range archives {
+		if a.OS != "windows" {
+			continue
+		}
+		m.Architecture[scoopArchs[a.Arch]] = scoopDownload{URL: a.URL, Hash: a.SHA256}
+		// Scoop substitutes $version when a newer release is found
+		autoURL := strings.ReplaceAll(a.URL, strings.TrimPrefix(version, "v"), "$version")
+		autoArchs[scoopArchs[a.Arch]] = map[string]string{"url": autoURL}
+	}
+	m.AutoUpdate = map[string]any{"architecture": autoArchs}
+
+	data, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func renderNfpm(opts Options, arch string) ([]byte, error) {
+	type completion struct{ Src, Dst string }
+	var completions []completion
+	for _, c := range completionFiles {
+		completions = append(completions, completion{
+			Src: filepath.ToSlash(filepath.Join(opts.OutDir, "completions", c.name)),
+			Dst: c.dst,
+		})
+	}
+	return render("nfpm", nfpmTemplate, map[string]any{
+		"Version":        opts.Version,
+		"PackageVersion": strings.TrimPrefix(opts.Version, "v"),
+		"Arch":           arch,
+		"Maintainer":     opts.Maintainer,
+		"Description":    description,
+		"Homepage":       homepage,
+		"Binary":         filepath.ToSlash(filepath.Join(opts.DistDir, BinaryPath("linux", arch))),
+		"Completions":    completions,
+	})
+}
+
+func render(name, tmpl string, data any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := template.Must(template.New(name).Parse(tmpl)).Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,140 @@
+package release
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func generate(shell string, w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# %s completion\n", shell)
+	return err
+}
+
+// writeDist lays out a release build's archives and Linux binaries, and
+// returns each archive's checksum.
+func writeDist(t *testing.T, dir, version string) map[string]string {
+	sums := map[string]string{}
+	for _, p := range platforms {
+		name := ArchiveName(version, p.os, p.arch)
+		data := []byte("archive " + name)
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(data)
+		sums[p.os+"/"+p.arch] = hex.EncodeToString(sum[:])
+		if p.os == "linux" {
+			bin := filepath.Join(dir, BinaryPath(p.os, p.arch))
+			if err := os.MkdirAll(filepath.Dir(bin), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(bin, []byte("binary"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return sums
+}
+
+func TestGenerate(t *testing.T) {
+	dist := t.TempDir()
+	out := filepath.Join(t.TempDir(), "packaging")
+	sums := writeDist(t, dist, "v1.4.0")
+
+	var log bytes.Buffer
+	opts := Options{Version: "v1.4.0", DistDir: dist, OutDir: out, Generate: generate}
+	if err := Generate(&log, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	formula, err := os.ReadFile(filepath.Join(out, "homebrew", "cozyctl.rb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`version "1.4.0"`,
+		`url "https://github.com/cozy-creator/cozyctl/releases/download/v1.4.0/cozyctl_1.4.0_darwin_arm64.tar.gz"`,
+		`sha256 "` + sums["darwin/arm64"] + `"`,
+		`sha256 "` + sums["linux/amd64"] + `"`,
+		`generate_completions_from_executable(bin/"cozyctl", "completion")`,
+	} {
+		if !strings.Contains(string(formula), want) {
+			t.Errorf("formula is missing %q:\n%s", want, formula)
+		}
+	}
+	if strings.Contains(string(formula), "windows") {
+		t.Errorf("formula should not reference Windows archives:\n%s", formula)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, "scoop", "cozyctl.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest scoopManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("scoop manifest: %v\n%s", err, data)
+	}
+	if manifest.Version != "1.4.0" || manifest.Bin != "cozyctl.exe" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if dl := manifest.Architecture["64bit"]; dl.Hash != sums["windows/amd64"] || !strings.HasSuffix(dl.URL, "/v1.4.0/cozyctl_1.4.0_windows_amd64.zip") {
+		t.Errorf("64bit download = %+v", dl)
+	}
+	if !strings.Contains(string(data), "/v$version/cozyctl_$version_windows_arm64.zip") {
+		t.Errorf("expected an autoupdate URL template:\n%s", data)
+	}
+
+	nfpm, err := os.ReadFile(filepath.Join(out, "nfpm", "nfpm-arm64.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"arch: arm64",
+		"version: 1.4.0",
+		"maintainer: " + DefaultMaintainer,
+		"src: " + filepath.ToSlash(filepath.Join(dist, "linux_arm64", "cozyctl")),
+		"src: " + filepath.ToSlash(filepath.Join(out, "completions", "_cozyctl")),
+		"dst: /usr/share/zsh/vendor-completions/_cozyctl",
+	} {
+		if !strings.Contains(string(nfpm), want) {
+			t.Errorf("nfpm config is missing %q:\n%s", want, nfpm)
+		}
+	}
+
+	script, err := os.ReadFile(filepath.Join(out, "completions", "cozyctl.fish"))
+	if err != nil || string(script) != "# fish completion\n" {
+		t.Errorf("fish completion = %q, %v", script, err)
+	}
+	if n := strings.Count(log.String(), "Wrote "); n != 7 {
+		t.Errorf("expected 7 files written, got %d:\n%s", n, log.String())
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dist := t.TempDir()
+	out := t.TempDir()
+
+	err := Generate(io.Discard, Options{Version: "devel+abc", DistDir: dist, OutDir: out, Generate: generate})
+	if err == nil || !strings.Contains(err.Error(), "release version is required") {
+		t.Errorf("expected a version error, got %v", err)
+	}
+
+	err = Generate(io.Discard, Options{Version: "v1.4.0", DistDir: dist, OutDir: out, Generate: generate})
+	if err == nil || !strings.Contains(err.Error(), "cozyctl_1.4.0_darwin_amd64.tar.gz not found") {
+		t.Errorf("expected a missing archive error, got %v", err)
+	}
+
+	writeDist(t, dist, "v1.4.0")
+	os.Remove(filepath.Join(dist, BinaryPath("linux", "arm64")))
+	err = Generate(io.Discard, Options{Version: "v1.4.0", DistDir: dist, OutDir: out, Generate: generate})
+	if err == nil || !strings.Contains(err.Error(), "build the release binaries first") {
+		t.Errorf("expected a missing binary error, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package release
+
+// The Homebrew formula generates its completions from the installed
+// binary, so they always match the version installed. The deb and rpm
+// packages ship the scripts Generate wrote from the same build.
+
+const formulaTemplate = `# Generated by cozyctl release {{ .Version }}; do not edit
+class Cozyctl < Formula
+  desc "{{ .Description }}"
+  homepage "{{ .Homepage }}"
+  version "{{ .PackageVersion }}"
+{{- range $os := .FormulaOSes }}
+
+  on_{{ $os.Block }} do
+{{- range $os.Archives }}
+    on_{{ .BrewArch }} do
+      url "{{ .URL }}"
+      sha256 "{{ .SHA256 }}"
+    end
+{{- end }}
+  end
+{{- end }}
+
+  def install
+    bin.install "cozyctl"
+    generate_completions_from_executable(bin/"cozyctl", "completion")
+  end
+
+  test do
+    assert_match "cozyctl", shell_output("#{bin}/cozyctl --help")
+  end
+end
+`
+
+const nfpmTemplate = `# Generated by cozyctl release {{ .Version }}; do not edit
+# Package with: nfpm package --config <this file> --packager deb (or rpm)
+name: cozyctl
+arch: {{ .Arch }}
+platform: linux
+version: {{ .PackageVersion }}
+section: utils
+priority: optional
+maintainer: {{ .Maintainer }}
+description: {{ .Description }}
+homepage: {{ .Homepage }}
+contents:
+  - src: {{ .Binary }}
+    dst: /usr/bin/cozyctl
+    file_info:
+      mode: 0755
+{{- range .Completions }}
+  - src: {{ .Src }}
+    dst: {{ .Dst }}
+    file_info:
+      mode: 0644
+{{- end }}
+`
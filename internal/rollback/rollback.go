@@ -0,0 +1,111 @@
+// Package rollback re-activates an earlier build of a deployment.
+package rollback
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+)
+
+// Options contains the options for rolling back a deployment.
+type Options struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	ToBuild      string // Build to re-activate; the previous build if empty
+}
+
+// Run rolls a deployment back to its previous build, or to opts.ToBuild.
+func Run(opts Options) (err error) {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	recorder := history.Start(opts.Profile, "rollback")
+	ids := map[string]string{"deployment_id": opts.DeploymentID}
+	defer func() { recorder.Finish(ids, err) }()
+
+	return rollback(os.Stdout, client, opts, ids)
+}
+
+// rollback shows the change of build and image, then makes it. The build
+// rolled back to is added to ids.
+func rollback(w io.Writer, client api.BuilderAPI, opts Options, ids map[string]string) error {
+	hub, err := client.GetHubDeployment(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s: %w", opts.DeploymentID, err)
+	}
+	if hub == nil {
+		return fmt.Errorf("deployment '%s' not found", opts.DeploymentID)
+	}
+
+	active := ""
+	if hub.ActiveBuildID != nil {
+		active = *hub.ActiveBuildID
+	}
+	target := opts.ToBuild
+	if target == "" {
+		if hub.PreviousBuildID == nil || *hub.PreviousBuildID == "" {
+			return fmt.Errorf("%s has no previous build to roll back to (pick one from 'cozyctl builds list --deployment %s' and pass --to-build)", opts.DeploymentID, opts.DeploymentID)
+		}
+		target = *hub.PreviousBuildID
+	}
+	if target == active {
+		return fmt.Errorf("build %s is already active on %s", target, opts.DeploymentID)
+	}
+	ids["build_id"] = target
+
+	// Only successful builds have an image to go back to
+	status, err := client.GetBuildStatus(target)
+	if err != nil {
+		return fmt.Errorf("failed to get build %s: %w", target, err)
+	}
+	if status.Status != "success" && status.Status != "succeeded" {
+		return fmt.Errorf("build %s has status %q; only successful builds can be rolled back to", target, status.Status)
+	}
+
+	fmt.Fprintf(w, "Rolling back %s from build %s to %s\n", opts.DeploymentID, orNone(active), target)
+	writeImageDiff(w, hub.ImageURL, status.ImageTag)
+
+	deployment, err := client.RollbackDeployment(opts.DeploymentID, target)
+	var pending *api.PendingApprovalError
+	if errors.As(err, &pending) {
+		approval := pending.Approval
+		ids["approval_id"] = approval.ID
+		fmt.Fprintf(w, "\n%s requires approval; the rollback is waiting for it.\n", opts.DeploymentID)
+		fmt.Fprintf(w, "  Approval: %s\n", approval.ID)
+		if approval.URL != "" {
+			fmt.Fprintf(w, "  Review: %s\n", approval.URL)
+		}
+		fmt.Fprintf(w, "  Approve with: cozyctl approvals approve %s\n", approval.ID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to roll back: %w", err)
+	}
+
+	fmt.Fprintf(w, "\nRolled back %s; build %s is active.\n", deployment.ID, target)
+	fmt.Fprintf(w, "Check it with 'cozyctl status %s'.\n", deployment.ID)
+	return nil
+}
+
+// writeImageDiff shows the image tag change, one line for each side like a
+// diff.
+func writeImageDiff(w io.Writer, before, after string) {
+	fmt.Fprintf(w, "- image: %s\n", orNone(before))
+	fmt.Fprintf(w, "+ image: %s\n", orNone(after))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package rollback
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestRollback(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	deployBuild := func() string {
+		t.Helper()
+		upload, err := client.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.DeployBuild(upload.BuildID, &api.DeployBuildRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		return upload.BuildID
+	}
+	first := deployBuild()
+
+	err := rollback(&bytes.Buffer{}, client, Options{DeploymentID: "my-model"}, map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "no previous build") {
+		t.Errorf("got %v, want no previous build", err)
+	}
+
+	second := deployBuild()
+	third := deployBuild()
+
+	var out bytes.Buffer
+	if err := rollback(&out, client, Options{DeploymentID: "my-model"}, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"from build " + third + " to " + second,
+		"- image: registry.mock/mock-tenant/my-model:" + third,
+		"+ image: registry.mock/mock-tenant/my-model:" + second,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	ids := map[string]string{}
+	if err := rollback(&bytes.Buffer{}, client, Options{DeploymentID: "my-model", ToBuild: first}, ids); err != nil {
+		t.Fatal(err)
+	}
+	hub, err := client.GetHubDeployment("my-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hub.ActiveBuildID == nil || *hub.ActiveBuildID != first || ids["build_id"] != first {
+		t.Errorf("active build %v, want %s", hub.ActiveBuildID, first)
+	}
+
+	err = rollback(&bytes.Buffer{}, client, Options{DeploymentID: "my-model", ToBuild: first}, map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "already active") {
+		t.Errorf("got %v, want already active", err)
+	}
+}
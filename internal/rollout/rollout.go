@@ -0,0 +1,174 @@
+// Package rollout watches a deployment after a deploy or update and decides
+// whether the new version is healthy enough to keep.
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+)
+
+const (
+	// DefaultWindow is how long a rollout is watched before it is accepted.
+	DefaultWindow = 5 * time.Minute
+	// DefaultMaxErrorRate is the fraction of failed invocations that fails a rollout.
+	DefaultMaxErrorRate = 0.05
+
+	// defaultInterval is how often the orchestrator is polled.
+	defaultInterval = 10 * time.Second
+	// minRequests is the fewest invocations an error rate is judged on.
+	minRequests = 10
+	// maxEvents is how many recent events are searched for crashes.
+	maxEvents = 50
+)
+
+// Policy decides when a rollout has failed.
+type Policy struct {
+	Window       time.Duration // How long to watch; workers must be ready within it
+	MaxErrorRate float64       // Fraction (0-1) of failed invocations that fails the rollout
+	Interval     time.Duration // How often to poll (default 10s)
+}
+
+// Report is the outcome of watching a rollout.
+type Report struct {
+	Healthy bool
+	Reason  string                // Why the rollout failed; empty if healthy
+	Health  *api.DeploymentHealth // The last health sample, if any
+	Crashes []api.DeploymentEvent // Worker crashes and OOM kills since the rollout, newest first
+	Elapsed time.Duration
+}
+
+// Watch polls a deployment's health from since (when it was deployed) until
+// the policy's window has passed or the rollout fails: the orchestrator
+// reports it failed, its error rate exceeds the policy, or no worker is
+// ready by the end of the window. Progress is written to out. Cancelling ctx
+// stops watching early; callers check ctx before acting on the report.
+func Watch(ctx context.Context, out io.Writer, client api.OrchestratorAPI, deploymentID string, since time.Time, policy Policy) *Report {
+	interval := policy.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	start := time.Now()
+	deadline := start.Add(policy.Window)
+	report := &Report{}
+	everReady := false
+	lastLine := ""
+
+	for {
+		health, err := client.GetDeploymentHealth(deploymentID)
+		if err != nil {
+			fmt.Fprintf(out, "  Warning: failed to get deployment health: %v\n", err)
+		} else {
+			report.Health = health
+			everReady = everReady || health.ReadyWorkers > 0
+
+			if line := summary(health); line != lastLine {
+				fmt.Fprintf(out, "  %s\n", line)
+				lastLine = line
+			}
+
+			switch {
+			case health.Status == "failed" || health.Status == "error":
+				report.Reason = fmt.Sprintf("deployment reported status %s", health.Status)
+			case health.Requests >= minRequests && health.ErrorRate() > policy.MaxErrorRate:
+				report.Reason = fmt.Sprintf("error rate %.1f%% (%d of %d requests) exceeded %.1f%%",
+					health.ErrorRate()*100, health.Errors, health.Requests, policy.MaxErrorRate*100)
+			}
+		}
+
+		if report.Reason == "" && !time.Now().Before(deadline) {
+			if !everReady {
+				report.Reason = fmt.Sprintf("no worker became ready within %v", policy.Window)
+			}
+			break
+		}
+		if report.Reason != "" {
+			break
+		}
+		if interrupt.Sleep(ctx, min(interval, time.Until(deadline))) != nil {
+			break
+		}
+	}
+
+	report.Elapsed = time.Since(start)
+	report.Healthy = report.Reason == ""
+	if !report.Healthy {
+		report.Crashes = crashesSince(client, deploymentID, since)
+	}
+	return report
+}
+
+// crashesSince returns the worker crashes and OOM kills recorded since the
+// rollout. They only explain a failure, so errors are ignored.
+func crashesSince(client api.OrchestratorAPI, deploymentID string, since time.Time) []api.DeploymentEvent {
+	events, err := client.ListDeploymentEvents(deploymentID, maxEvents)
+	if err != nil {
+		return nil
+	}
+	var crashes []api.DeploymentEvent
+	for _, e := range events {
+		if e.Timestamp.Before(since) {
+			continue
+		}
+		if e.Type == api.EventWorkerCrashed || e.Type == api.EventWorkerOOM {
+			crashes = append(crashes, e)
+		}
+	}
+	return crashes
+}
+
+// summary is a one-line description of a health sample.
+func summary(h *api.DeploymentHealth) string {
+	status := h.Status
+	if status == "" {
+		status = "unknown"
+	}
+	return fmt.Sprintf("Status: %s, %d/%d workers ready, %d requests (%.1f%% errors)",
+		status, h.ReadyWorkers, h.DesiredWorkers, h.Requests, h.ErrorRate()*100)
+}
+
+// Write prints why the rollout failed, for the report shown before rolling back.
+func (r *Report) Write(w io.Writer) {
+	if r.Healthy {
+		fmt.Fprintf(w, "Rollout healthy after %v\n", r.Elapsed.Round(time.Second))
+		return
+	}
+
+	fmt.Fprintf(w, "\nRollout failed after %v: %s\n", r.Elapsed.Round(time.Second), r.Reason)
+	if h := r.Health; h != nil {
+		fmt.Fprintf(w, "  Workers ready: %d of %d\n", h.ReadyWorkers, h.DesiredWorkers)
+		fmt.Fprintf(w, "  Requests:      %d in the last %ds, %d failed\n", h.Requests, h.WindowSeconds, h.Errors)
+	}
+	if len(r.Crashes) > 0 {
+		fmt.Fprintln(w, "  Worker failures:")
+		for _, e := range r.Crashes {
+			fmt.Fprintf(w, "    %s  %s  %s\n", e.Timestamp.Local().Format("15:04:05"), orDash(e.WorkerID), crashDetails(e))
+		}
+	}
+}
+
+// crashDetails is the event's message plus how the worker exited.
+func crashDetails(e api.DeploymentEvent) string {
+	details := e.Message
+	switch {
+	case e.ExitCode != nil && e.Reason != "":
+		details += fmt.Sprintf(" (exit code %d, %s)", *e.ExitCode, e.Reason)
+	case e.ExitCode != nil:
+		details += fmt.Sprintf(" (exit code %d)", *e.ExitCode)
+	case e.Reason != "":
+		details += fmt.Sprintf(" (%s)", e.Reason)
+	}
+	return details
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,79 @@
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func newMockClient(t *testing.T) *api.Client {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	return api.NewClient(ts.URL, "token", nil)
+}
+
+var testPolicy = Policy{Window: 200 * time.Millisecond, MaxErrorRate: 0.05, Interval: 20 * time.Millisecond}
+
+func TestWatchHealthy(t *testing.T) {
+	client := newMockClient(t)
+	since := time.Now()
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-model", ImageURL: "registry.example/my-model:1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	report := Watch(context.Background(), &out, client, "my-model", since, testPolicy)
+	if !report.Healthy {
+		t.Fatalf("expected a healthy rollout, got %q\n%s", report.Reason, out.String())
+	}
+	if !strings.Contains(out.String(), "1/1 workers ready") {
+		t.Errorf("output missing worker readiness:\n%s", out.String())
+	}
+}
+
+func TestWatchErrorRate(t *testing.T) {
+	client := newMockClient(t)
+	since := time.Now()
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-model", ImageURL: "registry.example/my-model:broken"}); err != nil {
+		t.Fatal(err)
+	}
+	for range minRequests {
+		client.Invoke("my-model", "generate", []byte(`{}`))
+	}
+
+	report := Watch(context.Background(), &bytes.Buffer{}, client, "my-model", since, testPolicy)
+	if report.Healthy || !strings.Contains(report.Reason, "error rate 100.0% (10 of 10 requests) exceeded 5.0%") {
+		t.Fatalf("got reason %q, want an error rate failure", report.Reason)
+	}
+}
+
+func TestWatchNeverReady(t *testing.T) {
+	client := newMockClient(t)
+	since := time.Now()
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "my-model", ImageURL: "registry.example/my-model:crashloop"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := Watch(context.Background(), &bytes.Buffer{}, client, "my-model", since, testPolicy)
+	if report.Healthy || !strings.Contains(report.Reason, "no worker became ready within 200ms") {
+		t.Fatalf("got reason %q, want a readiness failure", report.Reason)
+	}
+	if len(report.Crashes) != 1 {
+		t.Fatalf("got crashes %+v, want the startup crash", report.Crashes)
+	}
+
+	var out bytes.Buffer
+	report.Write(&out)
+	for _, want := range []string{"Rollout failed", "Workers ready: 0 of 1", "my-model-worker-0", "(exit code 1, Error)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,337 @@
+// Package scan checks a project's dependencies before its images go to production.
+package scan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/pypi"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// DefaultDeny are the licenses denied when the project doesn't configure any.
+var DefaultDeny = []string{"AGPL", "SSPL"}
+
+// Unknown is reported for dependencies whose license can't be determined.
+const Unknown = "UNKNOWN"
+
+// License scan statuses.
+const (
+	StatusOK      = "ok"
+	StatusDenied  = "denied"
+	StatusUnknown = "unknown"
+	StatusIgnored = "ignored"
+)
+
+// lookupConcurrency bounds the concurrent requests to the package index.
+const lookupConcurrency = 8
+
+// LicensesOptions contains the options for scanning dependency licenses.
+type LicensesOptions struct {
+	ProjectDir    string
+	IndexURL      string   // PyPI JSON API (default pypi.DefaultIndexURL)
+	Deny          []string // Denied in addition to the project's denylist
+	FailOnUnknown bool
+	Output        ui.Output
+}
+
+// LicenseResult is the license of one dependency.
+type LicenseResult struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	License string `json:"license"`
+	Status  string `json:"status"`
+	Detail  string `json:"detail,omitempty"` // Why the license is denied or unknown
+}
+
+// LicenseReport is the result of a license scan.
+type LicenseReport struct {
+	Source     string          `json:"source"`
+	Transitive bool            `json:"transitive"`
+	Deny       []string        `json:"deny"`
+	Packages   []LicenseResult `json:"packages"`
+}
+
+// Licenses resolves the project's Python dependencies, looks up their
+// licenses on the package index, and fails if any is denied.
+func Licenses(ctx context.Context, opts LicensesOptions) error {
+	return licenses(ctx, os.Stdout, pypi.NewClient(opts.IndexURL), opts)
+}
+
+func licenses(ctx context.Context, w io.Writer, client *pypi.Client, opts LicensesOptions) error {
+	cfg := &build.LicensesConfig{Deny: DefaultDeny}
+	if cozy, err := build.GetToolsCozyConfig(filepath.Join(opts.ProjectDir, build.PyProjectTomlPath)); err == nil && cozy.Licenses != nil {
+		cfg = cozy.Licenses
+		if cfg.Deny == nil {
+			cfg.Deny = DefaultDeny
+		}
+	}
+	deny := append(slices.Clone(cfg.Deny), opts.Deny...)
+	failOnUnknown := cfg.FailOnUnknown || opts.FailOnUnknown
+
+	resolution, err := pypi.Resolve(ctx, opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	report := &LicenseReport{Source: resolution.Source, Transitive: resolution.Transitive, Deny: deny}
+	report.Packages = lookupLicenses(ctx, client, resolution.Packages)
+	for i := range report.Packages {
+		r := &report.Packages[i]
+		switch {
+		case slices.ContainsFunc(cfg.Ignore, func(name string) bool { return pypi.NormalizeName(name) == r.Name }):
+			r.Status = StatusIgnored
+		case r.License == Unknown:
+			r.Status = StatusUnknown
+		default:
+			if denied := deniedBy(r.License, deny); denied != "" {
+				r.Status = StatusDenied
+				r.Detail = "matches " + denied
+			} else {
+				r.Status = StatusOK
+			}
+		}
+	}
+
+	if opts.Output.Structured() {
+		if err := ui.WriteStructured(w, opts.Output, report); err != nil {
+			return err
+		}
+	} else {
+		writeLicenseReport(w, report, opts.Output == ui.OutputWide)
+	}
+
+	var denied, unknown []string
+	for _, r := range report.Packages {
+		switch r.Status {
+		case StatusDenied:
+			denied = append(denied, fmt.Sprintf("%s (%s)", r.Name, r.License))
+		case StatusUnknown:
+			unknown = append(unknown, r.Name)
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("%d %s denied licenses: %s", len(denied), plural(len(denied), "dependency has", "dependencies have"), strings.Join(denied, ", "))
+	}
+	if failOnUnknown && len(unknown) > 0 {
+		return fmt.Errorf("%d %s no known license: %s", len(unknown), plural(len(unknown), "dependency has", "dependencies have"), strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// lookupLicenses fetches the license of every package from the index.
+func lookupLicenses(ctx context.Context, client *pypi.Client, packages []pypi.Package) []LicenseResult {
+	results := make([]LicenseResult, len(packages))
+	sem := make(chan struct{}, lookupConcurrency)
+	var wg sync.WaitGroup
+	for i, p := range packages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = LicenseResult{Name: p.Name, Version: p.Version, License: Unknown}
+			release, err := client.Release(ctx, p.Name, p.Version)
+			switch {
+			case err != nil:
+				results[i].Detail = err.Error()
+			case release == nil:
+				results[i].Detail = "not found on the package index"
+			default:
+				if results[i].Version == "" {
+					results[i].Version = release.Info.Version
+				}
+				results[i].License = licenseOf(release.Info)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func writeLicenseReport(w io.Writer, report *LicenseReport, wide bool) {
+	if !report.Transitive {
+		fmt.Fprintf(w, "Warning: only direct dependencies from %s were scanned; add a uv.lock or requirements.lock (or install uv) to include transitive ones\n\n", report.Source)
+	}
+	if len(report.Packages) == 0 {
+		fmt.Fprintf(w, "No dependencies found in %s.\n", report.Source)
+		return
+	}
+
+	table := &ui.Table{Columns: []string{"PACKAGE", "VERSION", "LICENSE", "STATUS"}}
+	if wide {
+		table.Columns = append(table.Columns, "DETAIL")
+	}
+	counts := map[string]int{}
+	for _, r := range report.Packages {
+		counts[r.Status]++
+		cells := []string{r.Name, orDash(r.Version), r.License, r.Status}
+		if wide {
+			cells = append(cells, orDash(r.Detail))
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: r.Name, Status: r.Status, Cells: cells})
+	}
+	table.Write(w)
+
+	fmt.Fprintf(w, "\n%d packages from %s: %d ok, %d denied, %d unknown, %d ignored (denied: %s)\n",
+		len(report.Packages), report.Source, counts[StatusOK], counts[StatusDenied], counts[StatusUnknown], counts[StatusIgnored], strings.Join(report.Deny, ", "))
+}
+
+// licenseClassifiers maps trove license classifiers to SPDX identifiers.
+var licenseClassifiers = map[string]string{
+	"MIT License":                                             "MIT",
+	"BSD License":                                             "BSD",
+	"Apache Software License":                                 "Apache-2.0",
+	"ISC License (ISCL)":                                      "ISC",
+	"Python Software Foundation License":                      "PSF-2.0",
+	"Mozilla Public License 2.0 (MPL 2.0)":                    "MPL-2.0",
+	"The Unlicense (Unlicense)":                               "Unlicense",
+	"GNU Affero General Public License v3":                    "AGPL-3.0-only",
+	"GNU Affero General Public License v3 or later (AGPLv3+)": "AGPL-3.0-or-later",
+	"GNU General Public License v2 (GPLv2)":                   "GPL-2.0-only",
+	"GNU General Public License v2 or later (GPLv2+)":         "GPL-2.0-or-later",
+	"GNU General Public License v3 (GPLv3)":                   "GPL-3.0-only",
+	"GNU General Public License v3 or later (GPLv3+)":         "GPL-3.0-or-later",
+	"GNU Lesser General Public License v2 (LGPLv2)":           "LGPL-2.0-only",
+	"GNU Lesser General Public License v2 or later (LGPLv2+)": "LGPL-2.0-or-later",
+	"GNU Lesser General Public License v3 (LGPLv3)":           "LGPL-3.0-only",
+	"GNU Lesser General Public License v3 or later (LGPLv3+)": "LGPL-3.0-or-later",
+	"Eclipse Public License 2.0 (EPL-2.0)":                    "EPL-2.0",
+	"Creative Commons Zero v1.0 Universal (CC0 1.0)":          "CC0-1.0",
+	"Server Side Public License (SSPL)":                       "SSPL-1.0",
+	"Other/Proprietary License":                               "LicenseRef-Proprietary",
+	"Public Domain":                                           "LicenseRef-Public-Domain",
+}
+
+// licenseTexts recognizes full license texts some packages put in their
+// license field, by a phrase unique to each.
+var licenseTexts = []struct{ phrase, spdx string }{
+	{"GNU AFFERO GENERAL PUBLIC LICENSE", "AGPL-3.0"},
+	{"GNU LESSER GENERAL PUBLIC LICENSE", "LGPL"},
+	{"GNU GENERAL PUBLIC LICENSE", "GPL"},
+	{"SERVER SIDE PUBLIC LICENSE", "SSPL-1.0"},
+	{"APACHE LICENSE", "Apache-2.0"},
+	{"PERMISSION IS HEREBY GRANTED, FREE OF CHARGE", "MIT"},
+	{"REDISTRIBUTION AND USE IN SOURCE AND BINARY FORMS", "BSD"},
+	{"MOZILLA PUBLIC LICENSE", "MPL-2.0"},
+}
+
+// licenseOf determines the license of a release: its SPDX license
+// expression if it declares one, then its license classifiers, then its
+// free-form license field.
+func licenseOf(info pypi.Info) string {
+	if expr := strings.TrimSpace(info.LicenseExpression); expr != "" {
+		return expr
+	}
+
+	var fromClassifiers []string
+	for _, c := range info.Classifiers {
+		name, ok := strings.CutPrefix(c, "License :: ")
+		if !ok {
+			continue
+		}
+		if i := strings.LastIndex(name, " :: "); i >= 0 {
+			name = name[i+len(" :: "):]
+		}
+		if spdx, ok := licenseClassifiers[name]; ok {
+			name = spdx
+		}
+		if name != "OSI Approved" && !slices.Contains(fromClassifiers, name) {
+			fromClassifiers = append(fromClassifiers, name)
+		}
+	}
+	if len(fromClassifiers) > 0 {
+		return strings.Join(fromClassifiers, " OR ")
+	}
+
+	license := strings.TrimSpace(info.License)
+	switch {
+	case license == "" || strings.EqualFold(license, "UNKNOWN"):
+		return Unknown
+	case len(license) <= 64 && !strings.Contains(license, "\n"):
+		return license
+	}
+	upper := strings.ToUpper(license)
+	for _, t := range licenseTexts {
+		if strings.Contains(upper, t.phrase) {
+			return t.spdx
+		}
+	}
+	return Unknown
+}
+
+// licenseIDs splits a license expression into its identifiers.
+var licenseIDs = regexp.MustCompile(`[^\s()]+`)
+
+// deniedBy returns the denylist entry a license expression violates, or "".
+// An expression with alternatives ("MIT OR GPL-3.0") is only denied if every
+// alternative is.
+func deniedBy(expr string, deny []string) string {
+	denied := ""
+	for _, alternative := range splitFold(expr, " OR ") {
+		match := ""
+		for _, id := range licenseIDs.FindAllString(alternative, -1) {
+			if strings.EqualFold(id, "AND") || strings.EqualFold(id, "WITH") {
+				continue
+			}
+			if i := slices.IndexFunc(deny, func(d string) bool { return licenseMatches(id, d) }); i >= 0 {
+				match = deny[i]
+				break
+			}
+		}
+		if match == "" {
+			return ""
+		}
+		denied = match
+	}
+	return denied
+}
+
+// licenseMatches reports whether a license identifier is a denylist entry
+// or a version of it: "AGPL" matches AGPL-3.0-only and AGPLv3, but "GPL"
+// doesn't match LGPL-3.0.
+func licenseMatches(id, entry string) bool {
+	id, entry = strings.ToUpper(strings.TrimSuffix(id, "+")), strings.ToUpper(entry)
+	if id == entry || strings.HasPrefix(id, entry+"-") {
+		return true
+	}
+	rest, ok := strings.CutPrefix(id, entry)
+	return ok && len(rest) > 1 && rest[0] == 'V' && rest[1] >= '0' && rest[1] <= '9'
+}
+
+// splitFold splits s around each case-insensitive instance of sep.
+func splitFold(s, sep string) []string {
+	var parts []string
+	upper, sep := strings.ToUpper(s), strings.ToUpper(sep)
+	for {
+		i := strings.Index(upper, sep)
+		if i < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s, upper = s[i+len(sep):], upper[i+len(sep):]
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
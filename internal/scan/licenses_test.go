@@ -0,0 +1,88 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/pypi"
+)
+
+// fakeIndex serves PyPI JSON for a fixed set of releases.
+func fakeIndex(t *testing.T, releases map[string]pypi.Info) *pypi.Client {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(strings.Trim(r.URL.Path, "/"), "/")[0]
+		info, ok := releases[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(pypi.Release{Info: info})
+	}))
+	t.Cleanup(ts.Close)
+	return pypi.NewClient(ts.URL)
+}
+
+func TestLicenses(t *testing.T) {
+	client := fakeIndex(t, map[string]pypi.Info{
+		"requests":  {Version: "2.32.3", License: "Apache 2.0"},
+		"ghostpdl":  {Version: "1.0", LicenseExpression: "AGPL-3.0-or-later"},
+		"dual":      {Version: "1.0", Classifiers: []string{"License :: OSI Approved :: MIT License", "License :: OSI Approved :: GNU Affero General Public License v3"}},
+		"gpl-thing": {Version: "1.0", Classifiers: []string{"License :: OSI Approved :: GNU General Public License v3 (GPLv3)"}},
+		"mystery":   {Version: "1.0"},
+	})
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "requirements.lock"), []byte("requests==2.32.3\nghostpdl==1.0\ndual==1.0\ngpl-thing==1.0\nmystery==1.0\n"), 0644)
+
+	var out bytes.Buffer
+	err := licenses(context.Background(), &out, client, LicensesOptions{ProjectDir: dir})
+	if err == nil || err.Error() != "1 dependency has denied licenses: ghostpdl (AGPL-3.0-or-later)" {
+		t.Errorf("expected ghostpdl to be denied, got %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"requests   2.32.3   Apache 2.0", "dual       1.0      MIT OR AGPL-3.0-only  ok", "mystery    1.0      UNKNOWN               unknown"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// The project's denylist replaces the default, and --deny adds to it
+	os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte("[tool.cozy.licenses]\ndeny = [\"GPL\"]\nignore = [\"GPL_Thing\"]\nfail-on-unknown = true\n"), 0644)
+	out.Reset()
+	err = licenses(context.Background(), &out, client, LicensesOptions{ProjectDir: dir})
+	if err == nil || err.Error() != "1 dependency has no known license: mystery" {
+		t.Errorf("expected only the unknown license to fail, got %v\n%s", err, out.String())
+	}
+
+	out.Reset()
+	err = licenses(context.Background(), &out, client, LicensesOptions{ProjectDir: dir, Deny: []string{"AGPL"}})
+	if err == nil || !strings.Contains(err.Error(), "ghostpdl (AGPL-3.0-or-later)") || strings.Contains(err.Error(), "dual") {
+		t.Errorf("expected --deny to add AGPL, got %v", err)
+	}
+}
+
+func TestDeniedBy(t *testing.T) {
+	deny := []string{"AGPL", "GPL-3.0"}
+	for expr, want := range map[string]string{
+		"AGPL-3.0-only":               "AGPL",
+		"AGPLv3+":                     "AGPL",
+		"GPL-3.0-or-later":            "GPL-3.0",
+		"LGPL-3.0-only":               "",
+		"GPL-2.0-only":                "",
+		"MIT OR AGPL-3.0":             "",
+		"(GPL-3.0 or AGPL-3.0)":       "AGPL",
+		"Apache-2.0 AND GPL-3.0-only": "GPL-3.0",
+		"MIT":                         "",
+	} {
+		if got := deniedBy(expr, deny); got != want {
+			t.Errorf("deniedBy(%q) = %q, want %q", expr, got, want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package selfupdate replaces the running cozyctl with a release downloaded
+// from GitHub, after verifying the release's signature.
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/release"
+)
+
+// DefaultLatestURL answers with the newest release's tag.
+const DefaultLatestURL = "https://api.github.com/repos/cozy-creator/cozyctl/releases/latest"
+
+// Options contains the options for updating cozyctl.
+type Options struct {
+	Version    string // Release tag to install; the latest release if empty
+	BaseURL    string // Archive download URL prefix; defaults to release.DefaultBaseURL
+	LatestURL  string // Defaults to DefaultLatestURL
+	Executable string // Binary to replace; defaults to the running cozyctl
+}
+
+// Run updates the running cozyctl to opts.Version or the latest release.
+func Run(opts Options) error {
+	return update(os.Stdout, http.DefaultClient, publicKeys, opts)
+}
+
+func update(w io.Writer, client *http.Client, keyList string, opts Options) error {
+	keys, err := trustedKeys(keyList)
+	if err != nil {
+		return err
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = release.DefaultBaseURL
+	}
+	if opts.LatestURL == "" {
+		opts.LatestURL = DefaultLatestURL
+	}
+	if opts.Executable == "" {
+		if opts.Executable, err = os.Executable(); err != nil {
+			return fmt.Errorf("failed to find the cozyctl binary: %w", err)
+		}
+		if opts.Executable, err = filepath.EvalSymlinks(opts.Executable); err != nil {
+			return fmt.Errorf("failed to find the cozyctl binary: %w", err)
+		}
+	}
+
+	version := opts.Version
+	if version == "" {
+		if version, err = latestVersion(client, opts.LatestURL); err != nil {
+			return err
+		}
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if current := build.CozyctlVersion(); current == version {
+		fmt.Fprintf(w, "cozyctl %s is already installed.\n", version)
+		return nil
+	}
+
+	name := release.ArchiveName(version, runtime.GOOS, runtime.GOARCH)
+	base := strings.ReplaceAll(opts.BaseURL, "{version}", version) + "/"
+	fmt.Fprintf(w, "Downloading %s...\n", name)
+	archive, err := download(client, base+name)
+	if err != nil {
+		return err
+	}
+	signatures, err := download(client, base+name+SignatureSuffix)
+	if errors.Is(err, errNotFound) {
+		return fmt.Errorf("refusing to install %s: %w", version, ErrUnsigned)
+	}
+	if err != nil {
+		return err
+	}
+	if err := verify(archive, signatures, keys); err != nil {
+		return fmt.Errorf("refusing to install %s: %w", version, err)
+	}
+	fmt.Fprintf(w, "Verified the signature of %s.\n", name)
+
+	binary, err := extract(name, archive)
+	if err != nil {
+		return err
+	}
+	if err := replace(opts.Executable, binary); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Updated %s to %s.\n", opts.Executable, version)
+	return nil
+}
+
+// errNotFound is returned by download for a URL that doesn't exist.
+var errNotFound = errors.New("not found")
+
+// latestVersion asks GitHub for the newest release's tag.
+func latestVersion(client *http.Client, url string) (string, error) {
+	data, err := download(client, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the latest release: %w", err)
+	}
+	var latest struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.Unmarshal(data, &latest); err != nil || latest.TagName == "" {
+		return "", fmt.Errorf("failed to find the latest release: unexpected response from %s", url)
+	}
+	return latest.TagName, nil
+}
+
+func download(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("failed to download %s: %w", url, errNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: HTTP %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// extract returns the cozyctl binary from a release archive.
+func extract(name string, archive []byte) ([]byte, error) {
+	binary := path.Base(filepath.ToSlash(release.BinaryPath(runtime.GOOS, runtime.GOARCH)))
+
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if path.Base(f.Name) != binary {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("%s has no %s", name, binary)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s has no %s", name, binary)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive %s: %w", name, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == binary {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replace swaps the binary at exe for a new one. The new binary is written
+// next to it and renamed into place, so an interrupted update leaves the
+// old one working; Windows can't replace a running binary, so there it is
+// moved aside first.
+func replace(exe string, binary []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".cozyctl-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the new cozyctl: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the new cozyctl: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the new cozyctl: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", exe, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", exe, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/release"
+)
+
+// newKey generates a release signing key and returns it with its public
+// half encoded as publicKeys expects.
+func newKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, base64.StdEncoding.EncodeToString(der)
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, data []byte) string {
+	t.Helper()
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(sig) + "\n"
+}
+
+// releaseArchive packs binary the way the release build does for this platform.
+func releaseArchive(t *testing.T, name string, binary []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	binaryName := filepath.Base(release.BinaryPath(runtime.GOOS, runtime.GOARCH))
+	if strings.HasSuffix(name, ".zip") {
+		zw := zip.NewWriter(&buf)
+		f, err := zw.Create(binaryName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(binary)
+		zw.Close()
+		return buf.Bytes()
+	}
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: 2, Typeflag: tar.TypeReg})
+	tw.Write([]byte("hi"))
+	tw.WriteHeader(&tar.Header{Name: binaryName, Mode: 0755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+	tw.Write(binary)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestUpdateVerifiesSignature(t *testing.T) {
+	oldKey, oldPub := newKey(t)
+	newKeyPair, newPub := newKey(t)
+	_, otherPub := newKey(t)
+
+	name := release.ArchiveName("v9.9.9", runtime.GOOS, runtime.GOARCH)
+	archive := releaseArchive(t, name, []byte("new cozyctl"))
+	files := map[string]string{
+		"/latest":                  `{"tag_name": "v9.9.9"}`,
+		"/v9.9.9/" + name:          string(archive),
+		"/v9.9.9/" + name + ".sig": sign(t, newKeyPair, archive),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+
+	exe := filepath.Join(t.TempDir(), "cozyctl")
+	if err := os.WriteFile(exe, []byte("old cozyctl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{BaseURL: ts.URL + "/{version}", LatestURL: ts.URL + "/latest", Executable: exe}
+	installed := func() string {
+		data, _ := os.ReadFile(exe)
+		return string(data)
+	}
+
+	if err := update(&bytes.Buffer{}, ts.Client(), "", opts); !errors.Is(err, ErrNoKeys) {
+		t.Errorf("without keys: got %v, want ErrNoKeys", err)
+	}
+	if err := update(&bytes.Buffer{}, ts.Client(), otherPub, opts); err == nil || !strings.Contains(err.Error(), "doesn't match any trusted") {
+		t.Errorf("with another key: got %v, want a signature mismatch", err)
+	}
+
+	// Tampered archives fail verification
+	files["/v9.9.9/"+name] = string(releaseArchive(t, name, []byte("evil cozyctl")))
+	if err := update(&bytes.Buffer{}, ts.Client(), newPub, opts); err == nil || !strings.Contains(err.Error(), "refusing to install v9.9.9") {
+		t.Errorf("tampered archive: got %v, want refusal", err)
+	}
+	files["/v9.9.9/"+name] = string(archive)
+
+	delete(files, "/v9.9.9/"+name+".sig")
+	if err := update(&bytes.Buffer{}, ts.Client(), newPub, opts); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("unsigned archive: got %v, want ErrUnsigned", err)
+	}
+	if installed() != "old cozyctl" {
+		t.Fatalf("binary replaced by a refused update: %q", installed())
+	}
+
+	// During a rotation the archive carries both signatures, and a cozyctl
+	// trusting only the old key still updates
+	files["/v9.9.9/"+name+".sig"] = sign(t, oldKey, archive) + sign(t, newKeyPair, archive)
+	var out bytes.Buffer
+	if err := update(&out, ts.Client(), oldPub, opts); err != nil {
+		t.Fatal(err)
+	}
+	if installed() != "new cozyctl" {
+		t.Errorf("installed %q, want the new binary", installed())
+	}
+	if !strings.Contains(out.String(), "Verified the signature") || !strings.Contains(out.String(), "to v9.9.9") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,141 @@
+package smoke
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Expectation is a JSONPath expression with an optional expected value.
+// Syntax: "$.path.to[0].field" (must exist) or "$.path==value" (must equal).
+// The value is parsed as JSON when possible, otherwise compared as a string.
+type Expectation struct {
+	Path     string
+	Value    any
+	HasValue bool
+}
+
+// ParseExpectation parses an expectation string.
+func ParseExpectation(expr string) (*Expectation, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+
+	exp := &Expectation{Path: expr}
+	if path, raw, ok := strings.Cut(expr, "=="); ok {
+		exp.Path = strings.TrimSpace(path)
+		exp.HasValue = true
+		raw = strings.TrimSpace(raw)
+		if err := json.Unmarshal([]byte(raw), &exp.Value); err != nil {
+			exp.Value = raw
+		}
+	}
+
+	if _, err := parsePath(exp.Path); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+// Match evaluates the expectation against a JSON document.
+func (e *Expectation) Match(body []byte) error {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("response is not valid JSON: %w", err)
+	}
+
+	got, err := Lookup(doc, e.Path)
+	if err != nil {
+		return err
+	}
+
+	if e.HasValue && !reflect.DeepEqual(got, e.Value) {
+		return fmt.Errorf("%s = %v, want %v", e.Path, formatValue(got), formatValue(e.Value))
+	}
+	return nil
+}
+
+// Lookup resolves a JSONPath expression (dot and bracket notation) in a decoded JSON value.
+func Lookup(doc any, path string) (any, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := doc
+	for _, seg := range segments {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[seg]
+			if !ok {
+				return nil, fmt.Errorf("%s: key %q not found", path, seg)
+			}
+			cur = next
+		case []any:
+			idx, err := strconv.Atoi(seg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %q is not an array index", path, seg)
+			}
+			if idx < 0 {
+				idx += len(v)
+			}
+			if idx < 0 || idx >= len(v) {
+				return nil, fmt.Errorf("%s: index %s out of range (length %d)", path, seg, len(v))
+			}
+			cur = v[idx]
+		default:
+			return nil, fmt.Errorf("%s: cannot descend into %s", path, seg)
+		}
+	}
+	return cur, nil
+}
+
+// parsePath splits "$.a.b[0]['c d']" into ["a", "b", "0", "c d"].
+func parsePath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", path)
+	}
+
+	var segments []string
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty key", path)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unclosed bracket", path)
+			}
+			key := strings.Trim(rest[1:end], `'"`)
+			if key == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty index", path)
+			}
+			segments = append(segments, key)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", path, rest[0])
+		}
+	}
+	return segments, nil
+}
+
+func formatValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
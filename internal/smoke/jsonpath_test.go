@@ -0,0 +1,77 @@
+package smoke
+
+import (
+	"testing"
+)
+
+func TestExpectationMatch(t *testing.T) {
+	body := []byte(`{"status":"ok","count":3,"images":[{"url":"a.png"},{"url":"b.png"}],"meta":{"model id":"sdxl"}}`)
+
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{name: "key exists", expr: "$.status"},
+		{name: "string equality", expr: `$.status == "ok"`},
+		{name: "bare string equality", expr: "$.status==ok"},
+		{name: "number equality", expr: "$.count == 3"},
+		{name: "array index", expr: `$.images[1].url == "b.png"`},
+		{name: "negative index", expr: `$.images[-1].url == "b.png"`},
+		{name: "quoted bracket key", expr: `$.meta['model id'] == "sdxl"`},
+		{name: "value mismatch", expr: `$.status == "error"`, wantErr: true},
+		{name: "missing key", expr: "$.missing", wantErr: true},
+		{name: "index out of range", expr: "$.images[5]", wantErr: true},
+		{name: "descend into scalar", expr: "$.status.inner", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, err := ParseExpectation(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpectation(%q) failed: %v", tt.expr, err)
+			}
+			err = exp.Match(body)
+			if tt.wantErr && err == nil {
+				t.Errorf("Match(%q) = nil, want error", tt.expr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Match(%q) = %v, want nil", tt.expr, err)
+			}
+		})
+	}
+}
+
+func TestParseExpectation_Invalid(t *testing.T) {
+	for _, expr := range []string{"status", "$..status", "$.images[0", "$.images[]"} {
+		if _, err := ParseExpectation(expr); err == nil {
+			t.Errorf("ParseExpectation(%q) = nil error, want error", expr)
+		}
+	}
+}
+
+func TestParseExpectation_Empty(t *testing.T) {
+	exp, err := ParseExpectation("")
+	if err != nil || exp != nil {
+		t.Errorf("ParseExpectation(\"\") = %v, %v; want nil, nil", exp, err)
+	}
+}
+
+func TestParseSpec_FunctionOnly(t *testing.T) {
+	test, err := ParseSpec("health", "")
+	if err != nil {
+		t.Fatalf("ParseSpec failed: %v", err)
+	}
+	if test.Function != "health" {
+		t.Errorf("Function = %q, want 'health'", test.Function)
+	}
+	if test.Payload != nil {
+		t.Errorf("Payload = %q, want nil", test.Payload)
+	}
+}
+
+func TestParseSpec_MissingFunction(t *testing.T) {
+	if _, err := ParseSpec(":payload.json", ""); err == nil {
+		t.Error("expected error for missing function name")
+	}
+}
@@ -0,0 +1,139 @@
+package smoke
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+)
+
+const (
+	// DefaultReadyTimeout bounds how long to wait for a deployment to become ready.
+	DefaultReadyTimeout = 10 * time.Minute
+
+	// DefaultInvokeTimeout bounds the smoke invocation. The first invocation
+	// after a deploy often includes the model's cold start, so it is far
+	// longer than the client's default request timeout.
+	DefaultInvokeTimeout = 5 * time.Minute
+
+	readyPollInterval = 5 * time.Second
+)
+
+// Test describes a post-deploy smoke test: invoke Function with Payload
+// and optionally match the response against Expect.
+type Test struct {
+	Function string
+	Payload  []byte
+	Expect   *Expectation
+	Timeout  time.Duration // Invocation timeout; DefaultInvokeTimeout if zero
+}
+
+// ParseSpec parses a "function:payload.json" spec. The payload file is optional;
+// "function" alone invokes with an empty JSON object.
+func ParseSpec(spec, expect string) (*Test, error) {
+	function, payloadPath, _ := strings.Cut(spec, ":")
+	function = strings.TrimSpace(function)
+	if function == "" {
+		return nil, fmt.Errorf("invalid smoke test %q: expected function:payload.json", spec)
+	}
+
+	test := &Test{Function: function, Timeout: DefaultInvokeTimeout}
+
+	if payloadPath = strings.TrimSpace(payloadPath); payloadPath != "" {
+		payload, err := os.ReadFile(payloadPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read smoke test payload: %w", err)
+		}
+		test.Payload = payload
+	}
+
+	exp, err := ParseExpectation(expect)
+	if err != nil {
+		return nil, err
+	}
+	test.Expect = exp
+
+	return test, nil
+}
+
+// WaitForReady polls the orchestrator until the deployment reports ready,
+// fails, or the timeout elapses.
+func WaitForReady(ctx context.Context, out io.Writer, client api.OrchestratorAPI, deploymentID string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	lastStatus := ""
+
+	for time.Now().Before(deadline) {
+		deployment, err := client.GetDeployment(deploymentID)
+		if err != nil {
+			fmt.Fprintf(out, "  Warning: failed to get deployment: %v\n", err)
+			if err := interrupt.Sleep(ctx, readyPollInterval); err != nil {
+				return err
+			}
+			continue
+		}
+		if deployment == nil {
+			return fmt.Errorf("deployment '%s' not found", deploymentID)
+		}
+
+		if deployment.Status != lastStatus {
+			fmt.Fprintf(out, "  Status: %s\n", deployment.Status)
+			lastStatus = deployment.Status
+		}
+
+		switch deployment.Status {
+		case "ready", "running", "active", "healthy":
+			return nil
+		case "failed", "error":
+			return fmt.Errorf("deployment '%s' reported status %s", deploymentID, deployment.Status)
+		case "":
+			// Older orchestrators don't report status; fall back to ready workers
+			if deployment.ReadyWorkers > 0 {
+				return nil
+			}
+		}
+
+		if err := interrupt.Sleep(ctx, readyPollInterval); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("deployment '%s' not ready after %v", deploymentID, timeout)
+}
+
+// Run waits for the deployment to become ready, invokes the test function,
+// and checks for a 2xx response matching the expectation.
+func (t *Test) Run(ctx context.Context, out io.Writer, client api.OrchestratorAPI, deploymentID string, readyTimeout time.Duration) error {
+	fmt.Fprintf(out, "\nRunning smoke test: %s\n", t.Function)
+	fmt.Fprintln(out, "Waiting for deployment to become ready...")
+	if err := WaitForReady(ctx, out, client, deploymentID, readyTimeout); err != nil {
+		return fmt.Errorf("smoke test failed: %w", err)
+	}
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = DefaultInvokeTimeout
+	}
+	resp, err := client.InvokeWithOptions(deploymentID, t.Function, t.Payload, api.InvokeOptions{Timeout: timeout})
+	if err != nil {
+		return fmt.Errorf("smoke test failed: %w", err)
+	}
+	fmt.Fprintf(out, "  %s returned %d in %v\n", t.Function, resp.StatusCode, resp.Duration.Round(time.Millisecond))
+	if resp.InvocationID != "" {
+		fmt.Fprintf(out, "  Invocation: %s\n", resp.InvocationID)
+	}
+
+	if t.Expect != nil {
+		if err := t.Expect.Match(resp.Body); err != nil {
+			return fmt.Errorf("smoke test failed: %w", err)
+		}
+		fmt.Fprintf(out, "  Matched %s\n", t.Expect.Path)
+	}
+
+	fmt.Fprintln(out, "Smoke test passed")
+	return nil
+}
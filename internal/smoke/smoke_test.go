@@ -0,0 +1,39 @@
+package smoke
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+func TestRunUsesInvokeTimeout(t *testing.T) {
+	delay := 200 * time.Millisecond
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"id": "my-model", "status": "ready"}`))
+			return
+		}
+		// A cold start
+		time.Sleep(delay)
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	t.Cleanup(ts.Close)
+	client := api.NewClient(ts.URL, "token", nil)
+
+	test := &Test{Function: "generate", Timeout: 50 * time.Millisecond}
+	err := test.Run(context.Background(), &bytes.Buffer{}, client, "my-model", time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "smoke test failed") {
+		t.Fatalf("expected the invocation to time out, got %v", err)
+	}
+
+	test.Timeout = 5 * time.Second
+	if err := test.Run(context.Background(), &bytes.Buffer{}, client, "my-model", time.Minute); err != nil {
+		t.Fatalf("expected the smoke test to pass within its timeout, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+// Package stacks manages stacks: named groups of deployments, such as the
+// stages of a pipeline, that are defined in one manifest and applied, checked,
+// and destroyed together.
+package stacks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultManifest is the manifest file read when no path is given.
+const DefaultManifest = "cozy-stack.toml"
+
+// Manifest describes a stack.
+//
+// Example:
+//
+//	name = "image-pipeline"
+//
+//	[[deployments]]
+//	id = "preprocess"
+//	build = "build-0001"
+//
+//	[[deployments]]
+//	id = "generate"
+//	build = "build-0002"
+//	max-workers = 4
+//	depends-on = ["preprocess"]
+type Manifest struct {
+	Name        string    `toml:"name" json:"name"`
+	Deployments []*Member `toml:"deployments" json:"deployments"`
+}
+
+// Member is one deployment of a stack.
+type Member struct {
+	ID         string   `toml:"id" json:"id"`
+	Build      string   `toml:"build" json:"build"`                       // Successful build to deploy
+	MinWorkers *int     `toml:"min-workers" json:"min_workers,omitempty"` // Left as is when unset
+	MaxWorkers *int     `toml:"max-workers" json:"max_workers,omitempty"` // Left as is when unset
+	DependsOn  []string `toml:"depends-on" json:"depends_on,omitempty"`   // Members applied before this one
+}
+
+// LoadManifest reads and validates a stack manifest.
+func LoadManifest(path string) (*Manifest, error) {
+	var m Manifest
+	if _, err := toml.DecodeFile(path, &m); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("stack manifest %s not found", path)
+		}
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("invalid stack manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+func (m *Manifest) validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(m.Deployments) == 0 {
+		return fmt.Errorf("at least one [[deployments]] entry is required")
+	}
+
+	seen := map[string]bool{}
+	for i, member := range m.Deployments {
+		if member.ID == "" {
+			return fmt.Errorf("deployment %d: id is required", i+1)
+		}
+		if seen[member.ID] {
+			return fmt.Errorf("deployment %s is listed more than once", member.ID)
+		}
+		seen[member.ID] = true
+		if member.Build == "" {
+			return fmt.Errorf("deployment %s: build is required", member.ID)
+		}
+		if member.MinWorkers != nil && member.MaxWorkers != nil && *member.MinWorkers > *member.MaxWorkers {
+			return fmt.Errorf("deployment %s: min-workers is greater than max-workers", member.ID)
+		}
+	}
+
+	for _, member := range m.Deployments {
+		for _, dep := range member.DependsOn {
+			if dep == member.ID {
+				return fmt.Errorf("deployment %s depends on itself", member.ID)
+			}
+			if !seen[dep] {
+				return fmt.Errorf("deployment %s depends on %q, which is not in the stack", member.ID, dep)
+			}
+		}
+	}
+	_, err := m.Order()
+	return err
+}
+
+// Order returns the members so that every member comes after the members it
+// depends on. Otherwise independent members keep their manifest order.
+func (m *Manifest) Order() ([]*Member, error) {
+	placed := map[string]bool{}
+	ordered := make([]*Member, 0, len(m.Deployments))
+	remaining := slices.Clone(m.Deployments)
+
+	for len(remaining) > 0 {
+		i := slices.IndexFunc(remaining, func(member *Member) bool {
+			return !slices.ContainsFunc(member.DependsOn, func(dep string) bool { return !placed[dep] })
+		})
+		if i < 0 {
+			ids := make([]string, len(remaining))
+			for j, member := range remaining {
+				ids[j] = member.ID
+			}
+			return nil, fmt.Errorf("depends-on cycle between %s", strings.Join(ids, ", "))
+		}
+		placed[remaining[i].ID] = true
+		ordered = append(ordered, remaining[i])
+		remaining = slices.Delete(remaining, i, i+1)
+	}
+	return ordered, nil
+}
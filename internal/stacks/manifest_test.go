@@ -0,0 +1,86 @@
+package stacks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultManifest)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadManifestOrder(t *testing.T) {
+	path := writeManifest(t, `
+name = "pipeline"
+
+[[deployments]]
+id = "postprocess"
+build = "b3"
+depends-on = ["generate"]
+
+[[deployments]]
+id = "preprocess"
+build = "b1"
+
+[[deployments]]
+id = "generate"
+build = "b2"
+max-workers = 4
+depends-on = ["preprocess"]
+`)
+
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	order, err := m.Order()
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	var ids []string
+	for _, member := range order {
+		ids = append(ids, member.ID)
+	}
+	if got := strings.Join(ids, ","); got != "preprocess,generate,postprocess" {
+		t.Errorf("Order = %s", got)
+	}
+	if max := order[1].MaxWorkers; max == nil || *max != 4 {
+		t.Errorf("generate max-workers = %v, want 4", max)
+	}
+}
+
+func TestLoadManifestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{"missing name", "[[deployments]]\nid = \"a\"\nbuild = \"b\"\n", "name is required"},
+		{"no deployments", "name = \"s\"\n", "at least one [[deployments]]"},
+		{"missing build", "name = \"s\"\n[[deployments]]\nid = \"a\"\n", "deployment a: build is required"},
+		{"duplicate", "name = \"s\"\n[[deployments]]\nid = \"a\"\nbuild = \"b\"\n[[deployments]]\nid = \"a\"\nbuild = \"c\"\n", "listed more than once"},
+		{"unknown dependency", "name = \"s\"\n[[deployments]]\nid = \"a\"\nbuild = \"b\"\ndepends-on = [\"x\"]\n", `depends on "x"`},
+		{"cycle", "name = \"s\"\n[[deployments]]\nid = \"a\"\nbuild = \"b\"\ndepends-on = [\"c\"]\n[[deployments]]\nid = \"c\"\nbuild = \"d\"\ndepends-on = [\"a\"]\n", "cycle between a, c"},
+		{"worker limits", "name = \"s\"\n[[deployments]]\nid = \"a\"\nbuild = \"b\"\nmin-workers = 3\nmax-workers = 1\n", "min-workers is greater"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadManifest(writeManifest(t, tt.manifest))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("LoadManifest error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadManifest(filepath.Join(t.TempDir(), "missing.toml")); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing manifest error = %v", err)
+	}
+}
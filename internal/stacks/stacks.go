@@ -0,0 +1,387 @@
+package stacks
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Results of applying or destroying a stack member.
+const (
+	resultCreated    = "created"
+	resultUpdated    = "updated"
+	resultScaled     = "scaled"
+	resultUnchanged  = "unchanged"
+	resultDeleted    = "deleted"
+	resultNotFound   = "not deployed"
+	resultFailed     = "failed"
+	resultNotApplied = "not applied"
+)
+
+// Sync states of a stack member reported by status.
+const (
+	syncInSync  = "in sync"
+	syncDrifted = "drifted"
+	syncMissing = "missing"
+)
+
+// ApplyOptions contains the options for applying a stack.
+type ApplyOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Manifest  string
+}
+
+// StatusOptions contains the options for showing a stack's status.
+type StatusOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Manifest  string
+	Output    ui.Output
+}
+
+// DestroyOptions contains the options for destroying a stack.
+type DestroyOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Manifest  string
+	Yes       bool // Skip the confirmation
+}
+
+// clients are the APIs a stack is managed through.
+type clients struct {
+	builder      api.BuilderAPI
+	orchestrator api.OrchestratorAPI
+	tenantID     string
+}
+
+// memberResult is the outcome of applying or destroying one member.
+type memberResult struct {
+	member  *Member
+	result  string
+	details string
+}
+
+// Apply deploys every member of a stack in depends-on order, so that each
+// member runs its manifest build and worker limits. Members that already match
+// are left alone. Applying stops at the first member that fails.
+func Apply(opts ApplyOptions) (err error) {
+	m, err := LoadManifest(opts.Manifest)
+	if err != nil {
+		return err
+	}
+	c, err := newClients(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	recorder := history.Start(opts.Profile, "stacks apply")
+	defer func() { recorder.Finish(map[string]string{"stack": m.Name}, err) }()
+
+	return apply(os.Stdout, c, m)
+}
+
+func apply(w io.Writer, c *clients, m *Manifest) error {
+	order, err := m.Order()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Applying stack %s (%d deployments)\n\n", m.Name, len(order))
+	results := make([]memberResult, 0, len(order))
+	var applyErr error
+	for _, member := range order {
+		if applyErr != nil {
+			results = append(results, memberResult{member, resultNotApplied, "-"})
+			continue
+		}
+		result, err := applyMember(c, member)
+		if err != nil {
+			applyErr = fmt.Errorf("failed to apply %s: %w", member.ID, err)
+			results = append(results, memberResult{member, resultFailed, err.Error()})
+			continue
+		}
+		results = append(results, memberResult{member, result, "build " + member.Build})
+	}
+
+	if err := resultTable(results).Write(w); err != nil {
+		return err
+	}
+	if applyErr != nil {
+		return fmt.Errorf("stack %s: %w", m.Name, applyErr)
+	}
+	fmt.Fprintf(w, "\nStack %s applied.\n", m.Name)
+	return nil
+}
+
+// applyMember makes one deployment match its manifest entry and reports
+// what changed.
+func applyMember(c *clients, member *Member) (string, error) {
+	hub, err := c.builder.GetHubDeployment(member.ID)
+	if err != nil {
+		return "", err
+	}
+
+	result := resultUnchanged
+	if hub == nil || hub.ActiveBuildID == nil || *hub.ActiveBuildID != member.Build {
+		if _, err := c.builder.DeployBuild(member.Build, &api.DeployBuildRequest{
+			TenantID:     c.tenantID,
+			DeploymentID: member.ID,
+		}); err != nil {
+			return "", fmt.Errorf("failed to deploy build %s: %w", member.Build, err)
+		}
+		result = resultUpdated
+		if hub == nil {
+			result = resultCreated
+		}
+	}
+
+	if member.MinWorkers == nil && member.MaxWorkers == nil {
+		return result, nil
+	}
+	d, err := c.orchestrator.GetDeployment(member.ID)
+	if err != nil {
+		return "", err
+	}
+	if d == nil {
+		return "", fmt.Errorf("deployment %s not found after deploying", member.ID)
+	}
+	if scaleMatches(member, d) {
+		return result, nil
+	}
+	if _, err := c.orchestrator.UpdateDeployment(member.ID, &api.UpdateDeploymentRequest{
+		MinWorkers: member.MinWorkers,
+		MaxWorkers: member.MaxWorkers,
+	}); err != nil {
+		return "", fmt.Errorf("failed to set worker limits: %w", err)
+	}
+	if result == resultUnchanged {
+		return resultScaled, nil
+	}
+	return result + ", " + resultScaled, nil
+}
+
+func scaleMatches(member *Member, d *api.DeploymentResponse) bool {
+	return (member.MinWorkers == nil || *member.MinWorkers == d.MinWorkers) &&
+		(member.MaxWorkers == nil || *member.MaxWorkers == d.MaxWorkers)
+}
+
+// memberStatus is the live state of one stack member.
+type memberStatus struct {
+	Deployment   string `json:"deployment"`
+	Status       string `json:"status"`
+	ReadyWorkers int    `json:"ready_workers"`
+	MaxWorkers   int    `json:"max_workers"`
+	ActiveBuild  string `json:"active_build,omitempty"`
+	DesiredBuild string `json:"desired_build"`
+	Sync         string `json:"sync"`
+	Drift        string `json:"drift,omitempty"` // What differs from the manifest
+}
+
+// stackStatus is the status of a whole stack.
+type stackStatus struct {
+	Name        string         `json:"name"`
+	Deployments []memberStatus `json:"deployments"`
+}
+
+// Status prints the live state of each stack member and whether it matches
+// the manifest.
+func Status(opts StatusOptions) error {
+	m, err := LoadManifest(opts.Manifest)
+	if err != nil {
+		return err
+	}
+	c, err := newClients(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return status(os.Stdout, c, m, opts.Output)
+}
+
+func status(w io.Writer, c *clients, m *Manifest, output ui.Output) error {
+	order, err := m.Order()
+	if err != nil {
+		return err
+	}
+
+	report := stackStatus{Name: m.Name}
+	for _, member := range order {
+		s, err := memberState(c, member)
+		if err != nil {
+			return fmt.Errorf("failed to get deployment %s: %w", member.ID, err)
+		}
+		report.Deployments = append(report.Deployments, *s)
+	}
+
+	if output.Structured() {
+		return ui.WriteStructured(w, output, report)
+	}
+
+	table := &ui.Table{Columns: []string{"DEPLOYMENT", "STATUS", "WORKERS", "BUILD", "SYNC"}}
+	inSync := 0
+	for _, s := range report.Deployments {
+		sync := s.Sync
+		if s.Drift != "" {
+			sync += " (" + s.Drift + ")"
+		}
+		if s.Sync == syncInSync {
+			inSync++
+		}
+		workers := "-"
+		if s.Sync != syncMissing {
+			workers = fmt.Sprintf("%d/%d", s.ReadyWorkers, s.MaxWorkers)
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: s.Deployment, Status: s.Status, Cells: []string{
+			s.Deployment, orDash(s.Status), workers, orDash(s.ActiveBuild), sync,
+		}})
+	}
+	fmt.Fprintf(w, "Stack %s: %d of %d deployments in sync\n\n", m.Name, inSync, len(report.Deployments))
+	return table.Write(w)
+}
+
+func memberState(c *clients, member *Member) (*memberStatus, error) {
+	s := &memberStatus{Deployment: member.ID, DesiredBuild: member.Build, Sync: syncMissing}
+
+	d, err := c.orchestrator.GetDeployment(member.ID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return s, nil
+	}
+	s.Status = d.Status
+	s.ReadyWorkers = d.ReadyWorkers
+	s.MaxWorkers = d.MaxWorkers
+
+	hub, err := c.builder.GetHubDeployment(member.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hub != nil && hub.ActiveBuildID != nil {
+		s.ActiveBuild = *hub.ActiveBuildID
+	}
+
+	var drift []string
+	if s.ActiveBuild != member.Build {
+		drift = append(drift, "build")
+	}
+	if !scaleMatches(member, d) {
+		drift = append(drift, "workers")
+	}
+	s.Sync = syncInSync
+	if len(drift) > 0 {
+		s.Sync = syncDrifted
+		s.Drift = strings.Join(drift, ", ")
+	}
+	return s, nil
+}
+
+// Destroy deletes every deployment of a stack, dependents first, after
+// confirming unless opts.Yes.
+func Destroy(opts DestroyOptions) (err error) {
+	m, err := LoadManifest(opts.Manifest)
+	if err != nil {
+		return err
+	}
+	c, err := newClients(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	recorder := history.Start(opts.Profile, "stacks destroy")
+	defer func() { recorder.Finish(map[string]string{"stack": m.Name}, err) }()
+
+	return destroy(os.Stdin, os.Stdout, c, m, opts.Yes)
+}
+
+func destroy(in io.Reader, w io.Writer, c *clients, m *Manifest, yes bool) error {
+	order, err := m.Order()
+	if err != nil {
+		return err
+	}
+	slices.Reverse(order)
+
+	if !yes {
+		ids := make([]string, len(order))
+		for i, member := range order {
+			ids[i] = member.ID
+		}
+		fmt.Fprintf(w, "Stack %s: %s\n", m.Name, strings.Join(ids, ", "))
+		ok, err := ui.Confirm(in, w, fmt.Sprintf("Delete these %d deployments?", len(order)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(w, "Aborted.")
+			return nil
+		}
+	}
+
+	results := make([]memberResult, 0, len(order))
+	failed := 0
+	for _, member := range order {
+		d, err := c.orchestrator.GetDeployment(member.ID)
+		if err == nil && d == nil {
+			results = append(results, memberResult{member, resultNotFound, "-"})
+			continue
+		}
+		if err == nil {
+			err = c.orchestrator.DeleteDeployment(member.ID)
+		}
+		if err != nil {
+			failed++
+			results = append(results, memberResult{member, resultFailed, err.Error()})
+			continue
+		}
+		results = append(results, memberResult{member, resultDeleted, "-"})
+	}
+
+	if err := resultTable(results).Write(w); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("stack %s: failed to delete %d of %d deployments", m.Name, failed, len(order))
+	}
+	fmt.Fprintf(w, "\nStack %s destroyed.\n", m.Name)
+	return nil
+}
+
+func resultTable(results []memberResult) *ui.Table {
+	table := &ui.Table{Columns: []string{"DEPLOYMENT", "RESULT", "DETAILS"}}
+	for _, r := range results {
+		table.Rows = append(table.Rows, ui.Row{Key: r.member.ID, Status: r.result, Cells: []string{
+			r.member.ID, r.result, r.details,
+		}})
+	}
+	return table
+}
+
+// newClients creates the builder and orchestrator clients for a profile.
+func newClients(ref config.ProfileRef, transport http.RoundTripper) (*clients, error) {
+	profileCfg, err := config.LoadLoggedInProfile(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := profileCfg.Config.WithDefaultURLs()
+	return &clients{
+		builder:      api.NewBuilderClient(cfg.BuilderURL, cfg.Token, transport),
+		orchestrator: api.NewClient(cfg.OrchestratorURL, cfg.Token, transport),
+		tenantID:     cfg.TenantID,
+	}, nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package stacks
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func newMockClients(t *testing.T) *clients {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	return &clients{
+		builder:      api.NewBuilderClient(ts.URL, "token", nil),
+		orchestrator: api.NewClient(ts.URL, "token", nil),
+		tenantID:     "tenant",
+	}
+}
+
+func uploadBuild(t *testing.T, c *clients, deployment string) string {
+	t.Helper()
+	resp, err := c.builder.UploadBuild(strings.NewReader("tarball"), deployment, api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.BuildID
+}
+
+func intPtr(n int) *int { return &n }
+
+func TestApplyStatusDestroy(t *testing.T) {
+	c := newMockClients(t)
+	m := &Manifest{Name: "pipeline", Deployments: []*Member{
+		{ID: "generate", Build: uploadBuild(t, c, "generate"), MaxWorkers: intPtr(3), DependsOn: []string{"preprocess"}},
+		{ID: "preprocess", Build: uploadBuild(t, c, "preprocess")},
+	}}
+
+	var out bytes.Buffer
+	if err := apply(&out, c, m); err != nil {
+		t.Fatalf("apply: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "created, scaled") || !strings.Contains(out.String(), "Stack pipeline applied.") {
+		t.Errorf("apply output:\n%s", out.String())
+	}
+	if i, j := strings.Index(out.String(), "preprocess"), strings.Index(out.String(), "generate "); i > j {
+		t.Errorf("preprocess should be applied before generate:\n%s", out.String())
+	}
+
+	// A second apply changes nothing
+	out.Reset()
+	if err := apply(&out, c, m); err != nil {
+		t.Fatalf("apply again: %v", err)
+	}
+	if strings.Count(out.String(), resultUnchanged) != 2 {
+		t.Errorf("second apply should leave both unchanged:\n%s", out.String())
+	}
+
+	// A new build in the manifest shows up as drift
+	m.Deployments[1].Build = uploadBuild(t, c, "preprocess")
+	out.Reset()
+	if err := status(&out, c, m, ui.OutputJSON); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	var report stackStatus
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("status JSON: %v\n%s", err, out.String())
+	}
+	syncs := map[string]string{}
+	for _, s := range report.Deployments {
+		syncs[s.Deployment] = s.Sync + "/" + s.Drift
+	}
+	if syncs["preprocess"] != "drifted/build" || syncs["generate"] != "in sync/" {
+		t.Errorf("sync = %v", syncs)
+	}
+
+	out.Reset()
+	if err := destroy(strings.NewReader("y\n"), &out, c, m, false); err != nil {
+		t.Fatalf("destroy: %v\n%s", err, out.String())
+	}
+	if strings.Count(out.String(), resultDeleted) != 2 {
+		t.Errorf("destroy output:\n%s", out.String())
+	}
+	if d, _ := c.orchestrator.GetDeployment("generate"); d != nil {
+		t.Error("generate should be deleted")
+	}
+
+	out.Reset()
+	if err := status(&out, c, m, ui.OutputDefault); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !strings.Contains(out.String(), "0 of 2 deployments in sync") || strings.Count(out.String(), syncMissing) != 2 {
+		t.Errorf("status after destroy:\n%s", out.String())
+	}
+}
+
+func TestApplyStopsAtFailure(t *testing.T) {
+	c := newMockClients(t)
+	m := &Manifest{Name: "pipeline", Deployments: []*Member{
+		{ID: "preprocess", Build: "no-such-build"},
+		{ID: "generate", Build: uploadBuild(t, c, "generate"), DependsOn: []string{"preprocess"}},
+	}}
+
+	var out bytes.Buffer
+	err := apply(&out, c, m)
+	if err == nil || !strings.Contains(err.Error(), "failed to apply preprocess") {
+		t.Fatalf("apply error = %v", err)
+	}
+	if !strings.Contains(out.String(), resultNotApplied) {
+		t.Errorf("generate should not be applied:\n%s", out.String())
+	}
+	if d, _ := c.orchestrator.GetDeployment("generate"); d != nil {
+		t.Error("generate should not be deployed")
+	}
+}
+
+func TestDestroyAborted(t *testing.T) {
+	c := newMockClients(t)
+	m := &Manifest{Name: "pipeline", Deployments: []*Member{{ID: "preprocess", Build: uploadBuild(t, c, "preprocess")}}}
+	if err := apply(&bytes.Buffer{}, c, m); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := destroy(strings.NewReader("n\n"), &out, c, m, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Aborted.") {
+		t.Errorf("output:\n%s", out.String())
+	}
+	if d, _ := c.orchestrator.GetDeployment("preprocess"); d == nil {
+		t.Error("preprocess should still exist")
+	}
+}
@@ -0,0 +1,190 @@
+// Package storage manages files in cozy-hub's file store.
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// TarballPrefix is where build source tarballs are uploaded, as
+// builds/<name>/<timestamp>.tar.gz.
+const TarballPrefix = "builds/"
+
+// DefaultKeepDays is how long tarballs are kept by default.
+const DefaultKeepDays = 30
+
+// PruneOptions contains the options for pruning tarballs.
+type PruneOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	KeepDays  int  // Tarballs uploaded more recently are kept
+	DryRun    bool // List what would be deleted without deleting it
+}
+
+// Prune deletes build tarballs older than KeepDays from the file store. The
+// newest tarball of each deployment, tarballs of builds that haven't
+// finished, and those rebuild policies rebuild from are always kept.
+func Prune(opts PruneOptions) (err error) {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	if !opts.DryRun {
+		recorder := history.Start(opts.Profile, "storage prune")
+		defer func() { recorder.Finish(nil, err) }()
+	}
+
+	return prune(os.Stdout, client, opts, time.Now())
+}
+
+func prune(w io.Writer, client api.BuilderAPI, opts PruneOptions, now time.Time) error {
+	if opts.KeepDays < 0 {
+		return fmt.Errorf("--keep-days must not be negative")
+	}
+
+	files, err := client.ListFiles(TarballPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list tarballs: %w", err)
+	}
+
+	// Group tarballs by build name, oldest first
+	byName := map[string][]api.StoredFile{}
+	for _, f := range files {
+		if strings.HasSuffix(f.Path, ".tar.gz") {
+			name := path.Base(path.Dir(f.Path))
+			byName[name] = append(byName[name], f)
+		}
+	}
+
+	cutoff := now.AddDate(0, 0, -opts.KeepDays)
+	var old []api.StoredFile
+	keep := map[string]bool{}
+	for _, tarballs := range byName {
+		// Paths end in the upload time, which breaks ties within a second
+		slices.SortFunc(tarballs, func(a, b api.StoredFile) int {
+			return cmp.Or(strings.Compare(a.CreatedAt, b.CreatedAt), strings.Compare(a.Path, b.Path))
+		})
+		keep[tarballs[len(tarballs)-1].Path] = true
+		for _, f := range tarballs {
+			if created, err := time.Parse(time.RFC3339, f.CreatedAt); err == nil && created.Before(cutoff) {
+				old = append(old, f)
+			}
+		}
+	}
+	if len(old) == 0 {
+		fmt.Fprintf(w, "No tarballs older than %d %s.\n", opts.KeepDays, plural(opts.KeepDays, "day", "days"))
+		return nil
+	}
+
+	if err := markInUse(client, old, keep); err != nil {
+		return err
+	}
+
+	var prunable []api.StoredFile
+	var size int64
+	for _, f := range old {
+		if !keep[f.Path] {
+			prunable = append(prunable, f)
+			size += f.Size
+		}
+	}
+	slices.SortFunc(prunable, func(a, b api.StoredFile) int { return strings.Compare(a.Path, b.Path) })
+
+	if kept := len(old) - len(prunable); kept > 0 {
+		fmt.Fprintf(w, "Keeping %d older %s still in use or the latest of a deployment.\n", kept, plural(kept, "tarball", "tarballs"))
+	}
+	if len(prunable) == 0 {
+		fmt.Fprintln(w, "Nothing to prune.")
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"PATH", "SIZE", "AGE"}}
+	for _, f := range prunable {
+		age := "-"
+		if created, err := time.Parse(time.RFC3339, f.CreatedAt); err == nil {
+			age = fmt.Sprintf("%dd", int(now.Sub(created).Hours()/24))
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: f.Path, Cells: []string{f.Path, ui.FormatBytes(f.Size), age}})
+	}
+	if err := table.Write(w); err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		fmt.Fprintf(w, "\nWould delete %d %s (%s).\n", len(prunable), plural(len(prunable), "tarball", "tarballs"), ui.FormatBytes(size))
+		return nil
+	}
+
+	var failed []string
+	deleted, freed := 0, int64(0)
+	for _, f := range prunable {
+		if err := client.DeleteFile(f.Path); err != nil {
+			fmt.Fprintf(w, "Warning: failed to delete %s: %v\n", f.Path, err)
+			failed = append(failed, f.Path)
+			continue
+		}
+		deleted++
+		freed += f.Size
+	}
+	fmt.Fprintf(w, "\nDeleted %d %s (%s).\n", deleted, plural(deleted, "tarball", "tarballs"), ui.FormatBytes(freed))
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete %d %s", len(failed), plural(len(failed), "tarball", "tarballs"))
+	}
+	return nil
+}
+
+// markInUse adds to keep the tarballs of unfinished builds and of the builds
+// rebuild policies rebuild from, looking only at the deployments of old
+// tarballs.
+func markInUse(client api.BuilderAPI, old []api.StoredFile, keep map[string]bool) error {
+	policies, err := client.ListRebuildPolicies()
+	if err != nil {
+		return fmt.Errorf("failed to list rebuild policies: %w", err)
+	}
+	sources := map[string]bool{}
+	for _, p := range policies {
+		sources[p.SourceBuildID] = true
+	}
+
+	seen := map[string]bool{}
+	for _, f := range old {
+		name := path.Base(path.Dir(f.Path))
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		builds, err := client.ListBuilds(name, 0)
+		if err != nil {
+			return fmt.Errorf("failed to list builds of %s: %w", name, err)
+		}
+		for _, b := range builds {
+			switch {
+			case b.TarballPath == "":
+			case sources[b.ID], b.Status == "pending", b.Status == "queued", b.Status == "running":
+				keep[b.TarballPath] = true
+			}
+		}
+	}
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
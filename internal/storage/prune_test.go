@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestPrune(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	var app []string
+	for range 3 {
+		upload, err := client.UploadBuild(strings.NewReader("tarball"), "app", api.BuildOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		app = append(app, upload.BuildID)
+	}
+	if _, err := client.UploadBuild(strings.NewReader("tarball"), "other", api.BuildOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first app build is deployed and rebuilt from, so its tarball stays
+	if _, err := client.DeployBuild(app[0], &api.DeployBuildRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SetRebuildPolicy("app", api.RebuildWeekly); err != nil {
+		t.Fatal(err)
+	}
+	tarballs := map[string]string{}
+	builds, err := client.ListBuilds("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range builds {
+		tarballs[b.ID] = b.TarballPath
+	}
+
+	// Nothing is old enough yet
+	var out bytes.Buffer
+	if err := prune(&out, client, PruneOptions{KeepDays: DefaultKeepDays}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "No tarballs older than 30 days") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	later := time.Now().AddDate(0, 0, 45)
+	out.Reset()
+	if err := prune(&out, client, PruneOptions{KeepDays: DefaultKeepDays, DryRun: true}, later); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), tarballs[app[1]]) || !strings.Contains(out.String(), "Would delete 1 tarball") {
+		t.Errorf("dry run should list only the middle app tarball:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Keeping 3 older tarballs") {
+		t.Errorf("dry run should report the kept tarballs:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := prune(&out, client, PruneOptions{KeepDays: DefaultKeepDays}, later); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Deleted 1 tarball") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	files, err := client.ListFiles(TarballPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var remaining []string
+	for _, f := range files {
+		remaining = append(remaining, f.Path)
+	}
+	if len(remaining) != 3 || strings.Contains(strings.Join(remaining, " "), tarballs[app[1]]) {
+		t.Errorf("remaining tarballs = %v", remaining)
+	}
+}
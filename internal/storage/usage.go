@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// sharedLabel names files not tied to a deployment, such as models.
+const sharedLabel = "(shared)"
+
+// UsageOptions contains the options for the storage usage report.
+type UsageOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Output    ui.Output
+}
+
+// Usage prints the file store consumption of each deployment by category.
+func Usage(opts UsageOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return usage(os.Stdout, client, opts)
+}
+
+func usage(w io.Writer, client api.BuilderAPI, opts UsageOptions) error {
+	report, err := client.GetStorageUsage()
+	if err != nil {
+		return fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		if report.Entries == nil {
+			report.Entries = []api.StorageUsageEntry{}
+		}
+		return ui.WriteStructured(w, opts.Output, report)
+	}
+	if len(report.Entries) == 0 {
+		fmt.Fprintln(w, "The file store is empty.")
+		return nil
+	}
+
+	// One row per deployment, largest first
+	type row struct {
+		deployment string
+		bytes      map[string]int64
+		total      int64
+	}
+	rows := map[string]*row{}
+	categoryTotals := map[string]int64{}
+	for _, e := range report.Entries {
+		r, ok := rows[e.DeploymentID]
+		if !ok {
+			r = &row{deployment: e.DeploymentID, bytes: map[string]int64{}}
+			rows[e.DeploymentID] = r
+		}
+		r.bytes[e.Category] += e.Bytes
+		r.total += e.Bytes
+		categoryTotals[e.Category] += e.Bytes
+	}
+	sorted := make([]*row, 0, len(rows))
+	for _, r := range rows {
+		sorted = append(sorted, r)
+	}
+	slices.SortFunc(sorted, func(a, b *row) int {
+		return cmp.Or(cmp.Compare(b.total, a.total), strings.Compare(a.deployment, b.deployment))
+	})
+
+	table := &ui.Table{Columns: []string{"DEPLOYMENT", "TARBALLS", "BUILD LOGS", "MODELS", "ARTIFACTS", "TOTAL"}}
+	for _, r := range sorted {
+		name := r.deployment
+		if name == "" {
+			name = sharedLabel
+		}
+		cells := []string{name}
+		for _, category := range api.StorageCategories {
+			cells = append(cells, formatSize(r.bytes[category]))
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: name, Cells: append(cells, ui.FormatBytes(r.total))})
+	}
+	totals := []string{"TOTAL"}
+	for _, category := range api.StorageCategories {
+		totals = append(totals, formatSize(categoryTotals[category]))
+	}
+	table.Rows = append(table.Rows, ui.Row{Key: "TOTAL", Cells: append(totals, ui.FormatBytes(report.TotalBytes))})
+	if err := table.Write(w); err != nil {
+		return err
+	}
+
+	if categoryTotals[api.StorageTarballs] > 0 {
+		fmt.Fprintln(w, "\nOld build tarballs can be deleted with 'cozyctl storage prune'.")
+	}
+	return nil
+}
+
+func formatSize(n int64) string {
+	if n == 0 {
+		return "-"
+	}
+	return ui.FormatBytes(n)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestUsage(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	var out bytes.Buffer
+	if err := usage(&out, client, UsageOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "empty") {
+		t.Errorf("unexpected output for an empty store:\n%s", out.String())
+	}
+
+	upload, err := client.UploadBuild(strings.NewReader("tarball"), "app", api.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DeployBuild(upload.BuildID, &api.DeployBuildRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.NewClient(ts.URL, "token", nil).Invoke("app", "generate", []byte(`{"num_images": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.UploadFile("models/my-lora/weights.safetensors", strings.NewReader("weights"), "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := usage(&out, client, UsageOptions{Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var report api.StorageUsage
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]api.StorageUsageEntry{}
+	var sum int64
+	for _, e := range report.Entries {
+		got[e.DeploymentID+"/"+e.Category] = e
+		sum += e.Bytes
+	}
+	if e := got["app/"+api.StorageTarballs]; e.Files != 1 || e.Bytes != int64(len("tarball")) {
+		t.Errorf("tarballs = %+v", e)
+	}
+	if e := got["app/"+api.StorageBuildLogs]; e.Files != 1 {
+		t.Errorf("build logs = %+v", e)
+	}
+	if e := got["app/"+api.StorageArtifacts]; e.Files != 3 {
+		t.Errorf("artifacts = %+v, want output.json and 2 images", e)
+	}
+	if e := got["/"+api.StorageModels]; e.Files != 1 || e.Bytes != int64(len("weights")) {
+		t.Errorf("models = %+v", e)
+	}
+	if report.TotalBytes != sum {
+		t.Errorf("total = %d, want %d", report.TotalBytes, sum)
+	}
+
+	out.Reset()
+	if err := usage(&out, client, UsageOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"app", sharedLabel, "TOTAL", "storage prune"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package templates
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/upgradeconfig"
+)
+
+// DefaultStarter is the starter 'cozyctl init' uses without --template.
+const DefaultStarter = "basic"
+
+// starters are the templates built into cozyctl, one directory each, laid
+// out like a git repository of templates. cozyignore is written to every
+// project as .cozyignore.
+//
+//go:embed starters
+var starters embed.FS
+
+// builtinSource serves the starters, so 'cozyctl init' works offline.
+type builtinSource struct{}
+
+func (builtinSource) List() ([]api.ProjectTemplate, error) {
+	entries, err := starters.ReadDir("starters")
+	if err != nil {
+		return nil, err
+	}
+	templates := []api.ProjectTemplate{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		t := api.ProjectTemplate{Name: e.Name()}
+		data, err := starters.ReadFile(path.Join("starters", e.Name(), ManifestFile))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := toml.Decode(string(data), &t); err != nil {
+			return nil, fmt.Errorf("invalid %s in starter %s: %w", ManifestFile, e.Name(), err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+func (s builtinSource) Fetch(name, dir string) (*api.ProjectTemplate, error) {
+	templates, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var found *api.ProjectTemplate
+	var names []string
+	for i := range templates {
+		names = append(names, templates[i].Name)
+		if templates[i].Name == name {
+			found = &templates[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("unknown template '%s' (must be one of %s)", name, strings.Join(names, ", "))
+	}
+
+	ignore, err := starters.ReadFile("starters/cozyignore")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, build.IgnoreFilePath), ignore, 0644); err != nil {
+		return nil, err
+	}
+
+	root := path.Join("starters", name)
+	err = fs.WalkDir(starters, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+		if rel == ManifestFile {
+			return nil
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := starters.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+	return found, err
+}
+
+func (builtinSource) Close() error { return nil }
+
+// InitOptions contains the options for starting a project with 'cozyctl init'.
+type InitOptions struct {
+	Dir      string // Created if it doesn't exist; may already hold other files
+	Template string // One of the starters; DefaultStarter if empty
+	Set      map[string]string
+}
+
+// Init writes a starter project into opts.Dir. Unlike New, the directory
+// may already exist, e.g. a fresh git checkout, but none of the starter's
+// files may.
+func Init(opts InitOptions) error {
+	return initProject(os.Stdout, opts)
+}
+
+func initProject(w io.Writer, opts InitOptions) error {
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if opts.Template == "" {
+		opts.Template = DefaultStarter
+	}
+
+	staging, err := os.MkdirTemp("", "cozyctl-init-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	t, err := builtinSource{}.Fetch(opts.Template, staging)
+	if err != nil {
+		return err
+	}
+
+	abs, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return err
+	}
+	vars, err := resolveVariables(t, filepath.Base(abs), opts.Set)
+	if err != nil {
+		return err
+	}
+	vars["module_name"] = moduleName(vars[VarProjectName])
+	vars["python"] = build.DefaultPython
+	vars["cuda"] = build.DefaultCuda
+	vars["pytorch"] = strings.TrimPrefix(build.DefaultTorchTag, "torch")
+	vars["min_workers"] = strconv.Itoa(upgradeconfig.DefaultMinWorkers)
+	vars["max_workers"] = strconv.Itoa(upgradeconfig.DefaultMaxWorkers)
+
+	// Check every file first, so a conflict leaves the directory untouched
+	var existing []string
+	err = filepath.WalkDir(staging, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(staging, p)
+		if err != nil {
+			return err
+		}
+		rendered := substitute(rel, vars)
+		if _, err := os.Stat(filepath.Join(opts.Dir, rendered)); err == nil {
+			existing = append(existing, filepath.ToSlash(rendered))
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		hint := ""
+		if slices.Contains(existing, build.PyProjectTomlPath) {
+			hint = " ('cozyctl upgrade-config' updates an existing project)"
+		}
+		verb := "exists"
+		if len(existing) > 1 {
+			verb = "exist"
+		}
+		return fmt.Errorf("%s already %s in %s%s", strings.Join(existing, ", "), verb, opts.Dir, hint)
+	}
+
+	files, err := render(staging, opts.Dir, vars)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Created %s in %s from the %s starter (%d files)\n", vars[VarProjectName], opts.Dir, t.Name, files)
+	buildCmd := "cozyctl build"
+	if opts.Dir != "." {
+		buildCmd += " --dir " + opts.Dir
+	}
+	fmt.Fprintf(w, "\nNext steps:\n  %s\n  cozyctl deploy <build-id>\n", buildCmd)
+	return nil
+}
+
+// moduleName turns a project name into a Python package name: lowercase,
+// with anything but letters, digits and underscores replaced by "_".
+func moduleName(project string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, project)
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,83 @@
+package templates
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+)
+
+func TestInitStarters(t *testing.T) {
+	starters, err := builtinSource{}.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, starter := range starters {
+		t.Run(starter.Name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "My-Worker")
+			if err := initProject(&bytes.Buffer{}, InitOptions{Dir: dir, Template: starter.Name}); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := build.GetToolsCozyConfig(filepath.Join(dir, "pyproject.toml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.DeploymentID != "My-Worker" || cfg.Python != build.DefaultPython || cfg.Workers == nil {
+				t.Errorf("[tool.cozy] not filled in: %+v", cfg)
+			}
+			detected, err := build.DetectWorkerFunctions(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(detected) == 0 || len(detected) != len(cfg.Functions) {
+				t.Errorf("detected %v, configured %v", detected, cfg.Functions)
+			}
+			for _, f := range detected {
+				if _, ok := cfg.Functions[f.Name]; !ok {
+					t.Errorf("function %s not in [tool.cozy.functions]", f.Name)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dir, "src", "my_worker", "worker.py")); err != nil {
+				t.Error(err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, ".cozyignore")); err != nil {
+				t.Error(err)
+			}
+			if _, err := build.ArchiveManifest(dir); err != nil {
+				t.Errorf(".cozyignore rejected: %v", err)
+			}
+		})
+	}
+}
+
+func TestInitExistingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# checkout\n"), 0644)
+
+	var out bytes.Buffer
+	if err := initProject(&out, InitOptions{Dir: dir, Template: "llm", Set: map[string]string{"deployment_id": "chat"}}); err != nil {
+		t.Fatal(err)
+	}
+	pyproject, _ := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if !strings.Contains(string(pyproject), `deployment-id = "chat"`) || !strings.Contains(string(pyproject), `llm = "hf:Qwen/Qwen2.5-7B-Instruct"`) {
+		t.Errorf("variables not substituted:\n%s", pyproject)
+	}
+
+	// A second init would overwrite the project: nothing is written
+	os.Remove(filepath.Join(dir, ".cozyignore"))
+	err := initProject(&out, InitOptions{Dir: dir})
+	if err == nil || !strings.Contains(err.Error(), "pyproject.toml") || !strings.Contains(err.Error(), "upgrade-config") {
+		t.Errorf("got %v, want refusal naming pyproject.toml", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".cozyignore")); err == nil {
+		t.Error("a refused init wrote .cozyignore")
+	}
+
+	if err := initProject(&out, InitOptions{Dir: t.TempDir(), Template: "nope"}); err == nil || !strings.Contains(err.Error(), "basic, cpu-api, llm, sdxl") {
+		t.Errorf("got %v, want the starters listed", err)
+	}
+}
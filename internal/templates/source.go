@@ -0,0 +1,237 @@
+package templates
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cozy-creator/cozyctl/internal/api"
+)
+
+// ManifestFile describes a template in a git repository of templates: each
+// top-level directory holding one is a template.
+const ManifestFile = "template.toml"
+
+// Source is where templates come from: cozy-hub's gallery or a git repository.
+type Source interface {
+	List() ([]api.ProjectTemplate, error)
+	// Fetch writes a template's files, with variables not yet substituted, to dir.
+	Fetch(name, dir string) (*api.ProjectTemplate, error)
+	Close() error
+}
+
+// hubSource serves templates from cozy-hub's gallery.
+type hubSource struct {
+	client api.BuilderAPI
+}
+
+func (s *hubSource) List() ([]api.ProjectTemplate, error) {
+	templates, err := s.client.ListTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (s *hubSource) Fetch(name, dir string) (*api.ProjectTemplate, error) {
+	templates, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(templates, func(t api.ProjectTemplate) bool { return t.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("template '%s' not found (see 'cozyctl templates list')", name)
+	}
+
+	archive, err := s.client.DownloadTemplate(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download template: %w", err)
+	}
+	defer archive.Close()
+
+	if err := extract(archive, dir); err != nil {
+		return nil, fmt.Errorf("failed to extract template: %w", err)
+	}
+	return &templates[i], nil
+}
+
+func (s *hubSource) Close() error { return nil }
+
+// extract unpacks a gzipped tarball into dir, refusing entries that would
+// land outside it.
+func extract(r io.Reader, dir string) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("archive entry %q is outside the template", hdr.Name)
+		}
+		path := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// gitSource serves templates from a shallow clone of a git repository.
+type gitSource struct {
+	repo  string
+	clone string
+}
+
+// newGitSource clones repo, given as URL[#ref], into a temporary directory.
+func newGitSource(repo string) (*gitSource, error) {
+	url, ref, _ := strings.Cut(repo, "#")
+
+	clone, err := os.MkdirTemp("", "cozyctl-templates-*")
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, url, clone)
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		os.RemoveAll(clone)
+		return nil, fmt.Errorf("failed to clone %s: %s", repo, strings.TrimSpace(string(output)))
+	}
+	return &gitSource{repo: repo, clone: clone}, nil
+}
+
+func (s *gitSource) List() ([]api.ProjectTemplate, error) {
+	found, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+	templates := []api.ProjectTemplate{}
+	for _, f := range found {
+		templates = append(templates, f.ProjectTemplate)
+	}
+	return templates, nil
+}
+
+func (s *gitSource) Fetch(name, dir string) (*api.ProjectTemplate, error) {
+	found, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range found {
+		if f.Name == name {
+			return &f.ProjectTemplate, copyTree(filepath.Join(s.clone, f.dir), dir)
+		}
+	}
+	return nil, fmt.Errorf("template '%s' not found in %s", name, s.repo)
+}
+
+// repoTemplate is a template in the clone and the directory it's in.
+type repoTemplate struct {
+	api.ProjectTemplate
+	dir string
+}
+
+// scan reads the manifest of each top-level directory that has one. A
+// template is named after its directory unless its manifest sets a name.
+func (s *gitSource) scan() ([]repoTemplate, error) {
+	entries, err := os.ReadDir(s.clone)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []repoTemplate
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		t := repoTemplate{dir: e.Name()}
+		if _, err := toml.DecodeFile(filepath.Join(s.clone, e.Name(), ManifestFile), &t.ProjectTemplate); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("invalid %s in %s: %w", ManifestFile, e.Name(), err)
+		}
+		if t.Name == "" {
+			t.Name = e.Name()
+		}
+		found = append(found, t)
+	}
+	return found, nil
+}
+
+func (s *gitSource) Close() error {
+	return os.RemoveAll(s.clone)
+}
+
+// copyTree copies a template directory's files, except its manifest, to dst.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == ManifestFile {
+			return nil
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,7 @@
+from gen_worker import worker_function, ActionContext
+
+
+@worker_function()
+def hello(ctx: ActionContext, payload: dict) -> dict:
+    """Greet payload["name"]."""
+    return {"message": f"Hello, {payload.get('name', 'world')}!"}
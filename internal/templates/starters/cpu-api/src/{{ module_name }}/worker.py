@@ -0,0 +1,14 @@
+from gen_worker import worker_function, ActionContext
+
+
+@worker_function()
+def health(ctx: ActionContext, payload: dict) -> dict:
+    """Report that the worker is up."""
+    return {"status": "ok"}
+
+
+@worker_function()
+def word_count(ctx: ActionContext, payload: dict) -> dict:
+    """Count the words in payload["text"]."""
+    words = payload["text"].split()
+    return {"words": len(words), "unique": len({w.lower() for w in words})}
@@ -0,0 +1,15 @@
+from typing import Annotated
+
+from gen_worker import worker_function, ActionContext, ModelRef, Src
+from transformers import Pipeline
+
+
+@worker_function()
+def chat(
+    ctx: ActionContext,
+    payload: dict,
+    generator: Annotated[Pipeline, ModelRef(Src.DEPLOYMENT, "llm")],
+) -> dict:
+    """Answer payload["messages"], a list of {"role", "content"} dicts."""
+    output = generator(payload["messages"], max_new_tokens=payload.get("max_tokens", 512))
+    return {"message": output[0]["generated_text"][-1]}
@@ -0,0 +1,22 @@
+import io
+from typing import Annotated
+
+from diffusers import AutoPipelineForText2Image
+from gen_worker import worker_function, ActionContext, ModelRef, Src
+
+
+@worker_function()
+def generate(
+    ctx: ActionContext,
+    payload: dict,
+    pipeline: Annotated[AutoPipelineForText2Image, ModelRef(Src.DEPLOYMENT, "sdxl")],
+) -> bytes:
+    """Generate a PNG for payload["prompt"]."""
+    image = pipeline(
+        prompt=payload["prompt"],
+        num_inference_steps=payload.get("steps", 1),
+        guidance_scale=0.0,
+    ).images[0]
+    buf = io.BytesIO()
+    image.save(buf, format="PNG")
+    return buf.getvalue()
@@ -0,0 +1,249 @@
+// Package templates lists the curated starter templates in cozy-hub's
+// gallery, or in a git repository of templates, and instantiates them as
+// new projects.
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Variables every template can use without declaring them: the project's
+// name, which defaults to the name of the directory it is created in, and
+// the deployment to deploy it to, which defaults to the project's name.
+const (
+	VarProjectName  = "project_name"
+	VarDeploymentID = "deployment_id"
+)
+
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// ListOptions contains the options for listing templates.
+type ListOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Repo      string // Git repository of templates, as URL[#ref], instead of cozy-hub
+	Output    ui.Output
+}
+
+// NewOptions contains the options for creating a project from a template.
+type NewOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	Repo      string
+	Template  string
+	Dir       string
+	Set       map[string]string // Variable values
+}
+
+// List prints the available templates.
+func List(opts ListOptions) error {
+	source, err := openSource(opts.Profile, opts.Transport, opts.Repo)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	return list(os.Stdout, source, opts)
+}
+
+func list(w io.Writer, source Source, opts ListOptions) error {
+	templates, err := source.List()
+	if err != nil {
+		return err
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, templates)
+	}
+
+	if len(templates) == 0 {
+		fmt.Fprintln(w, "No templates found.")
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"NAME", "DESCRIPTION", "TAGS"}}
+	for _, t := range templates {
+		table.Rows = append(table.Rows, ui.Row{Key: t.Name, Cells: []string{t.Name, orDash(t.Description), orDash(strings.Join(t.Tags, ", "))}})
+	}
+	return table.Write(w)
+}
+
+// New creates a project from a template.
+func New(opts NewOptions) error {
+	source, err := openSource(opts.Profile, opts.Transport, opts.Repo)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	return newProject(os.Stdout, source, opts)
+}
+
+func newProject(w io.Writer, source Source, opts NewOptions) error {
+	dir := opts.Dir
+	if dir == "" {
+		dir = opts.Template
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s already exists and is not empty", dir)
+	}
+
+	staging, err := os.MkdirTemp("", "cozyctl-template-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	t, err := source.Fetch(opts.Template, staging)
+	if err != nil {
+		return err
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	vars, err := resolveVariables(t, filepath.Base(abs), opts.Set)
+	if err != nil {
+		return err
+	}
+
+	files, err := render(staging, dir, vars)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Created %s from template %s (%d files)\n", dir, t.Name, files)
+	fmt.Fprintf(w, "\nNext steps:\n  cozyctl build --dir %s\n  cozyctl deploy <build-id>\n", dir)
+	return nil
+}
+
+// resolveVariables works out each variable's value: from --set, else the
+// template's default, else the built-in default. Setting an undeclared
+// variable or leaving a declared one without a value is an error.
+func resolveVariables(t *api.ProjectTemplate, dirName string, set map[string]string) (map[string]string, error) {
+	vars := map[string]string{VarProjectName: dirName}
+	declared := []string{VarProjectName, VarDeploymentID}
+	for _, v := range t.Variables {
+		declared = append(declared, v.Name)
+		if v.Default != "" {
+			vars[v.Name] = v.Default
+		}
+	}
+
+	for name, value := range set {
+		if !slices.Contains(declared, name) {
+			return nil, fmt.Errorf("template %s has no variable '%s' (variables: %s)", t.Name, name, strings.Join(slices.Compact(slices.Sorted(slices.Values(declared))), ", "))
+		}
+		vars[name] = value
+	}
+	if _, ok := vars[VarDeploymentID]; !ok {
+		vars[VarDeploymentID] = vars[VarProjectName]
+	}
+
+	var missing []string
+	for _, v := range t.Variables {
+		if vars[v.Name] == "" {
+			missing = append(missing, v.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("template %s needs a value for %s (set with --set %s=...)", t.Name, strings.Join(missing, ", "), missing[0])
+	}
+	return vars, nil
+}
+
+// render copies a template's files from src to dst, substituting variables
+// in paths and in text files. Placeholders naming no variable are left as
+// they are, so templates can ship files that use the same syntax. Binary
+// files are copied verbatim.
+func render(src, dst string, vars map[string]string) (int, error) {
+	files := 0
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		rendered := substitute(rel, vars)
+		if !filepath.IsLocal(rendered) && rendered != "." {
+			return fmt.Errorf("%s is outside the project once its variables are substituted", rendered)
+		}
+		target := filepath.Join(dst, rendered)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !isBinary(data) {
+			data = []byte(substitute(string(data), vars))
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		files++
+		return nil
+	})
+	return files, err
+}
+
+// substitute replaces {{ name }} with the variable's value.
+func substitute(s string, vars map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(m string) string {
+		if value, ok := vars[variablePattern.FindStringSubmatch(m)[1]]; ok {
+			return value
+		}
+		return m
+	})
+}
+
+// isBinary reports whether data looks binary: it has a NUL byte early on.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// openSource opens the git repository of templates if one is given, or
+// else cozy-hub's gallery.
+func openSource(ref config.ProfileRef, transport http.RoundTripper, repo string) (Source, error) {
+	if repo != "" {
+		return newGitSource(repo)
+	}
+	client, err := api.NewProfileBuilderClient(ref, transport)
+	if err != nil {
+		return nil, err
+	}
+	return &hubSource{client: client}, nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,177 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func newHubSource(t *testing.T) Source {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	return &hubSource{client: api.NewBuilderClient(ts.URL, "token", nil)}
+}
+
+func TestListHub(t *testing.T) {
+	var out bytes.Buffer
+	if err := list(&out, newHubSource(t), ListOptions{Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var templates []api.ProjectTemplate
+	if err := json.Unmarshal(out.Bytes(), &templates); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tmpl := range templates {
+		names = append(names, tmpl.Name)
+	}
+	if got := strings.Join(names, ","); got != "sdxl-worker,whisper-transcription,llm-chat" {
+		t.Errorf("got templates %s", got)
+	}
+}
+
+func TestNewFromHub(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my-worker")
+	var out bytes.Buffer
+	err := newProject(&out, newHubSource(t), NewOptions{
+		Template: "whisper-transcription",
+		Dir:      dir,
+		Set:      map[string]string{"model": "openai/whisper-small"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pyproject, err := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(pyproject), `name = "my-worker"`) || !strings.Contains(string(pyproject), `deployment-id = "my-worker"`) {
+		t.Errorf("variables not substituted in pyproject.toml:\n%s", pyproject)
+	}
+	worker, err := os.ReadFile(filepath.Join(dir, "src", "my-worker", "worker.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(worker), `MODEL = "openai/whisper-small"`) {
+		t.Errorf("model not substituted in worker.py:\n%s", worker)
+	}
+
+	err = newProject(&out, newHubSource(t), NewOptions{Template: "whisper-transcription", Dir: dir})
+	if err == nil || !strings.Contains(err.Error(), "not empty") {
+		t.Errorf("got %v, want refusal to write into a non-empty directory", err)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	source := newHubSource(t)
+	tests := []struct {
+		name string
+		opts NewOptions
+		want string
+	}{
+		{"unknown template", NewOptions{Template: "nope"}, "not found"},
+		{"unknown variable", NewOptions{Template: "llm-chat", Set: map[string]string{"modle": "x"}}, "no variable 'modle'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Dir = filepath.Join(t.TempDir(), "project")
+			err := newProject(&bytes.Buffer{}, source, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveVariablesRequired(t *testing.T) {
+	tmpl := &api.ProjectTemplate{Name: "t", Variables: []api.TemplateVariable{{Name: "api_key"}}}
+	if _, err := resolveVariables(tmpl, "dir", nil); err == nil || !strings.Contains(err.Error(), "--set api_key=") {
+		t.Errorf("got %v, want missing api_key", err)
+	}
+	vars, err := resolveVariables(tmpl, "dir", map[string]string{"api_key": "k", VarDeploymentID: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars[VarProjectName] != "dir" || vars[VarDeploymentID] != "prod" || vars["api_key"] != "k" {
+		t.Errorf("got %v", vars)
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	vars := map[string]string{"name": "cat"}
+	got := substitute("{{name}} {{ name }} {{ other }} {{ ctx.value }}", vars)
+	if want := "cat cat {{ other }} {{ ctx.value }}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewFromGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	files := map[string]string{
+		"echo/template.toml":         "description = \"Echo worker\"\n\n[[variables]]\nname = \"greeting\"\ndefault = \"hello\"\n",
+		"echo/{{ project_name }}.py": "GREETING = \"{{ greeting }}\"\n",
+		"echo/weights.bin":           "\x00{{ greeting }}",
+		"not-a-template/README.md":   "ignored\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	run("add", ".")
+	run("commit", "-q", "-m", "templates")
+
+	source, err := newGitSource(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	templates, err := source.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 || templates[0].Name != "echo" || templates[0].Description != "Echo worker" {
+		t.Fatalf("got templates %+v", templates)
+	}
+
+	dir := filepath.Join(t.TempDir(), "greeter")
+	if err := newProject(&bytes.Buffer{}, source, NewOptions{Template: "echo", Dir: dir, Set: map[string]string{"greeting": "hi"}}); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "greeter.py")); err != nil || string(data) != "GREETING = \"hi\"\n" {
+		t.Errorf("got %q, %v", data, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "weights.bin")); string(data) != "\x00{{ greeting }}" {
+		t.Errorf("binary file was modified: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+		t.Errorf("%s should not be copied into the project", ManifestFile)
+	}
+}
@@ -0,0 +1,144 @@
+package testrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+)
+
+// Options contains the options for running project tests in the build image.
+type Options struct {
+	ProjectPath string
+	Rebuild     bool     // Always build a fresh image instead of reusing the last local build
+	GPUs        string   // Passed to docker run --gpus (e.g. "all")
+	PytestArgs  []string // Extra arguments for pytest
+}
+
+// Run builds the project image (or reuses the last local build) and runs the
+// project's pytest suite inside it with the project directory mounted.
+func Run(opts Options) error {
+	absPath, err := filepath.Abs(opts.ProjectPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return fmt.Errorf("cannot access path: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", absPath)
+	}
+
+	pyprojectPath := filepath.Join(absPath, build.PyProjectTomlPath)
+	if _, err := os.Stat(pyprojectPath); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("pyproject.toml not found in %s", absPath)
+	}
+
+	cozyConfig, err := build.GetToolsCozyConfig(pyprojectPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse pyproject.toml: %w", err)
+	}
+
+	ctx := context.Background()
+	builder := build.NewDockerBuilder()
+
+	// Reuse the last local build when it is still present
+	imageTag := ""
+	if !opts.Rebuild {
+		if last := build.LastLocalBuild(absPath); last != "" && builder.ImageExists(ctx, last) {
+			imageTag = last
+			fmt.Printf("Reusing last local build: %s\n", imageTag)
+		}
+	}
+
+	if imageTag == "" {
+		result, err := build.BuildLocalImage(ctx, os.Stdout, absPath, cozyConfig, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Build completed in %v\n", result.Duration)
+		imageTag = result.ImageTag
+	}
+
+	// Mount the project where the image copied it, so tests see local edits
+	sourceDir := absPath
+	if cozyConfig.Root != "" {
+		sourceDir = filepath.Join(absPath, cozyConfig.Root)
+	}
+	mounts, err := sourceMounts(sourceDir)
+	if err != nil {
+		return err
+	}
+
+	// pytest is usually a dev dependency, so install it on the fly if missing
+	pytestCmd := "python -m pytest"
+	if len(opts.PytestArgs) > 0 {
+		pytestCmd += " " + shellJoin(opts.PytestArgs)
+	}
+	script := "python -m pytest --version >/dev/null 2>&1 || pip install --quiet pytest; " + pytestCmd
+
+	fmt.Printf("\nRunning tests in %s...\n\n", imageTag)
+	result := builder.Run(ctx, build.RunOptions{
+		ImageTag: imageTag,
+		Mounts:   mounts,
+		WorkDir:  imageAppDir,
+		GPUs:     opts.GPUs,
+		Command:  []string{"sh", "-c", script},
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	fmt.Println()
+	switch result.ExitCode {
+	case 0:
+		fmt.Printf("Tests passed in %v\n", result.Duration)
+		return nil
+	case 5:
+		// pytest exit code 5: no tests were collected
+		fmt.Println("No tests collected")
+		return nil
+	default:
+		return fmt.Errorf("tests failed (exit code %d) in %v", result.ExitCode, result.Duration)
+	}
+}
+
+// imageAppDir is where the Dockerfile copies the project.
+const imageAppDir = "/app"
+
+// sourceMounts mounts each entry of sourceDir over its copy in the image,
+// rather than the whole directory over /app, so the image's /app/.cozy (with
+// the manifest mapping model keys, baked in at build time) stays visible to
+// tests exactly as in the deployed image.
+func sourceMounts(sourceDir string) (map[string]string, error) {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", sourceDir, err)
+	}
+	mounts := map[string]string{}
+	for _, entry := range entries {
+		if entry.Name() == ".cozy" {
+			continue
+		}
+		mounts[filepath.Join(sourceDir, entry.Name())] = imageAppDir + "/" + entry.Name()
+	}
+	return mounts, nil
+}
+
+// shellJoin quotes arguments for use in a sh -c script.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,37 @@
+package testrun
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceMountsKeepImageManifest(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"tests", "src", ".cozy"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte("[project]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mounts, err := sourceMounts(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		filepath.Join(dir, "tests"):          "/app/tests",
+		filepath.Join(dir, "src"):            "/app/src",
+		filepath.Join(dir, "pyproject.toml"): "/app/pyproject.toml",
+	}
+	if len(mounts) != len(want) {
+		t.Fatalf("got mounts %v, want %v", mounts, want)
+	}
+	for host, container := range want {
+		if mounts[host] != container {
+			t.Errorf("%s mounted at %q, want %q", host, mounts[host], container)
+		}
+	}
+}
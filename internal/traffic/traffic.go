@@ -0,0 +1,134 @@
+// Package traffic shows and changes how a deployment's requests are split
+// between builds.
+package traffic
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ShowOptions contains the options for showing a traffic split.
+type ShowOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Output       ui.Output
+}
+
+// SetOptions contains the options for changing a traffic split.
+type SetOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Routes       []api.TrafficRoute
+}
+
+// ParseRoutes parses "build-id=percent" arguments (e.g. "abc-123=90"). The
+// percentages must add up to 100.
+func ParseRoutes(args []string) ([]api.TrafficRoute, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("at least one build-id=percent is required")
+	}
+
+	total := 0
+	seen := map[string]bool{}
+	routes := make([]api.TrafficRoute, 0, len(args))
+	for _, arg := range args {
+		buildID, value, ok := strings.Cut(arg, "=")
+		buildID = strings.TrimSpace(buildID)
+		if !ok || buildID == "" {
+			return nil, fmt.Errorf("invalid route %q: expected build-id=percent", arg)
+		}
+		percent, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid route %q: percent must be a whole number from 0 to 100", arg)
+		}
+		if seen[buildID] {
+			return nil, fmt.Errorf("build %s is listed more than once", buildID)
+		}
+		seen[buildID] = true
+		total += percent
+		routes = append(routes, api.TrafficRoute{BuildID: buildID, Percent: percent})
+	}
+
+	if total != 100 {
+		return nil, fmt.Errorf("percentages add up to %d; they must add up to 100", total)
+	}
+	return routes, nil
+}
+
+// Show prints a deployment's traffic split.
+func Show(opts ShowOptions) error {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return show(os.Stdout, client, opts)
+}
+
+func show(w io.Writer, client api.BuilderAPI, opts ShowOptions) error {
+	split, err := client.GetTraffic(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get traffic split: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, split)
+	}
+	if len(split.Routes) == 0 {
+		fmt.Fprintf(w, "Deployment %s has no active build.\n", split.DeploymentID)
+		return nil
+	}
+	return splitTable(split).Write(w)
+}
+
+// Set replaces a deployment's traffic split and prints the result.
+func Set(opts SetOptions) (err error) {
+	client, err := api.NewProfileBuilderClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	recorder := history.Start(opts.Profile, "traffic set")
+	defer func() { recorder.Finish(map[string]string{"deployment_id": opts.DeploymentID}, err) }()
+
+	return set(os.Stdout, client, opts)
+}
+
+func set(w io.Writer, client api.BuilderAPI, opts SetOptions) error {
+	split, err := client.SetTraffic(opts.DeploymentID, opts.Routes)
+	if err != nil {
+		return fmt.Errorf("failed to set traffic split: %w", err)
+	}
+
+	fmt.Fprintf(w, "Updated traffic split of %s:\n\n", split.DeploymentID)
+	return splitTable(split).Write(w)
+}
+
+func splitTable(split *api.TrafficSplit) *ui.Table {
+	table := &ui.Table{Columns: []string{"BUILD", "TRAFFIC", "IMAGE"}}
+	for _, route := range split.Routes {
+		table.Rows = append(table.Rows, ui.Row{Key: route.BuildID, Cells: []string{
+			route.BuildID,
+			fmt.Sprintf("%d%%", route.Percent),
+			orDash(route.ImageTag),
+		}})
+	}
+	return table
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
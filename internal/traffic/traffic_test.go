@@ -0,0 +1,103 @@
+package traffic
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes([]string{"build-a=90", "build-b=10%"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []api.TrafficRoute{{BuildID: "build-a", Percent: 90}, {BuildID: "build-b", Percent: 10}}
+	if len(routes) != 2 || routes[0] != want[0] || routes[1] != want[1] {
+		t.Errorf("got %+v, want %+v", routes, want)
+	}
+
+	for args, wantErr := range map[string]string{
+		"build-a=90 build-b=20": "add up to 110",
+		"build-a":               "expected build-id=percent",
+		"build-a=ninety":        "whole number",
+		"build-a=101":           "whole number",
+		"build-a=50 build-a=50": "more than once",
+	} {
+		if _, err := ParseRoutes(strings.Fields(args)); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("ParseRoutes(%s): got %v, want error containing %q", args, err, wantErr)
+		}
+	}
+}
+
+func TestSetAndShow(t *testing.T) {
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+	client := api.NewBuilderClient(ts.URL, "token", nil)
+
+	var ids []string
+	for range 2 {
+		resp, err := client.UploadBuild(strings.NewReader("tarball"), "my-model", api.BuildOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, resp.BuildID)
+	}
+	if _, err := client.DeployBuild(ids[0], &api.DeployBuildRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Before a split, the active build gets everything
+	var out bytes.Buffer
+	if err := show(&out, client, ShowOptions{DeploymentID: "my-model"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), ids[0]) || !strings.Contains(out.String(), "100%") {
+		t.Errorf("show output missing the active build:\n%s", out.String())
+	}
+
+	out.Reset()
+	routes := []api.TrafficRoute{{BuildID: ids[0], Percent: 90}, {BuildID: ids[1], Percent: 10}}
+	if err := set(&out, client, SetOptions{DeploymentID: "my-model", Routes: routes}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"90%", "10%", "registry.mock/"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("set output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := show(&out, client, ShowOptions{DeploymentID: "my-model", Output: ui.OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var split api.TrafficSplit
+	if err := json.Unmarshal(out.Bytes(), &split); err != nil {
+		t.Fatal(err)
+	}
+	if len(split.Routes) != 2 || split.Routes[1].BuildID != ids[1] || split.Routes[1].Percent != 10 {
+		t.Errorf("unexpected split: %+v", split)
+	}
+
+	err := set(&bytes.Buffer{}, client, SetOptions{DeploymentID: "my-model", Routes: []api.TrafficRoute{{BuildID: "missing", Percent: 100}}})
+	if err == nil || !strings.Contains(err.Error(), "build missing not found") {
+		t.Errorf("got %v, want unknown build error", err)
+	}
+}
+
+func TestShow_UnsupportedServer(t *testing.T) {
+	srv := mockserver.New()
+	srv.Features = []string{api.FeatureRebuildPolicies}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	err := show(&bytes.Buffer{}, api.NewBuilderClient(ts.URL, "token", nil), ShowOptions{DeploymentID: "my-model"})
+	if err == nil || !strings.Contains(err.Error(), "your cozy-hub (version mock) doesn't support traffic splitting yet") {
+		t.Errorf("got %v, want an unsupported feature error", err)
+	}
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"strings"
+	"time"
+)
+
+// Event types emitted in ModeJSON.
+const (
+	EventStageStart = "stage_start"
+	EventStageEnd   = "stage_end"
+	EventPercent    = "percent"
+	EventMessage    = "message"
+	EventID         = "id"
+)
+
+// Event is a single line of --progress json output.
+type Event struct {
+	Type       string            `json:"type"`
+	Time       time.Time         `json:"time"`
+	Stage      string            `json:"stage,omitempty"`
+	Status     string            `json:"status,omitempty"` // "done" or "failed" for stage_end
+	Percent    *int              `json:"percent,omitempty"`
+	Bytes      int64             `json:"bytes,omitempty"` // Bytes transferred, for stages that move data
+	Message    string            `json:"message,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	DurationMS int64             `json:"duration_ms,omitempty"`
+	IDs        map[string]string `json:"ids,omitempty"` // All identifiers known so far
+}
+
+// jsonRenderer writes one JSON event per line. Output written through the
+// Progress is split into lines and emitted as message events.
+type jsonRenderer struct {
+	enc    *json.Encoder
+	active *Stage
+	ids    map[string]string
+	buf    bytes.Buffer
+}
+
+func newJSONRenderer(w io.Writer) *jsonRenderer {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &jsonRenderer{enc: enc, ids: map[string]string{}}
+}
+
+func (r *jsonRenderer) emit(e Event) {
+	e.Time = time.Now().UTC()
+	if len(r.ids) > 0 {
+		e.IDs = maps.Clone(r.ids)
+	}
+	r.enc.Encode(e)
+}
+
+func (r *jsonRenderer) stageStart(s *Stage) {
+	r.flush()
+	r.active = s
+	r.emit(Event{Type: EventStageStart, Stage: s.Name})
+}
+
+func (r *jsonRenderer) stageEnd(s *Stage) {
+	r.flush()
+	e := Event{Type: EventStageEnd, Stage: s.Name, Status: "done", DurationMS: s.Duration.Milliseconds()}
+	if s.Err != nil {
+		e.Status = "failed"
+		e.Error = s.Err.Error()
+	}
+	r.emit(e)
+	if r.active == s {
+		r.active = nil
+	}
+}
+
+func (r *jsonRenderer) percent(s *Stage) {
+	pct := s.Percent
+	r.emit(Event{Type: EventPercent, Stage: s.Name, Percent: &pct, Bytes: s.Bytes})
+}
+
+func (r *jsonRenderer) id(key, value string) {
+	r.ids[key] = value
+	r.emit(Event{Type: EventID, Stage: r.stageName()})
+}
+
+func (r *jsonRenderer) write(p []byte) (int, error) {
+	r.buf.Write(p)
+	for {
+		line, err := r.buf.ReadString('\n')
+		if err != nil {
+			// Keep the partial line until it is completed
+			r.buf.Reset()
+			r.buf.WriteString(line)
+			break
+		}
+		r.message(line)
+	}
+	return len(p), nil
+}
+
+func (r *jsonRenderer) close() {
+	r.flush()
+}
+
+// flush emits any buffered partial line.
+func (r *jsonRenderer) flush() {
+	if r.buf.Len() > 0 {
+		r.message(r.buf.String())
+		r.buf.Reset()
+	}
+}
+
+func (r *jsonRenderer) message(line string) {
+	line = strings.TrimRight(line, " \t\r\n")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	r.emit(Event{Type: EventMessage, Stage: r.stageName(), Message: line})
+}
+
+func (r *jsonRenderer) stageName() string {
+	if r.active == nil {
+		return ""
+	}
+	return r.active.Name
+}
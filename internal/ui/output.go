@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Output selects how a command prints its results.
+type Output string
+
+const (
+	// OutputDefault is the human-readable view.
+	OutputDefault Output = ""
+	// OutputWide is the human-readable view with every detail.
+	OutputWide Output = "wide"
+	// OutputJSON is the full result as indented JSON.
+	OutputJSON Output = "json"
+	// OutputYAML is the full result as YAML.
+	OutputYAML Output = "yaml"
+)
+
+// ParseOutput parses an --output flag value. "table" names the default
+// human-readable view.
+func ParseOutput(s string) (Output, error) {
+	switch Output(s) {
+	case OutputDefault, OutputWide, OutputJSON, OutputYAML:
+		return Output(s), nil
+	case "table":
+		return OutputDefault, nil
+	}
+	return "", fmt.Errorf("invalid output format %q (must be table, wide, json, or yaml)", s)
+}
+
+// Structured reports whether o is a machine-readable format.
+func (o Output) Structured() bool {
+	return o == OutputJSON || o == OutputYAML
+}
+
+// WriteStructured writes v to w as JSON or YAML. YAML keys and field order
+// follow the JSON encoding, so both formats describe the same document.
+func WriteStructured(w io.Writer, o Output, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if o == OutputJSON {
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	// JSON is valid YAML; decoding into a node keeps the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the flow and quoting styles a node decoded from JSON
+// carries, so it encodes as conventional block YAML.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
@@ -0,0 +1,368 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/redact"
+	"golang.org/x/term"
+)
+
+// Progress reports the named stages of a multi-stage operation
+// (e.g. Packaging → Uploading → Building → Deploying → Waiting) with timing per stage.
+// On a terminal the active stage is shown with a spinner; otherwise stages are
+// printed as plain sequential log lines.
+//
+// Progress is an io.Writer: output written through it is interleaved cleanly
+// with the spinner, so code running inside a stage should print via Printf or
+// Write rather than directly to stdout.
+type Progress struct {
+	mu       sync.Mutex
+	renderer renderer
+	current  *Stage
+	stages   []*Stage
+	ids      map[string]string
+	warnings []string
+	start    time.Time
+	closed   bool
+}
+
+// Stage is a single named step of a Progress.
+type Stage struct {
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+	Percent  int   // Completion percentage, or -1 if unknown
+	Bytes    int64 // Bytes transferred so far, for stages that move data
+
+	p    *Progress
+	done bool
+}
+
+// renderer draws progress events for a particular output mode.
+type renderer interface {
+	stageStart(s *Stage)
+	stageEnd(s *Stage)
+	percent(s *Stage)
+	id(key, value string)
+	write(p []byte) (int, error)
+	close()
+}
+
+// Mode selects how progress is rendered.
+type Mode string
+
+const (
+	// ModeAuto uses a spinner on terminals and plain log lines otherwise.
+	ModeAuto Mode = "auto"
+	// ModePlain always prints plain sequential log lines.
+	ModePlain Mode = "plain"
+	// ModeJSON emits newline-delimited JSON events for tools driving their own UI.
+	ModeJSON Mode = "json"
+)
+
+// ParseMode parses a --progress flag value. An empty string selects ModeAuto.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "", ModeAuto:
+		return ModeAuto, nil
+	case ModePlain, ModeJSON:
+		return Mode(s), nil
+	}
+	return "", fmt.Errorf("invalid progress mode %q (must be auto, plain, or json)", s)
+}
+
+// New creates a Progress writing to w, using a spinner if w is a terminal.
+func New(w io.Writer) *Progress {
+	return NewWithMode(w, ModeAuto)
+}
+
+// NewWithMode creates a Progress writing to w in the given mode.
+func NewWithMode(w io.Writer, mode Mode) *Progress {
+	var r renderer
+	switch {
+	case mode == ModeJSON:
+		r = newJSONRenderer(w)
+	case mode == ModeAuto && isTerminal(w):
+		r = newSpinnerRenderer(w)
+	default:
+		r = &plainRenderer{out: w}
+	}
+	return &Progress{renderer: r, ids: map[string]string{}, start: time.Now()}
+}
+
+// Start begins a new stage, completing any stage still in progress.
+func (p *Progress) Start(name string) *Stage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current != nil && !p.current.done {
+		p.endLocked(p.current, nil)
+	}
+
+	s := &Stage{Name: name, Start: time.Now(), Percent: -1, p: p}
+	p.current = s
+	p.stages = append(p.stages, s)
+	p.renderer.stageStart(s)
+	return s
+}
+
+// Done marks the stage as successfully completed.
+func (s *Stage) Done() {
+	s.p.mu.Lock()
+	defer s.p.mu.Unlock()
+	s.p.endLocked(s, nil)
+}
+
+// Fail marks the stage as failed and returns err for convenient chaining:
+//
+//	return stage.Fail(err)
+func (s *Stage) Fail(err error) error {
+	s.p.mu.Lock()
+	defer s.p.mu.Unlock()
+	s.p.endLocked(s, err)
+	return err
+}
+
+// Ended reports whether the stage is done or failed.
+func (s *Stage) Ended() bool {
+	s.p.mu.Lock()
+	defer s.p.mu.Unlock()
+	return s.done
+}
+
+// SetPercent reports how far through the stage is, from 0 to 100.
+func (s *Stage) SetPercent(pct int) {
+	pct = max(0, min(pct, 100))
+
+	s.p.mu.Lock()
+	defer s.p.mu.Unlock()
+	if s.done || s.Percent == pct {
+		return
+	}
+	s.Percent = pct
+	s.p.renderer.percent(s)
+}
+
+// SetBytes reports how many bytes the stage has transferred, so terminals
+// can show the transfer rate. Unlike SetPercent it emits no event of its own;
+// the count is shown on the next redraw and with the next percent event.
+func (s *Stage) SetBytes(n int64) {
+	s.p.mu.Lock()
+	defer s.p.mu.Unlock()
+	if !s.done {
+		s.Bytes = n
+	}
+}
+
+// rate returns the stage's average transfer rate in bytes per second.
+// Callers hold the Progress lock.
+func (s *Stage) rate() float64 {
+	elapsed := s.Duration
+	if !s.done {
+		elapsed = time.Since(s.Start)
+	}
+	if s.Bytes <= 0 || elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / elapsed.Seconds()
+}
+
+// SetID records an identifier produced by the operation (e.g. "build_id"),
+// so machine-readable output can refer to it.
+func (p *Progress) SetID(key, value string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ids[key] == value {
+		return
+	}
+	p.ids[key] = value
+	p.renderer.id(key, value)
+}
+
+// IDs returns a copy of the identifiers recorded with SetID.
+func (p *Progress) IDs() map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return maps.Clone(p.ids)
+}
+
+// Warnf prints a warning and records it, so summaries of the operation can
+// list what went wrong without failing it.
+func (p *Progress) Warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	p.mu.Lock()
+	p.warnings = append(p.warnings, msg)
+	p.mu.Unlock()
+	p.Printf("Warning: %s\n", msg)
+}
+
+// Warnings returns the warnings recorded with Warnf.
+func (p *Progress) Warnings() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.warnings...)
+}
+
+// Elapsed returns the time since the Progress was created.
+func (p *Progress) Elapsed() time.Duration {
+	return time.Since(p.start)
+}
+
+func (p *Progress) endLocked(s *Stage, err error) {
+	if s.done {
+		return
+	}
+	s.done = true
+	s.Err = redact.Error(err)
+	s.Duration = time.Since(s.Start)
+	if p.current == s {
+		p.current = nil
+	}
+	p.renderer.stageEnd(s)
+}
+
+// Write prints output without disturbing the active stage's spinner.
+// Credentials in it, such as a token echoed in build logs, are redacted.
+func (p *Progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.renderer.write([]byte(redact.String(string(b)))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Printf formats and prints a line of output.
+func (p *Progress) Printf(format string, args ...any) {
+	fmt.Fprintf(p, format, args...)
+}
+
+// Println prints a line of output.
+func (p *Progress) Println(args ...any) {
+	fmt.Fprintln(p, args...)
+}
+
+// Stages returns the stages started so far.
+func (p *Progress) Stages() []*Stage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*Stage(nil), p.stages...)
+}
+
+// Close ends any stage in progress and stops the spinner.
+// It is safe to call more than once.
+func (p *Progress) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	if p.current != nil {
+		p.endLocked(p.current, nil)
+	}
+	p.renderer.close()
+}
+
+// Summary returns a one-line timing breakdown, e.g.
+// "Completed in 1m2s (Packaging 1.2s, Building 58s, Deploying 2.8s)".
+func (p *Progress) Summary() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var parts []string
+	for _, s := range p.stages {
+		if s.done {
+			parts = append(parts, fmt.Sprintf("%s %s", s.Name, FormatDuration(s.Duration)))
+		}
+	}
+	total := FormatDuration(time.Since(p.start))
+	if len(parts) == 0 {
+		return "Completed in " + total
+	}
+	return fmt.Sprintf("Completed in %s (%s)", total, strings.Join(parts, ", "))
+}
+
+// FormatDuration rounds a duration for display.
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
+// FormatBytes renders a byte count with a binary unit (e.g. "1.5 MiB").
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// isTerminal reports whether w is an interactive terminal that can be
+// redrawn with escape sequences. A Windows console that can't is treated
+// like a pipe.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return IsTerminal(f) && enableANSI(f)
+}
+
+// IsTerminal reports whether f is an interactive terminal. It uses the file's
+// descriptor (a handle on Windows), so it works for the standard streams on
+// every platform.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// SupportsANSI reports whether f is a terminal that understands escape
+// sequences, enabling them on Windows consoles that need it.
+func SupportsANSI(f *os.File) bool {
+	return isTerminal(f)
+}
+
+// plainRenderer prints stages as sequential log lines, for pipes and CI logs.
+type plainRenderer struct {
+	out io.Writer
+}
+
+func (r *plainRenderer) stageStart(s *Stage) {
+	fmt.Fprintf(r.out, "==> %s\n", s.Name)
+}
+
+func (r *plainRenderer) stageEnd(s *Stage) {
+	if s.Err != nil {
+		fmt.Fprintf(r.out, "==> %s failed after %s\n", s.Name, FormatDuration(s.Duration))
+		return
+	}
+	fmt.Fprintf(r.out, "==> %s done (%s)\n", s.Name, FormatDuration(s.Duration))
+}
+
+func (r *plainRenderer) percent(s *Stage) {}
+
+func (r *plainRenderer) id(key, value string) {}
+
+func (r *plainRenderer) write(p []byte) (int, error) {
+	return r.out.Write(p)
+}
+
+func (r *plainRenderer) close() {}
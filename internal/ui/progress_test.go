@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressPlainOutput(t *testing.T) {
+	var buf bytes.Buffer
+	p := New(&buf)
+
+	stage := p.Start("Packaging")
+	p.Printf("wrote %d files\n", 3)
+	stage.Done()
+
+	err := p.Start("Building").Fail(errors.New("boom"))
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("Fail returned %v, want boom", err)
+	}
+	p.Close()
+
+	out := buf.String()
+	for _, want := range []string{
+		"==> Packaging\n",
+		"wrote 3 files\n",
+		"==> Packaging done (",
+		"==> Building\n",
+		"==> Building failed after ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestProgressStartEndsPreviousStage(t *testing.T) {
+	p := New(&bytes.Buffer{})
+	p.Start("One")
+	p.Start("Two")
+	p.Close()
+
+	stages := p.Stages()
+	if len(stages) != 2 {
+		t.Fatalf("got %d stages, want 2", len(stages))
+	}
+	for _, s := range stages {
+		if !s.done || s.Err != nil {
+			t.Errorf("stage %s: done=%v err=%v", s.Name, s.done, s.Err)
+		}
+	}
+
+	summary := p.Summary()
+	if !strings.HasPrefix(summary, "Completed in ") || !strings.Contains(summary, "One ") || !strings.Contains(summary, "Two ") {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		1234 * time.Microsecond:               "1ms",
+		1234 * time.Millisecond:               "1.2s",
+		90*time.Second + 400*time.Millisecond: "1m30s",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestProgressJSONEvents(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWithMode(&buf, ModeJSON)
+
+	stage := p.Start("Uploading")
+	stage.SetPercent(40)
+	stage.SetPercent(40) // unchanged, not re-emitted
+	p.Printf("partial ")
+	p.Printf("line\n\n")
+	p.SetID("build_id", "b-1")
+	stage.Done()
+	p.Start("Building").Fail(errors.New("boom"))
+	p.Close()
+
+	var events []Event
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e Event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("invalid event: %v", err)
+		}
+		events = append(events, e)
+	}
+
+	want := []string{EventStageStart, EventPercent, EventMessage, EventID, EventStageEnd, EventStageStart, EventStageEnd}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, typ := range want {
+		if events[i].Type != typ {
+			t.Errorf("event %d: type %q, want %q", i, events[i].Type, typ)
+		}
+	}
+
+	if events[1].Percent == nil || *events[1].Percent != 40 {
+		t.Errorf("percent event: %+v", events[1])
+	}
+	if events[2].Message != "partial line" || events[2].Stage != "Uploading" {
+		t.Errorf("message event: %+v", events[2])
+	}
+	if events[4].Status != "done" || events[4].IDs["build_id"] != "b-1" {
+		t.Errorf("stage_end event: %+v", events[4])
+	}
+	if events[6].Status != "failed" || events[6].Error != "boom" {
+		t.Errorf("failed stage_end event: %+v", events[6])
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"": ModeAuto, "auto": ModeAuto, "plain": ModePlain, "json": ModeJSON} {
+		got, err := ParseMode(in)
+		if err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseMode("fancy"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestWriteStructuredYAML(t *testing.T) {
+	var out bytes.Buffer
+	v := struct {
+		Name  string   `json:"name"`
+		Flag  string   `json:"flag"`
+		Items []string `json:"items"`
+	}{"demo", "true", []string{"a"}}
+	if err := WriteStructured(&out, OutputYAML, v); err != nil {
+		t.Fatal(err)
+	}
+	want := "name: demo\nflag: \"true\"\nitems:\n  - a\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 20: "5.0 MiB"} {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
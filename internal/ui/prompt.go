@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Confirm asks a yes/no question on out and reads the answer from in.
+// Anything but "y" or "yes" (including end of input) is a no.
+func Confirm(in io.Reader, out io.Writer, prompt string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", prompt)
+	response, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes", nil
+}
+
+// Prompt asks for a line of input on out and reads it from in.
+func Prompt(in *bufio.Reader, out io.Writer, prompt string) (string, error) {
+	fmt.Fprint(out, prompt)
+	line, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// PromptSecret asks for a secret without echo when stdin is a terminal, or
+// reads a line from in otherwise (e.g. piped input in scripts). Pass the same
+// reader to every prompt of a command so piped lines are not lost to buffering.
+func PromptSecret(in *bufio.Reader, out io.Writer, prompt string) (string, error) {
+	if !IsTerminal(os.Stdin) {
+		fmt.Fprint(out, prompt)
+		line, err := in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(out, prompt)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(out) // newline after hidden input
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const (
+	spinnerInterval = 100 * time.Millisecond
+	clearLine       = "\r\033[K"
+)
+
+// spinnerRenderer animates the active stage on a single terminal line.
+// Callers hold the Progress lock for every method except the animation loop,
+// which takes it itself.
+type spinnerRenderer struct {
+	out    io.Writer
+	active *Stage
+	frame  int
+	// atLineStart tracks whether output left the cursor at the start of a line,
+	// so the spinner is never drawn over partial output.
+	atLineStart bool
+	stop        chan struct{}
+}
+
+func newSpinnerRenderer(w io.Writer) *spinnerRenderer {
+	return &spinnerRenderer{out: w, atLineStart: true}
+}
+
+func (r *spinnerRenderer) stageStart(s *Stage) {
+	r.active = s
+	r.frame = 0
+	r.draw()
+	if r.stop == nil {
+		r.stop = make(chan struct{})
+		go r.animate(s.p, r.stop)
+	}
+}
+
+func (r *spinnerRenderer) stageEnd(s *Stage) {
+	r.erase()
+	if !r.atLineStart {
+		fmt.Fprintln(r.out)
+	}
+	mark := "✓"
+	if s.Err != nil {
+		mark = "✗"
+	}
+	fmt.Fprintf(r.out, "%s %s (%s)\n", mark, s.Name, FormatDuration(s.Duration))
+	r.atLineStart = true
+	if r.active == s {
+		r.active = nil
+	}
+}
+
+func (r *spinnerRenderer) percent(s *Stage) {
+	r.draw()
+}
+
+func (r *spinnerRenderer) id(key, value string) {}
+
+func (r *spinnerRenderer) write(p []byte) (int, error) {
+	r.erase()
+	n, err := r.out.Write(p)
+	if len(p) > 0 {
+		r.atLineStart = p[len(p)-1] == '\n'
+	}
+	r.draw()
+	return n, err
+}
+
+func (r *spinnerRenderer) close() {
+	r.erase()
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
+
+// draw renders the spinner line for the active stage.
+func (r *spinnerRenderer) draw() {
+	if r.active == nil || !r.atLineStart {
+		return
+	}
+	elapsed := FormatDuration(time.Since(r.active.Start).Truncate(time.Second))
+	pct := ""
+	if r.active.Percent >= 0 {
+		pct = fmt.Sprintf(" %s %d%%", progressBar(r.active.Percent), r.active.Percent)
+	}
+	if r.active.Bytes > 0 {
+		pct += fmt.Sprintf(" %s, %s/s", FormatBytes(r.active.Bytes), FormatBytes(int64(r.active.rate())))
+	}
+	fmt.Fprintf(r.out, "%s%s %s%s (%s)", clearLine, spinnerFrames[r.frame%len(spinnerFrames)], r.active.Name, pct, elapsed)
+}
+
+// progressBar draws a percentage as a fixed-width bar, e.g. "[=====>    ]".
+func progressBar(pct int) string {
+	const width = 20
+	filled := pct * width / 100
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+	return "[" + bar + "]"
+}
+
+// erase clears the spinner line so regular output can be printed.
+func (r *spinnerRenderer) erase() {
+	if r.active != nil && r.atLineStart {
+		fmt.Fprint(r.out, clearLine)
+	}
+}
+
+func (r *spinnerRenderer) animate(p *Progress, stop chan struct{}) {
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			r.frame++
+			r.draw()
+			p.mu.Unlock()
+		}
+	}
+}
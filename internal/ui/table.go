@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Table is a list of rows rendered with aligned columns. Row keys and
+// statuses let Watch track rows across refreshes.
+type Table struct {
+	Columns []string
+	Rows    []Row
+}
+
+// Row is a single line of a Table.
+type Row struct {
+	Key    string // Identifies the row across refreshes (e.g. a deployment ID)
+	Status string // Compared across refreshes to highlight changes
+	Cells  []string
+}
+
+// Write renders the table to w.
+func (t *Table) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.Columns, "\t"))
+	for _, row := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(row.Cells, "\t"))
+	}
+	return tw.Flush()
+}
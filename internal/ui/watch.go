@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ANSI sequences used when watching on a terminal.
+const (
+	clearScreen = "\033[H\033[2J"
+	highlight   = "\033[1;33m"
+	reset       = "\033[0m"
+)
+
+// Watch polls fetch every interval and re-renders the table until ctx is
+// cancelled, in the spirit of `kubectl get -w`.
+//
+// On a terminal the screen is redrawn on each refresh and rows that are new or
+// whose status changed since the previous refresh are highlighted. Elsewhere
+// (pipes, CI logs) the table is printed once and each later change is printed
+// as a single line, so the output stays an append-only log.
+//
+// An error from the first fetch is returned; later errors are shown and
+// polling continues, so a transient network failure doesn't end the watch.
+func Watch(ctx context.Context, w io.Writer, interval time.Duration, fetch func() (*Table, error)) error {
+	table, err := fetch()
+	if err != nil {
+		return err
+	}
+
+	terminal := isTerminal(w)
+	previous := statuses(table)
+	if terminal {
+		drawWatch(w, interval, table, nil, nil)
+	} else if err := table.Write(w); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		table, err := fetch()
+		if err != nil {
+			if terminal {
+				drawWatch(w, interval, nil, nil, err)
+			} else {
+				fmt.Fprintf(w, "%s  error: %v\n", time.Now().Format("15:04:05"), err)
+			}
+			continue
+		}
+
+		changed := changedRows(previous, table)
+		if terminal {
+			drawWatch(w, interval, table, changed, nil)
+		} else {
+			for _, row := range table.Rows {
+				if was, ok := changed[row.Key]; ok {
+					fmt.Fprintf(w, "%s  %s\n", time.Now().Format("15:04:05"), describeChange(row, was))
+				}
+			}
+		}
+		previous = statuses(table)
+	}
+}
+
+// LiveView redraws a table in place each time the server pushes new data,
+// like nvidia-smi. On a terminal the screen is cleared and the table drawn
+// under a header; elsewhere (pipes, CI logs) each table is printed after a
+// timestamp line, so the output stays an append-only log.
+type LiveView struct {
+	w        io.Writer
+	header   string
+	terminal bool
+}
+
+// NewLiveView creates a LiveView writing to w.
+func NewLiveView(w io.Writer, header string) *LiveView {
+	return &LiveView{w: w, header: header, terminal: isTerminal(w)}
+}
+
+// Draw renders table, replacing the previous one on a terminal.
+func (v *LiveView) Draw(table *Table) {
+	v.DrawWithFooter(table, "")
+}
+
+// DrawWithFooter renders table followed by free-form text, such as a tail of
+// log lines, replacing the previous frame on a terminal.
+func (v *LiveView) DrawWithFooter(table *Table, footer string) {
+	var b strings.Builder
+	if v.terminal {
+		b.WriteString(clearScreen)
+		fmt.Fprintf(&b, "%s    %s\n\n", v.header, time.Now().Format("15:04:05"))
+	} else {
+		fmt.Fprintf(&b, "--- %s\n", time.Now().Format("15:04:05"))
+	}
+	table.Write(&b)
+	if footer != "" {
+		b.WriteString("\n")
+		b.WriteString(footer)
+		if !strings.HasSuffix(footer, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	if !v.terminal {
+		b.WriteString("\n")
+	}
+	io.WriteString(v.w, b.String())
+}
+
+// statuses maps row keys to their status.
+func statuses(t *Table) map[string]string {
+	m := make(map[string]string, len(t.Rows))
+	for _, row := range t.Rows {
+		m[row.Key] = row.Status
+	}
+	return m
+}
+
+// changedRows returns the rows that are new or whose status changed, mapped to
+// their previous status ("" for new rows).
+func changedRows(previous map[string]string, t *Table) map[string]string {
+	changed := map[string]string{}
+	for _, row := range t.Rows {
+		was, ok := previous[row.Key]
+		if !ok || was != row.Status {
+			changed[row.Key] = was
+		}
+	}
+	return changed
+}
+
+func describeChange(row Row, was string) string {
+	line := strings.Join(row.Cells, "  ")
+	if was == "" {
+		return line + "  (new)"
+	}
+	return fmt.Sprintf("%s  (was %s)", line, was)
+}
+
+// drawWatch redraws the whole screen: a header, the table with changed rows
+// highlighted, and the last error if the refresh failed.
+func drawWatch(w io.Writer, interval time.Duration, table *Table, changed map[string]string, fetchErr error) {
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	fmt.Fprintf(&b, "Every %s, press Ctrl+C to stop    %s\n\n", interval, time.Now().Format("15:04:05"))
+
+	if fetchErr != nil {
+		fmt.Fprintf(&b, "error: %v\n", fetchErr)
+		io.WriteString(w, b.String())
+		return
+	}
+
+	// Render without color first so alignment isn't thrown off by escape codes
+	var rendered strings.Builder
+	table.Write(&rendered)
+	lines := strings.Split(strings.TrimSuffix(rendered.String(), "\n"), "\n")
+	for i, line := range lines {
+		if i > 0 {
+			if _, ok := changed[table.Rows[i-1].Key]; ok {
+				line = highlight + line + reset
+			}
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchPrintsChanges(t *testing.T) {
+	snapshots := []*Table{
+		{Columns: []string{"ID", "STATUS"}, Rows: []Row{{Key: "b1", Status: "running", Cells: []string{"b1", "running"}}}},
+		{Columns: []string{"ID", "STATUS"}, Rows: []Row{{Key: "b1", Status: "running", Cells: []string{"b1", "running"}}}},
+		{Columns: []string{"ID", "STATUS"}, Rows: []Row{
+			{Key: "b2", Status: "queued", Cells: []string{"b2", "queued"}},
+			{Key: "b1", Status: "success", Cells: []string{"b1", "success"}},
+		}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	var out bytes.Buffer
+	err := Watch(ctx, &out, time.Millisecond, func() (*Table, error) {
+		if calls == len(snapshots) {
+			cancel()
+			return nil, errors.New("done")
+		}
+		calls++
+		return snapshots[calls-1], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.String()
+	for _, want := range []string{"ID  STATUS\nb1  running\n", "b2  queued  (new)", "b1  success  (was running)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "b1  running") != 1 {
+		t.Errorf("unchanged rows should not be repeated:\n%s", got)
+	}
+}
+
+func TestWatchReturnsFirstError(t *testing.T) {
+	err := Watch(context.Background(), &bytes.Buffer{}, time.Millisecond, func() (*Table, error) {
+		return nil, errors.New("unauthorized")
+	})
+	if err == nil || err.Error() != "unauthorized" {
+		t.Fatalf("expected the first fetch error, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
@@ -11,21 +12,37 @@ import (
 	"github.com/cozy-creator/cozyctl/internal/api"
 	"github.com/cozy-creator/cozyctl/internal/build"
 	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/deploy"
+	"github.com/cozy-creator/cozyctl/internal/history"
+	"github.com/cozy-creator/cozyctl/internal/hooks"
+	"github.com/cozy-creator/cozyctl/internal/interrupt"
+	"github.com/cozy-creator/cozyctl/internal/rollout"
+	"github.com/cozy-creator/cozyctl/internal/ui"
 	"github.com/google/uuid"
 )
 
 // Options contains the options for updating a deployment.
 type Options struct {
-	ProjectPath string
-	DryRun      bool
-	Functions   string
-	MinWorkers  int
-	MaxWorkers  int
-	ImageOnly   bool
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	ProjectPath  string
+	DryRun       bool
+	Functions    string
+	MinWorkers   int
+	MaxWorkers   int
+	ImageOnly    bool
+	SummaryFile  string    // Write a JSON summary of the result here (optional)
+	Output       ui.Output // Print the summary to stdout as JSON or YAML; progress goes to stderr
+	ForceRebuild bool      // Rebuild the image even if the source is unchanged since it was last deployed
+	Progress     ui.Mode
+
+	AutoRollback *rollout.Policy // Watch the rollout and restore the previous image if it fails
+
+	ConfirmFunctionChanges bool // Ask before adding or removing the deployment's functions
 }
 
 // Run executes the update process: rebuild image and update existing deployment.
-func Run(opts Options) error {
+func Run(ctx context.Context, opts Options) (err error) {
 	// Get absolute path
 	absPath, err := filepath.Abs(opts.ProjectPath)
 	if err != nil {
@@ -57,30 +74,26 @@ func Run(opts Options) error {
 		return fmt.Errorf("[tool.cozy] deployment-id is required in pyproject.toml")
 	}
 
-	fmt.Printf("Deployment ID: %s\n", cozyConfig.DeploymentID)
-
 	// Load config for API access
-	defaultCfg, err := config.GetDefaultConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
-	profileCfg, err := config.GetProfileConfig(defaultCfg.CurrentName, defaultCfg.CurrentProfile)
+	profileCfg, err := config.LoadProfileConfig(opts.Profile)
 	if err != nil {
-		return fmt.Errorf("failed to load profile config: %w", err)
+		return err
 	}
 
 	if profileCfg.Config == nil || profileCfg.Config.Token == "" {
 		return fmt.Errorf("not logged in (run 'cozyctl login' first)")
 	}
 
-	orchestratorURL := profileCfg.Config.OrchestratorURL
-	if orchestratorURL == "" {
-		orchestratorURL = config.DefaultConfigData().OrchestratorURL
-	}
+	orchestratorURL := deploy.OrchestratorURL(profileCfg.Config)
 
 	// Create API client
-	client := api.NewClient(orchestratorURL, profileCfg.Config.Token)
+	var client api.OrchestratorAPI = api.NewClient(orchestratorURL, profileCfg.Config.Token, opts.Transport)
+
+	progress := ui.NewWithMode(deploy.ProgressOutput(opts.Output), opts.Progress)
+	defer progress.Close()
+
+	progress.Printf("Deployment ID: %s\n", cozyConfig.DeploymentID)
+	progress.SetID("deployment_id", cozyConfig.DeploymentID)
 
 	// Check if deployment exists
 	existing, err := client.GetDeployment(cozyConfig.DeploymentID)
@@ -91,52 +104,41 @@ func Run(opts Options) error {
 		return fmt.Errorf("deployment '%s' not found (use 'cozyctl deploy' to create)", cozyConfig.DeploymentID)
 	}
 
-	fmt.Printf("Found existing deployment: %s\n", existing.ID)
+	progress.Printf("Found existing deployment: %s\n", existing.ID)
 
 	// Detect or parse functions (priority: flag > pyproject.toml > auto-detect)
 	var functions []build.DetectedFunction
 	if !opts.ImageOnly {
-		if opts.Functions != "" {
-			// 1. From command-line flag
-			functions, err = build.ParseFunctionsFromFlag(opts.Functions)
-			if err != nil {
-				return fmt.Errorf("failed to parse --functions: %w", err)
-			}
-			fmt.Printf("Using functions from flag: %d function(s)\n", len(functions))
-		} else if len(cozyConfig.Functions) > 0 {
-			// 2. From pyproject.toml [tool.cozy.functions]
-			for name, cfg := range cozyConfig.Functions {
-				functions = append(functions, build.DetectedFunction{
-					Name:        name,
-					RequiresGPU: cfg.RequiresGPU,
-				})
-			}
-			fmt.Printf("Using functions from pyproject.toml: %d function(s)\n", len(functions))
-			for _, fn := range functions {
-				gpuStr := "CPU"
-				if fn.RequiresGPU {
-					gpuStr = "GPU"
-				}
-				fmt.Printf("  - %s (%s)\n", fn.Name, gpuStr)
-			}
-		} else {
-			// 3. Auto-detect from Python source
-			functions, err = build.DetectWorkerFunctions(absPath)
-			if err != nil {
-				return fmt.Errorf("failed to detect functions: %w", err)
-			}
-			if len(functions) == 0 {
-				fmt.Println("Warning: No @worker_function() decorated functions detected")
-			} else {
-				fmt.Printf("Auto-detected %d function(s):\n", len(functions))
-				for _, fn := range functions {
-					gpuStr := "CPU"
-					if fn.RequiresGPU {
-						gpuStr = "GPU"
-					}
-					fmt.Printf("  - %s (%s)\n", fn.Name, gpuStr)
-				}
-			}
+		var source string
+		functions, source, err = build.ResolveFunctions(absPath, cozyConfig, opts.Functions)
+		if err != nil {
+			return err
+		}
+		build.PrintResolvedFunctions(progress, functions, source)
+
+		changes := deploy.DiffFunctions(existing.FunctionRequirements, functions)
+		if err := deploy.CheckFunctionChanges(progress, os.Stdin, existing.ID, changes, opts.ConfirmFunctionChanges && !opts.DryRun); err != nil {
+			return err
+		}
+	}
+
+	policies, err := deploy.NewPolicyCheck(ctx, absPath, cozyConfig, nil)
+	if err != nil {
+		return err
+	}
+
+	// Skip the Docker build when the deployment runs an image of this exact
+	// source, so only worker counts and functions change
+	sourceDigest, err := build.SourceDigest(absPath)
+	if err != nil {
+		progress.Warnf("%v; the image will be rebuilt", err)
+	}
+	rebuild := opts.ForceRebuild || !build.SourceUnchanged(absPath, cozyConfig.DeploymentID, existing.ImageURL, sourceDigest)
+	if !rebuild {
+		progress.Printf("Source unchanged since %s was deployed; skipping the Docker build (use --force-rebuild to rebuild anyway)\n", existing.ImageURL)
+		if opts.ImageOnly {
+			progress.Println("Deployment is up to date.")
+			return nil
 		}
 	}
 
@@ -145,73 +147,154 @@ func Run(opts Options) error {
 	if err != nil {
 		return fmt.Errorf("failed to resolve base image: %w", err)
 	}
-	fmt.Printf("Base image: %s\n", baseImage)
+	progress.Printf("Base image: %s\n", baseImage)
 
-	// Generate Dockerfile
-	dockerfile, err := build.GenerateDockerfile(baseImage, cozyConfig)
+	// Generate build ID and Dockerfile
+	buildID := uuid.New().String()
+	dockerfile, err := build.GenerateDockerfile(baseImage, cozyConfig, build.NewImageMetadata(absPath, buildID, functions))
 	if err != nil {
 		return fmt.Errorf("failed to generate Dockerfile: %w", err)
 	}
 
-	// Generate build ID and image tag
-	buildID := uuid.New().String()
+	// Generate image tag; without a rebuild the deployment keeps its image
 	imageTag := build.GenerateImageTag(buildID, cozyConfig.DeploymentID)
-	fmt.Printf("Image tag: %s\n", imageTag)
+	imageURL := ""
+	if rebuild {
+		imageURL = imageTag
+		progress.Printf("Image tag: %s\n", imageTag)
+	}
 
 	if opts.DryRun {
-		fmt.Println("\n--- Dry Run Mode ---")
-		fmt.Println("Would generate Dockerfile:")
-		fmt.Println(dockerfile)
-		fmt.Println("\nWould build image:", imageTag)
-		fmt.Println("Would update deployment:", cozyConfig.DeploymentID)
+		paths, err := build.WriteDryRunArtifacts(absPath, dockerfile, build.DryRunUpdateRequest, updateRequest(opts, imageURL, functions))
+		if err != nil {
+			return err
+		}
+		progress.Println("\n--- Dry Run Mode ---")
+		if rebuild {
+			progress.Println("Would build image:", imageTag)
+		} else {
+			progress.Println("Would keep image:", existing.ImageURL)
+		}
+		progress.Println("Would update deployment:", cozyConfig.DeploymentID)
+		progress.Println("\nWrote artifacts:")
+		for _, path := range paths {
+			progress.Printf("  %s\n", path)
+		}
+
+		if policies != nil {
+			progress.Println()
+			if err := policies.Run(ctx, progress, "update", cozyConfig.DeploymentID, updateRequest(opts, imageURL, functions)); err != nil {
+				return err
+			}
+			progress.Println("Policies: request allowed")
+		}
 		return nil
 	}
 
-	// Write Dockerfile
-	dockerfilePath := filepath.Join(absPath, "Dockerfile")
-	if err := os.WriteFile(dockerfilePath, []byte(dockerfile), 0644); err != nil {
-		return fmt.Errorf("failed to write Dockerfile: %w", err)
-	}
-	fmt.Printf("Generated Dockerfile: %s\n", dockerfilePath)
+	recorder := history.Start(opts.Profile, "update")
+	defer func() {
+		recorder.FinishProgress(progress, err)
+		err = deploy.WriteSummary(opts.SummaryFile, opts.Output, "update", progress, client, orchestratorURL, err)
+	}()
+
+	if rebuild {
+		// Write Dockerfile
+		dockerfilePath := filepath.Join(absPath, "Dockerfile")
+		if err := os.WriteFile(dockerfilePath, []byte(dockerfile), 0644); err != nil {
+			return fmt.Errorf("failed to write Dockerfile: %w", err)
+		}
+		progress.Printf("Generated Dockerfile: %s\n", dockerfilePath)
+		defer build.RemoveIfInterrupted(ctx, dockerfilePath)
 
-	// Build Docker image
-	fmt.Println("\nBuilding Docker image...")
-	builder := build.NewDockerBuilder()
-	ctx := context.Background()
-	buildTimeout := 30 * time.Minute
+		// Build Docker image
+		stage := progress.Start("Building")
+		builder := build.NewDockerBuilder()
+		buildTimeout := 30 * time.Minute
 
-	result := builder.Build(ctx, absPath, imageTag, buildTimeout)
+		result := builder.Build(ctx, absPath, imageTag, buildTimeout)
 
-	if result.Logs != "" {
-		fmt.Println("\n--- Build Logs ---")
-		fmt.Println(result.Logs)
-		fmt.Println("--- End Build Logs ---")
-	}
+		if result.Logs != "" {
+			progress.Println("\n--- Build Logs ---")
+			progress.Println(result.Logs)
+			progress.Println("--- End Build Logs ---")
+		}
 
-	if result.Error != nil {
-		return fmt.Errorf("docker build failed: %w", result.Error)
+		if result.Error != nil {
+			return stage.Fail(interrupt.Check(ctx, fmt.Errorf("docker build failed: %w", result.Error), "the docker build was stopped; the deployment is unchanged"))
+		}
+		progress.Printf("Image: %s\n", result.ImageTag)
+		stage.Done()
+		progress.SetID("image_tag", result.ImageTag)
 	}
 
-	fmt.Printf("\nBuild completed in %v\n", result.Duration)
-	fmt.Printf("Image: %s\n", result.ImageTag)
+	// Policy checks run before the deployment changes
+	hookContext := hooks.Context{
+		ProjectDir:   absPath,
+		DeploymentID: cozyConfig.DeploymentID,
+		Image:        existing.ImageURL,
+		Functions:    deploy.FunctionNames(functions),
+	}
+	if rebuild {
+		hookContext.Image, hookContext.LocalImage = imageTag, imageTag
+	}
+	if err := deploy.RunPreDeployHook(ctx, progress, cozyConfig.Hooks.PreDeploy, hookContext); err != nil {
+		return err
+	}
 
 	// Update deployment
-	fmt.Println("\nUpdating deployment...")
+	stage := progress.Start("Updating deployment")
+
+	req := updateRequest(opts, imageURL, functions)
+	if err := policies.Run(ctx, progress, "update", cozyConfig.DeploymentID, req); err != nil {
+		return stage.Fail(err)
+	}
+	updatedAt := time.Now()
+	deployment, err := client.UpdateDeployment(cozyConfig.DeploymentID, req)
+	if err != nil {
+		return stage.Fail(fmt.Errorf("failed to update deployment: %w", err))
+	}
+	stage.Done()
+	if rebuild && sourceDigest != "" {
+		if err := build.RecordDeployedSource(absPath, deployment.ID, imageURL, sourceDigest); err != nil {
+			progress.Warnf("failed to record the deployed source: %v", err)
+		}
+	}
 
+	progress.Printf("\nDeployment updated successfully!\n")
+	progress.Printf("  ID: %s\n", deployment.ID)
+	progress.Printf("  Tenant: %s\n", deployment.TenantID)
+	progress.Printf("  Image: %s\n", deployment.ImageURL)
+	progress.Printf("  Functions: %d\n", len(deployment.FunctionRequirements))
+
+	if opts.AutoRollback != nil {
+		stage = progress.Start("Watching rollout")
+		report := rollout.Watch(ctx, progress, client, deployment.ID, updatedAt, *opts.AutoRollback)
+		if ctx.Err() != nil {
+			return stage.Fail(deploy.Unverified(deployment.ID, ctx.Err()))
+		}
+		report.Write(progress)
+		if !report.Healthy {
+			err := fmt.Errorf("rollout failed: %s", report.Reason)
+			stage.Fail(err)
+			return deploy.RestoreImage(progress, client, existing, err)
+		}
+		stage.Done()
+	}
+
+	progress.Println("\nUpdate completed successfully!")
+	progress.Println(progress.Summary())
+	return nil
+}
+
+// updateRequest builds the UpdateDeployment payload for the new image.
+func updateRequest(opts Options, imageTag string, functions []build.DetectedFunction) *api.UpdateDeploymentRequest {
 	req := &api.UpdateDeploymentRequest{
 		ImageURL: imageTag,
 	}
 
 	// Update functions if not image-only
 	if !opts.ImageOnly && len(functions) > 0 {
-		funcReqs := make([]api.FunctionRequirement, len(functions))
-		for i, fn := range functions {
-			funcReqs[i] = api.FunctionRequirement{
-				Name:        fn.Name,
-				RequiresGPU: fn.RequiresGPU,
-			}
-		}
-		req.FunctionRequirements = funcReqs
+		req.FunctionRequirements = build.FunctionRequirements(functions)
 	}
 
 	// Update worker counts if specified
@@ -222,17 +305,5 @@ func Run(opts Options) error {
 		req.MaxWorkers = &opts.MaxWorkers
 	}
 
-	deployment, err := client.UpdateDeployment(cozyConfig.DeploymentID, req)
-	if err != nil {
-		return fmt.Errorf("failed to update deployment: %w", err)
-	}
-
-	fmt.Printf("\nDeployment updated successfully!\n")
-	fmt.Printf("  ID: %s\n", deployment.ID)
-	fmt.Printf("  Tenant: %s\n", deployment.TenantID)
-	fmt.Printf("  Image: %s\n", deployment.ImageURL)
-	fmt.Printf("  Functions: %d\n", len(deployment.FunctionRequirements))
-
-	fmt.Println("\nUpdate completed successfully!")
-	return nil
+	return req
 }
@@ -0,0 +1,193 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/deploy"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+)
+
+func TestRunSkipsRebuildWhenSourceUnchanged(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	if err := config.SaveProfileConfig("work", "prod", &config.ProfileConfig{
+		Config: &config.ConfigData{OrchestratorURL: ts.URL, TenantID: "t-1", Token: "token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	pyproject := "[project]\nname = \"demo\"\n\n[tool.cozy]\ndeployment-id = \"demo\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := api.NewClient(ts.URL, "token", nil)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "demo", ImageURL: "cozy/demo:old"}); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := build.SourceDigest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := build.RecordDeployedSource(dir, "demo", "cozy/demo:old", digest); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a Docker build, the deployment keeps its image and gets the
+	// new worker counts and functions
+	opts := Options{
+		Profile:     config.ProfileRef{Name: "work", Profile: "prod"},
+		ProjectPath: dir,
+		Functions:   "generate:true",
+		MinWorkers:  2,
+		MaxWorkers:  -1,
+	}
+	if err := Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	deployment, err := client.GetDeployment("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deployment.ImageURL != "cozy/demo:old" || deployment.MinWorkers != 2 || len(deployment.FunctionRequirements) != 1 {
+		t.Errorf("deployment = %+v", deployment)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Dockerfile")); err == nil {
+		t.Error("expected no Dockerfile to be generated")
+	}
+
+	// Replacing the function needs confirmation when asked for, which a
+	// test's empty stdin never gives
+	opts.Functions = "health:false"
+	opts.ConfirmFunctionChanges = true
+	if err := Run(context.Background(), opts); !errors.Is(err, deploy.ErrFunctionChangesNotConfirmed) {
+		t.Fatalf("expected the update to stop for confirmation, got %v", err)
+	}
+	if deployment, _ := client.GetDeployment("demo"); deployment.FunctionRequirements[0].Name != "generate" {
+		t.Errorf("expected the functions unchanged, got %+v", deployment.FunctionRequirements)
+	}
+	opts.ConfirmFunctionChanges = false
+
+	// With --image-only there is nothing to do
+	opts.MinWorkers = 5
+	opts.ImageOnly = true
+	if err := Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if deployment, _ := client.GetDeployment("demo"); deployment.MinWorkers != 2 {
+		t.Errorf("expected the deployment unchanged, got %+v", deployment)
+	}
+}
+
+func TestRunPreDeployHookAborts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts use sh")
+	}
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	if err := config.SaveProfileConfig("work", "prod", &config.ProfileConfig{
+		Config: &config.ConfigData{OrchestratorURL: ts.URL, TenantID: "t-1", Token: "token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	pyproject := "[project]\nname = \"demo\"\n\n[tool.cozy]\ndeployment-id = \"demo\"\n\n[tool.cozy.hooks]\npre-deploy = 'echo \"$COZY_IMAGE rejected\"; exit 1'\n"
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := api.NewClient(ts.URL, "token", nil)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "demo", ImageURL: "cozy/demo:old"}); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := build.SourceDigest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := build.RecordDeployedSource(dir, "demo", "cozy/demo:old", digest); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Run(context.Background(), Options{
+		Profile:     config.ProfileRef{Name: "work", Profile: "prod"},
+		ProjectPath: dir,
+		Functions:   "generate:true",
+		MinWorkers:  3,
+		MaxWorkers:  -1,
+	})
+	if err == nil || !strings.Contains(err.Error(), "pre-deploy hook") {
+		t.Fatalf("Run error = %v", err)
+	}
+	deployment, err := client.GetDeployment("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deployment.MinWorkers == 3 || len(deployment.FunctionRequirements) != 0 {
+		t.Errorf("expected the deployment unchanged, got %+v", deployment)
+	}
+}
+
+func TestRunDeniedByPolicy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(mockserver.New().Handler())
+	defer ts.Close()
+	if err := config.SaveProfileConfig("work", "prod", &config.ProfileConfig{
+		Config: &config.ConfigData{OrchestratorURL: ts.URL, TenantID: "t-1", Token: "token"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	pyproject := "[project]\nname = \"demo\"\n\n[tool.cozy]\ndeployment-id = \"demo\"\n\n[tool.cozy.policy]\nparams = { max_workers = 4 }\n"
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(pyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := api.NewClient(ts.URL, "token", nil)
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{ID: "demo", ImageURL: "cozy/demo:old"}); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := build.SourceDigest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := build.RecordDeployedSource(dir, "demo", "cozy/demo:old", digest); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{
+		Profile:     config.ProfileRef{Name: "work", Profile: "prod"},
+		ProjectPath: dir,
+		Functions:   "generate:true",
+		MinWorkers:  -1,
+		MaxWorkers:  8,
+	}
+	if err := Run(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "max_workers 8 exceeds the limit of 4") {
+		t.Fatalf("Run error = %v", err)
+	}
+	if deployment, _ := client.GetDeployment("demo"); deployment.MaxWorkers == 8 || len(deployment.FunctionRequirements) != 0 {
+		t.Errorf("expected the deployment unchanged, got %+v", deployment)
+	}
+
+	opts.MaxWorkers = 2
+	if err := Run(context.Background(), opts); err != nil {
+		t.Fatalf("Run within the limit: %v", err)
+	}
+	if deployment, _ := client.GetDeployment("demo"); deployment.MaxWorkers != 2 {
+		t.Errorf("expected max workers 2, got %+v", deployment)
+	}
+}
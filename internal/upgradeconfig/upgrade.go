@@ -0,0 +1,305 @@
+// Package upgradeconfig brings an existing project's [tool.cozy] section up
+// to current practice: explicit functions, pinned versions, and worker
+// bounds. It edits pyproject.toml as text, so comments and formatting the
+// project already has are kept.
+package upgradeconfig
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cozy-creator/cozyctl/internal/build"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// Worker bounds written to a project without [tool.cozy.workers]: scale to
+// zero when idle and run at most one worker until the project says otherwise.
+const (
+	DefaultMinWorkers = 0
+	DefaultMaxWorkers = 1
+)
+
+var headerPattern = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*(#.*)?$`)
+
+// Options contains the options for upgrading a project's config.
+type Options struct {
+	Dir    string
+	DryRun bool // Show the changes without writing them
+	Yes    bool // Write without prompting
+}
+
+// Result is an upgraded pyproject.toml.
+type Result struct {
+	Before   string
+	After    string
+	Changes  []string
+	Warnings []string
+}
+
+// Run shows how a project's pyproject.toml would be upgraded and writes the
+// upgrade once confirmed.
+func Run(opts Options) error {
+	return run(os.Stdin, os.Stdout, opts)
+}
+
+func run(in io.Reader, out io.Writer, opts Options) error {
+	path := filepath.Join(opts.Dir, build.PyProjectTomlPath)
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("pyproject.toml not found in %s", opts.Dir)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	detected, err := build.DetectWorkerFunctions(opts.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to detect functions: %w", err)
+	}
+
+	abs, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return err
+	}
+	result, err := Upgrade(string(data), filepath.Base(abs), detected)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range result.Warnings {
+		fmt.Fprintf(out, "Warning: %s\n", w)
+	}
+	if len(result.Changes) == 0 {
+		fmt.Fprintf(out, "%s is up to date.\n", path)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Proposed changes to %s:\n", path)
+	for _, c := range result.Changes {
+		fmt.Fprintf(out, "  - %s\n", c)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprint(out, unifiedDiff(path, result.Before, result.After))
+
+	if opts.DryRun {
+		return nil
+	}
+	if !opts.Yes {
+		ok, err := ui.Confirm(in, out, fmt.Sprintf("Write these changes to %s?", path))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Aborted.")
+			return nil
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(result.After), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(out, "Updated %s\n", path)
+	return nil
+}
+
+// Upgrade rewrites the [tool.cozy] section of a pyproject.toml: it adds a
+// deployment-id if there is none (the project's name, or dirName), pins
+// the Python version, and for GPU functions the CUDA and PyTorch versions,
+// lists the functions detected in the source that aren't configured yet,
+// and adds worker bounds. Keys and tables the project already has are left
+// as they are.
+func Upgrade(text, dirName string, detected []build.DetectedFunction) (*Result, error) {
+	var pyproject struct {
+		Project struct {
+			Name string `toml:"name"`
+		} `toml:"project"`
+		Tool struct {
+			Cozy build.ToolsCozyConfig `toml:"cozy"`
+		} `toml:"tool"`
+	}
+	if _, err := toml.Decode(text, &pyproject); err != nil {
+		return nil, fmt.Errorf("failed to parse pyproject.toml: %w", err)
+	}
+	cfg := pyproject.Tool.Cozy
+
+	result := &Result{Before: text}
+	doc := &document{lines: strings.Split(text, "\n")}
+
+	// Keys of [tool.cozy] itself
+	var keys []string
+	if cfg.DeploymentID == "" {
+		id := pyproject.Project.Name
+		if id == "" {
+			id = dirName
+		}
+		keys = append(keys, fmt.Sprintf("deployment-id = %q", id))
+		result.Changes = append(result.Changes, fmt.Sprintf("set deployment-id to %q", id))
+	}
+	if cfg.Python == "" {
+		keys = append(keys, fmt.Sprintf("python = %q", build.DefaultPython))
+		result.Changes = append(result.Changes, fmt.Sprintf("pin Python %s (the default base image's version)", build.DefaultPython))
+	}
+
+	needsGPU := slices.ContainsFunc(detected, func(f build.DetectedFunction) bool { return f.RequiresGPU })
+	for _, f := range cfg.Functions {
+		needsGPU = needsGPU || f.RequiresGPU
+	}
+	cuda := cfg.Cuda
+	if cuda == "" && needsGPU {
+		cuda = build.DefaultCuda
+		keys = append(keys, fmt.Sprintf("cuda = %q", cuda))
+		result.Changes = append(result.Changes, fmt.Sprintf("pin CUDA %s, since functions require a GPU", cuda))
+	}
+	if cuda != "" && cfg.Pytorch == "" {
+		pytorch := strings.TrimPrefix(build.DefaultTorchTag, "torch")
+		keys = append(keys, fmt.Sprintf("pytorch = %q", pytorch))
+		result.Changes = append(result.Changes, fmt.Sprintf("pin PyTorch %s (what CUDA base images ship)", pytorch))
+	}
+	if len(keys) > 0 {
+		if i := doc.find("tool.cozy"); i >= 0 {
+			doc.insert(doc.lastContent(i)+1, keys...)
+		} else {
+			doc.addTable("tool.cozy", keys)
+		}
+	}
+
+	// Functions detected in the source but not configured
+	var missing []build.DetectedFunction
+	for _, f := range detected {
+		if _, ok := cfg.Functions[f.Name]; !ok && !slices.ContainsFunc(missing, func(m build.DetectedFunction) bool { return m.Name == f.Name }) {
+			missing = append(missing, f)
+		}
+	}
+	slices.SortFunc(missing, func(a, b build.DetectedFunction) int { return strings.Compare(a.Name, b.Name) })
+	if len(missing) > 0 {
+		var lines []string
+		for _, f := range missing {
+			lines = append(lines, fmt.Sprintf("%s = { requires_gpu = %t }", f.Name, f.RequiresGPU))
+		}
+		switch i := doc.find("tool.cozy.functions"); {
+		case i >= 0:
+			doc.insert(doc.lastContent(i)+1, lines...)
+		case len(cfg.Functions) > 0:
+			// Configured inline, as functions = { ... }; don't rewrite it
+			result.Warnings = append(result.Warnings, fmt.Sprintf("functions %s are not in [tool.cozy] functions; add them by hand", functionNames(missing)))
+			lines = nil
+		default:
+			doc.addTable("tool.cozy.functions", lines)
+		}
+		if lines != nil {
+			result.Changes = append(result.Changes, fmt.Sprintf("list the detected %s %s in [tool.cozy.functions]", plural(len(missing), "function", "functions"), functionNames(missing)))
+		}
+	}
+
+	// Functions configured but not found in the source, or whose GPU
+	// requirement differs from what the source suggests
+	if len(detected) > 0 {
+		for _, name := range slices.Sorted(maps.Keys(cfg.Functions)) {
+			i := slices.IndexFunc(detected, func(f build.DetectedFunction) bool { return f.Name == name })
+			switch {
+			case i < 0:
+				result.Warnings = append(result.Warnings, fmt.Sprintf("function %s is configured but no @worker_function() named %s was found", name, name))
+			case detected[i].RequiresGPU && !cfg.Functions[name].RequiresGPU:
+				result.Warnings = append(result.Warnings, fmt.Sprintf("function %s loads models but is configured with requires_gpu = false", name))
+			}
+		}
+	}
+
+	if cfg.Workers == nil {
+		doc.addTable("tool.cozy.workers", []string{
+			fmt.Sprintf("min = %d # Scale to zero when idle", DefaultMinWorkers),
+			fmt.Sprintf("max = %d", DefaultMaxWorkers),
+		})
+		result.Changes = append(result.Changes, fmt.Sprintf("add [tool.cozy.workers] with min %d and max %d, used by deploys without --min-workers/--max-workers", DefaultMinWorkers, DefaultMaxWorkers))
+	}
+
+	result.After = strings.Join(doc.lines, "\n")
+	if _, err := toml.Decode(result.After, &pyproject); err != nil {
+		return nil, fmt.Errorf("upgrading pyproject.toml produced invalid TOML: %w", err)
+	}
+	return result, nil
+}
+
+// document is a TOML file as lines, edited in place.
+type document struct {
+	lines []string
+}
+
+// find returns the line of a table's header, or -1.
+func (d *document) find(table string) int {
+	for i, line := range d.lines {
+		if m := headerPattern.FindStringSubmatch(line); m != nil && m[1] == table {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastContent returns the last non-blank line of the table whose header is
+// at line header.
+func (d *document) lastContent(header int) int {
+	last := header
+	for i := header + 1; i < len(d.lines); i++ {
+		if headerPattern.MatchString(d.lines[i]) {
+			break
+		}
+		if strings.TrimSpace(d.lines[i]) != "" {
+			last = i
+		}
+	}
+	return last
+}
+
+func (d *document) insert(at int, lines ...string) {
+	d.lines = slices.Insert(d.lines, at, lines...)
+}
+
+// addTable adds a table after the last [tool.cozy] table, or at the end of
+// the file when there is none.
+func (d *document) addTable(table string, keys []string) {
+	last := -1
+	for i, line := range d.lines {
+		if m := headerPattern.FindStringSubmatch(line); m != nil && (m[1] == "tool.cozy" || strings.HasPrefix(m[1], "tool.cozy.")) {
+			last = i
+		}
+	}
+
+	block := append([]string{"", "[" + table + "]"}, keys...)
+	if last >= 0 {
+		d.insert(d.lastContent(last)+1, block...)
+		return
+	}
+
+	// At the end, before the trailing newline
+	end := len(d.lines)
+	for end > 0 && strings.TrimSpace(d.lines[end-1]) == "" {
+		end--
+	}
+	if end == 0 {
+		block = block[1:]
+	}
+	d.insert(end, block...)
+}
+
+func functionNames(fns []build.DetectedFunction) string {
+	names := make([]string, len(fns))
+	for i, f := range fns {
+		names[i] = f.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,162 @@
+package upgradeconfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+)
+
+const legacyPyproject = `[project]
+name = "my-worker"
+dependencies = ["gen-worker"]
+
+[tool.cozy]
+deployment-id = "my-worker" # Production deployment
+
+[tool.cozy.environment]
+HF_HOME = "/app/.cache"
+
+[build-system]
+requires = ["setuptools>=61.0"]
+`
+
+func TestUpgrade(t *testing.T) {
+	detected := []build.DetectedFunction{{Name: "generate", RequiresGPU: true}, {Name: "health"}}
+	result, err := Upgrade(legacyPyproject, "dir", detected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[project]
+name = "my-worker"
+dependencies = ["gen-worker"]
+
+[tool.cozy]
+deployment-id = "my-worker" # Production deployment
+python = "3.11"
+cuda = "12.6"
+pytorch = "2.9"
+
+[tool.cozy.environment]
+HF_HOME = "/app/.cache"
+
+[tool.cozy.functions]
+generate = { requires_gpu = true }
+health = { requires_gpu = false }
+
+[tool.cozy.workers]
+min = 0 # Scale to zero when idle
+max = 1
+
+[build-system]
+requires = ["setuptools>=61.0"]
+`
+	if result.After != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result.After, want)
+	}
+	if len(result.Changes) != 5 {
+		t.Errorf("got changes %q", result.Changes)
+	}
+
+	// Upgrading again changes nothing
+	again, err := Upgrade(result.After, "dir", detected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Changes) != 0 || again.After != result.After {
+		t.Errorf("second upgrade changed %q:\n%s", again.Changes, again.After)
+	}
+}
+
+func TestUpgradeExtendsFunctions(t *testing.T) {
+	text := `[tool.cozy]
+deployment-id = "x"
+python = "3.12"
+
+[tool.cozy.functions]
+generate = { requires_gpu = false }
+stale = { requires_gpu = false }
+
+[tool.cozy.workers]
+max = 4
+`
+	detected := []build.DetectedFunction{{Name: "generate", RequiresGPU: true}, {Name: "upscale", RequiresGPU: true}}
+	result, err := Upgrade(text, "dir", detected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.After, "stale = { requires_gpu = false }\nupscale = { requires_gpu = true }\n") {
+		t.Errorf("upscale not added to the functions table:\n%s", result.After)
+	}
+	if strings.Count(result.After, "[tool.cozy.workers]") != 1 {
+		t.Errorf("workers table should be left alone:\n%s", result.After)
+	}
+
+	warnings := strings.Join(result.Warnings, "\n")
+	for _, want := range []string{"function stale is configured", "function generate loads models"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("warnings %q missing %q", warnings, want)
+		}
+	}
+}
+
+func TestUpgradeWithoutToolCozy(t *testing.T) {
+	result, err := Upgrade("[project]\nname = \"demo\"\n", "dir", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.After, "[tool.cozy]\ndeployment-id = \"demo\"\npython = \"3.11\"\n") {
+		t.Errorf("got:\n%s", result.After)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	after := "a\nb\nc\nd\nX\ne\nf\ng\nh\ni\nj\n"
+	want := `--- f
++++ f
+@@ -2,6 +2,7 @@
+ b
+ c
+ d
++X
+ e
+ f
+ g
+`
+	if got := unifiedDiff("f", before, after); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRunConfirms(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, build.PyProjectTomlPath)
+	if err := os.WriteFile(path, []byte(legacyPyproject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run(strings.NewReader("n\n"), &out, Options{Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != legacyPyproject {
+		t.Errorf("declined upgrade wrote the file:\n%s", data)
+	}
+	if !strings.Contains(out.String(), "+[tool.cozy.workers]") {
+		t.Errorf("diff not shown:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := run(strings.NewReader("y\n"), &out, Options{Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "[tool.cozy.workers]") {
+		t.Errorf("confirmed upgrade not written:\n%s", data)
+	}
+}
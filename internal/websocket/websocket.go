@@ -0,0 +1,305 @@
+// Package websocket is a minimal RFC 6455 implementation: enough for the CLI
+// to hold an interactive stream open to the orchestrator, and for the mock
+// server to answer it. It supports unfragmented and fragmented data
+// messages, ping/pong, and the close handshake; extensions are not supported.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Message types (frame opcodes).
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// maxMessageSize bounds the size of a received message.
+const maxMessageSize = 16 << 20
+
+// acceptGUID is appended to the client's key to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HandshakeError is returned by Dial when the server answers the upgrade
+// request with an ordinary HTTP response, such as an API error.
+type HandshakeError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("websocket handshake failed (%d): %s", e.StatusCode, strings.TrimSpace(string(e.Body)))
+}
+
+// Conn is an open websocket connection. One goroutine may read while another
+// writes.
+type Conn struct {
+	rwc    io.ReadWriteCloser
+	br     *bufio.Reader
+	client bool // Clients mask the frames they send
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+// Dial opens a websocket connection. url may use the ws, wss, http, or https
+// scheme; header is sent with the upgrade request (e.g. Authorization).
+func Dial(ctx context.Context, url string, header http.Header) (*Conn, error) {
+	switch {
+	case strings.HasPrefix(url, "ws://"):
+		url = "http://" + strings.TrimPrefix(url, "ws://")
+	case strings.HasPrefix(url, "wss://"):
+		url = "https://" + strings.TrimPrefix(url, "wss://")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	key := newKey()
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	// No client timeout: the connection outlives the handshake. HTTP/2 is
+	// disabled because websockets upgrade an HTTP/1.1 connection.
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:        http.ProxyFromEnvironment,
+		TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{},
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, &HandshakeError{StatusCode: resp.StatusCode, Body: body}
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("websocket handshake failed: connection is not writable")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		rwc.Close()
+		return nil, fmt.Errorf("websocket handshake failed: bad Sec-WebSocket-Accept")
+	}
+
+	return &Conn{rwc: rwc, br: bufio.NewReader(rwc), client: true}, nil
+}
+
+// Upgrade answers a websocket upgrade request and takes over the connection.
+// On failure it has already written an error response.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return nil, fmt.Errorf("not a websocket upgrade request")
+	}
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "websocket upgrade unsupported", http.StatusInternalServerError)
+		return nil, err
+	}
+
+	fmt.Fprintf(brw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", acceptKey(key))
+	if err := brw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return &Conn{rwc: netConn, br: brw.Reader}, nil
+}
+
+// ReadMessage returns the next text or binary message. Pings are answered
+// and pongs skipped. When the peer closes the connection it returns io.EOF.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			c.writeFrame(CloseMessage, payload)
+			c.rwc.Close()
+			return 0, nil, io.EOF
+		case TextMessage, BinaryMessage:
+		default:
+			return 0, nil, fmt.Errorf("websocket: unexpected opcode %d", opcode)
+		}
+
+		// Collect continuation frames until the final one
+		messageType, data = opcode, payload
+		for !fin {
+			var more []byte
+			fin, opcode, more, err = c.readFrame()
+			if err != nil {
+				return 0, nil, err
+			}
+			if opcode != continuationFrame {
+				return 0, nil, fmt.Errorf("websocket: expected continuation frame, got opcode %d", opcode)
+			}
+			if len(data)+len(more) > maxMessageSize {
+				return 0, nil, fmt.Errorf("websocket: message exceeds %d bytes", maxMessageSize)
+			}
+			data = append(data, more...)
+		}
+		return messageType, data, nil
+	}
+}
+
+// WriteMessage sends a single-frame message.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	return c.writeFrame(messageType, data)
+}
+
+// Close sends a normal close frame and closes the connection.
+func (c *Conn) Close() error {
+	c.writeFrame(CloseMessage, []byte{0x03, 0xe8}) // 1000: normal closure
+	return c.rwc.Close()
+}
+
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = head[0]&0x80 != 0
+	opcode = int(head[0] & 0x0f)
+	masked := head[1]&0x80 != 0
+
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxMessageSize {
+		return false, 0, nil, fmt.Errorf("websocket: frame exceeds %d bytes", maxMessageSize)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return errors.New("websocket: connection closed")
+	}
+	if opcode == CloseMessage {
+		c.closed = true
+	}
+
+	frame := []byte{0x80 | byte(opcode)}
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	_, err := c.rwc.Write(frame)
+	return err
+}
+
+func newKey() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains reports whether a comma-separated header includes token.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
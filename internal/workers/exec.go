@@ -0,0 +1,131 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/websocket"
+	"golang.org/x/term"
+)
+
+// ExecOptions contains the options for running a command in a worker.
+type ExecOptions struct {
+	Profile   config.ProfileRef
+	Transport http.RoundTripper
+	WorkerID  string
+	Command   []string
+	TTY       bool // Allocate a terminal; ignored unless stdin is one
+}
+
+// ExitError reports a remote command that exited with a non-zero status.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.Code)
+}
+
+// ExitCode is the status cozyctl exits with.
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// Exec runs a command in a worker container, attached to the local stdin,
+// stdout, and stderr, through a websocket proxied by the orchestrator.
+func Exec(ctx context.Context, opts ExecOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+
+	fd := int(os.Stdin.Fd())
+	tty := opts.TTY && term.IsTerminal(fd)
+
+	conn, err := client.ExecWorker(ctx, opts.WorkerID, opts.Command, tty)
+	if err != nil {
+		return fmt.Errorf("failed to exec in worker %s: %w", opts.WorkerID, err)
+	}
+	defer conn.Close()
+
+	var size *api.TerminalSize
+	if tty {
+		if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			size = &api.TerminalSize{Width: width, Height: height}
+		}
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to put the terminal in raw mode: %w", err)
+		}
+		defer term.Restore(fd, state)
+	}
+
+	return stream(conn, os.Stdin, os.Stdout, os.Stderr, size)
+}
+
+// stream pumps stdin to the session and its output to stdout and stderr until
+// the worker reports the command's exit status.
+func stream(conn *websocket.Conn, stdin io.Reader, stdout, stderr io.Writer, size *api.TerminalSize) error {
+	if size != nil {
+		data, _ := json.Marshal(size)
+		if err := conn.WriteMessage(websocket.BinaryMessage, append([]byte{api.ExecResize}, data...)); err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		buf := make([]byte, 32<<10)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				if conn.WriteMessage(websocket.BinaryMessage, append([]byte{api.ExecStdin}, buf[:n]...)) != nil {
+					return
+				}
+			}
+			if err != nil {
+				// An empty stdin message tells the worker stdin is closed
+				conn.WriteMessage(websocket.BinaryMessage, []byte{api.ExecStdin})
+				return
+			}
+		}
+	}()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("connection closed before the command finished")
+		}
+		if err != nil {
+			return fmt.Errorf("exec session failed: %w", err)
+		}
+		if len(msg) == 0 {
+			continue
+		}
+
+		switch msg[0] {
+		case api.ExecStdout:
+			stdout.Write(msg[1:])
+		case api.ExecStderr:
+			stderr.Write(msg[1:])
+		case api.ExecStatus:
+			var result api.ExecResult
+			if err := json.Unmarshal(msg[1:], &result); err != nil {
+				return fmt.Errorf("invalid exit status from worker: %w", err)
+			}
+			if result.Error != "" {
+				return fmt.Errorf("failed to start command: %s", result.Error)
+			}
+			if result.ExitCode != 0 {
+				return &ExitError{Code: result.ExitCode}
+			}
+			return nil
+		}
+	}
+}
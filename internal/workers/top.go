@@ -0,0 +1,78 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// TopOptions contains the options for the live GPU view.
+type TopOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Interval     time.Duration // How often the orchestrator sends metrics
+	Once         bool          // Print the first snapshot and exit
+}
+
+// errStop ends the metrics stream after the first snapshot with Once.
+var errStop = errors.New("stop")
+
+// Top shows per-worker GPU usage from the orchestrator's metrics stream,
+// refreshed in place until ctx is cancelled.
+func Top(ctx context.Context, opts TopOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return top(ctx, os.Stdout, client, opts)
+}
+
+func top(ctx context.Context, w io.Writer, client api.OrchestratorAPI, opts TopOptions) error {
+	view := ui.NewLiveView(w, fmt.Sprintf("Workers of %s, press Ctrl+C to stop", opts.DeploymentID))
+
+	err := client.StreamWorkerMetrics(ctx, opts.DeploymentID, opts.Interval, func(snapshot *api.MetricsSnapshot) error {
+		table := metricsTable(snapshot)
+		if opts.Once {
+			table.Write(w)
+			return errStop
+		}
+		view.Draw(table)
+		return nil
+	})
+	if errors.Is(err, errStop) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stream metrics: %w", err)
+	}
+	return nil
+}
+
+func metricsTable(snapshot *api.MetricsSnapshot) *ui.Table {
+	table := &ui.Table{Columns: []string{"WORKER", "STATUS", "GPU", "UTIL", "MEMORY", "TEMP", "FUNCTION"}}
+	for _, m := range snapshot.Workers {
+		memory := "-"
+		if m.MemoryTotalMB > 0 {
+			memory = fmt.Sprintf("%d / %d MiB (%d%%)", m.MemoryUsedMB, m.MemoryTotalMB, m.MemoryUsedMB*100/m.MemoryTotalMB)
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: m.WorkerID, Status: m.Status, Cells: []string{
+			m.WorkerID,
+			m.Status,
+			orDash(m.GPU),
+			fmt.Sprintf("%.0f%%", m.UtilizationPercent),
+			memory,
+			fmt.Sprintf("%.0fC", m.TemperatureC),
+			orDash(m.CurrentFunction),
+		}})
+	}
+	return table
+}
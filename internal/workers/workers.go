@@ -0,0 +1,80 @@
+// Package workers inspects and debugs the containers running a deployment.
+package workers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/config"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+// ListOptions contains the options for listing workers.
+type ListOptions struct {
+	Profile      config.ProfileRef
+	Transport    http.RoundTripper
+	DeploymentID string
+	Output       ui.Output
+}
+
+// List prints the workers running a deployment.
+func List(opts ListOptions) error {
+	client, err := api.NewProfileClient(opts.Profile, opts.Transport)
+	if err != nil {
+		return err
+	}
+	return list(os.Stdout, client, opts, time.Now())
+}
+
+func list(w io.Writer, client api.OrchestratorAPI, opts ListOptions, now time.Time) error {
+	workers, err := client.ListWorkers(opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to list workers: %w", err)
+	}
+
+	if opts.Output.Structured() {
+		return ui.WriteStructured(w, opts.Output, workers)
+	}
+
+	if len(workers) == 0 {
+		fmt.Fprintf(w, "No workers running for %s.\n", opts.DeploymentID)
+		return nil
+	}
+
+	table := &ui.Table{Columns: []string{"ID", "STATUS", "GPU", "NODE", "AGE"}}
+	for _, wk := range workers {
+		age := "-"
+		if !wk.StartedAt.IsZero() {
+			age = formatAge(now.Sub(wk.StartedAt))
+		}
+		table.Rows = append(table.Rows, ui.Row{Key: wk.ID, Status: wk.Status, Cells: []string{
+			wk.ID, wk.Status, orDash(wk.GPU), orDash(wk.Node), age,
+		}})
+	}
+	return table.Write(w)
+}
+
+// formatAge renders a duration in its largest whole unit (e.g. "3d", "5m").
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
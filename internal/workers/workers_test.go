@@ -0,0 +1,137 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cozy-creator/cozyctl/internal/api"
+	"github.com/cozy-creator/cozyctl/internal/mockserver"
+	"github.com/cozy-creator/cozyctl/internal/ui"
+)
+
+func newMockClient(t *testing.T) *api.Client {
+	t.Helper()
+	ts := httptest.NewServer(mockserver.New().Handler())
+	t.Cleanup(ts.Close)
+
+	client := api.NewClient(ts.URL, "token", nil)
+	minWorkers := 2
+	if _, err := client.CreateDeployment(&api.CreateDeploymentRequest{
+		ID: "my-model", ImageURL: "registry.example/my-model:1", MinWorkers: &minWorkers,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// run execs command in the first worker with the given stdin.
+func run(t *testing.T, client *api.Client, command []string, stdin string) (stdout, stderr string, err error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := client.ExecWorker(ctx, "my-model-worker-0", command, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var out, errOut bytes.Buffer
+	err = stream(conn, strings.NewReader(stdin), &out, &errOut, nil)
+	return out.String(), errOut.String(), err
+}
+
+func TestList(t *testing.T) {
+	client := newMockClient(t)
+
+	var out bytes.Buffer
+	if err := list(&out, client, ListOptions{DeploymentID: "my-model", Output: ui.OutputDefault}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"my-model-worker-0", "my-model-worker-1", "ready"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if err := list(&out, client, ListOptions{DeploymentID: "missing"}, time.Now()); err == nil {
+		t.Error("expected listing workers of an unknown deployment to fail")
+	}
+}
+
+func TestExec(t *testing.T) {
+	client := newMockClient(t)
+
+	stdout, _, err := run(t, client, []string{"nvidia-smi"}, "")
+	if err != nil || !strings.Contains(stdout, "CUDA Version") {
+		t.Errorf("nvidia-smi: err = %v, stdout %q", err, stdout)
+	}
+
+	_, stderr, err := run(t, client, []string{"nvcc", "--version"}, "")
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 127 || !strings.Contains(stderr, "command not found") {
+		t.Errorf("missing command: err = %v, stderr %q", err, stderr)
+	}
+
+	stdout, _, err = run(t, client, []string{"bash"}, "hostname\necho ok\nexit\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "my-model-worker-0\n") || !strings.Contains(stdout, "ok\n") {
+		t.Errorf("shell session output:\n%s", stdout)
+	}
+}
+
+func TestExecUnknownWorker(t *testing.T) {
+	client := newMockClient(t)
+	_, err := client.ExecWorker(context.Background(), "my-model-worker-9", []string{"bash"}, false)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("err = %v, want not found", err)
+	}
+}
+
+func TestTopOnce(t *testing.T) {
+	client := newMockClient(t)
+
+	var out bytes.Buffer
+	opts := TopOptions{DeploymentID: "my-model", Interval: 100 * time.Millisecond, Once: true}
+	if err := top(context.Background(), &out, client, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"WORKER", "my-model-worker-0", "my-model-worker-1", "NVIDIA A100 80GB", "MiB", "%"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "---") {
+		t.Errorf("--once should print a single table:\n%s", out.String())
+	}
+}
+
+func TestTopRefreshesUntilCancelled(t *testing.T) {
+	client := newMockClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	if err := top(ctx, &out, client, TopOptions{DeploymentID: "my-model", Interval: 100 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out.String(), "--- "); n < 2 {
+		t.Errorf("got %d refreshes, want at least 2:\n%s", n, out.String())
+	}
+}
+
+func TestTopUnknownDeployment(t *testing.T) {
+	client := newMockClient(t)
+
+	err := top(context.Background(), &bytes.Buffer{}, client, TopOptions{DeploymentID: "missing", Once: true})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("got %v, want not found", err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package workspace loads multi-project workspaces: a root pyproject.toml
+// whose [tool.cozy.workspace] table lists member projects, each deployed as
+// its own deployment after the members it depends on.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cozy-creator/cozyctl/internal/build"
+)
+
+// Project is one member of a workspace.
+type Project struct {
+	Dir          string   // Absolute project directory
+	Name         string   // Directory relative to the workspace root
+	DeploymentID string   // From the project's [tool.cozy] deployment-id
+	DependsOn    []string // Deployment IDs of members that must deploy first
+	PreDeploy    string   // [tool.cozy.hooks] pre-deploy command, if any
+}
+
+// Workspace is a set of projects deployed together.
+type Workspace struct {
+	Root     string
+	Projects []*Project // In the order the members are listed
+}
+
+// Load reads the workspace whose root pyproject.toml is in dir.
+func Load(dir string) (*Workspace, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	pyprojectPath := filepath.Join(root, build.PyProjectTomlPath)
+	if _, err := os.Stat(pyprojectPath); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("pyproject.toml not found in %s", root)
+	}
+	rootConfig, err := build.GetToolsCozyConfig(pyprojectPath)
+	if err != nil {
+		return nil, err
+	}
+	if rootConfig.Workspace == nil || len(rootConfig.Workspace.Members) == 0 {
+		return nil, fmt.Errorf("%s has no [tool.cozy.workspace] members", pyprojectPath)
+	}
+
+	ws := &Workspace{Root: root}
+	byID := map[string]*Project{}
+	for _, pattern := range rootConfig.Workspace.Members {
+		dirs, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace member %q: %w", pattern, err)
+		}
+		if len(dirs) == 0 {
+			return nil, fmt.Errorf("workspace member %q matches no directory", pattern)
+		}
+
+		for _, projectDir := range dirs {
+			if info, err := os.Stat(projectDir); err != nil || !info.IsDir() {
+				continue
+			}
+			project, err := loadProject(root, projectDir)
+			if err != nil {
+				return nil, err
+			}
+			if other, ok := byID[project.DeploymentID]; ok {
+				if other.Dir == project.Dir {
+					continue // Matched by more than one pattern
+				}
+				return nil, fmt.Errorf("workspace members %s and %s both deploy %s", other.Name, project.Name, project.DeploymentID)
+			}
+			byID[project.DeploymentID] = project
+			ws.Projects = append(ws.Projects, project)
+		}
+	}
+
+	for _, project := range ws.Projects {
+		for _, dep := range project.DependsOn {
+			if _, ok := byID[dep]; !ok {
+				return nil, fmt.Errorf("%s depends on %q, which is not a deployment in this workspace", project.Name, dep)
+			}
+			if dep == project.DeploymentID {
+				return nil, fmt.Errorf("%s depends on itself", project.Name)
+			}
+		}
+	}
+	return ws, nil
+}
+
+func loadProject(root, dir string) (*Project, error) {
+	name, err := filepath.Rel(root, dir)
+	if err != nil {
+		name = dir
+	}
+	name = filepath.ToSlash(name)
+
+	pyprojectPath := filepath.Join(dir, build.PyProjectTomlPath)
+	if _, err := os.Stat(pyprojectPath); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("workspace member %s has no pyproject.toml", name)
+	}
+	cozyConfig, err := build.GetToolsCozyConfig(pyprojectPath)
+	if err != nil {
+		return nil, err
+	}
+	if cozyConfig.DeploymentID == "" {
+		return nil, fmt.Errorf("workspace member %s: [tool.cozy] deployment-id is required", name)
+	}
+
+	return &Project{
+		Dir:          dir,
+		Name:         name,
+		DeploymentID: cozyConfig.DeploymentID,
+		DependsOn:    cozyConfig.DependsOn,
+		PreDeploy:    cozyConfig.Hooks.PreDeploy,
+	}, nil
+}
+
+// Order returns the projects so that every project comes after the projects
+// it depends on. Otherwise independent projects keep their member order.
+func (w *Workspace) Order() ([]*Project, error) {
+	placed := map[string]bool{}
+	ordered := make([]*Project, 0, len(w.Projects))
+	remaining := slices.Clone(w.Projects)
+
+	for len(remaining) > 0 {
+		i := slices.IndexFunc(remaining, func(p *Project) bool {
+			return !slices.ContainsFunc(p.DependsOn, func(dep string) bool { return !placed[dep] })
+		})
+		if i < 0 {
+			names := make([]string, len(remaining))
+			for j, p := range remaining {
+				names[j] = p.DeploymentID
+			}
+			return nil, fmt.Errorf("depends-on cycle between %s", strings.Join(names, ", "))
+		}
+		placed[remaining[i].DeploymentID] = true
+		ordered = append(ordered, remaining[i])
+		remaining = slices.Delete(remaining, i, i+1)
+	}
+	return ordered, nil
+}
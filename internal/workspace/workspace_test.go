@@ -0,0 +1,139 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProject writes a pyproject.toml with the given [tool.cozy] body.
+func writeProject(t *testing.T, dir, cozy string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[project]\nname = \"" + filepath.Base(dir) + "\"\n\n" + cozy
+	if err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newWorkspace(t *testing.T, members string, projects map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	writeProject(t, root, "[tool.cozy.workspace]\nmembers = "+members+"\n")
+	for dir, cozy := range projects {
+		writeProject(t, filepath.Join(root, dir), cozy)
+	}
+	return root
+}
+
+func deploymentIDs(projects []*Project) string {
+	ids := make([]string, len(projects))
+	for i, p := range projects {
+		ids[i] = p.DeploymentID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestLoadAndOrder(t *testing.T) {
+	root := newWorkspace(t, `["api", "models/*"]`, map[string]string{
+		"api":           "[tool.cozy]\ndeployment-id = \"api\"\ndepends-on = [\"embedder\", \"reranker\"]\n",
+		"models/embed":  "[tool.cozy]\ndeployment-id = \"embedder\"\n",
+		"models/rerank": "[tool.cozy]\ndeployment-id = \"reranker\"\ndepends-on = [\"embedder\"]\n",
+	})
+
+	ws, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := deploymentIDs(ws.Projects); got != "api,embedder,reranker" {
+		t.Errorf("Projects = %s, want member order", got)
+	}
+	if ws.Projects[1].Name != "models/embed" {
+		t.Errorf("Name = %q, want models/embed", ws.Projects[1].Name)
+	}
+
+	order, err := ws.Order()
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	if got := deploymentIDs(order); got != "embedder,reranker,api" {
+		t.Errorf("Order = %s, want embedder,reranker,api", got)
+	}
+}
+
+func TestOrderCycle(t *testing.T) {
+	root := newWorkspace(t, `["a", "b", "c"]`, map[string]string{
+		"a": "[tool.cozy]\ndeployment-id = \"a\"\n",
+		"b": "[tool.cozy]\ndeployment-id = \"b\"\ndepends-on = [\"c\"]\n",
+		"c": "[tool.cozy]\ndeployment-id = \"c\"\ndepends-on = [\"b\"]\n",
+	})
+	ws, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	_, err = ws.Order()
+	if err == nil || !strings.Contains(err.Error(), "cycle between b, c") {
+		t.Fatalf("Order error = %v, want cycle between b, c", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		members  string
+		projects map[string]string
+		want     string
+	}{
+		{
+			name:     "unknown dependency",
+			members:  `["a"]`,
+			projects: map[string]string{"a": "[tool.cozy]\ndeployment-id = \"a\"\ndepends-on = [\"missing\"]\n"},
+			want:     `depends on "missing"`,
+		},
+		{
+			name:     "self dependency",
+			members:  `["a"]`,
+			projects: map[string]string{"a": "[tool.cozy]\ndeployment-id = \"a\"\ndepends-on = [\"a\"]\n"},
+			want:     "depends on itself",
+		},
+		{
+			name:     "missing deployment id",
+			members:  `["a"]`,
+			projects: map[string]string{"a": "[tool.cozy]\n"},
+			want:     "deployment-id is required",
+		},
+		{
+			name:    "duplicate deployment id",
+			members: `["a", "b"]`,
+			projects: map[string]string{
+				"a": "[tool.cozy]\ndeployment-id = \"same\"\n",
+				"b": "[tool.cozy]\ndeployment-id = \"same\"\n",
+			},
+			want: "both deploy same",
+		},
+		{
+			name:     "member matches nothing",
+			members:  `["nope/*"]`,
+			projects: map[string]string{},
+			want:     "matches no directory",
+		},
+		{
+			name:     "no members",
+			members:  `[]`,
+			projects: map[string]string{},
+			want:     "no [tool.cozy.workspace] members",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(newWorkspace(t, tt.members, tt.projects))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
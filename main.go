@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/cozy-creator/cozyctl/cmd"
@@ -8,6 +9,11 @@ import (
 
 func main() {
 	if err := cmd.Execute(); err != nil {
+		// Commands that run something remotely pass on its exit status
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
 		os.Exit(1)
 	}
 }